@@ -0,0 +1,71 @@
+package initialize
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	global "project/pkg/global"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	conditionStateCache *ConditionStateCache
+	conditionStateMu    sync.Mutex
+)
+
+// ConditionStateCache 场景联动设备条件的运行状态（持续时间/N-M 采样/回差）
+// 按 条件id + 设备id 保存，服务重启后可继续累计
+type ConditionStateCache struct {
+	client   *redis.Client
+	expireIn time.Duration
+}
+
+func NewConditionStateCache() *ConditionStateCache {
+	conditionStateMu.Lock()
+	defer conditionStateMu.Unlock()
+	if conditionStateCache == nil {
+		conditionStateCache = &ConditionStateCache{
+			client:   global.REDIS,
+			expireIn: time.Hour * 24 * 7,
+		}
+	}
+	return conditionStateCache
+}
+
+// ConditionState 单个设备条件的运行状态
+type ConditionState struct {
+	Active    bool   `json:"active"`     // 是否处于触发状态（未恢复）
+	Since     int64  `json:"since"`      // 条件本轮连续成立的开始时间(毫秒)，0 表示当前不成立
+	Samples   []bool `json:"samples"`    // 最近 M 次上报的判断结果
+	UpdatedAt int64  `json:"updated_at"` // 最后评估时间(毫秒)
+}
+
+func (*ConditionStateCache) getCacheKey(conditionId, deviceId string) string {
+	return fmt.Sprintf("automate_condition_state_v1_%s_%s", conditionId, deviceId)
+}
+
+// Get 获取条件状态，不存在时返回零值
+func (c *ConditionStateCache) Get(conditionId, deviceId string) (ConditionState, error) {
+	var state ConditionState
+	val, err := c.client.Get(context.Background(), c.getCacheKey(conditionId, deviceId)).Result()
+	if err == redis.Nil {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+	err = json.Unmarshal([]byte(val), &state)
+	return state, err
+}
+
+// Set 保存条件状态
+func (c *ConditionStateCache) Set(conditionId, deviceId string, state ConditionState) error {
+	valBytes, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return c.client.Set(context.Background(), c.getCacheKey(conditionId, deviceId), string(valBytes), c.expireIn).Err()
+}
@@ -0,0 +1,171 @@
+package initialize
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	global "project/pkg/global"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// windowRingCapacity 单个窗口最多保留的采样点数
+const windowRingCapacity = 512
+
+var (
+	conditionWindowCache *ConditionWindowCache
+	conditionWindowMu    sync.Mutex
+)
+
+// WindowSample 窗口内的一个采样点
+type WindowSample struct {
+	Ts    int64   `json:"t"` // 上报时间(毫秒)
+	Value float64 `json:"v"`
+}
+
+// SampleRing 固定容量的采样环形缓冲区，写满后覆盖最旧的采样点
+type SampleRing struct {
+	buf   []WindowSample
+	start int
+	size  int
+}
+
+func NewSampleRing(capacity int) *SampleRing {
+	return &SampleRing{buf: make([]WindowSample, capacity)}
+}
+
+// Push 追加采样点
+func (r *SampleRing) Push(s WindowSample) {
+	if len(r.buf) == 0 {
+		return
+	}
+	idx := (r.start + r.size) % len(r.buf)
+	r.buf[idx] = s
+	if r.size < len(r.buf) {
+		r.size++
+	} else {
+		r.start = (r.start + 1) % len(r.buf)
+	}
+}
+
+// TrimBefore 丢弃时间早于 ts 的采样点
+func (r *SampleRing) TrimBefore(ts int64) {
+	for r.size > 0 && r.buf[r.start].Ts < ts {
+		r.start = (r.start + 1) % len(r.buf)
+		r.size--
+	}
+}
+
+// Samples 按时间顺序返回采样点
+func (r *SampleRing) Samples() []WindowSample {
+	out := make([]WindowSample, 0, r.size)
+	for i := 0; i < r.size; i++ {
+		out = append(out, r.buf[(r.start+i)%len(r.buf)])
+	}
+	return out
+}
+
+// windowSweepInterval 清理空闲窗口的最小间隔
+const windowSweepInterval = time.Minute
+
+// windowEntry 一个条件、设备的采样窗口；读写 Redis 时只锁定本窗口
+type windowEntry struct {
+	mu       sync.Mutex
+	ring     *SampleRing
+	loaded   bool         // 是否已从 Redis 恢复
+	window   atomic.Int64 // 窗口长度(毫秒)
+	lastUsed atomic.Int64 // 最近访问时间(毫秒)
+}
+
+// ConditionWindowCache 场景联动窗口统计条件的采样缓存
+// 采样保存在进程内环形缓冲区，同时写入 Redis，服务重启后从 Redis 恢复
+type ConditionWindowCache struct {
+	client    *redis.Client
+	mu        sync.Mutex // 只保护 rings 与 lastSweep
+	rings     map[string]*windowEntry
+	lastSweep time.Time
+}
+
+func NewConditionWindowCache() *ConditionWindowCache {
+	conditionWindowMu.Lock()
+	defer conditionWindowMu.Unlock()
+	if conditionWindowCache == nil {
+		conditionWindowCache = &ConditionWindowCache{
+			client: global.REDIS,
+			rings:  make(map[string]*windowEntry),
+		}
+	}
+	return conditionWindowCache
+}
+
+func (*ConditionWindowCache) getCacheKey(conditionId, deviceId string) string {
+	return fmt.Sprintf("automate_condition_window_v1_%s_%s", conditionId, deviceId)
+}
+
+// entry 取得窗口，顺带清理空闲的窗口
+func (c *ConditionWindowCache) entry(key string, window time.Duration, now time.Time) *windowEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if now.Sub(c.lastSweep) >= windowSweepInterval {
+		c.evictIdle(now)
+		c.lastSweep = now
+	}
+	e, ok := c.rings[key]
+	if !ok {
+		e = &windowEntry{ring: NewSampleRing(windowRingCapacity)}
+		c.rings[key] = e
+	}
+	e.window.Store(window.Milliseconds())
+	e.lastUsed.Store(now.UnixMilli())
+	return e
+}
+
+// evictIdle 释放空闲时间超过窗口长度的窗口（窗口内已没有采样点，Redis 中的副本随过期时间删除），调用方持有 c.mu
+func (c *ConditionWindowCache) evictIdle(now time.Time) {
+	nowMs := now.UnixMilli()
+	for key, e := range c.rings {
+		if nowMs-e.lastUsed.Load() > e.window.Load() {
+			delete(c.rings, key)
+		}
+	}
+}
+
+// Push 写入一个采样点（sample 为 nil 时只读取），裁剪窗口外的采样点后返回窗口内全部采样
+func (c *ConditionWindowCache) Push(conditionId, deviceId string, window time.Duration, sample *WindowSample, now time.Time) ([]WindowSample, error) {
+	key := c.getCacheKey(conditionId, deviceId)
+	e := c.entry(key, window, now)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !e.loaded {
+		val, err := c.client.Get(context.Background(), key).Result()
+		if err != nil && err != redis.Nil {
+			return nil, err
+		}
+		if err == nil {
+			var saved []WindowSample
+			if err := json.Unmarshal([]byte(val), &saved); err == nil {
+				for _, s := range saved {
+					e.ring.Push(s)
+				}
+			}
+		}
+		e.loaded = true
+	}
+
+	e.ring.TrimBefore(now.Add(-window).UnixMilli())
+	if sample == nil {
+		return e.ring.Samples(), nil
+	}
+	e.ring.Push(*sample)
+	samples := e.ring.Samples()
+
+	valBytes, err := json.Marshal(samples)
+	if err != nil {
+		return samples, err
+	}
+	return samples, c.client.Set(context.Background(), key, string(valBytes), window+time.Hour).Err()
+}
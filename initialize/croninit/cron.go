@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"project/internal/service"
+	"project/pkg/common"
 
 	"github.com/robfig/cron"
 	"github.com/sirupsen/logrus"
@@ -12,6 +13,22 @@ import (
 
 var c = cron.New()
 
+// cronLockTTL 每分钟任务的锁有效期，短于执行间隔，实例异常退出时不影响下一轮
+const cronLockTTL = 50 * time.Second
+
+// exclusive 多实例部署时同一轮只有抢到锁的实例执行任务
+func exclusive(name string, fn func()) func() {
+	return func() {
+		key := "irrigation-iot-platform:cron:" + name
+		if !common.AcquireLock(key, cronLockTTL) {
+			logrus.Debug("【定时任务】未获取到锁，跳过：", name)
+			return
+		}
+		defer common.ReleaseLock(key)
+		fn()
+	}
+}
+
 // 定义任务初始化
 func CronInit() {
 	// 初始化设备统计定时任务
@@ -29,6 +46,67 @@ func CronInit() {
 		service.GroupApp.PeriodicTaskExecute()
 	})
 
+	// 每分钟评估设备分组/模板聚合条件
+	// 条件组状态的读取、写回与执行动作不是原子操作，多实例同时评估会重复执行动作
+	c.AddFunc("0 * * * * *", exclusive("automateAggregate", func() {
+		logrus.Debug("【定时任务】场景联动聚合条件评估开始：")
+		service.GroupApp.AutomateAggregate.EvaluateByCron()
+	}))
+
+	// 每分钟检查超过升级时限仍未确认的告警
+	c.AddFunc("30 * * * * *", func() {
+		logrus.Debug("【定时任务】告警升级检查开始：")
+		service.GroupApp.Alarm.EscalateByCron()
+	})
+
+	// 每分钟将超过有效期的审批申请置为过期
+	c.AddFunc("40 * * * * *", func() {
+		logrus.Debug("【定时任务】审批申请过期检查开始：")
+		service.GroupApp.Approval.ExpireByCron()
+	})
+
+	// 每10分钟将中断的租户导出导入任务置为失败
+	c.AddFunc("0 */10 * * * *", func() {
+		logrus.Debug("【定时任务】租户迁移任务超时检查开始：")
+		service.GroupApp.TenantData.FailStaleJobsByCron()
+	})
+
+	// 每天凌晨4点30分清除保留期已过的注销租户数据
+	c.AddFunc("0 30 4 * * *", func() {
+		logrus.Debug("【定时任务】注销租户数据清除开始：")
+		service.GroupApp.TenantOffboarding.PurgeByCron()
+	})
+
+	// 每分钟发送超出通知风暴限制的通知摘要
+	c.AddFunc("45 * * * * *", func() {
+		logrus.Debug("【定时任务】通知摘要发送开始：")
+		service.GroupApp.NotificationServicesConfig.FlushNotificationDigestsByCron()
+	})
+
+	// 每10秒投递到期的Webhook（失败按指数退避重试）
+	c.AddFunc("*/10 * * * * *", func() {
+		logrus.Debug("【定时任务】Webhook投递开始：")
+		service.GroupApp.WebhookSubscription.ProcessWebhookDeliveriesByCron()
+	})
+
+	// 每分钟将Open API密钥调用计量写入数据库
+	c.AddFunc("15 * * * * *", func() {
+		logrus.Debug("【定时任务】Open API密钥调用计量入库开始：")
+		service.GroupApp.OpenAPIKey.FlushOpenAPIKeyUsageByCron()
+	})
+
+	// 每天凌晨2点40分清理已结束的Webhook投递记录
+	c.AddFunc("0 40 2 * * *", func() {
+		logrus.Debug("【定时任务】Webhook投递记录清理开始：")
+		service.GroupApp.WebhookSubscription.CleanWebhookDeliveriesByCron()
+	})
+
+	// 每天凌晨2点50分清理已撤销或过期的登录会话
+	c.AddFunc("0 50 2 * * *", func() {
+		logrus.Debug("【定时任务】登录会话清理开始：")
+		service.GroupApp.UserSession.CleanSessionsByCron()
+	})
+
 	// 每天凌晨2点执行数据清理
 	c.AddFunc("0 2 * * *", func() {
 		logrus.Debug("【定时任务】系统数据清理任务开始：")
@@ -40,6 +118,25 @@ func CronInit() {
 		logrus.Debug("【定时任务】每天凌晨1点执行脚本任务开始：")
 		service.GroupApp.RunScript()
 	})
+
+	// 每15分钟增量切分电池充放电会话
+	c.AddFunc("0 */15 * * * *", func() {
+		logrus.Debug("【定时任务】电池充放电会话检测任务开始：")
+		service.GroupApp.BatteryCycle.DetectByCron()
+	})
+
+	// 每天凌晨3点计算电池健康快照（SOH/循环/寿命预测）
+	c.AddFunc("0 0 3 * * *", func() {
+		logrus.Debug("【定时任务】电池健康分析任务开始：")
+		service.GroupApp.BatteryHealth.RefreshDailyByCron()
+	})
+
+	// 每天凌晨3点30分按维护计划生成维护工单（在健康分析之后）
+	c.AddFunc("0 30 3 * * *", func() {
+		logrus.Debug("【定时任务】电池维护工单生成任务开始：")
+		service.GroupApp.BatteryMaintenancePlan.GenerateOrdersByCron()
+	})
+
 	// 每天凌晨
 	err := c.AddFunc("2 0 * * * *", func() {
 		logrus.Debug("【定时任务】消息推送清理任务开始：", time.Now())
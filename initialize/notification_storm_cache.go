@@ -0,0 +1,101 @@
+package initialize
+
+import (
+	"context"
+	"fmt"
+	global "project/pkg/global"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// notificationDigestMaxItems 摘要中最多保留的通知条目（总数另行计数）
+const notificationDigestMaxItems = 200
+
+const notificationDigestPendingKey = "notification_digest_pending_v1"
+
+var (
+	notificationStormCache *NotificationStormCache
+	notificationStormMu    sync.Mutex
+)
+
+// NotificationStormCache 通知组通知风暴限制：按固定时间窗口计数，超出限制的通知暂存为摘要，由定时任务合并发送
+type NotificationStormCache struct {
+	client *redis.Client
+}
+
+func NewNotificationStormCache() *NotificationStormCache {
+	notificationStormMu.Lock()
+	defer notificationStormMu.Unlock()
+	if notificationStormCache == nil {
+		notificationStormCache = &NotificationStormCache{client: global.REDIS}
+	}
+	return notificationStormCache
+}
+
+func (*NotificationStormCache) getCountKey(groupId string, window time.Duration, now time.Time) string {
+	bucket := now.Unix() / int64(window.Seconds())
+	return fmt.Sprintf("notification_storm_v1_%s_%d", groupId, bucket)
+}
+
+func (*NotificationStormCache) getDigestKey(groupId string) string {
+	return fmt.Sprintf("notification_digest_v1_%s", groupId)
+}
+
+func (*NotificationStormCache) getDigestCountKey(groupId string) string {
+	return fmt.Sprintf("notification_digest_count_v1_%s", groupId)
+}
+
+// Allow 当前时间窗口内的发送计数加一，未超过 limit 时返回 true
+func (c *NotificationStormCache) Allow(groupId string, limit int, window time.Duration, now time.Time) (bool, error) {
+	ctx := context.Background()
+	key := c.getCountKey(groupId, window, now)
+	cnt, err := c.client.Incr(ctx, key).Result()
+	if err != nil {
+		return true, err
+	}
+	if cnt == 1 {
+		c.client.Expire(ctx, key, window*2)
+	}
+	return cnt <= int64(limit), nil
+}
+
+// Defer 暂存一条被限制的通知摘要
+func (c *NotificationStormCache) Defer(groupId, item string) error {
+	ctx := context.Background()
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.RPush(ctx, c.getDigestKey(groupId), item)
+		pipe.LTrim(ctx, c.getDigestKey(groupId), -notificationDigestMaxItems, -1)
+		pipe.Incr(ctx, c.getDigestCountKey(groupId))
+		pipe.SAdd(ctx, notificationDigestPendingKey, groupId)
+		return nil
+	})
+	return err
+}
+
+// Pending 有待发送摘要的通知组
+func (c *NotificationStormCache) Pending() ([]string, error) {
+	return c.client.SMembers(context.Background(), notificationDigestPendingKey).Result()
+}
+
+// Drain 取出并清空通知组的摘要，返回保留的条目与被限制的通知总数
+func (c *NotificationStormCache) Drain(groupId string) ([]string, int64, error) {
+	ctx := context.Background()
+	var (
+		itemsCmd *redis.StringSliceCmd
+		countCmd *redis.StringCmd
+	)
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		itemsCmd = pipe.LRange(ctx, c.getDigestKey(groupId), 0, -1)
+		countCmd = pipe.Get(ctx, c.getDigestCountKey(groupId))
+		pipe.Del(ctx, c.getDigestKey(groupId), c.getDigestCountKey(groupId))
+		pipe.SRem(ctx, notificationDigestPendingKey, groupId)
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return nil, 0, err
+	}
+	total, _ := countCmd.Int64()
+	return itemsCmd.Val(), total, nil
+}
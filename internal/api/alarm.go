@@ -3,6 +3,7 @@ package api
 import (
 	"fmt"
 
+	"project/internal/middleware"
 	"project/internal/model"
 	"project/internal/service"
 	"project/pkg/errcode"
@@ -271,3 +272,93 @@ func (*AlarmApi) DeleteAlarmHistory(c *gin.Context) {
 	}
 	c.Set("data", nil)
 }
+
+// TransitionAlarmHistory 告警处理：确认/指派/开始处理/解决/关闭/重新打开
+// @Router /api/v1/alarm/info/history/{id}/transition [post]
+func (*AlarmApi) TransitionAlarmHistory(c *gin.Context) {
+	var req model.AlarmHistoryTransitionReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	userClaims := c.MustGet("claims").(*utils.UserClaims)
+	err := service.GroupApp.Alarm.TransitionAlarmHistory(c.Request.Context(), c.Param("id"), &req, userClaims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", nil)
+}
+
+// CommentAlarmHistory 添加告警评论
+// @Router /api/v1/alarm/info/history/{id}/comments [post]
+func (*AlarmApi) CommentAlarmHistory(c *gin.Context) {
+	var req model.AlarmHistoryCommentReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	userClaims := c.MustGet("claims").(*utils.UserClaims)
+	err := service.GroupApp.Alarm.CommentAlarmHistory(c.Request.Context(), c.Param("id"), &req, userClaims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", nil)
+}
+
+// HandleAlarmHistoryActivities 告警评论与处理记录
+// @Router /api/v1/alarm/info/history/{id}/activities [get]
+func (*AlarmApi) HandleAlarmHistoryActivities(c *gin.Context) {
+	userClaims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.Alarm.GetAlarmHistoryActivities(c.Request.Context(), c.Param("id"), userClaims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// HandleAlarmSlaReport 告警 SLA 统计（按租户/经销商）
+// @Router /api/v1/alarm/info/sla/report [get]
+func (*AlarmApi) HandleAlarmSlaReport(c *gin.Context) {
+	var req model.AlarmSlaReportReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	userClaims := c.MustGet("claims").(*utils.UserClaims)
+	dealerIDVal, _ := c.Get(middleware.DealerIDContextKey)
+	dealerID, _ := dealerIDVal.(string)
+	data, err := service.GroupApp.Alarm.GetAlarmSlaReport(c.Request.Context(), &req, userClaims, dealerID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// HandleAlarmIncidentListByPage 告警事件列表
+// @Router /api/v1/alarm/info/incidents [get]
+func (*AlarmApi) HandleAlarmIncidentListByPage(c *gin.Context) {
+	var req model.GetAlarmIncidentListByPageReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	userClaims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.Alarm.GetAlarmIncidentListByPage(c.Request.Context(), &req, userClaims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// HandleAlarmIncident 告警事件详情
+// @Router /api/v1/alarm/info/incidents/{id} [get]
+func (*AlarmApi) HandleAlarmIncident(c *gin.Context) {
+	userClaims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.Alarm.GetAlarmIncident(c.Request.Context(), c.Param("id"), userClaims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
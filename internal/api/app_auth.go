@@ -70,7 +70,7 @@ func (*AppAuthApi) PhoneLoginByCode(c *gin.Context) {
 		return
 	}
 	tenantID := middleware.GetTenantIDFromHeader(c)
-	rsp, err := service.GroupApp.AppAuth.PhoneLoginByCode(c.Request.Context(), tenantID, req.PhonePrefix, req.PhoneNumber, req.VerifyCode)
+	rsp, err := service.GroupApp.AppAuth.PhoneLoginByCode(c.Request.Context(), tenantID, req.PhonePrefix, req.PhoneNumber, req.VerifyCode, requestMeta(c))
 	if err != nil {
 		c.Error(err)
 		return
@@ -93,7 +93,7 @@ func (*AppAuthApi) EmailLoginByCode(c *gin.Context) {
 		return
 	}
 	tenantID := middleware.GetTenantIDFromHeader(c)
-	rsp, err := service.GroupApp.AppAuth.EmailLoginByCode(c.Request.Context(), tenantID, req.Email, req.VerifyCode)
+	rsp, err := service.GroupApp.AppAuth.EmailLoginByCode(c.Request.Context(), tenantID, req.Email, req.VerifyCode, requestMeta(c))
 	if err != nil {
 		c.Error(err)
 		return
@@ -116,7 +116,7 @@ func (*AppAuthApi) PhoneRegister(c *gin.Context) {
 		return
 	}
 	tenantID := middleware.GetTenantIDFromHeader(c)
-	rsp, err := service.GroupApp.AppAuth.PhoneRegister(c.Request.Context(), tenantID, req.PhonePrefix, req.PhoneNumber, req.VerifyCode, req.Password)
+	rsp, err := service.GroupApp.AppAuth.PhoneRegister(c.Request.Context(), tenantID, req.PhonePrefix, req.PhoneNumber, req.VerifyCode, req.Password, requestMeta(c))
 	if err != nil {
 		c.Error(err)
 		return
@@ -139,7 +139,7 @@ func (*AppAuthApi) EmailRegister(c *gin.Context) {
 		return
 	}
 	tenantID := middleware.GetTenantIDFromHeader(c)
-	rsp, err := service.GroupApp.AppAuth.EmailRegister(c.Request.Context(), tenantID, req.Email, req.VerifyCode, req.Password)
+	rsp, err := service.GroupApp.AppAuth.EmailRegister(c.Request.Context(), tenantID, req.Email, req.VerifyCode, req.Password, requestMeta(c))
 	if err != nil {
 		c.Error(err)
 		return
@@ -206,7 +206,7 @@ func (*AppAuthApi) WxmpLogin(c *gin.Context) {
 		return
 	}
 	tenantID := middleware.GetTenantIDFromHeader(c)
-	rsp, err := service.GroupApp.AppAuth.WxmpLogin(c.Request.Context(), tenantID, req.Code)
+	rsp, err := service.GroupApp.AppAuth.WxmpLogin(c.Request.Context(), tenantID, req.Code, requestMeta(c))
 	if err != nil {
 		c.Error(err)
 		return
@@ -4,9 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
+	"project/internal/model"
 	"project/internal/service"
 	"project/pkg/errcode"
 	"project/pkg/utils"
@@ -42,6 +44,59 @@ func (*AppBatteryApi) GetBatteryDetail(c *gin.Context) {
 	c.Set("data", data)
 }
 
+// GetBatteryHealth 获取APP端电池健康
+// @Summary 获取电池健康(APP)
+// @Description APP端电池健康页：SOH 趋势、循环次数与寿命预测（要求设备已绑定）
+// @Tags APP-Battery
+// @Produce json
+// @Param device_id path string true "设备ID(UUID)"
+// @Param days query int false "历史天数(默认180)"
+// @Success 200 {object} model.BatteryHealthDetailResp
+// @Router /api/v1/app/battery/health/{device_id} [get]
+func (*AppBatteryApi) GetBatteryHealth(c *gin.Context) {
+	deviceID := c.Param("device_id")
+	userClaims := c.MustGet("claims").(*utils.UserClaims)
+
+	days := 0
+	if s := c.Query("days"); s != "" {
+		if v, err := strconv.Atoi(s); err == nil && v > 0 && v <= 1095 {
+			days = v
+		}
+	}
+
+	data, err := service.GroupApp.AppBattery.GetBatteryHealthForApp(context.Background(), deviceID, days, userClaims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// ListBatteryCycles 获取APP端充放电记录
+// @Summary 获取充放电记录(APP)
+// @Description APP端"最近充电"等记录（默认最近20次充电，要求设备已绑定）
+// @Tags APP-Battery
+// @Produce json
+// @Param device_id path string true "设备ID(UUID)"
+// @Param session_type query string false "会话类型(CHARGE/DISCHARGE/IDLE)，默认CHARGE"
+// @Param limit query int false "条数(默认20)"
+// @Success 200 {array} model.BatteryCycleItemResp
+// @Router /api/v1/app/battery/cycles/{device_id} [get]
+func (*AppBatteryApi) ListBatteryCycles(c *gin.Context) {
+	var req model.AppBatteryCycleReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	userClaims := c.MustGet("claims").(*utils.UserClaims)
+
+	data, err := service.GroupApp.AppBattery.ListBatteryCyclesForApp(context.Background(), c.Param("device_id"), req, userClaims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
 // ServeBatterySocketByWS APP端：MQTT透传(WebSocket桥接)
 // 客户端首次消息需发送 JSON：{"device_id":"...","token":"..."}
 // 随后发送：
@@ -80,7 +135,7 @@ func (*AppBatteryApi) ServeBatterySocketByWS(c *gin.Context) {
 		return
 	}
 
-	claims, err := validateAuth(initMsg)
+	claims, err := validateAuth(initMsg, c.ClientIP())
 	if err != nil {
 		conn.WriteMessage(msgType, []byte(err.Error()))
 		return
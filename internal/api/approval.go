@@ -0,0 +1,153 @@
+package api
+
+import (
+	"project/internal/model"
+	"project/internal/service"
+	"project/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ApprovalApi struct{}
+
+// ListApprovalPolicies 获取审批策略
+// @Summary 获取审批策略
+// @Tags 操作审批
+// @Produce json
+// @Param tenant_id query string false "租户ID（仅SYS_ADMIN可用）"
+// @Success 200 {object} []model.ApprovalPolicy
+// @Router /api/v1/approval/policies [get]
+func (*ApprovalApi) ListApprovalPolicies(c *gin.Context) {
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.Approval.ListPolicies(c.Request.Context(), claims, c.Query("tenant_id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// SaveApprovalPolicy 保存审批策略
+// @Summary 保存审批策略
+// @Tags 操作审批
+// @Accept json
+// @Produce json
+// @Param operation_type path string true "操作类型: BATCH_COMMAND, BATCH_OTA, BATCH_ASSIGN_DEALER, ORG_TRANSFER"
+// @Param tenant_id query string false "租户ID（仅SYS_ADMIN可用）"
+// @Param body body model.ApprovalPolicySaveReq true "审批策略"
+// @Success 200 {object} model.ApprovalPolicy
+// @Router /api/v1/approval/policies/{operation_type} [put]
+func (*ApprovalApi) SaveApprovalPolicy(c *gin.Context) {
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	var req model.ApprovalPolicySaveReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	data, err := service.GroupApp.Approval.SavePolicy(c.Request.Context(), claims, c.Query("tenant_id"), c.Param("operation_type"), &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// HandleApprovalRequestListByPage 分页查询审批申请
+// @Summary 审批申请列表
+// @Tags 操作审批
+// @Produce json
+// @Param data query model.GetApprovalRequestListByPageReq true "筛选条件"
+// @Success 200 {object} []model.ApprovalRequestResp
+// @Router /api/v1/approval/requests [get]
+func (*ApprovalApi) HandleApprovalRequestListByPage(c *gin.Context) {
+	var req model.GetApprovalRequestListByPageReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.Approval.GetApprovalRequestListByPage(c.Request.Context(), &req, claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// HandleApprovalRequest 审批申请详情
+// @Summary 审批申请详情
+// @Tags 操作审批
+// @Produce json
+// @Param id path string true "审批申请ID"
+// @Success 200 {object} model.ApprovalRequestResp
+// @Router /api/v1/approval/requests/{id} [get]
+func (*ApprovalApi) HandleApprovalRequest(c *gin.Context) {
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.Approval.GetApprovalRequest(c.Request.Context(), c.Param("id"), claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// ApproveRequest 审批通过并执行
+// @Summary 审批通过
+// @Tags 操作审批
+// @Accept json
+// @Produce json
+// @Param id path string true "审批申请ID"
+// @Param body body model.ApprovalDecisionReq true "审批意见（可为空对象）"
+// @Success 200 {object} model.ApprovalRequest
+// @Router /api/v1/approval/requests/{id}/approve [post]
+func (*ApprovalApi) ApproveRequest(c *gin.Context) {
+	var req model.ApprovalDecisionReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.Approval.Approve(c.Request.Context(), c.Param("id"), &req, claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// RejectRequest 驳回审批申请
+// @Summary 驳回审批申请
+// @Tags 操作审批
+// @Accept json
+// @Produce json
+// @Param id path string true "审批申请ID"
+// @Param body body model.ApprovalDecisionReq true "驳回原因"
+// @Success 200 {object} model.ApprovalRequest
+// @Router /api/v1/approval/requests/{id}/reject [post]
+func (*ApprovalApi) RejectRequest(c *gin.Context) {
+	var req model.ApprovalDecisionReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.Approval.Reject(c.Request.Context(), c.Param("id"), &req, claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// CancelRequest 撤回自己提交的审批申请
+// @Summary 撤回审批申请
+// @Tags 操作审批
+// @Produce json
+// @Param id path string true "审批申请ID"
+// @Success 200 {object} model.ApprovalRequest
+// @Router /api/v1/approval/requests/{id}/cancel [post]
+func (*ApprovalApi) CancelRequest(c *gin.Context) {
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.Approval.Cancel(c.Request.Context(), c.Param("id"), claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
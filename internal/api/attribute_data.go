@@ -74,6 +74,10 @@ func (*AttributeDataApi) AttributePutMessage(c *gin.Context) {
 	}
 
 	userClaims := c.MustGet("claims").(*utils.UserClaims)
+	if err := service.GroupApp.DeviceParamPermission.CheckPutMessage(c, userClaims, req.DeviceID, model.DeviceParamATTR, req.Value); err != nil {
+		c.Error(err)
+		return
+	}
 	err := service.GroupApp.AttributeData.AttributePutMessage(c, userClaims.ID, &req, strconv.Itoa(constant.Manual))
 	if err != nil {
 		c.Error(err)
@@ -175,7 +175,7 @@ func (*BatteryApi) ImportBatteryList(c *gin.Context) {
 
 // BatchAssignDealer 批量分配经销商
 // @Summary 批量分配经销商
-// @Description BMS 电池管理-批量分配经销商
+// @Description BMS 电池管理-批量分配经销商，命中审批策略时返回 model.ApprovalPendingResp 待审批
 // @Tags 电池管理
 // @Accept json
 // @Produce json
@@ -192,7 +192,24 @@ func (*BatteryApi) BatchAssignDealer(c *gin.Context) {
 	dealerIDVal, _ := c.Get(middleware.DealerIDContextKey)
 	dealerID, _ := dealerIDVal.(string)
 
-	err := service.GroupApp.Battery.BatchAssignDealer(context.Background(), req, userClaims, dealerID)
+	// 命中审批策略时生成审批申请，审批通过后再执行
+	pending, err := service.GroupApp.Approval.Intercept(c.Request.Context(), userClaims, service.ApprovalSubmission{
+		OperationType: model.ApprovalOpBatchAssignDealer,
+		ScopeOrgID:    dealerID,
+		DeviceIDs:     req.DeviceIDs,
+		Target:        "经销商 " + req.DealerID,
+		Payload:       req,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if pending != nil {
+		c.Set("data", pending)
+		return
+	}
+
+	err = service.GroupApp.Battery.BatchAssignDealer(context.Background(), req, userClaims, dealerID)
 	if err != nil {
 		c.Error(err)
 		return
@@ -205,7 +222,7 @@ func (*BatteryApi) BatchAssignDealer(c *gin.Context) {
 
 // BatchSendCommand 批量下发指令
 // @Summary 批量下发指令
-// @Description BMS 电池管理-批量下发指令（仅在线设备）
+// @Description BMS 电池管理-批量下发指令（仅在线设备），命中审批策略时返回 model.ApprovalPendingResp 待审批
 // @Tags 电池管理
 // @Accept json
 // @Produce json
@@ -222,6 +239,24 @@ func (*BatteryApi) BatchSendCommand(c *gin.Context) {
 	dealerIDVal, _ := c.Get(middleware.DealerIDContextKey)
 	dealerID, _ := dealerIDVal.(string)
 
+	// 命中审批策略时生成审批申请，审批通过后再执行
+	pending, err := service.GroupApp.Approval.Intercept(c.Request.Context(), userClaims, service.ApprovalSubmission{
+		OperationType: model.ApprovalOpBatchCommand,
+		ScopeOrgID:    dealerID,
+		DeviceIDs:     req.DeviceIDs,
+		Identifiers:   []string{req.Identify},
+		Target:        "指令 " + req.Identify,
+		Payload:       req,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if pending != nil {
+		c.Set("data", pending)
+		return
+	}
+
 	data, err := service.GroupApp.Battery.BatchSendCommand(context.Background(), req, userClaims, dealerID)
 	if err != nil {
 		c.Error(err)
@@ -233,7 +268,7 @@ func (*BatteryApi) BatchSendCommand(c *gin.Context) {
 
 // BatchPushOTA 批量 OTA 推送
 // @Summary 批量 OTA 推送
-// @Description BMS 电池管理-批量 OTA 推送（创建升级任务并触发推送）
+// @Description BMS 电池管理-批量 OTA 推送（创建升级任务并触发推送），命中审批策略时返回 model.ApprovalPendingResp 待审批
 // @Tags 电池管理
 // @Accept json
 // @Produce json
@@ -250,6 +285,23 @@ func (*BatteryApi) BatchPushOTA(c *gin.Context) {
 	dealerIDVal, _ := c.Get(middleware.DealerIDContextKey)
 	dealerID, _ := dealerIDVal.(string)
 
+	// 命中审批策略时生成审批申请，审批通过后再执行
+	pending, err := service.GroupApp.Approval.Intercept(c.Request.Context(), userClaims, service.ApprovalSubmission{
+		OperationType: model.ApprovalOpBatchOTA,
+		ScopeOrgID:    dealerID,
+		DeviceIDs:     req.DeviceIDs,
+		Target:        "升级包 " + req.OTAUpgradePackageID,
+		Payload:       req,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if pending != nil {
+		c.Set("data", pending)
+		return
+	}
+
 	data, err := service.GroupApp.Battery.BatchPushOTA(context.Background(), req, userClaims, dealerID)
 	if err != nil {
 		c.Error(err)
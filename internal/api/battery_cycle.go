@@ -0,0 +1,75 @@
+package api
+
+import (
+	middleware "project/internal/middleware"
+	"project/internal/model"
+	"project/internal/service"
+	"project/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BatteryCycleApi BMS: 充放电会话（循环日志）
+type BatteryCycleApi struct{}
+
+// ListBatteryCycles 充放电会话列表
+// @Summary 充放电会话列表
+// @Description 由电流/SOC遥测切分的充电、放电、静置会话
+// @Tags 电池管理
+// @Produce json
+// @Param page query int true "页码"
+// @Param page_size query int true "每页数量"
+// @Param device_id query string false "设备ID"
+// @Param device_number query string false "设备编号"
+// @Param battery_model_id query string false "电池型号ID"
+// @Param session_type query string false "会话类型(CHARGE/DISCHARGE/IDLE)"
+// @Param start_time query string false "开始时间"
+// @Param end_time query string false "结束时间"
+// @Success 200 {object} model.BatteryCycleListResp
+// @Router /api/v1/battery/cycles [get]
+func (*BatteryCycleApi) ListBatteryCycles(c *gin.Context) {
+	var req model.BatteryCycleListReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	userClaims := c.MustGet("claims").(*utils.UserClaims)
+	dealerIDVal, _ := c.Get(middleware.DealerIDContextKey)
+	dealerID, _ := dealerIDVal.(string)
+
+	data, err := service.GroupApp.BatteryCycle.ListCycles(c, req, userClaims, dealerID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// GetBatteryCycleStats 充放电会话聚合
+// @Summary 充放电会话聚合
+// @Description 按设备/电池型号/经销商聚合充放电次数、能量、时长与温度
+// @Tags 电池管理
+// @Produce json
+// @Param group_by query string true "聚合维度(device/battery_model/dealer)"
+// @Param session_type query string false "会话类型(CHARGE/DISCHARGE/IDLE)"
+// @Param battery_model_id query string false "电池型号ID"
+// @Param start_time query string false "开始时间"
+// @Param end_time query string false "结束时间"
+// @Param limit query int false "返回条数(默认100)"
+// @Success 200 {object} model.BatteryCycleStatsResp
+// @Router /api/v1/battery/cycles/stats [get]
+func (*BatteryCycleApi) GetBatteryCycleStats(c *gin.Context) {
+	var req model.BatteryCycleStatsReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	userClaims := c.MustGet("claims").(*utils.UserClaims)
+	dealerIDVal, _ := c.Get(middleware.DealerIDContextKey)
+	dealerID, _ := dealerIDVal.(string)
+
+	data, err := service.GroupApp.BatteryCycle.CycleStats(c, req, userClaims, dealerID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
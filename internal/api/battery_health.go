@@ -0,0 +1,92 @@
+package api
+
+import (
+	"strconv"
+
+	middleware "project/internal/middleware"
+	"project/internal/model"
+	"project/internal/service"
+	"project/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BatteryHealthApi BMS: 电池健康分析
+type BatteryHealthApi struct{}
+
+// GetBatteryHealth 电池健康详情
+// @Summary 电池健康详情
+// @Description SOH 历史、等效循环、压差/内阻趋势与寿命预测
+// @Tags 电池管理
+// @Produce json
+// @Param device_id path string true "设备ID"
+// @Param days query int false "历史天数(默认180)"
+// @Success 200 {object} model.BatteryHealthDetailResp
+// @Router /api/v1/battery/health/{device_id} [get]
+func (*BatteryHealthApi) GetBatteryHealth(c *gin.Context) {
+	var req model.BatteryHealthDetailReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	userClaims := c.MustGet("claims").(*utils.UserClaims)
+	dealerIDVal, _ := c.Get(middleware.DealerIDContextKey)
+	dealerID, _ := dealerIDVal.(string)
+
+	data, err := service.GroupApp.BatteryHealth.GetDeviceHealth(c, c.Param("device_id"), req.Days, userClaims, dealerID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// RecalculateBatteryHealth 重新计算电池健康快照
+// @Summary 重新计算电池健康快照
+// @Description 按遥测历史回填最近N天的 SOH 快照并刷新寿命预测
+// @Tags 电池管理
+// @Produce json
+// @Param device_id path string true "设备ID"
+// @Param days query int false "回填天数(默认30，最大90)"
+// @Router /api/v1/battery/health/{device_id}/recalculate [post]
+func (*BatteryHealthApi) RecalculateBatteryHealth(c *gin.Context) {
+	userClaims := c.MustGet("claims").(*utils.UserClaims)
+	dealerIDVal, _ := c.Get(middleware.DealerIDContextKey)
+	dealerID, _ := dealerIDVal.(string)
+
+	days, _ := strconv.Atoi(c.Query("days"))
+	if err := service.GroupApp.BatteryHealth.Recalculate(c, c.Param("device_id"), days, userClaims, dealerID); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", nil)
+}
+
+// ListEolForecast 寿命预测列表
+// @Summary 寿命预测列表
+// @Description 预测在指定日期（默认当年年底）前 SOH 跌破寿命阈值的电池
+// @Tags 电池管理
+// @Produce json
+// @Param page query int true "页码"
+// @Param page_size query int true "每页数量"
+// @Param before_date query string false "截止日期(YYYY-MM-DD)"
+// @Param device_number query string false "设备编号"
+// @Param battery_model_id query string false "电池型号ID"
+// @Param in_warranty query bool false "仅质保期内"
+// @Success 200 {object} model.BatteryHealthForecastListResp
+// @Router /api/v1/battery/health/forecast [get]
+func (*BatteryHealthApi) ListEolForecast(c *gin.Context) {
+	var req model.BatteryHealthForecastListReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	userClaims := c.MustGet("claims").(*utils.UserClaims)
+	dealerIDVal, _ := c.Get(middleware.DealerIDContextKey)
+	dealerID, _ := dealerIDVal.(string)
+
+	data, err := service.GroupApp.BatteryHealth.ListEolForecast(c, req, userClaims, dealerID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	middleware "project/internal/middleware"
+	"project/internal/model"
+	"project/internal/service"
+	"project/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BatteryLifecycleApi BMS: 电池生命周期
+type BatteryLifecycleApi struct{}
+
+// GetBatteryLifecycle 电池生命周期详情
+// @Summary 电池生命周期详情
+// @Description 当前生命周期状态、可手动变更的目标状态及状态变更记录
+// @Tags 电池管理
+// @Produce json
+// @Param device_id path string true "设备ID"
+// @Success 200 {object} model.BatteryLifecycleResp
+// @Router /api/v1/battery/lifecycle/{device_id} [get]
+func (*BatteryLifecycleApi) GetBatteryLifecycle(c *gin.Context) {
+	userClaims := c.MustGet("claims").(*utils.UserClaims)
+	dealerIDVal, _ := c.Get(middleware.DealerIDContextKey)
+	dealerID, _ := dealerIDVal.(string)
+
+	data, err := service.GroupApp.BatteryLifecycle.GetLifecycle(c, c.Param("device_id"), userClaims, dealerID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// TransitionBatteryLifecycle 手动变更电池生命周期状态
+// @Summary 手动变更电池生命周期状态
+// @Description 发货/退回/翻新/报废等人工流转，未建档的电池可登记为已生产；非法流转返回错误
+// @Tags 电池管理
+// @Accept json
+// @Produce json
+// @Param device_id path string true "设备ID"
+// @Param data body model.BatteryLifecycleTransitionReq true "目标状态"
+// @Router /api/v1/battery/lifecycle/{device_id}/transition [post]
+func (*BatteryLifecycleApi) TransitionBatteryLifecycle(c *gin.Context) {
+	var req model.BatteryLifecycleTransitionReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	userClaims := c.MustGet("claims").(*utils.UserClaims)
+	dealerIDVal, _ := c.Get(middleware.DealerIDContextKey)
+	dealerID, _ := dealerIDVal.(string)
+
+	if err := service.GroupApp.BatteryLifecycle.Transition(c, c.Param("device_id"), req, userClaims, dealerID); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", nil)
+}
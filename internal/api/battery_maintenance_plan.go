@@ -0,0 +1,131 @@
+package api
+
+import (
+	middleware "project/internal/middleware"
+	"project/internal/model"
+	"project/internal/service"
+	"project/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BatteryMaintenancePlanApi 电池预测性维护（维护计划/工单）
+type BatteryMaintenancePlanApi struct{}
+
+// CreateMaintenancePlan 新增维护计划
+// @Router /api/v1/battery_maintenance/plans [post]
+func (*BatteryMaintenancePlanApi) CreateMaintenancePlan(c *gin.Context) {
+	var req model.BatteryMaintenancePlanCreateReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	userClaims := c.MustGet("claims").(*utils.UserClaims)
+
+	data, err := service.GroupApp.BatteryMaintenancePlan.CreatePlan(c, req, userClaims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// ListMaintenancePlans 维护计划列表
+// @Router /api/v1/battery_maintenance/plans [get]
+func (*BatteryMaintenancePlanApi) ListMaintenancePlans(c *gin.Context) {
+	var req model.BatteryMaintenancePlanListReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	userClaims := c.MustGet("claims").(*utils.UserClaims)
+
+	data, err := service.GroupApp.BatteryMaintenancePlan.ListPlans(c, req, userClaims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// UpdateMaintenancePlan 更新维护计划
+// @Router /api/v1/battery_maintenance/plans/{id} [put]
+func (*BatteryMaintenancePlanApi) UpdateMaintenancePlan(c *gin.Context) {
+	id := c.Param("id")
+	var req model.BatteryMaintenancePlanUpdateReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	userClaims := c.MustGet("claims").(*utils.UserClaims)
+
+	data, err := service.GroupApp.BatteryMaintenancePlan.UpdatePlan(c, id, req, userClaims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// DeleteMaintenancePlan 删除维护计划
+// @Router /api/v1/battery_maintenance/plans/{id} [delete]
+func (*BatteryMaintenancePlanApi) DeleteMaintenancePlan(c *gin.Context) {
+	id := c.Param("id")
+	userClaims := c.MustGet("claims").(*utils.UserClaims)
+
+	if err := service.GroupApp.BatteryMaintenancePlan.DeletePlan(c, id, userClaims); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", nil)
+}
+
+// ListMaintenanceOrders 维护工单列表
+// @Router /api/v1/battery_maintenance/orders [get]
+func (*BatteryMaintenancePlanApi) ListMaintenanceOrders(c *gin.Context) {
+	var req model.BatteryMaintenanceOrderListReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	userClaims := c.MustGet("claims").(*utils.UserClaims)
+	orgIDVal, _ := c.Get(middleware.DealerIDContextKey)
+	orgID, _ := orgIDVal.(string)
+
+	data, err := service.GroupApp.BatteryMaintenancePlan.ListOrders(c, req, userClaims, orgID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// ListDueMaintenanceOrders 即将到期的维护工单（默认本周，含已逾期）
+// @Router /api/v1/battery_maintenance/orders/due [get]
+func (*BatteryMaintenancePlanApi) ListDueMaintenanceOrders(c *gin.Context) {
+	var req model.BatteryMaintenanceDueReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	userClaims := c.MustGet("claims").(*utils.UserClaims)
+	orgIDVal, _ := c.Get(middleware.DealerIDContextKey)
+	orgID, _ := orgIDVal.(string)
+
+	data, err := service.GroupApp.BatteryMaintenancePlan.ListDueOrders(c, req, userClaims, orgID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// CancelMaintenanceOrder 取消维护工单
+// @Router /api/v1/battery_maintenance/orders/{id}/cancel [post]
+func (*BatteryMaintenancePlanApi) CancelMaintenanceOrder(c *gin.Context) {
+	id := c.Param("id")
+	userClaims := c.MustGet("claims").(*utils.UserClaims)
+	orgIDVal, _ := c.Get(middleware.DealerIDContextKey)
+	orgID, _ := orgIDVal.(string)
+
+	if err := service.GroupApp.BatteryMaintenancePlan.CancelOrder(c, id, userClaims, orgID); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", nil)
+}
@@ -77,3 +77,22 @@ func (*BmsDashboardApi) GetOnlineTrend(c *gin.Context) {
 	}
 	c.Set("data", data)
 }
+
+// GetBatteryHealth BMS Dashboard 电池健康概览
+// @Summary BMS Dashboard 电池健康概览
+// @Tags BMS-Dashboard
+// @Produce json
+// @Success 200 {object} model.BmsDashboardBatteryHealthResp
+// @Router /api/v1/dashboard/battery/health [get]
+func (*BmsDashboardApi) GetBatteryHealth(c *gin.Context) {
+	userClaims := c.MustGet("claims").(*utils.UserClaims)
+	dealerIDVal, _ := c.Get(middleware.DealerIDContextKey)
+	dealerID, _ := dealerIDVal.(string)
+
+	data, err := service.GroupApp.BmsDashboard.GetBatteryHealth(c, userClaims, dealerID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
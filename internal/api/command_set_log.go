@@ -38,6 +38,10 @@ func (CommandSetLogApi) CommandPutMessage(c *gin.Context) {
 	}
 
 	userClaims := c.MustGet("claims").(*utils.UserClaims)
+	if err := service.GroupApp.DeviceParamPermission.CheckIdentifiers(c, userClaims, req.DeviceID, model.DeviceParamCMD, model.DeviceParamExecute, []string{req.Identify}); err != nil {
+		c.Error(err)
+		return
+	}
 	err := service.GroupApp.CommandData.CommandPutMessage(c, userClaims.ID, &req, strconv.Itoa(constant.Manual))
 	if err != nil {
 		c.Error(err)
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"project/internal/model"
+	"project/internal/service"
+	"project/pkg/errcode"
+	"project/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// configBundleMaxSize 配置包请求体上限
+const configBundleMaxSize = 16 << 20
+
+type ConfigBundleApi struct{}
+
+// readConfigBundle 读取并校验请求体中的配置包（YAML 或 JSON）
+func readConfigBundle(c *gin.Context) (*model.ConfigBundle, bool) {
+	data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, configBundleMaxSize))
+	if err != nil {
+		c.Error(errcode.NewWithMessage(errcode.CodeParamError, err.Error()))
+		return nil, false
+	}
+	if len(data) == 0 {
+		c.Error(errcode.NewWithMessage(errcode.CodeParamError, "empty bundle"))
+		return nil, false
+	}
+	bundle, err := service.ParseConfigBundle(data)
+	if err != nil {
+		c.Error(errcode.NewWithMessage(errcode.CodeParamError, err.Error()))
+		return nil, false
+	}
+	if err := ValidateStruct(bundle); err != nil {
+		c.Error(errcode.NewWithMessage(errcode.CodeParamError, err.Error()))
+		return nil, false
+	}
+	return bundle, true
+}
+
+// ExportConfigBundle 导出配置包
+// @Summary 导出配置包
+// @Description 将当前租户的设备模板、设备配置（含数据脚本、Topic 映射）、告警配置与场景联动导出为声明式配置包，对象以 key 标识与引用
+// @Tags 配置即代码
+// @Produce application/x-yaml
+// @Param format query string false "yaml（默认）或 json"
+// @Param kinds query string false "导出的对象类型，逗号分隔：device_templates,device_configs,alarm_configs,scene_automations"
+// @Success 200 {file} file
+// @Router /api/v1/config_bundle/export [get]
+func (*ConfigBundleApi) ExportConfigBundle(c *gin.Context) {
+	var req model.ExportConfigBundleReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	if req.Format == "" {
+		req.Format = "yaml"
+	}
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.ConfigBundle.Export(c.Request.Context(), claims, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	contentType := "application/x-yaml"
+	if req.Format == "json" {
+		contentType = "application/json"
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=config_bundle.%s", req.Format))
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// PlanConfigBundle 预览配置包
+// @Summary 预览配置包
+// @Description 比较配置包与当前状态，返回各对象的新建、更新或未变更及变更字段，不做修改
+// @Tags 配置即代码
+// @Accept application/x-yaml
+// @Produce json
+// @Param body body model.ConfigBundle true "配置包（YAML 或 JSON）"
+// @Success 200 {object} model.ConfigBundlePlan
+// @Router /api/v1/config_bundle/plan [post]
+func (*ConfigBundleApi) PlanConfigBundle(c *gin.Context) {
+	bundle, ok := readConfigBundle(c)
+	if !ok {
+		return
+	}
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.ConfigBundle.Plan(c.Request.Context(), claims, bundle)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// ApplyConfigBundle 应用配置包
+// @Summary 应用配置包
+// @Description 在一个事务内应用配置包，任一对象失败则全部回滚；传入预览返回的指纹时，当前状态已变化则拒绝
+// @Tags 配置即代码
+// @Accept application/x-yaml
+// @Produce json
+// @Param plan_fingerprint query string false "预览返回的指纹"
+// @Param body body model.ConfigBundle true "配置包（YAML 或 JSON）"
+// @Success 200 {object} model.ConfigBundlePlan
+// @Router /api/v1/config_bundle/apply [post]
+func (*ConfigBundleApi) ApplyConfigBundle(c *gin.Context) {
+	// 请求体为配置包，参数从查询字符串读取
+	var req model.ApplyConfigBundleReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(errcode.NewWithMessage(errcode.CodeParamError, err.Error()))
+		return
+	}
+	if err := ValidateStruct(&req); err != nil {
+		c.Error(errcode.NewWithMessage(errcode.CodeParamError, err.Error()))
+		return
+	}
+	bundle, ok := readConfigBundle(c)
+	if !ok {
+		return
+	}
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.ConfigBundle.Apply(c.Request.Context(), claims, bundle, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
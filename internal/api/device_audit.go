@@ -0,0 +1,74 @@
+package api
+
+import (
+	"project/internal/model"
+	"project/internal/service"
+	"project/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DeviceAuditApi struct{}
+
+// HandleDeviceAuditLogListByPage 分页查询设备操作审计日志
+// @Summary 设备操作审计日志
+// @Tags 设备审计
+// @Produce json
+// @Param data query model.GetDeviceAuditLogListByPageReq true "筛选条件"
+// @Success 200 {object} []model.DeviceAuditLogResp
+// @Router /api/v1/device_audit/list [get]
+func (*DeviceAuditApi) HandleDeviceAuditLogListByPage(c *gin.Context) {
+	var req model.GetDeviceAuditLogListByPageReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	userClaims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.DeviceAudit.GetDeviceAuditLogListByPage(c.Request.Context(), &req, userClaims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// ExportDeviceAuditLogs 导出设备操作审计日志（CSV）
+// @Summary 导出设备操作审计日志
+// @Tags 设备审计
+// @Produce octet-stream
+// @Param data query model.DeviceAuditLogFilter false "筛选条件"
+// @Success 200 {file} file
+// @Router /api/v1/device_audit/export [get]
+func (*DeviceAuditApi) ExportDeviceAuditLogs(c *gin.Context) {
+	var req model.DeviceAuditLogFilter
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	userClaims := c.MustGet("claims").(*utils.UserClaims)
+	filePath, err := service.GroupApp.DeviceAudit.ExportDeviceAuditLogs(c.Request.Context(), &req, userClaims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.File(filePath)
+}
+
+// VerifyDeviceAuditChain 校验设备操作审计哈希链
+// @Summary 校验设备操作审计哈希链
+// @Tags 设备审计
+// @Produce json
+// @Param data query model.DeviceAuditVerifyReq false "校验范围"
+// @Success 200 {object} model.DeviceAuditVerifyResp
+// @Router /api/v1/device_audit/verify [get]
+func (*DeviceAuditApi) VerifyDeviceAuditChain(c *gin.Context) {
+	var req model.DeviceAuditVerifyReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	userClaims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.DeviceAudit.VerifyDeviceAuditChain(c.Request.Context(), &req, userClaims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
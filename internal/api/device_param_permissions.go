@@ -0,0 +1,65 @@
+package api
+
+import (
+	"project/internal/model"
+	"project/internal/service"
+	"project/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DeviceParamPermissionApi struct{}
+
+// ListDeviceParamRules 获取设备参数权限规则
+// @Summary 获取设备参数权限规则
+// @Tags 权限管理
+// @Accept json
+// @Produce json
+// @Param tenant_id query string false "租户ID（仅SYS_ADMIN可用）"
+// @Param data query model.DeviceParamRuleListReq false "筛选条件"
+// @Success 200 {object} []model.DeviceParamRule
+// @Router /api/v1/device_param_rules [get]
+func (*DeviceParamPermissionApi) ListDeviceParamRules(c *gin.Context) {
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	tenantID := c.Query("tenant_id")
+
+	var req model.DeviceParamRuleListReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+
+	data, err := service.GroupApp.DeviceParamPermission.ListRules(c.Request.Context(), claims, tenantID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// ReplaceDeviceParamRules 整体替换角色或机构类型的设备参数权限规则
+// @Summary 替换设备参数权限规则
+// @Tags 权限管理
+// @Accept json
+// @Produce json
+// @Param subject_type path string true "授权对象类型: ROLE, ORG_TYPE"
+// @Param subject_id path string true "角色ID或机构类型"
+// @Param tenant_id query string false "租户ID（仅SYS_ADMIN可用）"
+// @Param body body model.DeviceParamRuleReplaceReq true "规则列表"
+// @Success 200 {object} []model.DeviceParamRule
+// @Router /api/v1/device_param_rules/{subject_type}/{subject_id} [put]
+func (*DeviceParamPermissionApi) ReplaceDeviceParamRules(c *gin.Context) {
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	tenantID := c.Query("tenant_id")
+
+	var req model.DeviceParamRuleReplaceReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+
+	data, err := service.GroupApp.DeviceParamPermission.ReplaceRules(c.Request.Context(), claims, tenantID, c.Param("subject_type"), c.Param("subject_id"), &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
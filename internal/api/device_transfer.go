@@ -1,6 +1,9 @@
 package api
 
 import (
+	"context"
+
+	"project/internal/middleware"
 	"project/internal/model"
 	"project/internal/service"
 	"project/pkg/utils"
@@ -37,6 +40,55 @@ func (*DeviceTransferApi) TransferDevices(c *gin.Context) {
 	})
 }
 
+// TransferDevicesToOrg 批量转移设备到组织
+// @Summary 批量转移设备到组织
+// @Description 将设备转移到指定组织，目标组织为空表示退回厂家；命中审批策略时返回 model.ApprovalPendingResp 待审批
+// @Tags 设备转移
+// @Accept json
+// @Produce json
+// @Param body body model.DeviceOrgTransferReq true "转移请求"
+// @Success 200 {object} model.Response
+// @Router /api/v1/device/transfer/org [post]
+func (*DeviceTransferApi) TransferDevicesToOrg(c *gin.Context) {
+	var req model.DeviceOrgTransferReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+
+	userClaims := c.MustGet("claims").(*utils.UserClaims)
+	orgID := middleware.GetOrgID(c)
+
+	target := "退回厂家"
+	if req.ToOrgID != nil && *req.ToOrgID != "" {
+		target = "目标组织 " + *req.ToOrgID
+	}
+	// 命中审批策略时生成审批申请，审批通过后再执行
+	pending, err := service.GroupApp.Approval.Intercept(c.Request.Context(), userClaims, service.ApprovalSubmission{
+		OperationType: model.ApprovalOpOrgTransfer,
+		ScopeOrgID:    orgID,
+		DeviceIDs:     req.DeviceIDs,
+		Target:        target,
+		Payload:       req,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if pending != nil {
+		c.Set("data", pending)
+		return
+	}
+
+	if err := service.GroupApp.DeviceTransfer.TransferDevicesToOrg(context.Background(), req, userClaims, orgID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Set("data", map[string]interface{}{
+		"message": "transfer success",
+	})
+}
+
 // GetTransferHistory 获取设备转移记录
 // @Summary 获取设备转移记录
 // @Description 分页查询设备转移历史记录
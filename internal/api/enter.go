@@ -41,6 +41,9 @@ type Controller struct {
 	NotificationGroupApi          // 通知组
 	NotificationHistoryApi        // 通知历史
 	NotificationServicesConfigApi // 通知服务配置
+	NotificationInboxApi          // 站内信
+	NotificationTemplateApi       // 通知模板
+	WebhookSubscriptionApi        // Webhook事件订阅
 	AlarmApi                      // 告警
 	SceneAutomationsApi           // 场景联动
 	SceneApi                      // 场景
@@ -51,25 +54,39 @@ type Controller struct {
 	ServiceAccessApi              // 服务接入管理
 	ExpectedDataApi               // 预期数据
 	OpenAPIKeyApi                 // OpenAPI密钥
+	OidcApi                       // OIDC单点登录
+	UserMfaApi                    // 双因素认证
+	UserSessionApi                // 登录会话
 	MessagePushApi
 	SystemMonitorApi
 	DeviceAuthApi // 设备动态认证
 	DeviceTopicMappingApi
-	DealerApi             // BMS: 经销商管理
-	BmsDashboardApi       // BMS: Dashboard
-	BatteryApi            // BMS: 电池管理
-	BatteryModelApi       // BMS: 电池型号管理
-	DeviceTransferApi     // BMS: 设备转移
-	DeviceBindingApi      // BMS: 设备绑定(APP)
-	AppBatteryApi         // BMS: APP电池设备详情/透传
-	WarrantyApi           // BMS: 维保管理
-	EndUserApi            // BMS: 终端用户
-	ActivationLogApi      // BMS: 激活日志
-	BatteryMaintenanceApi // BMS: 电池维保记录
-	BatteryTagApi         // BMS: 电池标签
-	OfflineCommandApi     // BMS: 离线指令
-	OrgApi                // BMS: 组织管理（多层级）
-	OrgTypePermissionApi  // WEB: 机构类型权限配置（菜单权限/设备参数权限）
+	DealerApi                 // BMS: 经销商管理
+	BmsDashboardApi           // BMS: Dashboard
+	BatteryApi                // BMS: 电池管理
+	BatteryModelApi           // BMS: 电池型号管理
+	DeviceTransferApi         // BMS: 设备转移
+	DeviceBindingApi          // BMS: 设备绑定(APP)
+	AppBatteryApi             // BMS: APP电池设备详情/透传
+	WarrantyApi               // BMS: 维保管理
+	EndUserApi                // BMS: 终端用户
+	ActivationLogApi          // BMS: 激活日志
+	BatteryMaintenanceApi     // BMS: 电池维保记录
+	BatteryMaintenancePlanApi // BMS: 预测性维护（维护计划/工单）
+	BatteryHealthApi          // BMS: 电池健康分析
+	BatteryCycleApi           // BMS: 充放电会话（循环日志）
+	BatteryLifecycleApi       // BMS: 电池生命周期
+	BatteryTagApi             // BMS: 电池标签
+	OfflineCommandApi         // BMS: 离线指令
+	OrgApi                    // BMS: 组织管理（多层级）
+	OrgTypePermissionApi      // WEB: 机构类型权限配置（菜单权限/设备参数权限）
+	DeviceParamPermissionApi  // WEB: 设备参数细粒度权限（物模型标识符级别）
+	DeviceAuditApi            // WEB: 设备操作审计
+	ApprovalApi               // WEB: 高风险批量操作审批
+	TenantDataApi             // WEB: 租户数据迁移与注销
+	TenantSettingApi          // WEB: 租户设置（时区）
+	HolidayCalendarApi        // WEB: 节假日日历
+	ConfigBundleApi           // WEB: 配置即代码
 }
 
 var (
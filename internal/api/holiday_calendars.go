@@ -0,0 +1,136 @@
+package api
+
+import (
+	"strconv"
+
+	"project/internal/model"
+	"project/internal/service"
+	"project/pkg/errcode"
+	"project/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HolidayCalendarApi struct{}
+
+// ListHolidayCalendars 节假日日历列表
+// @Summary 节假日日历列表
+// @Tags 节假日日历
+// @Produce json
+// @Success 200 {object} []model.HolidayCalendarResp
+// @Router /api/v1/holiday_calendars [get]
+func (*HolidayCalendarApi) ListHolidayCalendars(c *gin.Context) {
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.HolidayCalendar.List(c.Request.Context(), claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// GetHolidayCalendar 节假日日历详情
+// @Summary 节假日日历详情（含日期）
+// @Tags 节假日日历
+// @Produce json
+// @Param id path string true "日历ID"
+// @Param year query int false "年份（不传返回全部）"
+// @Success 200 {object} model.HolidayCalendarResp
+// @Router /api/v1/holiday_calendars/{id} [get]
+func (*HolidayCalendarApi) GetHolidayCalendar(c *gin.Context) {
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	year := 0
+	if v := c.Query("year"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			c.Error(errcode.WithData(errcode.CodeParamError, map[string]interface{}{"year": err.Error()}))
+			return
+		}
+		year = n
+	}
+	data, err := service.GroupApp.HolidayCalendar.Detail(c.Request.Context(), c.Param("id"), year, claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// CreateHolidayCalendar 新增节假日日历
+// @Summary 新增节假日日历
+// @Tags 节假日日历
+// @Accept json
+// @Produce json
+// @Param body body model.HolidayCalendarReq true "日历"
+// @Success 200 {object} model.HolidayCalendarResp
+// @Router /api/v1/holiday_calendars [post]
+func (*HolidayCalendarApi) CreateHolidayCalendar(c *gin.Context) {
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	var req model.HolidayCalendarReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	data, err := service.GroupApp.HolidayCalendar.Create(c.Request.Context(), &req, claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// UpdateHolidayCalendar 更新节假日日历
+// @Summary 更新节假日日历
+// @Tags 节假日日历
+// @Accept json
+// @Produce json
+// @Param id path string true "日历ID"
+// @Param body body model.HolidayCalendarReq true "日历"
+// @Router /api/v1/holiday_calendars/{id} [put]
+func (*HolidayCalendarApi) UpdateHolidayCalendar(c *gin.Context) {
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	var req model.HolidayCalendarReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	if err := service.GroupApp.HolidayCalendar.Update(c.Request.Context(), c.Param("id"), &req, claims); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", nil)
+}
+
+// DeleteHolidayCalendar 删除节假日日历
+// @Summary 删除节假日日历（被场景联动引用时拒绝）
+// @Tags 节假日日历
+// @Produce json
+// @Param id path string true "日历ID"
+// @Router /api/v1/holiday_calendars/{id} [delete]
+func (*HolidayCalendarApi) DeleteHolidayCalendar(c *gin.Context) {
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	if err := service.GroupApp.HolidayCalendar.Delete(c.Request.Context(), c.Param("id"), claims); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", nil)
+}
+
+// SetHolidayCalendarDates 设置节假日日期
+// @Summary 整体替换指定年份的节假日日期
+// @Tags 节假日日历
+// @Accept json
+// @Produce json
+// @Param id path string true "日历ID"
+// @Param body body model.HolidayCalendarDatesReq true "日期"
+// @Router /api/v1/holiday_calendars/{id}/dates [put]
+func (*HolidayCalendarApi) SetHolidayCalendarDates(c *gin.Context) {
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	var req model.HolidayCalendarDatesReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	if err := service.GroupApp.HolidayCalendar.SetDates(c.Request.Context(), c.Param("id"), &req, claims); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", nil)
+}
@@ -32,3 +32,15 @@ func (*NotificationHistoryApi) HandleNotificationHistoryListByPage(c *gin.Contex
 	}
 	c.Set("data", ntfoutput)
 }
+
+// RedeliverNotificationHistory 重新投递 Webhook 通知
+// @Router   /api/v1/notification_history/{id}/redeliver [post]
+func (*NotificationHistoryApi) RedeliverNotificationHistory(c *gin.Context) {
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.WebhookSubscription.RedeliverNotificationHistory(c.Request.Context(), c.Param("id"), claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	model "project/internal/model"
+	service "project/internal/service"
+	utils "project/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationInboxApi struct{}
+
+// HandleNotificationInboxListByPage 当前用户的站内信列表
+// @Router   /api/v1/notification_inbox/list [get]
+func (*NotificationInboxApi) HandleNotificationInboxListByPage(c *gin.Context) {
+	var req model.GetNotificationInboxListByPageReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.NotificationInbox.GetNotificationInboxListByPage(c.Request.Context(), &req, claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// HandleNotificationInboxUnreadCount 当前用户的未读站内信数
+// @Router   /api/v1/notification_inbox/unread_count [get]
+func (*NotificationInboxApi) HandleNotificationInboxUnreadCount(c *gin.Context) {
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.NotificationInbox.GetNotificationInboxUnreadCount(c.Request.Context(), claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// MarkNotificationInboxRead 站内信标记已读
+// @Router   /api/v1/notification_inbox/read [put]
+func (*NotificationInboxApi) MarkNotificationInboxRead(c *gin.Context) {
+	var req model.NotificationInboxReadReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.NotificationInbox.MarkNotificationInboxRead(c.Request.Context(), &req, claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// MarkAllNotificationInboxRead 站内信全部标记已读
+// @Router   /api/v1/notification_inbox/read_all [put]
+func (*NotificationInboxApi) MarkAllNotificationInboxRead(c *gin.Context) {
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.NotificationInbox.MarkAllNotificationInboxRead(c.Request.Context(), claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
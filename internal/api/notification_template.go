@@ -0,0 +1,91 @@
+package api
+
+import (
+	model "project/internal/model"
+	service "project/internal/service"
+	utils "project/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationTemplateApi struct{}
+
+// CreateNotificationTemplate 新建通知模板
+// @Router   /api/v1/notification_template [post]
+func (*NotificationTemplateApi) CreateNotificationTemplate(c *gin.Context) {
+	var req model.CreateNotificationTemplateReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.NotificationTemplate.CreateNotificationTemplate(c.Request.Context(), &req, claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// UpdateNotificationTemplate 修改通知模板
+// @Router   /api/v1/notification_template/{id} [put]
+func (*NotificationTemplateApi) UpdateNotificationTemplate(c *gin.Context) {
+	var req model.UpdateNotificationTemplateReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.NotificationTemplate.UpdateNotificationTemplate(c.Request.Context(), c.Param("id"), &req, claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// DeleteNotificationTemplate 删除通知模板
+// @Router   /api/v1/notification_template/{id} [delete]
+func (*NotificationTemplateApi) DeleteNotificationTemplate(c *gin.Context) {
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	if err := service.GroupApp.NotificationTemplate.DeleteNotificationTemplate(c.Request.Context(), c.Param("id"), claims); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", nil)
+}
+
+// HandleNotificationTemplateListByPage 通知模板列表
+// @Router   /api/v1/notification_template/list [get]
+func (*NotificationTemplateApi) HandleNotificationTemplateListByPage(c *gin.Context) {
+	var req model.GetNotificationTemplateListByPageReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.NotificationTemplate.GetNotificationTemplateListByPage(c.Request.Context(), &req, claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// PreviewNotificationTemplate 预览通知模板
+// @Router   /api/v1/notification_template/preview [post]
+func (*NotificationTemplateApi) PreviewNotificationTemplate(c *gin.Context) {
+	var req model.PreviewNotificationTemplateReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	data, err := service.GroupApp.NotificationTemplate.PreviewNotificationTemplate(&req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// HandleNotificationTemplateVariables 通知模板可用变量
+// @Router   /api/v1/notification_template/variables [get]
+func (*NotificationTemplateApi) HandleNotificationTemplateVariables(c *gin.Context) {
+	c.Set("data", service.GroupApp.NotificationTemplate.GetNotificationTemplateVariables())
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"net/http"
+
+	model "project/internal/model"
+	service "project/internal/service"
+	utils "project/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OidcApi struct{}
+
+// oidcBaseURL 当前请求的外部访问地址，用于生成默认回调地址
+func oidcBaseURL(c *gin.Context) string {
+	scheme := c.GetHeader("X-Forwarded-Proto")
+	if scheme == "" {
+		if c.Request.TLS != nil {
+			scheme = "https"
+		} else {
+			scheme = "http"
+		}
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+// CreateOidcProvider 新建身份提供方
+// @Router   /api/v1/sso/oidc/provider [post]
+func (*OidcApi) CreateOidcProvider(c *gin.Context) {
+	var req model.CreateOidcProviderReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.Oidc.CreateOidcProvider(c.Request.Context(), &req, claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// UpdateOidcProvider 修改身份提供方
+// @Router   /api/v1/sso/oidc/provider/{id} [put]
+func (*OidcApi) UpdateOidcProvider(c *gin.Context) {
+	var req model.UpdateOidcProviderReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.Oidc.UpdateOidcProvider(c.Request.Context(), c.Param("id"), &req, claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// DeleteOidcProvider 删除身份提供方
+// @Router   /api/v1/sso/oidc/provider/{id} [delete]
+func (*OidcApi) DeleteOidcProvider(c *gin.Context) {
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	if err := service.GroupApp.Oidc.DeleteOidcProvider(c.Request.Context(), c.Param("id"), claims); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", nil)
+}
+
+// HandleOidcProviderListByPage 身份提供方列表
+// @Router   /api/v1/sso/oidc/provider/list [get]
+func (*OidcApi) HandleOidcProviderListByPage(c *gin.Context) {
+	var req model.GetOidcProviderListByPageReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.Oidc.GetOidcProviderListByPage(c.Request.Context(), &req, claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// TestOidcProvider 检查身份提供方发现文档与签名公钥
+// @Router   /api/v1/sso/oidc/provider/{id}/test [post]
+func (*OidcApi) TestOidcProvider(c *gin.Context) {
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.Oidc.TestOidcProvider(c.Request.Context(), c.Param("id"), claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// HandleOidcLoginProviders 登录页可用的身份提供方（无需登录）
+// @Router   /api/v1/sso/oidc/providers [get]
+func (*OidcApi) HandleOidcLoginProviders(c *gin.Context) {
+	var req model.OidcLoginProvidersReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	data, err := service.GroupApp.Oidc.GetLoginOidcProviders(c.Request.Context(), req.TenantID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// OidcAuthorize 跳转身份提供方登录（无需登录）
+// @Router   /api/v1/sso/oidc/{id}/authorize [get]
+func (*OidcApi) OidcAuthorize(c *gin.Context) {
+	var req model.OidcAuthorizeReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	location, err := service.GroupApp.Oidc.OidcAuthorize(c.Request.Context(), c.Param("id"), &req, oidcBaseURL(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Redirect(http.StatusFound, location)
+}
+
+// OidcCallback 身份提供方回调（无需登录），处理后跳转控制台
+// @Router   /api/v1/sso/oidc/{id}/callback [get]
+func (*OidcApi) OidcCallback(c *gin.Context) {
+	var req model.OidcCallbackReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(err)
+		return
+	}
+	location, err := service.GroupApp.Oidc.OidcCallback(c.Request.Context(), c.Param("id"), &req, requestMeta(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Redirect(http.StatusFound, location)
+}
+
+// ExchangeOidcTicket 用登录票据换取令牌（无需登录）
+// @Router   /api/v1/sso/oidc/token [post]
+func (*OidcApi) ExchangeOidcTicket(c *gin.Context) {
+	var req model.OidcTicketReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	data, err := service.GroupApp.Oidc.ExchangeOidcTicket(c.Request.Context(), req.Ticket)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// OidcBackchannelLogout 身份提供方后端通道退出通知（无需登录）
+// @Router   /api/v1/sso/oidc/{id}/backchannel_logout [post]
+func (*OidcApi) OidcBackchannelLogout(c *gin.Context) {
+	logoutToken := c.PostForm("logout_token")
+	if logoutToken == "" {
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+	if err := service.GroupApp.Oidc.OidcBackchannelLogout(c.Request.Context(), c.Param("id"), logoutToken); err != nil {
+		c.Header("Cache-Control", "no-store")
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+}
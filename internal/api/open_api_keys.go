@@ -83,3 +83,43 @@ func (*OpenAPIKeyApi) DeleteOpenAPIKey(c *gin.Context) {
 
 	c.Set("data", nil)
 }
+
+// RotateOpenAPIKey 轮换API密钥
+// @Router /api/v1/open/keys/{id}/rotate [post]
+func (*OpenAPIKeyApi) RotateOpenAPIKey(c *gin.Context) {
+	id := c.Param("id")
+	var req model.RotateOpenAPIKeyReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+
+	var userClaims = c.MustGet("claims").(*utils.UserClaims)
+
+	data, err := service.GroupApp.OpenAPIKey.RotateOpenAPIKey(id, &req, userClaims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Set("data", data)
+}
+
+// GetOpenAPIKeyUsage 获取API密钥调用统计
+// @Router /api/v1/open/keys/{id}/usage [get]
+func (*OpenAPIKeyApi) GetOpenAPIKeyUsage(c *gin.Context) {
+	id := c.Param("id")
+	var req model.OpenAPIKeyUsageReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+
+	var userClaims = c.MustGet("claims").(*utils.UserClaims)
+
+	data, err := service.GroupApp.OpenAPIKey.GetOpenAPIKeyUsage(id, &req, userClaims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Set("data", data)
+}
@@ -90,7 +90,8 @@ func (*OTAApi) CreateOTAUpgradeTask(c *gin.Context) {
 		return
 	}
 
-	err := service.GroupApp.OTA.CreateOTAUpgradeTask(&req)
+	userClaims := c.MustGet("claims").(*utils.UserClaims)
+	err := service.GroupApp.OTA.CreateOTAUpgradeTask(&req, userClaims.ID)
 	if err != nil {
 		c.Error(err)
 		return
@@ -148,7 +149,8 @@ func (*OTAApi) UpdateOTAUpgradeTaskStatus(c *gin.Context) {
 	if !BindAndValidate(c, &req) {
 		return
 	}
-	err := service.GroupApp.OTA.UpdateOTAUpgradeTaskStatus(&req)
+	userClaims := c.MustGet("claims").(*utils.UserClaims)
+	err := service.GroupApp.OTA.UpdateOTAUpgradeTaskStatus(&req, userClaims.ID)
 	if err != nil {
 		c.Error(err)
 		return
@@ -83,6 +83,38 @@ func (*SceneAutomationsApi) HandleSceneAutomations(c *gin.Context) {
 	c.Set("data", data)
 }
 
+// 场景联动试运行：按给定遥测评估条件并返回逐条结果，不执行动作
+// /api/v1/scene_automations/explain/{id} [post]
+func (*SceneAutomationsApi) ExplainSceneAutomations(c *gin.Context) {
+	var req model.SceneAutomationExplainReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	userClaims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.SceneAutomation.Explain(c.Request.Context(), c.Param("id"), &req, userClaims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// 场景联动历史回放：按设备历史遥测重放条件评估，返回会触发的时间点
+// /api/v1/scene_automations/replay/{id} [post]
+func (*SceneAutomationsApi) ReplaySceneAutomations(c *gin.Context) {
+	var req model.SceneAutomationReplayReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	userClaims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.SceneAutomation.Replay(c.Request.Context(), c.Param("id"), &req, userClaims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
 // 场景联动列表查询
 // /api/v1/scene_automations/list [get]
 func (*SceneAutomationsApi) HandleSceneAutomationsByPage(c *gin.Context) {
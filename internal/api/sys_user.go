@@ -13,7 +13,7 @@ type UserApi struct{}
 
 // Login
 // @Summary      用户登录
-// @Description  认证令牌(Token)将在用户成功登录后生成并返回。客户端需要在后续所有需要认证的API请求中，将此令牌添加到HTTP请求头(Header)的'x-token'字段中。服务器将通过验证此令牌来确认用户身份并授权访问受保护资源。
+// @Description  认证令牌(Token)将在用户成功登录后生成并返回；账号启用（或租户强制）双因素认证时返回 mfa_token，需调用 /api/v1/login/mfa/verify 完成二次验证。客户端需要在后续所有需要认证的API请求中，将此令牌添加到HTTP请求头(Header)的'x-token'字段中。服务器将通过验证此令牌来确认用户身份并授权访问受保护资源。
 // @Tags         用户认证
 // @Accept       json
 // @Produce      json
@@ -56,7 +56,7 @@ func (*UserApi) Login(c *gin.Context) {
 		}
 	}
 
-	loginRsp, err := service.GroupApp.User.Login(c, &loginReq)
+	loginRsp, err := service.GroupApp.User.Login(c, &loginReq, requestMeta(c))
 	if err != nil {
 		_ = loginLock.LoginFail(c, loginReq.Email)
 		c.Error(err)
@@ -69,18 +69,29 @@ func (*UserApi) Login(c *gin.Context) {
 // GET /api/v1/user/logout
 func (*UserApi) Logout(c *gin.Context) {
 	token := c.GetHeader("x-token")
-	err := service.GroupApp.User.Logout(token)
+	userClaims := c.MustGet("claims").(*utils.UserClaims)
+	err := service.GroupApp.UserSession.Logout(c.Request.Context(), userClaims, token)
 	if err != nil {
 		c.Error(err)
 		return
 	}
+	// 单点登录的会话返回身份提供方的退出地址，由控制台跳转完成全局退出；旧版令牌按令牌登记
+	sessionKey := userClaims.SessionID
+	if sessionKey == "" {
+		sessionKey = token
+	}
+	if endSessionURL := service.GroupApp.Oidc.OidcLogoutURL(c.Request.Context(), sessionKey); endSessionURL != "" {
+		c.Set("data", map[string]interface{}{"end_session_url": endSessionURL})
+		return
+	}
 	c.Set("data", nil)
 }
 
 // GET /api/v1/user/refresh
+// 旧版令牌升级为带刷新令牌的会话，升级后原令牌失效；会话令牌须凭刷新令牌续签（POST /api/v1/login/refresh）
 func (*UserApi) RefreshToken(c *gin.Context) {
 	userClaims := c.MustGet("claims").(*utils.UserClaims)
-	loginRsp, err := service.GroupApp.User.RefreshToken(userClaims)
+	loginRsp, err := service.GroupApp.UserSession.Reissue(c.Request.Context(), userClaims, c.GetHeader("x-token"), requestMeta(c))
 	if err != nil {
 		c.Error(err)
 		return
@@ -260,7 +271,7 @@ func (*UserApi) TransformUser(c *gin.Context) {
 
 	userClaims := c.MustGet("claims").(*utils.UserClaims)
 
-	loginRsp, err := service.GroupApp.User.TransformUser(&transformUserReq, userClaims)
+	loginRsp, err := service.GroupApp.User.TransformUser(&transformUserReq, userClaims, requestMeta(c))
 	if err != nil {
 		c.Error(err)
 		return
@@ -276,7 +287,7 @@ func (*UserApi) EmailRegister(c *gin.Context) {
 	if !BindAndValidate(c, &req) {
 		return
 	}
-	loginRsp, err := service.GroupApp.User.EmailRegister(c, &req)
+	loginRsp, err := service.GroupApp.User.EmailRegister(c, &req, requestMeta(c))
 	if err != nil {
 		c.Error(err)
 		return
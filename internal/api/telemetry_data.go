@@ -20,7 +20,6 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 	"github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
 
 	"project/internal/middleware"
 )
@@ -201,31 +200,12 @@ func (*TelemetryDataApi) SimulationTelemetryData(c *gin.Context) {
 
 // validateToken 验证WebSocket中的token
 func validateToken(token string) (*utils.UserClaims, error) {
-	// 验证 Redis 中的 token
-	if global.REDIS.Get(context.Background(), token).Val() != "1" {
-		return nil, errors.New("token is expired")
-	}
-
-	// 刷新 token 过期时间
-	timeout := viper.GetInt("session.timeout")
-	if timeout == 0 {
-		timeout = 60 // 默认60分钟
-	}
-	global.REDIS.Set(context.Background(), token, "1", time.Duration(timeout)*time.Minute)
-
-	// 验证 JWT
-	key := viper.GetString("jwt.key")
-	j := utils.NewJWT([]byte(key))
-	claims, err := j.ParseToken(token)
-	if err != nil {
-		return nil, errors.New("invalid token")
-	}
-
-	return claims, nil
+	return service.GroupApp.UserSession.ValidateAccessToken(context.Background(), token)
 }
 
 // validateAPIKey 验证WebSocket中的API Key
-func validateAPIKey(apiKey string) (*utils.UserClaims, error) {
+// 与 HTTP 接口共用IP白名单与限流校验；WebSocket 订阅的是遥测数据：密钥需有 telemetry:read 权限，受限密钥只能订阅其组织/设备分组内的设备
+func validateAPIKey(apiKey, deviceID, clientIP string) (*utils.UserClaims, error) {
 	// 创建API Key验证器
 	validator := middleware.NewAPIKeyValidator(global.DB, global.REDIS)
 
@@ -235,6 +215,27 @@ func validateAPIKey(apiKey string) (*utils.UserClaims, error) {
 		return nil, err
 	}
 
+	now := time.Now()
+	if _, denied := validator.CheckAccess(info, clientIP, now); denied != nil {
+		validator.RecordUsage(info, clientIP, true, now)
+		return nil, denied
+	}
+	if !info.HasScope(model.APIKeyScopeTelemetryRead) {
+		validator.RecordUsage(info, clientIP, true, now)
+		return nil, errors.New("api key scope does not allow telemetry subscription")
+	}
+	if info.Restricted() {
+		ok, err := validator.CanAccessDevices(info, []string{deviceID})
+		if err != nil {
+			logrus.Warnf("API Key device restriction check failed: %v", err)
+		}
+		if !ok || deviceID == "" {
+			validator.RecordUsage(info, clientIP, true, now)
+			return nil, errors.New("device is outside the scope of this api key")
+		}
+	}
+	validator.RecordUsage(info, clientIP, false, now)
+
 	// 构造UserClaims
 	claims := &utils.UserClaims{
 		TenantID:  info.TenantID,
@@ -246,7 +247,7 @@ func validateAPIKey(apiKey string) (*utils.UserClaims, error) {
 }
 
 // validateAuth 验证WebSocket中的认证信息（支持token和API Key双重认证）
-func validateAuth(msgMap map[string]interface{}) (*utils.UserClaims, error) {
+func validateAuth(msgMap map[string]interface{}, clientIP string) (*utils.UserClaims, error) {
 	// 优先验证token
 	if tokenInterface, ok := msgMap["token"]; ok {
 		if token, isString := tokenInterface.(string); isString && token != "" {
@@ -262,12 +263,17 @@ func validateAuth(msgMap map[string]interface{}) (*utils.UserClaims, error) {
 	// 尝试API Key验证
 	if apiKeyInterface, ok := msgMap["x-api-key"]; ok {
 		if apiKey, isString := apiKeyInterface.(string); isString && apiKey != "" {
-			claims, err := validateAPIKey(apiKey)
+			deviceID, _ := msgMap["device_id"].(string)
+			claims, err := validateAPIKey(apiKey, deviceID, clientIP)
 			if err == nil {
 				return claims, nil
 			}
-			// API Key验证失败，记录日志
+			// API Key验证失败，记录日志；IP白名单或限流拒绝时返回对应错误码
 			logrus.Warnf("API Key validation failed: %v", err)
+			var denied *middleware.APIKeyDenied
+			if errors.As(err, &denied) {
+				return nil, denied
+			}
 		}
 	}
 
@@ -319,7 +325,7 @@ func (*TelemetryDataApi) ServeCurrentDataByWS(c *gin.Context) {
 	}
 
 	// 验证认证信息（token或API Key）
-	claims, err := validateAuth(msgMap)
+	claims, err := validateAuth(msgMap, c.ClientIP())
 	if err != nil {
 		logrus.Error("认证失败:", err)
 		conn.WriteMessage(msgType, []byte(err.Error()))
@@ -478,7 +484,7 @@ func (*TelemetryDataApi) ServeDeviceStatusByWS(c *gin.Context) {
 	}
 
 	// 验证认证信息（token或API Key）
-	claims, err := validateAuth(msgMap)
+	claims, err := validateAuth(msgMap, c.ClientIP())
 	if err != nil {
 		logrus.Error("认证失败:", err)
 		conn.WriteMessage(msgType, []byte(err.Error()))
@@ -651,7 +657,7 @@ func (*TelemetryDataApi) ServeCurrentDataByKey(c *gin.Context) {
 	}
 
 	// 验证认证信息（token或API Key）
-	claims, err := validateAuth(msgMap)
+	claims, err := validateAuth(msgMap, c.ClientIP())
 	if err != nil {
 		logrus.Error("认证失败:", err)
 		conn.WriteMessage(msgType, []byte(err.Error()))
@@ -786,6 +792,10 @@ func (*TelemetryDataApi) TelemetryPutMessage(c *gin.Context) {
 	}
 
 	userClaims := c.MustGet("claims").(*utils.UserClaims)
+	if err := service.GroupApp.DeviceParamPermission.CheckPutMessage(c, userClaims, req.DeviceID, model.DeviceParamTEL, req.Value); err != nil {
+		c.Error(err)
+		return
+	}
 	err := service.GroupApp.TelemetryData.TelemetryPutMessage(c, userClaims.ID, &req, strconv.Itoa(constant.Manual))
 	if err != nil {
 		c.Error(err)
@@ -0,0 +1,208 @@
+package api
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"project/internal/model"
+	"project/internal/service"
+	"project/pkg/errcode"
+	"project/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TenantDataApi struct{}
+
+// CreateTenantExport 创建租户数据导出任务
+// @Summary 创建租户数据导出任务
+// @Description 异步导出租户的设备、配置、自动化、用户、告警历史及指定范围的遥测数据，完成后下载归档
+// @Tags 租户迁移
+// @Accept json
+// @Produce json
+// @Param body body model.TenantExportReq true "导出参数"
+// @Success 200 {object} model.TenantDataJob
+// @Router /api/v1/tenant_data/export [post]
+func (*TenantDataApi) CreateTenantExport(c *gin.Context) {
+	var req model.TenantExportReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.TenantData.CreateExportJob(c.Request.Context(), claims, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// CreateTenantImport 上传归档并创建租户数据导入任务
+// @Summary 创建租户数据导入任务
+// @Tags 租户迁移
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "租户归档（zip）"
+// @Param target_tenant_id formData string false "目标租户ID，为空时沿用归档中的租户ID；记录ID保持不变，本实例仍有源租户数据时导入其他租户会失败"
+// @Success 200 {object} model.TenantDataJob
+// @Router /api/v1/tenant_data/import [post]
+func (*TenantDataApi) CreateTenantImport(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil || file == nil {
+		c.Error(errcode.New(errcode.CodeFileEmpty))
+		return
+	}
+	ext := strings.ToLower(filepath.Ext(file.Filename))
+	if ext != ".zip" {
+		c.Error(errcode.WithVars(errcode.CodeFileTypeMismatch, map[string]interface{}{
+			"expected_type": ".zip",
+			"actual_type":   ext,
+		}))
+		return
+	}
+
+	uploadDir := "./files/upload/"
+	if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
+		c.Error(errcode.WithVars(errcode.CodeFilePathGenError, map[string]interface{}{
+			"error": err.Error(),
+		}))
+		return
+	}
+	// 导入任务异步读取归档，文件保留到任务结束后
+	filePath := filepath.Join(uploadDir, fmt.Sprintf("tenant_import_%d%s", time.Now().UnixNano(), ext))
+	if err := c.SaveUploadedFile(file, filePath); err != nil {
+		c.Error(errcode.WithVars(errcode.CodeFileSaveError, map[string]interface{}{
+			"error": err.Error(),
+		}))
+		return
+	}
+
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.TenantData.CreateImportJob(c.Request.Context(), claims, filePath, c.PostForm("target_tenant_id"))
+	if err != nil {
+		os.Remove(filePath)
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// HandleTenantDataJobListByPage 分页查询租户导出导入任务
+// @Summary 租户导出导入任务列表
+// @Tags 租户迁移
+// @Produce json
+// @Param data query model.GetTenantDataJobListByPageReq true "筛选条件"
+// @Success 200 {object} []model.TenantDataJob
+// @Router /api/v1/tenant_data/jobs [get]
+func (*TenantDataApi) HandleTenantDataJobListByPage(c *gin.Context) {
+	var req model.GetTenantDataJobListByPageReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.TenantData.GetJobListByPage(c.Request.Context(), &req, claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// HandleTenantDataJob 租户导出导入任务详情
+// @Summary 租户导出导入任务详情
+// @Tags 租户迁移
+// @Produce json
+// @Param id path string true "任务ID"
+// @Success 200 {object} model.TenantDataJob
+// @Router /api/v1/tenant_data/jobs/{id} [get]
+func (*TenantDataApi) HandleTenantDataJob(c *gin.Context) {
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.TenantData.GetJob(c.Request.Context(), c.Param("id"), claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// DownloadTenantExport 下载租户导出归档
+// @Summary 下载租户导出归档
+// @Tags 租户迁移
+// @Produce octet-stream
+// @Param id path string true "任务ID"
+// @Success 200 {file} file
+// @Router /api/v1/tenant_data/jobs/{id}/download [get]
+func (*TenantDataApi) DownloadTenantExport(c *gin.Context) {
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	filePath, err := service.GroupApp.TenantData.GetExportFile(c.Request.Context(), c.Param("id"), claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.FileAttachment(filePath, filepath.Base(filePath))
+}
+
+// ScheduleTenantOffboarding 发起租户注销
+// @Summary 发起租户注销
+// @Description 冻结租户全部账号并停用 API Key，保留期内可撤销，到期后清除租户全部数据
+// @Tags 租户迁移
+// @Accept json
+// @Produce json
+// @Param body body model.TenantOffboardingReq true "注销参数"
+// @Success 200 {object} model.TenantOffboarding
+// @Router /api/v1/tenant_offboarding [post]
+func (*TenantDataApi) ScheduleTenantOffboarding(c *gin.Context) {
+	var req model.TenantOffboardingReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.TenantOffboarding.Schedule(c.Request.Context(), claims, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// HandleTenantOffboardingListByPage 分页查询租户注销记录
+// @Summary 租户注销记录
+// @Tags 租户迁移
+// @Produce json
+// @Param data query model.GetTenantOffboardingListReq true "筛选条件"
+// @Success 200 {object} []model.TenantOffboarding
+// @Router /api/v1/tenant_offboarding [get]
+func (*TenantDataApi) HandleTenantOffboardingListByPage(c *gin.Context) {
+	var req model.GetTenantOffboardingListReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.TenantOffboarding.GetListByPage(c.Request.Context(), claims, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// CancelTenantOffboarding 撤销租户注销
+// @Summary 撤销租户注销
+// @Description 保留期内撤销，恢复注销时冻结的账号与停用的 API Key
+// @Tags 租户迁移
+// @Produce json
+// @Param id path string true "注销记录ID"
+// @Success 200 {object} model.TenantOffboarding
+// @Router /api/v1/tenant_offboarding/{id}/cancel [post]
+func (*TenantDataApi) CancelTenantOffboarding(c *gin.Context) {
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.TenantOffboarding.Cancel(c.Request.Context(), claims, c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
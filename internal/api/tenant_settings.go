@@ -0,0 +1,57 @@
+package api
+
+import (
+	"project/internal/model"
+	"project/internal/service"
+	"project/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TenantSettingApi struct{}
+
+// GetTenantSetting 获取租户设置
+// @Summary 获取租户设置（时区、双因素认证策略）
+// @Tags 租户设置
+// @Accept json
+// @Produce json
+// @Param tenant_id query string false "租户ID（仅SYS_ADMIN可用）"
+// @Success 200 {object} model.TenantSettingResp
+// @Router /api/v1/tenant_settings [get]
+func (*TenantSettingApi) GetTenantSetting(c *gin.Context) {
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	tenantID := c.Query("tenant_id")
+
+	data, err := service.GroupApp.TenantSetting.Get(c.Request.Context(), claims, tenantID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// UpdateTenantSetting 更新租户设置
+// @Summary 更新租户设置（时区、双因素认证策略；仅更新传入的字段，时区传空字符串表示清除）
+// @Tags 租户设置
+// @Accept json
+// @Produce json
+// @Param tenant_id query string false "租户ID（仅SYS_ADMIN可用）"
+// @Param body body model.TenantSettingUpdateReq true "租户设置"
+// @Success 200 {object} model.TenantSettingResp
+// @Router /api/v1/tenant_settings [put]
+func (*TenantSettingApi) UpdateTenantSetting(c *gin.Context) {
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	tenantID := c.Query("tenant_id")
+
+	var req model.TenantSettingUpdateReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+
+	data, err := service.GroupApp.TenantSetting.Update(c.Request.Context(), claims, tenantID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
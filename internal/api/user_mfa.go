@@ -0,0 +1,172 @@
+package api
+
+import (
+	model "project/internal/model"
+	service "project/internal/service"
+	utils "project/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UserMfaApi struct{}
+
+// VerifyLoginMfa 登录第二步：校验动态验证码或恢复码
+// @Router   /api/v1/login/mfa/verify [post]
+func (*UserMfaApi) VerifyLoginMfa(c *gin.Context) {
+	var req model.MfaVerifyReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	data, err := service.GroupApp.UserMfa.VerifyLogin(c.Request.Context(), &req, requestMeta(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// LoginMfaSetup 租户强制启用但尚未绑定：获取绑定密钥
+// @Router   /api/v1/login/mfa/setup [post]
+func (*UserMfaApi) LoginMfaSetup(c *gin.Context) {
+	var req model.MfaLoginSetupReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	data, err := service.GroupApp.UserMfa.LoginSetup(c.Request.Context(), &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// LoginMfaEnable 租户强制启用但尚未绑定：确认绑定并登录
+// @Router   /api/v1/login/mfa/enable [post]
+func (*UserMfaApi) LoginMfaEnable(c *gin.Context) {
+	var req model.MfaLoginEnableReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	data, err := service.GroupApp.UserMfa.LoginEnable(c.Request.Context(), &req, requestMeta(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// HandleMfaStatus 当前账号的双因素认证状态
+// @Router   /api/v1/user/mfa [get]
+func (*UserMfaApi) HandleMfaStatus(c *gin.Context) {
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.UserMfa.GetStatus(c.Request.Context(), claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// SetupMfa 开始绑定身份验证器
+// @Router   /api/v1/user/mfa/setup [post]
+func (*UserMfaApi) SetupMfa(c *gin.Context) {
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.UserMfa.Setup(c.Request.Context(), claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// EnableMfa 确认绑定，返回恢复码
+// @Router   /api/v1/user/mfa/enable [post]
+func (*UserMfaApi) EnableMfa(c *gin.Context) {
+	var req model.MfaCodeReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.UserMfa.Enable(c.Request.Context(), &req, claims, requestMeta(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// DisableMfa 关闭双因素认证
+// @Router   /api/v1/user/mfa/disable [post]
+func (*UserMfaApi) DisableMfa(c *gin.Context) {
+	var req model.MfaDisableReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	if err := service.GroupApp.UserMfa.Disable(c.Request.Context(), &req, claims, requestMeta(c)); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", nil)
+}
+
+// RegenerateMfaRecoveryCodes 重新生成恢复码
+// @Router   /api/v1/user/mfa/recovery_codes [post]
+func (*UserMfaApi) RegenerateMfaRecoveryCodes(c *gin.Context) {
+	var req model.MfaCodeReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.UserMfa.RegenerateRecoveryCodes(c.Request.Context(), &req, claims, requestMeta(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// HandleTrustedDevices 当前账号的受信任设备
+// @Router   /api/v1/user/mfa/trusted_devices [get]
+func (*UserMfaApi) HandleTrustedDevices(c *gin.Context) {
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.UserMfa.ListTrustedDevices(c.Request.Context(), claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// RevokeTrustedDevice 撤销指定受信任设备
+// @Router   /api/v1/user/mfa/trusted_devices/{id} [delete]
+func (*UserMfaApi) RevokeTrustedDevice(c *gin.Context) {
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	if err := service.GroupApp.UserMfa.RevokeTrustedDevices(c.Request.Context(), c.Param("id"), claims, requestMeta(c)); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", nil)
+}
+
+// RevokeAllTrustedDevices 撤销全部受信任设备
+// @Router   /api/v1/user/mfa/trusted_devices [delete]
+func (*UserMfaApi) RevokeAllTrustedDevices(c *gin.Context) {
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	if err := service.GroupApp.UserMfa.RevokeTrustedDevices(c.Request.Context(), "", claims, requestMeta(c)); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", nil)
+}
+
+// ResetUserMfa 管理员重置用户的双因素认证
+// @Router   /api/v1/user/{id}/mfa/reset [post]
+func (*UserMfaApi) ResetUserMfa(c *gin.Context) {
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	if err := service.GroupApp.UserMfa.ResetUserMfa(c.Request.Context(), c.Param("id"), claims, requestMeta(c)); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", nil)
+}
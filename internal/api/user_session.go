@@ -0,0 +1,92 @@
+package api
+
+import (
+	model "project/internal/model"
+	service "project/internal/service"
+	utils "project/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UserSessionApi struct{}
+
+// requestMeta 会话、审计日志与受信任设备所需的请求信息
+func requestMeta(c *gin.Context) *model.RequestMeta {
+	return &model.RequestMeta{
+		IP:        c.ClientIP(),
+		UserAgent: c.GetHeader("User-Agent"),
+		Path:      c.Request.URL.Path,
+	}
+}
+
+// RefreshSession 用刷新令牌换取新的访问令牌（刷新令牌同时轮换）
+// @Router   /api/v1/login/refresh [post]
+func (*UserSessionApi) RefreshSession(c *gin.Context) {
+	var req model.RefreshTokenReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	data, err := service.GroupApp.UserSession.Refresh(c.Request.Context(), &req, requestMeta(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// HandleSessions 当前用户的登录会话
+// @Router   /api/v1/user/sessions [get]
+func (*UserSessionApi) HandleSessions(c *gin.Context) {
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.UserSession.ListSessions(c.Request.Context(), claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// RevokeSession 撤销当前用户的指定会话
+// @Router   /api/v1/user/sessions/{id} [delete]
+func (*UserSessionApi) RevokeSession(c *gin.Context) {
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	if err := service.GroupApp.UserSession.RevokeSession(c.Request.Context(), c.Param("id"), claims, requestMeta(c)); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", nil)
+}
+
+// RevokeOtherSessions 撤销当前用户除当前会话外的全部会话
+// @Router   /api/v1/user/sessions [delete]
+func (*UserSessionApi) RevokeOtherSessions(c *gin.Context) {
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	if err := service.GroupApp.UserSession.RevokeOtherSessions(c.Request.Context(), claims, requestMeta(c)); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", nil)
+}
+
+// HandleUserSessions 管理员查看用户的登录会话
+// @Router   /api/v1/user/{id}/sessions [get]
+func (*UserSessionApi) HandleUserSessions(c *gin.Context) {
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.UserSession.ListUserSessions(c.Request.Context(), c.Param("id"), claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// ForceLogout 管理员强制用户下线
+// @Router   /api/v1/user/{id}/force_logout [post]
+func (*UserSessionApi) ForceLogout(c *gin.Context) {
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	if err := service.GroupApp.UserSession.ForceLogout(c.Request.Context(), c.Param("id"), claims, requestMeta(c)); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", nil)
+}
@@ -116,3 +116,90 @@ func (*WarrantyApi) GetWarrantyDetail(c *gin.Context) {
 	c.Set("data", data)
 }
 
+
+// EvaluateWarrantyApplication 重新评估质保资格
+// @Summary 重新评估质保资格
+// @Description 按电池型号规则重新检查质保期、激活、循环次数、SOH及滥用迹象，生成证据报告
+// @Tags Warranty
+// @Produce json
+// @Param id path string true "维保申请ID"
+// @Success 200 {object} model.WarrantyEvaluationReport
+// @Router /api/v1/warranty/{id}/evaluate [post]
+func (*WarrantyApi) EvaluateWarrantyApplication(c *gin.Context) {
+	id := c.Param("id")
+	userClaims := c.MustGet("claims").(*utils.UserClaims)
+
+	data, err := service.GroupApp.Warranty.EvaluateWarrantyApplication(id, userClaims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Set("data", data)
+}
+
+// ListEligibilityRules 获取质保评估规则列表
+// @Summary 获取质保评估规则列表
+// @Description 按电池型号列出质保资格评估规则，未配置的型号返回默认规则
+// @Tags Warranty
+// @Produce json
+// @Success 200 {array} model.WarrantyEligibilityRuleResp
+// @Router /api/v1/warranty/eligibility-rules [get]
+func (*WarrantyApi) ListEligibilityRules(c *gin.Context) {
+	userClaims := c.MustGet("claims").(*utils.UserClaims)
+
+	data, err := service.GroupApp.Warranty.ListEligibilityRules(userClaims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Set("data", data)
+}
+
+// UpsertEligibilityRule 配置电池型号质保评估规则
+// @Summary 配置电池型号质保评估规则
+// @Description 设置指定电池型号的质保评估阈值，未传字段沿用当前值
+// @Tags Warranty
+// @Accept json
+// @Produce json
+// @Param battery_model_id path string true "电池型号ID"
+// @Param body body model.WarrantyEligibilityRuleUpsertReq true "规则"
+// @Success 200 {object} model.WarrantyEligibilityRuleResp
+// @Router /api/v1/warranty/eligibility-rules/{battery_model_id} [put]
+func (*WarrantyApi) UpsertEligibilityRule(c *gin.Context) {
+	batteryModelID := c.Param("battery_model_id")
+	var req model.WarrantyEligibilityRuleUpsertReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+
+	userClaims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.Warranty.UpsertEligibilityRule(batteryModelID, req, userClaims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Set("data", data)
+}
+
+// DeleteEligibilityRule 删除电池型号质保评估规则
+// @Summary 删除电池型号质保评估规则
+// @Description 删除后该型号恢复使用默认规则
+// @Tags Warranty
+// @Produce json
+// @Param battery_model_id path string true "电池型号ID"
+// @Success 200 {object} model.Response
+// @Router /api/v1/warranty/eligibility-rules/{battery_model_id} [delete]
+func (*WarrantyApi) DeleteEligibilityRule(c *gin.Context) {
+	batteryModelID := c.Param("battery_model_id")
+	userClaims := c.MustGet("claims").(*utils.UserClaims)
+
+	if err := service.GroupApp.Warranty.DeleteEligibilityRule(batteryModelID, userClaims); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Set("data", nil)
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	model "project/internal/model"
+	service "project/internal/service"
+	utils "project/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookSubscriptionApi struct{}
+
+// CreateWebhookSubscription 新建Webhook订阅
+// @Router   /api/v1/webhook_subscription [post]
+func (*WebhookSubscriptionApi) CreateWebhookSubscription(c *gin.Context) {
+	var req model.CreateWebhookSubscriptionReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.WebhookSubscription.CreateWebhookSubscription(c.Request.Context(), &req, claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// UpdateWebhookSubscription 修改Webhook订阅
+// @Router   /api/v1/webhook_subscription/{id} [put]
+func (*WebhookSubscriptionApi) UpdateWebhookSubscription(c *gin.Context) {
+	var req model.UpdateWebhookSubscriptionReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.WebhookSubscription.UpdateWebhookSubscription(c.Request.Context(), c.Param("id"), &req, claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// DeleteWebhookSubscription 删除Webhook订阅
+// @Router   /api/v1/webhook_subscription/{id} [delete]
+func (*WebhookSubscriptionApi) DeleteWebhookSubscription(c *gin.Context) {
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	if err := service.GroupApp.WebhookSubscription.DeleteWebhookSubscription(c.Request.Context(), c.Param("id"), claims); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", nil)
+}
+
+// HandleWebhookSubscriptionListByPage Webhook订阅列表
+// @Router   /api/v1/webhook_subscription/list [get]
+func (*WebhookSubscriptionApi) HandleWebhookSubscriptionListByPage(c *gin.Context) {
+	var req model.GetWebhookSubscriptionListByPageReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.WebhookSubscription.GetWebhookSubscriptionListByPage(c.Request.Context(), &req, claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// HandleWebhookEventTypes 可订阅的事件类型
+// @Router   /api/v1/webhook_subscription/event_types [get]
+func (*WebhookSubscriptionApi) HandleWebhookEventTypes(c *gin.Context) {
+	c.Set("data", service.GroupApp.WebhookSubscription.GetWebhookEventTypes())
+}
+
+// TestWebhookSubscription 发送测试事件
+// @Router   /api/v1/webhook_subscription/{id}/test [post]
+func (*WebhookSubscriptionApi) TestWebhookSubscription(c *gin.Context) {
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.WebhookSubscription.TestWebhookSubscription(c.Request.Context(), c.Param("id"), claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// HandleWebhookDeliveryListByPage Webhook投递记录列表
+// @Router   /api/v1/webhook_subscription/deliveries [get]
+func (*WebhookSubscriptionApi) HandleWebhookDeliveryListByPage(c *gin.Context) {
+	var req model.GetWebhookDeliveryListByPageReq
+	if !BindAndValidate(c, &req) {
+		return
+	}
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.WebhookSubscription.GetWebhookDeliveryListByPage(c.Request.Context(), &req, claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
+
+// RedeliverWebhookDelivery 手动重新投递
+// @Router   /api/v1/webhook_subscription/deliveries/{id}/redeliver [post]
+func (*WebhookSubscriptionApi) RedeliverWebhookDelivery(c *gin.Context) {
+	claims := c.MustGet("claims").(*utils.UserClaims)
+	data, err := service.GroupApp.WebhookSubscription.RedeliverWebhookDelivery(c.Request.Context(), c.Param("id"), claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", data)
+}
@@ -8,7 +8,6 @@ import (
 	model "project/internal/model"
 	query "project/internal/query"
 	"project/pkg/global"
-	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -233,6 +232,14 @@ func GetAlarmHistoryListByPage(d *model.GetAlarmHisttoryListByPage, tenantID str
 		queryBuilder = queryBuilder.Where(query.AlarmConfig.AlarmLevel.Eq(*d.AlarmLevel))
 	}
 
+	if d.LifecycleState != nil && *d.LifecycleState != "" {
+		queryBuilder = queryBuilder.Where(q.LifecycleState.Eq(*d.LifecycleState))
+	}
+
+	if d.AssigneeID != nil && *d.AssigneeID != "" {
+		queryBuilder = queryBuilder.Where(q.AssigneeID.Eq(*d.AssigneeID))
+	}
+
 	if d.DeviceId != nil && *d.DeviceId != "" {
 		//queryBuilder = queryBuilder.Where(q.AlarmDeviceList.Like(fmt.Sprintf("%%%s%%", *d.DeviceId)))
 		queryBuilder = queryBuilder.Where(gen.Cond(datatypes.JSONQuery("alarm_device_list").HasKey(*d.DeviceId))...)
@@ -275,27 +282,6 @@ func AlarmHistorySave(history *model.AlarmHistory) error {
 	return query.AlarmHistory.Save(history)
 }
 
-func HandleAlarmHistory(req *model.AlarmHistoryHandleReq, tenantID string, userID string) error {
-	updates := map[string]interface{}{
-		"alarm_status": "N",
-		"processed_at": time.Now().UTC(),
-		"processed_by": userID,
-	}
-	if req.ProcessingRemark != nil {
-		updates["processing_remark"] = strings.TrimSpace(*req.ProcessingRemark)
-	}
-	result, err := query.AlarmHistory.Where(
-		query.AlarmHistory.ID.Eq(req.Id),
-		query.AlarmHistory.TenantID.Eq(tenantID),
-	).Updates(updates)
-	if err != nil {
-		return err
-	}
-	if result.RowsAffected == 0 {
-		return errors.New("处理告警失败")
-	}
-	return nil
-}
 func AlarmHistoryDescUpdate(req *model.AlarmHistoryDescUpdateReq, tenantID string) error {
 	result, err := query.AlarmHistory.Where(query.AlarmHistory.ID.Eq(req.AlarmHistoryId), query.AlarmHistory.TenantID.Eq(tenantID)).UpdateColumn(query.AlarmHistory.Description, req.Description)
 	if err != nil {
@@ -79,6 +79,12 @@ func GetUserMessagePushId(tenantId string) ([]model.MessagePushManage, error) {
 		Where(query.User.TenantID.Eq(tenantId)).Distinct(query.MessagePushManage.PushID).Select(query.MessagePushManage.ALL).Scan(&result)
 }
 
+// GetMessagePushIdByUserIds 指定用户的有效推送id
+func GetMessagePushIdByUserIds(userIds []string) ([]*model.MessagePushManage, error) {
+	q := query.MessagePushManage
+	return q.Where(q.UserID.In(userIds...), q.DeleteTime.IsNull(), q.Status.Eq(1)).Find()
+}
+
 func MessagePushSendLogSave(log *model.MessagePushLog) error {
 	return query.MessagePushLog.Save(log)
 }
@@ -91,20 +91,25 @@ func UpdateOpenAPIKey(id string, updates map[string]interface{}) error {
 
 // DeleteOpenAPIKey 删除OpenAPI密钥
 // param id 密钥ID
-// @note 删除时会同时清理Redis缓存
+// @note 删除时会同时清理Redis缓存（含轮换宽限期内的旧密钥）
 func DeleteOpenAPIKey(id string) error {
+	key, err := GetOpenAPIKeyByID(id)
+	if err != nil {
+		return err
+	}
+
 	// 删除数据库记录
-	_, err := query.OpenAPIKey.Where(query.OpenAPIKey.ID.Eq(id)).Delete()
+	_, err = query.OpenAPIKey.Where(query.OpenAPIKey.ID.Eq(id)).Delete()
 	if err != nil {
 		return err
 	}
 
 	// 清理缓存
-	cacheKey := "openapi:key:" + id
-	err = global.REDIS.Del(context.Background(), cacheKey).Err()
-	if err != nil {
-		logrus.Warnf("删除OpenAPI密钥缓存失败: %v", err)
+	apiKeys := []string{key.APIKey}
+	if key.PreviousAPIKey != nil {
+		apiKeys = append(apiKeys, *key.PreviousAPIKey)
 	}
+	DeleteOpenAPIKeyCache(context.Background(), apiKeys...)
 
 	return nil
 }
@@ -130,32 +135,39 @@ func (OpenAPIKeyQuery) Select(ctx context.Context, option ...gen.Condition) (lis
 	return
 }
 
-// 验证OpenAPI密钥是否有效并返回租户ID
-// Redis缓存结构 key: "apikey:{api_key}" value: tenantID
-// 有效期为1小时
-func VerifyOpenAPIKey(ctx context.Context, appKey string) (string, string, error) {
-	// 从Redis缓存中获取租户ID
-	cacheKey := "apikey:" + appKey
-	cacheKeyCreatedID := "apikey:createdid:" + appKey
-	tenantID, err := global.REDIS.Get(ctx, cacheKey).Result()
-	createdID, err1 := global.REDIS.Get(ctx, cacheKeyCreatedID).Result()
-	if err != nil || err1 != nil {
-		// 如果缓存中不存在，则从数据库中查询
-		apiKey, err := query.OpenAPIKey.WithContext(ctx).Where(query.OpenAPIKey.APIKey.Eq(appKey), query.OpenAPIKey.Status.Eq(1)).First()
-		if err != nil {
-			return "", "", err
-		}
-		// 将查询结果存入Redis缓存，有效期为1小时
-		tenantID = apiKey.TenantID
-		createdID = *apiKey.CreatedID
-		err = global.REDIS.Set(ctx, cacheKey, tenantID, time.Hour).Err()
-		if err != nil {
-			logrus.Warnf("设置OpenAPI密钥缓存失败: %v", err)
-		}
-		err = global.REDIS.Set(ctx, cacheKeyCreatedID, createdID, time.Hour).Err()
-		if err != nil {
-			logrus.Warnf("设置OpenAPI密钥创建者ID缓存失败: %v", err)
+// OpenAPI密钥缓存与调用计量的Redis键
+// 密钥信息 key: "apikey:info:{api_key}"
+// 当日计量 key: "apikey:usage:{key_id}|{tenant_id}|{date}" hash: total/rejected/last_used_at/last_used_ip
+// 待落库集合 key: "apikey:usage:dirty" member: "{key_id}|{tenant_id}|{date}"
+const OpenAPIKeyUsageDirtySet = "apikey:usage:dirty"
+
+// OpenAPIKeyCacheKey 密钥信息缓存键
+func OpenAPIKeyCacheKey(apiKey string) string {
+	return "apikey:info:" + apiKey
+}
+
+// OpenAPIKeyUsageMember 计量待落库成员，日期按UTC
+func OpenAPIKeyUsageMember(keyID, tenantID string, t time.Time) string {
+	return keyID + "|" + tenantID + "|" + t.UTC().Format("2006-01-02")
+}
+
+// OpenAPIKeyUsageKey 计量计数键
+func OpenAPIKeyUsageKey(member string) string {
+	return "apikey:usage:" + member
+}
+
+// DeleteOpenAPIKeyCache 清理密钥信息缓存，修改、轮换、删除密钥后调用
+func DeleteOpenAPIKeyCache(ctx context.Context, apiKeys ...string) {
+	keys := make([]string, 0, len(apiKeys))
+	for _, k := range apiKeys {
+		if k != "" {
+			keys = append(keys, OpenAPIKeyCacheKey(k))
 		}
 	}
-	return tenantID, createdID, nil
+	if len(keys) == 0 {
+		return
+	}
+	if err := global.REDIS.Del(ctx, keys...).Err(); err != nil {
+		logrus.Warnf("删除OpenAPI密钥缓存失败: %v", err)
+	}
 }
@@ -38,7 +38,9 @@ func DeletePeriodicTask(sceneAutomationId string, tx *query.QueryTx) error {
 	}
 }
 
-func GetPeriodicTaskListWithLock(limit int) ([]*model.PeriodicTask, error) {
+// GetPeriodicTaskListWithLock 获取到期的周期任务并推进下次执行时间
+// locOf 返回场景联动的时区（nil 表示沿用服务器时区）
+func GetPeriodicTaskListWithLock(limit int, locOf func(sceneAutomationID string) *time.Location) ([]*model.PeriodicTask, error) {
 	key := "irrigation-iot-platform:periodicTask"
 	if !common.AcquireLock(key, time.Second*5) {
 		return nil, errors.New("未获取到锁")
@@ -54,7 +56,7 @@ func GetPeriodicTaskListWithLock(limit int) ([]*model.PeriodicTask, error) {
 	}
 	var executeResult []*model.PeriodicTask
 	for _, v := range result {
-		nextExecuteTime, err := common.GetSceneExecuteTime(v.TaskType, v.Param)
+		nextExecuteTime, err := common.GetSceneExecuteTimeIn(v.TaskType, v.Param, locOf(v.SceneAutomationID), time.Now())
 		if err != nil {
 			return result, err
 		}
@@ -5,10 +5,17 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
+	"strconv"
+	"strings"
 	"time"
 
+	"project/internal/dal"
+	"project/internal/model"
+
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
@@ -16,19 +23,31 @@ var (
 	ErrInvalidAPIKey  = errors.New("invalid api key")
 	ErrAPIKeyDisabled = errors.New("api key is disabled")
 	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrAPIKeyExpired  = errors.New("api key has expired")
 	ErrInternalServer = errors.New("internal server error")
 )
 
+// apiKeyCacheTTL APIKey信息缓存时间
+const apiKeyCacheTTL = 5 * time.Minute
+
 // APIKey数据模型
 type OpenAPIKey struct {
-	ID        string    `gorm:"column:id;primary_key"`
-	TenantID  string    `gorm:"column:tenant_id"`
-	APIKey    string    `gorm:"column:api_key"`
-	Status    int       `gorm:"column:status"`
-	Name      string    `gorm:"column:name"`
-	CreatedID string    `gorm:"column:created_id"`
-	CreatedAt time.Time `gorm:"column:created_at"`
-	UpdatedAt time.Time `gorm:"column:updated_at"`
+	ID                   string     `gorm:"column:id;primary_key"`
+	TenantID             string     `gorm:"column:tenant_id"`
+	APIKey               string     `gorm:"column:api_key"`
+	Status               int        `gorm:"column:status"`
+	Name                 string     `gorm:"column:name"`
+	CreatedID            string     `gorm:"column:created_id"`
+	Scopes               string     `gorm:"column:scopes"`
+	OrgID                *string    `gorm:"column:org_id"`
+	DeviceGroupID        *string    `gorm:"column:device_group_id"`
+	IPAllowlist          *string    `gorm:"column:ip_allowlist"`
+	ExpiresAt            *time.Time `gorm:"column:expires_at"`
+	RateLimit            *int       `gorm:"column:rate_limit"`
+	PreviousAPIKey       *string    `gorm:"column:previous_api_key"`
+	PreviousKeyExpiresAt *time.Time `gorm:"column:previous_key_expires_at"`
+	CreatedAt            time.Time  `gorm:"column:created_at"`
+	UpdatedAt            time.Time  `gorm:"column:updated_at"`
 }
 
 func (OpenAPIKey) TableName() string {
@@ -37,11 +56,76 @@ func (OpenAPIKey) TableName() string {
 
 // Redis缓存的APIKey信息
 type APIKeyInfo struct {
-	ID        string `json:"id"`
-	TenantID  string `json:"tenant_id"`
-	Status    int    `json:"status"`
-	Name      string `json:"name"`
-	CreatedID string `json:"created_id"`
+	ID            string     `json:"id"`
+	TenantID      string     `json:"tenant_id"`
+	Status        int        `json:"status"`
+	Name          string     `json:"name"`
+	CreatedID     string     `json:"created_id"`
+	Scopes        []string   `json:"scopes"`                    // 权限范围，* 为全部
+	OrgID         string     `json:"org_id,omitempty"`          // 限制访问的组织（含下级）
+	DeviceGroupID string     `json:"device_group_id,omitempty"` // 限制访问的设备分组（含子分组）
+	IPAllowlist   []string   `json:"ip_allowlist,omitempty"`    // 允许调用的IP或CIDR
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`      // 过期时间
+	RateLimit     int        `json:"rate_limit,omitempty"`      // 每分钟请求上限
+	// GraceExpiresAt 使用轮换前的旧密钥认证时，旧密钥的失效时间
+	GraceExpiresAt *time.Time `json:"grace_expires_at,omitempty"`
+}
+
+// HasScope 是否拥有指定权限范围
+func (info *APIKeyInfo) HasScope(scope string) bool {
+	for _, s := range info.Scopes {
+		if s == model.APIKeyScopeAll || s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// FullAccess 是否为全部权限（兼容旧密钥）
+func (info *APIKeyInfo) FullAccess() bool {
+	return info.HasScope(model.APIKeyScopeAll)
+}
+
+// Restricted 是否限制了组织或设备分组
+func (info *APIKeyInfo) Restricted() bool {
+	return info.OrgID != "" || info.DeviceGroupID != ""
+}
+
+// AllowIP 调用方IP是否在白名单内，白名单为空不限制
+func (info *APIKeyInfo) AllowIP(ip string) bool {
+	if len(info.IPAllowlist) == 0 {
+		return true
+	}
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, entry := range info.IPAllowlist {
+		if strings.Contains(entry, "/") {
+			if _, cidr, err := net.ParseCIDR(entry); err == nil && cidr.Contains(addr) {
+				return true
+			}
+			continue
+		}
+		if allowed := net.ParseIP(entry); allowed != nil && allowed.Equal(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkValid 检查状态与有效期
+func (info *APIKeyInfo) checkValid(now time.Time) error {
+	if info.Status != 1 {
+		return ErrAPIKeyDisabled
+	}
+	if info.ExpiresAt != nil && !now.Before(*info.ExpiresAt) {
+		return ErrAPIKeyExpired
+	}
+	if info.GraceExpiresAt != nil && !now.Before(*info.GraceExpiresAt) {
+		return ErrAPIKeyExpired
+	}
+	return nil
 }
 
 // APIKey验证器
@@ -60,21 +144,49 @@ func NewAPIKeyValidator(db *gorm.DB, redisClient *redis.Client) *APIKeyValidator
 	}
 }
 
+// newAPIKeyInfo 数据库记录转为缓存信息
+func newAPIKeyInfo(key *OpenAPIKey, apiKey string) *APIKeyInfo {
+	info := &APIKeyInfo{
+		ID:        key.ID,
+		TenantID:  key.TenantID,
+		Status:    key.Status,
+		Name:      key.Name,
+		CreatedID: key.CreatedID,
+		ExpiresAt: key.ExpiresAt,
+	}
+	if err := json.Unmarshal([]byte(key.Scopes), &info.Scopes); err != nil || key.Scopes == "" {
+		info.Scopes = []string{model.APIKeyScopeAll}
+	}
+	if key.OrgID != nil {
+		info.OrgID = *key.OrgID
+	}
+	if key.DeviceGroupID != nil {
+		info.DeviceGroupID = *key.DeviceGroupID
+	}
+	if key.IPAllowlist != nil {
+		_ = json.Unmarshal([]byte(*key.IPAllowlist), &info.IPAllowlist)
+	}
+	if key.RateLimit != nil {
+		info.RateLimit = *key.RateLimit
+	}
+	if key.APIKey != apiKey {
+		info.GraceExpiresAt = key.PreviousKeyExpiresAt
+	}
+	return info
+}
+
 // 验证APIKey
 func (v *APIKeyValidator) ValidateAPIKey(apiKey string) (*APIKeyInfo, error) {
+	now := time.Now()
 	// 1. 从Redis缓存中获取
 	info, err := v.getFromCache(apiKey)
 	if err == nil {
-		// 检查状态
-		if info.Status != 1 {
-			return nil, ErrAPIKeyDisabled
-		}
-		return info, nil
+		return info, info.checkValid(now)
 	}
 
-	// 2. 缓存未命中,从数据库查询
+	// 2. 缓存未命中,从数据库查询（轮换宽限期内旧密钥仍有效）
 	var key OpenAPIKey
-	err = v.db.Where("api_key = ?", apiKey).First(&key).Error
+	err = v.db.Where("api_key = ? OR (previous_api_key = ? AND previous_key_expires_at > ?)", apiKey, apiKey, now).First(&key).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrAPIKeyNotFound
@@ -82,33 +194,22 @@ func (v *APIKeyValidator) ValidateAPIKey(apiKey string) (*APIKeyInfo, error) {
 		return nil, fmt.Errorf("query database error: %w", err)
 	}
 
-	// 3. 检查APIKey状态
-	if key.Status != 1 {
-		return nil, ErrAPIKeyDisabled
-	}
+	// 3. 构造缓存信息
+	info = newAPIKeyInfo(&key, apiKey)
 
-	// 4. 构造缓存信息
-	info = &APIKeyInfo{
-		ID:        key.ID,
-		TenantID:  key.TenantID,
-		Status:    key.Status,
-		Name:      key.Name,
-		CreatedID: key.CreatedID,
-	}
-
-	// 5. 更新缓存
+	// 4. 更新缓存
 	if err := v.setCache(apiKey, info); err != nil {
 		// 缓存更新失败仅记录日志,不影响验证结果
-		fmt.Printf("update cache error: %v\n", err)
+		logrus.Warnf("update api key cache error: %v", err)
 	}
 
-	return info, nil
+	// 5. 检查APIKey状态与有效期
+	return info, info.checkValid(now)
 }
 
 // 从缓存获取APIKey信息
 func (v *APIKeyValidator) getFromCache(apiKey string) (*APIKeyInfo, error) {
-	key := fmt.Sprintf("apikey:%s", apiKey)
-	data, err := v.redisClient.Get(v.ctx, key).Result()
+	data, err := v.redisClient.Get(v.ctx, dal.OpenAPIKeyCacheKey(apiKey)).Result()
 	if err != nil {
 		return nil, err
 	}
@@ -123,48 +224,101 @@ func (v *APIKeyValidator) getFromCache(apiKey string) (*APIKeyInfo, error) {
 
 // 设置APIKey缓存
 func (v *APIKeyValidator) setCache(apiKey string, info *APIKeyInfo) error {
-	key := fmt.Sprintf("apikey:%s", apiKey)
 	data, err := json.Marshal(info)
 	if err != nil {
 		return err
 	}
 
 	// 设置5分钟过期
-	return v.redisClient.Set(v.ctx, key, data, 5*time.Minute).Err()
+	return v.redisClient.Set(v.ctx, dal.OpenAPIKeyCacheKey(apiKey), data, apiKeyCacheTTL).Err()
 }
 
 // 删除APIKey缓存
 func (v *APIKeyValidator) DeleteCache(apiKey string) error {
-	key := fmt.Sprintf("apikey:%s", apiKey)
-	return v.redisClient.Del(v.ctx, key).Err()
+	return v.redisClient.Del(v.ctx, dal.OpenAPIKeyCacheKey(apiKey)).Err()
 }
 
-// 中间件示例用法
-func ValidateAPIKeyMiddleware(validator *APIKeyValidator) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		apiKey := c.GetHeader("X-API-Key")
-		if apiKey == "" {
-			c.JSON(401, gin.H{"code": 40001, "message": "Missing API Key"})
-			c.Abort()
-			return
+// AllowRequest 按分钟固定窗口限流，返回窗口内剩余次数
+func (v *APIKeyValidator) AllowRequest(info *APIKeyInfo, now time.Time) (int, bool) {
+	if info.RateLimit <= 0 {
+		return 0, true
+	}
+	key := "apikey:rate:" + info.ID + ":" + strconv.FormatInt(now.Unix()/60, 10)
+	pipe := v.redisClient.TxPipeline()
+	incr := pipe.Incr(v.ctx, key)
+	pipe.Expire(v.ctx, key, 2*time.Minute)
+	if _, err := pipe.Exec(v.ctx); err != nil {
+		// 限流依赖Redis，Redis异常时放行
+		logrus.Warnf("api key rate limit error: %v", err)
+		return info.RateLimit, true
+	}
+	count := int(incr.Val())
+	return info.RateLimit - count, count <= info.RateLimit
+}
+
+// RecordUsage 记录调用计量，由定时任务汇总入库
+func (v *APIKeyValidator) RecordUsage(info *APIKeyInfo, ip string, rejected bool, now time.Time) {
+	member := dal.OpenAPIKeyUsageMember(info.ID, info.TenantID, now)
+	key := dal.OpenAPIKeyUsageKey(member)
+	field := "total"
+	if rejected {
+		field = "rejected"
+	}
+	pipe := v.redisClient.Pipeline()
+	pipe.HIncrBy(v.ctx, key, field, 1)
+	pipe.HSet(v.ctx, key, "last_used_at", now.Unix(), "last_used_ip", ip)
+	pipe.Expire(v.ctx, key, 72*time.Hour)
+	pipe.SAdd(v.ctx, dal.OpenAPIKeyUsageDirtySet, member)
+	if _, err := pipe.Exec(v.ctx); err != nil {
+		logrus.Warnf("record api key usage error: %v", err)
+	}
+}
+
+// CanAccessDevices 设备是否都在密钥限制的组织子树与设备分组内
+func (v *APIKeyValidator) CanAccessDevices(info *APIKeyInfo, deviceIDs []string) (bool, error) {
+	ids := make([]string, 0, len(deviceIDs))
+	seen := make(map[string]bool, len(deviceIDs))
+	for _, id := range deviceIDs {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return false, nil
+	}
+	var count int64
+	if err := v.db.Table("devices").Where("tenant_id = ? AND id IN ?", info.TenantID, ids).Count(&count).Error; err != nil || count != int64(len(ids)) {
+		return false, err
+	}
+	if info.OrgID != "" {
+		if err := v.db.Table("device_batteries").
+			Where("device_id IN ?", ids).
+			Where("(owner_org_id = ? OR owner_org_id IN (SELECT descendant_id FROM org_closure WHERE tenant_id = ? AND ancestor_id = ?))", info.OrgID, info.TenantID, info.OrgID).
+			Count(&count).Error; err != nil || count != int64(len(ids)) {
+			return false, err
 		}
+	}
+	if info.DeviceGroupID != "" {
+		if err := v.db.Raw(`WITH RECURSIVE sub AS (
+				SELECT id FROM "groups" WHERE id = ? AND tenant_id = ?
+				UNION
+				SELECT g.id FROM "groups" g JOIN sub ON g.parent_id = sub.id
+			)
+			SELECT COUNT(DISTINCT device_id) FROM r_group_device WHERE device_id IN ? AND group_id IN (SELECT id FROM sub)`,
+			info.DeviceGroupID, info.TenantID, ids).Scan(&count).Error; err != nil || count != int64(len(ids)) {
+			return false, err
+		}
+	}
+	return true, nil
+}
 
-		info, err := validator.ValidateAPIKey(apiKey)
-		if err != nil {
-			switch err {
-			case ErrAPIKeyNotFound:
-				c.JSON(401, gin.H{"code": 40001, "message": "Invalid API Key"})
-			case ErrAPIKeyDisabled:
-				c.JSON(401, gin.H{"code": 40002, "message": "API Key Disabled"})
-			default:
-				c.JSON(500, gin.H{"code": 50001, "message": "Internal Server Error"})
-			}
-			c.Abort()
+// ValidateAPIKeyMiddleware 校验 X-API-Key：有效期、IP白名单、限流、权限范围与组织/设备分组限制
+func ValidateAPIKeyMiddleware(validator *APIKeyValidator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !authorizeAPIKey(c, validator, c.GetHeader("X-API-Key")) {
 			return
 		}
-
-		// 将验证信息存入上下文
-		c.Set("apikey_info", info)
 		c.Next()
 	}
 }
@@ -0,0 +1,285 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"project/internal/model"
+	"project/pkg/global"
+	utils "project/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// APIKeyInfoContextKey 在 Gin 上下文中存储APIKey信息的键
+const APIKeyInfoContextKey = "apikey_info"
+
+// apiKeyBodyMax 读取请求体中设备ID时的最大字节数
+const apiKeyBodyMax = 1 << 20
+
+// apiKeyRoute 接口与权限范围的对应关系
+type apiKeyRoute struct {
+	prefix      string // 路由模板前缀（按路径段匹配）
+	write       bool   // false-GET/HEAD true-其他方法
+	scope       string // 所需权限范围
+	deviceParam string // 路径中表示设备ID的参数名
+	deviceField bool   // 路径中没有设备ID时，由查询参数或 JSON 请求体中的 device_id/device_ids 指定目标设备
+	orgScoped   bool   // 接口已按组织数据权限过滤（BMS模块）
+}
+
+// apiKeyRoutes 按前缀从具体到一般排列，首个匹配的规则生效；未匹配的接口仅全部权限的密钥可访问。
+// 限制了组织或设备分组的密钥只能访问声明了目标设备（deviceParam/deviceField）或已按组织过滤的接口
+var apiKeyRoutes = []apiKeyRoute{
+	// 下发指令
+	{prefix: "/api/v1/telemetry/datas/pub", write: true, scope: model.APIKeyScopeCommandSend, deviceField: true},
+	{prefix: "/api/v1/attribute/datas/pub", write: true, scope: model.APIKeyScopeCommandSend, deviceField: true},
+	{prefix: "/api/v1/attribute/datas/get", scope: model.APIKeyScopeCommandSend, deviceField: true},
+	{prefix: "/api/v1/command/datas/pub", write: true, scope: model.APIKeyScopeCommandSend, deviceField: true},
+	{prefix: "/api/v1/expected/data/:id", write: true, scope: model.APIKeyScopeCommandSend}, // 路径参数为预期数据ID
+	{prefix: "/api/v1/expected/data", write: true, scope: model.APIKeyScopeCommandSend, deviceField: true},
+	{prefix: "/api/v1/battery/batch-command", write: true, scope: model.APIKeyScopeCommandSend, orgScoped: true},
+	{prefix: "/api/v1/battery/offline-commands", write: true, scope: model.APIKeyScopeCommandSend, orgScoped: true},
+	{prefix: "/api/v1/battery/params/pub", write: true, scope: model.APIKeyScopeCommandSend, deviceField: true},
+	{prefix: "/api/v1/battery/params/get", write: true, scope: model.APIKeyScopeCommandSend, deviceField: true},
+
+	// 读取遥测
+	{prefix: "/api/v1/telemetry/datas", scope: model.APIKeyScopeTelemetryRead, deviceParam: "id", deviceField: true},
+	{prefix: "/api/v1/attribute/datas", scope: model.APIKeyScopeTelemetryRead, deviceParam: "id", deviceField: true},
+	{prefix: "/api/v1/command/datas", scope: model.APIKeyScopeTelemetryRead, deviceParam: "id", deviceField: true},
+	{prefix: "/api/v1/event/datas", scope: model.APIKeyScopeTelemetryRead, deviceField: true},
+	{prefix: "/api/v1/expected/data", scope: model.APIKeyScopeTelemetryRead, deviceField: true},
+	{prefix: "/api/v1/device/map/telemetry", scope: model.APIKeyScopeTelemetryRead, deviceParam: "id"},
+
+	// 设备与电池
+	{prefix: "/api/v1/battery/params", scope: model.APIKeyScopeDeviceRead, deviceParam: "id", deviceField: true},
+	{prefix: "/api/v1/battery", scope: model.APIKeyScopeDeviceRead, orgScoped: true},
+	{prefix: "/api/v1/battery", write: true, scope: model.APIKeyScopeDeviceManage, orgScoped: true},
+	{prefix: "/api/v1/dashboard", scope: model.APIKeyScopeDeviceRead, orgScoped: true},
+	{prefix: "/api/v1/org", scope: model.APIKeyScopeDeviceRead, orgScoped: true},
+	{prefix: "/api/v1/dealer", scope: model.APIKeyScopeDeviceRead, orgScoped: true},
+	{prefix: "/api/v1/warranty", scope: model.APIKeyScopeDeviceRead, orgScoped: true},
+	{prefix: "/api/v1/warranty", write: true, scope: model.APIKeyScopeDeviceManage, orgScoped: true},
+	{prefix: "/api/v1/battery_maintenance", scope: model.APIKeyScopeDeviceRead, orgScoped: true},
+	{prefix: "/api/v1/battery_maintenance", write: true, scope: model.APIKeyScopeDeviceManage, orgScoped: true},
+	{prefix: "/api/v1/device/transfer", scope: model.APIKeyScopeDeviceRead, orgScoped: true},
+	{prefix: "/api/v1/device/transfer", write: true, scope: model.APIKeyScopeDeviceManage, orgScoped: true},
+	{prefix: "/api/v1/device/detail", scope: model.APIKeyScopeDeviceRead, deviceParam: "id"},
+	{prefix: "/api/v1/device/metrics", scope: model.APIKeyScopeDeviceRead, deviceParam: "id"},
+	{prefix: "/api/v1/device/online/status", scope: model.APIKeyScopeDeviceRead, deviceParam: "id"},
+	{prefix: "/api/v1/device/sub-list", scope: model.APIKeyScopeDeviceRead, deviceParam: "id"},
+	{prefix: "/api/v1/device", scope: model.APIKeyScopeDeviceRead},
+	{prefix: "/api/v1/device", write: true, scope: model.APIKeyScopeDeviceManage},
+	{prefix: "/api/v1/alarm", scope: model.APIKeyScopeDeviceRead},
+	{prefix: "/api/v1/ota", scope: model.APIKeyScopeDeviceRead},
+	{prefix: "/api/v1/ota", write: true, scope: model.APIKeyScopeDeviceManage},
+}
+
+// matchAPIKeyRoute 查找请求对应的规则
+func matchAPIKeyRoute(method, fullPath string) *apiKeyRoute {
+	write := method != http.MethodGet && method != http.MethodHead
+	for i := range apiKeyRoutes {
+		r := &apiKeyRoutes[i]
+		if r.write != write || !strings.HasPrefix(fullPath, r.prefix) {
+			continue
+		}
+		if len(fullPath) == len(r.prefix) || fullPath[len(r.prefix)] == '/' {
+			return r
+		}
+	}
+	return nil
+}
+
+// apiKeyRequestDeviceIDs 请求的目标设备：路径参数 device_id 与规则声明的路径参数；
+// 路径中没有设备ID且规则声明了 deviceField 时，取查询参数 device_id/device_ids 与 JSON 请求体中的同名字段。
+// 未声明的查询参数与请求体字段不代表接口实际操作的设备，不予采信
+func apiKeyRequestDeviceIDs(c *gin.Context, route *apiKeyRoute) []string {
+	ids := []string{c.Param("device_id")}
+	if route.deviceParam != "" {
+		ids = append(ids, c.Param(route.deviceParam))
+	}
+	if route.deviceField && compactDeviceIDs(ids) == nil {
+		ids = append(ids, c.Query("device_id"))
+		for _, v := range c.QueryArray("device_ids") {
+			ids = append(ids, strings.Split(v, ",")...)
+		}
+		if c.Request.Body != nil && strings.Contains(c.ContentType(), "json") {
+			body, err := io.ReadAll(io.LimitReader(c.Request.Body, apiKeyBodyMax))
+			if err == nil {
+				c.Request.Body = io.NopCloser(bytes.NewReader(body))
+				var payload struct {
+					DeviceID  string   `json:"device_id"`
+					DeviceIDs []string `json:"device_ids"`
+				}
+				if json.Unmarshal(body, &payload) == nil {
+					ids = append(ids, payload.DeviceID)
+					ids = append(ids, payload.DeviceIDs...)
+				}
+			}
+		}
+	}
+	return compactDeviceIDs(ids)
+}
+
+// compactDeviceIDs 去掉空白的设备ID，没有设备时返回 nil
+func compactDeviceIDs(ids []string) []string {
+	var out []string
+	for _, id := range ids {
+		if id = strings.TrimSpace(id); id != "" {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// apiKeyAbort 返回错误并中止请求
+func apiKeyAbort(c *gin.Context, status, code int, message string) {
+	c.JSON(status, ErrorResponse{
+		Code:      code,
+		Message:   message,
+		RequestID: c.GetString("X-Request-ID"),
+	})
+	c.Abort()
+}
+
+var (
+	defaultAPIKeyValidator     *APIKeyValidator
+	defaultAPIKeyValidatorOnce sync.Once
+)
+
+// apiKeyValidator 进程内共享的验证器
+func apiKeyValidator() *APIKeyValidator {
+	defaultAPIKeyValidatorOnce.Do(func() {
+		defaultAPIKeyValidator = NewAPIKeyValidator(global.DB, global.REDIS)
+	})
+	return defaultAPIKeyValidator
+}
+
+// APIKeyDenied 密钥校验未通过的原因，HTTP 接口与 WebSocket 订阅返回相同的错误码
+type APIKeyDenied struct {
+	Status  int
+	Code    int
+	Message string
+}
+
+func (e *APIKeyDenied) Error() string {
+	b, _ := json.Marshal(ErrorResponse{Code: e.Code, Message: e.Message})
+	return string(b)
+}
+
+// CheckAccess 校验来源IP白名单与调用频率，HTTP 接口与 WebSocket 订阅共用；
+// remaining 为本分钟窗口剩余次数（未限流时为 0）
+func (v *APIKeyValidator) CheckAccess(info *APIKeyInfo, ip string, now time.Time) (int, *APIKeyDenied) {
+	if !info.AllowIP(ip) {
+		return 0, &APIKeyDenied{Status: http.StatusForbidden, Code: ErrCodeAPIKeyForbidden, Message: "client ip is not allowed for this api key"}
+	}
+	remaining, ok := v.AllowRequest(info, now)
+	if remaining < 0 {
+		remaining = 0
+	}
+	if !ok {
+		return remaining, &APIKeyDenied{Status: http.StatusTooManyRequests, Code: ErrCodeAPIKeyRateLimited, Message: "api key rate limit exceeded"}
+	}
+	return remaining, nil
+}
+
+// authorizeAPIKey 完整的APIKey鉴权：有效期、IP白名单、限流、权限范围、组织/设备分组限制，并记录调用计量
+func authorizeAPIKey(c *gin.Context, validator *APIKeyValidator, apiKey string) bool {
+	if apiKey == "" {
+		apiKeyAbort(c, http.StatusUnauthorized, ErrCodeNoAuth, "missing authentication (x-token or x-api-key required)")
+		return false
+	}
+
+	info, err := validator.ValidateAPIKey(apiKey)
+	switch err {
+	case nil:
+	case ErrAPIKeyDisabled:
+		apiKeyAbort(c, http.StatusUnauthorized, ErrCodeAPIKeyDisabled, "api key is disabled")
+		return false
+	case ErrAPIKeyExpired:
+		apiKeyAbort(c, http.StatusUnauthorized, ErrCodeAPIKeyExpired, "api key has expired")
+		return false
+	case ErrAPIKeyNotFound:
+		apiKeyAbort(c, http.StatusUnauthorized, ErrCodeInvalidAPIKey, "api key verification failed")
+		return false
+	default:
+		logrus.WithError(err).Error("api key verification error")
+		apiKeyAbort(c, http.StatusInternalServerError, ErrCodeInvalidAPIKey, "api key verification failed")
+		return false
+	}
+
+	now := time.Now()
+	ip := c.ClientIP()
+	reject := func(status, code int, message string) bool {
+		validator.RecordUsage(info, ip, true, now)
+		apiKeyAbort(c, status, code, message)
+		return false
+	}
+
+	remaining, denied := validator.CheckAccess(info, ip, now)
+	if info.RateLimit > 0 && (denied == nil || denied.Code == ErrCodeAPIKeyRateLimited) {
+		c.Header("X-RateLimit-Limit", strconv.Itoa(info.RateLimit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		if denied != nil {
+			c.Header("Retry-After", strconv.FormatInt(60-now.Unix()%60, 10))
+		}
+	}
+	if denied != nil {
+		return reject(denied.Status, denied.Code, denied.Message)
+	}
+
+	route := matchAPIKeyRoute(c.Request.Method, c.FullPath())
+	if !info.FullAccess() && (route == nil || !info.HasScope(route.scope)) {
+		return reject(http.StatusForbidden, ErrCodeAPIKeyForbidden, "api key scope does not allow this request")
+	}
+
+	// 限制了组织或设备分组的密钥：仅允许声明了目标设备或已按组织数据权限过滤的接口；
+	// 目标设备逐个校验，未指定设备时仅允许按组织过滤的接口，且密钥未限制设备分组
+	if info.Restricted() {
+		if route == nil || (route.deviceParam == "" && !route.deviceField && !route.orgScoped) {
+			return reject(http.StatusForbidden, ErrCodeAPIKeyForbidden, "api key is restricted; this request cannot be limited to its devices")
+		}
+		deviceIDs := apiKeyRequestDeviceIDs(c, route)
+		if len(deviceIDs) > 0 {
+			ok, err := validator.CanAccessDevices(info, deviceIDs)
+			if err != nil {
+				logrus.WithError(err).Error("api key device restriction check error")
+			}
+			if !ok {
+				return reject(http.StatusForbidden, ErrCodeAPIKeyForbidden, "device is outside the scope of this api key")
+			}
+		} else if !route.orgScoped || info.DeviceGroupID != "" {
+			return reject(http.StatusForbidden, ErrCodeAPIKeyForbidden, "api key is restricted; request must target devices within its scope")
+		}
+	}
+
+	validator.RecordUsage(info, ip, false, now)
+	if info.GraceExpiresAt != nil {
+		c.Header("X-API-Key-Deprecated", info.GraceExpiresAt.UTC().Format(time.RFC3339))
+	}
+
+	// 设置 claims 到上下文
+	c.Set("claims", &utils.UserClaims{
+		TenantID:  info.TenantID,
+		Authority: "TENANT_ADMIN",
+		ID:        info.CreatedID,
+	})
+	c.Set(APIKeyInfoContextKey, info)
+	return true
+}
+
+// GetAPIKeyInfo 从 Gin Context 获取APIKey信息，非APIKey认证返回 nil
+func GetAPIKeyInfo(c *gin.Context) *APIKeyInfo {
+	if val, exists := c.Get(APIKeyInfoContextKey); exists {
+		if info, ok := val.(*APIKeyInfo); ok {
+			return info
+		}
+	}
+	return nil
+}
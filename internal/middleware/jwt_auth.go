@@ -1,17 +1,11 @@
 package middleware
 
 import (
-	"context"
 	"net/http"
-	"time"
 
-	"project/internal/dal"
-	"project/pkg/global"
-	utils "project/pkg/utils"
+	service "project/internal/service"
 
 	"github.com/gin-gonic/gin"
-	"github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
 )
 
 // 错误码常量
@@ -21,6 +15,11 @@ const (
 	ErrCodeTokenExpired   = 40102 // Token已过期
 	ErrCodeInvalidAPIKey  = 40103 // 无效的APIKey
 	ErrCodeAPIKeyDisabled = 40104 // APIKey已禁用
+	ErrCodeAPIKeyExpired  = 40105 // APIKey已过期
+	ErrCodeSessionRevoked = 40106 // 会话已被撤销（退出登录、强制下线等）
+
+	ErrCodeAPIKeyForbidden   = 40300 // APIKey权限范围或IP白名单不允许
+	ErrCodeAPIKeyRateLimited = 42900 // APIKey超出调用频率限制
 )
 
 // 统一的错误响应结构
@@ -55,37 +54,20 @@ func JWTAuth() gin.HandlerFunc {
 	}
 }
 
-// isValidJWT 验证JWT token
+// isValidJWT 验证JWT token：只校验签名、有效期与会话拒绝列表，不再逐请求写 Redis
 func isValidJWT(c *gin.Context, token string) bool {
 	requestID := c.GetString("X-Request-ID")
 
-	// 验证 Redis 中的 token
-	if global.REDIS.Get(context.Background(), token).Val() != "1" {
-		c.JSON(http.StatusUnauthorized, ErrorResponse{
-			Code:      ErrCodeTokenExpired,
-			Message:   "token has expired",
-			RequestID: requestID,
-		})
-		c.Abort()
-		return false
-	}
-
-	// 刷新 token 过期时间
-	timeout := viper.GetInt("session.timeout")
-	logrus.Infof("刷新 token 过期时间: %d 分钟", timeout)
-	logrus.Infof("token: %s", token)
-	global.REDIS.Set(context.Background(), token, "1", time.Duration(timeout)*time.Minute)
-
-	// 验证 JWT
-	key := viper.GetString("jwt.key")
-	j := utils.NewJWT([]byte(key))
-	claims, err := j.ParseToken(token)
+	claims, err := service.GroupApp.UserSession.ValidateAccessToken(c.Request.Context(), token)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, ErrorResponse{
-			Code:      ErrCodeInvalidToken,
-			Message:   "invalid token format",
-			RequestID: requestID,
-		})
+		resp := ErrorResponse{Code: ErrCodeInvalidToken, Message: "invalid token format", RequestID: requestID}
+		switch err {
+		case service.ErrAccessTokenExpired:
+			resp.Code, resp.Message = ErrCodeTokenExpired, "token has expired"
+		case service.ErrSessionRevoked:
+			resp.Code, resp.Message = ErrCodeSessionRevoked, "session has been revoked"
+		}
+		c.JSON(http.StatusUnauthorized, resp)
 		c.Abort()
 		return false
 	}
@@ -97,39 +79,5 @@ func isValidJWT(c *gin.Context, token string) bool {
 
 // OpenAPIKeyAuth APIKey 验证
 func OpenAPIKeyAuth(c *gin.Context) bool {
-	requestID := c.GetString("X-Request-ID")
-
-	appKey := c.Request.Header.Get("x-api-key")
-	if appKey == "" {
-		c.JSON(http.StatusUnauthorized, ErrorResponse{
-			Code:      ErrCodeNoAuth,
-			Message:   "missing authentication (x-token or x-api-key required)",
-			RequestID: requestID,
-		})
-		c.Abort()
-		return false
-	}
-
-	tenantID, createdID, err := dal.VerifyOpenAPIKey(context.Background(), appKey)
-	if err != nil {
-		errCode := ErrCodeInvalidAPIKey
-		errMsg := "api key verification failed"
-
-		c.JSON(http.StatusUnauthorized, ErrorResponse{
-			Code:      errCode,
-			Message:   errMsg,
-			RequestID: requestID,
-		})
-		c.Abort()
-		return false
-	}
-
-	// 设置 claims 到上下文
-	claims := utils.UserClaims{
-		TenantID:  tenantID,
-		Authority: "TENANT_ADMIN",
-		ID:        createdID,
-	}
-	c.Set("claims", &claims)
-	return true
+	return authorizeAPIKey(c, apiKeyValidator(), c.Request.Header.Get("x-api-key"))
 }
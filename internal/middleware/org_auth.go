@@ -57,6 +57,12 @@ func OrgAuthMiddleware() gin.HandlerFunc {
 			userInfo.UserKind = model.UserKindEndUser
 		}
 
+		// 限定了组织的APIKey按密钥的组织过滤数据，而非创建者的组织
+		if info := GetAPIKeyInfo(c); info != nil && info.OrgID != "" {
+			userInfo.OrgID = info.OrgID
+			userInfo.UserKind = model.UserKindOrgUser
+		}
+
 		c.Set(OrgIDContextKey, userInfo.OrgID)
 		c.Set(UserKindContextKey, userInfo.UserKind)
 		c.Set(TenantIDContextKey, userInfo.TenantID)
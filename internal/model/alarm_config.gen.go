@@ -12,17 +12,25 @@ const TableNameAlarmConfig = "alarm_config"
 
 // AlarmConfig mapped from table <alarm_config>
 type AlarmConfig struct {
-	ID                    string    `gorm:"column:id;primaryKey" json:"id"`
-	Name                  string    `gorm:"column:name;not null;comment:告警名称" json:"name"`                                    // 告警名称
-	Description           *string   `gorm:"column:description;comment:告警描述" json:"description"`                               // 告警描述
-	ProcessingSuggestions *string   `gorm:"column:processing_suggestions;comment:建议处理方式（换行分条）" json:"processing_suggestions"` // 建议处理方式（换行分条）
-	AlarmLevel            string    `gorm:"column:alarm_level;not null;comment:告警级别H: 高M: 中L: 低" json:"alarm_level"`          // 告警级别H: 高M: 中L: 低
-	NotificationGroupID   string    `gorm:"column:notification_group_id;not null;comment:通知组id" json:"notification_group_id"` // 通知组id
-	CreatedAt             time.Time `gorm:"column:created_at;not null" json:"created_at"`
-	UpdatedAt             time.Time `gorm:"column:updated_at;not null" json:"updated_at"`
-	TenantID              string    `gorm:"column:tenant_id;not null" json:"tenant_id"`
-	Remark                *string   `gorm:"column:remark" json:"remark"`
-	Enabled               string    `gorm:"column:enabled;not null;comment:是否启用Y-启用N-停止" json:"enabled"` // 是否启用Y-启用N-停止
+	ID                            string    `gorm:"column:id;primaryKey" json:"id"`
+	Name                          string    `gorm:"column:name;not null;comment:告警名称" json:"name"`                                    // 告警名称
+	Description                   *string   `gorm:"column:description;comment:告警描述" json:"description"`                               // 告警描述
+	ProcessingSuggestions         *string   `gorm:"column:processing_suggestions;comment:建议处理方式（换行分条）" json:"processing_suggestions"` // 建议处理方式（换行分条）
+	AlarmLevel                    string    `gorm:"column:alarm_level;not null;comment:告警级别H: 高M: 中L: 低" json:"alarm_level"`          // 告警级别H: 高M: 中L: 低
+	NotificationGroupID           string    `gorm:"column:notification_group_id;not null;comment:通知组id" json:"notification_group_id"` // 通知组id
+	CreatedAt                     time.Time `gorm:"column:created_at;not null" json:"created_at"`
+	UpdatedAt                     time.Time `gorm:"column:updated_at;not null" json:"updated_at"`
+	TenantID                      string    `gorm:"column:tenant_id;not null" json:"tenant_id"`
+	Remark                        *string   `gorm:"column:remark" json:"remark"`
+	Enabled                       string    `gorm:"column:enabled;not null;comment:是否启用Y-启用N-停止" json:"enabled"`                                     // 是否启用Y-启用N-停止
+	AckSlaMinutes                 *int32    `gorm:"column:ack_sla_minutes;comment:确认时限（分钟）" json:"ack_sla_minutes"`                                  // 确认时限（分钟）
+	ResolveSlaMinutes             *int32    `gorm:"column:resolve_sla_minutes;comment:解决时限（分钟）" json:"resolve_sla_minutes"`                          // 解决时限（分钟）
+	EscalationMinutes             *int32    `gorm:"column:escalation_minutes;comment:未确认升级时限（分钟）" json:"escalation_minutes"`                         // 未确认升级时限（分钟）
+	EscalationNotificationGroupID *string   `gorm:"column:escalation_notification_group_id;comment:升级通知组id" json:"escalation_notification_group_id"` // 升级通知组id
+	GroupBy                       *string   `gorm:"column:group_by;comment:告警合并方式 SCENE-同一场景条件组 GATEWAY-同一网关 CONFIG-同一告警配置" json:"group_by"`         // 告警合并方式 SCENE-同一场景条件组 GATEWAY-同一网关 CONFIG-同一告警配置
+	GroupWindowSeconds            *int32    `gorm:"column:group_window_seconds;comment:告警合并时间窗口（秒）" json:"group_window_seconds"`                     // 告警合并时间窗口（秒）
+	FlapThreshold                 *int32    `gorm:"column:flap_threshold;comment:抖动判定触发次数" json:"flap_threshold"`                                    // 抖动判定触发次数
+	FlapWindowSeconds             *int32    `gorm:"column:flap_window_seconds;comment:抖动判定时间窗口（秒）" json:"flap_window_seconds"`                       // 抖动判定时间窗口（秒）
 }
 
 // TableName AlarmConfig's table name
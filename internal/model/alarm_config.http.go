@@ -1,30 +1,46 @@
 package model
 
 type CreateAlarmConfigReq struct {
-	Name                  string  `json:"name" validate:"required"`
-	Description           *string `json:"description" validate:"omitempty"`
-	ProcessingSuggestions *string `json:"processing_suggestions" validate:"omitempty"`
-	AlarmLevel            string  `json:"alarm_level" validate:"required"`
-	NotificationGroupID   string  `json:"notification_group_id" validate:"omitempty"`
-	CreatedAt             *string `json:"created_at" validate:"omitempty"`
-	UpdatedAt             *string `json:"updated_at" validate:"omitempty"`
-	TenantID              string  `json:"tenant_id" validate:"omitempty"`
-	Remark                *string `json:"remark" validate:"omitempty"`
-	Enabled               string  `json:"enabled" validate:"omitempty"`
+	Name                          string  `json:"name" validate:"required"`
+	Description                   *string `json:"description" validate:"omitempty"`
+	ProcessingSuggestions         *string `json:"processing_suggestions" validate:"omitempty"`
+	AlarmLevel                    string  `json:"alarm_level" validate:"required"`
+	NotificationGroupID           string  `json:"notification_group_id" validate:"omitempty"`
+	CreatedAt                     *string `json:"created_at" validate:"omitempty"`
+	UpdatedAt                     *string `json:"updated_at" validate:"omitempty"`
+	TenantID                      string  `json:"tenant_id" validate:"omitempty"`
+	Remark                        *string `json:"remark" validate:"omitempty"`
+	Enabled                       string  `json:"enabled" validate:"omitempty"`
+	AckSlaMinutes                 *int32  `json:"ack_sla_minutes" validate:"omitempty,min=0"`     // 确认时限（分钟）
+	ResolveSlaMinutes             *int32  `json:"resolve_sla_minutes" validate:"omitempty,min=0"` // 解决时限（分钟）
+	EscalationMinutes             *int32  `json:"escalation_minutes" validate:"omitempty,min=0"`  // 超过N分钟未确认时升级
+	EscalationNotificationGroupID *string `json:"escalation_notification_group_id" validate:"omitempty,max=36"`
+	GroupBy                       *string `json:"group_by" validate:"omitempty,oneof=SCENE GATEWAY CONFIG"`  // 告警合并方式
+	GroupWindowSeconds            *int32  `json:"group_window_seconds" validate:"omitempty,min=0,max=86400"` // 告警合并时间窗口（秒）
+	FlapThreshold                 *int32  `json:"flap_threshold" validate:"omitempty,min=0"`                 // 抖动判定触发次数
+	FlapWindowSeconds             *int32  `json:"flap_window_seconds" validate:"omitempty,min=0,max=86400"`  // 抖动判定时间窗口（秒）
 }
 
 type UpdateAlarmConfigReq struct {
-	ID                    string  `json:"id" validate:"required,max=36"`
-	Name                  *string `json:"name" validate:"omitempty"`
-	Description           *string `json:"description" validate:"omitempty"`
-	ProcessingSuggestions *string `json:"processing_suggestions" validate:"omitempty"`
-	AlarmLevel            *string `json:"alarm_level" validate:"omitempty"`
-	NotificationGroupID   *string `json:"notification_group_id" validate:"omitempty"`
-	CreatedAt             *string `json:"created_at" validate:"omitempty"`
-	UpdatedAt             *string `json:"updated_at" validate:"omitempty"`
-	TenantID              *string `json:"tenant_id" validate:"omitempty"`
-	Remark                *string `json:"remark" validate:"omitempty"`
-	Enabled               *string `json:"enabled" validate:"omitempty"`
+	ID                            string  `json:"id" validate:"required,max=36"`
+	Name                          *string `json:"name" validate:"omitempty"`
+	Description                   *string `json:"description" validate:"omitempty"`
+	ProcessingSuggestions         *string `json:"processing_suggestions" validate:"omitempty"`
+	AlarmLevel                    *string `json:"alarm_level" validate:"omitempty"`
+	NotificationGroupID           *string `json:"notification_group_id" validate:"omitempty"`
+	CreatedAt                     *string `json:"created_at" validate:"omitempty"`
+	UpdatedAt                     *string `json:"updated_at" validate:"omitempty"`
+	TenantID                      *string `json:"tenant_id" validate:"omitempty"`
+	Remark                        *string `json:"remark" validate:"omitempty"`
+	Enabled                       *string `json:"enabled" validate:"omitempty"`
+	AckSlaMinutes                 *int32  `json:"ack_sla_minutes" validate:"omitempty,min=0"`
+	ResolveSlaMinutes             *int32  `json:"resolve_sla_minutes" validate:"omitempty,min=0"`
+	EscalationMinutes             *int32  `json:"escalation_minutes" validate:"omitempty,min=0"`
+	EscalationNotificationGroupID *string `json:"escalation_notification_group_id" validate:"omitempty,max=36"`
+	GroupBy                       *string `json:"group_by" validate:"omitempty,oneof=SCENE GATEWAY CONFIG"`  // 告警合并方式
+	GroupWindowSeconds            *int32  `json:"group_window_seconds" validate:"omitempty,min=0,max=86400"` // 告警合并时间窗口（秒）
+	FlapThreshold                 *int32  `json:"flap_threshold" validate:"omitempty,min=0"`                 // 抖动判定触发次数
+	FlapWindowSeconds             *int32  `json:"flap_window_seconds" validate:"omitempty,min=0,max=86400"`  // 抖动判定时间窗口（秒）
 }
 
 type GetAlarmConfigListByPageReq struct {
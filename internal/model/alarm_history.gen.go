@@ -22,8 +22,13 @@ type AlarmHistory struct {
 	AlarmStatus       string    `gorm:"column:alarm_status;not null;comment:L 底 M中 H 高 N 正常" json:"alarm_status"` // L 底 M中 H 高 N 正常
 	TenantID          string    `gorm:"column:tenant_id;not null;comment:租户" json:"tenant_id"`                    // 租户
 	Remark            *string   `gorm:"column:remark" json:"remark"`
-	CreateAt          time.Time `gorm:"column:create_at;not null;comment:创建时间" json:"create_at"`                   // 创建时间
-	AlarmDeviceList   string    `gorm:"column:alarm_device_list;not null;comment:触发设备id" json:"alarm_device_list"` // 触发设备id
+	CreateAt          time.Time `gorm:"column:create_at;not null;comment:创建时间" json:"create_at"`                              // 创建时间
+	AlarmDeviceList   string    `gorm:"column:alarm_device_list;not null;comment:触发设备id" json:"alarm_device_list"`            // 触发设备id
+	Severity          *string   `gorm:"column:severity;comment:触发时的告警级别，恢复记录为空" json:"severity"`                              // 触发时的告警级别，恢复记录为空
+	LifecycleState    string    `gorm:"column:lifecycle_state;not null;default:OPEN;comment:处理状态" json:"lifecycle_state"`     // 处理状态
+	AssigneeID        *string   `gorm:"column:assignee_id;comment:处理人" json:"assignee_id"`                                    // 处理人
+	IncidentID        *string   `gorm:"column:incident_id;comment:所属告警事件" json:"incident_id"`                                 // 所属告警事件
+	SuppressReason    *string   `gorm:"column:suppress_reason;comment:未发送通知的原因 DEDUP-已合并 FLAPPING-抖动" json:"suppress_reason"` // 未发送通知的原因 DEDUP-已合并 FLAPPING-抖动
 }
 
 // TableName AlarmHistory's table name
@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+const TableNameAlarmHistoryActivity = "alarm_history_activities"
+
+// 告警处理状态
+const (
+	AlarmStateOpen         = "OPEN"         // 待处理
+	AlarmStateAcknowledged = "ACKNOWLEDGED" // 已确认
+	AlarmStateAssigned     = "ASSIGNED"     // 已指派
+	AlarmStateInProgress   = "IN_PROGRESS"  // 处理中
+	AlarmStateResolved     = "RESOLVED"     // 已解决
+	AlarmStateClosed       = "CLOSED"       // 已关闭
+)
+
+// 告警处理动作
+const (
+	AlarmActionComment     = "COMMENT"
+	AlarmActionAcknowledge = "ACKNOWLEDGE"
+	AlarmActionAssign      = "ASSIGN"
+	AlarmActionStart       = "START"
+	AlarmActionResolve     = "RESOLVE"
+	AlarmActionClose       = "CLOSE"
+	AlarmActionReopen      = "REOPEN"
+	AlarmActionEscalate    = "ESCALATE"
+	AlarmActionAutoResolve = "AUTO_RESOLVE"
+)
+
+// AlarmHistoryActivity 告警评论与处理记录
+type AlarmHistoryActivity struct {
+	ID             string    `gorm:"column:id;primaryKey" json:"id"`
+	AlarmHistoryID string    `gorm:"column:alarm_history_id;not null" json:"alarm_history_id"`
+	TenantID       string    `gorm:"column:tenant_id;not null" json:"tenant_id"`
+	Action         string    `gorm:"column:action;not null" json:"action"`
+	FromState      *string   `gorm:"column:from_state" json:"from_state"`
+	ToState        *string   `gorm:"column:to_state" json:"to_state"`
+	OperatorID     *string   `gorm:"column:operator_id" json:"operator_id"`
+	AssigneeID     *string   `gorm:"column:assignee_id" json:"assignee_id"`
+	Content        *string   `gorm:"column:content" json:"content"`
+	CreatedAt      time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (*AlarmHistoryActivity) TableName() string {
+	return TableNameAlarmHistoryActivity
+}
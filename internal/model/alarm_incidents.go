@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+const TableNameAlarmIncident = "alarm_incidents"
+
+// 告警合并方式
+const (
+	AlarmGroupByScene   = "SCENE"   // 同一场景条件组
+	AlarmGroupByGateway = "GATEWAY" // 同一网关（子设备按父设备归并）
+	AlarmGroupByConfig  = "CONFIG"  // 同一告警配置
+)
+
+// 告警事件状态
+const (
+	AlarmIncidentOpen     = "OPEN"
+	AlarmIncidentResolved = "RESOLVED"
+)
+
+// 告警未发送通知的原因
+const (
+	AlarmSuppressDedup    = "DEDUP"    // 已并入告警事件
+	AlarmSuppressFlapping = "FLAPPING" // 抖动
+)
+
+// AlarmIncident 告警事件：按规则合并的相关告警
+type AlarmIncident struct {
+	ID            string     `gorm:"column:id;primaryKey" json:"id"`
+	TenantID      string     `gorm:"column:tenant_id;not null" json:"tenant_id"`
+	AlarmConfigID string     `gorm:"column:alarm_config_id;not null" json:"alarm_config_id"`
+	GroupBy       string     `gorm:"column:group_by;not null" json:"group_by"`
+	GroupKey      string     `gorm:"column:group_key;not null" json:"group_key"`
+	Name          string     `gorm:"column:name;not null" json:"name"`
+	Severity      string     `gorm:"column:severity;not null" json:"severity"`
+	Status        string     `gorm:"column:status;not null" json:"status"`
+	AlarmCount    int32      `gorm:"column:alarm_count;not null" json:"alarm_count"`
+	DeviceList    string     `gorm:"column:device_list;type:jsonb;not null" json:"device_list"`
+	Flapping      bool       `gorm:"column:flapping;not null" json:"flapping"`
+	FirstAt       time.Time  `gorm:"column:first_at;not null" json:"first_at"`
+	LastAt        time.Time  `gorm:"column:last_at;not null" json:"last_at"`
+	ResolvedAt    *time.Time `gorm:"column:resolved_at" json:"resolved_at"`
+}
+
+func (*AlarmIncident) TableName() string {
+	return TableNameAlarmIncident
+}
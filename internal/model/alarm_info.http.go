@@ -24,12 +24,14 @@ type UpdateAlarmInfoBatchReq struct {
 
 type GetAlarmHisttoryListByPage struct {
 	PageReq
-	StartTime   *time.Time `json:"start_time" form:"start_time" validate:"omitempty"`     // 告警时间
-	EndTime     *time.Time `json:"end_time" form:"end_time" validate:"omitempty"`         // 告警时间
-	AlarmStatus *string    `json:"alarm_status" form:"alarm_status" validate:"omitempty"` // 告警状态
-	Handled     *bool      `json:"handled" form:"handled" validate:"omitempty"`           // 是否已处理（true: alarm_status=N, false: alarm_status!=N）
-	AlarmLevel  *string    `json:"alarm_level" form:"alarm_level" validate:"omitempty"`   // 告警级别（来自配置）
-	DeviceId    *string    `json:"device_id" form:"device_id" validate:"omitempty"`       // 设备id
+	StartTime      *time.Time `json:"start_time" form:"start_time" validate:"omitempty"`                                                                        // 告警时间
+	EndTime        *time.Time `json:"end_time" form:"end_time" validate:"omitempty"`                                                                            // 告警时间
+	AlarmStatus    *string    `json:"alarm_status" form:"alarm_status" validate:"omitempty"`                                                                    // 告警状态
+	Handled        *bool      `json:"handled" form:"handled" validate:"omitempty"`                                                                              // 是否已处理（true: alarm_status=N, false: alarm_status!=N）
+	AlarmLevel     *string    `json:"alarm_level" form:"alarm_level" validate:"omitempty"`                                                                      // 告警级别（来自配置）
+	DeviceId       *string    `json:"device_id" form:"device_id" validate:"omitempty"`                                                                          // 设备id
+	LifecycleState *string    `json:"lifecycle_state" form:"lifecycle_state" validate:"omitempty,oneof=OPEN ACKNOWLEDGED ASSIGNED IN_PROGRESS RESOLVED CLOSED"` // 处理状态
+	AssigneeID     *string    `json:"assignee_id" form:"assignee_id" validate:"omitempty,max=36"`                                                               // 处理人
 }
 
 type AlarmHistoryDescUpdateReq struct {
@@ -44,3 +46,64 @@ type AlarmHistoryHandleReq struct {
 type GetDeviceAlarmStatusReq struct {
 	DeviceId string `json:"device_id" form:"device_id" validate:"required"` // 设备id
 }
+
+// AlarmHistoryTransitionReq 告警处理状态变更
+type AlarmHistoryTransitionReq struct {
+	Action     string  `json:"action" validate:"required,oneof=ACKNOWLEDGE ASSIGN START RESOLVE CLOSE REOPEN"`
+	AssigneeID *string `json:"assignee_id" validate:"omitempty,max=36"` // 指派时必填
+	Comment    *string `json:"comment" validate:"omitempty,max=2000"`
+}
+
+// AlarmHistoryCommentReq 告警评论
+type AlarmHistoryCommentReq struct {
+	Content string `json:"content" validate:"required,max=2000"`
+}
+
+// AlarmHistoryActivityResp 告警评论与处理记录
+type AlarmHistoryActivityResp struct {
+	ID           string  `json:"id"`
+	Action       string  `json:"action"`
+	FromState    *string `json:"from_state"`
+	ToState      *string `json:"to_state"`
+	OperatorID   *string `json:"operator_id"`
+	OperatorName *string `json:"operator_name"`
+	AssigneeID   *string `json:"assignee_id"`
+	AssigneeName *string `json:"assignee_name"`
+	Content      *string `json:"content"`
+	CreatedAt    string  `json:"created_at"`
+}
+
+// AlarmSlaReportReq 告警 SLA 统计
+type AlarmSlaReportReq struct {
+	StartTime *time.Time `json:"start_time" form:"start_time" validate:"omitempty"`
+	EndTime   *time.Time `json:"end_time" form:"end_time" validate:"omitempty"`
+	GroupBy   string     `json:"group_by" form:"group_by" validate:"omitempty,oneof=tenant dealer"` // 默认 tenant
+}
+
+// AlarmSlaReportItem 单个租户/经销商的 SLA 统计
+type AlarmSlaReportItem struct {
+	ID              string   `json:"id"`
+	Name            string   `json:"name"`
+	Total           int64    `json:"total"`            // 告警数
+	Acknowledged    int64    `json:"acknowledged"`     // 已确认数
+	Resolved        int64    `json:"resolved"`         // 已解决数
+	AckBreached     int64    `json:"ack_breached"`     // 超出确认时限数
+	ResolveBreached int64    `json:"resolve_breached"` // 超出解决时限数
+	MttaSeconds     *float64 `json:"mtta_seconds"`     // 平均确认时长
+	MttrSeconds     *float64 `json:"mttr_seconds"`     // 平均解决时长
+}
+
+// AlarmSlaReportResp 告警 SLA 统计
+type AlarmSlaReportResp struct {
+	GroupBy string               `json:"group_by"`
+	List    []AlarmSlaReportItem `json:"list"`
+}
+
+// GetAlarmIncidentListByPageReq 告警事件列表
+type GetAlarmIncidentListByPageReq struct {
+	PageReq
+	Status        *string    `json:"status" form:"status" validate:"omitempty,oneof=OPEN RESOLVED"`
+	AlarmConfigID *string    `json:"alarm_config_id" form:"alarm_config_id" validate:"omitempty,max=36"`
+	StartTime     *time.Time `json:"start_time" form:"start_time" validate:"omitempty"` // 最近告警时间
+	EndTime       *time.Time `json:"end_time" form:"end_time" validate:"omitempty"`
+}
@@ -0,0 +1,79 @@
+package model
+
+import "time"
+
+const (
+	TableNameApprovalPolicy  = "approval_policies"
+	TableNameApprovalRequest = "approval_requests"
+)
+
+// 需要审批的高风险操作
+const (
+	ApprovalOpBatchCommand      = "BATCH_COMMAND"       // 电池批量下发指令
+	ApprovalOpBatchOTA          = "BATCH_OTA"           // 电池批量OTA推送
+	ApprovalOpBatchAssignDealer = "BATCH_ASSIGN_DEALER" // 电池批量分配经销商
+	ApprovalOpOrgTransfer       = "ORG_TRANSFER"        // 设备批量转移到组织
+)
+
+// 审批申请状态
+const (
+	ApprovalPending   = "PENDING"   // 待审批
+	ApprovalApproved  = "APPROVED"  // 已通过，执行中
+	ApprovalExecuted  = "EXECUTED"  // 已执行
+	ApprovalFailed    = "FAILED"    // 执行失败
+	ApprovalRejected  = "REJECTED"  // 已驳回
+	ApprovalExpired   = "EXPIRED"   // 已过期
+	ApprovalCancelled = "CANCELLED" // 申请人撤回
+)
+
+// ApprovalCommandAll 策略中表示全部指令的标识符
+const ApprovalCommandAll = "*"
+
+// ApprovalPolicy 审批策略
+type ApprovalPolicy struct {
+	ID                  string    `gorm:"column:id;primaryKey" json:"id"`
+	TenantID            string    `gorm:"column:tenant_id;not null" json:"tenant_id"`
+	OperationType       string    `gorm:"column:operation_type;not null" json:"operation_type"`
+	Enabled             bool      `gorm:"column:enabled;not null" json:"enabled"`
+	DeviceThreshold     *int32    `gorm:"column:device_threshold" json:"device_threshold"`       // 设备数超过该值需要审批
+	CommandIdentifiers  *string   `gorm:"column:command_identifiers" json:"command_identifiers"` // JSON数组
+	ApproverUserIDs     *string   `gorm:"column:approver_user_ids" json:"approver_user_ids"`     // JSON数组
+	ApproverRoleIDs     *string   `gorm:"column:approver_role_ids" json:"approver_role_ids"`     // JSON数组
+	NotificationGroupID *string   `gorm:"column:notification_group_id" json:"notification_group_id"`
+	ExpireHours         int32     `gorm:"column:expire_hours;not null" json:"expire_hours"`
+	Remark              *string   `gorm:"column:remark" json:"remark"`
+	CreatedAt           time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt           time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (*ApprovalPolicy) TableName() string {
+	return TableNameApprovalPolicy
+}
+
+// ApprovalRequest 审批申请
+type ApprovalRequest struct {
+	ID             string     `gorm:"column:id;primaryKey" json:"id"`
+	TenantID       string     `gorm:"column:tenant_id;not null" json:"tenant_id"`
+	OperationType  string     `gorm:"column:operation_type;not null" json:"operation_type"`
+	ScopeOrgID     *string    `gorm:"column:scope_org_id" json:"scope_org_id"`
+	Payload        string     `gorm:"column:payload;not null" json:"payload"`
+	DeviceCount    int32      `gorm:"column:device_count;not null" json:"device_count"`
+	Summary        string     `gorm:"column:summary;not null" json:"summary"`
+	TriggerReason  string     `gorm:"column:trigger_reason;not null" json:"trigger_reason"`
+	RequesterID    string     `gorm:"column:requester_id;not null" json:"requester_id"`
+	ApproverIDs    string     `gorm:"column:approver_ids;not null" json:"approver_ids"` // JSON数组
+	Status         string     `gorm:"column:status;not null" json:"status"`
+	DecidedBy      *string    `gorm:"column:decided_by" json:"decided_by"`
+	DecisionReason *string    `gorm:"column:decision_reason" json:"decision_reason"`
+	DecidedAt      *time.Time `gorm:"column:decided_at" json:"decided_at"`
+	ExpiresAt      time.Time  `gorm:"column:expires_at;not null" json:"expires_at"`
+	ExecutedAt     *time.Time `gorm:"column:executed_at" json:"executed_at"`
+	Result         *string    `gorm:"column:result" json:"result"`
+	ErrorMessage   *string    `gorm:"column:error_message" json:"error_message"`
+	CreatedAt      time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (*ApprovalRequest) TableName() string {
+	return TableNameApprovalRequest
+}
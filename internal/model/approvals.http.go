@@ -0,0 +1,45 @@
+package model
+
+import "time"
+
+// ApprovalPolicySaveReq 保存审批策略（按操作类型覆盖）
+type ApprovalPolicySaveReq struct {
+	Enabled             bool     `json:"enabled"`
+	DeviceThreshold     *int32   `json:"device_threshold" validate:"omitempty,gte=0"`
+	CommandIdentifiers  []string `json:"command_identifiers" validate:"max=200,dive,required,max=255"`
+	ApproverUserIDs     []string `json:"approver_user_ids" validate:"max=100,dive,required,max=36"`
+	ApproverRoleIDs     []string `json:"approver_role_ids" validate:"max=100,dive,required,max=36"`
+	NotificationGroupID *string  `json:"notification_group_id" validate:"omitempty,max=36"`
+	ExpireHours         int32    `json:"expire_hours" validate:"omitempty,gte=1,lte=720"` // 默认24小时
+	Remark              *string  `json:"remark" validate:"omitempty,max=255"`
+}
+
+// GetApprovalRequestListByPageReq 审批申请查询
+type GetApprovalRequestListByPageReq struct {
+	PageReq
+	OperationType *string `json:"operation_type" form:"operation_type" validate:"omitempty,oneof=BATCH_COMMAND BATCH_OTA BATCH_ASSIGN_DEALER ORG_TRANSFER"`
+	Status        *string `json:"status" form:"status" validate:"omitempty,oneof=PENDING APPROVED EXECUTED FAILED REJECTED EXPIRED CANCELLED"`
+	// Scope mine-我提交的 todo-待我审批，不传时租户管理员查看全部，其他用户查看与自己相关的申请
+	Scope *string `json:"scope" form:"scope" validate:"omitempty,oneof=mine todo"`
+}
+
+// ApprovalRequestResp 审批申请详情
+type ApprovalRequestResp struct {
+	ApprovalRequest
+	RequesterName *string `json:"requester_name"`
+	DecidedByName *string `json:"decided_by_name"`
+}
+
+// ApprovalDecisionReq 审批意见（驳回时必填）
+type ApprovalDecisionReq struct {
+	Reason *string `json:"reason" validate:"omitempty,max=500"`
+}
+
+// ApprovalPendingResp 操作需要审批时的返回，原操作暂不执行
+type ApprovalPendingResp struct {
+	ApprovalRequired  bool      `json:"approval_required"`
+	ApprovalRequestID string    `json:"approval_request_id"`
+	Status            string    `json:"status"`
+	TriggerReason     string    `json:"trigger_reason"`
+	ExpiresAt         time.Time `json:"expires_at"`
+}
@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+const TableNameBatteryCycleSession = "battery_cycle_sessions"
+
+// 充放电会话类型
+const (
+	BatterySessionCharge    = "CHARGE"
+	BatterySessionDischarge = "DISCHARGE"
+	BatterySessionIdle      = "IDLE"
+)
+
+// BatteryCycleSession 电池充放电会话（由电流/SOC遥测切分）
+type BatteryCycleSession struct {
+	ID             string    `gorm:"column:id;primaryKey" json:"id"`
+	TenantID       string    `gorm:"column:tenant_id;not null" json:"tenant_id"`
+	DeviceID       string    `gorm:"column:device_id;not null" json:"device_id"`
+	SessionType    string    `gorm:"column:session_type;not null" json:"session_type"`
+	StartAt        time.Time `gorm:"column:start_at;not null" json:"start_at"`
+	EndAt          time.Time `gorm:"column:end_at;not null" json:"end_at"`
+	DurationSec    int32     `gorm:"column:duration_sec;not null" json:"duration_sec"`
+	StartSoc       *float64  `gorm:"column:start_soc" json:"start_soc"`
+	EndSoc         *float64  `gorm:"column:end_soc" json:"end_soc"`
+	EnergyInWh     float64   `gorm:"column:energy_in_wh;not null" json:"energy_in_wh"`
+	EnergyOutWh    float64   `gorm:"column:energy_out_wh;not null" json:"energy_out_wh"`
+	ChargeAh       float64   `gorm:"column:charge_ah;not null" json:"charge_ah"`
+	PeakCurrent    *float64  `gorm:"column:peak_current" json:"peak_current"`
+	MaxTemperature *float64  `gorm:"column:max_temperature" json:"max_temperature"`
+	SampleCount    int32     `gorm:"column:sample_count;not null" json:"sample_count"`
+	CreatedAt      time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (*BatteryCycleSession) TableName() string {
+	return TableNameBatteryCycleSession
+}
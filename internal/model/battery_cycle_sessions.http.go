@@ -0,0 +1,77 @@
+package model
+
+import "time"
+
+// BatteryCycleListReq 充放电会话列表查询
+type BatteryCycleListReq struct {
+	PageReq
+	DeviceID       *string    `form:"device_id"`
+	DeviceNumber   *string    `form:"device_number"`
+	BatteryModelID *string    `form:"battery_model_id"`
+	SessionType    *string    `form:"session_type" validate:"omitempty,oneof=CHARGE DISCHARGE IDLE"`
+	StartTime      *time.Time `form:"start_time"`
+	EndTime        *time.Time `form:"end_time"`
+}
+
+// BatteryCycleItemResp 充放电会话行
+type BatteryCycleItemResp struct {
+	ID               string   `json:"id"`
+	DeviceID         string   `json:"device_id"`
+	DeviceNumber     string   `json:"device_number"`
+	BatteryModelName *string  `json:"battery_model_name"`
+	SessionType      string   `json:"session_type"`
+	StartAt          string   `json:"start_at"`
+	EndAt            string   `json:"end_at"`
+	DurationSec      int32    `json:"duration_sec"`
+	StartSoc         *float64 `json:"start_soc"`
+	EndSoc           *float64 `json:"end_soc"`
+	EnergyInWh       float64  `json:"energy_in_wh"`
+	EnergyOutWh      float64  `json:"energy_out_wh"`
+	ChargeAh         float64  `json:"charge_ah"`
+	PeakCurrent      *float64 `json:"peak_current"`
+	MaxTemperature   *float64 `json:"max_temperature"`
+}
+
+// BatteryCycleListResp 充放电会话列表
+type BatteryCycleListResp struct {
+	List     []BatteryCycleItemResp `json:"list"`
+	Total    int64                  `json:"total"`
+	Page     int                    `json:"page"`
+	PageSize int                    `json:"page_size"`
+}
+
+// BatteryCycleStatsReq 充放电会话聚合查询
+type BatteryCycleStatsReq struct {
+	GroupBy        string     `form:"group_by" validate:"required,oneof=device battery_model dealer"` // 聚合维度
+	SessionType    *string    `form:"session_type" validate:"omitempty,oneof=CHARGE DISCHARGE IDLE"`
+	BatteryModelID *string    `form:"battery_model_id"`
+	StartTime      *time.Time `form:"start_time"`
+	EndTime        *time.Time `form:"end_time"`
+	Limit          int        `form:"limit" validate:"omitempty,gte=1,lte=1000"` // 返回条数（默认100）
+}
+
+// BatteryCycleStatsItem 聚合行
+type BatteryCycleStatsItem struct {
+	GroupID        string   `json:"group_id"`
+	GroupName      string   `json:"group_name"`
+	ChargeCount    int64    `json:"charge_count"`
+	DischargeCount int64    `json:"discharge_count"`
+	EnergyInWh     float64  `json:"energy_in_wh"`
+	EnergyOutWh    float64  `json:"energy_out_wh"`
+	AvgChargeSec   *float64 `json:"avg_charge_sec"` // 平均充电时长(秒)
+	AvgDepthSoc    *float64 `json:"avg_depth_soc"`  // 平均放电深度(%)
+	MaxTemperature *float64 `json:"max_temperature"`
+	PeakCurrent    *float64 `json:"peak_current"`
+}
+
+// BatteryCycleStatsResp 聚合结果
+type BatteryCycleStatsResp struct {
+	GroupBy string                  `json:"group_by"`
+	List    []BatteryCycleStatsItem `json:"list"`
+}
+
+// AppBatteryCycleReq APP端充放电记录查询
+type AppBatteryCycleReq struct {
+	SessionType *string `form:"session_type" validate:"omitempty,oneof=CHARGE DISCHARGE IDLE"` // 默认 CHARGE
+	Limit       int     `form:"limit" validate:"omitempty,gte=1,lte=100"`                      // 默认20
+}
@@ -0,0 +1,71 @@
+package model
+
+// BatteryHealthDetailReq 电池健康详情查询
+type BatteryHealthDetailReq struct {
+	Days int `form:"days" validate:"omitempty,gte=1,lte=1095"` // 历史天数（默认180）
+}
+
+// BatteryHealthHistoryPoint SOH 历史点（按天）
+type BatteryHealthHistoryPoint struct {
+	Date                   string   `json:"date"` // YYYY-MM-DD
+	Soh                    *float64 `json:"soh"`
+	SohSource              string   `json:"soh_source"` // CAPACITY/REPORTED
+	CapacityAh             *float64 `json:"capacity_ah"`
+	EquivalentCycles       float64  `json:"equivalent_cycles"`
+	TotalCycles            float64  `json:"total_cycles"`
+	CellDeltaAvgMv         *float64 `json:"cell_delta_avg_mv"`
+	CellDeltaMaxMv         *float64 `json:"cell_delta_max_mv"`
+	InternalResistanceMohm *float64 `json:"internal_resistance_mohm"`
+	TemperatureMax         *float64 `json:"temperature_max"`
+}
+
+// BatteryHealthDetailResp 电池健康详情（当前指标 + 寿命预测 + 历史）
+type BatteryHealthDetailResp struct {
+	DeviceID         string  `json:"device_id"`
+	DeviceNumber     string  `json:"device_number"`
+	BatteryModelName *string `json:"battery_model_name"`
+
+	Soh             *float64 `json:"soh"`
+	CycleCount      *float64 `json:"cycle_count"`
+	SohFadePerMonth *float64 `json:"soh_fade_per_month"` // %/30天，正数表示衰减
+	EolThreshold    float64  `json:"eol_threshold"`      // 寿命阈值(%)
+	EolForecastDate *string  `json:"eol_forecast_date"`  // YYYY-MM-DD
+	EolDateLower    *string  `json:"eol_forecast_date_lower"`
+	EolDateUpper    *string  `json:"eol_forecast_date_upper"`
+	HealthUpdatedAt *string  `json:"health_updated_at"`
+
+	History []BatteryHealthHistoryPoint `json:"history"`
+}
+
+// BatteryHealthForecastListReq 寿命预测列表查询（预测在指定日期前跌破阈值的电池）
+type BatteryHealthForecastListReq struct {
+	PageReq
+	BeforeDate     *string `form:"before_date"` // YYYY-MM-DD，默认当年年底
+	DeviceNumber   *string `form:"device_number"`
+	BatteryModelID *string `form:"battery_model_id"`
+	InWarranty     *bool   `form:"in_warranty"` // 仅看预测日期仍在质保期内的电池
+}
+
+// BatteryHealthForecastItem 寿命预测行
+type BatteryHealthForecastItem struct {
+	DeviceID           string   `json:"device_id"`
+	DeviceNumber       string   `json:"device_number"`
+	BatteryModelName   *string  `json:"battery_model_name"`
+	OwnerOrgName       *string  `json:"owner_org_name"`
+	Soh                *float64 `json:"soh"`
+	CycleCount         *float64 `json:"cycle_count"`
+	SohFadePerMonth    *float64 `json:"soh_fade_per_month"`
+	EolForecastDate    string   `json:"eol_forecast_date"`
+	EolDateLower       *string  `json:"eol_forecast_date_lower"`
+	EolDateUpper       *string  `json:"eol_forecast_date_upper"`
+	WarrantyExpireDate *string  `json:"warranty_expire_date"`
+	InWarranty         bool     `json:"in_warranty"` // 预测日期是否早于质保到期
+}
+
+// BatteryHealthForecastListResp 寿命预测列表
+type BatteryHealthForecastListResp struct {
+	List     []BatteryHealthForecastItem `json:"list"`
+	Total    int64                       `json:"total"`
+	Page     int                         `json:"page"`
+	PageSize int                         `json:"page_size"`
+}
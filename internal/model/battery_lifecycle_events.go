@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+const TableNameBatteryLifecycleEvent = "battery_lifecycle_events"
+
+// 电池生命周期状态
+const (
+	BatteryLifecycleManufactured = "MANUFACTURED" // 已生产
+	BatteryLifecycleInStock      = "IN_STOCK"     // 厂家在库
+	BatteryLifecycleShipped      = "SHIPPED"      // 已发货（在途）
+	BatteryLifecycleAtDealer     = "AT_DEALER"    // 经销商在库
+	BatteryLifecycleActivated    = "ACTIVATED"    // 已售出激活
+	BatteryLifecycleInService    = "IN_SERVICE"   // 使用中
+	BatteryLifecycleReturned     = "RETURNED"     // 已退回
+	BatteryLifecycleRefurbished  = "REFURBISHED"  // 已翻新
+	BatteryLifecycleScrapped     = "SCRAPPED"     // 已报废
+)
+
+// 生命周期变更动作
+const (
+	BatteryLifecycleEventImport       = "IMPORT"
+	BatteryLifecycleEventAssignDealer = "ASSIGN_DEALER"
+	BatteryLifecycleEventTransfer     = "TRANSFER"
+	BatteryLifecycleEventBind         = "BIND"
+	BatteryLifecycleEventUnbind       = "UNBIND"
+	BatteryLifecycleEventFirstUse     = "FIRST_USE"
+	BatteryLifecycleEventManual       = "MANUAL"
+)
+
+// BatteryLifecycleEvent 电池生命周期状态变更记录
+type BatteryLifecycleEvent struct {
+	ID         string    `gorm:"column:id;primaryKey" json:"id"`
+	TenantID   string    `gorm:"column:tenant_id;not null" json:"tenant_id"`
+	DeviceID   string    `gorm:"column:device_id;not null" json:"device_id"`
+	FromStatus *string   `gorm:"column:from_status" json:"from_status"`
+	ToStatus   string    `gorm:"column:to_status;not null" json:"to_status"`
+	Event      string    `gorm:"column:event;not null" json:"event"`
+	OperatorID *string   `gorm:"column:operator_id" json:"operator_id"`
+	Reason     *string   `gorm:"column:reason" json:"reason"`
+	CreatedAt  time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (*BatteryLifecycleEvent) TableName() string {
+	return TableNameBatteryLifecycleEvent
+}
@@ -0,0 +1,32 @@
+package model
+
+// BatteryLifecycleTransitionReq 手动变更电池生命周期状态
+// 激活/使用中由 APP 绑定与遥测自动驱动，不可手动设置
+type BatteryLifecycleTransitionReq struct {
+	ToStatus string `json:"to_status" binding:"required,oneof=MANUFACTURED IN_STOCK SHIPPED AT_DEALER RETURNED REFURBISHED SCRAPPED"`
+	Reason   string `json:"reason" binding:"required,max=500"`
+}
+
+// BatteryLifecycleEventResp 生命周期变更记录
+type BatteryLifecycleEventResp struct {
+	ID           string  `json:"id"`
+	FromStatus   *string `json:"from_status"`
+	ToStatus     string  `json:"to_status"`
+	Event        string  `json:"event"`
+	OperatorID   *string `json:"operator_id"`
+	OperatorName *string `json:"operator_name"`
+	Reason       *string `json:"reason"`
+	CreatedAt    string  `json:"created_at"`
+}
+
+// BatteryLifecycleResp 电池生命周期详情
+type BatteryLifecycleResp struct {
+	DeviceID           string                      `json:"device_id"`
+	DeviceNumber       string                      `json:"device_number"`
+	LifecycleStatus    string                      `json:"lifecycle_status"`
+	ActivationStatus   *string                     `json:"activation_status"`
+	TransferStatus     *string                     `json:"transfer_status"`
+	LifecycleUpdatedAt *string                     `json:"lifecycle_updated_at"`
+	AllowedTransitions []string                    `json:"allowed_transitions"` // 可手动变更的目标状态
+	Events             []BatteryLifecycleEventResp `json:"events"`
+}
@@ -0,0 +1,69 @@
+package model
+
+import "time"
+
+const (
+	TableNameBatteryMaintenancePlan  = "battery_maintenance_plans"
+	TableNameBatteryMaintenanceOrder = "battery_maintenance_orders"
+)
+
+// 维护计划触发方式
+const (
+	MaintenanceTriggerCalendar = "CALENDAR"
+	MaintenanceTriggerCycle    = "CYCLE"
+	MaintenanceTriggerSoh      = "SOH"
+	MaintenanceTriggerAlarm    = "ALARM"
+)
+
+// 维护工单状态
+const (
+	MaintenanceOrderOpen      = "OPEN"
+	MaintenanceOrderClosed    = "CLOSED"
+	MaintenanceOrderCancelled = "CANCELLED"
+)
+
+// BatteryMaintenancePlan 电池维护计划（按电池型号）
+type BatteryMaintenancePlan struct {
+	ID                  string    `gorm:"column:id;primaryKey" json:"id"`
+	TenantID            string    `gorm:"column:tenant_id;not null" json:"tenant_id"`
+	BatteryModelID      string    `gorm:"column:battery_model_id;not null" json:"battery_model_id"`
+	Name                string    `gorm:"column:name;not null" json:"name"`
+	TriggerType         string    `gorm:"column:trigger_type;not null" json:"trigger_type"`
+	IntervalDays        *int32    `gorm:"column:interval_days" json:"interval_days"`
+	CycleInterval       *float64  `gorm:"column:cycle_interval" json:"cycle_interval"`
+	SohThreshold        *float64  `gorm:"column:soh_threshold" json:"soh_threshold"`
+	AlarmCount          *int32    `gorm:"column:alarm_count" json:"alarm_count"`
+	AlarmWindowDays     *int32    `gorm:"column:alarm_window_days" json:"alarm_window_days"`
+	MaintenanceType     *string   `gorm:"column:maintenance_type" json:"maintenance_type"`
+	LeadDays            int32     `gorm:"column:lead_days;not null" json:"lead_days"`
+	NotificationGroupID *string   `gorm:"column:notification_group_id" json:"notification_group_id"`
+	Enabled             bool      `gorm:"column:enabled;not null" json:"enabled"`
+	Remark              *string   `gorm:"column:remark" json:"remark"`
+	CreatedAt           time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt           time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (*BatteryMaintenancePlan) TableName() string {
+	return TableNameBatteryMaintenancePlan
+}
+
+// BatteryMaintenanceOrder 电池维护工单（由维护计划生成）
+type BatteryMaintenanceOrder struct {
+	ID                  string     `gorm:"column:id;primaryKey" json:"id"`
+	TenantID            string     `gorm:"column:tenant_id;not null" json:"tenant_id"`
+	PlanID              string     `gorm:"column:plan_id;not null" json:"plan_id"`
+	DeviceID            string     `gorm:"column:device_id;not null" json:"device_id"`
+	AssigneeOrgID       *string    `gorm:"column:assignee_org_id" json:"assignee_org_id"`
+	Status              string     `gorm:"column:status;not null" json:"status"`
+	DueDate             time.Time  `gorm:"column:due_date;not null" json:"due_date"`
+	TriggerReason       string     `gorm:"column:trigger_reason;not null" json:"trigger_reason"`
+	TriggerValue        *float64   `gorm:"column:trigger_value" json:"trigger_value"`
+	MaintenanceRecordID *string    `gorm:"column:maintenance_record_id" json:"maintenance_record_id"`
+	ClosedAt            *time.Time `gorm:"column:closed_at" json:"closed_at"`
+	CreatedAt           time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt           time.Time  `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (*BatteryMaintenanceOrder) TableName() string {
+	return TableNameBatteryMaintenanceOrder
+}
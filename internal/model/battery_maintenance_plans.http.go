@@ -0,0 +1,100 @@
+package model
+
+// BatteryMaintenancePlanCreateReq 新增维护计划
+type BatteryMaintenancePlanCreateReq struct {
+	BatteryModelID      string   `json:"battery_model_id" binding:"required"`
+	Name                string   `json:"name" binding:"required,max=100"`
+	TriggerType         string   `json:"trigger_type" binding:"required,oneof=CALENDAR CYCLE SOH ALARM"`
+	IntervalDays        *int32   `json:"interval_days" binding:"omitempty,min=1"`
+	CycleInterval       *float64 `json:"cycle_interval" binding:"omitempty,gt=0"`
+	SohThreshold        *float64 `json:"soh_threshold" binding:"omitempty,gt=0,lte=100"`
+	AlarmCount          *int32   `json:"alarm_count" binding:"omitempty,min=1"`
+	AlarmWindowDays     *int32   `json:"alarm_window_days" binding:"omitempty,min=1,max=365"`
+	MaintenanceType     *string  `json:"maintenance_type" binding:"omitempty,max=100"`
+	LeadDays            *int32   `json:"lead_days" binding:"omitempty,min=0,max=365"`
+	NotificationGroupID *string  `json:"notification_group_id"`
+	Enabled             *bool    `json:"enabled"`
+	Remark              *string  `json:"remark" binding:"omitempty,max=255"`
+}
+
+// BatteryMaintenancePlanUpdateReq 更新维护计划（触发方式与电池型号不可修改）
+type BatteryMaintenancePlanUpdateReq struct {
+	Name                *string  `json:"name" binding:"omitempty,max=100"`
+	IntervalDays        *int32   `json:"interval_days" binding:"omitempty,min=1"`
+	CycleInterval       *float64 `json:"cycle_interval" binding:"omitempty,gt=0"`
+	SohThreshold        *float64 `json:"soh_threshold" binding:"omitempty,gt=0,lte=100"`
+	AlarmCount          *int32   `json:"alarm_count" binding:"omitempty,min=1"`
+	AlarmWindowDays     *int32   `json:"alarm_window_days" binding:"omitempty,min=1,max=365"`
+	MaintenanceType     *string  `json:"maintenance_type" binding:"omitempty,max=100"`
+	LeadDays            *int32   `json:"lead_days" binding:"omitempty,min=0,max=365"`
+	NotificationGroupID *string  `json:"notification_group_id"`
+	Enabled             *bool    `json:"enabled"`
+	Remark              *string  `json:"remark" binding:"omitempty,max=255"`
+}
+
+// BatteryMaintenancePlanListReq 维护计划列表查询
+type BatteryMaintenancePlanListReq struct {
+	PageReq
+	BatteryModelID *string `form:"battery_model_id"`
+	TriggerType    *string `form:"trigger_type" binding:"omitempty,oneof=CALENDAR CYCLE SOH ALARM"`
+	Enabled        *bool   `form:"enabled"`
+}
+
+// BatteryMaintenancePlanResp 维护计划
+type BatteryMaintenancePlanResp struct {
+	BatteryMaintenancePlan
+	BatteryModelName *string `json:"battery_model_name"`
+	OpenOrders       int64   `json:"open_orders"`
+}
+
+// BatteryMaintenancePlanListResp 维护计划列表
+type BatteryMaintenancePlanListResp struct {
+	List     []BatteryMaintenancePlanResp `json:"list"`
+	Total    int64                        `json:"total"`
+	Page     int                          `json:"page"`
+	PageSize int                          `json:"page_size"`
+}
+
+// BatteryMaintenanceOrderListReq 维护工单列表查询
+type BatteryMaintenanceOrderListReq struct {
+	PageReq
+	Status       *string `form:"status" binding:"omitempty,oneof=OPEN CLOSED CANCELLED"`
+	PlanID       *string `form:"plan_id"`
+	DeviceNumber *string `form:"device_number"`
+	DueBefore    *string `form:"due_before"` // YYYY-MM-DD
+}
+
+// BatteryMaintenanceDueReq 即将到期工单查询
+type BatteryMaintenanceDueReq struct {
+	Days int `form:"days" binding:"omitempty,min=1,max=90"` // 默认7天（本周内）
+}
+
+// BatteryMaintenanceOrderItemResp 维护工单行
+type BatteryMaintenanceOrderItemResp struct {
+	ID                  string   `json:"id"`
+	PlanID              string   `json:"plan_id"`
+	PlanName            string   `json:"plan_name"`
+	TriggerType         string   `json:"trigger_type"`
+	MaintenanceType     *string  `json:"maintenance_type"`
+	DeviceID            string   `json:"device_id"`
+	DeviceNumber        string   `json:"device_number"`
+	BatteryModelName    *string  `json:"battery_model_name"`
+	AssigneeOrgID       *string  `json:"assignee_org_id"`
+	AssigneeOrgName     *string  `json:"assignee_org_name"`
+	Status              string   `json:"status"`
+	DueDate             string   `json:"due_date"`
+	Overdue             bool     `json:"overdue"`
+	TriggerReason       string   `json:"trigger_reason"`
+	TriggerValue        *float64 `json:"trigger_value"`
+	MaintenanceRecordID *string  `json:"maintenance_record_id"`
+	ClosedAt            *string  `json:"closed_at"`
+	CreatedAt           string   `json:"created_at"`
+}
+
+// BatteryMaintenanceOrderListResp 维护工单列表
+type BatteryMaintenanceOrderListResp struct {
+	List     []BatteryMaintenanceOrderItemResp `json:"list"`
+	Total    int64                             `json:"total"`
+	Page     int                               `json:"page"`
+	PageSize int                               `json:"page_size"`
+}
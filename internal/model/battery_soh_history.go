@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+const TableNameBatterySohHistory = "battery_soh_history"
+
+// SOH 来源
+const (
+	BatterySohSourceCapacity = "CAPACITY" // 按充放电容量估算
+	BatterySohSourceReported = "REPORTED" // BMS 自报
+)
+
+// BatterySohHistory 电池健康度日快照（由遥测计算）
+type BatterySohHistory struct {
+	ID                     string    `gorm:"column:id;primaryKey" json:"id"`
+	TenantID               string    `gorm:"column:tenant_id;not null" json:"tenant_id"`
+	DeviceID               string    `gorm:"column:device_id;not null" json:"device_id"`
+	StatDate               time.Time `gorm:"column:stat_date;not null" json:"stat_date"`
+	Soh                    *float64  `gorm:"column:soh" json:"soh"`
+	SohSource              string    `gorm:"column:soh_source;not null" json:"soh_source"`
+	CapacityAh             *float64  `gorm:"column:capacity_ah" json:"capacity_ah"`
+	EquivalentCycles       float64   `gorm:"column:equivalent_cycles;not null" json:"equivalent_cycles"`
+	TotalCycles            float64   `gorm:"column:total_cycles;not null" json:"total_cycles"`
+	CellDeltaAvgMv         *float64  `gorm:"column:cell_delta_avg_mv" json:"cell_delta_avg_mv"`
+	CellDeltaMaxMv         *float64  `gorm:"column:cell_delta_max_mv" json:"cell_delta_max_mv"`
+	InternalResistanceMohm *float64  `gorm:"column:internal_resistance_mohm" json:"internal_resistance_mohm"`
+	TemperatureMax         *float64  `gorm:"column:temperature_max" json:"temperature_max"`
+	SampleCount            int32     `gorm:"column:sample_count;not null" json:"sample_count"`
+	CreatedAt              time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (*BatterySohHistory) TableName() string {
+	return TableNameBatterySohHistory
+}
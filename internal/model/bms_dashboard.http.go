@@ -34,6 +34,8 @@ type BmsDashboardAlarmOverviewResp struct {
 	StatusCounts []BmsDashboardAlarmStatusCount `json:"status_counts"`
 	Top          []BmsDashboardAlarmTopItem     `json:"top"`
 	Trend        []BmsDashboardAlarmTrendPoint  `json:"trend"`
+	MttaSeconds  *float64                       `json:"mtta_seconds"` // 平均确认时长（秒），无已确认告警时为空
+	MttrSeconds  *float64                       `json:"mttr_seconds"` // 平均解决时长（秒），无已解决告警时为空
 }
 
 // BmsDashboardOnlineTrendPoint 在线趋势点（按小时/按采样）
@@ -48,3 +50,20 @@ type BmsDashboardOnlineTrendPoint struct {
 type BmsDashboardOnlineTrendResp struct {
 	Points []BmsDashboardOnlineTrendPoint `json:"points"`
 }
+
+// BmsDashboardSohBucket SOH 分布区间
+type BmsDashboardSohBucket struct {
+	Range string `json:"range"` // 如 "90-100"
+	Count int64  `json:"count"`
+}
+
+// BmsDashboardBatteryHealthResp 电池健康概览
+type BmsDashboardBatteryHealthResp struct {
+	SohBuckets    []BmsDashboardSohBucket `json:"soh_buckets"`
+	AvgSoh        *float64                `json:"avg_soh"`
+	AvgCycleCount *float64                `json:"avg_cycle_count"`
+	EolThreshold  float64                 `json:"eol_threshold"`
+
+	EolThisYear           int64 `json:"eol_this_year"`             // 预测今年内跌破阈值的电池数
+	EolThisYearInWarranty int64 `json:"eol_this_year_in_warranty"` // 其中仍在质保期内的数量
+}
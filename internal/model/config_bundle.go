@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+const TableNameConfigBundleBinding = "config_bundle_bindings"
+
+// 配置包中的对象类型
+const (
+	ConfigBundleDeviceTemplate  = "DEVICE_TEMPLATE"
+	ConfigBundleDeviceConfig    = "DEVICE_CONFIG"
+	ConfigBundleAlarmConfig     = "ALARM_CONFIG"
+	ConfigBundleSceneAutomation = "SCENE_AUTOMATION"
+)
+
+// ConfigBundleBinding 配置包对象 key 与本实例对象ID的映射
+type ConfigBundleBinding struct {
+	TenantID   string    `gorm:"column:tenant_id;primaryKey" json:"tenant_id"`
+	ObjectType string    `gorm:"column:object_type;primaryKey" json:"object_type"`
+	BundleKey  string    `gorm:"column:bundle_key;primaryKey" json:"bundle_key"`
+	ObjectID   string    `gorm:"column:object_id;not null" json:"object_id"`
+	UpdatedAt  time.Time `gorm:"column:updated_at;not null" json:"updated_at"`
+}
+
+func (*ConfigBundleBinding) TableName() string {
+	return TableNameConfigBundleBinding
+}
@@ -0,0 +1,196 @@
+package model
+
+import "time"
+
+// ConfigBundleAPIVersion 配置包格式版本
+const ConfigBundleAPIVersion = "config.bundle/v1"
+
+// 计划中的对象变更
+const (
+	ConfigBundleCreate    = "CREATE"
+	ConfigBundleUpdate    = "UPDATE"
+	ConfigBundleUnchanged = "UNCHANGED"
+)
+
+// ConfigBundle 声明式配置包（YAML/JSON）：对象以 key 标识，对象之间以 key 引用，不包含本实例的ID
+type ConfigBundle struct {
+	APIVersion       string                  `json:"api_version" validate:"required,eq=config.bundle/v1"`
+	DeviceTemplates  []BundleDeviceTemplate  `json:"device_templates,omitempty" validate:"max=1000,dive"`
+	DeviceConfigs    []BundleDeviceConfig    `json:"device_configs,omitempty" validate:"max=1000,dive"`
+	AlarmConfigs     []BundleAlarmConfig     `json:"alarm_configs,omitempty" validate:"max=1000,dive"`
+	SceneAutomations []BundleSceneAutomation `json:"scene_automations,omitempty" validate:"max=1000,dive"`
+}
+
+// BundleDeviceTemplate 设备模板及物模型
+// JSON 类型的列（如图表配置）在配置包中为结构化数据
+type BundleDeviceTemplate struct {
+	Key            string            `json:"key" validate:"required,max=255"`
+	Name           string            `json:"name" validate:"required,max=255"`
+	Author         *string           `json:"author,omitempty"`
+	Version        *string           `json:"version,omitempty"`
+	Description    *string           `json:"description,omitempty"`
+	Label          *string           `json:"label,omitempty"`
+	Path           *string           `json:"path,omitempty"`
+	WebChartConfig interface{}       `json:"web_chart_config,omitempty"`
+	AppChartConfig interface{}       `json:"app_chart_config,omitempty"`
+	Remark         *string           `json:"remark,omitempty"`
+	Telemetry      []BundleModelItem `json:"telemetry,omitempty" validate:"dive"`
+	Attributes     []BundleModelItem `json:"attributes,omitempty" validate:"dive"`
+	Events         []BundleModelItem `json:"events,omitempty" validate:"dive"`
+	Commands       []BundleModelItem `json:"commands,omitempty" validate:"dive"`
+}
+
+// BundleModelItem 物模型条目，以标识符区分
+type BundleModelItem struct {
+	Identifier     string      `json:"identifier" validate:"required,max=255"`
+	Name           *string     `json:"name,omitempty"`
+	ReadWriteFlag  *string     `json:"read_write_flag,omitempty" validate:"omitempty,oneof=R W RW"` // 遥测、属性
+	DataType       *string     `json:"data_type,omitempty"`                                         // 遥测、属性
+	Unit           *string     `json:"unit,omitempty"`                                              // 遥测、属性
+	Params         interface{} `json:"params,omitempty"`                                            // 事件、命令
+	Description    *string     `json:"description,omitempty"`
+	AdditionalInfo interface{} `json:"additional_info,omitempty"`
+	Remark         *string     `json:"remark,omitempty"`
+}
+
+// BundleDeviceConfig 设备配置及其数据脚本、Topic 映射；设备模板以 key 引用
+// 模板密钥不导出，新建时生成
+type BundleDeviceConfig struct {
+	Key            string               `json:"key" validate:"required,max=255"`
+	Name           string               `json:"name" validate:"required,max=99"`
+	DeviceTemplate *string              `json:"device_template,omitempty" validate:"omitempty,max=255"`
+	DeviceType     string               `json:"device_type" validate:"required,oneof=1 2 3"`
+	ProtocolType   *string              `json:"protocol_type,omitempty"`
+	VoucherType    *string              `json:"voucher_type,omitempty"`
+	ProtocolConfig interface{}          `json:"protocol_config,omitempty"`
+	DeviceConnType *string              `json:"device_conn_type,omitempty" validate:"omitempty,oneof=A B"`
+	AdditionalInfo interface{}          `json:"additional_info,omitempty"`
+	OtherConfig    interface{}          `json:"other_config,omitempty"`
+	AutoRegister   int16                `json:"auto_register"`
+	ImageURL       *string              `json:"image_url,omitempty"`
+	Description    *string              `json:"description,omitempty"`
+	Remark         *string              `json:"remark,omitempty"`
+	DataScripts    []BundleDataScript   `json:"data_scripts,omitempty" validate:"dive"`
+	TopicMappings  []BundleTopicMapping `json:"topic_mappings,omitempty" validate:"dive"`
+}
+
+// BundleDataScript 数据脚本，在同一设备配置内以名称区分
+type BundleDataScript struct {
+	Name        string  `json:"name" validate:"required,max=99"`
+	ScriptType  string  `json:"script_type" validate:"required,oneof=A B C D E F"`
+	EnableFlag  string  `json:"enable_flag" validate:"required,oneof=Y N"`
+	Content     *string `json:"content,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Remark      *string `json:"remark,omitempty"`
+}
+
+// BundleTopicMapping Topic 映射，在同一设备配置内以名称区分
+type BundleTopicMapping struct {
+	Name        string  `json:"name" validate:"required,max=500"`
+	Direction   string  `json:"direction" validate:"required,oneof=up down"`
+	SourceTopic string  `json:"source_topic" validate:"required,max=500"`
+	TargetTopic string  `json:"target_topic" validate:"required,max=500"`
+	Priority    int32   `json:"priority" validate:"gte=0,lte=100000"`
+	Enabled     bool    `json:"enabled"`
+	Description *string `json:"description,omitempty"`
+}
+
+// BundleAlarmConfig 告警配置；通知组以名称引用
+type BundleAlarmConfig struct {
+	Key                         string  `json:"key" validate:"required,max=255"`
+	Name                        string  `json:"name" validate:"required,max=255"`
+	Description                 *string `json:"description,omitempty"`
+	ProcessingSuggestions       *string `json:"processing_suggestions,omitempty"`
+	AlarmLevel                  string  `json:"alarm_level" validate:"required,oneof=H M L"`
+	NotificationGroup           *string `json:"notification_group,omitempty"`
+	Enabled                     string  `json:"enabled" validate:"required,oneof=Y N"`
+	AckSlaMinutes               *int32  `json:"ack_sla_minutes,omitempty" validate:"omitempty,min=0"`
+	ResolveSlaMinutes           *int32  `json:"resolve_sla_minutes,omitempty" validate:"omitempty,min=0"`
+	EscalationMinutes           *int32  `json:"escalation_minutes,omitempty" validate:"omitempty,min=0"`
+	EscalationNotificationGroup *string `json:"escalation_notification_group,omitempty"`
+	GroupBy                     *string `json:"group_by,omitempty" validate:"omitempty,oneof=SCENE GATEWAY CONFIG"`
+	GroupWindowSeconds          *int32  `json:"group_window_seconds,omitempty" validate:"omitempty,min=0,max=86400"`
+	FlapThreshold               *int32  `json:"flap_threshold,omitempty" validate:"omitempty,min=0"`
+	FlapWindowSeconds           *int32  `json:"flap_window_seconds,omitempty" validate:"omitempty,min=0,max=86400"`
+	Remark                      *string `json:"remark,omitempty"`
+}
+
+// BundleSceneAutomation 场景联动，条件与动作沿用场景联动接口的格式，其中的对象ID替换为引用：
+// 条件 trigger_source：单个设备(10)为设备编号，单类设备(11)为设备配置 key，分组聚合(12)为分组名称，模板聚合(13)为设备模板 key；
+// 动作 action_target：单个设备(10)为设备编号，单类设备(11)为设备配置 key，激活场景(20)为场景名称，告警(30)为告警配置 key
+type BundleSceneAutomation struct {
+	Key                    string              `json:"key" validate:"required,max=255"`
+	Name                   string              `json:"name" validate:"required,max=36"`
+	Description            *string             `json:"description,omitempty"`
+	Enabled                string              `json:"enabled" validate:"required,oneof=Y N"`
+	Timezone               *string             `json:"timezone,omitempty" validate:"omitempty,max=64"`
+	HolidayCalendar        *string             `json:"holiday_calendar,omitempty"` // 节假日日历名称
+	TriggerConditionGroups [][]BundleCondition `json:"trigger_condition_groups" validate:"required,dive,required,dive"`
+	Actions                []BundleAction      `json:"actions" validate:"required,dive"`
+	Remark                 *string             `json:"remark,omitempty"`
+}
+
+// BundleCondition 场景联动条件，字段同 Condition
+type BundleCondition struct {
+	TriggerConditionsType string     `json:"trigger_conditions_type" validate:"required,oneof=10 11 12 13 20 21 22"`
+	TriggerSource         *string    `json:"trigger_source,omitempty"`
+	TriggerParamType      *string    `json:"trigger_param_type,omitempty"`
+	TriggerParam          *string    `json:"trigger_param,omitempty"`
+	TriggerOperator       *string    `json:"trigger_operator,omitempty"`
+	TriggerValue          *string    `json:"trigger_value,omitempty"`
+	HoldDuration          *int32     `json:"hold_duration,omitempty" validate:"omitempty,min=0,max=86400"`
+	SampleWindow          *int32     `json:"sample_window,omitempty" validate:"omitempty,min=0,max=100"`
+	SampleHits            *int32     `json:"sample_hits,omitempty" validate:"omitempty,min=0,max=100"`
+	ClearValue            *string    `json:"clear_value,omitempty"`
+	WindowFunc            *string    `json:"window_func,omitempty" validate:"omitempty,oneof=RATE DELTA AVG MIN MAX STDDEV"`
+	WindowSeconds         *int32     `json:"window_seconds,omitempty" validate:"omitempty,min=0,max=86400"`
+	AggregateFunc         *string    `json:"aggregate_func,omitempty" validate:"omitempty,oneof=COUNT PERCENT AVG MIN MAX SUM"`
+	MemberOperator        *string    `json:"member_operator,omitempty"`
+	MemberValue           *string    `json:"member_value,omitempty"`
+	AggregateBySubgroup   *bool      `json:"aggregate_by_subgroup,omitempty"`
+	ExecutionTime         *time.Time `json:"execution_time,omitempty"`
+	ExpirationTime        *int       `json:"expiration_time,omitempty"`
+	TaskType              *string    `json:"task_type,omitempty"`
+	Params                *string    `json:"params,omitempty"`
+}
+
+// BundleAction 场景联动动作，字段同 Action
+type BundleAction struct {
+	ActionType      string  `json:"action_type" validate:"required,oneof=10 11 20 30 40"`
+	ActionTarget    *string `json:"action_target,omitempty"`
+	ActionParamType *string `json:"action_param_type,omitempty"`
+	ActionParam     *string `json:"action_param,omitempty"`
+	ActionValue     *string `json:"action_value,omitempty"`
+}
+
+// ExportConfigBundleReq 导出配置包
+type ExportConfigBundleReq struct {
+	Format string `json:"format" form:"format" validate:"omitempty,oneof=yaml json"` // 默认 yaml
+	// Kinds 导出的对象类型，逗号分隔，默认全部：device_templates,device_configs,alarm_configs,scene_automations
+	Kinds *string `json:"kinds" form:"kinds" validate:"omitempty,max=255"`
+}
+
+// ApplyConfigBundleReq 应用配置包的参数（配置包为请求体）
+type ApplyConfigBundleReq struct {
+	// PlanFingerprint 预览计划时返回的指纹，传入时当前状态与预览时不一致则拒绝应用
+	PlanFingerprint *string `json:"plan_fingerprint" form:"plan_fingerprint" validate:"omitempty,max=64"`
+}
+
+// ConfigBundleChange 计划中的一个对象
+type ConfigBundleChange struct {
+	ObjectType string   `json:"object_type"`
+	Key        string   `json:"key"`
+	Name       string   `json:"name"`
+	Action     string   `json:"action"`               // CREATE / UPDATE / UNCHANGED
+	ObjectID   string   `json:"object_id,omitempty"`  // 本实例中的对象ID（新建时为将要使用的ID）
+	MatchedBy  string   `json:"matched_by,omitempty"` // binding-已有映射 name-按名称匹配
+	Fields     []string `json:"fields,omitempty"`     // 变更的字段
+}
+
+// ConfigBundlePlan 配置包与当前状态的差异
+type ConfigBundlePlan struct {
+	Fingerprint string               `json:"fingerprint"`
+	Changes     []ConfigBundleChange `json:"changes"`
+	Summary     map[string]int       `json:"summary"` // 按变更类型计数
+	Applied     bool                 `json:"applied"`
+}
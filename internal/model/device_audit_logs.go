@@ -0,0 +1,54 @@
+package model
+
+import "time"
+
+const TableNameDeviceAuditLog = "device_audit_logs"
+
+// 设备审计动作
+const (
+	DeviceAuditCommand      = "COMMAND"       // 指令下发
+	DeviceAuditAttributeSet = "ATTRIBUTE_SET" // 属性设置
+	DeviceAuditTelemetrySet = "TELEMETRY_SET" // 遥测控制
+	DeviceAuditOtaPush      = "OTA_PUSH"      // OTA 升级推送
+	DeviceAuditTransfer     = "TRANSFER"      // 归属转移
+	DeviceAuditBind         = "BIND"          // APP 绑定
+	DeviceAuditUnbind       = "UNBIND"        // APP 解绑
+	DeviceAuditForceUnbind  = "FORCE_UNBIND"  // 管理员强制解绑
+)
+
+// 设备审计来源
+const (
+	DeviceAuditSourceManual = "MANUAL"
+	DeviceAuditSourceAuto   = "AUTO"
+)
+
+// 设备审计结果
+const (
+	DeviceAuditSuccess = "SUCCESS"
+	DeviceAuditFailed  = "FAILED"
+)
+
+// DeviceAuditLog 设备操作审计日志（仅追加，按租户哈希链防篡改）
+type DeviceAuditLog struct {
+	ID           string    `gorm:"column:id;primaryKey" json:"id"`
+	TenantID     string    `gorm:"column:tenant_id;not null" json:"tenant_id"`
+	Seq          int64     `gorm:"column:seq;not null" json:"seq"`
+	DeviceID     string    `gorm:"column:device_id;not null" json:"device_id"`
+	Action       string    `gorm:"column:action;not null" json:"action"`
+	Source       string    `gorm:"column:source;not null" json:"source"`
+	ActorID      *string   `gorm:"column:actor_id" json:"actor_id"`
+	ActorOrgID   *string   `gorm:"column:actor_org_id" json:"actor_org_id"`
+	Target       *string   `gorm:"column:target" json:"target"`
+	BeforeValue  *string   `gorm:"column:before_value" json:"before_value"`
+	AfterValue   *string   `gorm:"column:after_value" json:"after_value"`
+	Result       string    `gorm:"column:result;not null" json:"result"`
+	ErrorMessage *string   `gorm:"column:error_message" json:"error_message"`
+	RefID        *string   `gorm:"column:ref_id" json:"ref_id"`
+	PrevHash     string    `gorm:"column:prev_hash;not null" json:"prev_hash"`
+	Hash         string    `gorm:"column:hash;not null" json:"hash"`
+	CreatedAt    time.Time `gorm:"column:created_at;not null" json:"created_at"`
+}
+
+func (*DeviceAuditLog) TableName() string {
+	return TableNameDeviceAuditLog
+}
@@ -0,0 +1,42 @@
+package model
+
+// GetDeviceAuditLogListByPageReq 设备审计日志查询
+type GetDeviceAuditLogListByPageReq struct {
+	PageReq
+	DeviceAuditLogFilter
+}
+
+// DeviceAuditLogFilter 设备审计日志筛选条件（查询与导出共用）
+type DeviceAuditLogFilter struct {
+	DeviceID  *string `json:"device_id" form:"device_id" validate:"omitempty,max=36"`
+	Action    *string `json:"action" form:"action" validate:"omitempty,oneof=COMMAND ATTRIBUTE_SET TELEMETRY_SET OTA_PUSH TRANSFER BIND UNBIND FORCE_UNBIND"`
+	ActorID   *string `json:"actor_id" form:"actor_id" validate:"omitempty,max=36"`
+	Result    *string `json:"result" form:"result" validate:"omitempty,oneof=SUCCESS FAILED"`
+	StartTime *string `json:"start_time" form:"start_time" validate:"omitempty"` // 格式：2006-01-02 15:04:05
+	EndTime   *string `json:"end_time" form:"end_time" validate:"omitempty"`     // 格式：2006-01-02 15:04:05
+}
+
+// DeviceAuditLogResp 设备审计日志
+type DeviceAuditLogResp struct {
+	DeviceAuditLog
+	DeviceNumber *string `json:"device_number"`
+	DeviceName   *string `json:"device_name"`
+	ActorName    *string `json:"actor_name"`
+	ActorOrgName *string `json:"actor_org_name"`
+}
+
+// DeviceAuditVerifyReq 哈希链校验范围（序号），不传则校验全部
+type DeviceAuditVerifyReq struct {
+	FromSeq *int64 `json:"from_seq" form:"from_seq" validate:"omitempty,gte=1"`
+	ToSeq   *int64 `json:"to_seq" form:"to_seq" validate:"omitempty,gte=1"`
+}
+
+// DeviceAuditVerifyResp 哈希链校验结果
+type DeviceAuditVerifyResp struct {
+	Valid     bool   `json:"valid"`
+	Checked   int64  `json:"checked"`    // 已校验记录数
+	LastSeq   int64  `json:"last_seq"`   // 最后一条通过校验的序号
+	LastHash  string `json:"last_hash"`  // 最后一条通过校验的哈希，可留存用于发现尾部截断
+	BrokenSeq *int64 `json:"broken_seq"` // 首个校验失败的序号
+	Reason    string `json:"reason,omitempty"`
+}
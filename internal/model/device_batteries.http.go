@@ -17,6 +17,9 @@ type BatteryListReq struct {
 	// 激活状态：ACTIVE/INACTIVE
 	ActivationStatus *string `form:"activation_status" binding:"omitempty,oneof=ACTIVE INACTIVE"`
 
+	// 生命周期状态
+	LifecycleStatus *string `form:"lifecycle_status" binding:"omitempty,oneof=MANUFACTURED IN_STOCK SHIPPED AT_DEALER ACTIVATED IN_SERVICE RETURNED REFURBISHED SCRAPPED"`
+
 	// 持有方组织ID（替代 DealerID）
 	OwnerOrgID *string `form:"owner_org_id"`
 
@@ -64,6 +67,8 @@ type BatteryListItemResp struct {
 	Soh            *float64 `json:"soh"`
 	CurrentVersion *string  `json:"current_version"`
 	TransferStatus *string  `json:"transfer_status"`
+
+	LifecycleStatus *string `json:"lifecycle_status"`
 }
 
 // BatteryListResp 电池列表响应
@@ -115,6 +120,9 @@ type BatteryExportReq struct {
 	// 激活状态：ACTIVE/INACTIVE
 	ActivationStatus *string `form:"activation_status" binding:"omitempty,oneof=ACTIVE INACTIVE"`
 
+	// 生命周期状态
+	LifecycleStatus *string `form:"lifecycle_status" binding:"omitempty,oneof=MANUFACTURED IN_STOCK SHIPPED AT_DEALER ACTIVATED IN_SERVICE RETURNED REFURBISHED SCRAPPED"`
+
 	// 经销商
 	DealerID *string `form:"dealer_id"`
 
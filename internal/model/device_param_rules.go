@@ -0,0 +1,51 @@
+package model
+
+import "time"
+
+const TableNameDeviceParamRule = "device_param_rules"
+
+// 设备参数权限的授权对象
+const (
+	DeviceParamSubjectRole    = "ROLE"
+	DeviceParamSubjectOrgType = "ORG_TYPE"
+)
+
+// 设备参数类型（与 configs/device_param_permissions.json 一致）
+const (
+	DeviceParamTEL  = "TEL"
+	DeviceParamATTR = "ATTR"
+	DeviceParamCMD  = "CMD"
+)
+
+// 设备参数操作
+const (
+	DeviceParamRead    = "read"
+	DeviceParamWrite   = "write"
+	DeviceParamExecute = "execute"
+)
+
+// 规则效果
+const (
+	DeviceParamAllow = "allow"
+	DeviceParamDeny  = "deny"
+)
+
+// DeviceParamRule 设备参数权限规则（物模型标识符级别）
+type DeviceParamRule struct {
+	ID               string    `gorm:"column:id;primaryKey" json:"id"`
+	TenantID         string    `gorm:"column:tenant_id;not null" json:"tenant_id"`
+	SubjectType      string    `gorm:"column:subject_type;not null" json:"subject_type"`
+	SubjectID        string    `gorm:"column:subject_id;not null" json:"subject_id"`
+	DeviceTemplateID *string   `gorm:"column:device_template_id" json:"device_template_id"` // 空表示所有模板
+	ParamType        string    `gorm:"column:param_type;not null" json:"param_type"`
+	Identifier       string    `gorm:"column:identifier;not null" json:"identifier"` // * 表示全部标识符
+	Action           string    `gorm:"column:action;not null" json:"action"`
+	Effect           string    `gorm:"column:effect;not null" json:"effect"`
+	Remark           *string   `gorm:"column:remark" json:"remark"`
+	CreatedAt        time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt        time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (*DeviceParamRule) TableName() string {
+	return TableNameDeviceParamRule
+}
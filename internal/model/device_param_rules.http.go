@@ -0,0 +1,23 @@
+package model
+
+// DeviceParamRuleListReq 设备参数权限规则查询
+type DeviceParamRuleListReq struct {
+	SubjectType      *string `json:"subject_type" form:"subject_type" validate:"omitempty,oneof=ROLE ORG_TYPE"`
+	SubjectID        *string `json:"subject_id" form:"subject_id" validate:"omitempty,max=64"`
+	DeviceTemplateID *string `json:"device_template_id" form:"device_template_id" validate:"omitempty,max=36"`
+}
+
+// DeviceParamRuleItem 单条规则
+type DeviceParamRuleItem struct {
+	DeviceTemplateID *string `json:"device_template_id" validate:"omitempty,max=36"`
+	ParamType        string  `json:"param_type" validate:"required,oneof=TEL ATTR CMD"`
+	Identifier       string  `json:"identifier" validate:"required,max=255"`
+	Action           string  `json:"action" validate:"required,oneof=read write execute"`
+	Effect           string  `json:"effect" validate:"required,oneof=allow deny"`
+	Remark           *string `json:"remark" validate:"omitempty,max=255"`
+}
+
+// DeviceParamRuleReplaceReq 整体替换某个角色或机构类型的规则
+type DeviceParamRuleReplaceReq struct {
+	Rules []DeviceParamRuleItem `json:"rules" validate:"max=500,dive"`
+}
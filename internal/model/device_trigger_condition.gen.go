@@ -19,7 +19,17 @@ type DeviceTriggerCondition struct {
 	TriggerOperator      *string `gorm:"column:trigger_operator;comment:运算符 =：等于 !=：不等于 >：大于 <：小于 >=：大于等于 <=：小于等于 between：介于 in：包含在列表内" json:"trigger_operator"`                                                              // 运算符 =：等于 !=：不等于 >：大于 <：小于 >=：大于等于 <=：小于等于 between：介于 in：包含在列表内
 	TriggerValue         string  `gorm:"column:trigger_value;not null;comment:取值条件类型为10,11，运算符是为7时，假设最大值6最小值2, 格式为2-6；设备状态条件类型为10,11，运算符为8时，多个值英文逗号隔开条件类型为 条件类型是22，示例137|HH:mm:ss+00:00|HH:mm:ss+00:00" json:"trigger_value"` // 取值条件类型为10,11，运算符是为7时，假设最大值6最小值2, 格式为2-6；设备状态条件类型为10,11，运算符为8时，多个值英文逗号隔开条件类型为 条件类型是22，示例137|HH:mm:ss+00:00|HH:mm:ss+00:00
 	Remark               *string `gorm:"column:remark" json:"remark"`
-	TenantID             string  `gorm:"column:tenant_id;not null;comment:租户ID" json:"tenant_id"` // 租户ID
+	TenantID             string  `gorm:"column:tenant_id;not null;comment:租户ID" json:"tenant_id"`                                                                    // 租户ID
+	HoldDuration         *int32  `gorm:"column:hold_duration;comment:持续时间(秒)：条件连续成立达到该时长才触发" json:"hold_duration"`                                                   // 持续时间(秒)：条件连续成立达到该时长才触发
+	SampleWindow         *int32  `gorm:"column:sample_window;comment:N/M 采样窗口 M：最近 M 次上报" json:"sample_window"`                                                      // N/M 采样窗口 M：最近 M 次上报
+	SampleHits           *int32  `gorm:"column:sample_hits;comment:N/M 采样命中数 N：窗口内至少 N 次成立才触发" json:"sample_hits"`                                                   // N/M 采样命中数 N：窗口内至少 N 次成立才触发
+	ClearValue           *string `gorm:"column:clear_value;comment:恢复阈值（回差）：触发后数值越过该阈值才恢复，仅支持 > >= < <=" json:"clear_value"`                                         // 恢复阈值（回差）：触发后数值越过该阈值才恢复，仅支持 > >= < <=
+	WindowFunc           *string `gorm:"column:window_func;comment:窗口统计函数 RATE：变化率(每分钟) DELTA：差值 AVG MIN MAX STDDEV，为空时使用原始值" json:"window_func"`                    // 窗口统计函数 RATE：变化率(每分钟) DELTA：差值 AVG MIN MAX STDDEV，为空时使用原始值
+	WindowSeconds        *int32  `gorm:"column:window_seconds;comment:统计窗口长度(秒)" json:"window_seconds"`                                                              // 统计窗口长度(秒)
+	AggregateFunc        *string `gorm:"column:aggregate_func;comment:聚合函数（条件类型12/13）COUNT：满足成员条件的设备数 PERCENT：满足成员条件的设备占比(%) AVG MIN MAX SUM" json:"aggregate_func"` // 聚合函数（条件类型12/13）COUNT：满足成员条件的设备数 PERCENT：满足成员条件的设备占比(%) AVG MIN MAX SUM
+	MemberOperator       *string `gorm:"column:member_operator;comment:成员条件运算符（COUNT/PERCENT）" json:"member_operator"`                                               // 成员条件运算符（COUNT/PERCENT）
+	MemberValue          *string `gorm:"column:member_value;comment:成员条件取值（COUNT/PERCENT），状态条件为 ON-LINE/OFF-LINE" json:"member_value"`                               // 成员条件取值（COUNT/PERCENT），状态条件为 ON-LINE/OFF-LINE
+	AggregateBySubgroup  *bool   `gorm:"column:aggregate_by_subgroup;comment:按直属子分组分别聚合（条件类型12），任一子分组满足即触发" json:"aggregate_by_subgroup"`                            // 按直属子分组分别聚合（条件类型12），任一子分组满足即触发
 }
 
 // TableName DeviceTriggerCondition's table name
@@ -3,6 +3,8 @@ package model
 const (
 	DEVICE_TRIGGER_CONDITION_TYPE_ONE      = "10" // 单个设备
 	DEVICE_TRIGGER_CONDITION_TYPE_MULTIPLE = "11" // 单类设备
+	DEVICE_TRIGGER_CONDITION_TYPE_GROUP    = "12" // 设备分组聚合
+	DEVICE_TRIGGER_CONDITION_TYPE_TEMPLATE = "13" // 设备模板聚合
 	DEVICE_TRIGGER_CONDITION_TYPE_TIME     = "22" // 时间范围
 	// 条件类型
 	TRIGGER_PARAM_TYPE_TEL        = "TEL"        // 遥测TEL
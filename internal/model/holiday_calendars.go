@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+const (
+	TableNameHolidayCalendar     = "holiday_calendars"
+	TableNameHolidayCalendarDate = "holiday_calendar_dates"
+)
+
+// HolidayCalendar 节假日日历
+type HolidayCalendar struct {
+	ID        string    `gorm:"column:id;primaryKey" json:"id"`
+	TenantID  string    `gorm:"column:tenant_id;not null" json:"tenant_id"`
+	Name      string    `gorm:"column:name;not null" json:"name"`
+	Remark    *string   `gorm:"column:remark" json:"remark"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (*HolidayCalendar) TableName() string {
+	return TableNameHolidayCalendar
+}
+
+// HolidayCalendarDate 节假日日期（本地日期）
+type HolidayCalendarDate struct {
+	CalendarID  string    `gorm:"column:calendar_id;primaryKey" json:"calendar_id"`
+	HolidayDate time.Time `gorm:"column:holiday_date;primaryKey;type:date" json:"holiday_date"`
+	Name        *string   `gorm:"column:name" json:"name"`
+}
+
+func (*HolidayCalendarDate) TableName() string {
+	return TableNameHolidayCalendarDate
+}
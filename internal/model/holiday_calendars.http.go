@@ -0,0 +1,30 @@
+package model
+
+// HolidayCalendarReq 新增/更新节假日日历
+type HolidayCalendarReq struct {
+	Name   string  `json:"name" validate:"required,max=100"`
+	Remark *string `json:"remark" validate:"omitempty,max=255"`
+}
+
+// HolidayDateItem 节假日日期
+type HolidayDateItem struct {
+	Date string  `json:"date" validate:"required"` // YYYY-MM-DD
+	Name *string `json:"name" validate:"omitempty,max=100"`
+}
+
+// HolidayCalendarDatesReq 设置日历日期（整体替换指定年份的日期）
+type HolidayCalendarDatesReq struct {
+	Year  int               `json:"year" validate:"required,min=2000,max=2100"`
+	Dates []HolidayDateItem `json:"dates" validate:"dive"`
+}
+
+// HolidayCalendarResp 节假日日历
+type HolidayCalendarResp struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	Remark    *string           `json:"remark"`
+	DateCount int64             `json:"date_count"`
+	Dates     []HolidayDateItem `json:"dates,omitempty"`
+	CreatedAt string            `json:"created_at"`
+	UpdatedAt string            `json:"updated_at"`
+}
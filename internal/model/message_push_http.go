@@ -28,6 +28,7 @@ type MessagePushSend struct {
 type MessagePushSendPayload struct {
 	AlarmConfigId string `json:"alarm_config_id"`
 	TenantId      string `json:"tenant_id"`
+	InboxId       string `json:"inbox_id,omitempty"` // 成员通知站内信id
 }
 
 type MessagePushSendRes struct {
@@ -14,7 +14,7 @@ const TableNameMessagePushLog = "message_push_log"
 type MessagePushLog struct {
 	ID          string    `gorm:"column:id;primaryKey" json:"id"`
 	UserID      string    `gorm:"column:user_id;not null;comment:用户id" json:"user_id"`                 // 用户id
-	MessageType int64     `gorm:"column:message_type;not null;comment:消息类型 1告警消息" json:"message_type"` // 消息类型 1告警消息
+	MessageType int64     `gorm:"column:message_type;not null;comment:消息类型 1告警消息 2成员通知" json:"message_type"` // 消息类型 1告警消息 2成员通知
 	Content     string    `gorm:"column:content;not null;comment:消息体内容" json:"content"`                // 消息体内容
 	Status      int16     `gorm:"column:status;not null;comment:1推送成功 2推送失败" json:"status"`            // 1推送成功 2推送失败
 	ErrMessage  string    `gorm:"column:err_message;not null;comment:错误信息" json:"err_message"`         // 错误信息
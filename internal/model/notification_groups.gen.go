@@ -13,15 +13,17 @@ const TableNameNotificationGroup = "notification_groups"
 // NotificationGroup mapped from table <notification_groups>
 type NotificationGroup struct {
 	ID                 string    `gorm:"column:id;primaryKey" json:"id"`
-	Name               string    `gorm:"column:name;not null;comment:名称" json:"name"`                                                                                       // 名称
-	NotificationType   string    `gorm:"column:notification_type;not null;comment:通知类型MEMBER-成员通知 EMAIL-邮箱通知 SME-短信通知 VOICE-语音通知 WEBHOOK-webhook" json:"notification_type"` // 通知类型MEMBER-成员通知 EMAIL-邮箱通知 SME-短信通知 VOICE-语音通知 WEBHOOK-webhook
-	Status             string    `gorm:"column:status;not null;comment:通知状态ON-启用 OFF-停用" json:"status"`                                                                     // 通知状态ON-启用 OFF-停用
-	NotificationConfig *string   `gorm:"column:notification_config;comment:通知配置" json:"notification_config"`                                                                // 通知配置
-	Description        *string   `gorm:"column:description;comment:描述" json:"description"`                                                                                  // 描述
-	TenantID           string    `gorm:"column:tenant_id;not null;comment:租户id" json:"tenant_id"`                                                                           // 租户id
-	CreatedAt          time.Time `gorm:"column:created_at;not null;comment:创建时间" json:"created_at"`                                                                         // 创建时间
-	UpdatedAt          time.Time `gorm:"column:updated_at;not null;comment:更新时间" json:"updated_at"`                                                                         // 更新时间
-	Remark             *string   `gorm:"column:remark;comment:备注" json:"remark"`                                                                                            // 备注
+	Name               string    `gorm:"column:name;not null;comment:名称" json:"name"`                                                                                                                                             // 名称
+	NotificationType   string    `gorm:"column:notification_type;not null;comment:通知类型MEMBER-成员通知 EMAIL-邮箱通知 SME-短信通知 VOICE-语音通知 WEBHOOK-webhook DINGTALK-钉钉机器人 WECOM-企业微信机器人 FEISHU-飞书机器人 SLACK-Slack" json:"notification_type"` // 通知类型MEMBER-成员通知 EMAIL-邮箱通知 SME-短信通知 VOICE-语音通知 WEBHOOK-webhook DINGTALK-钉钉机器人 WECOM-企业微信机器人 FEISHU-飞书机器人 SLACK-Slack
+	Status             string    `gorm:"column:status;not null;comment:通知状态ON-启用 OFF-停用" json:"status"`                                                                                                                           // 通知状态ON-启用 OFF-停用
+	NotificationConfig *string   `gorm:"column:notification_config;comment:通知配置" json:"notification_config"`                                                                                                                      // 通知配置
+	Description        *string   `gorm:"column:description;comment:描述" json:"description"`                                                                                                                                        // 描述
+	TenantID           string    `gorm:"column:tenant_id;not null;comment:租户id" json:"tenant_id"`                                                                                                                                 // 租户id
+	CreatedAt          time.Time `gorm:"column:created_at;not null;comment:创建时间" json:"created_at"`                                                                                                                               // 创建时间
+	UpdatedAt          time.Time `gorm:"column:updated_at;not null;comment:更新时间" json:"updated_at"`                                                                                                                               // 更新时间
+	Remark             *string   `gorm:"column:remark;comment:备注" json:"remark"`                                                                                                                                                  // 备注
+	StormLimit         *int32    `gorm:"column:storm_limit;comment:通知风暴限制：时间窗口内最多发送条数，超出部分合并为摘要" json:"storm_limit"`                                                                                                              // 通知风暴限制：时间窗口内最多发送条数，超出部分合并为摘要
+	StormWindowSeconds *int32    `gorm:"column:storm_window_seconds;comment:通知风暴限制时间窗口（秒）" json:"storm_window_seconds"`                                                                                                           // 通知风暴限制时间窗口（秒）
 }
 
 // TableName NotificationGroup's table name
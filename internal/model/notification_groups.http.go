@@ -21,6 +21,8 @@ type CreateNotificationGroupReq struct {
 	NotificationConfig *string `json:"notification_config" validate:"omitempty" example:"{\"data\":123}"` // 通知配置
 	Description        *string `json:"description" validate:"omitempty"`                                  // 通知组描述
 	Remark             *string `json:"remark" validate:"omitempty"`                                       // 备注
+	StormLimit         *int32  `json:"storm_limit" validate:"omitempty,min=0"`                            // 时间窗口内最多发送条数，超出部分合并为摘要
+	StormWindowSeconds *int32  `json:"storm_window_seconds" validate:"omitempty,min=10,max=86400"`        // 通知风暴限制时间窗口（秒），默认60
 }
 
 type UpdateNotificationGroupReq struct {
@@ -30,6 +32,8 @@ type UpdateNotificationGroupReq struct {
 	NotificationConfig *string `json:"notification_config" validate:"omitempty" example:"{\"data\":123}"` // 通知配置
 	Description        *string `json:"description" validate:"omitempty"`                                  // 通知组描述
 	Remark             *string `json:"remark" validate:"omitempty"`                                       // 备注
+	StormLimit         *int32  `json:"storm_limit" validate:"omitempty,min=0"`                            // 时间窗口内最多发送条数，超出部分合并为摘要
+	StormWindowSeconds *int32  `json:"storm_window_seconds" validate:"omitempty,min=10,max=86400"`        // 通知风暴限制时间窗口（秒），默认60
 }
 
 type GetNotificationGroupListByPageReq struct {
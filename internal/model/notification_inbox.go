@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+const TableNameNotificationInbox = "notification_inbox"
+
+// NotificationInbox 成员通知站内信
+type NotificationInbox struct {
+	ID                  string     `gorm:"column:id;primaryKey" json:"id"`
+	TenantID            string     `gorm:"column:tenant_id;not null" json:"tenant_id"`
+	UserID              string     `gorm:"column:user_id;not null" json:"user_id"`
+	NotificationGroupID *string    `gorm:"column:notification_group_id" json:"notification_group_id"`
+	Title               string     `gorm:"column:title;not null" json:"title"`
+	Content             *string    `gorm:"column:content" json:"content"`
+	Payload             *string    `gorm:"column:payload" json:"payload"`
+	ReadAt              *time.Time `gorm:"column:read_at" json:"read_at"`
+	CreatedAt           time.Time  `gorm:"column:created_at" json:"created_at"`
+}
+
+func (*NotificationInbox) TableName() string {
+	return TableNameNotificationInbox
+}
@@ -0,0 +1,10 @@
+package model
+
+type GetNotificationInboxListByPageReq struct {
+	PageReq
+	IsRead *bool `json:"is_read" form:"is_read" validate:"omitempty"` // 是否已读，不传返回全部
+}
+
+type NotificationInboxReadReq struct {
+	IDs []string `json:"ids" validate:"required,min=1,max=500,dive,max=36"` // 站内信id
+}
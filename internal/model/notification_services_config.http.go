@@ -12,6 +12,11 @@ const (
 	NoticeType_Email    = "EMAIL"
 	NoticeType_SME_CODE = "SME_CODE"
 	NoticeType_Member   = "MEMBER"
+	NoticeType_SME      = "SME"
+	NoticeType_DingTalk = "DINGTALK" // 钉钉群机器人
+	NoticeType_WeCom    = "WECOM"    // 企业微信群机器人
+	NoticeType_Feishu   = "FEISHU"   // 飞书群机器人
+	NoticeType_Slack    = "SLACK"    // Slack Incoming Webhook
 	NoticeType_Voice    = "VOICE"
 	NoticeType_Webhook  = "WEBHOOK"
 )
@@ -45,3 +50,15 @@ type SendTestEmailReq struct {
 	Email string `json:"email" validate:"required"`
 	Body  string `json:"body" form:"body" validate:"required"`
 }
+
+// MemberNotificationConfig 成员通知组的通知配置：指定用户与角色（角色下的用户均接收）
+type MemberNotificationConfig struct {
+	UserIDs []string `json:"USER_IDS"`
+	RoleIDs []string `json:"ROLE_IDS"`
+}
+
+// RobotNotificationConfig 群机器人通知组的通知配置；SECRET 为钉钉/飞书的加签密钥，企业微信与 Slack 不需要
+type RobotNotificationConfig struct {
+	WebhookURL string `json:"WEBHOOK_URL"`
+	Secret     string `json:"SECRET"`
+}
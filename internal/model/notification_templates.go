@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+const TableNameNotificationTemplate = "notification_templates"
+
+// 通知事件类型（通知JSON中的 event_type）
+const (
+	NotificationEventAlarm           = "ALARM"            // 告警
+	NotificationEventAlarmEscalation = "ALARM_ESCALATION" // 告警升级
+	NotificationEventDigest          = "DIGEST"           // 通知风暴摘要
+	NotificationEventMaintenance     = "MAINTENANCE"      // 电池维护提醒
+	NotificationEventApproval        = "APPROVAL"         // 操作审批
+)
+
+// NotificationChannelAppPush 通知模板渠道：移动端推送（其余渠道与通知组类型一致）
+const NotificationChannelAppPush = "APP_PUSH"
+
+// NotificationTemplate 通知模板
+type NotificationTemplate struct {
+	ID              string    `gorm:"column:id;primaryKey" json:"id"`
+	TenantID        string    `gorm:"column:tenant_id;not null" json:"tenant_id"`
+	EventType       string    `gorm:"column:event_type;not null" json:"event_type"`
+	Channel         string    `gorm:"column:channel;not null" json:"channel"`
+	Language        string    `gorm:"column:language;not null" json:"language"`
+	Subject         *string   `gorm:"column:subject" json:"subject"`
+	Body            string    `gorm:"column:body;not null" json:"body"`
+	SmsTemplateCode *string   `gorm:"column:sms_template_code" json:"sms_template_code"`
+	Enabled         bool      `gorm:"column:enabled;not null" json:"enabled"`
+	Remark          *string   `gorm:"column:remark" json:"remark"`
+	CreatedAt       time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt       time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (*NotificationTemplate) TableName() string {
+	return TableNameNotificationTemplate
+}
@@ -0,0 +1,35 @@
+package model
+
+type CreateNotificationTemplateReq struct {
+	EventType       string  `json:"event_type" validate:"required,oneof=ALARM ALARM_ESCALATION DIGEST MAINTENANCE APPROVAL"`         // 事件类型
+	Channel         string  `json:"channel" validate:"required,oneof=EMAIL SME WEBHOOK MEMBER APP_PUSH DINGTALK WECOM FEISHU SLACK"` // 渠道
+	Language        string  `json:"language" validate:"omitempty,max=10"`                                                            // 语言代码，为空表示通用
+	Subject         *string `json:"subject" validate:"omitempty,max=1000"`                                                           // 标题模板
+	Body            string  `json:"body" validate:"required,max=20000"`                                                              // 正文模板
+	SmsTemplateCode *string `json:"sms_template_code" validate:"omitempty,max=100"`                                                  // 短信模板编码
+	Enabled         *bool   `json:"enabled" validate:"omitempty"`                                                                    // 是否启用，默认启用
+	Remark          *string `json:"remark" validate:"omitempty,max=255"`                                                             // 备注
+}
+
+type UpdateNotificationTemplateReq struct {
+	Subject         *string `json:"subject" validate:"omitempty,max=1000"`          // 标题模板
+	Body            *string `json:"body" validate:"omitempty,max=20000"`            // 正文模板
+	SmsTemplateCode *string `json:"sms_template_code" validate:"omitempty,max=100"` // 短信模板编码
+	Enabled         *bool   `json:"enabled" validate:"omitempty"`                   // 是否启用
+	Remark          *string `json:"remark" validate:"omitempty,max=255"`            // 备注
+}
+
+type GetNotificationTemplateListByPageReq struct {
+	PageReq
+	EventType *string `json:"event_type" form:"event_type" validate:"omitempty"` // 事件类型
+	Channel   *string `json:"channel" form:"channel" validate:"omitempty"`       // 渠道
+	Language  *string `json:"language" form:"language" validate:"omitempty"`     // 语言代码
+}
+
+type PreviewNotificationTemplateReq struct {
+	Channel  string                 `json:"channel" validate:"required,oneof=EMAIL SME WEBHOOK MEMBER APP_PUSH DINGTALK WECOM FEISHU SLACK"` // 渠道
+	Language string                 `json:"language" validate:"omitempty,max=10"`                                                            // 语言代码
+	Subject  *string                `json:"subject" validate:"omitempty,max=1000"`                                                           // 标题模板
+	Body     string                 `json:"body" validate:"required,max=20000"`                                                              // 正文模板
+	Data     map[string]interface{} `json:"data" validate:"omitempty"`                                                                       // 示例通知数据，不传使用内置示例
+}
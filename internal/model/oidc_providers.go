@@ -0,0 +1,70 @@
+package model
+
+import "time"
+
+const (
+	TableNameOidcProvider = "oidc_providers"
+	TableNameUserIdentity = "user_identities"
+)
+
+// OidcProvider 租户 OIDC 身份提供方
+type OidcProvider struct {
+	ID                    string    `gorm:"column:id;primaryKey" json:"id"`
+	TenantID              string    `gorm:"column:tenant_id;not null" json:"tenant_id"`
+	Name                  string    `gorm:"column:name;not null" json:"name"`
+	Issuer                string    `gorm:"column:issuer;not null" json:"issuer"`
+	ClientID              string    `gorm:"column:client_id;not null" json:"client_id"`
+	ClientSecret          *string   `gorm:"column:client_secret" json:"client_secret,omitempty"`
+	Scopes                string    `gorm:"column:scopes;not null" json:"scopes"`
+	RedirectURI           *string   `gorm:"column:redirect_uri" json:"redirect_uri"`
+	FrontendURL           string    `gorm:"column:frontend_url;not null" json:"frontend_url"`
+	PostLogoutRedirectURI *string   `gorm:"column:post_logout_redirect_uri" json:"post_logout_redirect_uri"`
+	EmailClaim            string    `gorm:"column:email_claim;not null" json:"email_claim"`
+	NameClaim             string    `gorm:"column:name_claim;not null" json:"name_claim"`
+	RoleClaim             *string   `gorm:"column:role_claim" json:"role_claim"`
+	RoleMappings          *string   `gorm:"column:role_mappings" json:"role_mappings"`
+	OrgClaim              *string   `gorm:"column:org_claim" json:"org_claim"`
+	OrgMappings           *string   `gorm:"column:org_mappings" json:"org_mappings"`
+	DefaultAuthority      string    `gorm:"column:default_authority;not null" json:"default_authority"`
+	DefaultOrgID          *string   `gorm:"column:default_org_id" json:"default_org_id"`
+	AutoProvision         bool      `gorm:"column:auto_provision;not null" json:"auto_provision"`
+	SyncLinkedUsers       bool      `gorm:"column:sync_linked_users;not null" json:"sync_linked_users"`
+	Enabled               bool      `gorm:"column:enabled;not null" json:"enabled"`
+	Remark                *string   `gorm:"column:remark" json:"remark"`
+	CreatedAt             time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt             time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (*OidcProvider) TableName() string {
+	return TableNameOidcProvider
+}
+
+// OidcRoleMapping 声明值到用户权限类型与角色的映射
+type OidcRoleMapping struct {
+	Value     string   `json:"value" validate:"required,max=255"`                             // 声明值
+	Authority string   `json:"authority" validate:"omitempty,oneof=TENANT_ADMIN TENANT_USER"` // 权限类型
+	RoleIDs   []string `json:"role_ids" validate:"omitempty,max=50,dive,max=36"`              // 角色
+}
+
+// OidcOrgMapping 声明值到组织的映射
+type OidcOrgMapping struct {
+	Value string `json:"value" validate:"required,max=255"` // 声明值
+	OrgID string `json:"org_id" validate:"required,max=36"` // 组织ID
+}
+
+// UserIdentity 用户绑定的外部身份
+type UserIdentity struct {
+	ID          string     `gorm:"column:id;primaryKey" json:"id"`
+	UserID      string     `gorm:"column:user_id;not null" json:"user_id"`
+	ProviderID  string     `gorm:"column:provider_id;not null" json:"provider_id"`
+	TenantID    string     `gorm:"column:tenant_id;not null" json:"tenant_id"`
+	Subject     string     `gorm:"column:subject;not null" json:"subject"`
+	Email       *string    `gorm:"column:email" json:"email"`
+	Provisioned bool       `gorm:"column:provisioned;not null" json:"provisioned"`
+	LastLoginAt *time.Time `gorm:"column:last_login_at" json:"last_login_at"`
+	CreatedAt   time.Time  `gorm:"column:created_at" json:"created_at"`
+}
+
+func (*UserIdentity) TableName() string {
+	return TableNameUserIdentity
+}
@@ -0,0 +1,81 @@
+package model
+
+type CreateOidcProviderReq struct {
+	Name                  string            `json:"name" validate:"required,max=100"`                                      // 登录页显示名称
+	Issuer                string            `json:"issuer" validate:"required,url,max=500"`                                // 颁发者
+	ClientID              string            `json:"client_id" validate:"required,max=255"`                                 // 客户端ID
+	ClientSecret          *string           `json:"client_secret" validate:"omitempty,max=500"`                            // 客户端密钥，公共客户端不传
+	Scopes                *string           `json:"scopes" validate:"omitempty,max=500"`                                   // 申请的scope，默认 openid profile email
+	RedirectURI           *string           `json:"redirect_uri" validate:"omitempty,url,max=500"`                         // 回调地址，不传按请求地址生成
+	FrontendURL           string            `json:"frontend_url" validate:"required,url,max=500"`                          // 登录完成后跳转的控制台地址
+	PostLogoutRedirectURI *string           `json:"post_logout_redirect_uri" validate:"omitempty,url,max=500"`             // 退出登录后跳转地址
+	EmailClaim            *string           `json:"email_claim" validate:"omitempty,max=100"`                              // 邮箱声明，默认 email
+	NameClaim             *string           `json:"name_claim" validate:"omitempty,max=100"`                               // 姓名声明，默认 name
+	RoleClaim             *string           `json:"role_claim" validate:"omitempty,max=100"`                               // 角色映射声明
+	RoleMappings          []OidcRoleMapping `json:"role_mappings" validate:"omitempty,max=100,dive"`                       // 角色映射
+	OrgClaim              *string           `json:"org_claim" validate:"omitempty,max=100"`                                // 组织映射声明
+	OrgMappings           []OidcOrgMapping  `json:"org_mappings" validate:"omitempty,max=500,dive"`                        // 组织映射
+	DefaultAuthority      *string           `json:"default_authority" validate:"omitempty,oneof=TENANT_ADMIN TENANT_USER"` // 未匹配映射时的权限类型，默认 TENANT_USER
+	DefaultOrgID          *string           `json:"default_org_id" validate:"omitempty,max=36"`                            // 未匹配映射时的组织
+	AutoProvision         *bool             `json:"auto_provision" validate:"omitempty"`                                   // 首次登录自动创建用户，默认是
+	SyncLinkedUsers       *bool             `json:"sync_linked_users" validate:"omitempty"`                                // 登录时按声明同步按邮箱关联的已有账号，默认否
+	Enabled               *bool             `json:"enabled" validate:"omitempty"`                                          // 是否启用，默认启用
+	Remark                *string           `json:"remark" validate:"omitempty,max=255"`                                   // 备注
+}
+
+type UpdateOidcProviderReq struct {
+	Name                  *string            `json:"name" validate:"omitempty,max=100"`
+	Issuer                *string            `json:"issuer" validate:"omitempty,url,max=500"`
+	ClientID              *string            `json:"client_id" validate:"omitempty,max=255"`
+	ClientSecret          *string            `json:"client_secret" validate:"omitempty,max=500"`
+	Scopes                *string            `json:"scopes" validate:"omitempty,max=500"`
+	RedirectURI           *string            `json:"redirect_uri" validate:"omitempty,max=500"` // 传空字符串按请求地址生成
+	FrontendURL           *string            `json:"frontend_url" validate:"omitempty,url,max=500"`
+	PostLogoutRedirectURI *string            `json:"post_logout_redirect_uri" validate:"omitempty,max=500"`
+	EmailClaim            *string            `json:"email_claim" validate:"omitempty,max=100"`
+	NameClaim             *string            `json:"name_claim" validate:"omitempty,max=100"`
+	RoleClaim             *string            `json:"role_claim" validate:"omitempty,max=100"`
+	RoleMappings          *[]OidcRoleMapping `json:"role_mappings" validate:"omitempty,max=100,dive"`
+	OrgClaim              *string            `json:"org_claim" validate:"omitempty,max=100"`
+	OrgMappings           *[]OidcOrgMapping  `json:"org_mappings" validate:"omitempty,max=500,dive"`
+	DefaultAuthority      *string            `json:"default_authority" validate:"omitempty,oneof=TENANT_ADMIN TENANT_USER"`
+	DefaultOrgID          *string            `json:"default_org_id" validate:"omitempty,max=36"` // 传空字符串取消
+	AutoProvision         *bool              `json:"auto_provision" validate:"omitempty"`
+	SyncLinkedUsers       *bool              `json:"sync_linked_users" validate:"omitempty"`
+	Enabled               *bool              `json:"enabled" validate:"omitempty"`
+	Remark                *string            `json:"remark" validate:"omitempty,max=255"`
+}
+
+type GetOidcProviderListByPageReq struct {
+	PageReq
+	Enabled *bool `json:"enabled" form:"enabled" validate:"omitempty"`
+}
+
+// OidcLoginProvidersReq 登录页查询可用的身份提供方
+type OidcLoginProvidersReq struct {
+	TenantID string `json:"tenant_id" form:"tenant_id" validate:"required,max=36"` // 租户ID
+}
+
+// OidcAuthorizeReq 发起单点登录
+type OidcAuthorizeReq struct {
+	Redirect *string `json:"redirect" form:"redirect" validate:"omitempty,max=500"` // 登录完成后控制台内的跳转路径
+}
+
+// OidcCallbackReq 身份提供方回调参数
+type OidcCallbackReq struct {
+	Code             string `form:"code"`
+	State            string `form:"state"`
+	Error            string `form:"error"`
+	ErrorDescription string `form:"error_description"`
+}
+
+// OidcTicketReq 控制台用登录票据换取令牌
+type OidcTicketReq struct {
+	Ticket string `json:"ticket" validate:"required,max=100"`
+}
+
+// OidcLoginProviderRsp 登录页展示的身份提供方
+type OidcLoginProviderRsp struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+const TableNameOpenAPIKeyUsage = "open_api_key_usage"
+
+// Open API 密钥权限范围
+const (
+	APIKeyScopeAll           = "*"              // 全部权限（兼容旧密钥）
+	APIKeyScopeTelemetryRead = "telemetry:read" // 读取遥测/属性/事件数据
+	APIKeyScopeCommandSend   = "command:send"   // 下发指令、属性与参数
+	APIKeyScopeDeviceRead    = "device:read"    // 读取设备与电池信息
+	APIKeyScopeDeviceManage  = "device:manage"  // 管理设备与电池
+)
+
+// APIKeyScopes 可授予的权限范围
+var APIKeyScopes = []string{
+	APIKeyScopeTelemetryRead,
+	APIKeyScopeCommandSend,
+	APIKeyScopeDeviceRead,
+	APIKeyScopeDeviceManage,
+}
+
+// OpenAPIKeyUsage Open API 密钥按日调用统计
+type OpenAPIKeyUsage struct {
+	KeyID         string    `gorm:"column:key_id;primaryKey" json:"key_id"`
+	UsageDate     time.Time `gorm:"column:usage_date;primaryKey;type:date" json:"usage_date"`
+	TenantID      string    `gorm:"column:tenant_id;not null" json:"tenant_id"`
+	RequestCount  int64     `gorm:"column:request_count;not null" json:"request_count"`
+	RejectedCount int64     `gorm:"column:rejected_count;not null" json:"rejected_count"`
+	UpdatedAt     time.Time `gorm:"column:updated_at;not null" json:"updated_at"`
+}
+
+func (*OpenAPIKeyUsage) TableName() string {
+	return TableNameOpenAPIKeyUsage
+}
@@ -12,14 +12,26 @@ const TableNameOpenAPIKey = "open_api_keys"
 
 // OpenAPIKey mapped from table <open_api_keys>
 type OpenAPIKey struct {
-	ID        string     `gorm:"column:id;primaryKey" json:"id"`
-	TenantID  string     `gorm:"column:tenant_id;not null" json:"tenant_id"`
-	APIKey    string     `gorm:"column:api_key;not null" json:"api_key"`
-	Status    *int16     `gorm:"column:status" json:"status"`
-	Name      string     `gorm:"column:name;not null" json:"name"`
-	CreatedAt *time.Time `gorm:"column:created_at" json:"created_at"`
-	UpdatedAt *time.Time `gorm:"column:updated_at" json:"updated_at"`
-	CreatedID *string    `gorm:"column:created_id" json:"created_id"`
+	ID                   string     `gorm:"column:id;primaryKey" json:"id"`
+	TenantID             string     `gorm:"column:tenant_id;not null" json:"tenant_id"`
+	APIKey               string     `gorm:"column:api_key;not null" json:"api_key"`
+	Status               *int16     `gorm:"column:status" json:"status"`
+	Name                 string     `gorm:"column:name;not null" json:"name"`
+	CreatedAt            *time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt            *time.Time `gorm:"column:updated_at" json:"updated_at"`
+	CreatedID            *string    `gorm:"column:created_id" json:"created_id"`
+	Scopes               string     `gorm:"column:scopes;not null;comment:权限范围" json:"scopes"`                                // 权限范围
+	OrgID                *string    `gorm:"column:org_id;comment:限制访问的组织（含下级组织）" json:"org_id"`                               // 限制访问的组织（含下级组织）
+	DeviceGroupID        *string    `gorm:"column:device_group_id;comment:限制访问的设备分组（含子分组）" json:"device_group_id"`            // 限制访问的设备分组（含子分组）
+	IPAllowlist          *string    `gorm:"column:ip_allowlist;comment:允许调用的IP或CIDR列表" json:"ip_allowlist"`                   // 允许调用的IP或CIDR列表
+	ExpiresAt            *time.Time `gorm:"column:expires_at;comment:过期时间" json:"expires_at"`                                 // 过期时间
+	RateLimit            *int32     `gorm:"column:rate_limit;comment:每分钟请求上限" json:"rate_limit"`                              // 每分钟请求上限
+	PreviousAPIKey       *string    `gorm:"column:previous_api_key;comment:轮换前的密钥" json:"-"`                                  // 轮换前的密钥
+	PreviousKeyExpiresAt *time.Time `gorm:"column:previous_key_expires_at;comment:轮换前密钥的失效时间" json:"previous_key_expires_at"` // 轮换前密钥的失效时间
+	RotatedAt            *time.Time `gorm:"column:rotated_at" json:"rotated_at"`
+	LastUsedAt           *time.Time `gorm:"column:last_used_at" json:"last_used_at"`
+	LastUsedIP           *string    `gorm:"column:last_used_ip" json:"last_used_ip"`
+	RequestCount         int64      `gorm:"column:request_count;not null" json:"request_count"`
 }
 
 // TableName OpenAPIKey's table name
@@ -1,6 +1,8 @@
 // internal/model/open_api_keys.http.go
 package model
 
+import "time"
+
 // OpenAPIKeyListReq 查询API密钥列表请求
 type OpenAPIKeyListReq struct {
 	PageReq        // 继承基础分页请求
@@ -9,20 +11,44 @@ type OpenAPIKeyListReq struct {
 
 // CreateOpenAPIKeyReq 创建API密钥请求
 type CreateOpenAPIKeyReq struct {
-	TenantID string `json:"tenant_id" validate:"required,max=36"` // 租户ID
-	Name     string `json:"name" validate:"omitempty,max=200"`    // 名称
+	TenantID      string     `json:"tenant_id" validate:"required,max=36"`                 // 租户ID
+	Name          string     `json:"name" validate:"omitempty,max=200"`                    // 名称
+	Scopes        []string   `json:"scopes" validate:"omitempty,dive,max=50"`              // 权限范围，不传为全部权限
+	OrgID         *string    `json:"org_id" validate:"omitempty,max=36"`                   // 限制访问的组织（含下级组织）
+	DeviceGroupID *string    `json:"device_group_id" validate:"omitempty,max=36"`          // 限制访问的设备分组（含子分组）
+	IPAllowlist   []string   `json:"ip_allowlist" validate:"omitempty,max=50,dive,max=64"` // 允许调用的IP或CIDR
+	ExpiresAt     *time.Time `json:"expires_at" validate:"omitempty"`                      // 过期时间
+	RateLimit     *int32     `json:"rate_limit" validate:"omitempty,gte=0,lte=100000"`     // 每分钟请求上限，0不限制
 }
 
 // UpdateOpenAPIKeyReq 更新API密钥请求
 type UpdateOpenAPIKeyReq struct {
-	ID     string  `json:"id" validate:"required,max=36"`         // 主键ID
-	Status *int16  `json:"status" validate:"omitempty,oneof=0 1"` // 状态: 0-禁用 1-启用
-	Name   *string `json:"name" validate:"omitempty,max=200"`     // 名称
+	ID            string     `json:"id" validate:"required,max=36"`                        // 主键ID
+	Status        *int16     `json:"status" validate:"omitempty,oneof=0 1"`                // 状态: 0-禁用 1-启用
+	Name          *string    `json:"name" validate:"omitempty,max=200"`                    // 名称
+	Scopes        *[]string  `json:"scopes" validate:"omitempty,dive,max=50"`              // 权限范围
+	OrgID         *string    `json:"org_id" validate:"omitempty,max=36"`                   // 限制访问的组织，传空字符串取消限制
+	DeviceGroupID *string    `json:"device_group_id" validate:"omitempty,max=36"`          // 限制访问的设备分组，传空字符串取消限制
+	IPAllowlist   *[]string  `json:"ip_allowlist" validate:"omitempty,max=50,dive,max=64"` // 允许调用的IP或CIDR，传空数组取消限制
+	ExpiresAt     *time.Time `json:"expires_at" validate:"omitempty"`                      // 过期时间
+	ClearExpires  bool       `json:"clear_expires"`                                        // 取消过期时间
+	RateLimit     *int32     `json:"rate_limit" validate:"omitempty,gte=0,lte=100000"`     // 每分钟请求上限，0不限制
+}
+
+// RotateOpenAPIKeyReq 轮换API密钥请求
+type RotateOpenAPIKeyReq struct {
+	GraceHours int `json:"grace_hours" validate:"omitempty,gte=0,lte=168"` // 旧密钥继续有效的小时数，0立即失效
+}
+
+// OpenAPIKeyUsageReq 查询API密钥调用统计请求
+type OpenAPIKeyUsageReq struct {
+	StartDate string `json:"start_date" form:"start_date" validate:"omitempty,datetime=2006-01-02"` // 开始日期
+	EndDate   string `json:"end_date" form:"end_date" validate:"omitempty,datetime=2006-01-02"`     // 结束日期
 }
 
 // OpenAPIKeyListRsp API密钥列表响应
 type OpenAPIKeyListRsp struct {
-	OpenAPIKey               // 嵌入OpenAPIKey结构体
+	OpenAPIKey         // 嵌入OpenAPIKey结构体
 	UserID     *string `json:"user_id"`   // 创建者用户ID
 	Email      *string `json:"email"`     // 创建者邮箱
 	UserName   *string `json:"user_name"` // 创建者用户名
@@ -12,16 +12,18 @@ const TableNameSceneAutomation = "scene_automations"
 
 // SceneAutomation mapped from table <scene_automations>
 type SceneAutomation struct {
-	ID          string     `gorm:"column:id;primaryKey;comment:联动" json:"id"`                     // 联动
-	Name        string     `gorm:"column:name;not null;comment:名称" json:"name"`                   // 名称
-	Description *string    `gorm:"column:description;comment:描述" json:"description"`              // 描述
-	Enabled     string     `gorm:"column:enabled;not null;comment:是否启用 Y：启用 N：停用" json:"enabled"` // 是否启用 Y：启用 N：停用
-	TenantID    string     `gorm:"column:tenant_id;not null;comment:租户ID" json:"tenant_id"`       // 租户ID
-	Creator     string     `gorm:"column:creator;not null;comment:创建人id" json:"creator"`          // 创建人id
-	Updator     string     `gorm:"column:updator;not null;comment:修改人id" json:"updator"`          // 修改人id
-	CreatedAt   time.Time  `gorm:"column:created_at;not null;comment:创建时间" json:"created_at"`     // 创建时间
-	UpdatedAt   *time.Time `gorm:"column:updated_at;comment:更新时间" json:"updated_at"`              // 更新时间
-	Remark      *string    `gorm:"column:remark" json:"remark"`
+	ID                string     `gorm:"column:id;primaryKey;comment:联动" json:"id"`                     // 联动
+	Name              string     `gorm:"column:name;not null;comment:名称" json:"name"`                   // 名称
+	Description       *string    `gorm:"column:description;comment:描述" json:"description"`              // 描述
+	Enabled           string     `gorm:"column:enabled;not null;comment:是否启用 Y：启用 N：停用" json:"enabled"` // 是否启用 Y：启用 N：停用
+	TenantID          string     `gorm:"column:tenant_id;not null;comment:租户ID" json:"tenant_id"`       // 租户ID
+	Creator           string     `gorm:"column:creator;not null;comment:创建人id" json:"creator"`          // 创建人id
+	Updator           string     `gorm:"column:updator;not null;comment:修改人id" json:"updator"`          // 修改人id
+	CreatedAt         time.Time  `gorm:"column:created_at;not null;comment:创建时间" json:"created_at"`     // 创建时间
+	UpdatedAt         *time.Time `gorm:"column:updated_at;comment:更新时间" json:"updated_at"`              // 更新时间
+	Remark            *string    `gorm:"column:remark" json:"remark"`
+	Timezone          *string    `gorm:"column:timezone;comment:IANA 时区，为空时使用租户时区" json:"timezone"`                                     // IANA 时区，为空时使用租户时区
+	HolidayCalendarID *string    `gorm:"column:holiday_calendar_id;comment:排除的节假日日历：节假日当天时间范围条件不成立、定时触发不执行" json:"holiday_calendar_id"` // 排除的节假日日历：节假日当天时间范围条件不成立、定时触发不执行
 }
 
 // TableName SceneAutomation's table name
@@ -8,6 +8,8 @@ type CreateSceneAutomationReq struct {
 	Enabled                string        `json:"enabled" validate:"omitempty,oneof=Y N"`
 	TriggerConditionGroups [][]Condition `json:"trigger_condition_groups" validate:"required"`
 	Actions                []Action      `json:"actions" validate:"required"`
+	Timezone               *string       `json:"timezone" validate:"omitempty,max=64"`     // IANA 时区，为空时使用租户时区
+	HolidayCalendarID      *string       `json:"holiday_calendar_id" validate:"omitempty"` // 排除的节假日日历
 	Remark                 string        `json:"remark" `
 }
 
@@ -18,6 +20,8 @@ type UpdateSceneAutomationReq struct {
 	Enabled                string        `json:"enabled" validate:"required,oneof=Y N"`
 	TriggerConditionGroups [][]Condition `json:"trigger_condition_groups" validate:"required"`
 	Actions                []Action      `json:"actions" validate:"required"`
+	Timezone               *string       `json:"timezone" validate:"omitempty,max=64"`     // IANA 时区，为空时使用租户时区
+	HolidayCalendarID      *string       `json:"holiday_calendar_id" validate:"omitempty"` // 排除的节假日日历
 	Remark                 string        `json:"remark" `
 }
 
@@ -28,6 +32,16 @@ type Condition struct {
 	TriggerParam          *string    `json:"trigger_param" validate:"omitempty"`
 	TriggerOperator       *string    `json:"trigger_operator" validate:"omitempty"`
 	TriggerValue          *string    `json:"trigger_value" validate:"omitempty"`
+	HoldDuration          *int32     `json:"hold_duration" validate:"omitempty,min=0,max=86400"`                      // 持续时间(秒)
+	SampleWindow          *int32     `json:"sample_window" validate:"omitempty,min=0,max=100"`                        // N/M 采样窗口 M
+	SampleHits            *int32     `json:"sample_hits" validate:"omitempty,min=0,max=100"`                          // N/M 采样命中数 N
+	ClearValue            *string    `json:"clear_value" validate:"omitempty,max=255"`                                // 恢复阈值（回差）
+	WindowFunc            *string    `json:"window_func" validate:"omitempty,oneof=RATE DELTA AVG MIN MAX STDDEV"`    // 窗口统计函数
+	WindowSeconds         *int32     `json:"window_seconds" validate:"omitempty,min=0,max=86400"`                     // 统计窗口长度(秒)
+	AggregateFunc         *string    `json:"aggregate_func" validate:"omitempty,oneof=COUNT PERCENT AVG MIN MAX SUM"` // 聚合函数（条件类型12/13）
+	MemberOperator        *string    `json:"member_operator" validate:"omitempty,max=20"`                             // 成员条件运算符
+	MemberValue           *string    `json:"member_value" validate:"omitempty,max=255"`                               // 成员条件取值
+	AggregateBySubgroup   *bool      `json:"aggregate_by_subgroup" validate:"omitempty"`                              // 按直属子分组分别聚合
 	ExecutionTime         *time.Time `json:"execution_time" validate:"omitempty"`
 	ExpirationTime        *int       `json:"expiration_time" validate:"omitempty"`
 	TaskType              *string    `json:"task_type" validate:"omitempty"`
@@ -54,3 +68,68 @@ type GetSceneAutomationsWithAlarmByPageReq struct {
 	DeviceId       *string `json:"device_id"  form:"device_id"  validate:"omitempty"`
 	DeviceConfigId *string `json:"device_config_id" form:"device_config_id" validata:"omitempty"`
 }
+
+// SceneAutomationExplainReq 场景联动试运行请求
+type SceneAutomationExplainReq struct {
+	DeviceID  string                 `json:"device_id" validate:"omitempty,max=36"` // 单类设备条件的评估设备
+	Telemetry map[string]interface{} `json:"telemetry" validate:"omitempty"`        // 假设上报的遥测数据，不传时使用设备当前数据
+}
+
+// SceneAutomationConditionTrace 单个条件的判断过程
+type SceneAutomationConditionTrace struct {
+	ConditionID   string      `json:"condition_id"`
+	ConditionType string      `json:"condition_type"`
+	ParamType     string      `json:"param_type,omitempty"`
+	Param         string      `json:"param,omitempty"`
+	ActualValue   interface{} `json:"actual_value"`
+	Operator      string      `json:"operator,omitempty"`
+	ExpectedValue string      `json:"expected_value"`
+	RawResult     bool        `json:"raw_result"` // 仅比较运算的结果（未计入持续时间/采样/回差）
+	Result        bool        `json:"result"`
+	Skipped       bool        `json:"skipped"` // 前序条件不成立被短路跳过
+	Note          string      `json:"note,omitempty"`
+}
+
+// SceneAutomationGroupTrace 条件组（组内为且）的判断过程
+type SceneAutomationGroupTrace struct {
+	GroupID                 string                          `json:"group_id"`
+	Result                  bool                            `json:"result"`
+	ShortCircuitConditionID string                          `json:"short_circuit_condition_id,omitempty"` // 导致组不成立的首个条件
+	Conditions              []SceneAutomationConditionTrace `json:"conditions"`
+}
+
+// SceneAutomationExplainResp 场景联动试运行结果（不执行动作）
+type SceneAutomationExplainResp struct {
+	SceneAutomationID string                      `json:"scene_automation_id"`
+	Name              string                      `json:"name"`
+	Enabled           string                      `json:"enabled"`
+	DeviceID          string                      `json:"device_id,omitempty"`
+	EvaluatedAt       string                      `json:"evaluated_at"`
+	WouldFire         bool                        `json:"would_fire"`
+	Groups            []SceneAutomationGroupTrace `json:"groups"`
+}
+
+// SceneAutomationReplayReq 场景联动历史回放请求
+type SceneAutomationReplayReq struct {
+	DeviceID  string `json:"device_id" validate:"required,max=36"`
+	StartTime int64  `json:"start_time" validate:"required"` // 开始时间(毫秒)
+	EndTime   int64  `json:"end_time" validate:"required"`   // 结束时间(毫秒)
+}
+
+// SceneAutomationReplayFiring 回放中的一次触发
+type SceneAutomationReplayFiring struct {
+	Ts       int64    `json:"ts"`
+	Time     string   `json:"time"`
+	GroupIDs []string `json:"group_ids"`
+	Contents []string `json:"contents"`
+}
+
+// SceneAutomationReplayResp 场景联动历史回放结果
+type SceneAutomationReplayResp struct {
+	SceneAutomationID string                        `json:"scene_automation_id"`
+	DeviceID          string                        `json:"device_id"`
+	Reports           int                           `json:"reports"`     // 回放的上报次数
+	Evaluations       int                           `json:"evaluations"` // 涉及本场景条件的上报次数
+	Firings           []SceneAutomationReplayFiring `json:"firings"`
+	Truncated         bool                          `json:"truncated"` // 数据量超出上限，只回放了前面部分
+}
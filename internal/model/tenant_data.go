@@ -0,0 +1,77 @@
+package model
+
+import "time"
+
+const (
+	TableNameTenantDataJob     = "tenant_data_jobs"
+	TableNameTenantOffboarding = "tenant_offboardings"
+)
+
+// 租户数据任务类型
+const (
+	TenantDataJobExport = "EXPORT"
+	TenantDataJobImport = "IMPORT"
+)
+
+// 租户数据任务状态
+const (
+	TenantDataJobPending   = "PENDING"
+	TenantDataJobRunning   = "RUNNING"
+	TenantDataJobSucceeded = "SUCCEEDED"
+	TenantDataJobFailed    = "FAILED"
+)
+
+// 租户注销状态
+const (
+	TenantOffboardingScheduled = "SCHEDULED" // 保留期中，可撤销
+	TenantOffboardingPurging   = "PURGING"   // 正在清除数据
+	TenantOffboardingPurged    = "PURGED"    // 数据已清除
+	TenantOffboardingFailed    = "FAILED"    // 清除失败，定时任务会重试
+	TenantOffboardingCancelled = "CANCELLED" // 已撤销
+)
+
+// TenantDataJob 租户数据导出/导入任务
+type TenantDataJob struct {
+	ID             string     `gorm:"column:id;primaryKey" json:"id"`
+	JobType        string     `gorm:"column:job_type;not null" json:"job_type"`
+	TenantID       string     `gorm:"column:tenant_id;not null" json:"tenant_id"`
+	SourceTenantID *string    `gorm:"column:source_tenant_id" json:"source_tenant_id"`
+	Status         string     `gorm:"column:status;not null" json:"status"`
+	TelemetryStart *time.Time `gorm:"column:telemetry_start" json:"telemetry_start"`
+	TelemetryEnd   *time.Time `gorm:"column:telemetry_end" json:"telemetry_end"`
+	FilePath       *string    `gorm:"column:file_path" json:"-"`
+	FileSize       *int64     `gorm:"column:file_size" json:"file_size"`
+	Checksum       *string    `gorm:"column:checksum" json:"checksum"`
+	Summary        *string    `gorm:"column:summary" json:"summary"`
+	ErrorMessage   *string    `gorm:"column:error_message" json:"error_message"`
+	RequestedBy    string     `gorm:"column:requested_by;not null" json:"requested_by"`
+	CreatedAt      time.Time  `gorm:"column:created_at" json:"created_at"`
+	StartedAt      *time.Time `gorm:"column:started_at" json:"started_at"`
+	FinishedAt     *time.Time `gorm:"column:finished_at" json:"finished_at"`
+}
+
+func (*TenantDataJob) TableName() string {
+	return TableNameTenantDataJob
+}
+
+// TenantOffboarding 租户注销
+type TenantOffboarding struct {
+	ID                string     `gorm:"column:id;primaryKey" json:"id"`
+	TenantID          string     `gorm:"column:tenant_id;not null" json:"tenant_id"`
+	Status            string     `gorm:"column:status;not null" json:"status"`
+	Reason            *string    `gorm:"column:reason" json:"reason"`
+	RequestedBy       string     `gorm:"column:requested_by;not null" json:"requested_by"`
+	PurgeAfter        time.Time  `gorm:"column:purge_after;not null" json:"purge_after"`
+	FrozenUserIDs     *string    `gorm:"column:frozen_user_ids" json:"frozen_user_ids"`
+	DisabledAPIKeyIDs *string    `gorm:"column:disabled_api_key_ids" json:"disabled_api_key_ids"`
+	Summary           *string    `gorm:"column:summary" json:"summary"`
+	ErrorMessage      *string    `gorm:"column:error_message" json:"error_message"`
+	CancelledBy       *string    `gorm:"column:cancelled_by" json:"cancelled_by"`
+	CreatedAt         time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt         time.Time  `gorm:"column:updated_at" json:"updated_at"`
+	PurgedAt          *time.Time `gorm:"column:purged_at" json:"purged_at"`
+}
+
+func (*TenantOffboarding) TableName() string {
+	return TableNameTenantOffboarding
+}
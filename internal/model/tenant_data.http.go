@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+// TenantExportReq 创建租户导出任务
+type TenantExportReq struct {
+	TenantID       string     `json:"tenant_id" validate:"omitempty,max=36"` // 仅SYS_ADMIN可指定，租户管理员导出本租户
+	TelemetryStart *time.Time `json:"telemetry_start"`                       // 遥测历史时间范围，为空不导出遥测历史
+	TelemetryEnd   *time.Time `json:"telemetry_end"`
+}
+
+// GetTenantDataJobListByPageReq 租户数据任务查询
+type GetTenantDataJobListByPageReq struct {
+	PageReq
+	TenantID *string `json:"tenant_id" form:"tenant_id" validate:"omitempty,max=36"`
+	JobType  *string `json:"job_type" form:"job_type" validate:"omitempty,oneof=EXPORT IMPORT"`
+	Status   *string `json:"status" form:"status" validate:"omitempty,oneof=PENDING RUNNING SUCCEEDED FAILED"`
+}
+
+// TenantOffboardingReq 发起租户注销
+type TenantOffboardingReq struct {
+	TenantID  string  `json:"tenant_id" validate:"required,max=36"`
+	GraceDays int     `json:"grace_days" validate:"omitempty,gte=1,lte=365"` // 保留期（天），默认30天
+	Reason    *string `json:"reason" validate:"omitempty,max=500"`
+}
+
+// GetTenantOffboardingListReq 租户注销查询
+type GetTenantOffboardingListReq struct {
+	PageReq
+	TenantID *string `json:"tenant_id" form:"tenant_id" validate:"omitempty,max=36"`
+	Status   *string `json:"status" form:"status" validate:"omitempty,oneof=SCHEDULED PURGING PURGED FAILED CANCELLED"`
+}
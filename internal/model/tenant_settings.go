@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+const TableNameTenantSetting = "tenant_settings"
+
+// TenantSetting 租户设置
+type TenantSetting struct {
+	TenantID    string    `gorm:"column:tenant_id;primaryKey" json:"tenant_id"`
+	Timezone    *string   `gorm:"column:timezone" json:"timezone"`         // IANA 时区
+	MfaEnforced bool      `gorm:"column:mfa_enforced" json:"mfa_enforced"` // 强制控制台账号启用双因素认证
+	CreatedAt   time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (*TenantSetting) TableName() string {
+	return TableNameTenantSetting
+}
@@ -0,0 +1,14 @@
+package model
+
+// TenantSettingUpdateReq 更新租户设置
+type TenantSettingUpdateReq struct {
+	Timezone    *string `json:"timezone" validate:"omitempty,max=64"` // IANA 时区，空字符串表示清除
+	MfaEnforced *bool   `json:"mfa_enforced" validate:"omitempty"`    // 强制控制台账号启用双因素认证
+}
+
+// TenantSettingResp 租户设置
+type TenantSettingResp struct {
+	TenantID    string  `json:"tenant_id"`
+	Timezone    *string `json:"timezone"`
+	MfaEnforced bool    `json:"mfa_enforced"`
+}
@@ -0,0 +1,43 @@
+package model
+
+import "time"
+
+const (
+	TableNameUserMfa           = "user_mfa"
+	TableNameUserTrustedDevice = "user_trusted_devices"
+)
+
+// UserMfa 用户双因素认证（TOTP）
+type UserMfa struct {
+	UserID        string     `gorm:"column:user_id;primaryKey" json:"user_id"`
+	TenantID      *string    `gorm:"column:tenant_id" json:"tenant_id"`
+	Enabled       bool       `gorm:"column:enabled;not null" json:"enabled"`
+	TotpSecret    *string    `gorm:"column:totp_secret" json:"-"`
+	PendingSecret *string    `gorm:"column:pending_secret" json:"-"`
+	RecoveryCodes string     `gorm:"column:recovery_codes;not null" json:"-"` // 未使用恢复码摘要的 JSON 数组
+	LastUsedStep  int64      `gorm:"column:last_used_step;not null" json:"-"`
+	EnabledAt     *time.Time `gorm:"column:enabled_at" json:"enabled_at"`
+	CreatedAt     time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (*UserMfa) TableName() string {
+	return TableNameUserMfa
+}
+
+// UserTrustedDevice 跳过双因素认证的受信任浏览器
+type UserTrustedDevice struct {
+	ID         string     `gorm:"column:id;primaryKey" json:"id"`
+	UserID     string     `gorm:"column:user_id;not null" json:"user_id"`
+	TenantID   *string    `gorm:"column:tenant_id" json:"tenant_id"`
+	TokenHash  string     `gorm:"column:token_hash;not null" json:"-"`
+	Name       *string    `gorm:"column:name" json:"name"`
+	IP         *string    `gorm:"column:ip" json:"ip"`
+	ExpiresAt  time.Time  `gorm:"column:expires_at;not null" json:"expires_at"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at" json:"last_used_at"`
+	CreatedAt  time.Time  `gorm:"column:created_at" json:"created_at"`
+}
+
+func (*UserTrustedDevice) TableName() string {
+	return TableNameUserTrustedDevice
+}
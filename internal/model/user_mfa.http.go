@@ -0,0 +1,62 @@
+package model
+
+import "time"
+
+// MfaVerifyReq 登录第二步：校验动态验证码或恢复码
+type MfaVerifyReq struct {
+	MfaToken       string  `json:"mfa_token" validate:"required,max=128"`     // 密码校验通过后返回的临时令牌
+	Code           *string `json:"code" validate:"omitempty,max=16"`          // 6位动态验证码
+	RecoveryCode   *string `json:"recovery_code" validate:"omitempty,max=32"` // 恢复码，与动态验证码二选一
+	RememberDevice bool    `json:"remember_device"`                           // 信任此浏览器，有效期内免二次验证
+	DeviceName     *string `json:"device_name" validate:"omitempty,max=255"`  // 受信任设备名称，默认取 User-Agent
+}
+
+// MfaLoginSetupReq 租户强制启用但尚未绑定时，凭临时令牌获取绑定密钥
+type MfaLoginSetupReq struct {
+	MfaToken string `json:"mfa_token" validate:"required,max=128"`
+}
+
+// MfaLoginEnableReq 租户强制启用但尚未绑定时，凭临时令牌完成绑定并登录
+type MfaLoginEnableReq struct {
+	MfaToken       string  `json:"mfa_token" validate:"required,max=128"`
+	Code           string  `json:"code" validate:"required,len=6,numeric"`
+	RememberDevice bool    `json:"remember_device"`
+	DeviceName     *string `json:"device_name" validate:"omitempty,max=255"`
+}
+
+// MfaCodeReq 需要动态验证码确认的操作
+type MfaCodeReq struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+// MfaDisableReq 关闭双因素认证，需提供动态验证码或恢复码
+type MfaDisableReq struct {
+	Code         *string `json:"code" validate:"omitempty,max=16"`
+	RecoveryCode *string `json:"recovery_code" validate:"omitempty,max=32"`
+}
+
+// MfaStatusRsp 双因素认证状态
+type MfaStatusRsp struct {
+	Enabled                bool       `json:"enabled"`
+	Enforced               bool       `json:"enforced"` // 租户是否强制启用
+	EnabledAt              *time.Time `json:"enabled_at"`
+	RecoveryCodesRemaining int        `json:"recovery_codes_remaining"` // 剩余可用恢复码数量
+	TrustedDevices         int64      `json:"trusted_devices"`          // 有效的受信任设备数量
+}
+
+// MfaSetupRsp 绑定密钥，客户端据此展示二维码
+type MfaSetupRsp struct {
+	Secret     string `json:"secret"`      // Base32 密钥，供手动输入
+	OtpauthURL string `json:"otpauth_url"` // otpauth:// 地址，用于生成二维码
+}
+
+// MfaRecoveryCodesRsp 新生成的恢复码，仅返回一次
+type MfaRecoveryCodesRsp struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// MfaEnableRsp 启用成功；登录流程中绑定时同时返回登录凭证
+type MfaEnableRsp struct {
+	MfaRecoveryCodesRsp
+	*LoginRsp
+}
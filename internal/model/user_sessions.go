@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+const TableNameUserSession = "user_sessions"
+
+// UserSession 用户登录会话（刷新令牌）
+type UserSession struct {
+	ID                string     `gorm:"column:id;primaryKey" json:"id"`
+	UserID            string     `gorm:"column:user_id;not null" json:"user_id"`
+	TenantID          *string    `gorm:"column:tenant_id" json:"tenant_id"`
+	RefreshTokenHash  string     `gorm:"column:refresh_token_hash;not null" json:"-"`
+	PreviousTokenHash *string    `gorm:"column:previous_token_hash" json:"-"`
+	RotatedAt         *time.Time `gorm:"column:rotated_at" json:"-"`
+	UserAgent         *string    `gorm:"column:user_agent" json:"user_agent"`
+	IP                *string    `gorm:"column:ip" json:"ip"`
+	CreatedAt         time.Time  `gorm:"column:created_at" json:"created_at"`
+	LastSeenAt        time.Time  `gorm:"column:last_seen_at" json:"last_seen_at"`
+	ExpiresAt         *time.Time `gorm:"column:expires_at" json:"expires_at"`
+	RevokedAt         *time.Time `gorm:"column:revoked_at" json:"revoked_at,omitempty"`
+	RevokedReason     *string    `gorm:"column:revoked_reason" json:"revoked_reason,omitempty"`
+}
+
+func (*UserSession) TableName() string {
+	return TableNameUserSession
+}
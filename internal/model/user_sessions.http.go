@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// RefreshTokenReq 用刷新令牌换取新的访问令牌
+type RefreshTokenReq struct {
+	RefreshToken string `json:"refresh_token" validate:"required,max=128"`
+}
+
+// UserSessionRsp 登录会话
+type UserSessionRsp struct {
+	ID         string     `json:"id"`
+	UserAgent  *string    `json:"user_agent"`   // 登录设备（User-Agent）
+	IP         *string    `json:"ip"`           // 登录或最近一次刷新的IP
+	CreatedAt  time.Time  `json:"created_at"`   // 登录时间
+	LastSeenAt time.Time  `json:"last_seen_at"` // 最近活跃时间（登录或刷新令牌）
+	ExpiresAt  *time.Time `json:"expires_at"`   // 会话过期时间，为空表示不过期
+	Current    bool       `json:"current"`      // 是否为当前会话
+}
@@ -25,11 +25,28 @@ type LoginReq struct {
 	Email    string `json:"email" validate:"required" example:"test@test.cn"`            // 登录账号(输入邮箱或者手机号)
 	Password string `json:"password" validate:"required,min=6,max=512" example:"123456"` // 密码
 	Salt     string `json:"salt" validate:"omitempty,max=512"`                           // 随机盐(如果在超管设置了前端RSA加密则需要上送)
+	// 受信任设备令牌（此前二次验证时选择“信任此浏览器”获得），有效时跳过二次验证
+	TrustedDeviceToken *string `json:"trusted_device_token" validate:"omitempty,max=128"`
 }
 
 type LoginRsp struct {
 	Token     *string `gorm:"column:token" json:"token"` // 登录凭证
 	ExpiresIn int64   `json:"expires_in"`                // 过期时间(单位:秒)
+	// 刷新令牌：访问令牌过期前调用 /api/v1/login/refresh 换取新的访问令牌，每次刷新都会轮换
+	RefreshToken     *string `json:"refresh_token,omitempty"`
+	RefreshExpiresIn int64   `json:"refresh_expires_in,omitempty"` // 会话过期时间(单位:秒)，0表示不过期
+	// 需要二次验证时不返回登录凭证，客户端凭 mfa_token 完成验证
+	MfaRequired        bool    `json:"mfa_required,omitempty"`         // 需输入动态验证码或恢复码
+	MfaSetupRequired   bool    `json:"mfa_setup_required,omitempty"`   // 租户强制启用但尚未绑定，需先绑定身份验证器
+	MfaToken           *string `json:"mfa_token,omitempty"`            // 二次验证临时令牌
+	TrustedDeviceToken *string `json:"trusted_device_token,omitempty"` // 选择信任此浏览器时返回，后续登录上送
+}
+
+// RequestMeta 会话、审计日志与受信任设备所需的请求信息
+type RequestMeta struct {
+	IP        string
+	UserAgent string
+	Path      string
 }
 
 type UserListReq struct {
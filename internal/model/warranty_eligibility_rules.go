@@ -0,0 +1,44 @@
+package model
+
+import "time"
+
+const TableNameWarrantyEligibilityRule = "warranty_eligibility_rules"
+
+// 质保评估建议
+const (
+	WarrantyRecommendApprove      = "APPROVE"
+	WarrantyRecommendReject       = "REJECT"
+	WarrantyRecommendManualReview = "MANUAL_REVIEW"
+)
+
+// 质保评估单项结果
+const (
+	WarrantyCheckPass    = "PASS"
+	WarrantyCheckFail    = "FAIL"
+	WarrantyCheckUnknown = "UNKNOWN" // 数据不足，需人工确认
+	WarrantyCheckInfo    = "INFO"    // 仅作参考，不影响结论
+)
+
+// WarrantyEligibilityRule 质保资格评估规则（按电池型号）
+type WarrantyEligibilityRule struct {
+	ID                       string    `gorm:"column:id;primaryKey" json:"id"`
+	TenantID                 string    `gorm:"column:tenant_id;not null" json:"tenant_id"`
+	BatteryModelID           string    `gorm:"column:battery_model_id;not null" json:"battery_model_id"`
+	GraceDays                int32     `gorm:"column:grace_days;not null" json:"grace_days"`
+	RequireActivation        bool      `gorm:"column:require_activation;not null" json:"require_activation"`
+	MaxCycleCount            *float64  `gorm:"column:max_cycle_count" json:"max_cycle_count"`
+	SohGuarantee             *float64  `gorm:"column:soh_guarantee" json:"soh_guarantee"`
+	OverTemperatureC         float64   `gorm:"column:over_temperature_c;not null" json:"over_temperature_c"`
+	MaxOverTemperatureEvents int32     `gorm:"column:max_over_temperature_events;not null" json:"max_over_temperature_events"`
+	DeepDischargeSoc         float64   `gorm:"column:deep_discharge_soc;not null" json:"deep_discharge_soc"`
+	MaxDeepDischargeEvents   int32     `gorm:"column:max_deep_discharge_events;not null" json:"max_deep_discharge_events"`
+	OverCurrentA             *float64  `gorm:"column:over_current_a" json:"over_current_a"`
+	MaxOverCurrentEvents     int32     `gorm:"column:max_over_current_events;not null" json:"max_over_current_events"`
+	Remark                   *string   `gorm:"column:remark" json:"remark"`
+	CreatedAt                time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt                time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (*WarrantyEligibilityRule) TableName() string {
+	return TableNameWarrantyEligibilityRule
+}
@@ -0,0 +1,54 @@
+package model
+
+// WarrantyEligibilityRuleUpsertReq 配置电池型号质保评估规则
+type WarrantyEligibilityRuleUpsertReq struct {
+	GraceDays                *int32   `json:"grace_days" binding:"omitempty,min=0,max=3650"`
+	RequireActivation        *bool    `json:"require_activation"`
+	MaxCycleCount            *float64 `json:"max_cycle_count" binding:"omitempty,gt=0"`
+	SohGuarantee             *float64 `json:"soh_guarantee" binding:"omitempty,gt=0,lte=100"`
+	OverTemperatureC         *float64 `json:"over_temperature_c" binding:"omitempty,gt=0"`
+	MaxOverTemperatureEvents *int32   `json:"max_over_temperature_events" binding:"omitempty,min=0"`
+	DeepDischargeSoc         *float64 `json:"deep_discharge_soc" binding:"omitempty,min=0,max=100"`
+	MaxDeepDischargeEvents   *int32   `json:"max_deep_discharge_events" binding:"omitempty,min=0"`
+	OverCurrentA             *float64 `json:"over_current_a" binding:"omitempty,gt=0"`
+	MaxOverCurrentEvents     *int32   `json:"max_over_current_events" binding:"omitempty,min=0"`
+	Remark                   *string  `json:"remark" binding:"omitempty,max=255"`
+}
+
+// WarrantyEligibilityRuleResp 电池型号质保评估规则
+type WarrantyEligibilityRuleResp struct {
+	BatteryModelID           string   `json:"battery_model_id"`
+	BatteryModelName         string   `json:"battery_model_name"`
+	IsDefault                bool     `json:"is_default"` // 未单独配置，使用系统默认规则
+	GraceDays                int32    `json:"grace_days"`
+	RequireActivation        bool     `json:"require_activation"`
+	MaxCycleCount            *float64 `json:"max_cycle_count"`
+	SohGuarantee             *float64 `json:"soh_guarantee"`
+	OverTemperatureC         float64  `json:"over_temperature_c"`
+	MaxOverTemperatureEvents int32    `json:"max_over_temperature_events"`
+	DeepDischargeSoc         float64  `json:"deep_discharge_soc"`
+	MaxDeepDischargeEvents   int32    `json:"max_deep_discharge_events"`
+	OverCurrentA             *float64 `json:"over_current_a"`
+	MaxOverCurrentEvents     int32    `json:"max_over_current_events"`
+	Remark                   *string  `json:"remark"`
+	UpdatedAt                *string  `json:"updated_at"`
+}
+
+// WarrantyEvaluationCheck 质保评估单项检查
+type WarrantyEvaluationCheck struct {
+	Code      string      `json:"code"`   // WARRANTY_PERIOD/ACTIVATION/CYCLE_COUNT/SOH/OVER_TEMPERATURE/DEEP_DISCHARGE/OVER_CURRENT
+	Result    string      `json:"result"` // PASS/FAIL/UNKNOWN/INFO
+	Actual    interface{} `json:"actual"`
+	Threshold interface{} `json:"threshold"`
+	Message   string      `json:"message"`
+}
+
+// WarrantyEvaluationReport 质保资格评估证据报告
+type WarrantyEvaluationReport struct {
+	Recommendation string                    `json:"recommendation"` // APPROVE/REJECT/MANUAL_REVIEW
+	Reasons        []string                  `json:"reasons"`
+	Checks         []WarrantyEvaluationCheck `json:"checks"`
+	BatteryModelID *string                   `json:"battery_model_id"`
+	RuleSource     string                    `json:"rule_source"` // MODEL/DEFAULT
+	EvaluatedAt    string                    `json:"evaluated_at"`
+}
@@ -0,0 +1,83 @@
+package model
+
+import "time"
+
+const (
+	TableNameWebhookSubscription = "webhook_subscriptions"
+	TableNameWebhookDelivery     = "webhook_deliveries"
+)
+
+// Webhook 订阅事件类型
+const (
+	WebhookEventTelemetry       = "telemetry"        // 遥测上报
+	WebhookEventDeviceOnline    = "device.online"    // 设备上线
+	WebhookEventDeviceOffline   = "device.offline"   // 设备离线
+	WebhookEventAlarm           = "alarm"            // 告警触发
+	WebhookEventAlarmRecovery   = "alarm.recovery"   // 告警恢复
+	WebhookEventOtaProgress     = "ota.progress"     // OTA升级进度
+	WebhookEventBatteryTransfer = "battery.transfer" // 电池（设备）转移
+	WebhookEventNotification    = "notification"     // 通知组 Webhook 通知
+	WebhookEventPing            = "ping"             // 订阅测试
+)
+
+// WebhookEventTypes 可订阅的事件类型
+var WebhookEventTypes = []string{
+	WebhookEventTelemetry,
+	WebhookEventDeviceOnline,
+	WebhookEventDeviceOffline,
+	WebhookEventAlarm,
+	WebhookEventAlarmRecovery,
+	WebhookEventOtaProgress,
+	WebhookEventBatteryTransfer,
+}
+
+// Webhook 投递状态
+const (
+	WebhookDeliveryPending = "PENDING" // 待投递/重试中
+	WebhookDeliverySuccess = "SUCCESS" // 成功
+	WebhookDeliveryDead    = "DEAD"    // 重试耗尽
+)
+
+// WebhookSubscription Webhook事件订阅
+type WebhookSubscription struct {
+	ID              string    `gorm:"column:id;primaryKey" json:"id"`
+	TenantID        string    `gorm:"column:tenant_id;not null" json:"tenant_id"`
+	Name            string    `gorm:"column:name;not null" json:"name"`
+	TargetURL       string    `gorm:"column:target_url;not null" json:"target_url"`
+	Secret          string    `gorm:"column:secret;not null" json:"secret,omitempty"`
+	EventTypes      string    `gorm:"column:event_types;not null" json:"event_types"`
+	DeviceIDs       *string   `gorm:"column:device_ids" json:"device_ids"`
+	DeviceConfigIDs *string   `gorm:"column:device_config_ids" json:"device_config_ids"`
+	Enabled         bool      `gorm:"column:enabled;not null" json:"enabled"`
+	Remark          *string   `gorm:"column:remark" json:"remark"`
+	CreatedAt       time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt       time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (*WebhookSubscription) TableName() string {
+	return TableNameWebhookSubscription
+}
+
+// WebhookDelivery Webhook投递记录
+type WebhookDelivery struct {
+	ID                    string     `gorm:"column:id;primaryKey" json:"id"`
+	TenantID              string     `gorm:"column:tenant_id;not null" json:"tenant_id"`
+	SubscriptionID        *string    `gorm:"column:subscription_id" json:"subscription_id"`
+	NotificationGroupID   *string    `gorm:"column:notification_group_id" json:"notification_group_id"`
+	NotificationHistoryID *string    `gorm:"column:notification_history_id" json:"notification_history_id"`
+	EventType             string     `gorm:"column:event_type;not null" json:"event_type"`
+	TargetURL             string     `gorm:"column:target_url;not null" json:"target_url"`
+	Payload               string     `gorm:"column:payload;not null" json:"payload"`
+	Status                string     `gorm:"column:status;not null" json:"status"`
+	Attempts              int32      `gorm:"column:attempts;not null" json:"attempts"`
+	NextAttemptAt         time.Time  `gorm:"column:next_attempt_at;not null" json:"next_attempt_at"`
+	LastStatusCode        *int32     `gorm:"column:last_status_code" json:"last_status_code"`
+	LastError             *string    `gorm:"column:last_error" json:"last_error"`
+	DeliveredAt           *time.Time `gorm:"column:delivered_at" json:"delivered_at"`
+	CreatedAt             time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt             time.Time  `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (*WebhookDelivery) TableName() string {
+	return TableNameWebhookDelivery
+}
@@ -0,0 +1,35 @@
+package model
+
+type CreateWebhookSubscriptionReq struct {
+	Name            string   `json:"name" validate:"required,max=100"`                           // 名称
+	TargetURL       string   `json:"target_url" validate:"required,url,max=500"`                 // 目标地址
+	Secret          *string  `json:"secret" validate:"omitempty,min=16,max=200"`                 // 签名密钥，不传自动生成
+	EventTypes      []string `json:"event_types" validate:"required,min=1,dive,max=50"`          // 订阅的事件类型
+	DeviceIDs       []string `json:"device_ids" validate:"omitempty,max=1000,dive,max=36"`       // 设备过滤
+	DeviceConfigIDs []string `json:"device_config_ids" validate:"omitempty,max=100,dive,max=36"` // 设备配置过滤
+	Enabled         *bool    `json:"enabled" validate:"omitempty"`                               // 是否启用，默认启用
+	Remark          *string  `json:"remark" validate:"omitempty,max=255"`                        // 备注
+}
+
+type UpdateWebhookSubscriptionReq struct {
+	Name            *string   `json:"name" validate:"omitempty,max=100"`
+	TargetURL       *string   `json:"target_url" validate:"omitempty,url,max=500"`
+	Secret          *string   `json:"secret" validate:"omitempty,min=16,max=200"`
+	EventTypes      *[]string `json:"event_types" validate:"omitempty,min=1,dive,max=50"`
+	DeviceIDs       *[]string `json:"device_ids" validate:"omitempty,max=1000,dive,max=36"`
+	DeviceConfigIDs *[]string `json:"device_config_ids" validate:"omitempty,max=100,dive,max=36"`
+	Enabled         *bool     `json:"enabled" validate:"omitempty"`
+	Remark          *string   `json:"remark" validate:"omitempty,max=255"`
+}
+
+type GetWebhookSubscriptionListByPageReq struct {
+	PageReq
+	Enabled *bool `json:"enabled" form:"enabled" validate:"omitempty"`
+}
+
+type GetWebhookDeliveryListByPageReq struct {
+	PageReq
+	SubscriptionID *string `json:"subscription_id" form:"subscription_id" validate:"omitempty,max=36"`   // 订阅id
+	Status         *string `json:"status" form:"status" validate:"omitempty,oneof=PENDING SUCCESS DEAD"` // 投递状态
+	EventType      *string `json:"event_type" form:"event_type" validate:"omitempty,max=50"`             // 事件类型
+}
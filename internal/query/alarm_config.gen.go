@@ -37,6 +37,14 @@ func newAlarmConfig(db *gorm.DB, opts ...gen.DOOption) alarmConfig {
 	_alarmConfig.TenantID = field.NewString(tableName, "tenant_id")
 	_alarmConfig.Remark = field.NewString(tableName, "remark")
 	_alarmConfig.Enabled = field.NewString(tableName, "enabled")
+	_alarmConfig.AckSlaMinutes = field.NewInt32(tableName, "ack_sla_minutes")
+	_alarmConfig.ResolveSlaMinutes = field.NewInt32(tableName, "resolve_sla_minutes")
+	_alarmConfig.EscalationMinutes = field.NewInt32(tableName, "escalation_minutes")
+	_alarmConfig.EscalationNotificationGroupID = field.NewString(tableName, "escalation_notification_group_id")
+	_alarmConfig.GroupBy = field.NewString(tableName, "group_by")
+	_alarmConfig.GroupWindowSeconds = field.NewInt32(tableName, "group_window_seconds")
+	_alarmConfig.FlapThreshold = field.NewInt32(tableName, "flap_threshold")
+	_alarmConfig.FlapWindowSeconds = field.NewInt32(tableName, "flap_window_seconds")
 
 	_alarmConfig.fillFieldMap()
 
@@ -46,17 +54,25 @@ func newAlarmConfig(db *gorm.DB, opts ...gen.DOOption) alarmConfig {
 type alarmConfig struct {
 	alarmConfigDo
 
-	ALL                 field.Asterisk
-	ID                  field.String
-	Name                field.String // 告警名称
-	Description         field.String // 告警描述
-	AlarmLevel          field.String // 告警级别H: 高M: 中L: 低
-	NotificationGroupID field.String // 通知组id
-	CreatedAt           field.Time
-	UpdatedAt           field.Time
-	TenantID            field.String
-	Remark              field.String
-	Enabled             field.String // 是否启用Y-启用N-停止
+	ALL                           field.Asterisk
+	ID                            field.String
+	Name                          field.String // 告警名称
+	Description                   field.String // 告警描述
+	AlarmLevel                    field.String // 告警级别H: 高M: 中L: 低
+	NotificationGroupID           field.String // 通知组id
+	CreatedAt                     field.Time
+	UpdatedAt                     field.Time
+	TenantID                      field.String
+	Remark                        field.String
+	Enabled                       field.String // 是否启用Y-启用N-停止
+	AckSlaMinutes                 field.Int32  // 确认时限（分钟）
+	ResolveSlaMinutes             field.Int32  // 解决时限（分钟）
+	EscalationMinutes             field.Int32  // 未确认升级时限（分钟）
+	EscalationNotificationGroupID field.String // 升级通知组id
+	GroupBy                       field.String // 告警合并方式
+	GroupWindowSeconds            field.Int32  // 告警合并时间窗口（秒）
+	FlapThreshold                 field.Int32  // 抖动判定触发次数
+	FlapWindowSeconds             field.Int32  // 抖动判定时间窗口（秒）
 
 	fieldMap map[string]field.Expr
 }
@@ -83,6 +99,14 @@ func (a *alarmConfig) updateTableName(table string) *alarmConfig {
 	a.TenantID = field.NewString(table, "tenant_id")
 	a.Remark = field.NewString(table, "remark")
 	a.Enabled = field.NewString(table, "enabled")
+	a.AckSlaMinutes = field.NewInt32(table, "ack_sla_minutes")
+	a.ResolveSlaMinutes = field.NewInt32(table, "resolve_sla_minutes")
+	a.EscalationMinutes = field.NewInt32(table, "escalation_minutes")
+	a.EscalationNotificationGroupID = field.NewString(table, "escalation_notification_group_id")
+	a.GroupBy = field.NewString(table, "group_by")
+	a.GroupWindowSeconds = field.NewInt32(table, "group_window_seconds")
+	a.FlapThreshold = field.NewInt32(table, "flap_threshold")
+	a.FlapWindowSeconds = field.NewInt32(table, "flap_window_seconds")
 
 	a.fillFieldMap()
 
@@ -99,7 +123,7 @@ func (a *alarmConfig) GetFieldByName(fieldName string) (field.OrderExpr, bool) {
 }
 
 func (a *alarmConfig) fillFieldMap() {
-	a.fieldMap = make(map[string]field.Expr, 10)
+	a.fieldMap = make(map[string]field.Expr, 18)
 	a.fieldMap["id"] = a.ID
 	a.fieldMap["name"] = a.Name
 	a.fieldMap["description"] = a.Description
@@ -110,6 +134,14 @@ func (a *alarmConfig) fillFieldMap() {
 	a.fieldMap["tenant_id"] = a.TenantID
 	a.fieldMap["remark"] = a.Remark
 	a.fieldMap["enabled"] = a.Enabled
+	a.fieldMap["ack_sla_minutes"] = a.AckSlaMinutes
+	a.fieldMap["resolve_sla_minutes"] = a.ResolveSlaMinutes
+	a.fieldMap["escalation_minutes"] = a.EscalationMinutes
+	a.fieldMap["escalation_notification_group_id"] = a.EscalationNotificationGroupID
+	a.fieldMap["group_by"] = a.GroupBy
+	a.fieldMap["group_window_seconds"] = a.GroupWindowSeconds
+	a.fieldMap["flap_threshold"] = a.FlapThreshold
+	a.fieldMap["flap_window_seconds"] = a.FlapWindowSeconds
 }
 
 func (a alarmConfig) clone(db *gorm.DB) alarmConfig {
@@ -39,6 +39,11 @@ func newAlarmHistory(db *gorm.DB, opts ...gen.DOOption) alarmHistory {
 	_alarmHistory.Remark = field.NewString(tableName, "remark")
 	_alarmHistory.CreateAt = field.NewTime(tableName, "create_at")
 	_alarmHistory.AlarmDeviceList = field.NewString(tableName, "alarm_device_list")
+	_alarmHistory.Severity = field.NewString(tableName, "severity")
+	_alarmHistory.LifecycleState = field.NewString(tableName, "lifecycle_state")
+	_alarmHistory.AssigneeID = field.NewString(tableName, "assignee_id")
+	_alarmHistory.IncidentID = field.NewString(tableName, "incident_id")
+	_alarmHistory.SuppressReason = field.NewString(tableName, "suppress_reason")
 
 	_alarmHistory.fillFieldMap()
 
@@ -61,6 +66,11 @@ type alarmHistory struct {
 	Remark            field.String
 	CreateAt          field.Time   // 创建时间
 	AlarmDeviceList   field.String // 触发设备id
+	Severity          field.String // 触发时的告警级别，恢复记录为空
+	LifecycleState    field.String // 处理状态
+	AssigneeID        field.String // 处理人
+	IncidentID        field.String // 所属告警事件
+	SuppressReason    field.String // 未发送通知的原因
 
 	fieldMap map[string]field.Expr
 }
@@ -89,6 +99,11 @@ func (a *alarmHistory) updateTableName(table string) *alarmHistory {
 	a.Remark = field.NewString(table, "remark")
 	a.CreateAt = field.NewTime(table, "create_at")
 	a.AlarmDeviceList = field.NewString(table, "alarm_device_list")
+	a.Severity = field.NewString(table, "severity")
+	a.LifecycleState = field.NewString(table, "lifecycle_state")
+	a.AssigneeID = field.NewString(table, "assignee_id")
+	a.IncidentID = field.NewString(table, "incident_id")
+	a.SuppressReason = field.NewString(table, "suppress_reason")
 
 	a.fillFieldMap()
 
@@ -105,7 +120,7 @@ func (a *alarmHistory) GetFieldByName(fieldName string) (field.OrderExpr, bool)
 }
 
 func (a *alarmHistory) fillFieldMap() {
-	a.fieldMap = make(map[string]field.Expr, 12)
+	a.fieldMap = make(map[string]field.Expr, 17)
 	a.fieldMap["id"] = a.ID
 	a.fieldMap["alarm_config_id"] = a.AlarmConfigID
 	a.fieldMap["group_id"] = a.GroupID
@@ -118,6 +133,11 @@ func (a *alarmHistory) fillFieldMap() {
 	a.fieldMap["remark"] = a.Remark
 	a.fieldMap["create_at"] = a.CreateAt
 	a.fieldMap["alarm_device_list"] = a.AlarmDeviceList
+	a.fieldMap["severity"] = a.Severity
+	a.fieldMap["lifecycle_state"] = a.LifecycleState
+	a.fieldMap["assignee_id"] = a.AssigneeID
+	a.fieldMap["incident_id"] = a.IncidentID
+	a.fieldMap["suppress_reason"] = a.SuppressReason
 }
 
 func (a alarmHistory) clone(db *gorm.DB) alarmHistory {
@@ -39,6 +39,16 @@ func newDeviceTriggerCondition(db *gorm.DB, opts ...gen.DOOption) deviceTriggerC
 	_deviceTriggerCondition.TriggerValue = field.NewString(tableName, "trigger_value")
 	_deviceTriggerCondition.Remark = field.NewString(tableName, "remark")
 	_deviceTriggerCondition.TenantID = field.NewString(tableName, "tenant_id")
+	_deviceTriggerCondition.HoldDuration = field.NewInt32(tableName, "hold_duration")
+	_deviceTriggerCondition.SampleWindow = field.NewInt32(tableName, "sample_window")
+	_deviceTriggerCondition.SampleHits = field.NewInt32(tableName, "sample_hits")
+	_deviceTriggerCondition.ClearValue = field.NewString(tableName, "clear_value")
+	_deviceTriggerCondition.WindowFunc = field.NewString(tableName, "window_func")
+	_deviceTriggerCondition.WindowSeconds = field.NewInt32(tableName, "window_seconds")
+	_deviceTriggerCondition.AggregateFunc = field.NewString(tableName, "aggregate_func")
+	_deviceTriggerCondition.MemberOperator = field.NewString(tableName, "member_operator")
+	_deviceTriggerCondition.MemberValue = field.NewString(tableName, "member_value")
+	_deviceTriggerCondition.AggregateBySubgroup = field.NewBool(tableName, "aggregate_by_subgroup")
 
 	_deviceTriggerCondition.fillFieldMap()
 
@@ -61,6 +71,16 @@ type deviceTriggerCondition struct {
 	TriggerValue         field.String // 取值条件类型为10,11，运算符是为7时，假设最大值6最小值2, 格式为2-6；设备状态条件类型为10,11，运算符为8时，多个值英文逗号隔开条件类型为 条件类型是22，示例137|HH:mm:ss+00:00|HH:mm:ss+00:00
 	Remark               field.String
 	TenantID             field.String // 租户ID
+	HoldDuration         field.Int32  // 持续时间(秒)：条件连续成立达到该时长才触发
+	SampleWindow         field.Int32  // N/M 采样窗口 M：最近 M 次上报
+	SampleHits           field.Int32  // N/M 采样命中数 N：窗口内至少 N 次成立才触发
+	ClearValue           field.String // 恢复阈值（回差）：触发后数值越过该阈值才恢复，仅支持 > >= < <=
+	WindowFunc           field.String // 窗口统计函数 RATE：变化率(每分钟) DELTA：差值 AVG MIN MAX STDDEV，为空时使用原始值
+	WindowSeconds        field.Int32  // 统计窗口长度(秒)
+	AggregateFunc        field.String // 聚合函数（条件类型12/13）COUNT：满足成员条件的设备数 PERCENT：满足成员条件的设备占比(%) AVG MIN MAX SUM
+	MemberOperator       field.String // 成员条件运算符（COUNT/PERCENT）
+	MemberValue          field.String // 成员条件取值（COUNT/PERCENT），状态条件为 ON-LINE/OFF-LINE
+	AggregateBySubgroup  field.Bool   // 按直属子分组分别聚合（条件类型12），任一子分组满足即触发
 
 	fieldMap map[string]field.Expr
 }
@@ -89,6 +109,16 @@ func (d *deviceTriggerCondition) updateTableName(table string) *deviceTriggerCon
 	d.TriggerValue = field.NewString(table, "trigger_value")
 	d.Remark = field.NewString(table, "remark")
 	d.TenantID = field.NewString(table, "tenant_id")
+	d.HoldDuration = field.NewInt32(table, "hold_duration")
+	d.SampleWindow = field.NewInt32(table, "sample_window")
+	d.SampleHits = field.NewInt32(table, "sample_hits")
+	d.ClearValue = field.NewString(table, "clear_value")
+	d.WindowFunc = field.NewString(table, "window_func")
+	d.WindowSeconds = field.NewInt32(table, "window_seconds")
+	d.AggregateFunc = field.NewString(table, "aggregate_func")
+	d.MemberOperator = field.NewString(table, "member_operator")
+	d.MemberValue = field.NewString(table, "member_value")
+	d.AggregateBySubgroup = field.NewBool(table, "aggregate_by_subgroup")
 
 	d.fillFieldMap()
 
@@ -105,7 +135,7 @@ func (d *deviceTriggerCondition) GetFieldByName(fieldName string) (field.OrderEx
 }
 
 func (d *deviceTriggerCondition) fillFieldMap() {
-	d.fieldMap = make(map[string]field.Expr, 12)
+	d.fieldMap = make(map[string]field.Expr, 22)
 	d.fieldMap["id"] = d.ID
 	d.fieldMap["scene_automation_id"] = d.SceneAutomationID
 	d.fieldMap["enabled"] = d.Enabled
@@ -118,6 +148,16 @@ func (d *deviceTriggerCondition) fillFieldMap() {
 	d.fieldMap["trigger_value"] = d.TriggerValue
 	d.fieldMap["remark"] = d.Remark
 	d.fieldMap["tenant_id"] = d.TenantID
+	d.fieldMap["hold_duration"] = d.HoldDuration
+	d.fieldMap["sample_window"] = d.SampleWindow
+	d.fieldMap["sample_hits"] = d.SampleHits
+	d.fieldMap["clear_value"] = d.ClearValue
+	d.fieldMap["window_func"] = d.WindowFunc
+	d.fieldMap["window_seconds"] = d.WindowSeconds
+	d.fieldMap["aggregate_func"] = d.AggregateFunc
+	d.fieldMap["member_operator"] = d.MemberOperator
+	d.fieldMap["member_value"] = d.MemberValue
+	d.fieldMap["aggregate_by_subgroup"] = d.AggregateBySubgroup
 }
 
 func (d deviceTriggerCondition) clone(db *gorm.DB) deviceTriggerCondition {
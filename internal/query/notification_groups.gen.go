@@ -37,6 +37,8 @@ func newNotificationGroup(db *gorm.DB, opts ...gen.DOOption) notificationGroup {
 	_notificationGroup.CreatedAt = field.NewTime(tableName, "created_at")
 	_notificationGroup.UpdatedAt = field.NewTime(tableName, "updated_at")
 	_notificationGroup.Remark = field.NewString(tableName, "remark")
+	_notificationGroup.StormLimit = field.NewInt32(tableName, "storm_limit")
+	_notificationGroup.StormWindowSeconds = field.NewInt32(tableName, "storm_window_seconds")
 
 	_notificationGroup.fillFieldMap()
 
@@ -49,7 +51,7 @@ type notificationGroup struct {
 	ALL                field.Asterisk
 	ID                 field.String
 	Name               field.String // 名称
-	NotificationType   field.String // 通知类型MEMBER-成员通知 EMAIL-邮箱通知 SME-短信通知 VOICE-语音通知 WEBHOOK-webhook
+	NotificationType   field.String // 通知类型MEMBER-成员通知 EMAIL-邮箱通知 SME-短信通知 VOICE-语音通知 WEBHOOK-webhook DINGTALK-钉钉机器人 WECOM-企业微信机器人 FEISHU-飞书机器人 SLACK-Slack
 	Status             field.String // 通知状态ON-启用 OFF-停用
 	NotificationConfig field.String // 通知配置
 	Description        field.String // 描述
@@ -57,6 +59,8 @@ type notificationGroup struct {
 	CreatedAt          field.Time   // 创建时间
 	UpdatedAt          field.Time   // 更新时间
 	Remark             field.String // 备注
+	StormLimit         field.Int32  // 通知风暴限制：时间窗口内最多发送条数
+	StormWindowSeconds field.Int32  // 通知风暴限制时间窗口（秒）
 
 	fieldMap map[string]field.Expr
 }
@@ -83,6 +87,8 @@ func (n *notificationGroup) updateTableName(table string) *notificationGroup {
 	n.CreatedAt = field.NewTime(table, "created_at")
 	n.UpdatedAt = field.NewTime(table, "updated_at")
 	n.Remark = field.NewString(table, "remark")
+	n.StormLimit = field.NewInt32(table, "storm_limit")
+	n.StormWindowSeconds = field.NewInt32(table, "storm_window_seconds")
 
 	n.fillFieldMap()
 
@@ -99,7 +105,7 @@ func (n *notificationGroup) GetFieldByName(fieldName string) (field.OrderExpr, b
 }
 
 func (n *notificationGroup) fillFieldMap() {
-	n.fieldMap = make(map[string]field.Expr, 10)
+	n.fieldMap = make(map[string]field.Expr, 12)
 	n.fieldMap["id"] = n.ID
 	n.fieldMap["name"] = n.Name
 	n.fieldMap["notification_type"] = n.NotificationType
@@ -110,6 +116,8 @@ func (n *notificationGroup) fillFieldMap() {
 	n.fieldMap["created_at"] = n.CreatedAt
 	n.fieldMap["updated_at"] = n.UpdatedAt
 	n.fieldMap["remark"] = n.Remark
+	n.fieldMap["storm_limit"] = n.StormLimit
+	n.fieldMap["storm_window_seconds"] = n.StormWindowSeconds
 }
 
 func (n notificationGroup) clone(db *gorm.DB) notificationGroup {
@@ -50,7 +50,7 @@ type notificationHistory struct {
 	SendContent      field.String // 发送内容
 	SendTarget       field.String // 发送目标
 	SendResult       field.String // 发送结果SUCCESS-成功FAILURE-失败
-	NotificationType field.String // 通知类型MEMBER-成员通知 EMAIL-邮箱通知 SME-短信通知 VOICE-语音通知 WEBHOOK-webhook
+	NotificationType field.String // 通知类型MEMBER-成员通知 EMAIL-邮箱通知 SME-短信通知 VOICE-语音通知 WEBHOOK-webhook DINGTALK-钉钉机器人 WECOM-企业微信机器人 FEISHU-飞书机器人 SLACK-Slack
 	TenantID         field.String // 租户id
 	Remark           field.String // 备注
 
@@ -35,6 +35,18 @@ func newOpenAPIKey(db *gorm.DB, opts ...gen.DOOption) openAPIKey {
 	_openAPIKey.CreatedAt = field.NewTime(tableName, "created_at")
 	_openAPIKey.UpdatedAt = field.NewTime(tableName, "updated_at")
 	_openAPIKey.CreatedID = field.NewString(tableName, "created_id")
+	_openAPIKey.Scopes = field.NewString(tableName, "scopes")
+	_openAPIKey.OrgID = field.NewString(tableName, "org_id")
+	_openAPIKey.DeviceGroupID = field.NewString(tableName, "device_group_id")
+	_openAPIKey.IPAllowlist = field.NewString(tableName, "ip_allowlist")
+	_openAPIKey.ExpiresAt = field.NewTime(tableName, "expires_at")
+	_openAPIKey.RateLimit = field.NewInt32(tableName, "rate_limit")
+	_openAPIKey.PreviousAPIKey = field.NewString(tableName, "previous_api_key")
+	_openAPIKey.PreviousKeyExpiresAt = field.NewTime(tableName, "previous_key_expires_at")
+	_openAPIKey.RotatedAt = field.NewTime(tableName, "rotated_at")
+	_openAPIKey.LastUsedAt = field.NewTime(tableName, "last_used_at")
+	_openAPIKey.LastUsedIP = field.NewString(tableName, "last_used_ip")
+	_openAPIKey.RequestCount = field.NewInt64(tableName, "request_count")
 
 	_openAPIKey.fillFieldMap()
 
@@ -44,15 +56,27 @@ func newOpenAPIKey(db *gorm.DB, opts ...gen.DOOption) openAPIKey {
 type openAPIKey struct {
 	openAPIKeyDo
 
-	ALL       field.Asterisk
-	ID        field.String
-	TenantID  field.String
-	APIKey    field.String
-	Status    field.Int16
-	Name      field.String
-	CreatedAt field.Time
-	UpdatedAt field.Time
-	CreatedID field.String
+	ALL                  field.Asterisk
+	ID                   field.String
+	TenantID             field.String
+	APIKey               field.String
+	Status               field.Int16
+	Name                 field.String
+	CreatedAt            field.Time
+	UpdatedAt            field.Time
+	CreatedID            field.String
+	Scopes               field.String
+	OrgID                field.String
+	DeviceGroupID        field.String
+	IPAllowlist          field.String
+	ExpiresAt            field.Time
+	RateLimit            field.Int32
+	PreviousAPIKey       field.String
+	PreviousKeyExpiresAt field.Time
+	RotatedAt            field.Time
+	LastUsedAt           field.Time
+	LastUsedIP           field.String
+	RequestCount         field.Int64
 
 	fieldMap map[string]field.Expr
 }
@@ -77,6 +101,18 @@ func (o *openAPIKey) updateTableName(table string) *openAPIKey {
 	o.CreatedAt = field.NewTime(table, "created_at")
 	o.UpdatedAt = field.NewTime(table, "updated_at")
 	o.CreatedID = field.NewString(table, "created_id")
+	o.Scopes = field.NewString(table, "scopes")
+	o.OrgID = field.NewString(table, "org_id")
+	o.DeviceGroupID = field.NewString(table, "device_group_id")
+	o.IPAllowlist = field.NewString(table, "ip_allowlist")
+	o.ExpiresAt = field.NewTime(table, "expires_at")
+	o.RateLimit = field.NewInt32(table, "rate_limit")
+	o.PreviousAPIKey = field.NewString(table, "previous_api_key")
+	o.PreviousKeyExpiresAt = field.NewTime(table, "previous_key_expires_at")
+	o.RotatedAt = field.NewTime(table, "rotated_at")
+	o.LastUsedAt = field.NewTime(table, "last_used_at")
+	o.LastUsedIP = field.NewString(table, "last_used_ip")
+	o.RequestCount = field.NewInt64(table, "request_count")
 
 	o.fillFieldMap()
 
@@ -93,7 +129,7 @@ func (o *openAPIKey) GetFieldByName(fieldName string) (field.OrderExpr, bool) {
 }
 
 func (o *openAPIKey) fillFieldMap() {
-	o.fieldMap = make(map[string]field.Expr, 8)
+	o.fieldMap = make(map[string]field.Expr, 20)
 	o.fieldMap["id"] = o.ID
 	o.fieldMap["tenant_id"] = o.TenantID
 	o.fieldMap["api_key"] = o.APIKey
@@ -102,6 +138,18 @@ func (o *openAPIKey) fillFieldMap() {
 	o.fieldMap["created_at"] = o.CreatedAt
 	o.fieldMap["updated_at"] = o.UpdatedAt
 	o.fieldMap["created_id"] = o.CreatedID
+	o.fieldMap["scopes"] = o.Scopes
+	o.fieldMap["org_id"] = o.OrgID
+	o.fieldMap["device_group_id"] = o.DeviceGroupID
+	o.fieldMap["ip_allowlist"] = o.IPAllowlist
+	o.fieldMap["expires_at"] = o.ExpiresAt
+	o.fieldMap["rate_limit"] = o.RateLimit
+	o.fieldMap["previous_api_key"] = o.PreviousAPIKey
+	o.fieldMap["previous_key_expires_at"] = o.PreviousKeyExpiresAt
+	o.fieldMap["rotated_at"] = o.RotatedAt
+	o.fieldMap["last_used_at"] = o.LastUsedAt
+	o.fieldMap["last_used_ip"] = o.LastUsedIP
+	o.fieldMap["request_count"] = o.RequestCount
 }
 
 func (o openAPIKey) clone(db *gorm.DB) openAPIKey {
@@ -37,6 +37,8 @@ func newSceneAutomation(db *gorm.DB, opts ...gen.DOOption) sceneAutomation {
 	_sceneAutomation.CreatedAt = field.NewTime(tableName, "created_at")
 	_sceneAutomation.UpdatedAt = field.NewTime(tableName, "updated_at")
 	_sceneAutomation.Remark = field.NewString(tableName, "remark")
+	_sceneAutomation.Timezone = field.NewString(tableName, "timezone")
+	_sceneAutomation.HolidayCalendarID = field.NewString(tableName, "holiday_calendar_id")
 
 	_sceneAutomation.fillFieldMap()
 
@@ -46,17 +48,19 @@ func newSceneAutomation(db *gorm.DB, opts ...gen.DOOption) sceneAutomation {
 type sceneAutomation struct {
 	sceneAutomationDo
 
-	ALL         field.Asterisk
-	ID          field.String // 联动
-	Name        field.String // 名称
-	Description field.String // 描述
-	Enabled     field.String // 是否启用 Y：启用 N：停用
-	TenantID    field.String // 租户ID
-	Creator     field.String // 创建人id
-	Updator     field.String // 修改人id
-	CreatedAt   field.Time   // 创建时间
-	UpdatedAt   field.Time   // 更新时间
-	Remark      field.String
+	ALL               field.Asterisk
+	ID                field.String // 联动
+	Name              field.String // 名称
+	Description       field.String // 描述
+	Enabled           field.String // 是否启用 Y：启用 N：停用
+	TenantID          field.String // 租户ID
+	Creator           field.String // 创建人id
+	Updator           field.String // 修改人id
+	CreatedAt         field.Time   // 创建时间
+	UpdatedAt         field.Time   // 更新时间
+	Remark            field.String
+	Timezone          field.String // IANA 时区，为空时使用租户时区
+	HolidayCalendarID field.String // 排除的节假日日历：节假日当天时间范围条件不成立、定时触发不执行
 
 	fieldMap map[string]field.Expr
 }
@@ -83,6 +87,8 @@ func (s *sceneAutomation) updateTableName(table string) *sceneAutomation {
 	s.CreatedAt = field.NewTime(table, "created_at")
 	s.UpdatedAt = field.NewTime(table, "updated_at")
 	s.Remark = field.NewString(table, "remark")
+	s.Timezone = field.NewString(table, "timezone")
+	s.HolidayCalendarID = field.NewString(table, "holiday_calendar_id")
 
 	s.fillFieldMap()
 
@@ -99,7 +105,7 @@ func (s *sceneAutomation) GetFieldByName(fieldName string) (field.OrderExpr, boo
 }
 
 func (s *sceneAutomation) fillFieldMap() {
-	s.fieldMap = make(map[string]field.Expr, 10)
+	s.fieldMap = make(map[string]field.Expr, 12)
 	s.fieldMap["id"] = s.ID
 	s.fieldMap["name"] = s.Name
 	s.fieldMap["description"] = s.Description
@@ -110,6 +116,8 @@ func (s *sceneAutomation) fillFieldMap() {
 	s.fieldMap["created_at"] = s.CreatedAt
 	s.fieldMap["updated_at"] = s.UpdatedAt
 	s.fieldMap["remark"] = s.Remark
+	s.fieldMap["timezone"] = s.Timezone
+	s.fieldMap["holiday_calendar_id"] = s.HolidayCalendarID
 }
 
 func (s sceneAutomation) clone(db *gorm.DB) sceneAutomation {
@@ -33,6 +33,14 @@ func (*Alarm) CreateAlarmConfig(req *model.CreateAlarmConfigReq) (data *model.Al
 	data.TenantID = req.TenantID
 	data.Remark = req.Remark
 	data.Enabled = req.Enabled
+	data.AckSlaMinutes = req.AckSlaMinutes
+	data.ResolveSlaMinutes = req.ResolveSlaMinutes
+	data.EscalationMinutes = req.EscalationMinutes
+	data.EscalationNotificationGroupID = req.EscalationNotificationGroupID
+	data.GroupBy = req.GroupBy
+	data.GroupWindowSeconds = req.GroupWindowSeconds
+	data.FlapThreshold = req.FlapThreshold
+	data.FlapWindowSeconds = req.FlapWindowSeconds
 
 	err = dal.CreateAlarmConfig(data)
 	if err != nil {
@@ -79,6 +87,14 @@ func (*Alarm) UpdateAlarmConfig(req *model.UpdateAlarmConfigReq) (data *model.Al
 	if req.Enabled != nil {
 		data.Enabled = *req.Enabled
 	}
+	data.AckSlaMinutes = req.AckSlaMinutes
+	data.ResolveSlaMinutes = req.ResolveSlaMinutes
+	data.EscalationMinutes = req.EscalationMinutes
+	data.EscalationNotificationGroupID = req.EscalationNotificationGroupID
+	data.GroupBy = req.GroupBy
+	data.GroupWindowSeconds = req.GroupWindowSeconds
+	data.FlapThreshold = req.FlapThreshold
+	data.FlapWindowSeconds = req.FlapWindowSeconds
 
 	err = dal.UpdateAlarmConfig(data)
 	if err != nil {
@@ -184,16 +200,6 @@ func (*Alarm) AlarmHistoryDescUpdate(req *model.AlarmHistoryDescUpdateReq, tenan
 	return
 }
 
-func (*Alarm) HandleAlarmHistory(req *model.AlarmHistoryHandleReq, tenantID string, userID string) (err error) {
-	err = dal.HandleAlarmHistory(req, tenantID, userID)
-	if err != nil {
-		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{
-			"sql_error": err.Error(),
-		})
-	}
-	return nil
-}
-
 func (*Alarm) GetDeviceAlarmStatus(req *model.GetDeviceAlarmStatusReq) bool {
 	return dal.GetDeviceAlarmStatus(req)
 }
@@ -249,8 +255,12 @@ Details: %s`,
 
 		// 构建增强的告警JSON (AddAlarmInfo方法没有device_ids参数，设为空数组)
 		alertData := map[string]interface{}{
+			"event_type":      model.NotificationEventAlarm,
+			"alarm_name":      alarmConfig.Name,
 			"subject":         subject,
 			"content":         notificationContent,
+			"description":     description,
+			"details":         content,
 			"timestamp":       time.Now().Format(time.RFC3339),
 			"alarm_level":     alarmConfig.AlarmLevel,
 			"tenant_id":       alarmConfig.TenantID,
@@ -313,12 +323,23 @@ func (*Alarm) AlarmRecovery(alarmConfigID, content, scene_automation_id, group_i
 		GroupID:           group_id,
 		AlarmDeviceList:   string(device_ids_str),
 		AlarmStatus:       "N",
+		LifecycleState:    model.AlarmStateResolved,
 		CreateAt:          t,
 	})
 	if err != nil {
 		logrus.Error(err)
 		return false, ""
 	}
+	autoResolveAlarms(alarmConfig.TenantID, alarmConfigID, scene_automation_id, group_id)
+	go publishWebhookEvent(alarmConfig.TenantID, model.WebhookEventAlarmRecovery, device_ids, map[string]interface{}{
+		"alarm_history_id":    id,
+		"alarm_config_id":     alarmConfigID,
+		"alarm_name":          alarmConfig.Name,
+		"alarm_level":         alarmConfig.AlarmLevel,
+		"content":             content,
+		"device_ids":          device_ids,
+		"scene_automation_id": scene_automation_id,
+	})
 	return true, id
 }
 
@@ -335,7 +356,10 @@ func (*Alarm) AlarmExecute(alarmConfigID, content, scene_automation_id, group_id
 	}
 	alarmName = alarmConfig.Name
 	id := uuid.New()
-	if alarmConfig.NotificationGroupID != "" {
+	t := time.Now().UTC()
+	// 相关告警合并为告警事件，并入已有事件或抖动中的告警不再单独通知
+	incidentID, suppressReason := alarmIncidentFor(alarmConfig, scene_automation_id, group_id, device_ids, t)
+	if alarmConfig.NotificationGroupID != "" && suppressReason == nil {
 		// 组装标准的通知内容
 		subject := fmt.Sprintf("[ALERT] %s [%s]", alarmConfig.Name, alarmConfig.AlarmLevel)
 
@@ -388,14 +412,18 @@ Details: %s`,
 			"id":                id,
 			"alarm_config_id":   alarmConfigID,
 			"alarm_config_name": alarmConfig.Name,
+			"event_type":        model.NotificationEventAlarm,
 			"subject":           subject,
 			"content":           notificationContent,
+			"description":       description,
+			"details":           content,
 			"timestamp":         time.Now().Format(time.RFC3339),
 			"alarm_level":       alarmConfig.AlarmLevel,
 			"tenant_id":         alarmConfig.TenantID,
 			"tenant_admin_id":   tenantAdminID,
 			"device_ids":        device_ids,
 			"devices":           devices,
+			"incident_id":       incidentID,
 		}
 
 		// 序列化JSON，不转义HTML字符
@@ -412,7 +440,6 @@ Details: %s`,
 	}
 	device_ids_str, _ := json.Marshal(device_ids)
 
-	t := time.Now().UTC()
 	err = dal.AlarmHistorySave(&model.AlarmHistory{
 		ID:                id,
 		Name:              alarmConfig.Name,
@@ -424,12 +451,27 @@ Details: %s`,
 		GroupID:           group_id,
 		AlarmDeviceList:   string(device_ids_str),
 		AlarmStatus:       alarmConfig.AlarmLevel,
+		Severity:          &alarmConfig.AlarmLevel,
+		LifecycleState:    model.AlarmStateOpen,
+		IncidentID:        incidentID,
+		SuppressReason:    suppressReason,
 		CreateAt:          t,
 	})
 	if err != nil {
 		logrus.Error(err)
 		return false, alarmName, err.Error()
 	}
+	go publishWebhookEvent(alarmConfig.TenantID, model.WebhookEventAlarm, device_ids, map[string]interface{}{
+		"alarm_history_id":    id,
+		"alarm_config_id":     alarmConfigID,
+		"alarm_name":          alarmConfig.Name,
+		"alarm_level":         alarmConfig.AlarmLevel,
+		"description":         alarmConfig.Description,
+		"content":             content,
+		"device_ids":          device_ids,
+		"scene_automation_id": scene_automation_id,
+		"incident_id":         incidentID,
+	})
 	for _, deviceId := range device_ids {
 		deviceInfo, err := dal.GetDeviceByID(deviceId)
 		if err != nil {
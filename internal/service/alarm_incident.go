@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"project/internal/dal"
+	"project/internal/model"
+	"project/pkg/errcode"
+	"project/pkg/global"
+	"project/pkg/utils"
+
+	"github.com/go-basic/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// alarmGroupWindowDefault 未配置合并时间窗口时的默认值
+const alarmGroupWindowDefault = 5 * time.Minute
+
+// alarmGroupKey 告警合并键；按网关合并时子设备取父设备，无设备时退化为按场景条件组合并
+func alarmGroupKey(groupBy, sceneAutomationID, groupID, gatewayID, alarmConfigID string) (string, string) {
+	switch groupBy {
+	case model.AlarmGroupByConfig:
+		return groupBy, alarmConfigID
+	case model.AlarmGroupByGateway:
+		if gatewayID != "" {
+			return groupBy, gatewayID
+		}
+	}
+	return model.AlarmGroupByScene, sceneAutomationID + ":" + groupID
+}
+
+// alarmGatewayID 告警设备所属网关（取第一个设备，子设备取父设备）
+func alarmGatewayID(deviceIDs []string) string {
+	if len(deviceIDs) == 0 {
+		return ""
+	}
+	device, err := dal.GetDeviceByID(deviceIDs[0])
+	if err != nil || device == nil {
+		return deviceIDs[0]
+	}
+	if device.ParentID != nil && *device.ParentID != "" {
+		return *device.ParentID
+	}
+	return device.ID
+}
+
+// alarmFlapping 同一场景条件组在抖动判定窗口内的触发次数（含本次）达到阈值
+func alarmFlapping(cfg *model.AlarmConfig, sceneAutomationID, groupID string, now time.Time) bool {
+	if cfg.FlapThreshold == nil || *cfg.FlapThreshold <= 1 || cfg.FlapWindowSeconds == nil || *cfg.FlapWindowSeconds <= 0 {
+		return false
+	}
+	var cnt int64
+	if err := global.DB.Table(model.TableNameAlarmHistory).
+		Where("alarm_config_id = ? AND scene_automation_id = ? AND group_id = ? AND severity IS NOT NULL", cfg.ID, sceneAutomationID, groupID).
+		Where("create_at >= ?", now.Add(-time.Duration(*cfg.FlapWindowSeconds)*time.Second)).
+		Count(&cnt).Error; err != nil {
+		logrus.WithError(err).Warn("alarm flapping: count failed")
+		return false
+	}
+	return cnt+1 >= int64(*cfg.FlapThreshold)
+}
+
+// alarmIncidentFor 为新告警匹配或创建告警事件，返回事件id与不发送通知的原因；
+// 并入已有事件或处于抖动状态的告警不再单独通知
+func alarmIncidentFor(cfg *model.AlarmConfig, sceneAutomationID, groupID string, deviceIDs []string, now time.Time) (*string, *string) {
+	var suppress *string
+	flapping := alarmFlapping(cfg, sceneAutomationID, groupID, now)
+	if flapping {
+		reason := model.AlarmSuppressFlapping
+		suppress = &reason
+	}
+	if cfg.GroupBy == nil || *cfg.GroupBy == "" {
+		return nil, suppress
+	}
+
+	gatewayID := ""
+	if *cfg.GroupBy == model.AlarmGroupByGateway {
+		gatewayID = alarmGatewayID(deviceIDs)
+	}
+	groupBy, key := alarmGroupKey(*cfg.GroupBy, sceneAutomationID, groupID, gatewayID, cfg.ID)
+	window := alarmGroupWindowDefault
+	if cfg.GroupWindowSeconds != nil && *cfg.GroupWindowSeconds > 0 {
+		window = time.Duration(*cfg.GroupWindowSeconds) * time.Second
+	}
+
+	var incidentID string
+	merged := false
+	err := global.DB.Transaction(func(tx *gorm.DB) error {
+		// 同一合并键串行处理，避免并发告警各自创建事件
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", cfg.TenantID+"|"+cfg.ID+"|"+key).Error; err != nil {
+			return err
+		}
+		var incident model.AlarmIncident
+		res := tx.Where("tenant_id = ? AND alarm_config_id = ? AND group_key = ? AND status = ? AND last_at >= ?",
+			cfg.TenantID, cfg.ID, key, model.AlarmIncidentOpen, now.Add(-window)).
+			Order("last_at DESC").
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Limit(1).
+			Find(&incident)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			devices, _ := json.Marshal(uniqueStrings(append([]string{}, deviceIDs...)))
+			incidentID = uuid.New()
+			return tx.Create(&model.AlarmIncident{
+				ID:            incidentID,
+				TenantID:      cfg.TenantID,
+				AlarmConfigID: cfg.ID,
+				GroupBy:       groupBy,
+				GroupKey:      key,
+				Name:          cfg.Name,
+				Severity:      cfg.AlarmLevel,
+				Status:        model.AlarmIncidentOpen,
+				AlarmCount:    1,
+				DeviceList:    string(devices),
+				Flapping:      flapping,
+				FirstAt:       now,
+				LastAt:        now,
+			}).Error
+		}
+
+		merged = true
+		incidentID = incident.ID
+		var existing []string
+		_ = json.Unmarshal([]byte(incident.DeviceList), &existing)
+		devices, _ := json.Marshal(uniqueStrings(append(existing, deviceIDs...)))
+		return tx.Model(&model.AlarmIncident{}).Where("id = ?", incident.ID).Updates(map[string]interface{}{
+			"alarm_count": gorm.Expr("alarm_count + 1"),
+			"last_at":     now,
+			"device_list": string(devices),
+			"flapping":    incident.Flapping || flapping,
+		}).Error
+	})
+	if err != nil {
+		// 合并失败时按独立告警处理，保证通知送达
+		logrus.WithError(err).Error("alarm incident: match failed")
+		return nil, suppress
+	}
+	if merged && suppress == nil {
+		reason := model.AlarmSuppressDedup
+		suppress = &reason
+	}
+	return &incidentID, suppress
+}
+
+// syncAlarmIncidentStatus 事件内告警全部解决时事件置为已解决，有告警重新打开时恢复为未解决
+func syncAlarmIncidentStatus(ctx context.Context, tx *gorm.DB, incidentID string) error {
+	var active int64
+	if err := tx.WithContext(ctx).Table(model.TableNameAlarmHistory).
+		Where("incident_id = ? AND lifecycle_state IN ?", incidentID, alarmActiveStates).
+		Count(&active).Error; err != nil {
+		return err
+	}
+	values := map[string]interface{}{"status": model.AlarmIncidentOpen, "resolved_at": nil}
+	if active == 0 {
+		values = map[string]interface{}{"status": model.AlarmIncidentResolved, "resolved_at": time.Now().UTC()}
+	}
+	return tx.WithContext(ctx).Model(&model.AlarmIncident{}).Where("id = ?", incidentID).Updates(values).Error
+}
+
+// GetAlarmIncidentListByPage 告警事件列表
+func (*Alarm) GetAlarmIncidentListByPage(ctx context.Context, req *model.GetAlarmIncidentListByPageReq, claims *utils.UserClaims) (map[string]interface{}, error) {
+	db := global.DB.WithContext(ctx).Model(&model.AlarmIncident{}).Where("tenant_id = ?", claims.TenantID)
+	if req.Status != nil && *req.Status != "" {
+		db = db.Where("status = ?", *req.Status)
+	}
+	if req.AlarmConfigID != nil && *req.AlarmConfigID != "" {
+		db = db.Where("alarm_config_id = ?", *req.AlarmConfigID)
+	}
+	if req.StartTime != nil && !req.StartTime.IsZero() {
+		db = db.Where("last_at >= ?", *req.StartTime)
+	}
+	if req.EndTime != nil && !req.EndTime.IsZero() {
+		db = db.Where("last_at <= ?", *req.EndTime)
+	}
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	list := make([]model.AlarmIncident, 0)
+	if err := db.Order("last_at DESC").
+		Offset((req.Page - 1) * req.PageSize).
+		Limit(req.PageSize).
+		Find(&list).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return map[string]interface{}{"total": total, "list": list}, nil
+}
+
+// GetAlarmIncident 告警事件详情（含事件内的告警）
+func (*Alarm) GetAlarmIncident(ctx context.Context, id string, claims *utils.UserClaims) (map[string]interface{}, error) {
+	var incident model.AlarmIncident
+	res := global.DB.WithContext(ctx).Where("id = ? AND tenant_id = ?", id, claims.TenantID).Limit(1).Find(&incident)
+	if res.Error != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": res.Error.Error()})
+	}
+	if res.RowsAffected == 0 {
+		return nil, errcode.New(errcode.CodeNotFound)
+	}
+	alarms := make([]model.AlarmHistory, 0)
+	if err := global.DB.WithContext(ctx).
+		Where("incident_id = ?", id).
+		Order("create_at DESC").
+		Limit(500).
+		Find(&alarms).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return map[string]interface{}{"incident": incident, "alarms": alarms}, nil
+}
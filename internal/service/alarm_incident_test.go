@@ -0,0 +1,52 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"project/internal/model"
+)
+
+func TestAlarmGroupKey(t *testing.T) {
+	cases := []struct {
+		groupBy, gateway string
+		wantBy, wantKey  string
+	}{
+		{model.AlarmGroupByConfig, "gw1", model.AlarmGroupByConfig, "cfg1"},
+		{model.AlarmGroupByGateway, "gw1", model.AlarmGroupByGateway, "gw1"},
+		// 无设备时按场景条件组合并
+		{model.AlarmGroupByGateway, "", model.AlarmGroupByScene, "scene1:group1"},
+		{model.AlarmGroupByScene, "gw1", model.AlarmGroupByScene, "scene1:group1"},
+	}
+	for _, c := range cases {
+		by, key := alarmGroupKey(c.groupBy, "scene1", "group1", c.gateway, "cfg1")
+		if by != c.wantBy || key != c.wantKey {
+			t.Errorf("alarmGroupKey(%s, %q) = %s, %s; want %s, %s", c.groupBy, c.gateway, by, key, c.wantBy, c.wantKey)
+		}
+	}
+}
+
+func TestBuildNotificationDigest(t *testing.T) {
+	group := &model.NotificationGroup{Name: "ops", TenantID: "t1"}
+	items := make([]string, 0, 60)
+	for i := 0; i < 60; i++ {
+		items = append(items, "[ALERT] pack offline")
+	}
+	d := buildNotificationDigest(group, items, 420)
+	if d["subject"] != "[DIGEST] 420 notifications suppressed" {
+		t.Fatalf("subject = %v", d["subject"])
+	}
+	content := d["content"].(string)
+	if n := strings.Count(content, "[ALERT]"); n != notificationDigestShown {
+		t.Fatalf("digest lists %d items; want %d", n, notificationDigestShown)
+	}
+	if !strings.Contains(content, "Latest 50:") {
+		t.Fatalf("content should note truncation: %s", content)
+	}
+
+	// 总数缺失时以条目数为准
+	d = buildNotificationDigest(group, items[:3], 0)
+	if d["count"] != int64(3) || strings.Contains(d["content"].(string), "Latest") {
+		t.Fatalf("unexpected digest: %v", d)
+	}
+}
@@ -0,0 +1,495 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"project/internal/dal"
+	"project/internal/model"
+	"project/pkg/errcode"
+	"project/pkg/global"
+	"project/pkg/utils"
+
+	"github.com/go-basic/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// alarmActiveStates 未解决的告警状态
+var alarmActiveStates = []string{
+	model.AlarmStateOpen, model.AlarmStateAcknowledged, model.AlarmStateAssigned, model.AlarmStateInProgress,
+}
+
+// alarmActionTransitions 处理动作允许的起始状态与目标状态
+var alarmActionTransitions = map[string]struct {
+	from []string
+	to   string
+}{
+	model.AlarmActionAcknowledge: {[]string{model.AlarmStateOpen}, model.AlarmStateAcknowledged},
+	model.AlarmActionAssign:      {alarmActiveStates, model.AlarmStateAssigned},
+	model.AlarmActionStart: {[]string{model.AlarmStateOpen, model.AlarmStateAcknowledged, model.AlarmStateAssigned},
+		model.AlarmStateInProgress},
+	model.AlarmActionResolve:     {alarmActiveStates, model.AlarmStateResolved},
+	model.AlarmActionAutoResolve: {alarmActiveStates, model.AlarmStateResolved},
+	model.AlarmActionClose:       {[]string{model.AlarmStateResolved}, model.AlarmStateClosed},
+	model.AlarmActionReopen:      {[]string{model.AlarmStateResolved}, model.AlarmStateInProgress},
+}
+
+// alarmTransitTarget 返回动作执行后的状态，不允许时返回 false
+func alarmTransitTarget(action, from string) (string, bool) {
+	t, ok := alarmActionTransitions[action]
+	if !ok {
+		return "", false
+	}
+	for _, s := range t.from {
+		if s == from {
+			return t.to, true
+		}
+	}
+	return "", false
+}
+
+// AlarmTransition 一次告警处理状态变更
+type AlarmTransition struct {
+	AlarmID    string
+	TenantID   string
+	Action     string
+	OperatorID *string // 系统操作（自动恢复）为空
+	AssigneeID *string
+	Comment    *string
+}
+
+// TransitAlarm 在事务 tx 内变更告警处理状态并记录操作；
+// 首次人工操作视为确认，解决后 alarm_status 置为 N
+func TransitAlarm(ctx context.Context, tx *gorm.DB, tr AlarmTransition) error {
+	var current struct {
+		ID             string     `gorm:"column:id"`
+		LifecycleState string     `gorm:"column:lifecycle_state"`
+		Severity       *string    `gorm:"column:severity"`
+		AssigneeID     *string    `gorm:"column:assignee_id"`
+		AcknowledgedAt *time.Time `gorm:"column:acknowledged_at"`
+		IncidentID     *string    `gorm:"column:incident_id"`
+	}
+	if err := tx.WithContext(ctx).Table(model.TableNameAlarmHistory).
+		Select("id, lifecycle_state, severity, assignee_id, acknowledged_at, incident_id").
+		Where("id = ? AND tenant_id = ?", tr.AlarmID, tr.TenantID).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Limit(1).
+		Scan(&current).Error; err != nil {
+		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	if current.ID == "" {
+		return errcode.New(errcode.CodeNotFound)
+	}
+	if current.Severity == nil {
+		return errcode.WithData(errcode.CodeOpDenied, map[string]interface{}{"message": "恢复记录无需处理"})
+	}
+
+	from := current.LifecycleState
+	to, ok := alarmTransitTarget(tr.Action, from)
+	if !ok {
+		return errcode.WithData(errcode.CodeOpDenied, map[string]interface{}{
+			"message":    fmt.Sprintf("告警状态 %s 不允许执行 %s", from, tr.Action),
+			"from_state": from,
+			"action":     tr.Action,
+		})
+	}
+
+	now := time.Now().UTC()
+	values := map[string]interface{}{"lifecycle_state": to}
+	if tr.OperatorID != nil && current.AcknowledgedAt == nil {
+		values["acknowledged_at"] = now
+		values["acknowledged_by"] = *tr.OperatorID
+	}
+	assignee := tr.AssigneeID
+	switch tr.Action {
+	case model.AlarmActionAssign:
+		if assignee == nil || *assignee == "" {
+			return errcode.WithData(errcode.CodeParamError, map[string]interface{}{"message": "assignee_id is required"})
+		}
+		var cnt int64
+		if err := tx.WithContext(ctx).Table("users").
+			Where("id = ? AND tenant_id = ?", *assignee, tr.TenantID).
+			Count(&cnt).Error; err != nil {
+			return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+		}
+		if cnt == 0 {
+			return errcode.WithData(errcode.CodeParamError, map[string]interface{}{"message": "assignee not found"})
+		}
+		values["assignee_id"] = *assignee
+	case model.AlarmActionStart:
+		// 未指派时由开始处理的人负责
+		if current.AssigneeID == nil && tr.OperatorID != nil {
+			values["assignee_id"] = *tr.OperatorID
+			assignee = tr.OperatorID
+		}
+	case model.AlarmActionResolve, model.AlarmActionAutoResolve:
+		values["alarm_status"] = "N"
+		values["processed_at"] = now
+		values["processed_by"] = tr.OperatorID
+		if tr.Comment != nil {
+			values["processing_remark"] = strings.TrimSpace(*tr.Comment)
+		}
+	case model.AlarmActionClose:
+		values["closed_at"] = now
+	case model.AlarmActionReopen:
+		values["alarm_status"] = *current.Severity
+		values["processed_at"] = nil
+		values["processed_by"] = nil
+		values["closed_at"] = nil
+	}
+	if err := tx.WithContext(ctx).Table(model.TableNameAlarmHistory).
+		Where("id = ?", tr.AlarmID).
+		Updates(values).Error; err != nil {
+		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	if current.IncidentID != nil && (to == model.AlarmStateResolved || tr.Action == model.AlarmActionReopen) {
+		if err := syncAlarmIncidentStatus(ctx, tx, *current.IncidentID); err != nil {
+			return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+		}
+	}
+
+	activity := &model.AlarmHistoryActivity{
+		ID:             uuid.New(),
+		AlarmHistoryID: tr.AlarmID,
+		TenantID:       tr.TenantID,
+		Action:         tr.Action,
+		FromState:      &from,
+		ToState:        &to,
+		OperatorID:     tr.OperatorID,
+		AssigneeID:     assignee,
+		Content:        tr.Comment,
+		CreatedAt:      now,
+	}
+	if err := tx.WithContext(ctx).Create(activity).Error; err != nil {
+		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return nil
+}
+
+// TransitionAlarmHistory 确认、指派、开始处理、解决、关闭或重新打开告警
+func (*Alarm) TransitionAlarmHistory(ctx context.Context, id string, req *model.AlarmHistoryTransitionReq, claims *utils.UserClaims) error {
+	return global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return TransitAlarm(ctx, tx, AlarmTransition{
+			AlarmID:    id,
+			TenantID:   claims.TenantID,
+			Action:     req.Action,
+			OperatorID: &claims.ID,
+			AssigneeID: req.AssigneeID,
+			Comment:    req.Comment,
+		})
+	})
+}
+
+// HandleAlarmHistory 处理告警（App 端），等同于解决
+func (*Alarm) HandleAlarmHistory(req *model.AlarmHistoryHandleReq, tenantID string, userID string) (err error) {
+	ctx := context.Background()
+	return global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return TransitAlarm(ctx, tx, AlarmTransition{
+			AlarmID:    req.Id,
+			TenantID:   tenantID,
+			Action:     model.AlarmActionResolve,
+			OperatorID: &userID,
+			Comment:    req.ProcessingRemark,
+		})
+	})
+}
+
+// CommentAlarmHistory 添加告警评论
+func (*Alarm) CommentAlarmHistory(ctx context.Context, id string, req *model.AlarmHistoryCommentReq, claims *utils.UserClaims) error {
+	var cnt int64
+	if err := global.DB.WithContext(ctx).Table(model.TableNameAlarmHistory).
+		Where("id = ? AND tenant_id = ?", id, claims.TenantID).
+		Count(&cnt).Error; err != nil {
+		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	if cnt == 0 {
+		return errcode.New(errcode.CodeNotFound)
+	}
+	content := strings.TrimSpace(req.Content)
+	activity := &model.AlarmHistoryActivity{
+		ID:             uuid.New(),
+		AlarmHistoryID: id,
+		TenantID:       claims.TenantID,
+		Action:         model.AlarmActionComment,
+		OperatorID:     &claims.ID,
+		Content:        &content,
+		CreatedAt:      time.Now().UTC(),
+	}
+	if err := global.DB.WithContext(ctx).Create(activity).Error; err != nil {
+		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return nil
+}
+
+// GetAlarmHistoryActivities 告警评论与处理记录（按时间正序）
+func (*Alarm) GetAlarmHistoryActivities(ctx context.Context, id string, claims *utils.UserClaims) ([]model.AlarmHistoryActivityResp, error) {
+	var rows []struct {
+		model.AlarmHistoryActivity
+		OperatorName *string `gorm:"column:operator_name"`
+		AssigneeName *string `gorm:"column:assignee_name"`
+	}
+	if err := global.DB.WithContext(ctx).Table("alarm_history_activities AS a").
+		Select("a.*, op.name AS operator_name, asg.name AS assignee_name").
+		Joins("LEFT JOIN users op ON op.id = a.operator_id").
+		Joins("LEFT JOIN users asg ON asg.id = a.assignee_id").
+		Where("a.alarm_history_id = ? AND a.tenant_id = ?", id, claims.TenantID).
+		Order("a.created_at ASC").
+		Limit(500).
+		Scan(&rows).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	list := make([]model.AlarmHistoryActivityResp, 0, len(rows))
+	for _, r := range rows {
+		list = append(list, model.AlarmHistoryActivityResp{
+			ID:           r.ID,
+			Action:       r.Action,
+			FromState:    r.FromState,
+			ToState:      r.ToState,
+			OperatorID:   r.OperatorID,
+			OperatorName: r.OperatorName,
+			AssigneeID:   r.AssigneeID,
+			AssigneeName: r.AssigneeName,
+			Content:      r.Content,
+			CreatedAt:    r.CreatedAt.In(time.Local).Format("2006-01-02 15:04:05"),
+		})
+	}
+	return list, nil
+}
+
+// autoResolveAlarms 告警恢复时自动解决同一场景条件组下未解决的告警
+func autoResolveAlarms(tenantID, alarmConfigID, sceneAutomationID, groupID string) {
+	ctx := context.Background()
+	var ids []string
+	if err := global.DB.WithContext(ctx).Table(model.TableNameAlarmHistory).
+		Where("tenant_id = ? AND alarm_config_id = ? AND scene_automation_id = ? AND group_id = ?",
+			tenantID, alarmConfigID, sceneAutomationID, groupID).
+		Where("severity IS NOT NULL AND lifecycle_state IN ?", alarmActiveStates).
+		Pluck("id", &ids).Error; err != nil {
+		logrus.WithError(err).Error("alarm auto resolve: query failed")
+		return
+	}
+	comment := "告警条件已恢复"
+	for _, id := range ids {
+		if err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return TransitAlarm(ctx, tx, AlarmTransition{
+				AlarmID:  id,
+				TenantID: tenantID,
+				Action:   model.AlarmActionAutoResolve,
+				Comment:  &comment,
+			})
+		}); err != nil {
+			logrus.WithError(err).WithField("alarm_history_id", id).Warn("alarm auto resolve failed")
+		}
+	}
+}
+
+// EscalateByCron 定时任务：告警超过升级时限仍未确认时通知升级通知组，每条告警只升级一次
+func (*Alarm) EscalateByCron() {
+	ctx := context.Background()
+	var rows []struct {
+		ID              string    `gorm:"column:id"`
+		TenantID        string    `gorm:"column:tenant_id"`
+		AlarmConfigID   string    `gorm:"column:alarm_config_id"`
+		Name            string    `gorm:"column:name"`
+		Severity        string    `gorm:"column:severity"`
+		Content         *string   `gorm:"column:content"`
+		CreateAt        time.Time `gorm:"column:create_at"`
+		AlarmDeviceList string    `gorm:"column:alarm_device_list"`
+		Minutes         int32     `gorm:"column:escalation_minutes"`
+		GroupID         string    `gorm:"column:escalation_notification_group_id"`
+	}
+	if err := global.DB.WithContext(ctx).Table("alarm_history AS ah").
+		Select("ah.id, ah.tenant_id, ah.alarm_config_id, ah.name, ah.severity, ah.content, ah.create_at, ah.alarm_device_list, ac.escalation_minutes, ac.escalation_notification_group_id").
+		Joins("JOIN alarm_config ac ON ac.id = ah.alarm_config_id").
+		// 并入事件或抖动中的告警未单独通知，随事件首条告警升级
+		Where("ah.lifecycle_state = ? AND ah.severity IS NOT NULL AND ah.escalated_at IS NULL AND ah.suppress_reason IS NULL", model.AlarmStateOpen).
+		Where("ac.escalation_minutes > 0 AND COALESCE(ac.escalation_notification_group_id, '') <> ''").
+		Where("ah.create_at <= NOW() - ac.escalation_minutes * INTERVAL '1 minute'").
+		Order("ah.create_at ASC").
+		Limit(500).
+		Scan(&rows).Error; err != nil {
+		logrus.WithError(err).Error("alarm escalation: query failed")
+		return
+	}
+
+	for _, r := range rows {
+		now := time.Now().UTC()
+		// 多实例部署时只有抢到更新的实例发送通知
+		res := global.DB.WithContext(ctx).Table(model.TableNameAlarmHistory).
+			Where("id = ? AND escalated_at IS NULL AND lifecycle_state = ?", r.ID, model.AlarmStateOpen).
+			Update("escalated_at", now)
+		if res.Error != nil {
+			logrus.WithError(res.Error).WithField("alarm_history_id", r.ID).Error("alarm escalation: update failed")
+			continue
+		}
+		if res.RowsAffected == 0 {
+			continue
+		}
+
+		content := fmt.Sprintf("告警超过 %d 分钟未确认", r.Minutes)
+		state := model.AlarmStateOpen
+		if err := global.DB.WithContext(ctx).Create(&model.AlarmHistoryActivity{
+			ID:             uuid.New(),
+			AlarmHistoryID: r.ID,
+			TenantID:       r.TenantID,
+			Action:         model.AlarmActionEscalate,
+			FromState:      &state,
+			ToState:        &state,
+			Content:        &content,
+			CreatedAt:      now,
+		}).Error; err != nil {
+			logrus.WithError(err).WithField("alarm_history_id", r.ID).Warn("alarm escalation: save activity failed")
+		}
+
+		details := ""
+		if r.Content != nil {
+			details = *r.Content
+		}
+		var deviceIDs []string
+		_ = json.Unmarshal([]byte(r.AlarmDeviceList), &deviceIDs)
+		if deviceIDs == nil {
+			deviceIDs = []string{}
+		}
+		var tenantAdminID string
+		if tenantAdmin, err := dal.GetTenantAdmin(r.TenantID); err == nil && tenantAdmin != nil {
+			tenantAdminID = tenantAdmin.ID
+		}
+		alertData := map[string]interface{}{
+			"id":              r.ID,
+			"alarm_config_id": r.AlarmConfigID,
+			"event_type":      model.NotificationEventAlarmEscalation,
+			"alarm_name":      r.Name,
+			"details":         details,
+			"subject":         fmt.Sprintf("[ESCALATION] %s [%s]", r.Name, r.Severity),
+			"content": fmt.Sprintf(`Alert: %s
+Level: %s
+Time: %s
+Unacknowledged: %d minutes
+Details: %s`,
+				r.Name,
+				r.Severity,
+				r.CreateAt.In(time.Local).Format("2006-01-02 15:04:05"),
+				int(now.Sub(r.CreateAt).Minutes()),
+				details),
+			"timestamp":       now.Format(time.RFC3339),
+			"alarm_level":     r.Severity,
+			"tenant_id":       r.TenantID,
+			"tenant_admin_id": tenantAdminID,
+			"device_ids":      deviceIDs,
+			"escalation":      true,
+		}
+		buffer := &bytes.Buffer{}
+		encoder := json.NewEncoder(buffer)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(alertData); err != nil {
+			logrus.Error("构建告警升级JSON失败:", err)
+			continue
+		}
+		GroupApp.NotificationServicesConfig.ExecuteNotification(r.GroupID, strings.TrimSpace(buffer.String()))
+	}
+}
+
+// alarmSlaSelect SLA 统计字段：未确认/未解决的告警按当前时间计算是否超时
+const alarmSlaSelect = `COUNT(1) AS total,
+	COUNT(t.acknowledged_at) AS acknowledged,
+	COUNT(t.processed_at) AS resolved,
+	COUNT(1) FILTER (WHERE t.ack_sla_minutes > 0 AND COALESCE(t.acknowledged_at, NOW()) > t.create_at + t.ack_sla_minutes * INTERVAL '1 minute') AS ack_breached,
+	COUNT(1) FILTER (WHERE t.resolve_sla_minutes > 0 AND COALESCE(t.processed_at, NOW()) > t.create_at + t.resolve_sla_minutes * INTERVAL '1 minute') AS resolve_breached,
+	AVG(EXTRACT(EPOCH FROM t.acknowledged_at - t.create_at)) AS mtta_seconds,
+	AVG(EXTRACT(EPOCH FROM t.processed_at - t.create_at)) AS mttr_seconds`
+
+// GetAlarmSlaReport 告警 SLA 统计：按租户（系统管理员可见全部租户）或按经销商汇总；
+// 经销商用户只统计本组织子树下的经销商
+func (*Alarm) GetAlarmSlaReport(ctx context.Context, req *model.AlarmSlaReportReq, claims *utils.UserClaims, orgID string) (*model.AlarmSlaReportResp, error) {
+	end := time.Now()
+	if req.EndTime != nil && !req.EndTime.IsZero() {
+		end = *req.EndTime
+	}
+	start := end.AddDate(0, 0, -30)
+	if req.StartTime != nil && !req.StartTime.IsZero() {
+		start = *req.StartTime
+	}
+	if !start.Before(end) {
+		return nil, errcode.WithData(errcode.CodeParamError, map[string]interface{}{"message": "start_time must be before end_time"})
+	}
+	groupBy := req.GroupBy
+	if groupBy == "" {
+		groupBy = "tenant"
+	}
+
+	db := global.DB.WithContext(ctx)
+	base := db.Table("alarm_history AS ah").
+		Joins("JOIN alarm_config ac ON ac.id = ah.alarm_config_id").
+		Where("ah.severity IS NOT NULL AND ah.create_at >= ? AND ah.create_at < ?", start, end)
+	if claims.Authority != "SYS_ADMIN" {
+		base = base.Where("ah.tenant_id = ?", claims.TenantID)
+	}
+
+	var sub *gorm.DB
+	if groupBy == "dealer" {
+		// 告警设备归属组织向上归集到经销商，同一告警对同一经销商只计一次
+		sub = base.Select(`DISTINCT ah.id, o.id AS group_id, o.name AS group_name, ah.create_at, ah.acknowledged_at, ah.processed_at,
+			ac.ack_sla_minutes, ac.resolve_sla_minutes`).
+			Joins("CROSS JOIN LATERAL jsonb_array_elements_text(ah.alarm_device_list) AS ad(device_id)").
+			Joins("JOIN device_batteries dbat ON dbat.device_id = ad.device_id").
+			Joins("JOIN org_closure oc ON oc.tenant_id = ah.tenant_id AND oc.descendant_id = dbat.owner_org_id").
+			Joins("JOIN orgs o ON o.id = oc.ancestor_id AND o.org_type = ?", "DEALER")
+		if orgID != "" {
+			sub = sub.Where(`o.id IN (
+				SELECT descendant_id FROM org_closure WHERE tenant_id = ? AND ancestor_id = ?
+			)`, claims.TenantID, orgID)
+		}
+	} else {
+		sub = base.Select(`ah.id, ah.tenant_id AS group_id,
+			COALESCE((SELECT u.name FROM users u WHERE u.tenant_id = ah.tenant_id AND u.authority = 'TENANT_ADMIN' ORDER BY u.created_at LIMIT 1), ah.tenant_id) AS group_name,
+			ah.create_at, ah.acknowledged_at, ah.processed_at, ac.ack_sla_minutes, ac.resolve_sla_minutes`)
+		if orgID != "" {
+			sub = sub.Where(`EXISTS (
+				SELECT 1 FROM jsonb_array_elements_text(ah.alarm_device_list) AS ad(device_id)
+				JOIN device_batteries dbat ON dbat.device_id = ad.device_id
+				WHERE dbat.owner_org_id IN (SELECT descendant_id FROM org_closure WHERE tenant_id = ? AND ancestor_id = ?)
+			)`, claims.TenantID, orgID)
+		}
+	}
+
+	var rows []struct {
+		GroupID         string   `gorm:"column:group_id"`
+		GroupName       string   `gorm:"column:group_name"`
+		Total           int64    `gorm:"column:total"`
+		Acknowledged    int64    `gorm:"column:acknowledged"`
+		Resolved        int64    `gorm:"column:resolved"`
+		AckBreached     int64    `gorm:"column:ack_breached"`
+		ResolveBreached int64    `gorm:"column:resolve_breached"`
+		MttaSeconds     *float64 `gorm:"column:mtta_seconds"`
+		MttrSeconds     *float64 `gorm:"column:mttr_seconds"`
+	}
+	if err := db.Table("(?) AS t", sub).
+		Select("t.group_id, MAX(t.group_name) AS group_name, " + alarmSlaSelect).
+		Group("t.group_id").
+		Order("total DESC").
+		Scan(&rows).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+
+	resp := &model.AlarmSlaReportResp{GroupBy: groupBy, List: make([]model.AlarmSlaReportItem, 0, len(rows))}
+	for _, r := range rows {
+		resp.List = append(resp.List, model.AlarmSlaReportItem{
+			ID:              r.GroupID,
+			Name:            r.GroupName,
+			Total:           r.Total,
+			Acknowledged:    r.Acknowledged,
+			Resolved:        r.Resolved,
+			AckBreached:     r.AckBreached,
+			ResolveBreached: r.ResolveBreached,
+			MttaSeconds:     r.MttaSeconds,
+			MttrSeconds:     r.MttrSeconds,
+		})
+	}
+	return resp, nil
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"testing"
+
+	"project/internal/model"
+)
+
+func TestAlarmTransitTarget(t *testing.T) {
+	cases := []struct {
+		action, from string
+		want         string
+		ok           bool
+	}{
+		{model.AlarmActionAcknowledge, model.AlarmStateOpen, model.AlarmStateAcknowledged, true},
+		{model.AlarmActionAcknowledge, model.AlarmStateAssigned, "", false},
+		{model.AlarmActionAssign, model.AlarmStateOpen, model.AlarmStateAssigned, true},
+		// 允许改派
+		{model.AlarmActionAssign, model.AlarmStateAssigned, model.AlarmStateAssigned, true},
+		{model.AlarmActionAssign, model.AlarmStateResolved, "", false},
+		{model.AlarmActionStart, model.AlarmStateAcknowledged, model.AlarmStateInProgress, true},
+		{model.AlarmActionStart, model.AlarmStateInProgress, "", false},
+		{model.AlarmActionResolve, model.AlarmStateOpen, model.AlarmStateResolved, true},
+		{model.AlarmActionResolve, model.AlarmStateInProgress, model.AlarmStateResolved, true},
+		{model.AlarmActionResolve, model.AlarmStateResolved, "", false},
+		{model.AlarmActionAutoResolve, model.AlarmStateAssigned, model.AlarmStateResolved, true},
+		{model.AlarmActionAutoResolve, model.AlarmStateClosed, "", false},
+		{model.AlarmActionClose, model.AlarmStateOpen, "", false},
+		{model.AlarmActionClose, model.AlarmStateResolved, model.AlarmStateClosed, true},
+		{model.AlarmActionReopen, model.AlarmStateResolved, model.AlarmStateInProgress, true},
+		// 关闭为终态
+		{model.AlarmActionReopen, model.AlarmStateClosed, "", false},
+		{model.AlarmActionComment, model.AlarmStateOpen, "", false},
+	}
+	for _, c := range cases {
+		got, ok := alarmTransitTarget(c.action, c.from)
+		if got != c.want || ok != c.ok {
+			t.Errorf("alarmTransitTarget(%s, %s) = %q, %t; want %q, %t", c.action, c.from, got, ok, c.want, c.ok)
+		}
+	}
+}
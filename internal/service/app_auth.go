@@ -169,7 +169,7 @@ func (a *AppAuth) SendPhoneCode(ctx context.Context, tenantID, phonePrefix, phon
 	return nil
 }
 
-func (a *AppAuth) PhoneLoginByCode(ctx context.Context, tenantID, phonePrefix, phoneNumber, verifyCode string) (*model.LoginRsp, error) {
+func (a *AppAuth) PhoneLoginByCode(ctx context.Context, tenantID, phonePrefix, phoneNumber, verifyCode string, meta *model.RequestMeta) (*model.LoginRsp, error) {
 	phone := normalizePhone(phonePrefix, phoneNumber)
 	if err := a.verifyCode(ctx, tenantID, dal.TemplateChannelSMS, dal.TemplateSceneLogin, phone, verifyCode); err != nil {
 		return nil, err
@@ -191,7 +191,7 @@ func (a *AppAuth) PhoneLoginByCode(ctx context.Context, tenantID, phonePrefix, p
 		return nil, errcode.New(errcode.CodeUserDisabled)
 	}
 
-	loginRsp, err := GroupApp.User.UserLoginAfter(user)
+	loginRsp, err := GroupApp.User.UserLoginAfter(ctx, user, meta)
 	if err != nil {
 		return nil, err
 	}
@@ -199,7 +199,7 @@ func (a *AppAuth) PhoneLoginByCode(ctx context.Context, tenantID, phonePrefix, p
 	return loginRsp, nil
 }
 
-func (a *AppAuth) EmailLoginByCode(ctx context.Context, tenantID, email, verifyCode string) (*model.LoginRsp, error) {
+func (a *AppAuth) EmailLoginByCode(ctx context.Context, tenantID, email, verifyCode string, meta *model.RequestMeta) (*model.LoginRsp, error) {
 	email = strings.TrimSpace(email)
 	if err := a.verifyCode(ctx, tenantID, dal.TemplateChannelEmail, dal.TemplateSceneLogin, email, verifyCode); err != nil {
 		return nil, err
@@ -221,7 +221,7 @@ func (a *AppAuth) EmailLoginByCode(ctx context.Context, tenantID, email, verifyC
 		return nil, errcode.New(errcode.CodeUserDisabled)
 	}
 
-	loginRsp, err := GroupApp.User.UserLoginAfter(user)
+	loginRsp, err := GroupApp.User.UserLoginAfter(ctx, user, meta)
 	if err != nil {
 		return nil, err
 	}
@@ -243,7 +243,7 @@ func randomPassword() string {
 	return b.String()
 }
 
-func (a *AppAuth) PhoneRegister(ctx context.Context, tenantID, phonePrefix, phoneNumber, verifyCode string, password *string) (*model.LoginRsp, error) {
+func (a *AppAuth) PhoneRegister(ctx context.Context, tenantID, phonePrefix, phoneNumber, verifyCode string, password *string, meta *model.RequestMeta) (*model.LoginRsp, error) {
 	phone := normalizePhone(phonePrefix, phoneNumber)
 	if err := a.verifyCode(ctx, tenantID, dal.TemplateChannelSMS, dal.TemplateSceneRegister, phone, verifyCode); err != nil {
 		return nil, err
@@ -313,10 +313,10 @@ func (a *AppAuth) PhoneRegister(ctx context.Context, tenantID, phonePrefix, phon
 		})
 	}
 
-	return GroupApp.User.UserLoginAfter(u)
+	return GroupApp.User.UserLoginAfter(ctx, u, meta)
 }
 
-func (a *AppAuth) EmailRegister(ctx context.Context, tenantID, email, verifyCode string, password *string) (*model.LoginRsp, error) {
+func (a *AppAuth) EmailRegister(ctx context.Context, tenantID, email, verifyCode string, password *string, meta *model.RequestMeta) (*model.LoginRsp, error) {
 	email = strings.TrimSpace(email)
 	if !utils.ValidateEmail(email) {
 		return nil, errcode.New(200014)
@@ -389,7 +389,7 @@ func (a *AppAuth) EmailRegister(ctx context.Context, tenantID, email, verifyCode
 		})
 	}
 
-	return GroupApp.User.UserLoginAfter(u)
+	return GroupApp.User.UserLoginAfter(ctx, u, meta)
 }
 
 func (a *AppAuth) ResetPasswordByPhone(ctx context.Context, tenantID, phonePrefix, phoneNumber, verifyCode, newPassword string) error {
@@ -801,7 +801,7 @@ func (a *AppAuth) SetUsername(ctx context.Context, tenantID, userID, name string
 }
 
 // WxmpLogin 微信小程序一键登录：通过 code2session 获取 openid，然后按租户查/建身份并登录
-func (a *AppAuth) WxmpLogin(ctx context.Context, tenantID, code string) (*model.LoginRsp, error) {
+func (a *AppAuth) WxmpLogin(ctx context.Context, tenantID, code string, meta *model.RequestMeta) (*model.LoginRsp, error) {
 	code = strings.TrimSpace(code)
 	if tenantID == "" || code == "" {
 		return nil, errcode.WithData(errcode.CodeParamError, map[string]interface{}{"error": "tenant_id/code is empty"})
@@ -832,7 +832,7 @@ func (a *AppAuth) WxmpLogin(ctx context.Context, tenantID, code string) (*model.
 		if user.Status != nil && *user.Status != "N" {
 			return nil, errcode.New(errcode.CodeUserDisabled)
 		}
-		loginRsp, err := GroupApp.User.UserLoginAfter(user)
+		loginRsp, err := GroupApp.User.UserLoginAfter(ctx, user, meta)
 		if err != nil {
 			return nil, err
 		}
@@ -888,7 +888,7 @@ func (a *AppAuth) WxmpLogin(ctx context.Context, tenantID, code string) (*model.
 		})
 	}
 
-	return GroupApp.User.UserLoginAfter(u)
+	return GroupApp.User.UserLoginAfter(ctx, u, meta)
 }
 
 // WxmpBindOpenID 微信小程序绑定微信身份（openid），用于“账号绑定：微信绑定”
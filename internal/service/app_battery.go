@@ -38,29 +38,37 @@ type appBatteryDetailRow struct {
 	DeviceRemark1 *string `gorm:"column:remark1"`
 }
 
-// GetBatteryDetailForApp 获取APP端电池设备详情（要求设备已绑定到当前用户）
-func (*AppBattery) GetBatteryDetailForApp(ctx context.Context, deviceID string, claims *utils.UserClaims) (*model.AppBatteryDetailResp, error) {
+// checkAppDeviceBinding APP端设备访问校验：终端用户要求已绑定；管理员允许跨设备查看（仍受 tenant 约束）
+func checkAppDeviceBinding(ctx context.Context, deviceID string, claims *utils.UserClaims) error {
 	if deviceID == "" {
-		return nil, errcode.NewWithMessage(errcode.CodeParamError, "device_id is required")
+		return errcode.NewWithMessage(errcode.CodeParamError, "device_id is required")
 	}
 	if claims == nil || claims.ID == "" || claims.TenantID == "" {
-		return nil, errcode.NewWithMessage(errcode.CodeParamError, "claims is required")
+		return errcode.NewWithMessage(errcode.CodeParamError, "claims is required")
 	}
 
-	// 终端用户默认要求绑定；管理员允许跨设备查看（仍受 tenant 约束）
 	isAdmin := strings.Contains(strings.ToUpper(claims.Authority), "ADMIN")
-	if !isAdmin {
-		q := query.Use(global.DB)
-		if _, err := q.DeviceUserBinding.WithContext(ctx).
-			Where(
-				q.DeviceUserBinding.DeviceID.Eq(deviceID),
-				q.DeviceUserBinding.UserID.Eq(claims.ID),
-			).First(); err != nil {
-			if err == gorm.ErrRecordNotFound {
-				return nil, errcode.NewWithMessage(errcode.CodeParamError, "device not bound to current user")
-			}
-			return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	if isAdmin {
+		return nil
+	}
+	q := query.Use(global.DB)
+	if _, err := q.DeviceUserBinding.WithContext(ctx).
+		Where(
+			q.DeviceUserBinding.DeviceID.Eq(deviceID),
+			q.DeviceUserBinding.UserID.Eq(claims.ID),
+		).First(); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errcode.NewWithMessage(errcode.CodeParamError, "device not bound to current user")
 		}
+		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return nil
+}
+
+// GetBatteryDetailForApp 获取APP端电池设备详情（要求设备已绑定到当前用户）
+func (*AppBattery) GetBatteryDetailForApp(ctx context.Context, deviceID string, claims *utils.UserClaims) (*model.AppBatteryDetailResp, error) {
+	if err := checkAppDeviceBinding(ctx, deviceID, claims); err != nil {
+		return nil, err
 	}
 
 	var row appBatteryDetailRow
@@ -116,3 +124,32 @@ func (*AppBattery) GetBatteryDetailForApp(ctx context.Context, deviceID string,
 		Remark:            row.DeviceRemark1,
 	}, nil
 }
+
+// GetBatteryHealthForApp APP端电池健康（SOH 趋势/循环次数/寿命预测），要求设备已绑定到当前用户
+func (*AppBattery) GetBatteryHealthForApp(ctx context.Context, deviceID string, days int, claims *utils.UserClaims) (*model.BatteryHealthDetailResp, error) {
+	if err := checkAppDeviceBinding(ctx, deviceID, claims); err != nil {
+		return nil, err
+	}
+	var cnt int64
+	if err := global.DB.WithContext(ctx).Table("devices").
+		Where("id = ? AND tenant_id = ?", deviceID, claims.TenantID).
+		Count(&cnt).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	if cnt == 0 {
+		return nil, errcode.NewWithMessage(errcode.CodeParamError, "device not found")
+	}
+	return loadDeviceHealth(ctx, deviceID, days)
+}
+
+// ListBatteryCyclesForApp APP端充放电记录（默认最近20次充电），要求设备已绑定到当前用户
+func (*AppBattery) ListBatteryCyclesForApp(ctx context.Context, deviceID string, req model.AppBatteryCycleReq, claims *utils.UserClaims) ([]model.BatteryCycleItemResp, error) {
+	if err := checkAppDeviceBinding(ctx, deviceID, claims); err != nil {
+		return nil, err
+	}
+	sessionType := model.BatterySessionCharge
+	if req.SessionType != nil && *req.SessionType != "" {
+		sessionType = *req.SessionType
+	}
+	return GroupApp.BatteryCycle.ListRecentCycles(ctx, deviceID, sessionType, req.Limit)
+}
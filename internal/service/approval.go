@@ -0,0 +1,690 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"project/internal/model"
+	"project/pkg/errcode"
+	"project/pkg/global"
+	"project/pkg/utils"
+
+	"github.com/go-basic/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Approval 高风险批量操作审批（四眼原则）：命中策略的操作先生成审批申请，由申请人以外的审批人通过后执行
+type Approval struct{}
+
+// approvalDefaultExpireHours 策略未配置有效期时的默认审批有效期
+const approvalDefaultExpireHours = 24
+
+// approvalOperationLabels 操作类型名称（用于摘要与通知）
+var approvalOperationLabels = map[string]string{
+	model.ApprovalOpBatchCommand:      "批量下发指令",
+	model.ApprovalOpBatchOTA:          "批量OTA推送",
+	model.ApprovalOpBatchAssignDealer: "批量分配经销商",
+	model.ApprovalOpOrgTransfer:       "设备批量转移",
+}
+
+// approvalStatusLabels 审批结果名称（用于通知）
+var approvalStatusLabels = map[string]string{
+	model.ApprovalExecuted:  "已通过并执行",
+	model.ApprovalFailed:    "已通过，执行失败",
+	model.ApprovalRejected:  "已驳回",
+	model.ApprovalExpired:   "已过期",
+	model.ApprovalCancelled: "已撤回",
+}
+
+// ApprovalSubmission 待判定是否需要审批的操作
+type ApprovalSubmission struct {
+	OperationType string
+	ScopeOrgID    string      // 申请人所在组织（执行时的数据隔离范围）
+	DeviceIDs     []string    // 涉及的设备
+	Identifiers   []string    // 指令标识符（仅批量下发指令）
+	Target        string      // 摘要中的操作对象，如目标组织、升级包
+	Payload       interface{} // 原始请求参数，审批通过后按原参数执行
+}
+
+// approvalStringList 解析 JSON 字符串数组，去掉空值
+func approvalStringList(s *string) []string {
+	if s == nil || strings.TrimSpace(*s) == "" {
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal([]byte(*s), &list); err != nil {
+		return nil
+	}
+	out := make([]string, 0, len(list))
+	for _, v := range list {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return uniqueStrings(out)
+}
+
+// approvalJSONList 字符串数组转 JSON，空数组返回 nil
+func approvalJSONList(list []string) *string {
+	clean := make([]string, 0, len(list))
+	for _, v := range list {
+		if v = strings.TrimSpace(v); v != "" {
+			clean = append(clean, v)
+		}
+	}
+	clean = uniqueStrings(clean)
+	if len(clean) == 0 {
+		return nil
+	}
+	b, _ := json.Marshal(clean)
+	s := string(b)
+	return &s
+}
+
+// approvalTrigger 判定操作是否命中审批策略，返回触发原因：设备数超过阈值，或下发了策略中列出的指令
+func approvalTrigger(p *model.ApprovalPolicy, deviceCount int, identifiers []string) (string, bool) {
+	if p == nil || !p.Enabled {
+		return "", false
+	}
+	if p.DeviceThreshold != nil && deviceCount > int(*p.DeviceThreshold) {
+		return fmt.Sprintf("设备数 %d 超过审批阈值 %d", deviceCount, *p.DeviceThreshold), true
+	}
+	if p.OperationType != model.ApprovalOpBatchCommand {
+		return "", false
+	}
+	for _, c := range approvalStringList(p.CommandIdentifiers) {
+		for _, id := range identifiers {
+			if c == model.ApprovalCommandAll || c == id {
+				return fmt.Sprintf("指令 %s 需要审批", id), true
+			}
+		}
+	}
+	return "", false
+}
+
+// approvalExpiresAt 审批截止时间
+func approvalExpiresAt(p *model.ApprovalPolicy, now time.Time) time.Time {
+	hours := p.ExpireHours
+	if hours <= 0 {
+		hours = approvalDefaultExpireHours
+	}
+	return now.Add(time.Duration(hours) * time.Hour)
+}
+
+// approvalSummary 审批申请摘要
+func approvalSummary(operationType string, deviceCount int, target string) string {
+	label := approvalOperationLabels[operationType]
+	if label == "" {
+		label = operationType
+	}
+	summary := fmt.Sprintf("%s：%d 台设备", label, deviceCount)
+	if target != "" {
+		summary += "，" + target
+	}
+	if r := []rune(summary); len(r) > 500 {
+		summary = string(r[:500])
+	}
+	return summary
+}
+
+// checkApprovalDecider 审批人校验：不能是申请人，且须在提交时的审批人快照中或为租户管理员
+func checkApprovalDecider(r *model.ApprovalRequest, claims *utils.UserClaims) error {
+	if r.RequesterID == claims.ID {
+		return errcode.New(errcode.CodeApprovalSelfDecision)
+	}
+	if claims.Authority == "TENANT_ADMIN" {
+		return nil
+	}
+	approvers := approvalStringList(&r.ApproverIDs)
+	for _, id := range approvers {
+		if id == claims.ID {
+			return nil
+		}
+	}
+	return errcode.New(errcode.CodeNoPermission)
+}
+
+// approvalApprovers 解析审批人：策略配置的用户与角色下的用户，未配置时为租户管理员；申请人本人除外
+func approvalApprovers(ctx context.Context, p *model.ApprovalPolicy, requesterID string) ([]string, error) {
+	cfg := model.MemberNotificationConfig{
+		UserIDs: approvalStringList(p.ApproverUserIDs),
+		RoleIDs: approvalStringList(p.ApproverRoleIDs),
+	}
+	var users []string
+	var err error
+	if len(cfg.UserIDs) == 0 && len(cfg.RoleIDs) == 0 {
+		err = global.DB.WithContext(ctx).Table("users").
+			Where("tenant_id = ? AND authority = ?", p.TenantID, "TENANT_ADMIN").
+			Where("status IS NULL OR status <> 'F'").
+			Pluck("id", &users).Error
+	} else {
+		users, err = memberNotificationRecipients(p.TenantID, cfg)
+	}
+	if err != nil {
+		return nil, err
+	}
+	approvers := make([]string, 0, len(users))
+	for _, id := range users {
+		if id != requesterID {
+			approvers = append(approvers, id)
+		}
+	}
+	return approvers, nil
+}
+
+// Intercept 判定操作是否需要审批；需要时创建审批申请并通知审批人，返回待审批信息，不需要时返回 nil
+func (s *Approval) Intercept(ctx context.Context, claims *utils.UserClaims, sub ApprovalSubmission) (*model.ApprovalPendingResp, error) {
+	var policy model.ApprovalPolicy
+	err := global.DB.WithContext(ctx).
+		Where("tenant_id = ? AND operation_type = ?", claims.TenantID, sub.OperationType).
+		First(&policy).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+
+	deviceCount := len(uniqueStrings(sub.DeviceIDs))
+	reason, required := approvalTrigger(&policy, deviceCount, sub.Identifiers)
+	if !required {
+		return nil, nil
+	}
+
+	approvers, err := approvalApprovers(ctx, &policy, claims.ID)
+	if err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	if len(approvers) == 0 {
+		return nil, errcode.New(errcode.CodeApprovalNoApprover)
+	}
+	payload, err := json.Marshal(sub.Payload)
+	if err != nil {
+		return nil, errcode.WithData(errcode.CodeParamError, map[string]interface{}{"error": err.Error()})
+	}
+	approverIDs, _ := json.Marshal(approvers)
+
+	now := time.Now().UTC()
+	r := &model.ApprovalRequest{
+		ID:            uuid.New(),
+		TenantID:      claims.TenantID,
+		OperationType: sub.OperationType,
+		Payload:       string(payload),
+		DeviceCount:   int32(deviceCount),
+		Summary:       approvalSummary(sub.OperationType, deviceCount, sub.Target),
+		TriggerReason: reason,
+		RequesterID:   claims.ID,
+		ApproverIDs:   string(approverIDs),
+		Status:        model.ApprovalPending,
+		ExpiresAt:     approvalExpiresAt(&policy, now),
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if sub.ScopeOrgID != "" {
+		scope := sub.ScopeOrgID
+		r.ScopeOrgID = &scope
+	}
+	if err := global.DB.WithContext(ctx).Create(r).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+
+	go notifyApprovalSubmitted(&policy, r, approvers)
+
+	return &model.ApprovalPendingResp{
+		ApprovalRequired:  true,
+		ApprovalRequestID: r.ID,
+		Status:            r.Status,
+		TriggerReason:     r.TriggerReason,
+		ExpiresAt:         r.ExpiresAt,
+	}, nil
+}
+
+// Approve 审批通过并以申请人身份执行原操作；执行失败时申请状态为 FAILED
+func (s *Approval) Approve(ctx context.Context, id string, req *model.ApprovalDecisionReq, claims *utils.UserClaims) (*model.ApprovalRequest, error) {
+	r, err := s.load(ctx, id, claims.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkApprovalDecider(r, claims); err != nil {
+		return nil, err
+	}
+
+	// 条件更新抢占审批，避免同一申请被重复执行
+	now := time.Now().UTC()
+	res := global.DB.WithContext(ctx).Model(&model.ApprovalRequest{}).
+		Where("id = ? AND status = ? AND expires_at > ?", r.ID, model.ApprovalPending, now).
+		Updates(map[string]interface{}{
+			"status":          model.ApprovalApproved,
+			"decided_by":      claims.ID,
+			"decision_reason": req.Reason,
+			"decided_at":      now,
+			"updated_at":      now,
+		})
+	if res.Error != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": res.Error.Error()})
+	}
+	if res.RowsAffected == 0 {
+		return nil, errcode.New(errcode.CodeApprovalNotPending)
+	}
+
+	// 与批量接口一致，执行过程不随审批请求取消
+	result, execErr := s.execute(context.Background(), r)
+	updates := map[string]interface{}{
+		"status":      model.ApprovalExecuted,
+		"executed_at": time.Now().UTC(),
+		"updated_at":  time.Now().UTC(),
+	}
+	if result != nil {
+		if b, err := json.Marshal(result); err == nil {
+			updates["result"] = string(b)
+		}
+	}
+	if execErr != nil {
+		updates["status"] = model.ApprovalFailed
+		updates["error_message"] = execErr.Error()
+	}
+	if err := global.DB.WithContext(context.Background()).Model(&model.ApprovalRequest{}).
+		Where("id = ?", r.ID).Updates(updates).Error; err != nil {
+		logrus.WithError(err).WithField("approval_request_id", r.ID).Error("approval: save result failed")
+	}
+
+	r, err = s.load(ctx, id, claims.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	go notifyApprovalDecided(r)
+	return r, nil
+}
+
+// Reject 驳回审批申请，须填写驳回原因
+func (s *Approval) Reject(ctx context.Context, id string, req *model.ApprovalDecisionReq, claims *utils.UserClaims) (*model.ApprovalRequest, error) {
+	if req.Reason == nil || strings.TrimSpace(*req.Reason) == "" {
+		return nil, errcode.WithData(errcode.CodeParamError, map[string]interface{}{"reason": "reason is required when rejecting"})
+	}
+	r, err := s.load(ctx, id, claims.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkApprovalDecider(r, claims); err != nil {
+		return nil, err
+	}
+	reason := strings.TrimSpace(*req.Reason)
+	return s.finish(ctx, r, model.ApprovalRejected, map[string]interface{}{
+		"decided_by":      claims.ID,
+		"decision_reason": reason,
+	})
+}
+
+// Cancel 申请人撤回待审批的申请
+func (s *Approval) Cancel(ctx context.Context, id string, claims *utils.UserClaims) (*model.ApprovalRequest, error) {
+	r, err := s.load(ctx, id, claims.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	if r.RequesterID != claims.ID {
+		return nil, errcode.New(errcode.CodeNoPermission)
+	}
+	return s.finish(ctx, r, model.ApprovalCancelled, nil)
+}
+
+// finish 将待审批申请置为终态并通知申请人
+func (s *Approval) finish(ctx context.Context, r *model.ApprovalRequest, status string, extra map[string]interface{}) (*model.ApprovalRequest, error) {
+	now := time.Now().UTC()
+	updates := map[string]interface{}{
+		"status":     status,
+		"decided_at": now,
+		"updated_at": now,
+	}
+	for k, v := range extra {
+		updates[k] = v
+	}
+	res := global.DB.WithContext(ctx).Model(&model.ApprovalRequest{}).
+		Where("id = ? AND status = ?", r.ID, model.ApprovalPending).
+		Updates(updates)
+	if res.Error != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": res.Error.Error()})
+	}
+	if res.RowsAffected == 0 {
+		return nil, errcode.New(errcode.CodeApprovalNotPending)
+	}
+	r, err := s.load(ctx, r.ID, r.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	if status != model.ApprovalCancelled {
+		go notifyApprovalDecided(r)
+	}
+	return r, nil
+}
+
+// ExpireByCron 定时任务：超过有效期仍未审批的申请置为过期并通知申请人
+func (s *Approval) ExpireByCron() {
+	ctx := context.Background()
+	var rows []model.ApprovalRequest
+	if err := global.DB.WithContext(ctx).
+		Where("status = ? AND expires_at <= ?", model.ApprovalPending, time.Now().UTC()).
+		Order("expires_at ASC").
+		Limit(500).
+		Find(&rows).Error; err != nil {
+		logrus.WithError(err).Error("approval expiry: query failed")
+		return
+	}
+	for i := range rows {
+		// 多实例部署时只有抢到更新的实例发送通知
+		r, err := s.finish(ctx, &rows[i], model.ApprovalExpired, nil)
+		if err != nil {
+			if e, ok := err.(*errcode.Error); !ok || e.Code != errcode.CodeApprovalNotPending {
+				logrus.WithError(err).WithField("approval_request_id", rows[i].ID).Warn("approval expiry: update failed")
+			}
+			continue
+		}
+		logrus.WithField("approval_request_id", r.ID).Debug("approval request expired")
+	}
+}
+
+// load 查询本租户的审批申请
+func (s *Approval) load(ctx context.Context, id, tenantID string) (*model.ApprovalRequest, error) {
+	var r model.ApprovalRequest
+	err := global.DB.WithContext(ctx).Where("id = ? AND tenant_id = ?", id, tenantID).First(&r).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, errcode.WithData(errcode.CodeNotFound, map[string]interface{}{"id": id, "error": "approval request not found"})
+	}
+	if err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return &r, nil
+}
+
+// requesterClaims 以申请人身份执行，申请人被冻结或已删除时不再执行
+func (s *Approval) requesterClaims(ctx context.Context, r *model.ApprovalRequest) (*utils.UserClaims, error) {
+	var user model.User
+	if err := global.DB.WithContext(ctx).Where("id = ? AND tenant_id = ?", r.RequesterID, r.TenantID).First(&user).Error; err != nil {
+		return nil, fmt.Errorf("load requester failed: %w", err)
+	}
+	if user.Status != nil && *user.Status == "F" {
+		return nil, fmt.Errorf("requester is frozen")
+	}
+	return &utils.UserClaims{
+		ID:        user.ID,
+		Email:     user.Email,
+		Authority: SafeDeref(user.Authority),
+		TenantID:  SafeDeref(user.TenantID),
+	}, nil
+}
+
+// execute 按原请求参数执行被审批的操作
+func (s *Approval) execute(ctx context.Context, r *model.ApprovalRequest) (interface{}, error) {
+	claims, err := s.requesterClaims(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	scopeOrgID := SafeDeref(r.ScopeOrgID)
+	payload := []byte(r.Payload)
+
+	switch r.OperationType {
+	case model.ApprovalOpBatchCommand:
+		var req model.BatteryBatchCommandReq
+		if err := json.Unmarshal(payload, &req); err != nil {
+			return nil, err
+		}
+		return GroupApp.Battery.BatchSendCommand(ctx, req, claims, scopeOrgID)
+	case model.ApprovalOpBatchOTA:
+		var req model.BatteryBatchOtaPushReq
+		if err := json.Unmarshal(payload, &req); err != nil {
+			return nil, err
+		}
+		return GroupApp.Battery.BatchPushOTA(ctx, req, claims, scopeOrgID)
+	case model.ApprovalOpBatchAssignDealer:
+		var req model.BatteryBatchAssignDealerReq
+		if err := json.Unmarshal(payload, &req); err != nil {
+			return nil, err
+		}
+		return nil, GroupApp.Battery.BatchAssignDealer(ctx, req, claims, scopeOrgID)
+	case model.ApprovalOpOrgTransfer:
+		var req model.DeviceOrgTransferReq
+		if err := json.Unmarshal(payload, &req); err != nil {
+			return nil, err
+		}
+		return nil, GroupApp.DeviceTransfer.TransferDevicesToOrg(ctx, req, claims, scopeOrgID)
+	}
+	return nil, fmt.Errorf("unsupported operation type: %s", r.OperationType)
+}
+
+// approvalQuery 审批申请查询（含申请人、审批人名称），按可见范围过滤：租户管理员可见全部，其他用户可见自己提交或待自己审批的申请
+func approvalQuery(ctx context.Context, claims *utils.UserClaims) *gorm.DB {
+	db := global.DB.WithContext(ctx).Table("approval_requests AS r").
+		Select("r.*, ru.name AS requester_name, du.name AS decided_by_name").
+		Joins("LEFT JOIN users ru ON ru.id = r.requester_id").
+		Joins("LEFT JOIN users du ON du.id = r.decided_by").
+		Where("r.tenant_id = ?", claims.TenantID)
+	if claims.Authority != "TENANT_ADMIN" {
+		db = db.Where("(r.requester_id = ? OR r.approver_ids @> ?::jsonb)", claims.ID, approverContains(claims.ID))
+	}
+	return db
+}
+
+// approverContains 审批人快照包含指定用户的 jsonb 查询参数
+func approverContains(userID string) string {
+	b, _ := json.Marshal([]string{userID})
+	return string(b)
+}
+
+// GetApprovalRequestListByPage 审批申请列表
+func (s *Approval) GetApprovalRequestListByPage(ctx context.Context, req *model.GetApprovalRequestListByPageReq, claims *utils.UserClaims) (map[string]interface{}, error) {
+	db := approvalQuery(ctx, claims)
+	if req.OperationType != nil {
+		db = db.Where("r.operation_type = ?", *req.OperationType)
+	}
+	if req.Status != nil {
+		db = db.Where("r.status = ?", *req.Status)
+	}
+	if req.Scope != nil {
+		switch *req.Scope {
+		case "mine":
+			db = db.Where("r.requester_id = ?", claims.ID)
+		case "todo":
+			db = db.Where("r.status = ? AND r.expires_at > ? AND r.requester_id <> ?", model.ApprovalPending, time.Now().UTC(), claims.ID)
+			if claims.Authority != "TENANT_ADMIN" {
+				db = db.Where("r.approver_ids @> ?::jsonb", approverContains(claims.ID))
+			}
+		}
+	}
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	list := make([]model.ApprovalRequestResp, 0)
+	if err := db.Order("r.created_at DESC").
+		Offset((req.Page - 1) * req.PageSize).
+		Limit(req.PageSize).
+		Scan(&list).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return map[string]interface{}{"total": total, "list": list}, nil
+}
+
+// GetApprovalRequest 审批申请详情
+func (s *Approval) GetApprovalRequest(ctx context.Context, id string, claims *utils.UserClaims) (*model.ApprovalRequestResp, error) {
+	list := make([]model.ApprovalRequestResp, 0, 1)
+	if err := approvalQuery(ctx, claims).Where("r.id = ?", id).Limit(1).Scan(&list).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	if len(list) == 0 {
+		return nil, errcode.WithData(errcode.CodeNotFound, map[string]interface{}{"id": id, "error": "approval request not found"})
+	}
+	return &list[0], nil
+}
+
+// ListPolicies 审批策略列表
+func (s *Approval) ListPolicies(ctx context.Context, claims *utils.UserClaims, tenantID string) ([]model.ApprovalPolicy, error) {
+	resolvedTenantID, err := GroupApp.OrgTypePermission.resolveTenantID(claims, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	policies := make([]model.ApprovalPolicy, 0)
+	if err := global.DB.WithContext(ctx).Where("tenant_id = ?", resolvedTenantID).
+		Order("operation_type").Find(&policies).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return policies, nil
+}
+
+// SavePolicy 保存某类操作的审批策略（存在则覆盖）
+func (s *Approval) SavePolicy(ctx context.Context, claims *utils.UserClaims, tenantID, operationType string, req *model.ApprovalPolicySaveReq) (*model.ApprovalPolicy, error) {
+	resolvedTenantID, err := GroupApp.OrgTypePermission.resolveTenantID(claims, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := approvalOperationLabels[operationType]; !ok {
+		return nil, errcode.WithData(errcode.CodeParamError, map[string]interface{}{
+			"operation_type": operationType,
+			"error":          "operation_type must be one of BATCH_COMMAND/BATCH_OTA/BATCH_ASSIGN_DEALER/ORG_TRANSFER",
+		})
+	}
+	if operationType != model.ApprovalOpBatchCommand && len(req.CommandIdentifiers) > 0 {
+		return nil, errcode.WithData(errcode.CodeParamError, map[string]interface{}{
+			"error": "command_identifiers only applies to BATCH_COMMAND",
+		})
+	}
+	if err := s.checkPolicyRefs(ctx, resolvedTenantID, req); err != nil {
+		return nil, err
+	}
+
+	expireHours := req.ExpireHours
+	if expireHours <= 0 {
+		expireHours = approvalDefaultExpireHours
+	}
+	var groupID *string
+	if req.NotificationGroupID != nil && strings.TrimSpace(*req.NotificationGroupID) != "" {
+		g := strings.TrimSpace(*req.NotificationGroupID)
+		groupID = &g
+	}
+	now := time.Now().UTC()
+	policy := &model.ApprovalPolicy{
+		ID:                  uuid.New(),
+		TenantID:            resolvedTenantID,
+		OperationType:       operationType,
+		Enabled:             req.Enabled,
+		DeviceThreshold:     req.DeviceThreshold,
+		CommandIdentifiers:  approvalJSONList(req.CommandIdentifiers),
+		ApproverUserIDs:     approvalJSONList(req.ApproverUserIDs),
+		ApproverRoleIDs:     approvalJSONList(req.ApproverRoleIDs),
+		NotificationGroupID: groupID,
+		ExpireHours:         expireHours,
+		Remark:              req.Remark,
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}
+	err = global.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "tenant_id"}, {Name: "operation_type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "device_threshold", "command_identifiers",
+			"approver_user_ids", "approver_role_ids", "notification_group_id", "expire_hours", "remark", "updated_at"}),
+	}).Create(policy).Error
+	if err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+
+	var saved model.ApprovalPolicy
+	if err := global.DB.WithContext(ctx).Where("tenant_id = ? AND operation_type = ?", resolvedTenantID, operationType).
+		First(&saved).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return &saved, nil
+}
+
+// checkPolicyRefs 校验策略引用的用户、角色与通知组属于该租户
+func (s *Approval) checkPolicyRefs(ctx context.Context, tenantID string, req *model.ApprovalPolicySaveReq) error {
+	check := func(table, field string, ids []string) error {
+		ids = uniqueStrings(ids)
+		if len(ids) == 0 {
+			return nil
+		}
+		var count int64
+		if err := global.DB.WithContext(ctx).Table(table).
+			Where("tenant_id = ? AND id IN ?", tenantID, ids).
+			Count(&count).Error; err != nil {
+			return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+		}
+		if int(count) != len(ids) {
+			return errcode.WithData(errcode.CodeParamError, map[string]interface{}{field: "contains ids not found in tenant"})
+		}
+		return nil
+	}
+	if err := check("users", "approver_user_ids", req.ApproverUserIDs); err != nil {
+		return err
+	}
+	if err := check("roles", "approver_role_ids", req.ApproverRoleIDs); err != nil {
+		return err
+	}
+	if req.NotificationGroupID != nil && strings.TrimSpace(*req.NotificationGroupID) != "" {
+		return check("notification_groups", "notification_group_id", []string{strings.TrimSpace(*req.NotificationGroupID)})
+	}
+	return nil
+}
+
+// approvalNotificationJSON 审批通知内容（标准通知JSON）
+func approvalNotificationJSON(r *model.ApprovalRequest, subject, content string) (string, error) {
+	payload := map[string]interface{}{
+		"event_type":      model.NotificationEventApproval,
+		"subject":         subject,
+		"content":         content,
+		"timestamp":       time.Now().UTC().Format(time.RFC3339),
+		"tenant_id":       r.TenantID,
+		"approval_id":     r.ID,
+		"operation_type":  r.OperationType,
+		"approval_status": r.Status,
+		"summary":         r.Summary,
+		"device_count":    r.DeviceCount,
+		"expires_at":      r.ExpiresAt.Format(time.RFC3339),
+	}
+	buffer := &bytes.Buffer{}
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(payload); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buffer.String()), nil
+}
+
+// notifyApprovalSubmitted 通知审批人（站内信），并推送策略配置的通知组
+func notifyApprovalSubmitted(p *model.ApprovalPolicy, r *model.ApprovalRequest, approvers []string) {
+	subject := "待审批：" + r.Summary
+	content := fmt.Sprintf("%s\n触发原因：%s\n请在 %s 前处理", r.Summary, r.TriggerReason, r.ExpiresAt.In(time.Local).Format("2006-01-02 15:04:05"))
+	alertJson, err := approvalNotificationJSON(r, subject, content)
+	if err != nil {
+		logrus.WithError(err).Error("approval: build notification failed")
+		return
+	}
+	deliverMemberInbox(r.TenantID, nil, approvers, alertJson)
+	if p.NotificationGroupID != nil && *p.NotificationGroupID != "" {
+		GroupApp.NotificationServicesConfig.ExecuteNotification(*p.NotificationGroupID, alertJson)
+	}
+}
+
+// notifyApprovalDecided 通知申请人审批结果
+func notifyApprovalDecided(r *model.ApprovalRequest) {
+	label := approvalStatusLabels[r.Status]
+	if label == "" {
+		label = r.Status
+	}
+	subject := fmt.Sprintf("审批%s：%s", label, r.Summary)
+	content := subject
+	if r.DecisionReason != nil && *r.DecisionReason != "" {
+		content += "\n审批意见：" + *r.DecisionReason
+	}
+	if r.ErrorMessage != nil && *r.ErrorMessage != "" {
+		content += "\n错误信息：" + *r.ErrorMessage
+	}
+	alertJson, err := approvalNotificationJSON(r, subject, content)
+	if err != nil {
+		logrus.WithError(err).Error("approval: build notification failed")
+		return
+	}
+	deliverMemberInbox(r.TenantID, nil, []string{r.RequesterID}, alertJson)
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"testing"
+
+	model "project/internal/model"
+	"project/pkg/errcode"
+	"project/pkg/utils"
+)
+
+func TestApprovalTrigger(t *testing.T) {
+	threshold := int32(100)
+	commands := approvalJSONList([]string{"factory_reset", " ", "factory_reset"})
+	if commands == nil || *commands != `["factory_reset"]` {
+		t.Fatalf("approvalJSONList = %v", commands)
+	}
+	policy := &model.ApprovalPolicy{
+		OperationType:      model.ApprovalOpBatchCommand,
+		Enabled:            true,
+		DeviceThreshold:    &threshold,
+		CommandIdentifiers: commands,
+	}
+
+	cases := []struct {
+		count       int
+		identifiers []string
+		want        bool
+	}{
+		{100, []string{"reboot"}, false},
+		{101, []string{"reboot"}, true},
+		{1, []string{"factory_reset"}, true},
+	}
+	for _, c := range cases {
+		if _, got := approvalTrigger(policy, c.count, c.identifiers); got != c.want {
+			t.Errorf("approvalTrigger(%d, %v) = %t; want %t", c.count, c.identifiers, got, c.want)
+		}
+	}
+
+	all := `["*"]`
+	policy.CommandIdentifiers = &all
+	if _, got := approvalTrigger(policy, 1, []string{"reboot"}); !got {
+		t.Error("wildcard command should require approval")
+	}
+
+	// 非指令类操作只按设备数判断
+	policy.OperationType = model.ApprovalOpOrgTransfer
+	if _, got := approvalTrigger(policy, 1, []string{"reboot"}); got {
+		t.Error("command identifiers should not apply to ORG_TRANSFER")
+	}
+
+	policy.Enabled = false
+	if _, got := approvalTrigger(policy, 1000, nil); got {
+		t.Error("disabled policy should not require approval")
+	}
+}
+
+func TestCheckApprovalDecider(t *testing.T) {
+	r := &model.ApprovalRequest{RequesterID: "u1", ApproverIDs: `["u2"]`}
+
+	codeOf := func(err error) int {
+		if e, ok := err.(*errcode.Error); ok {
+			return e.Code
+		}
+		return 0
+	}
+	if err := checkApprovalDecider(r, &utils.UserClaims{ID: "u1", Authority: "TENANT_ADMIN"}); codeOf(err) != errcode.CodeApprovalSelfDecision {
+		t.Errorf("requester approving own request: %v", err)
+	}
+	if err := checkApprovalDecider(r, &utils.UserClaims{ID: "u2", Authority: "TENANT_USER"}); err != nil {
+		t.Errorf("listed approver: %v", err)
+	}
+	if err := checkApprovalDecider(r, &utils.UserClaims{ID: "u3", Authority: "TENANT_USER"}); codeOf(err) != errcode.CodeNoPermission {
+		t.Errorf("unlisted user: %v", err)
+	}
+	if err := checkApprovalDecider(r, &utils.UserClaims{ID: "u4", Authority: "TENANT_ADMIN"}); err != nil {
+		t.Errorf("tenant admin: %v", err)
+	}
+}
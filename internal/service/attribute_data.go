@@ -118,12 +118,35 @@ func (a *AttributeData) SetDownlinkBus(bus *downlink.Bus) {
 	a.downlinkBus = bus
 }
 
-// AttributePutMessage 属性设置下发（改造为异步模式，支持多层网关）
+// AttributePutMessage 属性设置下发（改造为异步模式，支持多层网关），下发结果写入设备审计
 func (a *AttributeData) AttributePutMessage(ctx context.Context, operatorID string, putMessageReq *model.AttributePutMessage, operationType string) error {
+	// 多层网关会改写 Value，审计记录原始下发内容
+	value := putMessageReq.Value
+	before := deviceAuditCurrentValues(putMessageReq.DeviceID, model.DeviceParamATTR, value)
+	messageId, err := a.attributePutMessage(ctx, putMessageReq, operationType)
+
+	var after interface{} = value
+	if json.Valid([]byte(value)) {
+		after = json.RawMessage(value)
+	}
+	GroupApp.DeviceAudit.Record(ctx, DeviceAuditEntry{
+		DeviceID: putMessageReq.DeviceID,
+		Action:   model.DeviceAuditAttributeSet,
+		Source:   deviceAuditSource(operationType),
+		ActorID:  operatorID,
+		Before:   before,
+		After:    after,
+		Err:      err,
+		RefID:    messageId,
+	})
+	return err
+}
+
+func (a *AttributeData) attributePutMessage(ctx context.Context, putMessageReq *model.AttributePutMessage, operationType string) (string, error) {
 	// 1. 获取设备信息
 	device, err := initialize.GetDeviceCacheById(putMessageReq.DeviceID)
 	if err != nil {
-		return fmt.Errorf("device not found: %w", err)
+		return "", fmt.Errorf("device not found: %w", err)
 	}
 
 	// 2. 生成 message_id（8位唯一字符串）
@@ -135,7 +158,7 @@ func (a *AttributeData) AttributePutMessage(ctx context.Context, operatorID stri
 	if device.DeviceConfigID != nil {
 		deviceConfig, err := dal.GetDeviceConfigByID(*device.DeviceConfigID)
 		if err != nil {
-			return fmt.Errorf("failed to get device config: %w", err)
+			return "", fmt.Errorf("failed to get device config: %w", err)
 		}
 		deviceType = deviceConfig.DeviceType
 		if deviceConfig.ProtocolType != nil {
@@ -150,13 +173,13 @@ func (a *AttributeData) AttributePutMessage(ctx context.Context, operatorID stri
 
 	// 4. 处理多层网关数据嵌套
 	if err := transformAttributeDataForMultiLevelGateway(putMessageReq, device, deviceType); err != nil {
-		return fmt.Errorf("failed to transform attribute data: %w", err)
+		return "", fmt.Errorf("failed to transform attribute data: %w", err)
 	}
 
 	// 5. 处理网关层级，获取目标设备信息
 	targetDevice, targetDeviceNumber, topicPrefix, err := a.resolveDeviceInfo(device, deviceType, protocolType)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	// 6. 构造属性数据（已经过多层网关嵌套处理）
@@ -191,10 +214,10 @@ func (a *AttributeData) AttributePutMessage(ctx context.Context, operatorID stri
 			"message_id":          messageId,
 		}).Info("Attribute set sent via downlink")
 	} else {
-		return fmt.Errorf("downlink service not available")
+		return "", fmt.Errorf("downlink service not available")
 	}
 
-	return nil
+	return messageId, nil
 }
 
 // resolveDeviceInfo 处理多层网关，返回目标设备、目标设备编号和Topic前缀
@@ -0,0 +1,483 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"project/initialize"
+	"project/internal/dal"
+	model "project/internal/model"
+	global "project/pkg/global"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 聚合函数
+const (
+	AGGREGATE_FUNC_COUNT   = "COUNT"   // 满足成员条件的设备数
+	AGGREGATE_FUNC_PERCENT = "PERCENT" // 满足成员条件的设备占比(%)
+	AGGREGATE_FUNC_AVG     = "AVG"
+	AGGREGATE_FUNC_MIN     = "MIN"
+	AGGREGATE_FUNC_MAX     = "MAX"
+	AGGREGATE_FUNC_SUM     = "SUM"
+)
+
+var aggregateFuncNames = map[string]string{
+	AGGREGATE_FUNC_COUNT:   "设备数",
+	AGGREGATE_FUNC_PERCENT: "设备占比(%)",
+	AGGREGATE_FUNC_AVG:     "平均值",
+	AGGREGATE_FUNC_MIN:     "最小值",
+	AGGREGATE_FUNC_MAX:     "最大值",
+	AGGREGATE_FUNC_SUM:     "合计",
+}
+
+// aggregateStateKey 聚合条件组触发状态在条件状态缓存中的设备维度 key
+const aggregateStateKey = "aggregate"
+
+// AutomateAggregate 设备分组/设备模板聚合条件（定时评估）
+type AutomateAggregate struct{}
+
+// aggregateMember 聚合成员的当前取值，无数据时 Value 为 nil
+type aggregateMember struct {
+	DeviceID string
+	Value    interface{}
+}
+
+// aggregatePartition 一次聚合的成员范围（整个分组或某个子分组）
+type aggregatePartition struct {
+	Name      string
+	DeviceIDs []string
+}
+
+func conditionAggregated(cond model.DeviceTriggerCondition) bool {
+	return cond.TriggerConditionType == model.DEVICE_TRIGGER_CONDITION_TYPE_GROUP ||
+		cond.TriggerConditionType == model.DEVICE_TRIGGER_CONDITION_TYPE_TEMPLATE
+}
+
+// aggregateEvaluate 计算聚合值，返回聚合值、参与聚合（或满足成员条件）的设备、是否有可用数据
+func aggregateEvaluate(fn, memberOp, memberValue string, members []aggregateMember, check func(op, condValue string, actual interface{}) bool) (float64, []string, bool) {
+	if len(members) == 0 {
+		return 0, nil, false
+	}
+	var matched []string
+	switch fn {
+	case AGGREGATE_FUNC_COUNT, AGGREGATE_FUNC_PERCENT:
+		for _, m := range members {
+			if m.Value != nil && check(memberOp, memberValue, m.Value) {
+				matched = append(matched, m.DeviceID)
+			}
+		}
+		if fn == AGGREGATE_FUNC_COUNT {
+			return float64(len(matched)), matched, true
+		}
+		return float64(len(matched)) * 100 / float64(len(members)), matched, true
+	}
+
+	var (
+		result float64
+		n      int
+	)
+	for _, m := range members {
+		v, ok := conditionFloatValue(m.Value)
+		if !ok {
+			continue
+		}
+		matched = append(matched, m.DeviceID)
+		switch {
+		case n == 0:
+			result = v
+		case fn == AGGREGATE_FUNC_MIN && v < result, fn == AGGREGATE_FUNC_MAX && v > result:
+			result = v
+		case fn == AGGREGATE_FUNC_AVG, fn == AGGREGATE_FUNC_SUM:
+			result += v
+		}
+		n++
+	}
+	if n == 0 {
+		return 0, nil, false
+	}
+	if fn == AGGREGATE_FUNC_AVG {
+		result /= float64(n)
+	}
+	return result, matched, true
+}
+
+// EvaluateByCron 周期评估所有启用的聚合条件组，条件组由不成立变为成立时执行动作，恢复时恢复告警
+// 状态读取与写回不是原子操作，多实例部署时须由调用方加分布式锁（见 croninit）
+func (s *AutomateAggregate) EvaluateByCron() {
+	defer GroupApp.Automate.ErrorRecover()
+	var groupIds []string
+	if err := global.DB.Model(&model.DeviceTriggerCondition{}).
+		Where("enabled = ? AND trigger_condition_type IN ?", "Y", []string{model.DEVICE_TRIGGER_CONDITION_TYPE_GROUP, model.DEVICE_TRIGGER_CONDITION_TYPE_TEMPLATE}).
+		Distinct("group_id").
+		Pluck("group_id", &groupIds).Error; err != nil {
+		logrus.Error("查询聚合条件失败", err)
+		return
+	}
+	if len(groupIds) == 0 {
+		return
+	}
+	conds, err := dal.GetDeviceTriggerConditionByGroupIds(groupIds)
+	if err != nil {
+		logrus.Error("查询聚合条件组失败", err)
+		return
+	}
+	byGroup := make(map[string]initialize.DTConditions)
+	var order []string
+	for _, c := range conds {
+		if _, ok := byGroup[c.GroupID]; !ok {
+			order = append(order, c.GroupID)
+		}
+		byGroup[c.GroupID] = append(byGroup[c.GroupID], c)
+	}
+	for _, groupID := range order {
+		s.evaluateGroup(byGroup[groupID])
+	}
+}
+
+func (s *AutomateAggregate) evaluateGroup(conditions initialize.DTConditions) {
+	groupID := conditions[0].GroupID
+	sceneAutomationID := conditions[0].SceneAutomationID
+	if GroupApp.CheckSceneAutomationHasClose(sceneAutomationID) {
+		return
+	}
+
+	ok := true
+	var (
+		contents []string
+		scope    []string
+	)
+	for _, cond := range conditions {
+		var (
+			condOk  bool
+			content string
+			devices []string
+		)
+		if conditionAggregated(cond) {
+			condOk, devices, content = s.checkCondition(cond)
+		} else {
+			condOk = GroupApp.Automate.automateConditionCheckWithTime(cond)
+		}
+		if content != "" {
+			contents = append(contents, content)
+		}
+		scope = append(scope, devices...)
+		if !condOk {
+			ok = false
+			break
+		}
+	}
+	scope = uniqueStrings(scope)
+
+	stateCache := initialize.NewConditionStateCache()
+	st, err := stateCache.Get(groupID, aggregateStateKey)
+	if err != nil {
+		logrus.Error("获取聚合条件状态失败", err)
+		return
+	}
+	changed := st.Active != ok
+	st.Active = ok
+	st.UpdatedAt = time.Now().UnixMilli()
+	// 每次评估都写回，避免持续成立期间状态过期而重复触发
+	if err := stateCache.Set(groupID, aggregateStateKey, st); err != nil {
+		logrus.Error("保存聚合条件状态失败", err)
+	}
+	// 只在状态变化时处理：成立时执行一次动作，恢复时恢复告警
+	if !changed {
+		return
+	}
+	logrus.Debugf("聚合条件组 %s 状态变化: %t, 范围设备数: %d, %v", groupID, ok, len(scope), contents)
+
+	// 恢复时按触发时缓存的设备范围恢复告警
+	if !ok {
+		if cache, err := initialize.NewAlarmCache().GetByGroupId(groupID); err == nil {
+			scope = cache.AlaramDeviceIdList
+		}
+	}
+
+	if err := conditionAfterAlarmWithDevices(ok, groupID, sceneAutomationID, scope, contents); err != nil {
+		logrus.Error(err)
+	}
+	if !ok {
+		return
+	}
+
+	actions, err := dal.GetActionInfoListBySceneAutomationId([]string{sceneAutomationID})
+	if err != nil || len(actions) == 0 {
+		return
+	}
+	deviceIds, err := aggregateActionDevices(actions, scope)
+	if err != nil {
+		logrus.Error("查询聚合动作设备失败", err)
+		return
+	}
+	err = GroupApp.SceneAutomateExecute(sceneAutomationID, deviceIds, actions)
+	for _, deviceID := range scope {
+		GroupApp.Automate.actionAfterDecorationRun(actions, deviceID, err)
+	}
+}
+
+// aggregateActionDevices 单类设备动作只作用于聚合范围内属于该设备配置的设备
+func aggregateActionDevices(actions []model.ActionInfo, scope []string) ([]string, error) {
+	var configIds []string
+	for _, v := range actions {
+		if v.ActionType == model.AUTOMATE_ACTION_TYPE_MULTIPLE && v.ActionTarget != nil {
+			configIds = append(configIds, *v.ActionTarget)
+		}
+	}
+	if len(configIds) == 0 || len(scope) == 0 {
+		return scope, nil
+	}
+	var deviceIds []string
+	err := global.DB.Model(&model.Device{}).
+		Where("id IN ? AND device_config_id IN ?", scope, configIds).
+		Pluck("id", &deviceIds).Error
+	return deviceIds, err
+}
+
+// checkCondition 评估单个聚合条件，返回是否成立、告警/动作范围设备、条件描述
+func (s *AutomateAggregate) checkCondition(cond model.DeviceTriggerCondition) (bool, []string, string) {
+	if cond.TriggerSource == nil || cond.AggregateFunc == nil || cond.TriggerParamType == nil {
+		return false, nil, ""
+	}
+	partitions, err := s.partitions(cond)
+	if err != nil {
+		logrus.Error("查询聚合成员失败", err)
+		return false, nil, ""
+	}
+	operator := model.CONDITION_TRIGGER_OPERATOR_EQ
+	if cond.TriggerOperator != nil {
+		operator = *cond.TriggerOperator
+	}
+	var memberOp, memberValue, param string
+	if cond.MemberOperator != nil {
+		memberOp = *cond.MemberOperator
+	}
+	if cond.MemberValue != nil {
+		memberValue = *cond.MemberValue
+	}
+	if cond.TriggerParam != nil {
+		param = *cond.TriggerParam
+	}
+
+	var (
+		ok       bool
+		scope    []string
+		contents []string
+	)
+	for _, p := range partitions {
+		members, err := aggregateMemberValues(cond.TenantID, strings.ToUpper(*cond.TriggerParamType), param, p.DeviceIDs)
+		if err != nil {
+			logrus.Error("查询聚合成员数据失败", err)
+			return false, nil, ""
+		}
+		value, matched, has := aggregateEvaluate(*cond.AggregateFunc, memberOp, memberValue, members, GroupApp.Automate.automateConditionCheckByOperator)
+		if !has {
+			continue
+		}
+		label := fmt.Sprintf("%s %s", p.Name, aggregateFuncNames[*cond.AggregateFunc])
+		if param != "" {
+			label = fmt.Sprintf("%s [%s]", label, param)
+		}
+		if GroupApp.Automate.automateConditionCheckByOperator(operator, cond.TriggerValue, value) {
+			ok = true
+			// 如“在线占比低于 20%”时满足成员条件的设备可能为空，此时以整个范围为告警对象
+			if len(matched) == 0 {
+				matched = p.DeviceIDs
+			}
+			scope = append(scope, matched...)
+			contents = append(contents, fmt.Sprintf("%s: %.2f %s %s", label, value, operator, cond.TriggerValue))
+		}
+	}
+	return ok, scope, strings.Join(contents, "; ")
+}
+
+// partitions 聚合成员范围：设备模板下全部设备；设备分组（含下级分组）全部设备，或按直属子分组分别聚合
+func (*AutomateAggregate) partitions(cond model.DeviceTriggerCondition) ([]aggregatePartition, error) {
+	source := *cond.TriggerSource
+	if cond.TriggerConditionType == model.DEVICE_TRIGGER_CONDITION_TYPE_TEMPLATE {
+		var ids []string
+		err := global.DB.Table("devices AS d").
+			Joins("JOIN device_configs dc ON dc.id = d.device_config_id").
+			Where("d.tenant_id = ? AND dc.device_template_id = ?", cond.TenantID, source).
+			Pluck("d.id", &ids).Error
+		if err != nil {
+			return nil, err
+		}
+		return []aggregatePartition{{Name: "设备模板", DeviceIDs: ids}}, nil
+	}
+
+	var groups []model.Group
+	if err := global.DB.Where("tenant_id = ?", cond.TenantID).Find(&groups).Error; err != nil {
+		return nil, err
+	}
+	children := make(map[string][]string)
+	names := make(map[string]string)
+	for _, g := range groups {
+		names[g.ID] = g.Name
+		if g.ParentID != nil {
+			children[*g.ParentID] = append(children[*g.ParentID], g.ID)
+		}
+	}
+	groupDevices := func(root string) ([]string, error) {
+		ids := []string{root}
+		for i := 0; i < len(ids); i++ {
+			ids = append(ids, children[ids[i]]...)
+		}
+		var deviceIds []string
+		err := global.DB.Model(&model.RGroupDevice{}).
+			Where("tenant_id = ? AND group_id IN ?", cond.TenantID, ids).
+			Distinct("device_id").
+			Pluck("device_id", &deviceIds).Error
+		return deviceIds, err
+	}
+
+	roots := []string{source}
+	if cond.AggregateBySubgroup != nil && *cond.AggregateBySubgroup && len(children[source]) > 0 {
+		roots = children[source]
+	}
+	partitions := make([]aggregatePartition, 0, len(roots))
+	for _, root := range roots {
+		ids, err := groupDevices(root)
+		if err != nil {
+			return nil, err
+		}
+		partitions = append(partitions, aggregatePartition{Name: fmt.Sprintf("分组(%s)", names[root]), DeviceIDs: ids})
+	}
+	return partitions, nil
+}
+
+// aggregateMemberValues 批量查询成员设备的最新遥测/属性/在线状态
+func aggregateMemberValues(tenantID, paramType, key string, deviceIds []string) ([]aggregateMember, error) {
+	members := make([]aggregateMember, 0, len(deviceIds))
+	if len(deviceIds) == 0 {
+		return members, nil
+	}
+	values := make(map[string]interface{}, len(deviceIds))
+	switch paramType {
+	case model.TRIGGER_PARAM_TYPE_STATUS:
+		var rows []struct {
+			ID       string
+			IsOnline int16
+		}
+		if err := global.DB.Model(&model.Device{}).
+			Select("id, is_online").
+			Where("tenant_id = ? AND id IN ?", tenantID, deviceIds).
+			Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, r := range rows {
+			values[r.ID] = "OFF-LINE"
+			if r.IsOnline == 1 {
+				values[r.ID] = "ON-LINE"
+			}
+		}
+	case model.TRIGGER_PARAM_TYPE_TEL, model.TRIGGER_PARAM_TYPE_TELEMETRY, model.TRIGGER_PARAM_TYPE_ATTR, model.TRIGGER_PARAM_TYPE_ATTRIBUTES:
+		table := model.TableNameTelemetryCurrentData
+		if paramType == model.TRIGGER_PARAM_TYPE_ATTR || paramType == model.TRIGGER_PARAM_TYPE_ATTRIBUTES {
+			table = model.TableNameAttributeData
+		}
+		var rows []struct {
+			DeviceID string
+			BoolV    *bool
+			NumberV  *float64
+			StringV  *string
+		}
+		if err := global.DB.Table(table).
+			Select("device_id, bool_v, number_v, string_v").
+			Where("device_id IN ? AND key = ?", deviceIds, key).
+			Order("ts ASC").
+			Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, r := range rows {
+			switch {
+			case r.NumberV != nil:
+				values[r.DeviceID] = *r.NumberV
+			case r.StringV != nil:
+				values[r.DeviceID] = *r.StringV
+			case r.BoolV != nil:
+				values[r.DeviceID] = *r.BoolV
+			}
+		}
+	}
+	for _, id := range deviceIds {
+		members = append(members, aggregateMember{DeviceID: id, Value: values[id]})
+	}
+	return members, nil
+}
+
+func uniqueStrings(list []string) []string {
+	seen := make(map[string]bool, len(list))
+	out := list[:0]
+	for _, v := range list {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// validateAggregateCondition 校验聚合条件配置
+func validateAggregateCondition(c model.Condition) error {
+	if c.TriggerSource == nil || *c.TriggerSource == "" {
+		return conditionParamError("aggregate conditions require trigger_source (group or device template id)")
+	}
+	if c.AggregateFunc == nil || *c.AggregateFunc == "" {
+		return conditionParamError("aggregate conditions require aggregate_func")
+	}
+	if _, ok := aggregateFuncNames[*c.AggregateFunc]; !ok {
+		return conditionParamError("unsupported aggregate_func")
+	}
+	if c.TriggerParamType == nil {
+		return conditionParamError("aggregate conditions require trigger_param_type")
+	}
+	if c.TriggerOperator == nil || c.TriggerValue == nil {
+		return conditionParamError("aggregate conditions require trigger_operator and trigger_value")
+	}
+	if c.AggregateBySubgroup != nil && *c.AggregateBySubgroup && c.TriggerConditionsType != model.DEVICE_TRIGGER_CONDITION_TYPE_GROUP {
+		return conditionParamError("aggregate_by_subgroup only applies to group conditions")
+	}
+	paramType := strings.ToUpper(*c.TriggerParamType)
+	switch paramType {
+	case model.TRIGGER_PARAM_TYPE_STATUS:
+		if *c.AggregateFunc != AGGREGATE_FUNC_COUNT && *c.AggregateFunc != AGGREGATE_FUNC_PERCENT {
+			return conditionParamError("status aggregates only support COUNT and PERCENT")
+		}
+	case model.TRIGGER_PARAM_TYPE_TEL, model.TRIGGER_PARAM_TYPE_TELEMETRY, model.TRIGGER_PARAM_TYPE_ATTR, model.TRIGGER_PARAM_TYPE_ATTRIBUTES:
+		if c.TriggerParam == nil || *c.TriggerParam == "" {
+			return conditionParamError("aggregate conditions require trigger_param")
+		}
+	default:
+		return conditionParamError("aggregate conditions only support telemetry, attributes and status")
+	}
+	if *c.AggregateFunc == AGGREGATE_FUNC_COUNT || *c.AggregateFunc == AGGREGATE_FUNC_PERCENT {
+		if c.MemberOperator == nil || *c.MemberOperator == "" || c.MemberValue == nil {
+			return conditionParamError("COUNT and PERCENT require member_operator and member_value")
+		}
+	}
+	return nil
+}
+
+// validateAggregateGroup 聚合条件按周期评估，同组内只能与时间范围条件组合
+func validateAggregateGroup(group []model.Condition) error {
+	var hasAggregate, hasOther bool
+	for _, c := range group {
+		switch c.TriggerConditionsType {
+		case model.DEVICE_TRIGGER_CONDITION_TYPE_GROUP, model.DEVICE_TRIGGER_CONDITION_TYPE_TEMPLATE:
+			hasAggregate = true
+			if err := validateAggregateCondition(c); err != nil {
+				return err
+			}
+		case model.DEVICE_TRIGGER_CONDITION_TYPE_TIME:
+		default:
+			hasOther = true
+		}
+	}
+	if hasAggregate && hasOther {
+		return conditionParamError("aggregate conditions can only be combined with time range conditions in the same group")
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"math"
+	"testing"
+
+	model "project/internal/model"
+)
+
+func TestAggregateEvaluate(t *testing.T) {
+	a := &Automate{}
+	status := []aggregateMember{
+		{DeviceID: "d1", Value: "OFF-LINE"},
+		{DeviceID: "d2", Value: "ON-LINE"},
+		{DeviceID: "d3", Value: "ON-LINE"},
+		{DeviceID: "d4", Value: "OFF-LINE"},
+		{DeviceID: "d5", Value: "ON-LINE"},
+	}
+	v, matched, ok := aggregateEvaluate(AGGREGATE_FUNC_PERCENT, "=", "OFF-LINE", status, a.automateConditionCheckByOperator)
+	if !ok || v != 40 || len(matched) != 2 {
+		t.Fatalf("offline percent = %v %v %t; want 40 with 2 devices", v, matched, ok)
+	}
+
+	temps := []aggregateMember{
+		{DeviceID: "p1", Value: 56.0},
+		{DeviceID: "p2", Value: 54.0},
+		{DeviceID: "p3", Value: 58.5},
+		{DeviceID: "p4", Value: nil},
+		{DeviceID: "p5", Value: 60.0},
+	}
+	v, matched, _ = aggregateEvaluate(AGGREGATE_FUNC_COUNT, ">", "55", temps, a.automateConditionCheckByOperator)
+	if v != 3 || len(matched) != 3 {
+		t.Fatalf("count over 55 = %v %v; want 3", v, matched)
+	}
+
+	v, matched, _ = aggregateEvaluate(AGGREGATE_FUNC_AVG, "", "", temps, a.automateConditionCheckByOperator)
+	if math.Abs(v-57.125) > 1e-9 || len(matched) != 4 {
+		t.Fatalf("avg = %v over %d devices; want 57.125 over 4", v, len(matched))
+	}
+	if v, _, _ = aggregateEvaluate(AGGREGATE_FUNC_MIN, "", "", temps, a.automateConditionCheckByOperator); v != 54 {
+		t.Fatalf("min = %v; want 54", v)
+	}
+	if v, _, _ = aggregateEvaluate(AGGREGATE_FUNC_MAX, "", "", temps, a.automateConditionCheckByOperator); v != 60 {
+		t.Fatalf("max = %v; want 60", v)
+	}
+	if v, _, _ = aggregateEvaluate(AGGREGATE_FUNC_SUM, "", "", temps, a.automateConditionCheckByOperator); v != 228.5 {
+		t.Fatalf("sum = %v; want 228.5", v)
+	}
+
+	if _, _, ok = aggregateEvaluate(AGGREGATE_FUNC_AVG, "", "", []aggregateMember{{DeviceID: "x"}}, a.automateConditionCheckByOperator); ok {
+		t.Fatal("avg without any data should not be available")
+	}
+}
+
+func TestValidateAggregateGroup(t *testing.T) {
+	str := func(s string) *string { return &s }
+	offline := model.Condition{
+		TriggerConditionsType: model.DEVICE_TRIGGER_CONDITION_TYPE_GROUP,
+		TriggerSource:         str("group-1"),
+		TriggerParamType:      str(model.TRIGGER_PARAM_TYPE_STATUS),
+		TriggerOperator:       str(">"),
+		TriggerValue:          str("20"),
+		AggregateFunc:         str(AGGREGATE_FUNC_PERCENT),
+		MemberOperator:        str("="),
+		MemberValue:           str("OFF-LINE"),
+	}
+	timeRange := model.Condition{TriggerConditionsType: model.DEVICE_TRIGGER_CONDITION_TYPE_TIME}
+	if err := validateAggregateGroup([]model.Condition{offline, timeRange}); err != nil {
+		t.Fatalf("aggregate with time range rejected: %v", err)
+	}
+
+	device := model.Condition{TriggerConditionsType: model.DEVICE_TRIGGER_CONDITION_TYPE_ONE}
+	if validateAggregateGroup([]model.Condition{offline, device}) == nil {
+		t.Fatal("aggregate mixed with device conditions should be rejected")
+	}
+
+	avgStatus := offline
+	avgStatus.AggregateFunc = str(AGGREGATE_FUNC_AVG)
+	if validateAggregateGroup([]model.Condition{avgStatus}) == nil {
+		t.Fatal("AVG over status should be rejected")
+	}
+
+	noMember := offline
+	noMember.MemberOperator = nil
+	if validateAggregateGroup([]model.Condition{noMember}) == nil {
+		t.Fatal("PERCENT without member predicate should be rejected")
+	}
+}
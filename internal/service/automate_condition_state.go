@@ -0,0 +1,227 @@
+package service
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"project/initialize"
+	model "project/internal/model"
+	"project/pkg/errcode"
+
+	"github.com/sirupsen/logrus"
+)
+
+// conditionStateOptions 设备条件的持续时间/N-M 采样/回差配置
+type conditionStateOptions struct {
+	Hold     time.Duration // 持续时间
+	Window   int           // N/M 采样窗口 M
+	Hits     int           // N/M 采样命中数 N
+	HasClear bool          // 是否配置了恢复阈值
+}
+
+func conditionStateOptionsOf(cond model.DeviceTriggerCondition) conditionStateOptions {
+	var opt conditionStateOptions
+	if cond.HoldDuration != nil && *cond.HoldDuration > 0 {
+		opt.Hold = time.Duration(*cond.HoldDuration) * time.Second
+	}
+	if cond.SampleWindow != nil && *cond.SampleWindow > 0 && cond.SampleHits != nil && *cond.SampleHits > 0 {
+		opt.Window = int(*cond.SampleWindow)
+		opt.Hits = int(*cond.SampleHits)
+	}
+	opt.HasClear = cond.ClearValue != nil && *cond.ClearValue != ""
+	return opt
+}
+
+// conditionStateful 条件是否需要跨上报保存状态
+func conditionStateful(cond model.DeviceTriggerCondition) bool {
+	opt := conditionStateOptionsOf(cond)
+	return opt.Hold > 0 || opt.Window > 0 || opt.HasClear
+}
+
+// conditionClearCheck 回差恢复判断：大于类条件数值低于恢复阈值、小于类条件数值高于恢复阈值时视为恢复
+func conditionClearCheck(operator, clearValue string, actual float64) bool {
+	clear, err := strconv.ParseFloat(clearValue, 64)
+	if err != nil {
+		return true
+	}
+	switch operator {
+	case model.CONDITION_TRIGGER_OPERATOR_GT, model.CONDITION_TRIGGER_OPERATOR_GTE:
+		return actual < clear
+	case model.CONDITION_TRIGGER_OPERATOR_LT, model.CONDITION_TRIGGER_OPERATOR_LTE:
+		return actual > clear
+	}
+	return true
+}
+
+// advanceConditionState 根据本次判断结果推进条件状态，返回条件是否成立
+// raw 为本次上报的原始比较结果；cleared 为回差恢复判断结果（仅 HasClear 时有效）；
+// fresh 表示本次上报包含该参数（只有新上报才计入 N/M 采样窗口）
+func advanceConditionState(st *initialize.ConditionState, opt conditionStateOptions, raw, cleared, fresh bool, now time.Time) bool {
+	nowMs := now.UnixMilli()
+	st.UpdatedAt = nowMs
+	if raw {
+		if st.Since == 0 {
+			st.Since = nowMs
+		}
+	} else {
+		st.Since = 0
+	}
+	if opt.Window > 0 && fresh {
+		st.Samples = append(st.Samples, raw)
+		if len(st.Samples) > opt.Window {
+			st.Samples = st.Samples[len(st.Samples)-opt.Window:]
+		}
+	}
+	hits := 0
+	for _, s := range st.Samples {
+		if s {
+			hits++
+		}
+	}
+
+	// 已触发：直到满足恢复条件才恢复
+	if st.Active {
+		switch {
+		case opt.HasClear:
+		case opt.Window > 0:
+			cleared = hits < opt.Hits
+		default:
+			cleared = !raw
+		}
+		if !cleared {
+			return true
+		}
+		st.Active = false
+		st.Samples = nil
+		return false
+	}
+
+	met := raw
+	if opt.Hold > 0 {
+		met = raw && nowMs-st.Since >= opt.Hold.Milliseconds()
+	} else if opt.Window > 0 {
+		met = true
+	}
+	if opt.Window > 0 {
+		met = met && hits >= opt.Hits
+	}
+	st.Active = met
+	return met
+}
+
+// automateConditionCheckWithState 带状态的设备条件判断（状态保存在 Redis）
+func (a *Automate) automateConditionCheckWithState(cond model.DeviceTriggerCondition, deviceId string, raw bool, actualValue interface{}) bool {
+	stateCache := initialize.NewConditionStateCache()
+	st, err := a.conditionState(cond.ID, deviceId)
+	if err != nil {
+		logrus.Error("获取条件状态失败", err)
+		return raw
+	}
+	opt := conditionStateOptionsOf(cond)
+
+	var fresh bool
+	if cond.TriggerParam != nil {
+		_, fresh = a.formExt.TriggerValues[*cond.TriggerParam]
+	}
+	cleared := !raw
+	if opt.HasClear && cond.TriggerOperator != nil {
+		if v, ok := conditionFloatValue(actualValue); ok {
+			cleared = conditionClearCheck(*cond.TriggerOperator, *cond.ClearValue, v)
+		}
+	}
+
+	ok := advanceConditionState(&st, opt, raw, cleared, fresh, a.now())
+	// 试运行/回放只在内存中推进状态
+	if a.explain != nil {
+		a.explain.states[explainKey(cond.ID, deviceId)] = st
+		return ok
+	}
+	if err := stateCache.Set(cond.ID, deviceId, st); err != nil {
+		logrus.Error("保存条件状态失败", err)
+	}
+	logrus.Debugf("条件状态: raw:%t, cleared:%t, state:%#v, 结果:%t", raw, cleared, st, ok)
+	return ok
+}
+
+func conditionFloatValue(v interface{}) (float64, bool) {
+	switch val := v.(type) {
+	case float64:
+		return val, true
+	case string:
+		f, err := strconv.ParseFloat(val, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// validateConditionGroups 校验设备条件的窗口统计、持续时间/N-M 采样/回差配置
+func validateConditionGroups(groups [][]model.Condition) error {
+	for _, group := range groups {
+		if err := validateAggregateGroup(group); err != nil {
+			return err
+		}
+		for _, c := range group {
+			if err := validateConditionWindow(c); err != nil {
+				return err
+			}
+			hasHold := c.HoldDuration != nil && *c.HoldDuration > 0
+			hasWindow := (c.SampleWindow != nil && *c.SampleWindow > 0) || (c.SampleHits != nil && *c.SampleHits > 0)
+			hasClear := c.ClearValue != nil && *c.ClearValue != ""
+			if !hasHold && !hasWindow && !hasClear {
+				continue
+			}
+			if c.TriggerConditionsType != model.DEVICE_TRIGGER_CONDITION_TYPE_ONE && c.TriggerConditionsType != model.DEVICE_TRIGGER_CONDITION_TYPE_MULTIPLE {
+				return conditionParamError("duration, sampling and clear threshold only apply to device conditions")
+			}
+			if c.TriggerParamType != nil {
+				switch strings.ToUpper(*c.TriggerParamType) {
+				case model.TRIGGER_PARAM_TYPE_EVT, model.TRIGGER_PARAM_TYPE_EVENT, model.TRIGGER_PARAM_TYPE_STATUS:
+					return conditionParamError("duration, sampling and clear threshold do not apply to event or status conditions")
+				}
+			}
+			if hasWindow {
+				if c.SampleWindow == nil || c.SampleHits == nil || *c.SampleHits < 1 || *c.SampleHits > *c.SampleWindow {
+					return conditionParamError("sample_hits must be between 1 and sample_window")
+				}
+			}
+			if hasClear {
+				if err := validateClearValue(c); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func validateClearValue(c model.Condition) error {
+	if c.TriggerOperator == nil || c.TriggerValue == nil {
+		return conditionParamError("clear_value requires trigger_operator and trigger_value")
+	}
+	clear, err := strconv.ParseFloat(*c.ClearValue, 64)
+	if err != nil {
+		return conditionParamError("clear_value must be numeric")
+	}
+	trigger, err := strconv.ParseFloat(*c.TriggerValue, 64)
+	if err != nil {
+		return conditionParamError("clear_value requires a numeric trigger_value")
+	}
+	switch *c.TriggerOperator {
+	case model.CONDITION_TRIGGER_OPERATOR_GT, model.CONDITION_TRIGGER_OPERATOR_GTE:
+		if clear > trigger {
+			return conditionParamError("clear_value must not exceed trigger_value for > and >=")
+		}
+	case model.CONDITION_TRIGGER_OPERATOR_LT, model.CONDITION_TRIGGER_OPERATOR_LTE:
+		if clear < trigger {
+			return conditionParamError("clear_value must not be below trigger_value for < and <=")
+		}
+	default:
+		return conditionParamError("clear_value only supports >, >=, < and <=")
+	}
+	return nil
+}
+
+func conditionParamError(msg string) error {
+	return errcode.WithData(errcode.CodeParamError, map[string]interface{}{"message": msg})
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"project/initialize"
+	model "project/internal/model"
+)
+
+func TestAdvanceConditionState_HoldDuration(t *testing.T) {
+	opt := conditionStateOptions{Hold: 5 * time.Minute}
+	var st initialize.ConditionState
+	base := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+
+	if advanceConditionState(&st, opt, true, false, true, base) {
+		t.Fatal("should not fire on the first sample")
+	}
+	if advanceConditionState(&st, opt, true, false, true, base.Add(3*time.Minute)) {
+		t.Fatal("should not fire before the hold duration")
+	}
+	// 中途跌回阈值内，重新计时
+	advanceConditionState(&st, opt, false, true, true, base.Add(4*time.Minute))
+	if advanceConditionState(&st, opt, true, false, true, base.Add(6*time.Minute)) {
+		t.Fatal("hold duration should restart after the condition breaks")
+	}
+	if !advanceConditionState(&st, opt, true, false, true, base.Add(11*time.Minute)) {
+		t.Fatal("should fire once held for the full duration")
+	}
+}
+
+func TestAdvanceConditionState_NOfM(t *testing.T) {
+	opt := conditionStateOptions{Window: 5, Hits: 3}
+	var st initialize.ConditionState
+	now := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+
+	results := []bool{true, false, true, false, true}
+	var got bool
+	for i, r := range results {
+		got = advanceConditionState(&st, opt, r, !r, true, now.Add(time.Duration(i)*time.Second))
+	}
+	if !got {
+		t.Fatal("expected fire on 3 of 5 samples")
+	}
+	// 单次不成立但仍有 3/5 成立：保持触发
+	advanceConditionState(&st, opt, true, false, true, now.Add(10*time.Second))
+	if !advanceConditionState(&st, opt, false, true, true, now.Add(11*time.Second)) {
+		t.Fatal("expected still active with 3 of last 5")
+	}
+	// 非新上报不计入窗口
+	if !advanceConditionState(&st, opt, false, true, false, now.Add(12*time.Second)) {
+		t.Fatal("stale values must not shift the window")
+	}
+	if advanceConditionState(&st, opt, false, true, true, now.Add(13*time.Second)) {
+		t.Fatal("expected recovery when hits drop below N")
+	}
+}
+
+func TestAdvanceConditionState_Hysteresis(t *testing.T) {
+	opt := conditionStateOptions{HasClear: true}
+	var st initialize.ConditionState
+	now := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+	check := func(v float64) bool {
+		raw := v > 60
+		return advanceConditionState(&st, opt, raw, conditionClearCheck(">", "55", v), true, now)
+	}
+	if !check(61) {
+		t.Fatal("expected fire above trigger threshold")
+	}
+	if !check(58) {
+		t.Fatal("expected still active between clear and trigger threshold")
+	}
+	if check(54) {
+		t.Fatal("expected recovery below clear threshold")
+	}
+	if check(58) {
+		t.Fatal("expected no re-fire until trigger threshold is crossed again")
+	}
+}
+
+func TestValidateConditionGroups(t *testing.T) {
+	op, val, clear := ">", "60", "65"
+	window, hits := int32(3), int32(5)
+	groups := [][]model.Condition{{{TriggerConditionsType: "10", TriggerOperator: &op, TriggerValue: &val, ClearValue: &clear}}}
+	if validateConditionGroups(groups) == nil {
+		t.Fatal("clear_value above trigger value should be rejected for >")
+	}
+	groups = [][]model.Condition{{{TriggerConditionsType: "11", SampleWindow: &window, SampleHits: &hits}}}
+	if validateConditionGroups(groups) == nil {
+		t.Fatal("sample_hits above sample_window should be rejected")
+	}
+	clear = "55"
+	groups = [][]model.Condition{{{TriggerConditionsType: "10", TriggerOperator: &op, TriggerValue: &val, ClearValue: &clear}}}
+	if err := validateConditionGroups(groups); err != nil {
+		t.Fatalf("expected valid hysteresis config, got %v", err)
+	}
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"project/initialize"
+	model "project/internal/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 窗口统计函数
+const (
+	WINDOW_FUNC_RATE   = "RATE"   // 变化率（每分钟，最小二乘斜率）
+	WINDOW_FUNC_DELTA  = "DELTA"  // 差值（最新值 - 窗口内最早值）
+	WINDOW_FUNC_AVG    = "AVG"    // 移动平均
+	WINDOW_FUNC_MIN    = "MIN"    // 最小值
+	WINDOW_FUNC_MAX    = "MAX"    // 最大值
+	WINDOW_FUNC_STDDEV = "STDDEV" // 标准差
+)
+
+var windowFuncNames = map[string]string{
+	WINDOW_FUNC_RATE:   "变化率/分钟",
+	WINDOW_FUNC_DELTA:  "差值",
+	WINDOW_FUNC_AVG:    "均值",
+	WINDOW_FUNC_MIN:    "最小值",
+	WINDOW_FUNC_MAX:    "最大值",
+	WINDOW_FUNC_STDDEV: "标准差",
+}
+
+// conditionWindowed 条件是否按窗口统计值比较
+func conditionWindowed(cond model.DeviceTriggerCondition) bool {
+	return cond.WindowFunc != nil && *cond.WindowFunc != "" && cond.WindowSeconds != nil && *cond.WindowSeconds > 0
+}
+
+// windowStat 计算窗口统计值，采样点不足时返回 false
+func windowStat(fn string, samples []initialize.WindowSample) (float64, bool) {
+	n := len(samples)
+	if n == 0 {
+		return 0, false
+	}
+	switch fn {
+	case WINDOW_FUNC_AVG:
+		var sum float64
+		for _, s := range samples {
+			sum += s.Value
+		}
+		return sum / float64(n), true
+	case WINDOW_FUNC_MIN:
+		v := samples[0].Value
+		for _, s := range samples[1:] {
+			v = math.Min(v, s.Value)
+		}
+		return v, true
+	case WINDOW_FUNC_MAX:
+		v := samples[0].Value
+		for _, s := range samples[1:] {
+			v = math.Max(v, s.Value)
+		}
+		return v, true
+	case WINDOW_FUNC_DELTA:
+		if n < 2 {
+			return 0, false
+		}
+		return samples[n-1].Value - samples[0].Value, true
+	case WINDOW_FUNC_STDDEV:
+		if n < 2 {
+			return 0, false
+		}
+		var sum, sq float64
+		for _, s := range samples {
+			sum += s.Value
+		}
+		mean := sum / float64(n)
+		for _, s := range samples {
+			sq += (s.Value - mean) * (s.Value - mean)
+		}
+		return math.Sqrt(sq / float64(n)), true
+	case WINDOW_FUNC_RATE:
+		if n < 2 || samples[n-1].Ts == samples[0].Ts {
+			return 0, false
+		}
+		// 以分钟为横轴做最小二乘拟合，抗单点抖动
+		var sx, sy, sxx, sxy float64
+		t0 := samples[0].Ts
+		for _, s := range samples {
+			x := float64(s.Ts-t0) / float64(time.Minute.Milliseconds())
+			sx += x
+			sy += s.Value
+			sxx += x * x
+			sxy += x * s.Value
+		}
+		den := float64(n)*sxx - sx*sx
+		if den == 0 {
+			return 0, false
+		}
+		return (float64(n)*sxy - sx*sy) / den, true
+	}
+	return 0, false
+}
+
+// conditionWindowValue 记录本次上报的数值并返回窗口统计值
+// 本次未上报该参数时只读取窗口，不重复计入最新值
+func (a *Automate) conditionWindowValue(cond model.DeviceTriggerCondition, deviceId string, actualValue interface{}) (interface{}, bool) {
+	var sample *initialize.WindowSample
+	now := a.now()
+	if cond.TriggerParam != nil {
+		if _, fresh := a.formExt.TriggerValues[*cond.TriggerParam]; fresh {
+			if v, ok := conditionFloatValue(actualValue); ok {
+				sample = &initialize.WindowSample{Ts: now.UnixMilli(), Value: v}
+			}
+		}
+	}
+	window := time.Duration(*cond.WindowSeconds) * time.Second
+	var (
+		samples []initialize.WindowSample
+		err     error
+	)
+	if a.explain != nil {
+		samples, err = a.explainWindowPush(cond.ID, deviceId, window, sample, now)
+	} else {
+		samples, err = initialize.NewConditionWindowCache().Push(cond.ID, deviceId, window, sample, now)
+	}
+	if err != nil {
+		logrus.Error("保存窗口采样失败", err)
+	}
+	stat, ok := windowStat(*cond.WindowFunc, samples)
+	logrus.Debugf("窗口统计: func:%s, window:%s, samples:%d, 结果:%v, ok:%t", *cond.WindowFunc, window, len(samples), stat, ok)
+	if !ok {
+		return nil, false
+	}
+	return stat, true
+}
+
+// windowConditionLabel 条件描述中的统计函数说明，如 “变化率/分钟(600s)”
+func windowConditionLabel(cond model.DeviceTriggerCondition) string {
+	return fmt.Sprintf("%s(%ds)", windowFuncNames[*cond.WindowFunc], *cond.WindowSeconds)
+}
+
+// validateConditionWindow 校验窗口统计配置：仅适用于遥测数值比较
+func validateConditionWindow(c model.Condition) error {
+	hasFunc := c.WindowFunc != nil && *c.WindowFunc != ""
+	hasSeconds := c.WindowSeconds != nil && *c.WindowSeconds > 0
+	if !hasFunc && !hasSeconds {
+		return nil
+	}
+	if !hasFunc || !hasSeconds {
+		return conditionParamError("window_func and window_seconds must be set together")
+	}
+	if _, ok := windowFuncNames[*c.WindowFunc]; !ok {
+		return conditionParamError("unsupported window_func")
+	}
+	if *c.WindowSeconds < 10 {
+		return conditionParamError("window_seconds must be at least 10")
+	}
+	if c.TriggerConditionsType != model.DEVICE_TRIGGER_CONDITION_TYPE_ONE && c.TriggerConditionsType != model.DEVICE_TRIGGER_CONDITION_TYPE_MULTIPLE {
+		return conditionParamError("window statistics only apply to device conditions")
+	}
+	if c.TriggerParamType == nil {
+		return conditionParamError("window statistics only apply to telemetry conditions")
+	}
+	switch strings.ToUpper(*c.TriggerParamType) {
+	case model.TRIGGER_PARAM_TYPE_TEL, model.TRIGGER_PARAM_TYPE_TELEMETRY:
+	default:
+		return conditionParamError("window statistics only apply to telemetry conditions")
+	}
+	if c.TriggerOperator == nil || c.TriggerValue == nil {
+		return conditionParamError("window statistics require trigger_operator and trigger_value")
+	}
+	switch *c.TriggerOperator {
+	case model.CONDITION_TRIGGER_OPERATOR_GT, model.CONDITION_TRIGGER_OPERATOR_GTE,
+		model.CONDITION_TRIGGER_OPERATOR_LT, model.CONDITION_TRIGGER_OPERATOR_LTE:
+		if _, err := strconv.ParseFloat(*c.TriggerValue, 64); err != nil {
+			return conditionParamError("window statistics require a numeric trigger_value")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"project/initialize"
+	model "project/internal/model"
+)
+
+func windowSamples(step time.Duration, values ...float64) []initialize.WindowSample {
+	base := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+	out := make([]initialize.WindowSample, 0, len(values))
+	for i, v := range values {
+		out = append(out, initialize.WindowSample{Ts: base.Add(time.Duration(i) * step).UnixMilli(), Value: v})
+	}
+	return out
+}
+
+func TestWindowStat(t *testing.T) {
+	// 每 30 秒上升 1.5，即 3/分钟
+	rising := windowSamples(30*time.Second, 20, 21.5, 23, 24.5)
+	if v, ok := windowStat(WINDOW_FUNC_RATE, rising); !ok || math.Abs(v-3) > 1e-9 {
+		t.Fatalf("rate = %v, %t; want 3", v, ok)
+	}
+	if v, _ := windowStat(WINDOW_FUNC_DELTA, rising); math.Abs(v-4.5) > 1e-9 {
+		t.Fatalf("delta = %v; want 4.5", v)
+	}
+
+	s := windowSamples(time.Minute, 2, 4, 4, 4, 5, 5, 7, 9)
+	if v, _ := windowStat(WINDOW_FUNC_AVG, s); v != 5 {
+		t.Fatalf("avg = %v; want 5", v)
+	}
+	if v, _ := windowStat(WINDOW_FUNC_MIN, s); v != 2 {
+		t.Fatalf("min = %v; want 2", v)
+	}
+	if v, _ := windowStat(WINDOW_FUNC_MAX, s); v != 9 {
+		t.Fatalf("max = %v; want 9", v)
+	}
+	if v, _ := windowStat(WINDOW_FUNC_STDDEV, s); math.Abs(v-2) > 1e-9 {
+		t.Fatalf("stddev = %v; want 2", v)
+	}
+
+	one := windowSamples(time.Minute, 1)
+	for _, fn := range []string{WINDOW_FUNC_RATE, WINDOW_FUNC_DELTA, WINDOW_FUNC_STDDEV} {
+		if _, ok := windowStat(fn, one); ok {
+			t.Fatalf("%s should need at least two samples", fn)
+		}
+	}
+	if _, ok := windowStat(WINDOW_FUNC_AVG, nil); ok {
+		t.Fatal("empty window should not produce a value")
+	}
+}
+
+func TestSampleRing(t *testing.T) {
+	r := initialize.NewSampleRing(3)
+	for i, v := range []float64{1, 2, 3, 4} {
+		r.Push(initialize.WindowSample{Ts: int64(i * 1000), Value: v})
+	}
+	got := r.Samples()
+	if len(got) != 3 || got[0].Value != 2 || got[2].Value != 4 {
+		t.Fatalf("ring should keep the newest 3 samples in order, got %v", got)
+	}
+	r.TrimBefore(2500)
+	got = r.Samples()
+	if len(got) != 1 || got[0].Value != 4 {
+		t.Fatalf("trim should drop samples before the window, got %v", got)
+	}
+}
+
+func TestValidateConditionWindow(t *testing.T) {
+	str := func(s string) *string { return &s }
+	i32 := func(v int32) *int32 { return &v }
+	base := model.Condition{
+		TriggerConditionsType: model.DEVICE_TRIGGER_CONDITION_TYPE_ONE,
+		TriggerParamType:      str(model.TRIGGER_PARAM_TYPE_TEL),
+		TriggerOperator:       str(model.CONDITION_TRIGGER_OPERATOR_GT),
+		TriggerValue:          str("2"),
+		WindowFunc:            str(WINDOW_FUNC_RATE),
+		WindowSeconds:         i32(300),
+	}
+	if err := validateConditionWindow(base); err != nil {
+		t.Fatalf("valid rate condition rejected: %v", err)
+	}
+
+	c := base
+	c.WindowSeconds = nil
+	if validateConditionWindow(c) == nil {
+		t.Fatal("window_func without window_seconds should be rejected")
+	}
+	c = base
+	c.TriggerParamType = str(model.TRIGGER_PARAM_TYPE_ATTR)
+	if validateConditionWindow(c) == nil {
+		t.Fatal("window statistics on attributes should be rejected")
+	}
+	c = base
+	c.TriggerValue = str("abc")
+	if validateConditionWindow(c) == nil {
+		t.Fatal("non-numeric threshold should be rejected")
+	}
+}
@@ -57,7 +57,6 @@ func ConditionAfterAlarm(ok bool, conditions initialize.DTConditions, deviceId s
 		device_ids          []string
 		group_id            string
 		scene_automation_id string
-		alarmCache          = initialize.NewAlarmCache()
 	)
 	for _, cond := range conditions {
 		group_id = cond.GroupID
@@ -70,6 +69,13 @@ func ConditionAfterAlarm(ok bool, conditions initialize.DTConditions, deviceId s
 		}
 	}
 	logrus.Debug("ConditionAfterAlarm:", group_id, device_ids, ok, contents)
+	return conditionAfterAlarmWithDevices(ok, group_id, scene_automation_id, device_ids, contents)
+}
+
+// conditionAfterAlarmWithDevices
+// @description 条件判断后告警缓存处理（指定告警范围设备，聚合条件使用分组/模板内的设备）
+func conditionAfterAlarmWithDevices(ok bool, group_id, scene_automation_id string, device_ids, contents []string) error {
+	alarmCache := initialize.NewAlarmCache()
 	if len(device_ids) == 0 {
 		return nil
 	}
@@ -0,0 +1,452 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"project/initialize"
+	"project/internal/dal"
+	model "project/internal/model"
+	"project/pkg/errcode"
+	global "project/pkg/global"
+	"project/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	replayMaxRange   = 7 * 24 * time.Hour // 回放最长时间范围
+	replayMaxRows    = 50000              // 回放最多读取的遥测数据行数
+	replayMaxFirings = 500                // 回放最多返回的触发记录数
+)
+
+// automateExplain 试运行/回放上下文：条件状态与窗口采样只保存在内存中，不影响线上状态
+type automateExplain struct {
+	at     time.Time // 评估时间，零值表示当前时间
+	replay bool      // 回放：不读取设备当前数据与 Redis 中的条件状态
+	trace  bool      // 是否记录条件判断过程
+	values map[string]interface{}
+	states map[string]initialize.ConditionState
+	rings  map[string]*initialize.SampleRing
+	traces []model.SceneAutomationConditionTrace
+}
+
+func newAutomateExplain(replay, trace bool) *automateExplain {
+	return &automateExplain{
+		replay: replay,
+		trace:  trace,
+		values: make(map[string]interface{}),
+		states: make(map[string]initialize.ConditionState),
+		rings:  make(map[string]*initialize.SampleRing),
+	}
+}
+
+func explainKey(conditionId, deviceId string) string {
+	return conditionId + "_" + deviceId
+}
+
+// now 自动化评估使用的当前时间（回放时为历史上报时间）
+func (a *Automate) now() time.Time {
+	if a.explain != nil && !a.explain.at.IsZero() {
+		return a.explain.at
+	}
+	return time.Now()
+}
+
+// conditionState 读取条件状态；试运行以线上状态为起点，回放从空状态开始
+func (a *Automate) conditionState(conditionId, deviceId string) (initialize.ConditionState, error) {
+	if a.explain == nil {
+		return initialize.NewConditionStateCache().Get(conditionId, deviceId)
+	}
+	if st, ok := a.explain.states[explainKey(conditionId, deviceId)]; ok {
+		return st, nil
+	}
+	if a.explain.replay {
+		return initialize.ConditionState{}, nil
+	}
+	return initialize.NewConditionStateCache().Get(conditionId, deviceId)
+}
+
+// explainWindowPush 试运行/回放的窗口采样，只写入内存
+func (a *Automate) explainWindowPush(conditionId, deviceId string, window time.Duration, sample *initialize.WindowSample, now time.Time) ([]initialize.WindowSample, error) {
+	key := explainKey(conditionId, deviceId)
+	ring, ok := a.explain.rings[key]
+	if !ok {
+		ring = initialize.NewSampleRing(512)
+		if !a.explain.replay {
+			saved, err := initialize.NewConditionWindowCache().Push(conditionId, deviceId, window, nil, now)
+			if err != nil {
+				return nil, err
+			}
+			for _, s := range saved {
+				ring.Push(s)
+			}
+		}
+		a.explain.rings[key] = ring
+	}
+	ring.TrimBefore(now.Add(-window).UnixMilli())
+	if sample != nil {
+		ring.Push(*sample)
+	}
+	return ring.Samples(), nil
+}
+
+func (a *Automate) addTrace(t model.SceneAutomationConditionTrace) {
+	if a.explain == nil || !a.explain.trace {
+		return
+	}
+	a.explain.traces = append(a.explain.traces, t)
+}
+
+func newConditionTrace(cond model.DeviceTriggerCondition) model.SceneAutomationConditionTrace {
+	t := model.SceneAutomationConditionTrace{
+		ConditionID:   cond.ID,
+		ConditionType: cond.TriggerConditionType,
+		ExpectedValue: cond.TriggerValue,
+	}
+	if cond.TriggerParamType != nil {
+		t.ParamType = *cond.TriggerParamType
+	}
+	if cond.TriggerParam != nil {
+		t.Param = *cond.TriggerParam
+	}
+	return t
+}
+
+func (a *Automate) traceSkipped(cond model.DeviceTriggerCondition) {
+	t := newConditionTrace(cond)
+	t.Skipped = true
+	t.Note = "前序条件不成立，短路跳过"
+	a.addTrace(t)
+}
+
+func (a *Automate) traceTime(cond model.DeviceTriggerCondition, ok bool) {
+	if a.explain == nil {
+		return
+	}
+	t := newConditionTrace(cond)
+	zone := sceneAutomationZone(cond.SceneAutomationID)
+	loc := zone.Loc
+	if loc == nil {
+		loc = time.UTC
+	}
+	t.ActualValue = a.now().In(loc).Format("2006-01-02 15:04:05 Mon MST")
+	t.Operator = "in"
+	t.RawResult = ok
+	t.Result = ok
+	if isHolidayIn(zone, a.now()) {
+		t.Note = "节假日"
+	}
+	a.addTrace(t)
+}
+
+func (a *Automate) traceDevice(cond model.DeviceTriggerCondition, actual interface{}, operator, expected string, raw, ok bool, note string) {
+	if a.explain == nil {
+		return
+	}
+	t := newConditionTrace(cond)
+	t.ActualValue = actual
+	t.Operator = operator
+	t.ExpectedValue = expected
+	t.RawResult = raw
+	t.Result = ok
+	t.Note = note
+	if note == "" {
+		switch {
+		case conditionWindowed(cond):
+			t.Note = "窗口统计: " + windowConditionLabel(cond)
+		case conditionStateful(cond) && raw != ok:
+			t.Note = "持续时间/采样/回差状态未满足或未恢复"
+		}
+	}
+	a.addTrace(t)
+}
+
+// loadExplainScene 校验场景归属并按条件组整理条件
+func loadExplainScene(ctx context.Context, id string, claims *utils.UserClaims) (*model.SceneAutomation, []initialize.DTConditions, error) {
+	var scenes []model.SceneAutomation
+	if err := global.DB.WithContext(ctx).
+		Where("id = ? AND tenant_id = ?", id, claims.TenantID).
+		Limit(1).
+		Find(&scenes).Error; err != nil {
+		return nil, nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	if len(scenes) == 0 {
+		return nil, nil, errcode.WithData(errcode.CodeNotFound, map[string]interface{}{"message": "scene automation not found"})
+	}
+	var conds []model.DeviceTriggerCondition
+	if err := global.DB.WithContext(ctx).
+		Where("scene_automation_id = ?", id).
+		Order("group_id, id").
+		Find(&conds).Error; err != nil {
+		return nil, nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	var (
+		groups []initialize.DTConditions
+		index  = make(map[string]int)
+	)
+	for _, c := range conds {
+		i, ok := index[c.GroupID]
+		if !ok {
+			i = len(groups)
+			index[c.GroupID] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], c)
+	}
+	return &scenes[0], groups, nil
+}
+
+// loadExplainDevice 单类设备条件需要指定设备
+func loadExplainDevice(deviceID string, groups []initialize.DTConditions, tenantID string) (*model.Device, error) {
+	if deviceID == "" {
+		for _, g := range groups {
+			for _, c := range g {
+				if c.TriggerConditionType == model.DEVICE_TRIGGER_CONDITION_TYPE_MULTIPLE {
+					return nil, conditionParamError("device_id is required for device class conditions")
+				}
+			}
+		}
+		return &model.Device{}, nil
+	}
+	device, err := dal.GetDeviceByID(deviceID)
+	if err != nil || device == nil || device.TenantID != tenantID {
+		return nil, errcode.WithData(errcode.CodeNotFound, map[string]interface{}{"message": "device not found"})
+	}
+	if device.Name == nil {
+		name := device.ID
+		device.Name = &name
+	}
+	return device, nil
+}
+
+// Explain 试运行：按设备当前数据或假设上报数据评估场景条件，返回逐条件判断过程，不执行动作、不写入条件状态
+func (*SceneAutomation) Explain(ctx context.Context, id string, req *model.SceneAutomationExplainReq, claims *utils.UserClaims) (*model.SceneAutomationExplainResp, error) {
+	scene, groups, err := loadExplainScene(ctx, id, claims)
+	if err != nil {
+		return nil, err
+	}
+	device, err := loadExplainDevice(req.DeviceID, groups, claims.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Automate{device: device, explain: newAutomateExplain(false, true)}
+	a.formExt = AutomateFromExt{TriggerParamType: model.TRIGGER_PARAM_TYPE_TEL, TriggerValues: req.Telemetry}
+	for k := range req.Telemetry {
+		a.formExt.TriggerParam = append(a.formExt.TriggerParam, k)
+	}
+
+	resp := &model.SceneAutomationExplainResp{
+		SceneAutomationID: scene.ID,
+		Name:              scene.Name,
+		Enabled:           scene.Enabled,
+		DeviceID:          req.DeviceID,
+		EvaluatedAt:       a.now().In(time.Local).Format("2006-01-02 15:04:05"),
+		Groups:            make([]model.SceneAutomationGroupTrace, 0, len(groups)),
+	}
+	for _, g := range groups {
+		a.explain.traces = nil
+		var ok bool
+		if hasAggregateCondition(g) {
+			ok = a.explainAggregateGroup(g)
+		} else {
+			ok, _ = a.AutomateConditionCheckWithGroup(g, device.ID)
+		}
+		gt := model.SceneAutomationGroupTrace{GroupID: g[0].GroupID, Result: ok, Conditions: a.explain.traces}
+		if !ok {
+			for _, t := range gt.Conditions {
+				if !t.Result && !t.Skipped {
+					gt.ShortCircuitConditionID = t.ConditionID
+					break
+				}
+			}
+		}
+		resp.WouldFire = resp.WouldFire || ok
+		resp.Groups = append(resp.Groups, gt)
+	}
+	return resp, nil
+}
+
+func hasAggregateCondition(g initialize.DTConditions) bool {
+	for _, c := range g {
+		if conditionAggregated(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// explainAggregateGroup 聚合条件组试运行（聚合条件本身只读，不推进触发状态）
+func (a *Automate) explainAggregateGroup(g initialize.DTConditions) bool {
+	ok := true
+	for _, cond := range g {
+		if !ok {
+			a.traceSkipped(cond)
+			continue
+		}
+		if !conditionAggregated(cond) {
+			ok = a.automateConditionCheckWithTime(cond)
+			a.traceTime(cond, ok)
+			continue
+		}
+		condOk, devices, content := GroupApp.AutomateAggregate.checkCondition(cond)
+		t := newConditionTrace(cond)
+		t.ActualValue = content
+		if cond.TriggerOperator != nil {
+			t.Operator = *cond.TriggerOperator
+		}
+		t.RawResult = condOk
+		t.Result = condOk
+		t.Note = fmt.Sprintf("聚合条件由定时任务评估，范围设备数: %d", len(devices))
+		a.addTrace(t)
+		ok = condOk
+	}
+	return ok
+}
+
+// Replay 历史回放：按时间顺序重放设备在时间范围内的遥测上报，报告场景条件在哪些上报时刻会成立
+// 只回放指定设备的遥测数据；限流与动作不参与回放
+func (*SceneAutomation) Replay(ctx context.Context, id string, req *model.SceneAutomationReplayReq, claims *utils.UserClaims) (*model.SceneAutomationReplayResp, error) {
+	if req.EndTime <= req.StartTime {
+		return nil, conditionParamError("end_time must be after start_time")
+	}
+	if time.Duration(req.EndTime-req.StartTime)*time.Millisecond > replayMaxRange {
+		return nil, conditionParamError("replay range must not exceed 7 days")
+	}
+	_, groups, err := loadExplainScene(ctx, id, claims)
+	if err != nil {
+		return nil, err
+	}
+	device, err := loadExplainDevice(req.DeviceID, groups, claims.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 回放的遥测 key：本设备参与的遥测条件
+	keySet := make(map[string]bool)
+	var deviceGroups []initialize.DTConditions
+	for _, g := range groups {
+		if hasAggregateCondition(g) {
+			continue
+		}
+		deviceGroups = append(deviceGroups, g)
+		for _, c := range g {
+			if c.TriggerParamType == nil || c.TriggerParam == nil {
+				continue
+			}
+			pt := strings.ToUpper(*c.TriggerParamType)
+			if pt != model.TRIGGER_PARAM_TYPE_TEL && pt != model.TRIGGER_PARAM_TYPE_TELEMETRY {
+				continue
+			}
+			if c.TriggerConditionType == model.DEVICE_TRIGGER_CONDITION_TYPE_ONE && (c.TriggerSource == nil || *c.TriggerSource != device.ID) {
+				continue
+			}
+			keySet[*c.TriggerParam] = true
+		}
+	}
+	resp := &model.SceneAutomationReplayResp{
+		SceneAutomationID: id,
+		DeviceID:          device.ID,
+		Firings:           []model.SceneAutomationReplayFiring{},
+	}
+	if len(keySet) == 0 {
+		return resp, nil
+	}
+	keys := make([]string, 0, len(keySet))
+	for k := range keySet {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var rows []model.TelemetryData
+	if err := global.DB.WithContext(ctx).
+		Where("device_id = ? AND key IN ? AND ts >= ? AND ts <= ?", device.ID, keys, req.StartTime, req.EndTime).
+		Order("ts ASC").
+		Limit(replayMaxRows + 1).
+		Find(&rows).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	if len(rows) > replayMaxRows {
+		rows = rows[:replayMaxRows]
+		resp.Truncated = true
+	}
+
+	a := &Automate{device: device, explain: newAutomateExplain(true, false)}
+	for i := 0; i < len(rows); {
+		// 同一时间戳的数据视为一次上报
+		ts := rows[i].T
+		values := make(map[string]interface{})
+		var params []string
+		for ; i < len(rows) && rows[i].T == ts; i++ {
+			if v := telemetryDataValue(rows[i]); v != nil {
+				values[rows[i].Key] = v
+				params = append(params, rows[i].Key)
+			}
+		}
+		resp.Reports++
+		a.explain.at = time.UnixMilli(ts)
+		a.formExt = AutomateFromExt{TriggerParamType: model.TRIGGER_PARAM_TYPE_TEL, TriggerParam: params, TriggerValues: values}
+
+		// 与设备上报一致：只有本次上报涉及场景条件参数时才评估
+		if replayTouches(deviceGroups, values) {
+			resp.Evaluations++
+			var firing model.SceneAutomationReplayFiring
+			for _, g := range deviceGroups {
+				ok, contents := a.AutomateConditionCheckWithGroup(g, device.ID)
+				if ok {
+					firing.GroupIDs = append(firing.GroupIDs, g[0].GroupID)
+					firing.Contents = append(firing.Contents, contents...)
+				}
+			}
+			if len(firing.GroupIDs) > 0 {
+				if len(resp.Firings) < replayMaxFirings {
+					firing.Ts = ts
+					firing.Time = time.UnixMilli(ts).In(time.Local).Format("2006-01-02 15:04:05")
+					resp.Firings = append(resp.Firings, firing)
+				} else {
+					resp.Truncated = true
+				}
+			}
+		}
+		for k, v := range values {
+			a.explain.values[k] = v
+		}
+	}
+	logrus.Debugf("场景回放 %s: 上报 %d 次, 评估 %d 次, 触发 %d 次", id, resp.Reports, resp.Evaluations, len(resp.Firings))
+	return resp, nil
+}
+
+// replayTouches 本次上报是否包含场景中遥测条件的参数
+func replayTouches(groups []initialize.DTConditions, values map[string]interface{}) bool {
+	for _, g := range groups {
+		for _, c := range g {
+			if c.TriggerParamType == nil || c.TriggerParam == nil {
+				continue
+			}
+			pt := strings.ToUpper(*c.TriggerParamType)
+			if pt != model.TRIGGER_PARAM_TYPE_TEL && pt != model.TRIGGER_PARAM_TYPE_TELEMETRY {
+				continue
+			}
+			if _, ok := values[*c.TriggerParam]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func telemetryDataValue(d model.TelemetryData) interface{} {
+	switch {
+	case d.NumberV != nil:
+		return *d.NumberV
+	case d.StringV != nil:
+		return *d.StringV
+	case d.BoolV != nil:
+		return *d.BoolV
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"project/initialize"
+	model "project/internal/model"
+)
+
+func TestExplainWindowReplay(t *testing.T) {
+	fn, secs, param := WINDOW_FUNC_DELTA, int32(120), "temp"
+	cond := model.DeviceTriggerCondition{ID: "c1", WindowFunc: &fn, WindowSeconds: &secs, TriggerParam: &param}
+	a := &Automate{explain: newAutomateExplain(true, false)}
+	base := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+
+	report := func(offset time.Duration, v float64) (interface{}, bool) {
+		a.explain.at = base.Add(offset)
+		a.formExt = AutomateFromExt{TriggerValues: map[string]interface{}{param: v}}
+		return a.conditionWindowValue(cond, "d1", v)
+	}
+	if _, ok := report(0, 20); ok {
+		t.Fatal("delta with one sample should be insufficient")
+	}
+	if v, ok := report(time.Minute, 23); !ok || v.(float64) != 3 {
+		t.Fatalf("delta = %v, %t; want 3", v, ok)
+	}
+	// 窗口滑过第一个采样点
+	if v, ok := report(150*time.Second, 24); !ok || v.(float64) != 1 {
+		t.Fatalf("delta = %v, %t; want 1", v, ok)
+	}
+
+	// 本次未上报该参数时只读取窗口
+	a.explain.at = base.Add(170 * time.Second)
+	a.formExt = AutomateFromExt{TriggerValues: map[string]interface{}{}}
+	if v, ok := a.conditionWindowValue(cond, "d1", 24.0); !ok || v.(float64) != 1 {
+		t.Fatalf("read-only delta = %v, %t; want 1", v, ok)
+	}
+	if n := len(a.explain.rings[explainKey("c1", "d1")].Samples()); n != 2 {
+		t.Fatalf("samples = %d; want 2", n)
+	}
+}
+
+func TestExplainReplayState(t *testing.T) {
+	a := &Automate{explain: newAutomateExplain(true, false)}
+	st, err := a.conditionState("c1", "d1")
+	if err != nil || st.Active || st.Since != 0 || len(st.Samples) != 0 {
+		t.Fatalf("replay state = %+v, %v; want empty", st, err)
+	}
+	at := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+	a.explain.at = at
+	if !a.now().Equal(at) {
+		t.Fatalf("now = %v; want %v", a.now(), at)
+	}
+}
+
+func TestReplayTouches(t *testing.T) {
+	tel, temp, attr := model.TRIGGER_PARAM_TYPE_TELEMETRY, "temp", "ATTRIBUTES"
+	groups := []initialize.DTConditions{{
+		{TriggerParamType: &attr, TriggerParam: &temp},
+		{TriggerParamType: &tel, TriggerParam: &temp},
+	}}
+	if !replayTouches(groups, map[string]interface{}{"temp": 1.0}) {
+		t.Fatal("expected telemetry param to be touched")
+	}
+	if replayTouches(groups, map[string]interface{}{"hum": 1.0}) {
+		t.Fatal("unrelated param should not touch the scene")
+	}
+
+	n, s := 1.5, "on"
+	if v := telemetryDataValue(model.TelemetryData{NumberV: &n}); v != 1.5 {
+		t.Fatalf("number value = %v", v)
+	}
+	if v := telemetryDataValue(model.TelemetryData{StringV: &s}); v != "on" {
+		t.Fatalf("string value = %v", v)
+	}
+	if v := telemetryDataValue(model.TelemetryData{}); v != nil {
+		t.Fatalf("empty value = %v", v)
+	}
+}
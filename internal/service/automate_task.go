@@ -45,6 +45,10 @@ func (*AutomateTask) TaskAutomationExecute(sceneAutomationId string) {
 	if GroupApp.CheckSceneAutomationHasClose(sceneAutomationId) {
 		return
 	}
+	// 节假日不执行定时触发
+	if isHolidayIn(sceneAutomationZone(sceneAutomationId), time.Now()) {
+		return
+	}
 	actions, err := dal.GetActionInfoListBySceneAutomationId([]string{sceneAutomationId})
 	if err != nil {
 		return
@@ -75,7 +79,7 @@ func (*AutomateTask) TaskAutomationExecute(sceneAutomationId string) {
 func (t *AutomateTask) PeriodicTaskExecute() error {
 
 	limit := viper.GetInt("automation_task_confg.periodic_task_limit")
-	result, err := dal.GetPeriodicTaskListWithLock(limit)
+	result, err := dal.GetPeriodicTaskListWithLock(limit, sceneAutomationLocation)
 	if err != nil {
 		return err
 	}
@@ -27,6 +27,8 @@ type Automate struct {
 	mu      sync.Mutex
 	// 用于跟踪在单次设备上报过程中已经执行过的场景ID
 	executedSceneIDs map[string]bool
+	// 试运行/回放时不为空：不写入条件状态，记录条件判断过程
+	explain *automateExplain
 }
 
 var conditionAfterDecoration = []ConditionAfterFunc{
@@ -371,11 +373,18 @@ func (a *Automate) AutomateConditionCheckWithGroup(conditions initialize.DTCondi
 		resultOk bool = true
 	)
 	for _, val := range conditions {
+		// 前面条件已不成立时，带状态的条件仍需评估，以持续累计持续时间/采样窗口
+		if !resultOk && !conditionStateful(val) {
+			a.traceSkipped(val)
+			continue
+		}
 		ok, content := a.AutomateConditionCheckWithGroupOne(val, deviceId)
+		if !resultOk {
+			continue
+		}
 		result = append(result, content)
 		if !ok {
 			resultOk = false
-			break
 		}
 	}
 
@@ -389,9 +398,14 @@ func (a *Automate) AutomateConditionCheckWithGroupOne(cond model.DeviceTriggerCo
 	logrus.Debug("条件type:", cond.TriggerConditionType)
 	switch cond.TriggerConditionType {
 	case model.DEVICE_TRIGGER_CONDITION_TYPE_TIME:
-		return a.automateConditionCheckWithTime(cond), ""
+		ok := a.automateConditionCheckWithTime(cond)
+		a.traceTime(cond, ok)
+		return ok, ""
 	case model.DEVICE_TRIGGER_CONDITION_TYPE_ONE, model.DEVICE_TRIGGER_CONDITION_TYPE_MULTIPLE:
 		return a.automateConditionCheckWithDevice(cond, deviceId)
+	case model.DEVICE_TRIGGER_CONDITION_TYPE_GROUP, model.DEVICE_TRIGGER_CONDITION_TYPE_TEMPLATE:
+		// 聚合条件由定时任务评估，不参与设备上报触发
+		return false, ""
 	default:
 		return true, ""
 	}
@@ -400,12 +414,21 @@ func (a *Automate) AutomateConditionCheckWithGroupOne(cond model.DeviceTriggerCo
 // @description automateConditionCheckWithTime 单个条件时间范围验证
 // @params cond model.DeviceTriggerCondition
 // @return bool
-func (*Automate) automateConditionCheckWithTime(cond model.DeviceTriggerCondition) bool {
+func (a *Automate) automateConditionCheckWithTime(cond model.DeviceTriggerCondition) bool {
 	logrus.Debug("时间范围对比开始... 条件:", cond.TriggerValue)
-	nowTime := time.Now().UTC()
+	nowTime := a.now().UTC()
 	if cond.TriggerValue == "" {
 		return false
 	}
+	// 场景/租户配置了时区或节假日日历时，按本地时间判断并排除节假日
+	zone := sceneAutomationZone(cond.SceneAutomationID)
+	if isHolidayIn(zone, nowTime) {
+		logrus.Debug("节假日，时间范围条件不成立")
+		return false
+	}
+	if zone.Loc != nil {
+		return timeRangeMatchIn(cond.TriggerValue, nowTime, zone.Loc)
+	}
 	valParts := strings.Split(cond.TriggerValue, "|")
 	if len(valParts) < 3 {
 		return false
@@ -453,6 +476,10 @@ func (a *Automate) getActualValue(deviceId string, key string, triggerParamType
 			return v, nil
 		}
 	}
+	// 回放时只使用回放过程中出现过的数据
+	if a.explain != nil && a.explain.replay {
+		return a.explain.values[key], nil
+	}
 	switch triggerParamType {
 	case model.TRIGGER_PARAM_TYPE_TEL:
 		return dal.GetCurrentTelemetryDataOneKeys(deviceId, key)
@@ -514,6 +541,19 @@ func (a *Automate) automateConditionCheckWithDevice(cond model.DeviceTriggerCond
 		triggerKey = *cond.TriggerParam
 		logrus.Debugf("GetCurrentTelemetryDataOneKeys:triggerOperator:%s, TriggerParam:%s, triggerValue:%v, actualValue:%v", triggerOperator, *cond.TriggerParam, triggerValue, actualValue)
 		dataValue := a.getTriggerParamsValue(triggerKey, dal.GetIdentifierNameTelemetry())
+		// 窗口统计条件：使用窗口内的变化率/均值等统计值比较
+		if conditionWindowed(cond) {
+			stat, ok := a.conditionWindowValue(cond, deviceId, actualValue)
+			result = fmt.Sprintf("设备(%s)%s [%s] %s: %v %s %v", deviceName, trigger, dataValue, windowConditionLabel(cond), stat, triggerOperator, triggerValue)
+			if !ok {
+				// 采样不足时条件不成立，但仍推进持续时间/采样状态
+				ok = conditionStateful(cond) && a.automateConditionCheckWithState(cond, deviceId, false, nil)
+				a.traceDevice(cond, nil, triggerOperator, triggerValue, false, ok, "窗口采样不足")
+				return ok, result
+			}
+			actualValue = stat
+			break
+		}
 		result = fmt.Sprintf("设备(%s)%s [%s]: %v %s %v", deviceName, trigger, dataValue, actualValue, triggerOperator, triggerValue)
 	case model.TRIGGER_PARAM_TYPE_ATTR, model.TRIGGER_PARAM_TYPE_ATTRIBUTES: // 属性
 		trigger = "属性"
@@ -541,12 +581,19 @@ func (a *Automate) automateConditionCheckWithDevice(cond model.DeviceTriggerCond
 		result = fmt.Sprintf("设备(%s)已%s", deviceName, trigger)
 		triggerOperator = "="
 		if strings.ToUpper(triggerValue) == "ALL" {
+			a.traceDevice(cond, actualValue, triggerOperator, triggerValue, true, true, "任意上下线")
 			return true, result
 		}
 	}
 	logrus.Debug("automateConditionCheckByOperator:设备条件验证参数...", triggerOperator, triggerValue, actualValue)
 	ok := a.automateConditionCheckByOperator(triggerOperator, triggerValue, actualValue)
 	logrus.Debugf("比较结果:%t", ok)
+	raw := ok
+	// 持续时间/N-M 采样/回差
+	if conditionStateful(cond) {
+		ok = a.automateConditionCheckWithState(cond, deviceId, ok, actualValue)
+	}
+	a.traceDevice(cond, actualValue, triggerOperator, triggerValue, raw, ok, "")
 	return ok, result
 }
 
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	model "project/internal/model"
+	"project/pkg/common"
+	"project/pkg/errcode"
+	"project/pkg/global"
+
+	"github.com/sirupsen/logrus"
+)
+
+// automateZone 场景联动的时区与节假日日历
+type automateZone struct {
+	Loc               *time.Location // 为空表示未配置时区（沿用原有行为）
+	HolidayCalendarID string
+}
+
+type automateZoneEntry struct {
+	zone     automateZone
+	expireAt time.Time
+}
+
+type holidayEntry struct {
+	dates    map[string]bool
+	expireAt time.Time
+}
+
+// 时区与节假日在每条上报的条件判断中都会用到，进程内短暂缓存
+const automateZoneTTL = time.Minute
+
+var (
+	automateZoneMu    sync.Mutex
+	automateZoneCache = make(map[string]automateZoneEntry)
+	holidayCache      = make(map[string]holidayEntry)
+)
+
+// invalidateAutomateZoneCache 场景/租户时区或节假日日历变更后清除缓存
+func invalidateAutomateZoneCache() {
+	automateZoneMu.Lock()
+	defer automateZoneMu.Unlock()
+	automateZoneCache = make(map[string]automateZoneEntry)
+	holidayCache = make(map[string]holidayEntry)
+}
+
+func getAutomateZoneCache(key string) (automateZone, bool) {
+	automateZoneMu.Lock()
+	defer automateZoneMu.Unlock()
+	e, ok := automateZoneCache[key]
+	if !ok || time.Now().After(e.expireAt) {
+		return automateZone{}, false
+	}
+	return e.zone, true
+}
+
+func setAutomateZoneCache(key string, zone automateZone) {
+	automateZoneMu.Lock()
+	defer automateZoneMu.Unlock()
+	automateZoneCache[key] = automateZoneEntry{zone: zone, expireAt: time.Now().Add(automateZoneTTL)}
+}
+
+// parseTimezone 校验 IANA 时区名称
+func parseTimezone(name string) (*time.Location, error) {
+	loc, err := time.LoadLocation(name)
+	if err != nil || strings.TrimSpace(name) == "" {
+		return nil, errcode.WithData(errcode.CodeParamError, map[string]interface{}{
+			"message":  "invalid IANA timezone",
+			"timezone": name,
+		})
+	}
+	return loc, nil
+}
+
+func loadLocationOrNil(name *string) *time.Location {
+	if name == nil || *name == "" {
+		return nil
+	}
+	loc, err := time.LoadLocation(*name)
+	if err != nil {
+		logrus.Warnf("无效的时区配置: %s", *name)
+		return nil
+	}
+	return loc
+}
+
+// tenantLocation 租户时区，未配置返回 nil
+func tenantLocation(ctx context.Context, tenantID string) *time.Location {
+	key := "tenant:" + tenantID
+	if zone, ok := getAutomateZoneCache(key); ok {
+		return zone.Loc
+	}
+	var timezone *string
+	if err := global.DB.WithContext(ctx).Table(model.TableNameTenantSetting).
+		Select("timezone").
+		Where("tenant_id = ?", tenantID).
+		Limit(1).
+		Scan(&timezone).Error; err != nil {
+		logrus.Error("查询租户时区失败", err)
+		return nil
+	}
+	loc := loadLocationOrNil(timezone)
+	setAutomateZoneCache(key, automateZone{Loc: loc})
+	return loc
+}
+
+// tenantLocationOrLocal 租户时区，未配置时使用服务器时区（日统计口径）
+func tenantLocationOrLocal(ctx context.Context, tenantID string) *time.Location {
+	if loc := tenantLocation(ctx, tenantID); loc != nil {
+		return loc
+	}
+	return time.Local
+}
+
+// sceneAutomationZone 场景联动时区：场景时区 > 租户时区 > 未配置
+func sceneAutomationZone(sceneAutomationID string) automateZone {
+	key := "scene:" + sceneAutomationID
+	if zone, ok := getAutomateZoneCache(key); ok {
+		return zone
+	}
+	ctx := context.Background()
+	var row struct {
+		TenantID          string  `gorm:"column:tenant_id"`
+		Timezone          *string `gorm:"column:timezone"`
+		HolidayCalendarID *string `gorm:"column:holiday_calendar_id"`
+	}
+	if err := global.DB.WithContext(ctx).Table(model.TableNameSceneAutomation).
+		Select("tenant_id, timezone, holiday_calendar_id").
+		Where("id = ?", sceneAutomationID).
+		Limit(1).
+		Scan(&row).Error; err != nil {
+		logrus.Error("查询场景联动时区失败", err)
+		return automateZone{}
+	}
+	zone := automateZone{Loc: loadLocationOrNil(row.Timezone)}
+	if zone.Loc == nil && row.TenantID != "" {
+		zone.Loc = tenantLocation(ctx, row.TenantID)
+	}
+	if row.HolidayCalendarID != nil {
+		zone.HolidayCalendarID = *row.HolidayCalendarID
+	}
+	setAutomateZoneCache(key, zone)
+	return zone
+}
+
+// sceneAutomationLocation 定时任务计算下次执行时间使用的时区
+func sceneAutomationLocation(sceneAutomationID string) *time.Location {
+	return sceneAutomationZone(sceneAutomationID).Loc
+}
+
+// isHolidayIn 判断 now 在 zone 时区下的本地日期是否属于节假日日历
+func isHolidayIn(zone automateZone, now time.Time) bool {
+	if zone.HolidayCalendarID == "" {
+		return false
+	}
+	loc := zone.Loc
+	if loc == nil {
+		loc = time.Local
+	}
+	dates := holidayDates(zone.HolidayCalendarID)
+	return dates[now.In(loc).Format("2006-01-02")]
+}
+
+func holidayDates(calendarID string) map[string]bool {
+	automateZoneMu.Lock()
+	e, ok := holidayCache[calendarID]
+	automateZoneMu.Unlock()
+	if ok && time.Now().Before(e.expireAt) {
+		return e.dates
+	}
+
+	var days []time.Time
+	if err := global.DB.Table(model.TableNameHolidayCalendarDate).
+		Where("calendar_id = ?", calendarID).
+		Pluck("holiday_date", &days).Error; err != nil {
+		logrus.Error("查询节假日失败", err)
+		return nil
+	}
+	dates := make(map[string]bool, len(days))
+	for _, d := range days {
+		dates[d.Format("2006-01-02")] = true
+	}
+	automateZoneMu.Lock()
+	holidayCache[calendarID] = holidayEntry{dates: dates, expireAt: time.Now().Add(automateZoneTTL)}
+	automateZoneMu.Unlock()
+	return dates
+}
+
+// timeRangeMatchIn 按时区 loc 判断时间范围条件（星期|开始|结束），时刻按 loc 的本地时间比较，
+// 开始时刻晚于结束时刻表示跨零点
+func timeRangeMatchIn(value string, now time.Time, loc *time.Location) bool {
+	parts := strings.Split(value, "|")
+	if len(parts) < 3 {
+		return false
+	}
+	local := now.In(loc)
+	if !strings.ContainsRune(parts[0], rune('0'+common.GetWeekDay(local))) {
+		return false
+	}
+	sh, sm, ss, err := common.ParseClock(parts[1])
+	if err != nil {
+		logrus.Error("时间格式不正确, 字符串", value)
+		return false
+	}
+	eh, em, es, err := common.ParseClock(parts[2])
+	if err != nil {
+		logrus.Error("时间格式不正确, 字符串", value)
+		return false
+	}
+	cur := local.Hour()*3600 + local.Minute()*60 + local.Second()
+	start := sh*3600 + sm*60 + ss
+	end := eh*3600 + em*60 + es
+	if start <= end {
+		return cur >= start && cur <= end
+	}
+	return cur >= start || cur <= end
+}
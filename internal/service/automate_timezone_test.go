@@ -0,0 +1,62 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"project/pkg/common"
+)
+
+func TestTimeRangeMatchIn_TenantZone(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("tzdata not available")
+	}
+	// 2025-03-10 周一，夏令时开始后一天；纽约 08:30 = UTC 12:30
+	now := time.Date(2025, 3, 10, 12, 30, 0, 0, time.UTC)
+	if !timeRangeMatchIn("1|08:00:00|09:00:00", now, ny) {
+		t.Fatal("08:30 local should match 08:00-09:00 on Monday")
+	}
+	if timeRangeMatchIn("2|08:00:00|09:00:00", now, ny) {
+		t.Fatal("weekday must be evaluated in the tenant zone")
+	}
+	// 冬令时同一本地时刻 = UTC 13:30
+	winter := time.Date(2025, 1, 6, 13, 30, 0, 0, time.UTC)
+	if !timeRangeMatchIn("1|08:00:00|09:00:00", winter, ny) {
+		t.Fatal("local wall-clock match should not shift with DST")
+	}
+}
+
+func TestTimeRangeMatchIn_Overnight(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	at := func(h int) time.Time { return time.Date(2025, 6, 2, h, 0, 0, 0, loc) } // 周一
+	if !timeRangeMatchIn("1|22:00:00|06:00:00", at(23), loc) {
+		t.Fatal("23:00 should match overnight range")
+	}
+	if !timeRangeMatchIn("1|22:00:00|06:00:00", at(5), loc) {
+		t.Fatal("05:00 should match overnight range")
+	}
+	if timeRangeMatchIn("1|22:00:00|06:00:00", at(12), loc) {
+		t.Fatal("12:00 should not match overnight range")
+	}
+}
+
+func TestGetSceneExecuteTimeIn_DayAcrossDST(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("tzdata not available")
+	}
+	// 2025-03-08 周六 09:00 本地，次日进入夏令时
+	now := time.Date(2025, 3, 8, 9, 0, 0, 0, ny)
+	next, err := common.GetSceneExecuteTimeIn("DAY", "08:00:00+08:00", ny, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	local := next.In(ny)
+	if local.Day() != 9 || local.Hour() != 8 || local.Minute() != 0 {
+		t.Fatalf("expected 2025-03-09 08:00 local, got %s", local)
+	}
+	if next.Sub(now) != 22*time.Hour {
+		t.Fatalf("expected 22h gap across spring-forward, got %s", next.Sub(now))
+	}
+}
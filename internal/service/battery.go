@@ -57,6 +57,8 @@ type batteryListRow struct {
 	Soh            *float64 `gorm:"column:soh"`
 	CurrentVersion *string  `gorm:"column:current_version"`
 	TransferStatus *string  `gorm:"column:transfer_status"`
+
+	LifecycleStatus *string `gorm:"column:lifecycle_status"`
 }
 
 // checkDeviceOrgAccess 检查用户是否有权访问设备（基于组织子树）
@@ -121,8 +123,21 @@ func (*Battery) GetDeviceAttributes(ctx context.Context, deviceID string, claims
 		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
 	}
 
+	// 设备参数权限：过滤无读取权限的属性
+	guard, err := GroupApp.DeviceParamPermission.guardFor(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+	templateID := ""
+	if !guard.bypass {
+		templateID = deviceTemplateID(device.ID)
+	}
+
 	var easyData []map[string]interface{}
 	for _, v := range data {
+		if key, _ := v["key"].(string); !guard.allowed(templateID, model.DeviceParamATTR, key, model.DeviceParamRead) {
+			continue
+		}
 		d := make(map[string]interface{})
 		d["id"] = v["id"]
 		d["device_id"] = deviceID
@@ -165,6 +180,11 @@ func (*Battery) PutDeviceAttributes(ctx context.Context, req model.AttributePutM
 		return err
 	}
 
+	// 设备参数权限：逐个校验下发的属性标识符
+	if err := GroupApp.DeviceParamPermission.CheckPutMessage(ctx, claims, req.DeviceID, model.DeviceParamATTR, req.Value); err != nil {
+		return err
+	}
+
 	// 复用现有属性下发逻辑（会写入 attribute_set_logs，并走 downlink）
 	return GroupApp.AttributeData.AttributePutMessage(ctx, claims.ID, &req, strconv.Itoa(constant.Manual))
 }
@@ -247,7 +267,8 @@ func (*Battery) GetBatteryList(ctx context.Context, req model.BatteryListReq, cl
 			dbat.soc AS soc,
 			dbat.soh AS soh,
 			d.current_version AS current_version,
-			dbat.transfer_status AS transfer_status
+			dbat.transfer_status AS transfer_status,
+			dbat.lifecycle_status AS lifecycle_status
 		`).
 		Joins(`LEFT JOIN device_batteries AS dbat ON dbat.device_id = d.id`).
 		Joins(`LEFT JOIN battery_models AS bm ON bm.id = dbat.battery_model_id`).
@@ -278,6 +299,9 @@ func (*Battery) GetBatteryList(ctx context.Context, req model.BatteryListReq, cl
 	if req.ActivationStatus != nil && *req.ActivationStatus != "" {
 		queryBuilder = queryBuilder.Where("dbat.activation_status = ?", *req.ActivationStatus)
 	}
+	if req.LifecycleStatus != nil && *req.LifecycleStatus != "" {
+		queryBuilder = queryBuilder.Where("dbat.lifecycle_status = ?", *req.LifecycleStatus)
+	}
 	if req.OwnerOrgID != nil && *req.OwnerOrgID != "" {
 		// 按指定组织筛选（厂家侧可用）
 		queryBuilder = queryBuilder.Where("dbat.owner_org_id = ?", *req.OwnerOrgID)
@@ -351,6 +375,7 @@ func (*Battery) GetBatteryList(ctx context.Context, req model.BatteryListReq, cl
 			Soh:              r.Soh,
 			CurrentVersion:   r.CurrentVersion,
 			TransferStatus:   r.TransferStatus,
+			LifecycleStatus:  r.LifecycleStatus,
 		}
 
 		if r.ProductionDate != nil {
@@ -401,7 +426,8 @@ func buildBatteryQuery(ctx context.Context, req model.BatteryExportReq, claims *
 			dbat.soc AS soc,
 			dbat.soh AS soh,
 			d.current_version AS current_version,
-			dbat.transfer_status AS transfer_status
+			dbat.transfer_status AS transfer_status,
+			dbat.lifecycle_status AS lifecycle_status
 		`).
 		Joins(`LEFT JOIN device_batteries AS dbat ON dbat.device_id = d.id`).
 		Joins(`LEFT JOIN battery_models AS bm ON bm.id = dbat.battery_model_id`).
@@ -428,6 +454,9 @@ func buildBatteryQuery(ctx context.Context, req model.BatteryExportReq, claims *
 	if req.ActivationStatus != nil && *req.ActivationStatus != "" {
 		queryBuilder = queryBuilder.Where("dbat.activation_status = ?", *req.ActivationStatus)
 	}
+	if req.LifecycleStatus != nil && *req.LifecycleStatus != "" {
+		queryBuilder = queryBuilder.Where("dbat.lifecycle_status = ?", *req.LifecycleStatus)
+	}
 	if req.DealerID != nil && *req.DealerID != "" {
 		queryBuilder = queryBuilder.Where("dbat.dealer_id = ?", *req.DealerID)
 	}
@@ -485,7 +514,7 @@ func (*Battery) ExportBatteryList(ctx context.Context, req model.BatteryExportRe
 	f.SetActiveSheet(index)
 
 	// 设置表头
-	headers := []string{"序列号", "设备名称", "电池型号", "出厂日期", "质保到期", "经销商", "终端用户", "用户电话", "激活状态", "激活时间", "在线状态", "SOC(%)", "SOH(%)", "固件版本", "流转状态"}
+	headers := []string{"序列号", "设备名称", "电池型号", "出厂日期", "质保到期", "经销商", "终端用户", "用户电话", "激活状态", "激活时间", "在线状态", "SOC(%)", "SOH(%)", "固件版本", "流转状态", "生命周期状态"}
 	for i, h := range headers {
 		cell := fmt.Sprintf("%c1", 'A'+i)
 		f.SetCellValue(sheetName, cell, h)
@@ -577,6 +606,11 @@ func (*Battery) ExportBatteryList(ctx context.Context, req model.BatteryExportRe
 		} else {
 			setCell("FACTORY")
 		}
+		if r.LifecycleStatus != nil {
+			setCell(*r.LifecycleStatus)
+		} else {
+			setCell("")
+		}
 	}
 
 	// 保存文件
@@ -783,24 +817,33 @@ func (*Battery) ImportBatteryList(ctx context.Context, filePath string, claims *
 			First()
 		if err != nil {
 			if err == gorm.ErrRecordNotFound {
-				// 创建新记录
-				now := time.Now()
-				newDeviceBattery := &model.DeviceBattery{
-					DeviceID:           device.ID,
-					BatteryModelID:     batteryModelID,
-					DealerID:           importDealerID,
-					ProductionDate:     productionDate,
-					WarrantyExpireDate: warrantyExpireDate,
-					BatchNumber:        batchNumber,
-					ActivationStatus:   StringPtr("INACTIVE"),
-					TransferStatus:     StringPtr("FACTORY"),
-					UpdatedAt:          &now,
+				// 创建新记录（入库建档）
+				fields := make(map[string]interface{})
+				if batteryModelID != nil {
+					fields["battery_model_id"] = *batteryModelID
+				}
+				if importDealerID != nil {
+					fields["dealer_id"] = *importDealerID
+				}
+				if productionDate != nil {
+					fields["production_date"] = *productionDate
 				}
-				if err := tx.Create(newDeviceBattery).Error; err != nil {
+				if warrantyExpireDate != nil {
+					fields["warranty_expire_date"] = *warrantyExpireDate
+				}
+				if batchNumber != nil {
+					fields["batch_number"] = *batchNumber
+				}
+				if err := TransitBatteryLifecycle(ctx, tx, BatteryLifecycleChange{
+					TenantID:   claims.TenantID,
+					DeviceID:   device.ID,
+					To:         model.BatteryLifecycleInStock,
+					Event:      model.BatteryLifecycleEventImport,
+					OperatorID: &claims.ID,
+					Updates:    fields,
+				}); err != nil {
 					tx.Rollback()
-					return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{
-						"sql_error": err.Error(),
-					})
+					return nil, err
 				}
 			} else {
 				resp.Failed++
@@ -896,46 +939,27 @@ func (*Battery) BatchAssignDealer(ctx context.Context, req model.BatteryBatchAss
 			}
 		}
 
-		// 查找或创建 device_batteries 记录
-		_, err = query.DeviceBattery.WithContext(ctx).
-			Where(query.DeviceBattery.DeviceID.Eq(deviceID)).
-			First()
+		// 分配到经销商（无档案时一并建档）
+		currentStatus, err := getBatteryLifecycleStatus(ctx, tx, deviceID)
 		if err != nil {
-			if err == gorm.ErrRecordNotFound {
-				// 创建新记录
-				newBattery := &model.DeviceBattery{
-					DeviceID:         deviceID,
-					DealerID:         &req.DealerID,
-					ActivationStatus: StringPtr("INACTIVE"),
-					TransferStatus:   StringPtr("DEALER"),
-					UpdatedAt:        &now,
-				}
-				if err := tx.Create(newBattery).Error; err != nil {
-					tx.Rollback()
-					return errcode.WithData(errcode.CodeDBError, map[string]interface{}{
-						"sql_error": err.Error(),
-					})
-				}
-			} else {
-				tx.Rollback()
-				return errcode.WithData(errcode.CodeDBError, map[string]interface{}{
-					"sql_error": err.Error(),
-				})
-			}
-		} else {
-			// 更新经销商ID
-			if err := tx.Model(&model.DeviceBattery{}).
-				Where("device_id = ?", deviceID).
-				Updates(map[string]interface{}{
-					"dealer_id":       req.DealerID,
-					"updated_at":      now,
-					"transfer_status": "DEALER",
-				}).Error; err != nil {
-				tx.Rollback()
-				return errcode.WithData(errcode.CodeDBError, map[string]interface{}{
-					"sql_error": err.Error(),
-				})
-			}
+			tx.Rollback()
+			return errcode.WithData(errcode.CodeDBError, map[string]interface{}{
+				"sql_error": err.Error(),
+			})
+		}
+		if err := TransitBatteryLifecycle(ctx, tx, BatteryLifecycleChange{
+			TenantID:   claims.TenantID,
+			DeviceID:   deviceID,
+			To:         custodyLifecycleStatus(currentStatus, true),
+			Event:      model.BatteryLifecycleEventAssignDealer,
+			OperatorID: &claims.ID,
+			Updates: map[string]interface{}{
+				"dealer_id":  req.DealerID,
+				"updated_at": now,
+			},
+		}); err != nil {
+			tx.Rollback()
+			return err
 		}
 	}
 
@@ -957,6 +981,11 @@ func (*Battery) BatchSendCommand(ctx context.Context, req model.BatteryBatchComm
 		Failures: make([]model.BatteryBatchCommandFailure, 0),
 	}
 
+	guard, err := GroupApp.DeviceParamPermission.guardFor(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
 	for _, deviceID := range req.DeviceIDs {
 		// 校验设备是否存在且属于当前租户
 		device, err := query.Device.WithContext(ctx).
@@ -988,6 +1017,17 @@ func (*Battery) BatchSendCommand(ctx context.Context, req model.BatteryBatchComm
 			}
 		}
 
+		// 设备参数权限：按设备所属物模型校验指令执行权限
+		if !guard.bypass && !guard.allowed(deviceTemplateID(deviceID), model.DeviceParamCMD, req.Identify, model.DeviceParamExecute) {
+			resp.Failed++
+			resp.Failures = append(resp.Failures, model.BatteryBatchCommandFailure{
+				DeviceID:     deviceID,
+				DeviceNumber: device.DeviceNumber,
+				Message:      "无权执行该指令",
+			})
+			continue
+		}
+
 		// 仅在线设备允许立即下发
 		if device.IsOnline != 1 {
 			resp.Failed++
@@ -1103,7 +1143,7 @@ func (*Battery) BatchPushOTA(ctx context.Context, req model.BatteryBatchOtaPushR
 	}
 	go func(details []*model.OtaUpgradeTaskDetail) {
 		for _, d := range details {
-			_ = GroupApp.OTA.PushOTAUpgradePackage(d)
+			_ = GroupApp.OTA.PushOTAUpgradePackage(d, claims.ID)
 		}
 	}(taskDetails)
 
@@ -0,0 +1,465 @@
+package service
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"project/internal/model"
+	"project/pkg/errcode"
+	global "project/pkg/global"
+	"project/pkg/utils"
+
+	"github.com/go-basic/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BatteryCycle BMS: 充放电会话检测与循环日志
+type BatteryCycle struct{}
+
+const (
+	cycleIdleCurrentA     = 0.5                  // |I| 小于该值视为静置(A)
+	cycleMinSessionMs     = 60 * 1000            // 短于该时长的片段并入前一会话(ms)
+	cycleMaxSampleGapMs   = healthMaxSampleGapMs // 采样间隔超过该值视为断流，强制切分会话
+	cycleInitialLookback  = 48 * time.Hour       // 首次处理时回溯的时长
+	cycleMaxProcessWindow = 7 * 24 * time.Hour   // 最多补算的历史时长
+)
+
+// cycleSession 检测得到的会话
+type cycleSession struct {
+	Type        string
+	StartTS     int64
+	EndTS       int64
+	StartSoc    *float64
+	EndSoc      *float64
+	EnergyInWh  float64
+	EnergyOutWh float64
+	ChargeAh    float64
+	PeakCurrent float64
+	MaxTemp     *float64
+	Samples     int
+}
+
+// classifyCurrent 按电流方向判断状态（充电为正）
+func classifyCurrent(i float64) string {
+	switch {
+	case i > cycleIdleCurrentA:
+		return model.BatterySessionCharge
+	case i < -cycleIdleCurrentA:
+		return model.BatterySessionDischarge
+	default:
+		return model.BatterySessionIdle
+	}
+}
+
+// cycleRun 电流序列上的连续同状态片段（下标含首尾）
+type cycleRun struct {
+	Type     string
+	From, To int
+	// Broken 表示片段之后出现断流（与下一片段不连续）
+	Broken bool
+}
+
+// splitCycleRuns 将电流序列切分为同状态片段，并把过短的片段并入前一片段
+func splitCycleRuns(current []bmsSample) []cycleRun {
+	if len(current) == 0 {
+		return nil
+	}
+	var runs []cycleRun
+	cur := cycleRun{Type: classifyCurrent(current[0].Value), From: 0, To: 0}
+	for i := 1; i < len(current); i++ {
+		t := classifyCurrent(current[i].Value)
+		gap := current[i].TS-current[i-1].TS > cycleMaxSampleGapMs
+		if gap || t != cur.Type {
+			cur.Broken = gap
+			runs = append(runs, cur)
+			cur = cycleRun{Type: t, From: i, To: i}
+			continue
+		}
+		cur.To = i
+	}
+	runs = append(runs, cur)
+
+	// 抖动过滤：过短片段并入前一片段（断流处不合并），随后合并相邻同状态片段
+	merged := make([]cycleRun, 0, len(runs))
+	for i, r := range runs {
+		end := current[r.To].TS
+		if i+1 < len(runs) && !r.Broken {
+			end = current[runs[i+1].From].TS
+		}
+		short := end-current[r.From].TS < cycleMinSessionMs
+		if len(merged) > 0 {
+			prev := &merged[len(merged)-1]
+			if !prev.Broken && (short || prev.Type == r.Type) {
+				prev.To = r.To
+				prev.Broken = r.Broken
+				continue
+			}
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// detectCycleSessions 将遥测切分为充电/放电/静置会话
+// untilTs 为本次处理的截止时间：最后一个片段只有在其后已断流时才视为结束，
+// 未结束的片段不返回，下次从已落库会话的结束时间（即该片段起点）重新计算
+func detectCycleSessions(series map[string][]bmsSample, untilTs int64) []cycleSession {
+	current := series[BmsKeyCurrent]
+	runs := splitCycleRuns(current)
+	if len(runs) == 0 {
+		return nil
+	}
+	voltage := series[BmsKeyVoltage]
+	soc := series[BmsKeySoc]
+	temps := series[BmsKeyTemperatureMax]
+
+	sessions := make([]cycleSession, 0, len(runs))
+	for i, r := range runs {
+		last := i == len(runs)-1
+		if last && untilTs-current[r.To].TS <= cycleMaxSampleGapMs {
+			// 仍在进行中的会话
+			break
+		}
+		if r.From == r.To && (r.Broken || last) && (i == 0 || runs[i-1].Broken) {
+			// 孤立采样（之前为断档或窗口起点，之后为断档或窗口终点）：通常是续算时重新读到的上一会话末尾采样，不构成会话
+			continue
+		}
+
+		s := cycleSession{Type: r.Type, StartTS: current[r.From].TS, EndTS: current[r.To].TS}
+		to := r.To
+		if !last && !r.Broken {
+			// 与下一片段连续：以下一片段首个采样作为结束，保证积分不丢区间
+			to = runs[i+1].From
+			s.EndTS = current[to].TS
+		}
+		s.Samples = r.To - r.From + 1
+
+		for j := r.From; j <= to; j++ {
+			s.PeakCurrent = math.Max(s.PeakCurrent, math.Abs(current[j].Value))
+			if j == r.From {
+				continue
+			}
+			a, b := current[j-1], current[j]
+			dt := b.TS - a.TS
+			if dt <= 0 || dt > cycleMaxSampleGapMs {
+				continue
+			}
+			hours := float64(dt) / 3600000
+			avgI := (a.Value + b.Value) / 2
+			s.ChargeAh += math.Abs(avgI) * hours
+			if v, ok := seriesValueAt(voltage, b.TS); ok {
+				wh := avgI * v * hours
+				if wh > 0 {
+					s.EnergyInWh += wh
+				} else {
+					s.EnergyOutWh -= wh
+				}
+			}
+		}
+
+		if v, ok := seriesValueAt(soc, s.StartTS); ok {
+			s.StartSoc = &v
+		}
+		if v, ok := seriesValueAt(soc, s.EndTS); ok {
+			s.EndSoc = &v
+		}
+		for _, t := range temps {
+			if t.TS < s.StartTS || t.TS > s.EndTS {
+				continue
+			}
+			if s.MaxTemp == nil || t.Value > *s.MaxTemp {
+				v := t.Value
+				s.MaxTemp = &v
+			}
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions
+}
+
+// detectDevice 增量检测单个设备的会话（从已落库的最后一个会话结束时间继续）
+func (*BatteryCycle) detectDevice(ctx context.Context, t batteryHealthTarget, now time.Time) (int, error) {
+	db := global.DB.WithContext(ctx)
+
+	var watermark *time.Time
+	if err := db.Table(model.TableNameBatteryCycleSession).
+		Select("MAX(end_at)").
+		Where("device_id = ?", t.DeviceID).
+		Scan(&watermark).Error; err != nil {
+		return 0, err
+	}
+	// 无历史会话时回溯 48 小时；长期离线的设备最多补算 7 天
+	start := now.Add(-cycleInitialLookback)
+	if watermark != nil && !watermark.IsZero() {
+		start = *watermark
+	}
+	if earliest := now.Add(-cycleMaxProcessWindow); start.Before(earliest) {
+		start = earliest
+	}
+	end := now
+
+	series := make(map[string][]bmsSample, 4)
+	for _, name := range []string{BmsKeyCurrent, BmsKeyVoltage, BmsKeySoc, BmsKeyTemperatureMax} {
+		s, err := loadBmsSeries(t.DeviceID, name, start.UnixMilli(), end.UnixMilli())
+		if err != nil {
+			return 0, err
+		}
+		series[name] = s
+	}
+
+	sessions := detectCycleSessions(series, end.UnixMilli())
+	if len(sessions) == 0 {
+		return 0, nil
+	}
+
+	nowUTC := time.Now().UTC()
+	recs := make([]*model.BatteryCycleSession, 0, len(sessions))
+	for _, s := range sessions {
+		var peak *float64
+		if s.PeakCurrent > 0 {
+			p := s.PeakCurrent
+			peak = &p
+		}
+		recs = append(recs, &model.BatteryCycleSession{
+			ID:             uuid.New(),
+			TenantID:       t.TenantID,
+			DeviceID:       t.DeviceID,
+			SessionType:    s.Type,
+			StartAt:        time.UnixMilli(s.StartTS).UTC(),
+			EndAt:          time.UnixMilli(s.EndTS).UTC(),
+			DurationSec:    int32((s.EndTS - s.StartTS) / 1000),
+			StartSoc:       s.StartSoc,
+			EndSoc:         s.EndSoc,
+			EnergyInWh:     s.EnergyInWh,
+			EnergyOutWh:    s.EnergyOutWh,
+			ChargeAh:       s.ChargeAh,
+			PeakCurrent:    peak,
+			MaxTemperature: s.MaxTemp,
+			SampleCount:    int32(s.Samples),
+			CreatedAt:      nowUTC,
+		})
+	}
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(recs, 200).Error; err != nil {
+		return 0, err
+	}
+	return len(recs), nil
+}
+
+// DetectByCron 定时任务：增量切分全部电池的充放电会话
+func (s *BatteryCycle) DetectByCron() {
+	ctx := context.Background()
+	targets, err := listHealthTargets(ctx, "")
+	if err != nil {
+		logrus.WithError(err).Error("battery cycle: list targets failed")
+		return
+	}
+	now := time.Now()
+	total := 0
+	for _, t := range targets {
+		n, err := s.detectDevice(ctx, t, now)
+		if err != nil {
+			logrus.WithError(err).WithField("device_id", t.DeviceID).Warn("battery cycle: detect sessions failed")
+			continue
+		}
+		if n > 0 {
+			markBatteryInService(ctx, t.TenantID, t.DeviceID)
+		}
+		total += n
+	}
+	logrus.Debugf("battery cycle: %d new sessions from %d devices", total, len(targets))
+}
+
+// ListCycles 充放电会话列表（按组织范围隔离）
+func (*BatteryCycle) ListCycles(ctx context.Context, req model.BatteryCycleListReq, claims *utils.UserClaims, orgID string) (*model.BatteryCycleListResp, error) {
+	db := global.DB.WithContext(ctx).Table("battery_cycle_sessions AS bcs").
+		Joins("JOIN devices d ON d.id = bcs.device_id").
+		Joins("LEFT JOIN device_batteries dbat ON dbat.device_id = bcs.device_id").
+		Joins("LEFT JOIN battery_models bm ON bm.id = dbat.battery_model_id").
+		Where("bcs.tenant_id = ?", claims.TenantID)
+	if orgID != "" {
+		db = db.Where(`dbat.owner_org_id IN (
+			SELECT descendant_id FROM org_closure WHERE tenant_id = ? AND ancestor_id = ?
+		)`, claims.TenantID, orgID)
+	}
+	if req.DeviceID != nil && *req.DeviceID != "" {
+		db = db.Where("bcs.device_id = ?", *req.DeviceID)
+	}
+	if req.DeviceNumber != nil && *req.DeviceNumber != "" {
+		db = db.Where("d.device_number LIKE ?", "%"+*req.DeviceNumber+"%")
+	}
+	if req.BatteryModelID != nil && *req.BatteryModelID != "" {
+		db = db.Where("dbat.battery_model_id = ?", *req.BatteryModelID)
+	}
+	if req.SessionType != nil && *req.SessionType != "" {
+		db = db.Where("bcs.session_type = ?", *req.SessionType)
+	}
+	if req.StartTime != nil {
+		db = db.Where("bcs.start_at >= ?", req.StartTime.UTC())
+	}
+	if req.EndTime != nil {
+		db = db.Where("bcs.start_at <= ?", req.EndTime.UTC())
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+
+	list, err := scanCycleItems(db.Order("bcs.start_at DESC").Limit(req.PageSize).Offset((req.Page - 1) * req.PageSize))
+	if err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return &model.BatteryCycleListResp{
+		List:     list,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, nil
+}
+
+type cycleItemRow struct {
+	model.BatteryCycleSession
+	DeviceNumber     string  `gorm:"column:device_number"`
+	BatteryModelName *string `gorm:"column:battery_model_name"`
+}
+
+// scanCycleItems 读取会话行（db 需已 join devices d / battery_models bm）
+func scanCycleItems(db *gorm.DB) ([]model.BatteryCycleItemResp, error) {
+	var rows []cycleItemRow
+	if err := db.Select("bcs.*, d.device_number, bm.name AS battery_model_name").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make([]model.BatteryCycleItemResp, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, model.BatteryCycleItemResp{
+			ID:               r.ID,
+			DeviceID:         r.DeviceID,
+			DeviceNumber:     r.DeviceNumber,
+			BatteryModelName: r.BatteryModelName,
+			SessionType:      r.SessionType,
+			StartAt:          r.StartAt.In(time.Local).Format("2006-01-02 15:04:05"),
+			EndAt:            r.EndAt.In(time.Local).Format("2006-01-02 15:04:05"),
+			DurationSec:      r.DurationSec,
+			StartSoc:         r.StartSoc,
+			EndSoc:           r.EndSoc,
+			EnergyInWh:       r.EnergyInWh,
+			EnergyOutWh:      r.EnergyOutWh,
+			ChargeAh:         r.ChargeAh,
+			PeakCurrent:      r.PeakCurrent,
+			MaxTemperature:   r.MaxTemperature,
+		})
+	}
+	return out, nil
+}
+
+// cycleStatsGroupColumns 聚合维度对应的分组列
+var cycleStatsGroupColumns = map[string][2]string{
+	"device":        {"d.id", "d.device_number"},
+	"battery_model": {"bm.id", "bm.name"},
+	"dealer":        {"o.id", "o.name"},
+}
+
+// CycleStats 按设备/电池型号/经销商聚合充放电会话
+func (*BatteryCycle) CycleStats(ctx context.Context, req model.BatteryCycleStatsReq, claims *utils.UserClaims, orgID string) (*model.BatteryCycleStatsResp, error) {
+	cols, ok := cycleStatsGroupColumns[req.GroupBy]
+	if !ok {
+		return nil, errcode.WithData(errcode.CodeParamError, map[string]interface{}{"message": "group_by 仅支持 device/battery_model/dealer"})
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+
+	db := global.DB.WithContext(ctx).Table("battery_cycle_sessions AS bcs").
+		Joins("JOIN devices d ON d.id = bcs.device_id").
+		Joins("LEFT JOIN device_batteries dbat ON dbat.device_id = bcs.device_id").
+		Joins("LEFT JOIN battery_models bm ON bm.id = dbat.battery_model_id").
+		Joins("LEFT JOIN orgs o ON o.id = dbat.owner_org_id").
+		Where("bcs.tenant_id = ?", claims.TenantID)
+	if orgID != "" {
+		db = db.Where(`dbat.owner_org_id IN (
+			SELECT descendant_id FROM org_closure WHERE tenant_id = ? AND ancestor_id = ?
+		)`, claims.TenantID, orgID)
+	}
+	if req.SessionType != nil && *req.SessionType != "" {
+		db = db.Where("bcs.session_type = ?", *req.SessionType)
+	}
+	if req.BatteryModelID != nil && *req.BatteryModelID != "" {
+		db = db.Where("dbat.battery_model_id = ?", *req.BatteryModelID)
+	}
+	if req.StartTime != nil {
+		db = db.Where("bcs.start_at >= ?", req.StartTime.UTC())
+	}
+	if req.EndTime != nil {
+		db = db.Where("bcs.start_at <= ?", req.EndTime.UTC())
+	}
+
+	type statsRow struct {
+		GroupID        *string  `gorm:"column:group_id"`
+		GroupName      *string  `gorm:"column:group_name"`
+		ChargeCount    int64    `gorm:"column:charge_count"`
+		DischargeCount int64    `gorm:"column:discharge_count"`
+		EnergyInWh     float64  `gorm:"column:energy_in_wh"`
+		EnergyOutWh    float64  `gorm:"column:energy_out_wh"`
+		AvgChargeSec   *float64 `gorm:"column:avg_charge_sec"`
+		AvgDepthSoc    *float64 `gorm:"column:avg_depth_soc"`
+		MaxTemperature *float64 `gorm:"column:max_temperature"`
+		PeakCurrent    *float64 `gorm:"column:peak_current"`
+	}
+	var rows []statsRow
+	if err := db.Select(cols[0] + ` AS group_id, ` + cols[1] + ` AS group_name,
+			COUNT(1) FILTER (WHERE bcs.session_type = 'CHARGE') AS charge_count,
+			COUNT(1) FILTER (WHERE bcs.session_type = 'DISCHARGE') AS discharge_count,
+			COALESCE(SUM(bcs.energy_in_wh), 0) AS energy_in_wh,
+			COALESCE(SUM(bcs.energy_out_wh), 0) AS energy_out_wh,
+			AVG(bcs.duration_sec) FILTER (WHERE bcs.session_type = 'CHARGE') AS avg_charge_sec,
+			AVG(bcs.start_soc - bcs.end_soc) FILTER (WHERE bcs.session_type = 'DISCHARGE') AS avg_depth_soc,
+			MAX(bcs.max_temperature) AS max_temperature,
+			MAX(bcs.peak_current) AS peak_current`).
+		Group(cols[0] + ", " + cols[1]).
+		Order("energy_out_wh DESC").
+		Limit(limit).
+		Scan(&rows).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+
+	list := make([]model.BatteryCycleStatsItem, 0, len(rows))
+	for _, r := range rows {
+		list = append(list, model.BatteryCycleStatsItem{
+			GroupID:        SafeDeref(r.GroupID),
+			GroupName:      SafeDeref(r.GroupName),
+			ChargeCount:    r.ChargeCount,
+			DischargeCount: r.DischargeCount,
+			EnergyInWh:     r.EnergyInWh,
+			EnergyOutWh:    r.EnergyOutWh,
+			AvgChargeSec:   r.AvgChargeSec,
+			AvgDepthSoc:    r.AvgDepthSoc,
+			MaxTemperature: r.MaxTemperature,
+			PeakCurrent:    r.PeakCurrent,
+		})
+	}
+	return &model.BatteryCycleStatsResp{GroupBy: req.GroupBy, List: list}, nil
+}
+
+// ListRecentCycles 设备最近的会话（调用方负责权限校验）
+func (*BatteryCycle) ListRecentCycles(ctx context.Context, deviceID, sessionType string, limit int) ([]model.BatteryCycleItemResp, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	db := global.DB.WithContext(ctx).Table("battery_cycle_sessions AS bcs").
+		Joins("JOIN devices d ON d.id = bcs.device_id").
+		Joins("LEFT JOIN device_batteries dbat ON dbat.device_id = bcs.device_id").
+		Joins("LEFT JOIN battery_models bm ON bm.id = dbat.battery_model_id").
+		Where("bcs.device_id = ?", deviceID)
+	if sessionType != "" {
+		db = db.Where("bcs.session_type = ?", sessionType)
+	}
+	list, err := scanCycleItems(db.Order("bcs.start_at DESC").Limit(limit))
+	if err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return list, nil
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"math"
+	"testing"
+
+	"project/internal/model"
+)
+
+// buildCycleSeries 每 10 秒一个采样：放电 30 分钟(-20A) -> 静置 10 分钟 -> 充电 30 分钟(+10A)
+func buildCycleSeries() map[string][]bmsSample {
+	var current, voltage, soc []bmsSample
+	ts := int64(0)
+	socV := 80.0
+	add := func(i float64, n int) {
+		for k := 0; k < n; k++ {
+			current = append(current, bmsSample{TS: ts, Value: i})
+			voltage = append(voltage, bmsSample{TS: ts, Value: 50})
+			soc = append(soc, bmsSample{TS: ts, Value: socV})
+			socV += i / 100
+			ts += 10 * 1000
+		}
+	}
+	add(-20, 180)
+	add(0, 60)
+	add(10, 180)
+	return map[string][]bmsSample{BmsKeyCurrent: current, BmsKeyVoltage: voltage, BmsKeySoc: soc}
+}
+
+func TestDetectCycleSessions_OpenTailKept(t *testing.T) {
+	series := buildCycleSeries()
+	current := series[BmsKeyCurrent]
+	untilTs := current[len(current)-1].TS + 1000
+
+	sessions := detectCycleSessions(series, untilTs)
+	if len(sessions) != 2 {
+		t.Fatalf("expected 2 closed sessions, got %d", len(sessions))
+	}
+	if sessions[0].Type != model.BatterySessionDischarge || sessions[1].Type != model.BatterySessionIdle {
+		t.Fatalf("unexpected session types: %s, %s", sessions[0].Type, sessions[1].Type)
+	}
+	// 放电 30 分钟 × 20A × 50V ≈ 500Wh（末段与静置采样之间按梯形积分）
+	if math.Abs(sessions[0].EnergyOutWh-500) > 2 {
+		t.Fatalf("expected ~500Wh discharged, got %v", sessions[0].EnergyOutWh)
+	}
+	if sessions[0].PeakCurrent != 20 {
+		t.Fatalf("expected peak 20A, got %v", sessions[0].PeakCurrent)
+	}
+	// 充电仍在进行：静置会话以充电起点结束，下次从该时间继续
+	if chargeStart := current[240].TS; sessions[1].EndTS != chargeStart {
+		t.Fatalf("expected idle to end at charge start %d, got %d", chargeStart, sessions[1].EndTS)
+	}
+}
+
+func TestDetectCycleSessions_ClosedAfterGap(t *testing.T) {
+	series := buildCycleSeries()
+	current := series[BmsKeyCurrent]
+	untilTs := current[len(current)-1].TS + cycleMaxSampleGapMs + 1
+
+	sessions := detectCycleSessions(series, untilTs)
+	if len(sessions) != 3 {
+		t.Fatalf("expected 3 sessions, got %d", len(sessions))
+	}
+	charge := sessions[2]
+	if charge.Type != model.BatterySessionCharge {
+		t.Fatalf("expected charge session, got %s", charge.Type)
+	}
+	if charge.StartSoc == nil || charge.EndSoc == nil || *charge.EndSoc <= *charge.StartSoc {
+		t.Fatalf("expected SOC to rise during charge")
+	}
+	if charge.EnergyInWh <= 0 || charge.EnergyOutWh != 0 {
+		t.Fatalf("unexpected charge energy in=%v out=%v", charge.EnergyInWh, charge.EnergyOutWh)
+	}
+}
+
+func TestSplitCycleRuns_GlitchMerged(t *testing.T) {
+	var current []bmsSample
+	for i := 0; i < 30; i++ {
+		v := -20.0
+		if i == 15 {
+			v = 0 // 单点电流抖动
+		}
+		current = append(current, bmsSample{TS: int64(i) * 10 * 1000, Value: v})
+	}
+	runs := splitCycleRuns(current)
+	if len(runs) != 1 || runs[0].Type != model.BatterySessionDischarge {
+		t.Fatalf("expected glitch merged into single discharge run, got %+v", runs)
+	}
+}
+
+func TestDetectCycleSessions_ResumeAfterGap(t *testing.T) {
+	// 续算从上一会话 end_at 开始，首个采样即上一会话末尾采样，其后为断档
+	series := buildCycleSeries()
+	resumed := map[string][]bmsSample{}
+	for k, v := range series {
+		last := v[len(v)-1]
+		tail := []bmsSample{last}
+		for _, s := range v {
+			s.TS += last.TS + cycleMaxSampleGapMs + 1
+			tail = append(tail, s)
+		}
+		resumed[k] = tail
+	}
+	current := resumed[BmsKeyCurrent]
+	untilTs := current[len(current)-1].TS + cycleMaxSampleGapMs + 1
+
+	sessions := detectCycleSessions(resumed, untilTs)
+	if len(sessions) != 3 {
+		t.Fatalf("expected 3 sessions, got %d", len(sessions))
+	}
+	for _, s := range sessions {
+		if s.Samples <= 1 || s.EndTS <= s.StartTS {
+			t.Fatalf("unexpected one-sample session %+v", s)
+		}
+	}
+	if sessions[0].StartTS != current[1].TS {
+		t.Fatalf("expected first session to start after the gap at %d, got %d", current[1].TS, sessions[0].StartTS)
+	}
+}
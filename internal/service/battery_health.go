@@ -0,0 +1,743 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"project/internal/model"
+	"project/pkg/errcode"
+	global "project/pkg/global"
+	"project/pkg/utils"
+
+	"github.com/go-basic/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"gorm.io/gorm/clause"
+)
+
+// BatteryHealth BMS: 电池健康分析（SOH 历史/等效循环/压差/内阻/寿命预测）
+type BatteryHealth struct{}
+
+const (
+	defaultEolSohThreshold = 80.0 // 默认寿命阈值(%)
+
+	healthMinSocSwing      = 20.0           // 容量估算所需最小 SOC 变化(%)
+	healthMaxSampleGapMs   = 10 * 60 * 1000 // 超过该间隔视为数据缺失，不做积分(ms)
+	healthIRMinCurrentStep = 5.0            // 内阻估算所需最小电流阶跃(A)
+	healthIRMaxStepGapMs   = 10 * 1000      // 内阻估算：阶跃前后采样最大间隔(ms)
+	healthForecastMinPts   = 5              // 寿命预测最少历史点
+	healthForecastMinDays  = 14.0           // 寿命预测最少历史跨度(天)
+	healthForecastWindow   = 365            // 寿命预测使用的历史天数
+	healthZ95              = 1.96           // 95% 置信区间系数
+)
+
+// EolSohThreshold 寿命阈值（bms.eol_soh_threshold，默认80）
+func EolSohThreshold() float64 {
+	if v := viper.GetFloat64("bms.eol_soh_threshold"); v > 0 {
+		return v
+	}
+	return defaultEolSohThreshold
+}
+
+// batteryDayMetrics 单日健康指标
+type batteryDayMetrics struct {
+	Soh              *float64
+	SohSource        string
+	CapacityAh       *float64
+	EquivalentCycles float64
+	CellDeltaAvgMv   *float64
+	CellDeltaMaxMv   *float64
+	IRMohm           *float64
+	TemperatureMax   *float64
+	SampleCount      int
+}
+
+// equivalentCycles 等效满充次数：Σ|ΔSOC| / 200（一次完整充放为 200% 的 SOC 变化）
+func equivalentCycles(soc []bmsSample) float64 {
+	var sum float64
+	for i := 1; i < len(soc); i++ {
+		sum += math.Abs(soc[i].Value - soc[i-1].Value)
+	}
+	return sum / 200
+}
+
+// integrateCurrentAh 电流对时间积分（梯形法，取绝对值），返回 Ah；跳过采样缺失区间
+func integrateCurrentAh(current []bmsSample, startTs, endTs int64) float64 {
+	var ah float64
+	for i := 1; i < len(current); i++ {
+		a, b := current[i-1], current[i]
+		if a.TS < startTs || b.TS > endTs {
+			continue
+		}
+		dt := b.TS - a.TS
+		if dt <= 0 || dt > healthMaxSampleGapMs {
+			continue
+		}
+		ah += (math.Abs(a.Value) + math.Abs(b.Value)) / 2 * float64(dt) / 3600000
+	}
+	return ah
+}
+
+// estimateCapacityAh 按单调 SOC 区段（充电或放电）估算实际容量：Ah / (ΔSOC/100)，按 ΔSOC 加权平均
+func estimateCapacityAh(soc, current []bmsSample) (float64, bool) {
+	if len(soc) < 2 || len(current) < 2 {
+		return 0, false
+	}
+
+	var weighted, weights float64
+	flush := func(start, end int) {
+		if end <= start {
+			return
+		}
+		swing := math.Abs(soc[end].Value - soc[start].Value)
+		if swing < healthMinSocSwing {
+			return
+		}
+		ah := integrateCurrentAh(current, soc[start].TS, soc[end].TS)
+		if ah <= 0 {
+			return
+		}
+		weighted += ah / (swing / 100) * swing
+		weights += swing
+	}
+
+	start, dir := 0, 0
+	for i := 1; i < len(soc); i++ {
+		if soc[i].TS-soc[i-1].TS > healthMaxSampleGapMs {
+			flush(start, i-1)
+			start, dir = i, 0
+			continue
+		}
+		d := 0
+		if soc[i].Value > soc[i-1].Value {
+			d = 1
+		} else if soc[i].Value < soc[i-1].Value {
+			d = -1
+		}
+		if d == 0 {
+			continue
+		}
+		if dir != 0 && d != dir {
+			flush(start, i-1)
+			start = i - 1
+		}
+		dir = d
+	}
+	flush(start, len(soc)-1)
+
+	if weights == 0 {
+		return 0, false
+	}
+	return weighted / weights, true
+}
+
+// cellVoltageMv 单体电压统一换算为 mV（物模型可能上报 V 或 mV）
+func cellVoltageMv(v float64) float64 {
+	if math.Abs(v) > 100 {
+		return v
+	}
+	return v * 1000
+}
+
+// cellDeltaStats 压差统计（mV）：按最高单体电压采样时刻对齐最低单体电压
+func cellDeltaStats(maxV, minV []bmsSample) (avg, max float64, ok bool) {
+	var sum float64
+	n := 0
+	for _, s := range maxV {
+		lo, found := seriesValueAt(minV, s.TS)
+		if !found {
+			continue
+		}
+		d := cellVoltageMv(s.Value) - cellVoltageMv(lo)
+		if d < 0 {
+			continue
+		}
+		sum += d
+		if d > max {
+			max = d
+		}
+		n++
+	}
+	if n == 0 {
+		return 0, 0, false
+	}
+	return sum / float64(n), max, true
+}
+
+// internalResistanceMohm 内阻代理值：电流阶跃前后 |ΔV/ΔI| 的中位数（mΩ）
+func internalResistanceMohm(voltage, current []bmsSample) (float64, bool) {
+	var rs []float64
+	for i := 1; i < len(current); i++ {
+		a, b := current[i-1], current[i]
+		dI := b.Value - a.Value
+		if math.Abs(dI) < healthIRMinCurrentStep || b.TS-a.TS > healthIRMaxStepGapMs {
+			continue
+		}
+		va, okA := seriesValueAt(voltage, a.TS)
+		vb, okB := seriesValueAt(voltage, b.TS)
+		if !okA || !okB {
+			continue
+		}
+		rs = append(rs, math.Abs((vb-va)/dI)*1000)
+	}
+	if len(rs) < 3 {
+		return 0, false
+	}
+	return median(rs), true
+}
+
+// median 中位数（会对入参排序）
+func median(vs []float64) float64 {
+	sort.Float64s(vs)
+	mid := len(vs) / 2
+	if len(vs)%2 == 0 {
+		return (vs[mid-1] + vs[mid]) / 2
+	}
+	return vs[mid]
+}
+
+// computeBatteryDayMetrics 由单日遥测计算健康指标；ratedAh 为型号额定容量（<=0 表示未知）
+func computeBatteryDayMetrics(series map[string][]bmsSample, ratedAh float64) batteryDayMetrics {
+	var m batteryDayMetrics
+	soc := series[BmsKeySoc]
+	current := series[BmsKeyCurrent]
+	for _, s := range series {
+		m.SampleCount += len(s)
+	}
+
+	m.EquivalentCycles = equivalentCycles(soc)
+
+	if capAh, ok := estimateCapacityAh(soc, current); ok {
+		m.CapacityAh = &capAh
+		if ratedAh > 0 {
+			soh := math.Min(capAh/ratedAh*100, 120)
+			m.Soh = &soh
+			m.SohSource = model.BatterySohSourceCapacity
+		}
+	}
+	if m.Soh == nil {
+		if reported := series[BmsKeySoh]; len(reported) > 0 {
+			vs := make([]float64, 0, len(reported))
+			for _, s := range reported {
+				vs = append(vs, s.Value)
+			}
+			soh := median(vs)
+			m.Soh = &soh
+			m.SohSource = model.BatterySohSourceReported
+		}
+	}
+
+	if avg, max, ok := cellDeltaStats(series[BmsKeyCellVoltageMax], series[BmsKeyCellVoltageMin]); ok {
+		m.CellDeltaAvgMv = &avg
+		m.CellDeltaMaxMv = &max
+	}
+	if r, ok := internalResistanceMohm(series[BmsKeyVoltage], current); ok {
+		m.IRMohm = &r
+	}
+	if temps := series[BmsKeyTemperatureMax]; len(temps) > 0 {
+		t := temps[0].Value
+		for _, s := range temps[1:] {
+			t = math.Max(t, s.Value)
+		}
+		m.TemperatureMax = &t
+	}
+	return m
+}
+
+// sohPoint 用于趋势拟合的 SOH 点（X 为天数）
+type sohPoint struct {
+	X float64
+	Y float64
+}
+
+// sohForecast 寿命预测结果
+type sohForecast struct {
+	FadePerMonth float64    // %/30天，正数表示衰减
+	Eol          *time.Time // 预测跌破阈值日期
+	EolLower     *time.Time // 置信区间下限（更早）
+	EolUpper     *time.Time // 置信区间上限（更晚）；衰减不显著时为空
+}
+
+// forecastSohEol 对 SOH 历史做线性回归，预测跌破 threshold 的日期及 95% 置信区间
+// 置信区间通过斜率标准误差得到：以 (x̄, ȳ) 为支点分别按 b±1.96·SE 外推
+func forecastSohEol(points []sohPoint, threshold float64, epoch time.Time) (*sohForecast, bool) {
+	n := float64(len(points))
+	if len(points) < healthForecastMinPts {
+		return nil, false
+	}
+	var sx, sy float64
+	minX, maxX := points[0].X, points[0].X
+	for _, p := range points {
+		sx += p.X
+		sy += p.Y
+		minX = math.Min(minX, p.X)
+		maxX = math.Max(maxX, p.X)
+	}
+	if maxX-minX < healthForecastMinDays {
+		return nil, false
+	}
+	mx, my := sx/n, sy/n
+	var sxx, sxy float64
+	for _, p := range points {
+		sxx += (p.X - mx) * (p.X - mx)
+		sxy += (p.X - mx) * (p.Y - my)
+	}
+	if sxx == 0 {
+		return nil, false
+	}
+	b := sxy / sxx
+	a := my - b*mx
+
+	var sse float64
+	for _, p := range points {
+		r := p.Y - (a + b*p.X)
+		sse += r * r
+	}
+	se := math.Sqrt(sse/(n-2)) / math.Sqrt(sxx)
+
+	f := &sohForecast{FadePerMonth: -b * 30}
+	dateAt := func(slope float64) *time.Time {
+		if slope >= 0 {
+			return nil
+		}
+		x := mx + (threshold-my)/slope
+		// 超过 50 年的外推没有意义
+		if x-maxX > 365*50 {
+			return nil
+		}
+		t := epoch.AddDate(0, 0, int(math.Round(x)))
+		return &t
+	}
+	f.Eol = dateAt(b)
+	if f.Eol != nil {
+		f.EolLower = dateAt(b - healthZ95*se)
+		f.EolUpper = dateAt(b + healthZ95*se)
+	}
+	return f, true
+}
+
+// loadBatteryDaySeries 读取单日计算所需的全部 BMS 指标
+func loadBatteryDaySeries(deviceID string, startMs, endMs int64) (map[string][]bmsSample, error) {
+	names := []string{BmsKeySoc, BmsKeySoh, BmsKeyCurrent, BmsKeyVoltage, BmsKeyCellVoltageMax, BmsKeyCellVoltageMin, BmsKeyTemperatureMax}
+	series := make(map[string][]bmsSample, len(names))
+	for _, name := range names {
+		s, err := loadBmsSeries(deviceID, name, startMs, endMs)
+		if err != nil {
+			return nil, err
+		}
+		series[name] = s
+	}
+	return series, nil
+}
+
+type batteryHealthTarget struct {
+	DeviceID      string   `gorm:"column:device_id"`
+	TenantID      string   `gorm:"column:tenant_id"`
+	CapacityRated *float64 `gorm:"column:capacity_rated"`
+}
+
+// refreshDevice 计算设备在 day（租户时区下的本地日期，未配置时区时为服务器时区）的健康快照，并更新寿命预测
+func (s *BatteryHealth) refreshDevice(ctx context.Context, t batteryHealthTarget, day time.Time) error {
+	loc := tenantLocationOrLocal(ctx, t.TenantID)
+	day = day.In(loc)
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+	end := start.AddDate(0, 0, 1)
+	// stat_date 为日期列，按服务器时区写入避免数据库会话时区转换导致日期偏移
+	statDate := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.Local)
+
+	series, err := loadBatteryDaySeries(t.DeviceID, start.UnixMilli(), end.UnixMilli()-1)
+	if err != nil {
+		return err
+	}
+	var rated float64
+	if t.CapacityRated != nil {
+		rated = *t.CapacityRated
+	}
+	m := computeBatteryDayMetrics(series, rated)
+	if m.SampleCount == 0 {
+		return nil
+	}
+
+	db := global.DB.WithContext(ctx)
+
+	// 累计循环：取统计日之前最近一条快照
+	var prevTotal float64
+	if err := db.Table(model.TableNameBatterySohHistory).
+		Select("COALESCE(MAX(total_cycles), 0)").
+		Where("device_id = ? AND stat_date < ?", t.DeviceID, start.Format("2006-01-02")).
+		Scan(&prevTotal).Error; err != nil {
+		return err
+	}
+
+	sohSource := m.SohSource
+	if sohSource == "" {
+		sohSource = model.BatterySohSourceCapacity
+	}
+	rec := &model.BatterySohHistory{
+		ID:                     uuid.New(),
+		TenantID:               t.TenantID,
+		DeviceID:               t.DeviceID,
+		StatDate:               statDate,
+		Soh:                    m.Soh,
+		SohSource:              sohSource,
+		CapacityAh:             m.CapacityAh,
+		EquivalentCycles:       m.EquivalentCycles,
+		TotalCycles:            prevTotal + m.EquivalentCycles,
+		CellDeltaAvgMv:         m.CellDeltaAvgMv,
+		CellDeltaMaxMv:         m.CellDeltaMaxMv,
+		InternalResistanceMohm: m.IRMohm,
+		TemperatureMax:         m.TemperatureMax,
+		SampleCount:            int32(m.SampleCount),
+		CreatedAt:              time.Now().UTC(),
+	}
+	if err := db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "device_id"}, {Name: "stat_date"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"soh", "soh_source", "capacity_ah", "equivalent_cycles", "total_cycles",
+			"cell_delta_avg_mv", "cell_delta_max_mv", "internal_resistance_mohm",
+			"temperature_max", "sample_count", "created_at",
+		}),
+	}).Create(rec).Error; err != nil {
+		return err
+	}
+
+	return s.updateForecast(ctx, t.DeviceID)
+}
+
+// updateForecast 基于最近一年的 SOH 历史更新 device_batteries 上的汇总与预测字段
+func (*BatteryHealth) updateForecast(ctx context.Context, deviceID string) error {
+	db := global.DB.WithContext(ctx)
+
+	type histRow struct {
+		StatDate    time.Time `gorm:"column:stat_date"`
+		Soh         float64   `gorm:"column:soh"`
+		TotalCycles float64   `gorm:"column:total_cycles"`
+	}
+	var rows []histRow
+	since := time.Now().AddDate(0, 0, -healthForecastWindow).Format("2006-01-02")
+	if err := db.Table(model.TableNameBatterySohHistory).
+		Select("stat_date, soh, total_cycles").
+		Where("device_id = ? AND stat_date >= ? AND soh IS NOT NULL", deviceID, since).
+		Order("stat_date ASC").
+		Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	updates := map[string]interface{}{
+		"health_updated_at":       time.Now().UTC(),
+		"soh_fade_per_month":      nil,
+		"eol_forecast_date":       nil,
+		"eol_forecast_date_lower": nil,
+		"eol_forecast_date_upper": nil,
+	}
+
+	var totalCycles float64
+	if err := db.Table(model.TableNameBatterySohHistory).
+		Select("COALESCE(MAX(total_cycles), 0)").
+		Where("device_id = ?", deviceID).
+		Scan(&totalCycles).Error; err != nil {
+		return err
+	}
+	updates["cycle_count"] = totalCycles
+
+	if len(rows) > 0 {
+		epoch := rows[0].StatDate
+		points := make([]sohPoint, 0, len(rows))
+		for _, r := range rows {
+			points = append(points, sohPoint{X: r.StatDate.Sub(epoch).Hours() / 24, Y: r.Soh})
+		}
+		if f, ok := forecastSohEol(points, EolSohThreshold(), epoch); ok {
+			updates["soh_fade_per_month"] = f.FadePerMonth
+			if f.Eol != nil {
+				updates["eol_forecast_date"] = f.Eol.Format("2006-01-02")
+			}
+			if f.EolLower != nil {
+				updates["eol_forecast_date_lower"] = f.EolLower.Format("2006-01-02")
+			}
+			if f.EolUpper != nil {
+				updates["eol_forecast_date_upper"] = f.EolUpper.Format("2006-01-02")
+			}
+		}
+	}
+
+	return db.Table(model.TableNameDeviceBattery).Where("device_id = ?", deviceID).Updates(updates).Error
+}
+
+// listHealthTargets 需要计算健康指标的电池（可按设备过滤）
+func listHealthTargets(ctx context.Context, deviceID string) ([]batteryHealthTarget, error) {
+	q := global.DB.WithContext(ctx).Table("device_batteries AS dbat").
+		Select("dbat.device_id, d.tenant_id, bm.capacity_rated").
+		Joins("JOIN devices d ON d.id = dbat.device_id").
+		Joins("LEFT JOIN battery_models bm ON bm.id = dbat.battery_model_id")
+	if deviceID != "" {
+		q = q.Where("dbat.device_id = ?", deviceID)
+	}
+	var targets []batteryHealthTarget
+	if err := q.Scan(&targets).Error; err != nil {
+		return nil, err
+	}
+	return targets, nil
+}
+
+// RefreshDailyByCron 定时任务：计算昨日全部电池的健康快照与预测
+func (s *BatteryHealth) RefreshDailyByCron() {
+	ctx := context.Background()
+	day := time.Now().AddDate(0, 0, -1)
+
+	targets, err := listHealthTargets(ctx, "")
+	if err != nil {
+		logrus.WithError(err).Error("battery health: list targets failed")
+		return
+	}
+	failed := 0
+	for _, t := range targets {
+		if err := s.refreshDevice(ctx, t, day); err != nil {
+			failed++
+			logrus.WithError(err).WithField("device_id", t.DeviceID).Warn("battery health: refresh device failed")
+		}
+	}
+	logrus.Infof("battery health: refreshed %d devices (%d failed) for %s", len(targets), failed, day.Format("2006-01-02"))
+}
+
+// Recalculate 重新计算单个电池最近 days 天的健康快照（历史回填）
+func (s *BatteryHealth) Recalculate(ctx context.Context, deviceID string, days int, claims *utils.UserClaims, orgID string) error {
+	if days <= 0 || days > 90 {
+		days = 30
+	}
+	if err := checkBatteryHealthAccess(ctx, deviceID, claims, orgID); err != nil {
+		return err
+	}
+	targets, err := listHealthTargets(ctx, deviceID)
+	if err != nil {
+		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	if len(targets) == 0 {
+		return errcode.WithData(errcode.CodeNotFound, map[string]interface{}{"message": "电池不存在"})
+	}
+	today := time.Now()
+	for i := days; i >= 1; i-- {
+		if err := s.refreshDevice(ctx, targets[0], today.AddDate(0, 0, -i)); err != nil {
+			return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+		}
+	}
+	return nil
+}
+
+// checkBatteryHealthAccess 校验设备租户与组织范围
+func checkBatteryHealthAccess(ctx context.Context, deviceID string, claims *utils.UserClaims, orgID string) error {
+	var cnt int64
+	if err := global.DB.WithContext(ctx).Table("devices").
+		Where("id = ? AND tenant_id = ?", deviceID, claims.TenantID).
+		Count(&cnt).Error; err != nil {
+		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	if cnt == 0 {
+		return errcode.WithData(errcode.CodeNotFound, map[string]interface{}{"message": "设备不存在"})
+	}
+	if err := checkDeviceOrgAccess(ctx, deviceID, claims.TenantID, orgID); err != nil {
+		if _, ok := err.(*errcode.Error); ok {
+			return err
+		}
+		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return nil
+}
+
+// GetDeviceHealth 单个电池健康详情（当前指标 + 预测 + 历史）
+func (*BatteryHealth) GetDeviceHealth(ctx context.Context, deviceID string, days int, claims *utils.UserClaims, orgID string) (*model.BatteryHealthDetailResp, error) {
+	if err := checkBatteryHealthAccess(ctx, deviceID, claims, orgID); err != nil {
+		return nil, err
+	}
+	return loadDeviceHealth(ctx, deviceID, days)
+}
+
+// loadDeviceHealth 读取健康详情（调用方负责权限校验）
+func loadDeviceHealth(ctx context.Context, deviceID string, days int) (*model.BatteryHealthDetailResp, error) {
+	if days <= 0 {
+		days = 180
+	}
+	db := global.DB.WithContext(ctx)
+
+	type summaryRow struct {
+		DeviceID         string     `gorm:"column:device_id"`
+		DeviceNumber     string     `gorm:"column:device_number"`
+		BatteryModelName *string    `gorm:"column:battery_model_name"`
+		Soh              *float64   `gorm:"column:soh"`
+		CycleCount       *float64   `gorm:"column:cycle_count"`
+		SohFadePerMonth  *float64   `gorm:"column:soh_fade_per_month"`
+		EolDate          *time.Time `gorm:"column:eol_forecast_date"`
+		EolLower         *time.Time `gorm:"column:eol_forecast_date_lower"`
+		EolUpper         *time.Time `gorm:"column:eol_forecast_date_upper"`
+		HealthUpdatedAt  *time.Time `gorm:"column:health_updated_at"`
+	}
+	var sr summaryRow
+	if err := db.Table("devices AS d").
+		Joins("LEFT JOIN device_batteries dbat ON dbat.device_id = d.id").
+		Joins("LEFT JOIN battery_models bm ON bm.id = dbat.battery_model_id").
+		Select(`d.id AS device_id, d.device_number, bm.name AS battery_model_name,
+			dbat.soh, dbat.cycle_count, dbat.soh_fade_per_month,
+			dbat.eol_forecast_date, dbat.eol_forecast_date_lower, dbat.eol_forecast_date_upper,
+			dbat.health_updated_at`).
+		Where("d.id = ?", deviceID).
+		Scan(&sr).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+
+	var hist []model.BatterySohHistory
+	since := time.Now().AddDate(0, 0, -days).Format("2006-01-02")
+	if err := db.Where("device_id = ? AND stat_date >= ?", deviceID, since).
+		Order("stat_date ASC").
+		Find(&hist).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+
+	points := make([]model.BatteryHealthHistoryPoint, 0, len(hist))
+	for _, h := range hist {
+		points = append(points, model.BatteryHealthHistoryPoint{
+			Date:                   h.StatDate.Format("2006-01-02"),
+			Soh:                    h.Soh,
+			SohSource:              h.SohSource,
+			CapacityAh:             h.CapacityAh,
+			EquivalentCycles:       h.EquivalentCycles,
+			TotalCycles:            h.TotalCycles,
+			CellDeltaAvgMv:         h.CellDeltaAvgMv,
+			CellDeltaMaxMv:         h.CellDeltaMaxMv,
+			InternalResistanceMohm: h.InternalResistanceMohm,
+			TemperatureMax:         h.TemperatureMax,
+		})
+	}
+
+	// 当前 SOH：优先使用最新计算值，否则使用 device_batteries.soh
+	soh := sr.Soh
+	for i := len(hist) - 1; i >= 0; i-- {
+		if hist[i].Soh != nil {
+			soh = hist[i].Soh
+			break
+		}
+	}
+
+	return &model.BatteryHealthDetailResp{
+		DeviceID:         sr.DeviceID,
+		DeviceNumber:     sr.DeviceNumber,
+		BatteryModelName: sr.BatteryModelName,
+		Soh:              soh,
+		CycleCount:       sr.CycleCount,
+		SohFadePerMonth:  sr.SohFadePerMonth,
+		EolThreshold:     EolSohThreshold(),
+		EolForecastDate:  formatDatePtr(sr.EolDate),
+		EolDateLower:     formatDatePtr(sr.EolLower),
+		EolDateUpper:     formatDatePtr(sr.EolUpper),
+		HealthUpdatedAt:  formatTimePtr(sr.HealthUpdatedAt),
+		History:          points,
+	}, nil
+}
+
+func formatDatePtr(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	s := t.Format("2006-01-02")
+	return &s
+}
+
+func formatTimePtr(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	s := t.In(time.Local).Format("2006-01-02 15:04:05")
+	return &s
+}
+
+// ListEolForecast 预测在 before_date（默认当年年底）前 SOH 跌破阈值的电池
+func (*BatteryHealth) ListEolForecast(ctx context.Context, req model.BatteryHealthForecastListReq, claims *utils.UserClaims, orgID string) (*model.BatteryHealthForecastListResp, error) {
+	before := time.Date(time.Now().Year(), 12, 31, 0, 0, 0, 0, time.Local)
+	if req.BeforeDate != nil && *req.BeforeDate != "" {
+		t, err := time.ParseInLocation("2006-01-02", *req.BeforeDate, time.Local)
+		if err != nil {
+			return nil, errcode.WithData(errcode.CodeParamError, map[string]interface{}{"message": "before_date 格式应为 YYYY-MM-DD"})
+		}
+		before = t
+	}
+
+	db := global.DB.WithContext(ctx).Table("device_batteries AS dbat").
+		Joins("JOIN devices d ON d.id = dbat.device_id").
+		Joins("LEFT JOIN battery_models bm ON bm.id = dbat.battery_model_id").
+		Joins("LEFT JOIN orgs o ON o.id = dbat.owner_org_id").
+		Where("d.tenant_id = ?", claims.TenantID).
+		Where("dbat.eol_forecast_date IS NOT NULL AND dbat.eol_forecast_date <= ?", before.Format("2006-01-02"))
+	if orgID != "" {
+		db = db.Where(`dbat.owner_org_id IN (
+			SELECT descendant_id FROM org_closure WHERE tenant_id = ? AND ancestor_id = ?
+		)`, claims.TenantID, orgID)
+	}
+	if req.DeviceNumber != nil && *req.DeviceNumber != "" {
+		db = db.Where("d.device_number LIKE ?", "%"+*req.DeviceNumber+"%")
+	}
+	if req.BatteryModelID != nil && *req.BatteryModelID != "" {
+		db = db.Where("dbat.battery_model_id = ?", *req.BatteryModelID)
+	}
+	if req.InWarranty != nil {
+		if *req.InWarranty {
+			db = db.Where("dbat.warranty_expire_date IS NOT NULL AND dbat.eol_forecast_date <= dbat.warranty_expire_date")
+		} else {
+			db = db.Where("dbat.warranty_expire_date IS NULL OR dbat.eol_forecast_date > dbat.warranty_expire_date")
+		}
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+
+	type row struct {
+		DeviceID           string     `gorm:"column:device_id"`
+		DeviceNumber       string     `gorm:"column:device_number"`
+		BatteryModelName   *string    `gorm:"column:battery_model_name"`
+		OwnerOrgName       *string    `gorm:"column:owner_org_name"`
+		Soh                *float64   `gorm:"column:soh"`
+		CycleCount         *float64   `gorm:"column:cycle_count"`
+		SohFadePerMonth    *float64   `gorm:"column:soh_fade_per_month"`
+		EolDate            time.Time  `gorm:"column:eol_forecast_date"`
+		EolLower           *time.Time `gorm:"column:eol_forecast_date_lower"`
+		EolUpper           *time.Time `gorm:"column:eol_forecast_date_upper"`
+		WarrantyExpireDate *time.Time `gorm:"column:warranty_expire_date"`
+	}
+	var rows []row
+	if err := db.Select(`dbat.device_id, d.device_number, bm.name AS battery_model_name, o.name AS owner_org_name,
+			dbat.soh, dbat.cycle_count, dbat.soh_fade_per_month,
+			dbat.eol_forecast_date, dbat.eol_forecast_date_lower, dbat.eol_forecast_date_upper,
+			dbat.warranty_expire_date`).
+		Order("dbat.eol_forecast_date ASC").
+		Limit(req.PageSize).
+		Offset((req.Page - 1) * req.PageSize).
+		Scan(&rows).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+
+	list := make([]model.BatteryHealthForecastItem, 0, len(rows))
+	for _, r := range rows {
+		list = append(list, model.BatteryHealthForecastItem{
+			DeviceID:           r.DeviceID,
+			DeviceNumber:       r.DeviceNumber,
+			BatteryModelName:   r.BatteryModelName,
+			OwnerOrgName:       r.OwnerOrgName,
+			Soh:                r.Soh,
+			CycleCount:         r.CycleCount,
+			SohFadePerMonth:    r.SohFadePerMonth,
+			EolForecastDate:    r.EolDate.Format("2006-01-02"),
+			EolDateLower:       formatDatePtr(r.EolLower),
+			EolDateUpper:       formatDatePtr(r.EolUpper),
+			WarrantyExpireDate: formatDatePtr(r.WarrantyExpireDate),
+			InWarranty:         r.WarrantyExpireDate != nil && !r.EolDate.After(*r.WarrantyExpireDate),
+		})
+	}
+
+	return &model.BatteryHealthForecastListResp{
+		List:     list,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, nil
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestEquivalentCycles(t *testing.T) {
+	// 100 -> 0 -> 100：一次完整充放
+	soc := []bmsSample{{TS: 0, Value: 100}, {TS: 1, Value: 50}, {TS: 2, Value: 0}, {TS: 3, Value: 100}}
+	if got := equivalentCycles(soc); math.Abs(got-1) > 1e-9 {
+		t.Fatalf("expected 1 cycle, got %v", got)
+	}
+}
+
+func TestEstimateCapacityAh_ConstantDischarge(t *testing.T) {
+	// 50A 放电 1 小时，SOC 100 -> 50：容量 = 50Ah / 0.5 = 100Ah
+	var soc, current []bmsSample
+	for i := 0; i <= 60; i++ {
+		ts := int64(i) * 60 * 1000
+		soc = append(soc, bmsSample{TS: ts, Value: 100 - float64(i)*50/60})
+		current = append(current, bmsSample{TS: ts, Value: -50})
+	}
+	got, ok := estimateCapacityAh(soc, current)
+	if !ok {
+		t.Fatalf("expected capacity estimate")
+	}
+	if math.Abs(got-100) > 0.5 {
+		t.Fatalf("expected ~100Ah, got %v", got)
+	}
+}
+
+func TestEstimateCapacityAh_SmallSwingIgnored(t *testing.T) {
+	soc := []bmsSample{{TS: 0, Value: 60}, {TS: 60000, Value: 55}}
+	current := []bmsSample{{TS: 0, Value: -10}, {TS: 60000, Value: -10}}
+	if _, ok := estimateCapacityAh(soc, current); ok {
+		t.Fatalf("expected no estimate for small SOC swing")
+	}
+}
+
+func TestInternalResistanceMohm(t *testing.T) {
+	// 每次电流阶跃 10A 伴随 0.5V 压降：R = 50mΩ
+	var voltage, current []bmsSample
+	for i := 0; i < 6; i++ {
+		ts := int64(i) * 1000
+		amps := 0.0
+		volts := 52.0
+		if i%2 == 1 {
+			amps = 10
+			volts = 52.5
+		}
+		current = append(current, bmsSample{TS: ts, Value: amps})
+		voltage = append(voltage, bmsSample{TS: ts, Value: volts})
+	}
+	got, ok := internalResistanceMohm(voltage, current)
+	if !ok {
+		t.Fatalf("expected resistance estimate")
+	}
+	if math.Abs(got-50) > 1e-6 {
+		t.Fatalf("expected 50mΩ, got %v", got)
+	}
+}
+
+func TestCellDeltaStats_MixedUnits(t *testing.T) {
+	maxV := []bmsSample{{TS: 10, Value: 3.350}, {TS: 20, Value: 3360}}
+	minV := []bmsSample{{TS: 5, Value: 3.300}, {TS: 15, Value: 3300}}
+	avg, max, ok := cellDeltaStats(maxV, minV)
+	if !ok {
+		t.Fatalf("expected stats")
+	}
+	if math.Abs(avg-55) > 1e-6 || math.Abs(max-60) > 1e-6 {
+		t.Fatalf("unexpected stats avg=%v max=%v", avg, max)
+	}
+}
+
+func TestForecastSohEol_LinearFade(t *testing.T) {
+	epoch := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	// 每 30 天衰减 1%，从 95% 开始：跌破 80% 约需 450 天
+	var pts []sohPoint
+	for d := 0; d <= 180; d += 10 {
+		noise := 0.05
+		if (d/10)%2 == 0 {
+			noise = -noise
+		}
+		pts = append(pts, sohPoint{X: float64(d), Y: 95 - float64(d)/30 + noise})
+	}
+	f, ok := forecastSohEol(pts, 80, epoch)
+	if !ok || f.Eol == nil {
+		t.Fatalf("expected forecast")
+	}
+	if math.Abs(f.FadePerMonth-1) > 0.05 {
+		t.Fatalf("expected fade ~1%%/month, got %v", f.FadePerMonth)
+	}
+	want := epoch.AddDate(0, 0, 450)
+	if diff := math.Abs(f.Eol.Sub(want).Hours() / 24); diff > 10 {
+		t.Fatalf("expected eol near %s, got %s", want.Format("2006-01-02"), f.Eol.Format("2006-01-02"))
+	}
+	if f.EolLower == nil || f.EolUpper == nil || !f.EolLower.Before(*f.Eol) || !f.EolUpper.After(*f.Eol) {
+		t.Fatalf("expected confidence interval around eol: %v %v %v", f.EolLower, f.Eol, f.EolUpper)
+	}
+}
+
+func TestForecastSohEol_NotEnoughHistory(t *testing.T) {
+	pts := []sohPoint{{X: 0, Y: 95}, {X: 1, Y: 94}, {X: 2, Y: 93}}
+	if _, ok := forecastSohEol(pts, 80, time.Now()); ok {
+		t.Fatalf("expected no forecast for short history")
+	}
+}
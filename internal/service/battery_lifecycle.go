@@ -0,0 +1,342 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"project/internal/model"
+	"project/pkg/errcode"
+	"project/pkg/global"
+	"project/pkg/utils"
+
+	"github.com/go-basic/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BatteryLifecycle 电池生命周期状态机
+type BatteryLifecycle struct{}
+
+// batteryLifecycleTransitions 允许的状态迁移（同状态变更见 canTransitLifecycle）；
+// 售出前的状态（含已发货、已翻新）均可由 APP 绑定直接激活
+var batteryLifecycleTransitions = map[string][]string{
+	model.BatteryLifecycleManufactured: {model.BatteryLifecycleInStock, model.BatteryLifecycleShipped,
+		model.BatteryLifecycleAtDealer, model.BatteryLifecycleActivated, model.BatteryLifecycleScrapped},
+	model.BatteryLifecycleInStock: {model.BatteryLifecycleShipped, model.BatteryLifecycleAtDealer,
+		model.BatteryLifecycleActivated, model.BatteryLifecycleScrapped},
+	model.BatteryLifecycleShipped: {model.BatteryLifecycleAtDealer, model.BatteryLifecycleInStock,
+		model.BatteryLifecycleActivated},
+	model.BatteryLifecycleAtDealer: {model.BatteryLifecycleShipped,
+		model.BatteryLifecycleInStock, model.BatteryLifecycleActivated, model.BatteryLifecycleReturned},
+	model.BatteryLifecycleActivated: {model.BatteryLifecycleInService, model.BatteryLifecycleAtDealer,
+		model.BatteryLifecycleInStock, model.BatteryLifecycleReturned},
+	model.BatteryLifecycleInService: {model.BatteryLifecycleAtDealer, model.BatteryLifecycleInStock,
+		model.BatteryLifecycleReturned, model.BatteryLifecycleScrapped},
+	model.BatteryLifecycleReturned: {model.BatteryLifecycleRefurbished, model.BatteryLifecycleScrapped,
+		model.BatteryLifecycleInService},
+	model.BatteryLifecycleRefurbished: {model.BatteryLifecycleInStock, model.BatteryLifecycleAtDealer,
+		model.BatteryLifecycleActivated, model.BatteryLifecycleScrapped},
+	model.BatteryLifecycleScrapped: {},
+}
+
+// batteryLifecycleInitial 首次建档允许的状态
+var batteryLifecycleInitial = []string{
+	model.BatteryLifecycleManufactured, model.BatteryLifecycleInStock,
+	model.BatteryLifecycleAtDealer, model.BatteryLifecycleActivated,
+}
+
+// batteryLifecycleManual 允许手动设置的目标状态（激活/使用中由绑定与遥测驱动）；
+// 已生产没有入向迁移，只能在首次建档时手动设置
+var batteryLifecycleManual = map[string]bool{
+	model.BatteryLifecycleManufactured: true,
+	model.BatteryLifecycleInStock:      true,
+	model.BatteryLifecycleShipped:      true,
+	model.BatteryLifecycleAtDealer:     true,
+	model.BatteryLifecycleReturned:     true,
+	model.BatteryLifecycleRefurbished:  true,
+	model.BatteryLifecycleScrapped:     true,
+}
+
+// canTransitLifecycle 判断状态迁移是否合法，from 为空表示首次建档；
+// 同状态变更（仅调整归属等字段，如经销商间调拨）除报废外均允许
+func canTransitLifecycle(from, to string) bool {
+	if from != "" && from == to {
+		return from != model.BatteryLifecycleScrapped
+	}
+	targets := batteryLifecycleInitial
+	if from != "" {
+		targets = batteryLifecycleTransitions[from]
+	}
+	for _, t := range targets {
+		if t == to {
+			return true
+		}
+	}
+	return false
+}
+
+// lifecycleLegacyStatus 由生命周期状态派生兼容字段 activation_status / transfer_status
+func lifecycleLegacyStatus(status string) (string, string) {
+	switch status {
+	case model.BatteryLifecycleActivated, model.BatteryLifecycleInService:
+		return "ACTIVE", "USER"
+	case model.BatteryLifecycleShipped, model.BatteryLifecycleAtDealer:
+		return "INACTIVE", "DEALER"
+	default:
+		return "INACTIVE", "FACTORY"
+	}
+}
+
+// custodyLifecycleStatus 归属变更（分配经销商/组织转移）后的状态：
+// 已售出或已退回的电池只变更归属，库存电池按去向转为经销商在库或厂家在库
+func custodyLifecycleStatus(current string, atDealer bool) string {
+	switch current {
+	case model.BatteryLifecycleActivated, model.BatteryLifecycleInService, model.BatteryLifecycleReturned:
+		return current
+	}
+	if atDealer {
+		return model.BatteryLifecycleAtDealer
+	}
+	return model.BatteryLifecycleInStock
+}
+
+// BatteryLifecycleChange 一次生命周期状态变更
+type BatteryLifecycleChange struct {
+	TenantID   string
+	DeviceID   string
+	To         string
+	Event      string
+	OperatorID *string
+	Reason     *string
+	// 与状态一同写入 device_batteries 的其它列（如 dealer_id / owner_org_id / activation_date）
+	Updates map[string]interface{}
+}
+
+// TransitBatteryLifecycle 在事务 tx 内执行状态迁移并记录变更；
+// 电池状态（含 activation_status / transfer_status）只能通过该函数修改。
+// 电池档案不存在时按首次建档处理。
+func TransitBatteryLifecycle(ctx context.Context, tx *gorm.DB, ch BatteryLifecycleChange) error {
+	var current struct {
+		DeviceID        string `gorm:"column:device_id"`
+		LifecycleStatus string `gorm:"column:lifecycle_status"`
+	}
+	if err := tx.WithContext(ctx).Table(model.TableNameDeviceBattery).
+		Select("device_id, lifecycle_status").
+		Where("device_id = ?", ch.DeviceID).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Limit(1).
+		Scan(&current).Error; err != nil {
+		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	exists := current.DeviceID != ""
+	from := current.LifecycleStatus
+
+	if !canTransitLifecycle(from, ch.To) {
+		fromText := from
+		if fromText == "" {
+			fromText = "未建档"
+		}
+		return errcode.WithData(errcode.CodeOpDenied, map[string]interface{}{
+			"message":     fmt.Sprintf("电池状态 %s 不允许变更为 %s", fromText, ch.To),
+			"device_id":   ch.DeviceID,
+			"from_status": from,
+			"to_status":   ch.To,
+		})
+	}
+
+	now := time.Now().UTC()
+	activation, transfer := lifecycleLegacyStatus(ch.To)
+	values := map[string]interface{}{}
+	for k, v := range ch.Updates {
+		values[k] = v
+	}
+	values["lifecycle_status"] = ch.To
+	values["lifecycle_updated_at"] = now
+	values["activation_status"] = activation
+	values["transfer_status"] = transfer
+	if _, ok := values["updated_at"]; !ok {
+		values["updated_at"] = now
+	}
+
+	if exists {
+		if err := tx.WithContext(ctx).Table(model.TableNameDeviceBattery).
+			Where("device_id = ?", ch.DeviceID).
+			Updates(values).Error; err != nil {
+			return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+		}
+	} else {
+		values["device_id"] = ch.DeviceID
+		if err := tx.WithContext(ctx).Table(model.TableNameDeviceBattery).Create(values).Error; err != nil {
+			return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+		}
+	}
+
+	event := &model.BatteryLifecycleEvent{
+		ID:         uuid.New(),
+		TenantID:   ch.TenantID,
+		DeviceID:   ch.DeviceID,
+		ToStatus:   ch.To,
+		Event:      ch.Event,
+		OperatorID: ch.OperatorID,
+		Reason:     ch.Reason,
+		CreatedAt:  now,
+	}
+	if exists {
+		event.FromStatus = &from
+	}
+	if err := tx.WithContext(ctx).Create(event).Error; err != nil {
+		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return nil
+}
+
+// getBatteryLifecycleStatus 读取当前生命周期状态（无档案返回空）
+func getBatteryLifecycleStatus(ctx context.Context, db *gorm.DB, deviceID string) (string, error) {
+	var status string
+	err := db.WithContext(ctx).Table(model.TableNameDeviceBattery).
+		Select("lifecycle_status").
+		Where("device_id = ?", deviceID).
+		Limit(1).
+		Scan(&status).Error
+	return status, err
+}
+
+// resetBatteryActivation 设备解除全部绑定后，已激活/使用中的电池退回经销商在库或厂家在库；
+// 其它状态（如已退回、已报废）保持不变
+func resetBatteryActivation(ctx context.Context, tx *gorm.DB, battery *model.DeviceBattery, claims *utils.UserClaims, reason *string) error {
+	status, err := getBatteryLifecycleStatus(ctx, tx, battery.DeviceID)
+	if err != nil {
+		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	if status != model.BatteryLifecycleActivated && status != model.BatteryLifecycleInService {
+		return nil
+	}
+	atDealer := battery.OwnerOrgID != nil && *battery.OwnerOrgID != ""
+	return TransitBatteryLifecycle(ctx, tx, BatteryLifecycleChange{
+		TenantID:   claims.TenantID,
+		DeviceID:   battery.DeviceID,
+		To:         custodyLifecycleStatus("", atDealer),
+		Event:      model.BatteryLifecycleEventUnbind,
+		OperatorID: &claims.ID,
+		Reason:     reason,
+		Updates:    map[string]interface{}{"activation_date": nil},
+	})
+}
+
+// markBatteryInService 已激活电池首次出现充放电数据时自动转为使用中
+func markBatteryInService(ctx context.Context, tenantID, deviceID string) {
+	status, err := getBatteryLifecycleStatus(ctx, global.DB, deviceID)
+	if err != nil || status != model.BatteryLifecycleActivated {
+		return
+	}
+	reason := "检测到充放电数据"
+	if err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return TransitBatteryLifecycle(ctx, tx, BatteryLifecycleChange{
+			TenantID: tenantID,
+			DeviceID: deviceID,
+			To:       model.BatteryLifecycleInService,
+			Event:    model.BatteryLifecycleEventFirstUse,
+			Reason:   &reason,
+		})
+	}); err != nil {
+		logrus.WithError(err).WithField("device_id", deviceID).Warn("battery lifecycle: mark in service failed")
+	}
+}
+
+// GetLifecycle 电池生命周期详情（当前状态、可手动变更状态、变更记录）
+func (*BatteryLifecycle) GetLifecycle(ctx context.Context, deviceID string, claims *utils.UserClaims, orgID string) (*model.BatteryLifecycleResp, error) {
+	if err := checkBatteryHealthAccess(ctx, deviceID, claims, orgID); err != nil {
+		return nil, err
+	}
+	db := global.DB.WithContext(ctx)
+
+	var row struct {
+		DeviceNumber       string     `gorm:"column:device_number"`
+		LifecycleStatus    *string    `gorm:"column:lifecycle_status"`
+		ActivationStatus   *string    `gorm:"column:activation_status"`
+		TransferStatus     *string    `gorm:"column:transfer_status"`
+		LifecycleUpdatedAt *time.Time `gorm:"column:lifecycle_updated_at"`
+	}
+	if err := db.Table("devices AS d").
+		Select("d.device_number, dbat.lifecycle_status, dbat.activation_status, dbat.transfer_status, dbat.lifecycle_updated_at").
+		Joins("LEFT JOIN device_batteries dbat ON dbat.device_id = d.id").
+		Where("d.id = ?", deviceID).
+		Limit(1).
+		Scan(&row).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+
+	resp := &model.BatteryLifecycleResp{
+		DeviceID:           deviceID,
+		DeviceNumber:       row.DeviceNumber,
+		ActivationStatus:   row.ActivationStatus,
+		TransferStatus:     row.TransferStatus,
+		LifecycleUpdatedAt: formatTimePtr(row.LifecycleUpdatedAt),
+		AllowedTransitions: []string{},
+		Events:             []model.BatteryLifecycleEventResp{},
+	}
+	from := ""
+	if row.LifecycleStatus != nil {
+		from = *row.LifecycleStatus
+		resp.LifecycleStatus = from
+	}
+	candidates := batteryLifecycleInitial
+	if from != "" {
+		candidates = batteryLifecycleTransitions[from]
+	}
+	for _, to := range candidates {
+		if batteryLifecycleManual[to] {
+			resp.AllowedTransitions = append(resp.AllowedTransitions, to)
+		}
+	}
+
+	var events []struct {
+		model.BatteryLifecycleEvent
+		OperatorName *string `gorm:"column:operator_name"`
+	}
+	if err := db.Table("battery_lifecycle_events AS e").
+		Select("e.*, u.name AS operator_name").
+		Joins("LEFT JOIN users u ON u.id = e.operator_id").
+		Where("e.device_id = ?", deviceID).
+		Order("e.created_at DESC").
+		Limit(200).
+		Scan(&events).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	for _, e := range events {
+		resp.Events = append(resp.Events, model.BatteryLifecycleEventResp{
+			ID:           e.ID,
+			FromStatus:   e.FromStatus,
+			ToStatus:     e.ToStatus,
+			Event:        e.Event,
+			OperatorID:   e.OperatorID,
+			OperatorName: e.OperatorName,
+			Reason:       e.Reason,
+			CreatedAt:    e.CreatedAt.In(time.Local).Format("2006-01-02 15:04:05"),
+		})
+	}
+	return resp, nil
+}
+
+// Transition 手动变更电池生命周期状态（发货、退回、翻新、报废等）
+func (*BatteryLifecycle) Transition(ctx context.Context, deviceID string, req model.BatteryLifecycleTransitionReq, claims *utils.UserClaims, orgID string) error {
+	if err := checkBatteryHealthAccess(ctx, deviceID, claims, orgID); err != nil {
+		return err
+	}
+	if !batteryLifecycleManual[req.ToStatus] {
+		return errcode.WithData(errcode.CodeParamError, map[string]interface{}{"message": "该状态不支持手动设置"})
+	}
+	reason := req.Reason
+	return global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return TransitBatteryLifecycle(ctx, tx, BatteryLifecycleChange{
+			TenantID:   claims.TenantID,
+			DeviceID:   deviceID,
+			To:         req.ToStatus,
+			Event:      model.BatteryLifecycleEventManual,
+			OperatorID: &claims.ID,
+			Reason:     &reason,
+		})
+	})
+}
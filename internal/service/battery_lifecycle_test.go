@@ -0,0 +1,83 @@
+package service
+
+import (
+	"testing"
+
+	"project/internal/model"
+)
+
+func TestCanTransitLifecycle(t *testing.T) {
+	cases := []struct {
+		from, to string
+		want     bool
+	}{
+		{"", model.BatteryLifecycleInStock, true},
+		{"", model.BatteryLifecycleScrapped, false},
+		{"", model.BatteryLifecycleManufactured, true},
+		{model.BatteryLifecycleManufactured, model.BatteryLifecycleInStock, true},
+		{model.BatteryLifecycleManufactured, model.BatteryLifecycleAtDealer, true},
+		{model.BatteryLifecycleManufactured, model.BatteryLifecycleInService, false},
+		{model.BatteryLifecycleInStock, model.BatteryLifecycleManufactured, false},
+		{model.BatteryLifecycleInStock, model.BatteryLifecycleAtDealer, true},
+		{model.BatteryLifecycleAtDealer, model.BatteryLifecycleActivated, true},
+		{model.BatteryLifecycleActivated, model.BatteryLifecycleInService, true},
+		{model.BatteryLifecycleInService, model.BatteryLifecycleReturned, true},
+		{model.BatteryLifecycleReturned, model.BatteryLifecycleRefurbished, true},
+		{model.BatteryLifecycleRefurbished, model.BatteryLifecycleInStock, true},
+		{model.BatteryLifecycleInStock, model.BatteryLifecycleInService, false},
+		{model.BatteryLifecycleReturned, model.BatteryLifecycleActivated, false},
+		// 同状态调拨允许，报废为终态
+		{model.BatteryLifecycleAtDealer, model.BatteryLifecycleAtDealer, true},
+		{model.BatteryLifecycleScrapped, model.BatteryLifecycleScrapped, false},
+		{model.BatteryLifecycleScrapped, model.BatteryLifecycleInStock, false},
+	}
+	for _, c := range cases {
+		if got := canTransitLifecycle(c.from, c.to); got != c.want {
+			t.Errorf("canTransitLifecycle(%q, %q) = %v, want %v", c.from, c.to, got, c.want)
+		}
+	}
+}
+
+func TestBindActivatesUnsoldBattery(t *testing.T) {
+	// APP 绑定：未建档及售出前的各状态均可激活，已退回、已报废不可
+	for _, from := range []string{"", model.BatteryLifecycleManufactured, model.BatteryLifecycleInStock,
+		model.BatteryLifecycleShipped, model.BatteryLifecycleAtDealer, model.BatteryLifecycleRefurbished} {
+		if !canTransitLifecycle(from, model.BatteryLifecycleActivated) {
+			t.Errorf("bind from %q rejected", from)
+		}
+	}
+	for _, from := range []string{model.BatteryLifecycleReturned, model.BatteryLifecycleScrapped} {
+		if canTransitLifecycle(from, model.BatteryLifecycleActivated) {
+			t.Errorf("bind from %q accepted", from)
+		}
+	}
+}
+
+func TestLifecycleDerivedStatus(t *testing.T) {
+	if a, tr := lifecycleLegacyStatus(model.BatteryLifecycleInService); a != "ACTIVE" || tr != "USER" {
+		t.Fatalf("in service: got %s/%s", a, tr)
+	}
+	if a, tr := lifecycleLegacyStatus(model.BatteryLifecycleAtDealer); a != "INACTIVE" || tr != "DEALER" {
+		t.Fatalf("at dealer: got %s/%s", a, tr)
+	}
+	if a, tr := lifecycleLegacyStatus(model.BatteryLifecycleReturned); a != "INACTIVE" || tr != "FACTORY" {
+		t.Fatalf("returned: got %s/%s", a, tr)
+	}
+
+	// 归属变更不改变已售出/已退回电池的状态
+	if got := custodyLifecycleStatus(model.BatteryLifecycleInService, true); got != model.BatteryLifecycleInService {
+		t.Fatalf("expected IN_SERVICE kept, got %s", got)
+	}
+	if got := custodyLifecycleStatus(model.BatteryLifecycleInStock, true); got != model.BatteryLifecycleAtDealer {
+		t.Fatalf("expected AT_DEALER, got %s", got)
+	}
+	if got := custodyLifecycleStatus(model.BatteryLifecycleAtDealer, false); got != model.BatteryLifecycleInStock {
+		t.Fatalf("expected IN_STOCK, got %s", got)
+	}
+	// 已生产的电池可直接分配经销商或入库
+	for _, atDealer := range []bool{true, false} {
+		if to := custodyLifecycleStatus(model.BatteryLifecycleManufactured, atDealer); !canTransitLifecycle(model.BatteryLifecycleManufactured, to) {
+			t.Fatalf("custody move from MANUFACTURED to %s rejected", to)
+		}
+	}
+}
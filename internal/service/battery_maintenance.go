@@ -11,6 +11,7 @@ import (
 	"project/pkg/utils"
 
 	"github.com/go-basic/uuid"
+	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
@@ -76,6 +77,11 @@ func (*BatteryMaintenance) Create(ctx context.Context, req model.BatteryMaintena
 	if err := global.DB.WithContext(ctx).Create(rec).Error; err != nil {
 		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
 	}
+
+	// 关闭该设备匹配的维护工单
+	if _, err := closeMaintenanceOrders(ctx, rec); err != nil {
+		logrus.WithError(err).WithField("device_id", rec.DeviceID).Warn("battery maintenance: close orders failed")
+	}
 	return nil
 }
 
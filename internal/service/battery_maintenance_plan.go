@@ -0,0 +1,620 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"project/internal/model"
+	"project/pkg/errcode"
+	"project/pkg/global"
+	"project/pkg/utils"
+
+	"github.com/go-basic/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BatteryMaintenancePlan 电池预测性维护（维护计划 + 维护工单）
+type BatteryMaintenancePlan struct{}
+
+// validateMaintenancePlan 校验触发方式所需参数
+func validateMaintenancePlan(p *model.BatteryMaintenancePlan) error {
+	missing := ""
+	switch p.TriggerType {
+	case model.MaintenanceTriggerCalendar:
+		if p.IntervalDays == nil {
+			missing = "interval_days"
+		}
+	case model.MaintenanceTriggerCycle:
+		if p.CycleInterval == nil {
+			missing = "cycle_interval"
+		}
+	case model.MaintenanceTriggerSoh:
+		if p.SohThreshold == nil {
+			missing = "soh_threshold"
+		}
+	case model.MaintenanceTriggerAlarm:
+		if p.AlarmCount == nil {
+			missing = "alarm_count"
+		} else if p.AlarmWindowDays == nil {
+			missing = "alarm_window_days"
+		}
+	}
+	if missing != "" {
+		return errcode.WithData(errcode.CodeParamError, map[string]interface{}{
+			"message": fmt.Sprintf("触发方式 %s 需要配置 %s", p.TriggerType, missing),
+		})
+	}
+	return nil
+}
+
+// checkNotificationGroup 校验通知组属于当前租户
+func checkNotificationGroup(ctx context.Context, groupID *string, tenantID string) error {
+	if groupID == nil || *groupID == "" {
+		return nil
+	}
+	var cnt int64
+	if err := global.DB.WithContext(ctx).Table("notification_groups").
+		Where("id = ? AND tenant_id = ?", *groupID, tenantID).
+		Count(&cnt).Error; err != nil {
+		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	if cnt == 0 {
+		return errcode.WithData(errcode.CodeParamError, map[string]interface{}{"message": "通知组不存在"})
+	}
+	return nil
+}
+
+func (*BatteryMaintenancePlan) CreatePlan(ctx context.Context, req model.BatteryMaintenancePlanCreateReq, claims *utils.UserClaims) (*model.BatteryMaintenancePlan, error) {
+	var cnt int64
+	if err := global.DB.WithContext(ctx).Table("battery_models").
+		Where("id = ? AND tenant_id = ?", req.BatteryModelID, claims.TenantID).
+		Count(&cnt).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	if cnt == 0 {
+		return nil, errcode.WithData(errcode.CodeParamError, map[string]interface{}{"message": "电池型号不存在"})
+	}
+	if err := checkNotificationGroup(ctx, req.NotificationGroupID, claims.TenantID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	plan := &model.BatteryMaintenancePlan{
+		ID:                  uuid.New(),
+		TenantID:            claims.TenantID,
+		BatteryModelID:      req.BatteryModelID,
+		Name:                req.Name,
+		TriggerType:         req.TriggerType,
+		IntervalDays:        req.IntervalDays,
+		CycleInterval:       req.CycleInterval,
+		SohThreshold:        req.SohThreshold,
+		AlarmCount:          req.AlarmCount,
+		AlarmWindowDays:     req.AlarmWindowDays,
+		MaintenanceType:     req.MaintenanceType,
+		LeadDays:            7,
+		NotificationGroupID: req.NotificationGroupID,
+		Enabled:             true,
+		Remark:              req.Remark,
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}
+	if req.LeadDays != nil {
+		plan.LeadDays = *req.LeadDays
+	}
+	if req.Enabled != nil {
+		plan.Enabled = *req.Enabled
+	}
+	if err := validateMaintenancePlan(plan); err != nil {
+		return nil, err
+	}
+
+	if err := global.DB.WithContext(ctx).Create(plan).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return plan, nil
+}
+
+func (*BatteryMaintenancePlan) UpdatePlan(ctx context.Context, id string, req model.BatteryMaintenancePlanUpdateReq, claims *utils.UserClaims) (*model.BatteryMaintenancePlan, error) {
+	var plan model.BatteryMaintenancePlan
+	if err := global.DB.WithContext(ctx).
+		Where("id = ? AND tenant_id = ?", id, claims.TenantID).
+		First(&plan).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errcode.WithData(errcode.CodeNotFound, map[string]interface{}{"message": "维护计划不存在"})
+		}
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	if err := checkNotificationGroup(ctx, req.NotificationGroupID, claims.TenantID); err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		plan.Name = *req.Name
+	}
+	if req.IntervalDays != nil {
+		plan.IntervalDays = req.IntervalDays
+	}
+	if req.CycleInterval != nil {
+		plan.CycleInterval = req.CycleInterval
+	}
+	if req.SohThreshold != nil {
+		plan.SohThreshold = req.SohThreshold
+	}
+	if req.AlarmCount != nil {
+		plan.AlarmCount = req.AlarmCount
+	}
+	if req.AlarmWindowDays != nil {
+		plan.AlarmWindowDays = req.AlarmWindowDays
+	}
+	if req.MaintenanceType != nil {
+		plan.MaintenanceType = req.MaintenanceType
+	}
+	if req.LeadDays != nil {
+		plan.LeadDays = *req.LeadDays
+	}
+	if req.NotificationGroupID != nil {
+		plan.NotificationGroupID = req.NotificationGroupID
+		if *req.NotificationGroupID == "" {
+			plan.NotificationGroupID = nil
+		}
+	}
+	if req.Enabled != nil {
+		plan.Enabled = *req.Enabled
+	}
+	if req.Remark != nil {
+		plan.Remark = req.Remark
+	}
+	if err := validateMaintenancePlan(&plan); err != nil {
+		return nil, err
+	}
+	plan.UpdatedAt = time.Now().UTC()
+
+	if err := global.DB.WithContext(ctx).Save(&plan).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return &plan, nil
+}
+
+// DeletePlan 删除维护计划（其工单一并删除）
+func (*BatteryMaintenancePlan) DeletePlan(ctx context.Context, id string, claims *utils.UserClaims) error {
+	res := global.DB.WithContext(ctx).
+		Where("id = ? AND tenant_id = ?", id, claims.TenantID).
+		Delete(&model.BatteryMaintenancePlan{})
+	if res.Error != nil {
+		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": res.Error.Error()})
+	}
+	if res.RowsAffected == 0 {
+		return errcode.WithData(errcode.CodeNotFound, map[string]interface{}{"message": "维护计划不存在"})
+	}
+	return nil
+}
+
+func (*BatteryMaintenancePlan) ListPlans(ctx context.Context, req model.BatteryMaintenancePlanListReq, claims *utils.UserClaims) (*model.BatteryMaintenancePlanListResp, error) {
+	db := global.DB.WithContext(ctx).Table("battery_maintenance_plans AS p").
+		Joins("LEFT JOIN battery_models bm ON bm.id = p.battery_model_id").
+		Where("p.tenant_id = ?", claims.TenantID)
+	if req.BatteryModelID != nil && *req.BatteryModelID != "" {
+		db = db.Where("p.battery_model_id = ?", *req.BatteryModelID)
+	}
+	if req.TriggerType != nil && *req.TriggerType != "" {
+		db = db.Where("p.trigger_type = ?", *req.TriggerType)
+	}
+	if req.Enabled != nil {
+		db = db.Where("p.enabled = ?", *req.Enabled)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+
+	var rows []model.BatteryMaintenancePlanResp
+	if err := db.Select(`p.*, bm.name AS battery_model_name,
+			(SELECT COUNT(*) FROM battery_maintenance_orders o WHERE o.plan_id = p.id AND o.status = ?) AS open_orders`,
+		model.MaintenanceOrderOpen).
+		Order("p.created_at DESC").
+		Limit(req.PageSize).
+		Offset((req.Page - 1) * req.PageSize).
+		Scan(&rows).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	if rows == nil {
+		rows = []model.BatteryMaintenancePlanResp{}
+	}
+	return &model.BatteryMaintenancePlanListResp{
+		List:     rows,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, nil
+}
+
+// maintenanceFacts 计划评估所需的单台电池数据
+type maintenanceFacts struct {
+	DeviceID          string     `gorm:"column:device_id"`
+	DeviceNumber      string     `gorm:"column:device_number"`
+	OwnerOrgID        *string    `gorm:"column:owner_org_id"`
+	ActivationDate    *time.Time `gorm:"column:activation_date"`
+	CycleCount        *float64   `gorm:"column:cycle_count"`
+	Soh               *float64   `gorm:"column:soh"`
+	LastMaintenanceAt *time.Time `gorm:"column:last_maintenance_at"`
+	LastOrderStatus   *string    `gorm:"column:last_order_status"`
+	LastOrderAt       *time.Time `gorm:"column:last_order_at"`
+	LastOrderDueDate  *time.Time `gorm:"column:last_order_due_date"`
+	LastOrderValue    *float64   `gorm:"column:last_order_value"`
+	AlarmCount        int64      `gorm:"column:alarm_count"`
+}
+
+// maintenanceDue 计划对单台电池的判定结果
+type maintenanceDue struct {
+	DueDate time.Time
+	Reason  string
+	Value   *float64
+}
+
+// evaluateMaintenancePlan 判定是否需要生成工单（已有未完成工单时不再生成）
+func evaluateMaintenancePlan(p *model.BatteryMaintenancePlan, f maintenanceFacts, now time.Time) (*maintenanceDue, bool) {
+	if f.LastOrderStatus != nil && *f.LastOrderStatus == model.MaintenanceOrderOpen {
+		return nil, false
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	deadline := today.AddDate(0, 0, int(p.LeadDays))
+
+	switch p.TriggerType {
+	case model.MaintenanceTriggerCalendar:
+		// 以最近一次维护（无则激活日）为起点，按间隔推算下一期；本期已生成过工单则顺延
+		base := f.LastMaintenanceAt
+		if base == nil {
+			base = f.ActivationDate
+		}
+		if base == nil || p.IntervalDays == nil || *p.IntervalDays <= 0 {
+			return nil, false
+		}
+		start := time.Date(base.Year(), base.Month(), base.Day(), 0, 0, 0, 0, time.UTC)
+		due := start.AddDate(0, 0, int(*p.IntervalDays))
+		if f.LastOrderAt != nil && f.LastOrderDueDate != nil && !f.LastOrderAt.Before(*base) {
+			for !due.After(*f.LastOrderDueDate) {
+				due = due.AddDate(0, 0, int(*p.IntervalDays))
+			}
+		}
+		if due.After(deadline) {
+			return nil, false
+		}
+		return &maintenanceDue{
+			DueDate: due,
+			Reason:  fmt.Sprintf("按 %d 天间隔定期维护，起算日 %s", *p.IntervalDays, start.Format("2006-01-02")),
+		}, true
+
+	case model.MaintenanceTriggerCycle:
+		if f.CycleCount == nil || p.CycleInterval == nil || *p.CycleInterval <= 0 {
+			return nil, false
+		}
+		baseline := 0.0
+		if f.LastOrderValue != nil {
+			baseline = *f.LastOrderValue
+		}
+		if *f.CycleCount < baseline+*p.CycleInterval {
+			return nil, false
+		}
+		milestone := math.Floor(*f.CycleCount / *p.CycleInterval) * *p.CycleInterval
+		return &maintenanceDue{
+			DueDate: deadline,
+			Reason:  fmt.Sprintf("累计循环 %.0f 次，达到 %.0f 次维护节点", *f.CycleCount, milestone),
+			Value:   &milestone,
+		}, true
+
+	case model.MaintenanceTriggerSoh:
+		// SOH 阈值为一次性触发
+		if f.Soh == nil || p.SohThreshold == nil || f.LastOrderAt != nil || *f.Soh > *p.SohThreshold {
+			return nil, false
+		}
+		soh := *f.Soh
+		return &maintenanceDue{
+			DueDate: deadline,
+			Reason:  fmt.Sprintf("SOH %.1f%% 不高于阈值 %.1f%%", soh, *p.SohThreshold),
+			Value:   &soh,
+		}, true
+
+	case model.MaintenanceTriggerAlarm:
+		if p.AlarmCount == nil || p.AlarmWindowDays == nil || f.AlarmCount < int64(*p.AlarmCount) {
+			return nil, false
+		}
+		// 同一统计窗口内只生成一次
+		if f.LastOrderAt != nil && f.LastOrderAt.After(now.AddDate(0, 0, -int(*p.AlarmWindowDays))) {
+			return nil, false
+		}
+		cnt := float64(f.AlarmCount)
+		return &maintenanceDue{
+			DueDate: deadline,
+			Reason:  fmt.Sprintf("近 %d 天告警 %d 次（阈值 %d 次）", *p.AlarmWindowDays, f.AlarmCount, *p.AlarmCount),
+			Value:   &cnt,
+		}, true
+	}
+	return nil, false
+}
+
+// loadMaintenanceFacts 加载计划对应电池型号下所有电池的评估数据
+func loadMaintenanceFacts(ctx context.Context, p *model.BatteryMaintenancePlan, now time.Time) ([]maintenanceFacts, error) {
+	maintenanceType := ""
+	if p.MaintenanceType != nil {
+		maintenanceType = *p.MaintenanceType
+	}
+	alarmSince := now
+	if p.TriggerType == model.MaintenanceTriggerAlarm && p.AlarmWindowDays != nil {
+		alarmSince = now.AddDate(0, 0, -int(*p.AlarmWindowDays))
+	}
+
+	var facts []maintenanceFacts
+	err := global.DB.WithContext(ctx).Table("device_batteries AS dbat").
+		Select(`dbat.device_id, d.device_number, dbat.owner_org_id, dbat.activation_date, dbat.cycle_count, dbat.soh,
+			(SELECT MAX(r.maintain_at) FROM battery_maintenance_records r
+				WHERE r.device_id = dbat.device_id AND (? = '' OR r.fault_type = ?)) AS last_maintenance_at,
+			lo.status AS last_order_status, lo.created_at AS last_order_at,
+			lo.due_date AS last_order_due_date, lo.trigger_value AS last_order_value,
+			(SELECT COUNT(*) FROM alarm_history ah
+				WHERE ah.tenant_id = d.tenant_id AND ah.create_at >= ?
+				AND ah.alarm_device_list LIKE '%' || dbat.device_id || '%') AS alarm_count`,
+			maintenanceType, maintenanceType, alarmSince).
+		Joins("JOIN devices d ON d.id = dbat.device_id").
+		Joins(`LEFT JOIN LATERAL (
+			SELECT o.status, o.created_at, o.due_date, o.trigger_value FROM battery_maintenance_orders o
+			WHERE o.plan_id = ? AND o.device_id = dbat.device_id
+			ORDER BY o.created_at DESC LIMIT 1
+		) lo ON true`, p.ID).
+		Where("d.tenant_id = ? AND dbat.battery_model_id = ?", p.TenantID, p.BatteryModelID).
+		Scan(&facts).Error
+	return facts, err
+}
+
+// GenerateOrdersByCron 按维护计划生成到期工单，并通知计划配置的通知组
+func (*BatteryMaintenancePlan) GenerateOrdersByCron() {
+	ctx := context.Background()
+	var plans []model.BatteryMaintenancePlan
+	if err := global.DB.WithContext(ctx).Where("enabled = ?", true).Find(&plans).Error; err != nil {
+		logrus.WithError(err).Error("battery maintenance: list plans failed")
+		return
+	}
+
+	now := time.Now().UTC()
+	total := 0
+	for i := range plans {
+		p := &plans[i]
+		facts, err := loadMaintenanceFacts(ctx, p, now)
+		if err != nil {
+			logrus.WithError(err).WithField("plan_id", p.ID).Warn("battery maintenance: load facts failed")
+			continue
+		}
+
+		var created []string
+		for _, f := range facts {
+			due, ok := evaluateMaintenancePlan(p, f, now)
+			if !ok {
+				continue
+			}
+			order := &model.BatteryMaintenanceOrder{
+				ID:            uuid.New(),
+				TenantID:      p.TenantID,
+				PlanID:        p.ID,
+				DeviceID:      f.DeviceID,
+				AssigneeOrgID: f.OwnerOrgID,
+				Status:        model.MaintenanceOrderOpen,
+				DueDate:       due.DueDate,
+				TriggerReason: due.Reason,
+				TriggerValue:  due.Value,
+				CreatedAt:     now,
+				UpdatedAt:     now,
+			}
+			res := global.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(order)
+			if res.Error != nil {
+				logrus.WithError(res.Error).WithField("device_id", f.DeviceID).Warn("battery maintenance: create order failed")
+				continue
+			}
+			if res.RowsAffected > 0 {
+				created = append(created, fmt.Sprintf("%s（到期 %s）：%s", f.DeviceNumber, due.DueDate.Format("2006-01-02"), due.Reason))
+			}
+		}
+		total += len(created)
+
+		if len(created) > 0 && p.NotificationGroupID != nil && *p.NotificationGroupID != "" {
+			notifyMaintenanceOrders(p, created, now)
+		}
+	}
+	logrus.Debugf("battery maintenance: %d orders generated from %d plans", total, len(plans))
+}
+
+// notifyMaintenanceOrders 通过通知组推送新生成的工单（按计划汇总为一条）
+func notifyMaintenanceOrders(p *model.BatteryMaintenancePlan, lines []string, now time.Time) {
+	subject := fmt.Sprintf("电池维护提醒：%s（%d 台）", p.Name, len(lines))
+	payload := map[string]interface{}{
+		"event_type": model.NotificationEventMaintenance,
+		"subject":    subject,
+		"content":    subject + "\n" + strings.Join(lines, "\n"),
+		"timestamp":  now.Format(time.RFC3339),
+		"tenant_id":  p.TenantID,
+		"plan_id":    p.ID,
+		"plan_name":  p.Name,
+	}
+	buffer := &bytes.Buffer{}
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(payload); err != nil {
+		logrus.WithError(err).Error("battery maintenance: build notification failed")
+		return
+	}
+	GroupApp.NotificationServicesConfig.ExecuteNotification(*p.NotificationGroupID, strings.TrimSpace(buffer.String()))
+}
+
+// closeMaintenanceOrders 维保记录录入后关闭该设备匹配的未完成工单
+func closeMaintenanceOrders(ctx context.Context, rec *model.BatteryMaintenanceRecord) (int64, error) {
+	now := time.Now().UTC()
+	res := global.DB.WithContext(ctx).Table("battery_maintenance_orders AS o").
+		Where("o.device_id = ? AND o.status = ?", rec.DeviceID, model.MaintenanceOrderOpen).
+		Where("o.created_at::date <= ?::date", rec.MaintainAt).
+		Where(`o.plan_id IN (
+			SELECT p.id FROM battery_maintenance_plans p
+			WHERE p.maintenance_type IS NULL OR p.maintenance_type = '' OR p.maintenance_type = ?
+		)`, rec.FaultType).
+		Updates(map[string]interface{}{
+			"status":                model.MaintenanceOrderClosed,
+			"maintenance_record_id": rec.ID,
+			"closed_at":             now,
+			"updated_at":            now,
+		})
+	return res.RowsAffected, res.Error
+}
+
+// withOrderOrgScope 按组织子树隔离工单（orgID 为空时不隔离）
+func withOrderOrgScope(db *gorm.DB, tenantID, orgID string) *gorm.DB {
+	if orgID == "" {
+		return db
+	}
+	return db.Where(`o.assignee_org_id IN (
+		SELECT descendant_id FROM org_closure WHERE tenant_id = ? AND ancestor_id = ?
+	)`, tenantID, orgID)
+}
+
+func maintenanceOrderBaseQuery(ctx context.Context, claims *utils.UserClaims, orgID string) *gorm.DB {
+	db := global.DB.WithContext(ctx).Table("battery_maintenance_orders AS o").
+		Joins("JOIN battery_maintenance_plans p ON p.id = o.plan_id").
+		Joins("JOIN devices d ON d.id = o.device_id").
+		Joins("LEFT JOIN device_batteries dbat ON dbat.device_id = o.device_id").
+		Joins("LEFT JOIN battery_models bm ON bm.id = dbat.battery_model_id").
+		Joins("LEFT JOIN orgs org ON org.id = o.assignee_org_id").
+		Where("o.tenant_id = ?", claims.TenantID)
+	return withOrderOrgScope(db, claims.TenantID, orgID)
+}
+
+type maintenanceOrderRow struct {
+	model.BatteryMaintenanceOrder
+	PlanName         string  `gorm:"column:plan_name"`
+	TriggerType      string  `gorm:"column:trigger_type"`
+	MaintenanceType  *string `gorm:"column:maintenance_type"`
+	DeviceNumber     string  `gorm:"column:device_number"`
+	BatteryModelName *string `gorm:"column:battery_model_name"`
+	AssigneeOrgName  *string `gorm:"column:assignee_org_name"`
+}
+
+func scanMaintenanceOrders(db *gorm.DB) ([]model.BatteryMaintenanceOrderItemResp, error) {
+	var rows []maintenanceOrderRow
+	if err := db.Select(`o.*, p.name AS plan_name, p.trigger_type, p.maintenance_type,
+		d.device_number, bm.name AS battery_model_name, org.name AS assignee_org_name`).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	out := make([]model.BatteryMaintenanceOrderItemResp, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, model.BatteryMaintenanceOrderItemResp{
+			ID:                  r.ID,
+			PlanID:              r.PlanID,
+			PlanName:            r.PlanName,
+			TriggerType:         r.TriggerType,
+			MaintenanceType:     r.MaintenanceType,
+			DeviceID:            r.DeviceID,
+			DeviceNumber:        r.DeviceNumber,
+			BatteryModelName:    r.BatteryModelName,
+			AssigneeOrgID:       r.AssigneeOrgID,
+			AssigneeOrgName:     r.AssigneeOrgName,
+			Status:              r.Status,
+			DueDate:             r.DueDate.Format("2006-01-02"),
+			Overdue:             r.Status == model.MaintenanceOrderOpen && r.DueDate.Before(today),
+			TriggerReason:       r.TriggerReason,
+			TriggerValue:        r.TriggerValue,
+			MaintenanceRecordID: r.MaintenanceRecordID,
+			ClosedAt:            formatTimePtr(r.ClosedAt),
+			CreatedAt:           r.CreatedAt.In(time.Local).Format("2006-01-02 15:04:05"),
+		})
+	}
+	return out, nil
+}
+
+// ListOrders 维护工单列表（按组织范围隔离）
+func (*BatteryMaintenancePlan) ListOrders(ctx context.Context, req model.BatteryMaintenanceOrderListReq, claims *utils.UserClaims, orgID string) (*model.BatteryMaintenanceOrderListResp, error) {
+	db := maintenanceOrderBaseQuery(ctx, claims, orgID)
+	if req.Status != nil && *req.Status != "" {
+		db = db.Where("o.status = ?", *req.Status)
+	}
+	if req.PlanID != nil && *req.PlanID != "" {
+		db = db.Where("o.plan_id = ?", *req.PlanID)
+	}
+	if req.DeviceNumber != nil && *req.DeviceNumber != "" {
+		db = db.Where("d.device_number LIKE ?", "%"+*req.DeviceNumber+"%")
+	}
+	if req.DueBefore != nil && *req.DueBefore != "" {
+		if t, err := time.Parse("2006-01-02", *req.DueBefore); err == nil {
+			db = db.Where("o.due_date <= ?", t)
+		}
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	list, err := scanMaintenanceOrders(db.Order("o.due_date ASC, o.created_at DESC").
+		Limit(req.PageSize).Offset((req.Page - 1) * req.PageSize))
+	if err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return &model.BatteryMaintenanceOrderListResp{
+		List:     list,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, nil
+}
+
+// ListDueOrders 未来 N 天内到期（含已逾期）的未完成工单，默认本周
+func (*BatteryMaintenancePlan) ListDueOrders(ctx context.Context, req model.BatteryMaintenanceDueReq, claims *utils.UserClaims, orgID string) ([]model.BatteryMaintenanceOrderItemResp, error) {
+	days := req.Days
+	if days <= 0 {
+		days = 7
+	}
+	now := time.Now()
+	until := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, days)
+
+	list, err := scanMaintenanceOrders(maintenanceOrderBaseQuery(ctx, claims, orgID).
+		Where("o.status = ? AND o.due_date <= ?", model.MaintenanceOrderOpen, until).
+		Order("o.due_date ASC"))
+	if err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return list, nil
+}
+
+// CancelOrder 取消未完成工单
+func (*BatteryMaintenancePlan) CancelOrder(ctx context.Context, id string, claims *utils.UserClaims, orgID string) error {
+	db := global.DB.WithContext(ctx).Table("battery_maintenance_orders AS o").
+		Where("o.id = ? AND o.tenant_id = ?", id, claims.TenantID)
+	db = withOrderOrgScope(db, claims.TenantID, orgID)
+
+	var order model.BatteryMaintenanceOrder
+	if err := db.Select("o.*").Take(&order).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errcode.WithData(errcode.CodeNotFound, map[string]interface{}{"message": "工单不存在"})
+		}
+		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	if order.Status != model.MaintenanceOrderOpen {
+		return errcode.WithData(errcode.CodeOpDenied, map[string]interface{}{"message": "仅可取消未完成工单"})
+	}
+
+	now := time.Now().UTC()
+	if err := global.DB.WithContext(ctx).Model(&model.BatteryMaintenanceOrder{}).
+		Where("id = ? AND status = ?", id, model.MaintenanceOrderOpen).
+		Updates(map[string]interface{}{
+			"status":     model.MaintenanceOrderCancelled,
+			"closed_at":  now,
+			"updated_at": now,
+		}).Error; err != nil {
+		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"project/internal/model"
+)
+
+func TestEvaluateMaintenancePlan_Calendar(t *testing.T) {
+	interval := int32(90)
+	p := &model.BatteryMaintenancePlan{TriggerType: model.MaintenanceTriggerCalendar, IntervalDays: &interval, LeadDays: 7}
+	now := time.Date(2025, 3, 28, 3, 30, 0, 0, time.UTC)
+	lastMaint := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC) // 下一期 2025-04-01
+
+	due, ok := evaluateMaintenancePlan(p, maintenanceFacts{LastMaintenanceAt: &lastMaint}, now)
+	if !ok || due.DueDate.Format("2006-01-02") != "2025-04-01" {
+		t.Fatalf("expected order due 2025-04-01 within lead days, got %+v %v", due, ok)
+	}
+
+	// 本期工单已取消：顺延到下一期，不在提前期内
+	cancelled := model.MaintenanceOrderCancelled
+	orderAt := time.Date(2025, 3, 25, 3, 30, 0, 0, time.UTC)
+	orderDue := due.DueDate
+	f := maintenanceFacts{LastMaintenanceAt: &lastMaint, LastOrderStatus: &cancelled, LastOrderAt: &orderAt, LastOrderDueDate: &orderDue}
+	if _, ok := evaluateMaintenancePlan(p, f, now); ok {
+		t.Fatalf("expected no order for the already-generated period")
+	}
+}
+
+func TestEvaluateMaintenancePlan_CycleMilestones(t *testing.T) {
+	interval := 500.0
+	p := &model.BatteryMaintenancePlan{TriggerType: model.MaintenanceTriggerCycle, CycleInterval: &interval}
+	now := time.Now().UTC()
+
+	cycles := 1120.0
+	due, ok := evaluateMaintenancePlan(p, maintenanceFacts{CycleCount: &cycles}, now)
+	if !ok || due.Value == nil || *due.Value != 1000 {
+		t.Fatalf("expected milestone 1000, got %+v %v", due, ok)
+	}
+
+	closed := model.MaintenanceOrderClosed
+	last := 1000.0
+	if _, ok := evaluateMaintenancePlan(p, maintenanceFacts{CycleCount: &cycles, LastOrderStatus: &closed, LastOrderValue: &last}, now); ok {
+		t.Fatalf("expected no order before next milestone")
+	}
+}
+
+func TestEvaluateMaintenancePlan_OpenOrderSkipped(t *testing.T) {
+	threshold := 80.0
+	p := &model.BatteryMaintenancePlan{TriggerType: model.MaintenanceTriggerSoh, SohThreshold: &threshold}
+	soh := 75.0
+	open := model.MaintenanceOrderOpen
+	if _, ok := evaluateMaintenancePlan(p, maintenanceFacts{Soh: &soh, LastOrderStatus: &open}, time.Now()); ok {
+		t.Fatalf("expected open order to suppress generation")
+	}
+	if _, ok := evaluateMaintenancePlan(p, maintenanceFacts{Soh: &soh}, time.Now()); !ok {
+		t.Fatalf("expected SOH below threshold to generate order")
+	}
+}
+
+func TestEvaluateMaintenancePlan_AlarmWindow(t *testing.T) {
+	count, window := int32(3), int32(30)
+	p := &model.BatteryMaintenancePlan{TriggerType: model.MaintenanceTriggerAlarm, AlarmCount: &count, AlarmWindowDays: &window}
+	now := time.Now().UTC()
+	recent := now.AddDate(0, 0, -10)
+	closed := model.MaintenanceOrderClosed
+
+	if _, ok := evaluateMaintenancePlan(p, maintenanceFacts{AlarmCount: 5, LastOrderStatus: &closed, LastOrderAt: &recent}, now); ok {
+		t.Fatalf("expected one order per alarm window")
+	}
+	if _, ok := evaluateMaintenancePlan(p, maintenanceFacts{AlarmCount: 5}, now); !ok {
+		t.Fatalf("expected alarm count over threshold to generate order")
+	}
+}
@@ -154,10 +154,31 @@ func (*BmsDashboard) GetAlarmOverview(ctx context.Context, claims *utils.UserCla
 		trend = append(trend, model.BmsDashboardAlarmTrendPoint{Date: r.Day, Count: r.Cnt})
 	}
 
+	// MTTA/MTTR：近N天触发的告警（不含恢复记录）
+	var mean struct {
+		Mtta *float64 `gorm:"column:mtta"`
+		Mttr *float64 `gorm:"column:mttr"`
+	}
+	meanQ := db.Table("alarm_history AS ah").
+		Select("AVG(EXTRACT(EPOCH FROM ah.acknowledged_at - ah.create_at)) AS mtta, AVG(EXTRACT(EPOCH FROM ah.processed_at - ah.create_at)) AS mttr").
+		Where("ah.tenant_id = ? AND ah.severity IS NOT NULL AND ah.create_at >= ?", claims.TenantID, start)
+	if orgID != "" {
+		meanQ = meanQ.Where(`EXISTS (
+			SELECT 1 FROM jsonb_array_elements_text(ah.alarm_device_list) AS ad(device_id)
+			JOIN device_batteries dbat ON dbat.device_id = ad.device_id
+			WHERE dbat.owner_org_id IN (SELECT descendant_id FROM org_closure WHERE tenant_id = ? AND ancestor_id = ?)
+		)`, claims.TenantID, orgID)
+	}
+	if err := meanQ.Scan(&mean).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+
 	return &model.BmsDashboardAlarmOverviewResp{
 		StatusCounts: statusCounts,
 		Top:          top,
 		Trend:        trend,
+		MttaSeconds:  mean.Mtta,
+		MttrSeconds:  mean.Mttr,
 	}, nil
 }
 
@@ -202,6 +223,80 @@ func (*BmsDashboard) GetOnlineTrend(ctx context.Context, claims *utils.UserClaim
 	return &model.BmsDashboardOnlineTrendResp{Points: points}, nil
 }
 
+// GetBatteryHealth 电池健康概览（SOH 分布 / 平均循环 / 今年预计跌破寿命阈值数量）
+func (*BmsDashboard) GetBatteryHealth(ctx context.Context, claims *utils.UserClaims, orgID string) (*model.BmsDashboardBatteryHealthResp, error) {
+	db := global.DB.WithContext(ctx)
+
+	base := db.Table("device_batteries AS dbat").
+		Joins("JOIN devices AS d ON d.id = dbat.device_id").
+		Where("d.tenant_id = ?", claims.TenantID)
+	if orgID != "" {
+		base = base.Where(`dbat.owner_org_id IN (
+			SELECT descendant_id FROM org_closure WHERE tenant_id = ? AND ancestor_id = ?
+		)`, claims.TenantID, orgID)
+	}
+
+	type bucketRow struct {
+		Bucket string `gorm:"column:bucket"`
+		Cnt    int64  `gorm:"column:cnt"`
+	}
+	var bucketRows []bucketRow
+	if err := base.Session(&gorm.Session{}).
+		Select(`CASE
+			WHEN dbat.soh >= 90 THEN '90-100'
+			WHEN dbat.soh >= 80 THEN '80-90'
+			WHEN dbat.soh >= 70 THEN '70-80'
+			ELSE '0-70'
+		END AS bucket, COUNT(1) AS cnt`).
+		Where("dbat.soh IS NOT NULL").
+		Group("bucket").
+		Scan(&bucketRows).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	counts := make(map[string]int64, len(bucketRows))
+	for _, r := range bucketRows {
+		counts[r.Bucket] = r.Cnt
+	}
+	buckets := make([]model.BmsDashboardSohBucket, 0, 4)
+	for _, b := range []string{"90-100", "80-90", "70-80", "0-70"} {
+		buckets = append(buckets, model.BmsDashboardSohBucket{Range: b, Count: counts[b]})
+	}
+
+	var avg struct {
+		AvgSoh        *float64 `gorm:"column:avg_soh"`
+		AvgCycleCount *float64 `gorm:"column:avg_cycle_count"`
+	}
+	if err := base.Session(&gorm.Session{}).
+		Select("AVG(dbat.soh) AS avg_soh, AVG(dbat.cycle_count) AS avg_cycle_count").
+		Scan(&avg).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+
+	yearEnd := time.Date(time.Now().Year(), 12, 31, 0, 0, 0, 0, time.Local).Format("2006-01-02")
+	eolQ := base.Session(&gorm.Session{}).
+		Where("dbat.eol_forecast_date IS NOT NULL AND dbat.eol_forecast_date <= ?", yearEnd)
+
+	var eolThisYear int64
+	if err := eolQ.Session(&gorm.Session{}).Count(&eolThisYear).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	var eolInWarranty int64
+	if err := eolQ.Session(&gorm.Session{}).
+		Where("dbat.warranty_expire_date IS NOT NULL AND dbat.eol_forecast_date <= dbat.warranty_expire_date").
+		Count(&eolInWarranty).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+
+	return &model.BmsDashboardBatteryHealthResp{
+		SohBuckets:            buckets,
+		AvgSoh:                avg.AvgSoh,
+		AvgCycleCount:         avg.AvgCycleCount,
+		EolThreshold:          EolSohThreshold(),
+		EolThisYear:           eolThisYear,
+		EolThisYearInWarranty: eolInWarranty,
+	}, nil
+}
+
 // 让 gorm/gen 的 query 引用被 go 编译器认为已使用（避免未来 refactor 误删）
 var _ = query.Device
 var _ = gorm.ErrRecordNotFound
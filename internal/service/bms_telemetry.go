@@ -0,0 +1,60 @@
+package service
+
+import (
+	"sort"
+
+	dal "project/internal/dal"
+
+	"github.com/spf13/viper"
+)
+
+// BMS 遥测标识符（物模型 key）。不同 BMS 板卡的物模型命名可能不同，可通过配置 bms.telemetry_keys.* 覆盖。
+const (
+	BmsKeySoc            = "soc"              // 剩余电量(%)
+	BmsKeySoh            = "soh"              // 健康度(%)，BMS 自报
+	BmsKeyCurrent        = "current"          // 电流(A)，充电为正、放电为负
+	BmsKeyVoltage        = "voltage"          // 总电压(V)
+	BmsKeyCellVoltageMax = "cell_voltage_max" // 单体最高电压(V)
+	BmsKeyCellVoltageMin = "cell_voltage_min" // 单体最低电压(V)
+	BmsKeyTemperatureMax = "temperature_max"  // 最高温度(℃)
+)
+
+// bmsTelemetryKey 返回 BMS 指标对应的实际遥测 key（配置优先）
+func bmsTelemetryKey(name string) string {
+	if k := viper.GetString("bms.telemetry_keys." + name); k != "" {
+		return k
+	}
+	return name
+}
+
+// bmsSample 数值型遥测采样点
+type bmsSample struct {
+	TS    int64 // 毫秒时间戳
+	Value float64
+}
+
+// loadBmsSeries 读取设备某一指标在 [startMs, endMs] 内的数值序列（按时间升序）
+func loadBmsSeries(deviceID, name string, startMs, endMs int64) ([]bmsSample, error) {
+	rows, err := dal.GetHistoryTelemetrData(deviceID, bmsTelemetryKey(name), startMs, endMs)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]bmsSample, 0, len(rows))
+	for _, r := range rows {
+		if r == nil || r.NumberV == nil {
+			continue
+		}
+		out = append(out, bmsSample{TS: r.T, Value: *r.NumberV})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].TS < out[j].TS })
+	return out, nil
+}
+
+// seriesValueAt 取 ts 时刻（含）之前最近的采样值；序列需按时间升序
+func seriesValueAt(series []bmsSample, ts int64) (float64, bool) {
+	idx := sort.Search(len(series), func(i int) bool { return series[i].TS > ts })
+	if idx == 0 {
+		return 0, false
+	}
+	return series[idx-1].Value, true
+}
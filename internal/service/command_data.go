@@ -36,8 +36,28 @@ func (c *CommandData) CommandPutMessage(ctx context.Context, operatorID string,
 	return err
 }
 
-// CommandPutMessageReturnMessageID 下发命令并返回 message_id（供离线指令等场景关联）
+// CommandPutMessageReturnMessageID 下发命令并返回 message_id（供离线指令等场景关联），下发结果写入设备审计
 func (c *CommandData) CommandPutMessageReturnMessageID(ctx context.Context, operatorID string, putMessageReq *model.PutMessageForCommand, operationType string) (string, error) {
+	messageId, err := c.commandPutMessage(ctx, putMessageReq, operationType)
+
+	after := map[string]interface{}{"identify": putMessageReq.Identify}
+	if putMessageReq.Value != nil && json.Valid([]byte(*putMessageReq.Value)) {
+		after["params"] = json.RawMessage(*putMessageReq.Value)
+	}
+	GroupApp.DeviceAudit.Record(ctx, DeviceAuditEntry{
+		DeviceID: putMessageReq.DeviceID,
+		Action:   model.DeviceAuditCommand,
+		Source:   deviceAuditSource(operationType),
+		ActorID:  operatorID,
+		Target:   putMessageReq.Identify,
+		After:    after,
+		Err:      err,
+		RefID:    messageId,
+	})
+	return messageId, err
+}
+
+func (c *CommandData) commandPutMessage(ctx context.Context, putMessageReq *model.PutMessageForCommand, operationType string) (string, error) {
 	// 1. 获取设备信息
 	device, err := initialize.GetDeviceCacheById(putMessageReq.DeviceID)
 	if err != nil {
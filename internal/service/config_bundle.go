@@ -0,0 +1,1851 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"project/initialize"
+	"project/internal/model"
+	"project/pkg/errcode"
+	"project/pkg/global"
+	"project/pkg/utils"
+
+	"github.com/go-basic/uuid"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ConfigBundle 配置即代码：设备模板、设备配置（含数据脚本、Topic 映射）、告警配置与场景联动导出为声明式配置包（YAML/JSON），
+// 在另一环境中预览差异后于一个事务内应用。
+// 不同环境中同一对象的ID不同，配置包以 key 标识对象、以 key 相互引用，本实例在 config_bundle_bindings 中记录 key 与对象ID的映射
+type ConfigBundle struct{}
+
+// 配置包中的对象集合（导出时按集合筛选）
+const (
+	configBundleKindTemplates   = "device_templates"
+	configBundleKindConfigs     = "device_configs"
+	configBundleKindAlarms      = "alarm_configs"
+	configBundleKindAutomations = "scene_automations"
+)
+
+// 引用配置包之外的本实例对象：设备以设备编号引用，其余以名称引用
+const (
+	bundleRefDevice            = "DEVICE"
+	bundleRefGroup             = "GROUP"
+	bundleRefScene             = "SCENE"
+	bundleRefNotificationGroup = "NOTIFICATION_GROUP"
+	bundleRefHolidayCalendar   = "HOLIDAY_CALENDAR"
+)
+
+// bundleConditionRefs 场景联动条件 trigger_source 的引用类型（按条件类型）
+var bundleConditionRefs = map[string]string{
+	model.DEVICE_TRIGGER_CONDITION_TYPE_ONE:      bundleRefDevice,
+	model.DEVICE_TRIGGER_CONDITION_TYPE_MULTIPLE: model.ConfigBundleDeviceConfig,
+	model.DEVICE_TRIGGER_CONDITION_TYPE_GROUP:    bundleRefGroup,
+	model.DEVICE_TRIGGER_CONDITION_TYPE_TEMPLATE: model.ConfigBundleDeviceTemplate,
+}
+
+// bundleActionRefs 场景联动动作 action_target 的引用类型（按动作类型）
+var bundleActionRefs = map[string]string{
+	model.AUTOMATE_ACTION_TYPE_ONE:      bundleRefDevice,
+	model.AUTOMATE_ACTION_TYPE_MULTIPLE: model.ConfigBundleDeviceConfig,
+	model.AUTOMATE_ACTION_TYPE_SCENE:    bundleRefScene,
+	model.AUTOMATE_ACTION_TYPE_ALARM:    model.ConfigBundleAlarmConfig,
+}
+
+// 定时触发条件（存于一次性任务、周期任务表）
+const (
+	bundleConditionOnceTask     = "20"
+	bundleConditionPeriodicTask = "21"
+)
+
+// ParseConfigBundle 解析配置包（YAML 或 JSON），不允许未知字段
+func ParseConfigBundle(data []byte) (*model.ConfigBundle, error) {
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid bundle: %w", err)
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bundle: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	var bundle model.ConfigBundle
+	if err := dec.Decode(&bundle); err != nil {
+		return nil, fmt.Errorf("invalid bundle: %w", err)
+	}
+	return &bundle, nil
+}
+
+// encodeConfigBundle 序列化配置包；YAML 保持字段顺序，多行文本（如脚本）输出为块文本，便于在 git 中比较
+func encodeConfigBundle(bundle *model.ConfigBundle, format string) ([]byte, error) {
+	b, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil || format == "json" {
+		return b, err
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(b, &node); err != nil {
+		return nil, err
+	}
+	clearYAMLStyle(&node)
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// clearYAMLStyle 去掉从 JSON 解析得到的流式风格与引号，输出为块风格
+func clearYAMLStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		clearYAMLStyle(c)
+	}
+}
+
+// parseConfigBundleKinds 导出的对象集合，为空时全部导出
+func parseConfigBundleKinds(kinds *string) (map[string]bool, error) {
+	all := []string{configBundleKindTemplates, configBundleKindConfigs, configBundleKindAlarms, configBundleKindAutomations}
+	out := make(map[string]bool)
+	if kinds == nil || strings.TrimSpace(*kinds) == "" {
+		for _, k := range all {
+			out[k] = true
+		}
+		return out, nil
+	}
+	for _, k := range strings.Split(*kinds, ",") {
+		k = strings.TrimSpace(k)
+		if k == "" {
+			continue
+		}
+		valid := false
+		for _, a := range all {
+			valid = valid || a == k
+		}
+		if !valid {
+			return nil, fmt.Errorf("unknown kind %q", k)
+		}
+		out[k] = true
+	}
+	return out, nil
+}
+
+// bundleJSONValue JSON 文本列转换为配置包中的结构化数据；空对象、空数组视为未设置，不是 JSON 对象或数组时保留原文
+func bundleJSONValue(s *string) interface{} {
+	if s == nil || strings.TrimSpace(*s) == "" {
+		return nil
+	}
+	var v interface{}
+	if err := json.Unmarshal([]byte(*s), &v); err == nil {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			if len(t) == 0 {
+				return nil
+			}
+			return v
+		case []interface{}:
+			if len(t) == 0 {
+				return nil
+			}
+			return v
+		}
+	}
+	return *s
+}
+
+// bundleJSONText 配置包中的结构化数据转换为 JSON 文本列
+func bundleJSONText(v interface{}) (*string, error) {
+	switch t := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		if t == "" {
+			return nil, nil
+		}
+		return &t, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	s := string(b)
+	return &s, nil
+}
+
+// bundleFieldChanges 两个配置包对象逐字段比较，返回不同的字段
+func bundleFieldChanges(current, desired interface{}) ([]string, error) {
+	a, err := bundleFields(current)
+	if err != nil {
+		return nil, err
+	}
+	b, err := bundleFields(desired)
+	if err != nil {
+		return nil, err
+	}
+	var fields []string
+	for k, v := range a {
+		if b[k] != v {
+			fields = append(fields, k)
+		}
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			fields = append(fields, k)
+		}
+	}
+	sort.Strings(fields)
+	return fields, nil
+}
+
+// bundleFields 对象各字段的规范化 JSON（map 按键排序）
+func bundleFields(v interface{}) (map[string]string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	out := make(map[string]string, len(m))
+	for k, val := range m {
+		c, err := json.Marshal(val)
+		if err != nil {
+			return nil, err
+		}
+		out[k] = string(c)
+	}
+	return out, nil
+}
+
+// canonicalJSON 规范化 JSON，用于排序与指纹
+func canonicalJSON(v interface{}) string {
+	b, _ := json.Marshal(v)
+	var generic interface{}
+	if err := json.Unmarshal(b, &generic); err != nil {
+		return string(b)
+	}
+	b, _ = json.Marshal(generic)
+	return string(b)
+}
+
+// assignBundleKeys 现有对象的 key：已有映射的沿用映射，其余使用名称，重名时追加ID前缀区分
+func assignBundleKeys(names map[string]string, bound map[string]string) map[string]string {
+	keys := make(map[string]string, len(names))
+	used := make(map[string]bool)
+	for key, id := range bound {
+		if _, ok := names[id]; ok {
+			keys[id] = key
+		}
+		used[key] = true
+	}
+	var unbound []string
+	nameCount := make(map[string]int)
+	for id, name := range names {
+		if _, ok := keys[id]; !ok {
+			unbound = append(unbound, id)
+			nameCount[name]++
+		}
+	}
+	sort.Slice(unbound, func(i, j int) bool {
+		if names[unbound[i]] != names[unbound[j]] {
+			return names[unbound[i]] < names[unbound[j]]
+		}
+		return unbound[i] < unbound[j]
+	})
+	for _, id := range unbound {
+		key := names[id]
+		if key == "" || used[key] || nameCount[names[id]] > 1 {
+			suffix := id
+			if len(suffix) > 8 {
+				suffix = suffix[:8]
+			}
+			key = strings.TrimSpace(key + "-" + suffix)
+			if used[key] {
+				key = names[id] + "-" + id
+			}
+		}
+		keys[id] = key
+		used[key] = true
+	}
+	return keys
+}
+
+// bundleNameIndex 本实例对象名称（设备为设备编号）与ID的对应
+type bundleNameIndex struct {
+	byID   map[string]string
+	byName map[string][]string
+}
+
+func newBundleNameIndex() *bundleNameIndex {
+	return &bundleNameIndex{byID: make(map[string]string), byName: make(map[string][]string)}
+}
+
+func (x *bundleNameIndex) add(id, name string) {
+	if _, ok := x.byID[id]; ok {
+		return
+	}
+	x.byID[id] = name
+	x.byName[name] = append(x.byName[name], id)
+}
+
+// configBundleRefs 配置包中的引用与本实例对象ID的相互转换
+type configBundleRefs struct {
+	keys  map[string]map[string]string // 对象类型 -> 对象ID -> key
+	ids   map[string]map[string]string // 对象类型 -> key -> 对象ID
+	names map[string]*bundleNameIndex  // 配置包之外的对象
+}
+
+func newConfigBundleRefs() *configBundleRefs {
+	r := &configBundleRefs{
+		keys:  make(map[string]map[string]string),
+		ids:   make(map[string]map[string]string),
+		names: make(map[string]*bundleNameIndex),
+	}
+	for _, t := range []string{model.ConfigBundleDeviceTemplate, model.ConfigBundleDeviceConfig, model.ConfigBundleAlarmConfig, model.ConfigBundleSceneAutomation} {
+		r.keys[t] = make(map[string]string)
+		r.ids[t] = make(map[string]string)
+	}
+	for _, t := range []string{bundleRefDevice, bundleRefGroup, bundleRefScene, bundleRefNotificationGroup, bundleRefHolidayCalendar} {
+		r.names[t] = newBundleNameIndex()
+	}
+	return r
+}
+
+// setKey 记录对象的 key（同一对象只保留一个 key）
+func (r *configBundleRefs) setKey(objectType, id, key string) {
+	if old, ok := r.keys[objectType][id]; ok {
+		delete(r.ids[objectType], old)
+	}
+	r.keys[objectType][id] = key
+	r.ids[objectType][key] = id
+}
+
+// toKey 对象ID转换为配置包中的引用；找不到对应对象时保留原值
+func (r *configBundleRefs) toKey(kind string, id *string) *string {
+	id = emptyToNil(id)
+	if id == nil || kind == "" {
+		return id
+	}
+	if m, ok := r.keys[kind]; ok {
+		if k, ok := m[*id]; ok {
+			return &k
+		}
+		return id
+	}
+	if n, ok := r.names[kind].byID[*id]; ok {
+		return &n
+	}
+	return id
+}
+
+// toID 配置包中的引用转换为本实例对象ID
+func (r *configBundleRefs) toID(kind string, key *string) (*string, error) {
+	key = emptyToNil(key)
+	if key == nil || kind == "" {
+		return key, nil
+	}
+	label := strings.ToLower(kind)
+	if m, ok := r.ids[kind]; ok {
+		if id, ok := m[*key]; ok {
+			return &id, nil
+		}
+		return nil, fmt.Errorf("unknown %s key %q", label, *key)
+	}
+	ids := r.names[kind].byName[*key]
+	switch len(ids) {
+	case 0:
+		return nil, fmt.Errorf("%s %q not found", label, *key)
+	case 1:
+		return &ids[0], nil
+	}
+	return nil, fmt.Errorf("%s %q is ambiguous", label, *key)
+}
+
+type bundleTemplateState struct {
+	Template   model.DeviceTemplate
+	Telemetry  []model.DeviceModelTelemetry
+	Attributes []model.DeviceModelAttribute
+	Events     []model.DeviceModelEvent
+	Commands   []model.DeviceModelCommand
+}
+
+type bundleConfigState struct {
+	Config   model.DeviceConfig
+	Scripts  []model.DataScript
+	Mappings []model.DeviceTopicMapping
+}
+
+type bundleAutomationState struct {
+	Automation model.SceneAutomation
+	Conditions []model.DeviceTriggerCondition
+	OneTime    []model.OneTimeTask
+	Periodic   []model.PeriodicTask
+	Actions    []model.ActionInfo
+}
+
+// configBundleState 租户当前的配置对象
+type configBundleState struct {
+	tenantID    string
+	templates   map[string]*bundleTemplateState
+	configs     map[string]*bundleConfigState
+	alarms      map[string]*model.AlarmConfig
+	automations map[string]*bundleAutomationState
+	bindings    map[string]map[string]string // 对象类型 -> key -> 对象ID
+	refs        *configBundleRefs
+}
+
+// loadConfigBundleState 读取租户当前的配置对象、映射与被引用对象的名称
+func loadConfigBundleState(ctx context.Context, db *gorm.DB, tenantID string) (*configBundleState, error) {
+	db = db.WithContext(ctx)
+	s := &configBundleState{
+		tenantID:    tenantID,
+		templates:   make(map[string]*bundleTemplateState),
+		configs:     make(map[string]*bundleConfigState),
+		alarms:      make(map[string]*model.AlarmConfig),
+		automations: make(map[string]*bundleAutomationState),
+		bindings:    make(map[string]map[string]string),
+		refs:        newConfigBundleRefs(),
+	}
+
+	var templates []model.DeviceTemplate
+	if err := db.Where("tenant_id = ?", tenantID).Find(&templates).Error; err != nil {
+		return nil, err
+	}
+	for i := range templates {
+		s.templates[templates[i].ID] = &bundleTemplateState{Template: templates[i]}
+	}
+	var telemetry []model.DeviceModelTelemetry
+	if err := db.Where("tenant_id = ?", tenantID).Find(&telemetry).Error; err != nil {
+		return nil, err
+	}
+	for _, m := range telemetry {
+		if st := s.templates[m.DeviceTemplateID]; st != nil {
+			st.Telemetry = append(st.Telemetry, m)
+		}
+	}
+	var attributes []model.DeviceModelAttribute
+	if err := db.Where("tenant_id = ?", tenantID).Find(&attributes).Error; err != nil {
+		return nil, err
+	}
+	for _, m := range attributes {
+		if st := s.templates[m.DeviceTemplateID]; st != nil {
+			st.Attributes = append(st.Attributes, m)
+		}
+	}
+	var events []model.DeviceModelEvent
+	if err := db.Where("tenant_id = ?", tenantID).Find(&events).Error; err != nil {
+		return nil, err
+	}
+	for _, m := range events {
+		if st := s.templates[m.DeviceTemplateID]; st != nil {
+			st.Events = append(st.Events, m)
+		}
+	}
+	var commands []model.DeviceModelCommand
+	if err := db.Where("tenant_id = ?", tenantID).Find(&commands).Error; err != nil {
+		return nil, err
+	}
+	for _, m := range commands {
+		if st := s.templates[m.DeviceTemplateID]; st != nil {
+			st.Commands = append(st.Commands, m)
+		}
+	}
+
+	var configs []model.DeviceConfig
+	if err := db.Where("tenant_id = ?", tenantID).Find(&configs).Error; err != nil {
+		return nil, err
+	}
+	configIDs := make([]string, 0, len(configs))
+	for i := range configs {
+		s.configs[configs[i].ID] = &bundleConfigState{Config: configs[i]}
+		configIDs = append(configIDs, configs[i].ID)
+	}
+	if len(configIDs) > 0 {
+		var scripts []model.DataScript
+		if err := db.Where("device_config_id IN ?", configIDs).Find(&scripts).Error; err != nil {
+			return nil, err
+		}
+		for _, m := range scripts {
+			s.configs[m.DeviceConfigID].Scripts = append(s.configs[m.DeviceConfigID].Scripts, m)
+		}
+		var mappings []model.DeviceTopicMapping
+		if err := db.Where("device_config_id IN ?", configIDs).Find(&mappings).Error; err != nil {
+			return nil, err
+		}
+		for _, m := range mappings {
+			s.configs[m.DeviceConfigID].Mappings = append(s.configs[m.DeviceConfigID].Mappings, m)
+		}
+	}
+
+	var alarms []model.AlarmConfig
+	if err := db.Where("tenant_id = ?", tenantID).Find(&alarms).Error; err != nil {
+		return nil, err
+	}
+	for i := range alarms {
+		s.alarms[alarms[i].ID] = &alarms[i]
+	}
+
+	var automations []model.SceneAutomation
+	if err := db.Where("tenant_id = ?", tenantID).Find(&automations).Error; err != nil {
+		return nil, err
+	}
+	automationIDs := make([]string, 0, len(automations))
+	for i := range automations {
+		s.automations[automations[i].ID] = &bundleAutomationState{Automation: automations[i]}
+		automationIDs = append(automationIDs, automations[i].ID)
+	}
+	if len(automationIDs) > 0 {
+		var conditions []model.DeviceTriggerCondition
+		if err := db.Where("scene_automation_id IN ?", automationIDs).Find(&conditions).Error; err != nil {
+			return nil, err
+		}
+		for _, m := range conditions {
+			s.automations[m.SceneAutomationID].Conditions = append(s.automations[m.SceneAutomationID].Conditions, m)
+		}
+		var once []model.OneTimeTask
+		if err := db.Where("scene_automation_id IN ?", automationIDs).Find(&once).Error; err != nil {
+			return nil, err
+		}
+		for _, m := range once {
+			s.automations[m.SceneAutomationID].OneTime = append(s.automations[m.SceneAutomationID].OneTime, m)
+		}
+		var periodic []model.PeriodicTask
+		if err := db.Where("scene_automation_id IN ?", automationIDs).Find(&periodic).Error; err != nil {
+			return nil, err
+		}
+		for _, m := range periodic {
+			s.automations[m.SceneAutomationID].Periodic = append(s.automations[m.SceneAutomationID].Periodic, m)
+		}
+		var actions []model.ActionInfo
+		if err := db.Where("scene_automation_id IN ?", automationIDs).Find(&actions).Error; err != nil {
+			return nil, err
+		}
+		for _, m := range actions {
+			s.automations[m.SceneAutomationID].Actions = append(s.automations[m.SceneAutomationID].Actions, m)
+		}
+	}
+
+	var bindings []model.ConfigBundleBinding
+	if err := db.Where("tenant_id = ?", tenantID).Find(&bindings).Error; err != nil {
+		return nil, err
+	}
+	for _, b := range bindings {
+		if s.bindings[b.ObjectType] == nil {
+			s.bindings[b.ObjectType] = make(map[string]string)
+		}
+		s.bindings[b.ObjectType][b.BundleKey] = b.ObjectID
+	}
+
+	// 现有对象的 key
+	for objectType, names := range s.objectNames() {
+		for id, key := range assignBundleKeys(names, s.bindings[objectType]) {
+			s.refs.setKey(objectType, id, key)
+		}
+	}
+
+	// 配置包之外被引用的对象
+	for kind, table := range map[string]string{
+		bundleRefGroup:             model.TableNameGroup,
+		bundleRefScene:             model.TableNameSceneInfo,
+		bundleRefNotificationGroup: model.TableNameNotificationGroup,
+		bundleRefHolidayCalendar:   model.TableNameHolidayCalendar,
+	} {
+		var rows []struct {
+			ID   string
+			Name string
+		}
+		if err := db.Table(table).Select("id, name").Where("tenant_id = ?", tenantID).Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			s.refs.names[kind].add(row.ID, row.Name)
+		}
+	}
+	var deviceIDs []string
+	for _, st := range s.automations {
+		for _, c := range st.Conditions {
+			if c.TriggerConditionType == model.DEVICE_TRIGGER_CONDITION_TYPE_ONE && c.TriggerSource != nil {
+				deviceIDs = append(deviceIDs, *c.TriggerSource)
+			}
+		}
+		for _, a := range st.Actions {
+			if a.ActionType == model.AUTOMATE_ACTION_TYPE_ONE && a.ActionTarget != nil {
+				deviceIDs = append(deviceIDs, *a.ActionTarget)
+			}
+		}
+	}
+	if err := s.loadDevices(db, "id", deviceIDs); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// loadDevices 按设备ID或设备编号读取被引用的设备
+func (s *configBundleState) loadDevices(db *gorm.DB, column string, values []string) error {
+	if len(values) == 0 {
+		return nil
+	}
+	var rows []struct {
+		ID           string
+		DeviceNumber string
+	}
+	if err := db.Table(model.TableNameDevice).Select("id, device_number").
+		Where("tenant_id = ? AND "+column+" IN ?", s.tenantID, values).Scan(&rows).Error; err != nil {
+		return err
+	}
+	for _, row := range rows {
+		s.refs.names[bundleRefDevice].add(row.ID, row.DeviceNumber)
+	}
+	return nil
+}
+
+// objectNames 各类对象的ID与名称
+func (s *configBundleState) objectNames() map[string]map[string]string {
+	out := map[string]map[string]string{
+		model.ConfigBundleDeviceTemplate:  make(map[string]string),
+		model.ConfigBundleDeviceConfig:    make(map[string]string),
+		model.ConfigBundleAlarmConfig:     make(map[string]string),
+		model.ConfigBundleSceneAutomation: make(map[string]string),
+	}
+	for id, st := range s.templates {
+		out[model.ConfigBundleDeviceTemplate][id] = st.Template.Name
+	}
+	for id, st := range s.configs {
+		out[model.ConfigBundleDeviceConfig][id] = st.Config.Name
+	}
+	for id, a := range s.alarms {
+		out[model.ConfigBundleAlarmConfig][id] = a.Name
+	}
+	for id, st := range s.automations {
+		out[model.ConfigBundleSceneAutomation][id] = st.Automation.Name
+	}
+	return out
+}
+
+// unboundBindings 尚未建立映射的现有对象（导出时以导出的 key 建立映射，对象改名后 key 保持不变）
+func (s *configBundleState) unboundBindings(now time.Time) []model.ConfigBundleBinding {
+	var out []model.ConfigBundleBinding
+	for objectType, keys := range s.refs.keys {
+		for id, key := range keys {
+			if s.bindings[objectType][key] == id {
+				continue
+			}
+			out = append(out, model.ConfigBundleBinding{
+				TenantID:   s.tenantID,
+				ObjectType: objectType,
+				BundleKey:  key,
+				ObjectID:   id,
+				UpdatedAt:  now,
+			})
+		}
+	}
+	return out
+}
+
+// export 当前状态转换为配置包
+func (s *configBundleState) export(kinds map[string]bool) *model.ConfigBundle {
+	bundle := &model.ConfigBundle{APIVersion: model.ConfigBundleAPIVersion}
+	r := s.refs
+	if kinds[configBundleKindTemplates] {
+		for id, st := range s.templates {
+			bundle.DeviceTemplates = append(bundle.DeviceTemplates, exportBundleTemplate(st, r.keys[model.ConfigBundleDeviceTemplate][id]))
+		}
+		sort.Slice(bundle.DeviceTemplates, func(i, j int) bool { return bundle.DeviceTemplates[i].Key < bundle.DeviceTemplates[j].Key })
+	}
+	if kinds[configBundleKindConfigs] {
+		for id, st := range s.configs {
+			bundle.DeviceConfigs = append(bundle.DeviceConfigs, exportBundleConfig(st, r.keys[model.ConfigBundleDeviceConfig][id], r))
+		}
+		sort.Slice(bundle.DeviceConfigs, func(i, j int) bool { return bundle.DeviceConfigs[i].Key < bundle.DeviceConfigs[j].Key })
+	}
+	if kinds[configBundleKindAlarms] {
+		for id, a := range s.alarms {
+			bundle.AlarmConfigs = append(bundle.AlarmConfigs, exportBundleAlarm(a, r.keys[model.ConfigBundleAlarmConfig][id], r))
+		}
+		sort.Slice(bundle.AlarmConfigs, func(i, j int) bool { return bundle.AlarmConfigs[i].Key < bundle.AlarmConfigs[j].Key })
+	}
+	if kinds[configBundleKindAutomations] {
+		for id, st := range s.automations {
+			bundle.SceneAutomations = append(bundle.SceneAutomations, exportBundleAutomation(st, r.keys[model.ConfigBundleSceneAutomation][id], r))
+		}
+		sort.Slice(bundle.SceneAutomations, func(i, j int) bool { return bundle.SceneAutomations[i].Key < bundle.SceneAutomations[j].Key })
+	}
+	return bundle
+}
+
+func sortBundleModelItems(items []model.BundleModelItem) {
+	sort.Slice(items, func(i, j int) bool { return items[i].Identifier < items[j].Identifier })
+}
+
+func exportBundleTemplate(st *bundleTemplateState, key string) model.BundleDeviceTemplate {
+	t := st.Template
+	b := model.BundleDeviceTemplate{
+		Key:            key,
+		Name:           t.Name,
+		Author:         emptyToNil(t.Author),
+		Version:        emptyToNil(t.Version),
+		Description:    emptyToNil(t.Description),
+		Label:          emptyToNil(t.Label),
+		Path:           emptyToNil(t.Path),
+		WebChartConfig: bundleJSONValue(t.WebChartConfig),
+		AppChartConfig: bundleJSONValue(t.AppChartConfig),
+		Remark:         emptyToNil(t.Remark),
+	}
+	for _, m := range st.Telemetry {
+		b.Telemetry = append(b.Telemetry, model.BundleModelItem{
+			Identifier:     m.DataIdentifier,
+			Name:           emptyToNil(m.DataName),
+			ReadWriteFlag:  emptyToNil(m.ReadWriteFlag),
+			DataType:       emptyToNil(m.DataType),
+			Unit:           emptyToNil(m.Unit),
+			Description:    emptyToNil(m.Description),
+			AdditionalInfo: bundleJSONValue(m.AdditionalInfo),
+			Remark:         emptyToNil(m.Remark),
+		})
+	}
+	for _, m := range st.Attributes {
+		b.Attributes = append(b.Attributes, model.BundleModelItem{
+			Identifier:     m.DataIdentifier,
+			Name:           emptyToNil(m.DataName),
+			ReadWriteFlag:  emptyToNil(m.ReadWriteFlag),
+			DataType:       emptyToNil(m.DataType),
+			Unit:           emptyToNil(m.Unit),
+			Description:    emptyToNil(m.Description),
+			AdditionalInfo: bundleJSONValue(m.AdditionalInfo),
+			Remark:         emptyToNil(m.Remark),
+		})
+	}
+	for _, m := range st.Events {
+		b.Events = append(b.Events, model.BundleModelItem{
+			Identifier:     m.DataIdentifier,
+			Name:           emptyToNil(m.DataName),
+			Params:         bundleJSONValue(m.Param),
+			Description:    emptyToNil(m.Description),
+			AdditionalInfo: bundleJSONValue(m.AdditionalInfo),
+			Remark:         emptyToNil(m.Remark),
+		})
+	}
+	for _, m := range st.Commands {
+		b.Commands = append(b.Commands, model.BundleModelItem{
+			Identifier:     m.DataIdentifier,
+			Name:           emptyToNil(m.DataName),
+			Params:         bundleJSONValue(m.Param),
+			Description:    emptyToNil(m.Description),
+			AdditionalInfo: bundleJSONValue(m.AdditionalInfo),
+			Remark:         emptyToNil(m.Remark),
+		})
+	}
+	sortBundleModelItems(b.Telemetry)
+	sortBundleModelItems(b.Attributes)
+	sortBundleModelItems(b.Events)
+	sortBundleModelItems(b.Commands)
+	return b
+}
+
+// checkBundleItemKeys 同一列表中的条目标识不能重复
+func checkBundleItemKeys(field string, keys []string) error {
+	seen := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		if seen[k] {
+			return fmt.Errorf("duplicate %s %q", field, k)
+		}
+		seen[k] = true
+	}
+	return nil
+}
+
+func bundleModelItemKeys(items []model.BundleModelItem) []string {
+	keys := make([]string, len(items))
+	for i, item := range items {
+		keys[i] = item.Identifier
+	}
+	return keys
+}
+
+// templateFromBundle 由配置包生成设备模板及物模型的目标状态；物模型按标识符沿用现有记录
+func templateFromBundle(b *model.BundleDeviceTemplate, id, tenantID string, current *bundleTemplateState, now time.Time) (*bundleTemplateState, error) {
+	for field, items := range map[string][]model.BundleModelItem{
+		"telemetry": b.Telemetry, "attribute": b.Attributes, "event": b.Events, "command": b.Commands,
+	} {
+		if err := checkBundleItemKeys(field, bundleModelItemKeys(items)); err != nil {
+			return nil, err
+		}
+	}
+
+	st := &bundleTemplateState{Template: model.DeviceTemplate{ID: id, TenantID: tenantID, CreatedAt: now}}
+	if current != nil {
+		st.Template = current.Template
+	}
+	t := &st.Template
+	var err error
+	t.Name = b.Name
+	t.Author = emptyToNil(b.Author)
+	t.Version = emptyToNil(b.Version)
+	t.Description = emptyToNil(b.Description)
+	t.Label = emptyToNil(b.Label)
+	t.Path = emptyToNil(b.Path)
+	t.Remark = emptyToNil(b.Remark)
+	if t.WebChartConfig, err = bundleJSONText(b.WebChartConfig); err != nil {
+		return nil, fmt.Errorf("web_chart_config: %w", err)
+	}
+	if t.AppChartConfig, err = bundleJSONText(b.AppChartConfig); err != nil {
+		return nil, fmt.Errorf("app_chart_config: %w", err)
+	}
+	t.UpdatedAt = now
+
+	existingTelemetry := make(map[string]model.DeviceModelTelemetry)
+	existingAttributes := make(map[string]model.DeviceModelAttribute)
+	existingEvents := make(map[string]model.DeviceModelEvent)
+	existingCommands := make(map[string]model.DeviceModelCommand)
+	if current != nil {
+		for _, m := range current.Telemetry {
+			existingTelemetry[m.DataIdentifier] = m
+		}
+		for _, m := range current.Attributes {
+			existingAttributes[m.DataIdentifier] = m
+		}
+		for _, m := range current.Events {
+			existingEvents[m.DataIdentifier] = m
+		}
+		for _, m := range current.Commands {
+			existingCommands[m.DataIdentifier] = m
+		}
+	}
+
+	for _, item := range b.Telemetry {
+		row, ok := existingTelemetry[item.Identifier]
+		if !ok {
+			row = model.DeviceModelTelemetry{ID: uuid.New(), DeviceTemplateID: id, TenantID: tenantID, CreatedAt: now}
+		}
+		row.DataIdentifier = item.Identifier
+		row.DataName = emptyToNil(item.Name)
+		row.ReadWriteFlag = emptyToNil(item.ReadWriteFlag)
+		row.DataType = emptyToNil(item.DataType)
+		row.Unit = emptyToNil(item.Unit)
+		row.Description = emptyToNil(item.Description)
+		row.Remark = emptyToNil(item.Remark)
+		if row.AdditionalInfo, err = bundleJSONText(item.AdditionalInfo); err != nil {
+			return nil, fmt.Errorf("telemetry %s: %w", item.Identifier, err)
+		}
+		row.UpdatedAt = now
+		st.Telemetry = append(st.Telemetry, row)
+	}
+	for _, item := range b.Attributes {
+		row, ok := existingAttributes[item.Identifier]
+		if !ok {
+			row = model.DeviceModelAttribute{ID: uuid.New(), DeviceTemplateID: id, TenantID: tenantID, CreatedAt: now}
+		}
+		row.DataIdentifier = item.Identifier
+		row.DataName = emptyToNil(item.Name)
+		row.ReadWriteFlag = emptyToNil(item.ReadWriteFlag)
+		row.DataType = emptyToNil(item.DataType)
+		row.Unit = emptyToNil(item.Unit)
+		row.Description = emptyToNil(item.Description)
+		row.Remark = emptyToNil(item.Remark)
+		if row.AdditionalInfo, err = bundleJSONText(item.AdditionalInfo); err != nil {
+			return nil, fmt.Errorf("attribute %s: %w", item.Identifier, err)
+		}
+		row.UpdatedAt = now
+		st.Attributes = append(st.Attributes, row)
+	}
+	for _, item := range b.Events {
+		row, ok := existingEvents[item.Identifier]
+		if !ok {
+			row = model.DeviceModelEvent{ID: uuid.New(), DeviceTemplateID: id, TenantID: tenantID, CreatedAt: now}
+		}
+		row.DataIdentifier = item.Identifier
+		row.DataName = emptyToNil(item.Name)
+		row.Description = emptyToNil(item.Description)
+		row.Remark = emptyToNil(item.Remark)
+		if row.Param, err = bundleJSONText(item.Params); err != nil {
+			return nil, fmt.Errorf("event %s: %w", item.Identifier, err)
+		}
+		if row.AdditionalInfo, err = bundleJSONText(item.AdditionalInfo); err != nil {
+			return nil, fmt.Errorf("event %s: %w", item.Identifier, err)
+		}
+		row.UpdatedAt = now
+		st.Events = append(st.Events, row)
+	}
+	for _, item := range b.Commands {
+		row, ok := existingCommands[item.Identifier]
+		if !ok {
+			row = model.DeviceModelCommand{ID: uuid.New(), DeviceTemplateID: id, TenantID: tenantID, CreatedAt: now}
+		}
+		row.DataIdentifier = item.Identifier
+		row.DataName = emptyToNil(item.Name)
+		row.Description = emptyToNil(item.Description)
+		row.Remark = emptyToNil(item.Remark)
+		if row.Param, err = bundleJSONText(item.Params); err != nil {
+			return nil, fmt.Errorf("command %s: %w", item.Identifier, err)
+		}
+		if row.AdditionalInfo, err = bundleJSONText(item.AdditionalInfo); err != nil {
+			return nil, fmt.Errorf("command %s: %w", item.Identifier, err)
+		}
+		row.UpdatedAt = now
+		st.Commands = append(st.Commands, row)
+	}
+	return st, nil
+}
+
+func exportBundleConfig(st *bundleConfigState, key string, r *configBundleRefs) model.BundleDeviceConfig {
+	c := st.Config
+	b := model.BundleDeviceConfig{
+		Key:            key,
+		Name:           c.Name,
+		DeviceTemplate: r.toKey(model.ConfigBundleDeviceTemplate, c.DeviceTemplateID),
+		DeviceType:     c.DeviceType,
+		ProtocolType:   emptyToNil(c.ProtocolType),
+		VoucherType:    emptyToNil(c.VoucherType),
+		ProtocolConfig: bundleJSONValue(c.ProtocolConfig),
+		DeviceConnType: emptyToNil(c.DeviceConnType),
+		AdditionalInfo: bundleJSONValue(c.AdditionalInfo),
+		OtherConfig:    bundleJSONValue(c.OtherConfig),
+		AutoRegister:   c.AutoRegister,
+		ImageURL:       emptyToNil(c.ImageURL),
+		Description:    emptyToNil(c.Description),
+		Remark:         emptyToNil(c.Remark),
+	}
+	for _, s := range st.Scripts {
+		b.DataScripts = append(b.DataScripts, model.BundleDataScript{
+			Name:        s.Name,
+			ScriptType:  s.ScriptType,
+			EnableFlag:  s.EnableFlag,
+			Content:     emptyToNil(s.Content),
+			Description: emptyToNil(s.Description),
+			Remark:      emptyToNil(s.Remark),
+		})
+	}
+	for _, m := range st.Mappings {
+		b.TopicMappings = append(b.TopicMappings, model.BundleTopicMapping{
+			Name:        m.Name,
+			Direction:   m.Direction,
+			SourceTopic: m.SourceTopic,
+			TargetTopic: m.TargetTopic,
+			Priority:    m.Priority,
+			Enabled:     m.Enabled,
+			Description: emptyToNil(m.Description),
+		})
+	}
+	sort.Slice(b.DataScripts, func(i, j int) bool { return b.DataScripts[i].Name < b.DataScripts[j].Name })
+	sort.Slice(b.TopicMappings, func(i, j int) bool {
+		if b.TopicMappings[i].Name != b.TopicMappings[j].Name {
+			return b.TopicMappings[i].Name < b.TopicMappings[j].Name
+		}
+		return b.TopicMappings[i].SourceTopic < b.TopicMappings[j].SourceTopic
+	})
+	return b
+}
+
+// configFromBundle 由配置包生成设备配置的目标状态；数据脚本与 Topic 映射按名称沿用现有记录
+func configFromBundle(b *model.BundleDeviceConfig, id, tenantID string, current *bundleConfigState, r *configBundleRefs, now time.Time) (*bundleConfigState, error) {
+	scriptNames := make([]string, len(b.DataScripts))
+	for i, s := range b.DataScripts {
+		scriptNames[i] = s.Name
+	}
+	if err := checkBundleItemKeys("data script", scriptNames); err != nil {
+		return nil, err
+	}
+	mappingNames := make([]string, len(b.TopicMappings))
+	for i, m := range b.TopicMappings {
+		mappingNames[i] = m.Name
+	}
+	if err := checkBundleItemKeys("topic mapping", mappingNames); err != nil {
+		return nil, err
+	}
+
+	st := &bundleConfigState{Config: model.DeviceConfig{ID: id, TenantID: tenantID, CreatedAt: now, TemplateSecret: StringPtr(uuid.New())}}
+	if current != nil {
+		st.Config = current.Config
+	}
+	c := &st.Config
+	var err error
+	if c.DeviceTemplateID, err = r.toID(model.ConfigBundleDeviceTemplate, b.DeviceTemplate); err != nil {
+		return nil, err
+	}
+	c.Name = b.Name
+	c.DeviceType = b.DeviceType
+	c.ProtocolType = emptyToNil(b.ProtocolType)
+	if c.ProtocolType == nil {
+		c.ProtocolType = StringPtr("MQTT")
+	}
+	c.VoucherType = emptyToNil(b.VoucherType)
+	if c.VoucherType == nil {
+		c.VoucherType = StringPtr("ACCESSTOKEN")
+	}
+	c.DeviceConnType = emptyToNil(b.DeviceConnType)
+	c.AutoRegister = b.AutoRegister
+	c.ImageURL = emptyToNil(b.ImageURL)
+	c.Description = emptyToNil(b.Description)
+	c.Remark = emptyToNil(b.Remark)
+	if c.ProtocolConfig, err = bundleJSONText(b.ProtocolConfig); err != nil {
+		return nil, fmt.Errorf("protocol_config: %w", err)
+	}
+	if c.AdditionalInfo, err = bundleJSONText(b.AdditionalInfo); err != nil {
+		return nil, fmt.Errorf("additional_info: %w", err)
+	}
+	if c.AdditionalInfo == nil {
+		c.AdditionalInfo = StringPtr("{}")
+	}
+	if c.OtherConfig, err = bundleJSONText(b.OtherConfig); err != nil {
+		return nil, fmt.Errorf("other_config: %w", err)
+	}
+	if c.OtherConfig != nil {
+		var otherConfig model.DeviceConfigOtherConfig
+		if err := json.Unmarshal([]byte(*c.OtherConfig), &otherConfig); err != nil {
+			return nil, fmt.Errorf("other_config: %w", err)
+		}
+		if otherConfig.OnlineTimeout != 0 && otherConfig.Heartbeat != 0 {
+			return nil, errcode.New(210001)
+		}
+	}
+	c.UpdatedAt = now
+
+	existingScripts := make(map[string]model.DataScript)
+	existingMappings := make(map[string]model.DeviceTopicMapping)
+	if current != nil {
+		for _, s := range current.Scripts {
+			existingScripts[s.Name] = s
+		}
+		for _, m := range current.Mappings {
+			if _, ok := existingMappings[m.Name]; !ok {
+				existingMappings[m.Name] = m
+			}
+		}
+	}
+	for _, s := range b.DataScripts {
+		row, ok := existingScripts[s.Name]
+		if !ok {
+			created := now
+			row = model.DataScript{ID: uuid.New(), DeviceConfigID: id, CreatedAt: &created}
+		}
+		row.Name = s.Name
+		row.ScriptType = s.ScriptType
+		row.EnableFlag = s.EnableFlag
+		row.Content = emptyToNil(s.Content)
+		row.Description = emptyToNil(s.Description)
+		row.Remark = emptyToNil(s.Remark)
+		updated := now
+		row.UpdatedAt = &updated
+		st.Scripts = append(st.Scripts, row)
+	}
+	for _, m := range b.TopicMappings {
+		row, ok := existingMappings[m.Name]
+		if !ok {
+			row = model.DeviceTopicMapping{DeviceConfigID: id, CreatedAt: now}
+		}
+		row.Name = strings.TrimSpace(m.Name)
+		row.Direction = m.Direction
+		row.SourceTopic = strings.TrimSpace(m.SourceTopic)
+		row.TargetTopic = strings.TrimSpace(m.TargetTopic)
+		row.Priority = m.Priority
+		row.Enabled = m.Enabled
+		row.Description = emptyToNil(m.Description)
+		row.UpdatedAt = now
+		st.Mappings = append(st.Mappings, row)
+	}
+	return st, nil
+}
+
+func exportBundleAlarm(a *model.AlarmConfig, key string, r *configBundleRefs) model.BundleAlarmConfig {
+	return model.BundleAlarmConfig{
+		Key:                         key,
+		Name:                        a.Name,
+		Description:                 emptyToNil(a.Description),
+		ProcessingSuggestions:       emptyToNil(a.ProcessingSuggestions),
+		AlarmLevel:                  a.AlarmLevel,
+		NotificationGroup:           r.toKey(bundleRefNotificationGroup, &a.NotificationGroupID),
+		Enabled:                     a.Enabled,
+		AckSlaMinutes:               a.AckSlaMinutes,
+		ResolveSlaMinutes:           a.ResolveSlaMinutes,
+		EscalationMinutes:           a.EscalationMinutes,
+		EscalationNotificationGroup: r.toKey(bundleRefNotificationGroup, a.EscalationNotificationGroupID),
+		GroupBy:                     emptyToNil(a.GroupBy),
+		GroupWindowSeconds:          a.GroupWindowSeconds,
+		FlapThreshold:               a.FlapThreshold,
+		FlapWindowSeconds:           a.FlapWindowSeconds,
+		Remark:                      emptyToNil(a.Remark),
+	}
+}
+
+// alarmFromBundle 由配置包生成告警配置的目标状态
+func alarmFromBundle(b *model.BundleAlarmConfig, id, tenantID string, current *model.AlarmConfig, r *configBundleRefs, now time.Time) (*model.AlarmConfig, error) {
+	a := &model.AlarmConfig{ID: id, TenantID: tenantID, CreatedAt: now}
+	if current != nil {
+		row := *current
+		a = &row
+	}
+	group, err := r.toID(bundleRefNotificationGroup, b.NotificationGroup)
+	if err != nil {
+		return nil, err
+	}
+	a.NotificationGroupID = ""
+	if group != nil {
+		a.NotificationGroupID = *group
+	}
+	if a.EscalationNotificationGroupID, err = r.toID(bundleRefNotificationGroup, b.EscalationNotificationGroup); err != nil {
+		return nil, err
+	}
+	a.Name = b.Name
+	a.Description = emptyToNil(b.Description)
+	a.ProcessingSuggestions = emptyToNil(b.ProcessingSuggestions)
+	a.AlarmLevel = b.AlarmLevel
+	a.Enabled = b.Enabled
+	a.AckSlaMinutes = b.AckSlaMinutes
+	a.ResolveSlaMinutes = b.ResolveSlaMinutes
+	a.EscalationMinutes = b.EscalationMinutes
+	a.GroupBy = emptyToNil(b.GroupBy)
+	a.GroupWindowSeconds = b.GroupWindowSeconds
+	a.FlapThreshold = b.FlapThreshold
+	a.FlapWindowSeconds = b.FlapWindowSeconds
+	a.Remark = emptyToNil(b.Remark)
+	a.UpdatedAt = now
+	return a, nil
+}
+
+// sortBundleAutomation 条件组、组内条件与动作在库中没有顺序，按内容排序使导出结果稳定
+func sortBundleAutomation(b *model.BundleSceneAutomation) {
+	for _, group := range b.TriggerConditionGroups {
+		sort.Slice(group, func(i, j int) bool { return canonicalJSON(group[i]) < canonicalJSON(group[j]) })
+	}
+	sort.Slice(b.TriggerConditionGroups, func(i, j int) bool {
+		return canonicalJSON(b.TriggerConditionGroups[i]) < canonicalJSON(b.TriggerConditionGroups[j])
+	})
+	sort.Slice(b.Actions, func(i, j int) bool { return canonicalJSON(b.Actions[i]) < canonicalJSON(b.Actions[j]) })
+}
+
+func exportBundleAutomation(st *bundleAutomationState, key string, r *configBundleRefs) model.BundleSceneAutomation {
+	a := st.Automation
+	b := model.BundleSceneAutomation{
+		Key:                    key,
+		Name:                   a.Name,
+		Description:            emptyToNil(a.Description),
+		Enabled:                a.Enabled,
+		Timezone:               emptyToNil(a.Timezone),
+		HolidayCalendar:        r.toKey(bundleRefHolidayCalendar, a.HolidayCalendarID),
+		TriggerConditionGroups: [][]model.BundleCondition{},
+		Actions:                []model.BundleAction{},
+		Remark:                 emptyToNil(a.Remark),
+	}
+	groups := make(map[string][]model.BundleCondition)
+	var groupIDs []string
+	for _, c := range st.Conditions {
+		if _, ok := groups[c.GroupID]; !ok {
+			groupIDs = append(groupIDs, c.GroupID)
+		}
+		groups[c.GroupID] = append(groups[c.GroupID], model.BundleCondition{
+			TriggerConditionsType: c.TriggerConditionType,
+			TriggerSource:         r.toKey(bundleConditionRefs[c.TriggerConditionType], c.TriggerSource),
+			TriggerParamType:      emptyToNil(c.TriggerParamType),
+			TriggerParam:          emptyToNil(c.TriggerParam),
+			TriggerOperator:       emptyToNil(c.TriggerOperator),
+			TriggerValue:          emptyToNil(&c.TriggerValue),
+			HoldDuration:          c.HoldDuration,
+			SampleWindow:          c.SampleWindow,
+			SampleHits:            c.SampleHits,
+			ClearValue:            emptyToNil(c.ClearValue),
+			WindowFunc:            emptyToNil(c.WindowFunc),
+			WindowSeconds:         c.WindowSeconds,
+			AggregateFunc:         emptyToNil(c.AggregateFunc),
+			MemberOperator:        emptyToNil(c.MemberOperator),
+			MemberValue:           emptyToNil(c.MemberValue),
+			AggregateBySubgroup:   c.AggregateBySubgroup,
+		})
+	}
+	for _, id := range groupIDs {
+		b.TriggerConditionGroups = append(b.TriggerConditionGroups, groups[id])
+	}
+	// 定时触发单独成组
+	for _, t := range st.OneTime {
+		executionTime := t.ExecutionTime.UTC()
+		expirationTime := int(t.ExpirationTime)
+		b.TriggerConditionGroups = append(b.TriggerConditionGroups, []model.BundleCondition{{
+			TriggerConditionsType: bundleConditionOnceTask,
+			ExecutionTime:         &executionTime,
+			ExpirationTime:        &expirationTime,
+		}})
+	}
+	// 周期任务的执行时间由调度推进，不属于配置
+	for _, t := range st.Periodic {
+		expirationTime := int(t.ExpirationTime)
+		b.TriggerConditionGroups = append(b.TriggerConditionGroups, []model.BundleCondition{{
+			TriggerConditionsType: bundleConditionPeriodicTask,
+			TaskType:              StringPtr(t.TaskType),
+			Params:                StringPtr(t.Param),
+			ExpirationTime:        &expirationTime,
+		}})
+	}
+	for _, act := range st.Actions {
+		b.Actions = append(b.Actions, model.BundleAction{
+			ActionType:      act.ActionType,
+			ActionTarget:    r.toKey(bundleActionRefs[act.ActionType], act.ActionTarget),
+			ActionParamType: emptyToNil(act.ActionParamType),
+			ActionParam:     emptyToNil(act.ActionParam),
+			ActionValue:     emptyToNil(act.ActionValue),
+		})
+	}
+	sortBundleAutomation(&b)
+	return b
+}
+
+// automationFromBundle 由配置包生成场景联动的目标状态；条件、定时任务与动作整体重建（与编辑场景联动一致）
+func automationFromBundle(b *model.BundleSceneAutomation, id, tenantID string, current *bundleAutomationState, r *configBundleRefs, claims *utils.UserClaims, now time.Time) (*bundleAutomationState, error) {
+	st := &bundleAutomationState{Automation: model.SceneAutomation{ID: id, TenantID: tenantID, Creator: claims.ID, CreatedAt: now}}
+	if current != nil {
+		st.Automation = current.Automation
+	}
+	a := &st.Automation
+	var err error
+	if a.HolidayCalendarID, err = r.toID(bundleRefHolidayCalendar, b.HolidayCalendar); err != nil {
+		return nil, err
+	}
+	a.Name = b.Name
+	a.Description = emptyToNil(b.Description)
+	a.Enabled = b.Enabled
+	a.Timezone = emptyToNil(b.Timezone)
+	a.Remark = emptyToNil(b.Remark)
+	a.Updator = claims.ID
+	updated := now
+	a.UpdatedAt = &updated
+	if err := validateSceneZone(a.Timezone, a.HolidayCalendarID, tenantID); err != nil {
+		return nil, err
+	}
+
+	validateGroups := make([][]model.Condition, 0, len(b.TriggerConditionGroups))
+	for _, group := range b.TriggerConditionGroups {
+		groupID := uuid.New()
+		var oneCondition, multipleCondition bool
+		validateGroup := make([]model.Condition, 0, len(group))
+		for _, c := range group {
+			source, err := r.toID(bundleConditionRefs[c.TriggerConditionsType], c.TriggerSource)
+			if err != nil {
+				return nil, err
+			}
+			switch c.TriggerConditionsType {
+			case bundleConditionOnceTask:
+				if c.ExecutionTime == nil {
+					return nil, fmt.Errorf("one-time trigger requires execution_time")
+				}
+				task := model.OneTimeTask{
+					ID:                uuid.New(),
+					SceneAutomationID: id,
+					ExecutionTime:     c.ExecutionTime.UTC(),
+					ExecutingState:    "NEX",
+					Enabled:           b.Enabled,
+				}
+				if c.ExpirationTime != nil {
+					task.ExpirationTime = int64(*c.ExpirationTime)
+				}
+				st.OneTime = append(st.OneTime, task)
+			case bundleConditionPeriodicTask:
+				if c.TaskType == nil || c.Params == nil {
+					return nil, fmt.Errorf("periodic trigger requires task_type and params")
+				}
+				task := model.PeriodicTask{
+					ID:                uuid.New(),
+					SceneAutomationID: id,
+					TaskType:          *c.TaskType,
+					Param:             *c.Params,
+					Enabled:           b.Enabled,
+				}
+				if c.ExpirationTime != nil {
+					task.ExpirationTime = int64(*c.ExpirationTime)
+				}
+				st.Periodic = append(st.Periodic, task)
+			default:
+				oneCondition = oneCondition || c.TriggerConditionsType == model.DEVICE_TRIGGER_CONDITION_TYPE_ONE
+				multipleCondition = multipleCondition || c.TriggerConditionsType == model.DEVICE_TRIGGER_CONDITION_TYPE_MULTIPLE
+				dtc := model.DeviceTriggerCondition{
+					ID:                   uuid.New(),
+					SceneAutomationID:    id,
+					Enabled:              b.Enabled,
+					GroupID:              groupID,
+					TriggerConditionType: c.TriggerConditionsType,
+					TriggerSource:        source,
+					TriggerParamType:     emptyToNil(c.TriggerParamType),
+					TriggerParam:         emptyToNil(c.TriggerParam),
+					TriggerOperator:      emptyToNil(c.TriggerOperator),
+					HoldDuration:         c.HoldDuration,
+					SampleWindow:         c.SampleWindow,
+					SampleHits:           c.SampleHits,
+					ClearValue:           emptyToNil(c.ClearValue),
+					WindowFunc:           emptyToNil(c.WindowFunc),
+					WindowSeconds:        c.WindowSeconds,
+					AggregateFunc:        emptyToNil(c.AggregateFunc),
+					MemberOperator:       emptyToNil(c.MemberOperator),
+					MemberValue:          emptyToNil(c.MemberValue),
+					AggregateBySubgroup:  c.AggregateBySubgroup,
+					TenantID:             tenantID,
+				}
+				if c.TriggerValue != nil {
+					dtc.TriggerValue = *c.TriggerValue
+				}
+				st.Conditions = append(st.Conditions, dtc)
+			}
+			validateGroup = append(validateGroup, model.Condition{
+				TriggerConditionsType: c.TriggerConditionsType,
+				TriggerSource:         source,
+				TriggerParamType:      c.TriggerParamType,
+				TriggerParam:          c.TriggerParam,
+				TriggerOperator:       c.TriggerOperator,
+				TriggerValue:          c.TriggerValue,
+				HoldDuration:          c.HoldDuration,
+				SampleWindow:          c.SampleWindow,
+				SampleHits:            c.SampleHits,
+				ClearValue:            c.ClearValue,
+				WindowFunc:            c.WindowFunc,
+				WindowSeconds:         c.WindowSeconds,
+				AggregateFunc:         c.AggregateFunc,
+				MemberOperator:        c.MemberOperator,
+				MemberValue:           c.MemberValue,
+				AggregateBySubgroup:   c.AggregateBySubgroup,
+			})
+		}
+		if oneCondition && multipleCondition {
+			return nil, errcode.New(200060)
+		}
+		validateGroups = append(validateGroups, validateGroup)
+	}
+	if err := validateConditionGroups(validateGroups); err != nil {
+		return nil, err
+	}
+
+	for _, act := range b.Actions {
+		target, err := r.toID(bundleActionRefs[act.ActionType], act.ActionTarget)
+		if err != nil {
+			return nil, err
+		}
+		st.Actions = append(st.Actions, model.ActionInfo{
+			ID:                uuid.New(),
+			SceneAutomationID: id,
+			ActionType:        act.ActionType,
+			ActionTarget:      target,
+			ActionParamType:   emptyToNil(act.ActionParamType),
+			ActionParam:       emptyToNil(act.ActionParam),
+			ActionValue:       emptyToNil(act.ActionValue),
+		})
+	}
+	return st, nil
+}
+
+type plannedTemplate struct {
+	action  string
+	current *bundleTemplateState
+	desired *bundleTemplateState
+}
+
+type plannedConfig struct {
+	action  string
+	current *bundleConfigState
+	desired *bundleConfigState
+}
+
+type plannedAlarm struct {
+	action  string
+	desired *model.AlarmConfig
+}
+
+type plannedAutomation struct {
+	action  string
+	desired *bundleAutomationState
+}
+
+// configBundlePlan 配置包与当前状态的差异及各对象的目标状态
+type configBundlePlan struct {
+	resp        model.ConfigBundlePlan
+	templates   []plannedTemplate
+	configs     []plannedConfig
+	alarms      []plannedAlarm
+	automations []plannedAutomation
+	bindings    []model.ConfigBundleBinding
+	fingerprint []string
+}
+
+// resolveBundleObject 确定配置包对象对应的本实例对象：优先已有映射，其次同名且未映射的对象，都没有时新建
+func resolveBundleObject(s *configBundleState, objectType, key, name string, claimed map[string]bool) (id, matchedBy string, err error) {
+	names := s.objectNames()[objectType]
+	if boundID, ok := s.bindings[objectType][key]; ok {
+		if _, exists := names[boundID]; exists {
+			return boundID, "binding", nil
+		}
+	}
+	bound := make(map[string]bool)
+	for _, boundID := range s.bindings[objectType] {
+		bound[boundID] = true
+	}
+	var candidates []string
+	for objectID, n := range names {
+		if n == name && !bound[objectID] && !claimed[objectID] {
+			candidates = append(candidates, objectID)
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		return uuid.New(), "", nil
+	case 1:
+		return candidates[0], "name", nil
+	}
+	return "", "", fmt.Errorf("%s %q matches %d objects named %q; export from this instance first to bind keys", strings.ToLower(objectType), key, len(candidates), name)
+}
+
+// buildConfigBundlePlan 计算配置包与当前状态的差异
+func buildConfigBundlePlan(ctx context.Context, s *configBundleState, bundle *model.ConfigBundle, claims *utils.UserClaims) (*configBundlePlan, error) {
+	now := time.Now().UTC()
+	p := &configBundlePlan{resp: model.ConfigBundlePlan{Changes: []model.ConfigBundleChange{}, Summary: make(map[string]int)}}
+
+	type bundleObject struct {
+		objectType string
+		key        string
+		name       string
+	}
+	var objects []bundleObject
+	for _, b := range bundle.DeviceTemplates {
+		objects = append(objects, bundleObject{model.ConfigBundleDeviceTemplate, b.Key, b.Name})
+	}
+	for _, b := range bundle.DeviceConfigs {
+		objects = append(objects, bundleObject{model.ConfigBundleDeviceConfig, b.Key, b.Name})
+	}
+	for _, b := range bundle.AlarmConfigs {
+		objects = append(objects, bundleObject{model.ConfigBundleAlarmConfig, b.Key, b.Name})
+	}
+	for _, b := range bundle.SceneAutomations {
+		objects = append(objects, bundleObject{model.ConfigBundleSceneAutomation, b.Key, b.Name})
+	}
+
+	// 先确定全部对象的ID，对象之间的引用才能转换
+	ids := make(map[string]string)
+	matched := make(map[string]string)
+	claimed := make(map[string]map[string]bool)
+	for _, o := range objects {
+		ref := o.objectType + "/" + o.key
+		if _, dup := ids[ref]; dup {
+			return nil, fmt.Errorf("duplicate %s key %q", strings.ToLower(o.objectType), o.key)
+		}
+		if claimed[o.objectType] == nil {
+			claimed[o.objectType] = make(map[string]bool)
+		}
+		id, matchedBy, err := resolveBundleObject(s, o.objectType, o.key, o.name, claimed[o.objectType])
+		if err != nil {
+			return nil, err
+		}
+		claimed[o.objectType][id] = true
+		ids[ref] = id
+		matched[ref] = matchedBy
+		s.refs.setKey(o.objectType, id, o.key)
+		p.bindings = append(p.bindings, model.ConfigBundleBinding{
+			TenantID:   s.tenantID,
+			ObjectType: o.objectType,
+			BundleKey:  o.key,
+			ObjectID:   id,
+			UpdatedAt:  now,
+		})
+	}
+
+	// 配置包中引用的设备
+	var deviceNumbers []string
+	for _, b := range bundle.SceneAutomations {
+		for _, group := range b.TriggerConditionGroups {
+			for _, c := range group {
+				if c.TriggerConditionsType == model.DEVICE_TRIGGER_CONDITION_TYPE_ONE && c.TriggerSource != nil {
+					deviceNumbers = append(deviceNumbers, *c.TriggerSource)
+				}
+			}
+		}
+		for _, a := range b.Actions {
+			if a.ActionType == model.AUTOMATE_ACTION_TYPE_ONE && a.ActionTarget != nil {
+				deviceNumbers = append(deviceNumbers, *a.ActionTarget)
+			}
+		}
+	}
+	if err := s.loadDevices(global.DB.WithContext(ctx), "device_number", deviceNumbers); err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+
+	addChange := func(objectType, key, name string, current, desired interface{}, exists bool) (string, error) {
+		ref := objectType + "/" + key
+		change := model.ConfigBundleChange{
+			ObjectType: objectType,
+			Key:        key,
+			Name:       name,
+			ObjectID:   ids[ref],
+			MatchedBy:  matched[ref],
+		}
+		desiredJSON := canonicalJSON(desired)
+		currentJSON := ""
+		if !exists {
+			change.Action = model.ConfigBundleCreate
+		} else {
+			fields, err := bundleFieldChanges(current, desired)
+			if err != nil {
+				return "", err
+			}
+			change.Fields = fields
+			change.Action = model.ConfigBundleUnchanged
+			if len(fields) > 0 {
+				change.Action = model.ConfigBundleUpdate
+			}
+			currentJSON = canonicalJSON(current)
+		}
+		p.resp.Changes = append(p.resp.Changes, change)
+		p.resp.Summary[change.Action]++
+		// 新建对象的ID每次计划都不同，不计入指纹
+		fingerprintID := change.ObjectID
+		if !exists {
+			fingerprintID = ""
+		}
+		p.fingerprint = append(p.fingerprint, strings.Join([]string{objectType, key, change.Action, fingerprintID, currentJSON, desiredJSON}, "\x00"))
+		return change.Action, nil
+	}
+	objectError := func(objectType, key string, err error) error {
+		if _, ok := err.(*errcode.Error); ok {
+			return err
+		}
+		return fmt.Errorf("%s %q: %w", strings.ToLower(objectType), key, err)
+	}
+
+	r := s.refs
+	for i := range bundle.DeviceTemplates {
+		b := &bundle.DeviceTemplates[i]
+		id := ids[model.ConfigBundleDeviceTemplate+"/"+b.Key]
+		current := s.templates[id]
+		desired, err := templateFromBundle(b, id, s.tenantID, current, now)
+		if err != nil {
+			return nil, objectError(model.ConfigBundleDeviceTemplate, b.Key, err)
+		}
+		var currentBundle interface{}
+		if current != nil {
+			currentBundle = exportBundleTemplate(current, b.Key)
+		}
+		action, err := addChange(model.ConfigBundleDeviceTemplate, b.Key, b.Name, currentBundle, exportBundleTemplate(desired, b.Key), current != nil)
+		if err != nil {
+			return nil, err
+		}
+		p.templates = append(p.templates, plannedTemplate{action: action, current: current, desired: desired})
+	}
+	for i := range bundle.DeviceConfigs {
+		b := &bundle.DeviceConfigs[i]
+		id := ids[model.ConfigBundleDeviceConfig+"/"+b.Key]
+		current := s.configs[id]
+		desired, err := configFromBundle(b, id, s.tenantID, current, r, now)
+		if err != nil {
+			return nil, objectError(model.ConfigBundleDeviceConfig, b.Key, err)
+		}
+		var currentBundle interface{}
+		if current != nil {
+			currentBundle = exportBundleConfig(current, b.Key, r)
+		}
+		action, err := addChange(model.ConfigBundleDeviceConfig, b.Key, b.Name, currentBundle, exportBundleConfig(desired, b.Key, r), current != nil)
+		if err != nil {
+			return nil, err
+		}
+		p.configs = append(p.configs, plannedConfig{action: action, current: current, desired: desired})
+	}
+	for i := range bundle.AlarmConfigs {
+		b := &bundle.AlarmConfigs[i]
+		id := ids[model.ConfigBundleAlarmConfig+"/"+b.Key]
+		current := s.alarms[id]
+		desired, err := alarmFromBundle(b, id, s.tenantID, current, r, now)
+		if err != nil {
+			return nil, objectError(model.ConfigBundleAlarmConfig, b.Key, err)
+		}
+		var currentBundle interface{}
+		if current != nil {
+			currentBundle = exportBundleAlarm(current, b.Key, r)
+		}
+		action, err := addChange(model.ConfigBundleAlarmConfig, b.Key, b.Name, currentBundle, exportBundleAlarm(desired, b.Key, r), current != nil)
+		if err != nil {
+			return nil, err
+		}
+		p.alarms = append(p.alarms, plannedAlarm{action: action, desired: desired})
+	}
+	for i := range bundle.SceneAutomations {
+		b := &bundle.SceneAutomations[i]
+		id := ids[model.ConfigBundleSceneAutomation+"/"+b.Key]
+		current := s.automations[id]
+		desired, err := automationFromBundle(b, id, s.tenantID, current, r, claims, now)
+		if err != nil {
+			return nil, objectError(model.ConfigBundleSceneAutomation, b.Key, err)
+		}
+		var currentBundle interface{}
+		if current != nil {
+			currentBundle = exportBundleAutomation(current, b.Key, r)
+		}
+		action, err := addChange(model.ConfigBundleSceneAutomation, b.Key, b.Name, currentBundle, exportBundleAutomation(desired, b.Key, r), current != nil)
+		if err != nil {
+			return nil, err
+		}
+		p.automations = append(p.automations, plannedAutomation{action: action, desired: desired})
+	}
+
+	h := sha256.New()
+	for _, line := range p.fingerprint {
+		h.Write([]byte(line))
+		h.Write([]byte{'\n'})
+	}
+	p.resp.Fingerprint = hex.EncodeToString(h.Sum(nil))
+	return p, nil
+}
+
+// syncBundleRows 同步子表：删除不再声明的行，其余按主键更新或新建
+func syncBundleRows[T any, K comparable](tx *gorm.DB, rows []T, parentColumn, parentID string, keep []K) error {
+	del := tx.Where(parentColumn+" = ?", parentID)
+	if len(keep) > 0 {
+		del = del.Where("id NOT IN ?", keep)
+	}
+	if err := del.Delete(new(T)).Error; err != nil {
+		return err
+	}
+	for i := range rows {
+		if err := tx.Save(&rows[i]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyConfigBundlePlan 在一个事务内写入有变更的对象并更新 key 映射
+func applyConfigBundlePlan(tx *gorm.DB, p *configBundlePlan) error {
+	for _, t := range p.templates {
+		if t.action == model.ConfigBundleUnchanged {
+			continue
+		}
+		d := t.desired
+		if err := tx.Save(&d.Template).Error; err != nil {
+			return err
+		}
+		id := d.Template.ID
+		keep := func(n int, idOf func(i int) string) []string {
+			out := make([]string, n)
+			for i := range out {
+				out[i] = idOf(i)
+			}
+			return out
+		}
+		if err := syncBundleRows(tx, d.Telemetry, "device_template_id", id, keep(len(d.Telemetry), func(i int) string { return d.Telemetry[i].ID })); err != nil {
+			return err
+		}
+		if err := syncBundleRows(tx, d.Attributes, "device_template_id", id, keep(len(d.Attributes), func(i int) string { return d.Attributes[i].ID })); err != nil {
+			return err
+		}
+		if err := syncBundleRows(tx, d.Events, "device_template_id", id, keep(len(d.Events), func(i int) string { return d.Events[i].ID })); err != nil {
+			return err
+		}
+		if err := syncBundleRows(tx, d.Commands, "device_template_id", id, keep(len(d.Commands), func(i int) string { return d.Commands[i].ID })); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range p.configs {
+		if c.action == model.ConfigBundleUnchanged {
+			continue
+		}
+		d := c.desired
+		if err := tx.Save(&d.Config).Error; err != nil {
+			return err
+		}
+		scriptIDs := make([]string, len(d.Scripts))
+		for i, s := range d.Scripts {
+			scriptIDs[i] = s.ID
+		}
+		if err := syncBundleRows(tx, d.Scripts, "device_config_id", d.Config.ID, scriptIDs); err != nil {
+			return err
+		}
+		var mappingIDs []int64
+		for _, m := range d.Mappings {
+			if m.ID != 0 {
+				mappingIDs = append(mappingIDs, m.ID)
+			}
+		}
+		if err := syncBundleRows(tx, d.Mappings, "device_config_id", d.Config.ID, mappingIDs); err != nil {
+			return err
+		}
+	}
+
+	for _, a := range p.alarms {
+		if a.action == model.ConfigBundleUnchanged {
+			continue
+		}
+		if err := tx.Save(a.desired).Error; err != nil {
+			return err
+		}
+	}
+
+	for _, a := range p.automations {
+		if a.action == model.ConfigBundleUnchanged {
+			continue
+		}
+		d := a.desired
+		if err := tx.Save(&d.Automation).Error; err != nil {
+			return err
+		}
+		id := d.Automation.ID
+		for _, m := range []interface{}{&model.DeviceTriggerCondition{}, &model.OneTimeTask{}, &model.PeriodicTask{}, &model.ActionInfo{}} {
+			if err := tx.Where("scene_automation_id = ?", id).Delete(m).Error; err != nil {
+				return err
+			}
+		}
+		if len(d.Conditions) > 0 {
+			if err := tx.Create(&d.Conditions).Error; err != nil {
+				return err
+			}
+		}
+		if len(d.OneTime) > 0 {
+			if err := tx.Create(&d.OneTime).Error; err != nil {
+				return err
+			}
+		}
+		if len(d.Periodic) > 0 {
+			if err := tx.Create(&d.Periodic).Error; err != nil {
+				return err
+			}
+		}
+		if len(d.Actions) > 0 {
+			if err := tx.Create(&d.Actions).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(p.bindings) > 0 {
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "object_type"}, {Name: "bundle_key"}},
+			DoUpdates: clause.AssignmentColumns([]string{"object_id", "updated_at"}),
+		}).Create(&p.bindings).Error
+	}
+	return nil
+}
+
+// requireConfigBundleTenant 配置包按当前用户所属租户导出与应用
+func requireConfigBundleTenant(claims *utils.UserClaims) error {
+	if claims.TenantID == "" {
+		return errcode.WithVars(errcode.CodeNoPermission, map[string]interface{}{
+			"required_role": "TENANT_ADMIN",
+			"current_role":  claims.Authority,
+		})
+	}
+	return nil
+}
+
+// Export 导出当前租户的配置包
+func (*ConfigBundle) Export(ctx context.Context, claims *utils.UserClaims, req *model.ExportConfigBundleReq) ([]byte, error) {
+	if err := requireConfigBundleTenant(claims); err != nil {
+		return nil, err
+	}
+	kinds, err := parseConfigBundleKinds(req.Kinds)
+	if err != nil {
+		return nil, errcode.WithData(errcode.CodeParamError, map[string]interface{}{"error": err.Error()})
+	}
+	state, err := loadConfigBundleState(ctx, global.DB, claims.TenantID)
+	if err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	// 首次导出时以导出的 key 建立映射，对象改名后 key 不变
+	if bindings := state.unboundBindings(time.Now().UTC()); len(bindings) > 0 {
+		if err := global.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&bindings).Error; err != nil {
+			return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+		}
+	}
+	data, err := encodeConfigBundle(state.export(kinds), req.Format)
+	if err != nil {
+		return nil, errcode.WithData(errcode.CodeSystemError, map[string]interface{}{"error": err.Error()})
+	}
+	return data, nil
+}
+
+// planConfigBundle 读取当前状态并计算计划
+func planConfigBundle(ctx context.Context, claims *utils.UserClaims, bundle *model.ConfigBundle) (*configBundlePlan, error) {
+	if claims.Authority != "TENANT_ADMIN" {
+		return nil, errcode.WithVars(errcode.CodeNoPermission, map[string]interface{}{
+			"required_role": "TENANT_ADMIN",
+			"current_role":  claims.Authority,
+		})
+	}
+	state, err := loadConfigBundleState(ctx, global.DB, claims.TenantID)
+	if err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	p, err := buildConfigBundlePlan(ctx, state, bundle, claims)
+	if err != nil {
+		if _, ok := err.(*errcode.Error); ok {
+			return nil, err
+		}
+		return nil, errcode.WithData(errcode.CodeParamError, map[string]interface{}{"error": err.Error()})
+	}
+	return p, nil
+}
+
+// Plan 预览配置包与当前状态的差异，不做修改
+func (*ConfigBundle) Plan(ctx context.Context, claims *utils.UserClaims, bundle *model.ConfigBundle) (*model.ConfigBundlePlan, error) {
+	p, err := planConfigBundle(ctx, claims, bundle)
+	if err != nil {
+		return nil, err
+	}
+	return &p.resp, nil
+}
+
+// Apply 应用配置包：重新计算计划，传入预览时的指纹且当前状态已变化时拒绝，否则在一个事务内写入全部变更
+func (*ConfigBundle) Apply(ctx context.Context, claims *utils.UserClaims, bundle *model.ConfigBundle, req *model.ApplyConfigBundleReq) (*model.ConfigBundlePlan, error) {
+	p, err := planConfigBundle(ctx, claims, bundle)
+	if err != nil {
+		return nil, err
+	}
+	if req.PlanFingerprint != nil && *req.PlanFingerprint != "" && *req.PlanFingerprint != p.resp.Fingerprint {
+		return nil, errcode.WithData(errcode.CodeParamError, map[string]interface{}{
+			"error":       "configuration changed since the plan was made, plan again",
+			"fingerprint": p.resp.Fingerprint,
+		})
+	}
+	if err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return applyConfigBundlePlan(tx, p)
+	}); err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	p.resp.Applied = true
+	refreshConfigBundleCaches(ctx, p)
+	return &p.resp, nil
+}
+
+// refreshConfigBundleCaches 清除变更对象的缓存，启用的场景联动重建自动化缓存
+func refreshConfigBundleCaches(ctx context.Context, p *configBundlePlan) {
+	for _, c := range p.configs {
+		if c.action == model.ConfigBundleUnchanged {
+			continue
+		}
+		id := c.desired.Config.ID
+		initialize.DelDeviceConfigCache(id)
+		initialize.DelDeviceDataScriptCache(id)
+		if err := invalidateTopicMappingCache(ctx, id); err != nil {
+			logrus.WithError(err).WithField("device_config_id", id).Warn("config bundle: invalidate topic mapping cache failed")
+		}
+	}
+	var automations []plannedAutomation
+	for _, a := range p.automations {
+		if a.action != model.ConfigBundleUnchanged {
+			automations = append(automations, a)
+		}
+	}
+	if len(automations) == 0 {
+		return
+	}
+	invalidateAutomateZoneCache()
+	go func() {
+		for _, a := range automations {
+			resetSceneAutomationCache(a.desired.Automation.ID, a.desired.Automation.Enabled == "Y")
+		}
+	}()
+}
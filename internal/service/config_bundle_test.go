@@ -0,0 +1,212 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"project/internal/model"
+	"project/pkg/utils"
+)
+
+const testConfigBundleYAML = `
+api_version: config.bundle/v1
+device_configs:
+  - key: meter
+    name: Meter
+    device_type: "1"
+    auto_register: 0
+    other_config:
+      online_timeout: 30
+    data_scripts:
+      - name: decode
+        script_type: A
+        enable_flag: Y
+        content: |
+          function encodeInp(msg, topic) {
+            return msg;
+          }
+`
+
+func TestParseConfigBundle(t *testing.T) {
+	b, err := ParseConfigBundle([]byte(testConfigBundleYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.APIVersion != model.ConfigBundleAPIVersion || len(b.DeviceConfigs) != 1 {
+		t.Fatalf("bundle = %+v", b)
+	}
+	c := b.DeviceConfigs[0]
+	if c.Key != "meter" || len(c.DataScripts) != 1 || !strings.Contains(*c.DataScripts[0].Content, "return msg;") {
+		t.Errorf("device config = %+v", c)
+	}
+	if m, ok := c.OtherConfig.(map[string]interface{}); !ok || m["online_timeout"] != float64(30) {
+		t.Errorf("other_config = %#v", c.OtherConfig)
+	}
+
+	// JSON 是 YAML 的子集
+	if _, err := ParseConfigBundle([]byte(`{"api_version":"config.bundle/v1","alarm_configs":[]}`)); err != nil {
+		t.Errorf("json bundle rejected: %v", err)
+	}
+	if _, err := ParseConfigBundle([]byte("api_version: config.bundle/v1\ndevice_config: []\n")); err == nil {
+		t.Error("unknown field accepted")
+	}
+	if _, err := ParseConfigBundle([]byte("api_version: [")); err == nil {
+		t.Error("invalid yaml accepted")
+	}
+}
+
+func TestEncodeConfigBundle(t *testing.T) {
+	b, err := ParseConfigBundle([]byte(testConfigBundleYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, format := range []string{"yaml", "json"} {
+		data, err := encodeConfigBundle(b, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if format == "yaml" && !strings.Contains(string(data), "content: |") {
+			t.Errorf("multiline script not emitted as block:\n%s", data)
+		}
+		back, err := ParseConfigBundle(data)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if !reflect.DeepEqual(back, b) {
+			t.Errorf("%s round trip = %+v; want %+v", format, back, b)
+		}
+	}
+}
+
+func TestBundleJSONColumns(t *testing.T) {
+	cases := []struct {
+		in   *string
+		want interface{}
+	}{
+		{nil, nil},
+		{StringPtr(""), nil},
+		{StringPtr("{}"), nil},
+		{StringPtr("[]"), nil},
+		{StringPtr(`{"a":1}`), map[string]interface{}{"a": float64(1)}},
+		{StringPtr("plain"), "plain"},
+		{StringPtr("12"), "12"},
+	}
+	for _, c := range cases {
+		if got := bundleJSONValue(c.in); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("bundleJSONValue(%v) = %#v; want %#v", c.in, got, c.want)
+		}
+	}
+
+	if s, err := bundleJSONText(nil); err != nil || s != nil {
+		t.Errorf("bundleJSONText(nil) = %v, %v", s, err)
+	}
+	if s, err := bundleJSONText("raw"); err != nil || *s != "raw" {
+		t.Errorf("bundleJSONText(raw) = %v, %v", s, err)
+	}
+	if s, err := bundleJSONText(map[string]interface{}{"b": 1, "a": "x"}); err != nil || *s != `{"a":"x","b":1}` {
+		t.Errorf("bundleJSONText(map) = %v, %v", *s, err)
+	}
+}
+
+func TestAssignBundleKeys(t *testing.T) {
+	names := map[string]string{
+		"11111111-aaaa": "Meter",
+		"22222222-bbbb": "Pump",
+		"33333333-cccc": "Pump",
+		"44444444-dddd": "Renamed",
+	}
+	bound := map[string]string{
+		"valve": "44444444-dddd",
+		"gone":  "55555555-eeee",
+	}
+	got := assignBundleKeys(names, bound)
+	want := map[string]string{
+		"11111111-aaaa": "Meter",
+		"22222222-bbbb": "Pump-22222222",
+		"33333333-cccc": "Pump-33333333",
+		"44444444-dddd": "valve",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("assignBundleKeys = %v; want %v", got, want)
+	}
+
+	// 名称与已占用的 key 冲突
+	got = assignBundleKeys(map[string]string{"66666666-ffff": "gone"}, bound)
+	if got["66666666-ffff"] != "gone-66666666" {
+		t.Errorf("key = %q; want gone-66666666", got["66666666-ffff"])
+	}
+}
+
+func TestBundleFieldChanges(t *testing.T) {
+	current := model.BundleAlarmConfig{Key: "a", Name: "High", AlarmLevel: "H", Enabled: "Y"}
+	desired := current
+	fields, err := bundleFieldChanges(current, desired)
+	if err != nil || len(fields) != 0 {
+		t.Fatalf("unchanged fields = %v, %v", fields, err)
+	}
+	desired.AlarmLevel = "M"
+	desired.Remark = StringPtr("r")
+	fields, err = bundleFieldChanges(current, desired)
+	if err != nil || !reflect.DeepEqual(fields, []string{"alarm_level", "remark"}) {
+		t.Errorf("fields = %v, %v", fields, err)
+	}
+}
+
+func TestBundleAutomationRoundTrip(t *testing.T) {
+	r := newConfigBundleRefs()
+	r.setKey(model.ConfigBundleDeviceConfig, "cfg-id", "meter")
+	r.setKey(model.ConfigBundleAlarmConfig, "alarm-id", "high-temp")
+	r.names[bundleRefDevice].add("dev-id", "SN-001")
+
+	executionTime := time.Date(2026, 1, 2, 3, 4, 0, 0, time.UTC)
+	expiration := 5
+	b := model.BundleSceneAutomation{
+		Key:     "night",
+		Name:    "Night",
+		Enabled: "Y",
+		TriggerConditionGroups: [][]model.BundleCondition{
+			{
+				{TriggerConditionsType: "11", TriggerSource: StringPtr("meter"), TriggerParamType: StringPtr("TEL"), TriggerParam: StringPtr("temp"), TriggerOperator: StringPtr(">"), TriggerValue: StringPtr("30")},
+				{TriggerConditionsType: "22", TriggerParam: StringPtr("22:00:00-06:00:00")},
+			},
+			{{TriggerConditionsType: "20", ExecutionTime: &executionTime, ExpirationTime: &expiration}},
+		},
+		Actions: []model.BundleAction{
+			{ActionType: "30", ActionTarget: StringPtr("high-temp")},
+			{ActionType: "10", ActionTarget: StringPtr("SN-001"), ActionParamType: StringPtr("TEL"), ActionParam: StringPtr("switch"), ActionValue: StringPtr("1")},
+		},
+	}
+	st, err := automationFromBundle(&b, "auto-id", "t1", nil, r, &utils.UserClaims{ID: "u1"}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(st.Conditions) != 2 || len(st.OneTime) != 1 || len(st.Actions) != 2 {
+		t.Fatalf("state = %+v", st)
+	}
+	if *st.Conditions[0].TriggerSource != "cfg-id" || st.Conditions[0].GroupID != st.Conditions[1].GroupID {
+		t.Errorf("conditions = %+v", st.Conditions)
+	}
+
+	got := exportBundleAutomation(st, "night", r)
+	want := b
+	sortBundleAutomation(&want)
+	if canonicalJSON(got) != canonicalJSON(want) {
+		t.Errorf("export = %s\nwant %s", canonicalJSON(got), canonicalJSON(want))
+	}
+
+	// 未知引用与混用单个设备、单类设备条件
+	bad := b
+	bad.TriggerConditionGroups = [][]model.BundleCondition{{{TriggerConditionsType: "11", TriggerSource: StringPtr("missing")}}}
+	if _, err := automationFromBundle(&bad, "auto-id", "t1", nil, r, &utils.UserClaims{}, time.Now()); err == nil {
+		t.Error("unknown device config key accepted")
+	}
+	bad.TriggerConditionGroups = [][]model.BundleCondition{{
+		{TriggerConditionsType: "10", TriggerSource: StringPtr("SN-001")},
+		{TriggerConditionsType: "11", TriggerSource: StringPtr("meter")},
+	}}
+	if _, err := automationFromBundle(&bad, "auto-id", "t1", nil, r, &utils.UserClaims{}, time.Now()); err == nil {
+		t.Error("mixed device and device config conditions accepted")
+	}
+}
@@ -0,0 +1,401 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"project/initialize"
+	dal "project/internal/dal"
+	"project/internal/model"
+	"project/pkg/constant"
+	"project/pkg/errcode"
+	"project/pkg/global"
+	"project/pkg/utils"
+
+	"github.com/go-basic/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// DeviceAudit 设备操作审计：统一记录改变设备状态的操作，按租户串成哈希链，仅允许追加
+type DeviceAudit struct{}
+
+// DeviceAuditEntry 一次设备操作
+type DeviceAuditEntry struct {
+	TenantID string // 为空时按设备解析
+	DeviceID string
+	Action   string
+	Source   string // 为空按手动处理
+	ActorID  string // 为空表示系统触发
+	Target   string
+	Before   interface{}
+	After    interface{}
+	Err      error // 非空记为失败
+	RefID    string
+}
+
+// deviceAuditSource 下发日志的 operation_type 转换为审计来源
+func deviceAuditSource(operationType string) string {
+	if operationType == strconv.Itoa(constant.Auto) {
+		return model.DeviceAuditSourceAuto
+	}
+	return model.DeviceAuditSourceManual
+}
+
+// deviceAuditHash 计算记录哈希，覆盖除 id 与 hash 外的全部字段
+func deviceAuditHash(l *model.DeviceAuditLog) string {
+	b, _ := json.Marshal(struct {
+		Seq          int64   `json:"seq"`
+		TenantID     string  `json:"tenant_id"`
+		DeviceID     string  `json:"device_id"`
+		Action       string  `json:"action"`
+		Source       string  `json:"source"`
+		ActorID      *string `json:"actor_id"`
+		ActorOrgID   *string `json:"actor_org_id"`
+		Target       *string `json:"target"`
+		BeforeValue  *string `json:"before_value"`
+		AfterValue   *string `json:"after_value"`
+		Result       string  `json:"result"`
+		ErrorMessage *string `json:"error_message"`
+		RefID        *string `json:"ref_id"`
+		CreatedAt    string  `json:"created_at"`
+		PrevHash     string  `json:"prev_hash"`
+	}{
+		l.Seq, l.TenantID, l.DeviceID, l.Action, l.Source, l.ActorID, l.ActorOrgID, l.Target,
+		l.BeforeValue, l.AfterValue, l.Result, l.ErrorMessage, l.RefID,
+		l.CreatedAt.UTC().Format(time.RFC3339Nano), l.PrevHash,
+	})
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// verifyDeviceAuditChain 从 (prevSeq, prevHash) 开始顺序校验一段记录，返回首个异常记录的序号与原因
+func verifyDeviceAuditChain(prevSeq int64, prevHash string, logs []model.DeviceAuditLog) (int64, string) {
+	for i := range logs {
+		l := &logs[i]
+		switch {
+		case l.Seq != prevSeq+1:
+			return prevSeq + 1, fmt.Sprintf("sequence gap: expected %d, got %d", prevSeq+1, l.Seq)
+		case l.PrevHash != prevHash:
+			return l.Seq, "prev_hash does not match previous record"
+		case deviceAuditHash(l) != l.Hash:
+			return l.Seq, "record content does not match hash"
+		}
+		prevSeq, prevHash = l.Seq, l.Hash
+	}
+	return 0, ""
+}
+
+// deviceAuditJSON 变更前后的值序列化为 JSON 文本
+func deviceAuditJSON(v interface{}) *string {
+	if v == nil {
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		s := fmt.Sprintf("%v", v)
+		b, _ = json.Marshal(s)
+	}
+	s := string(b)
+	return &s
+}
+
+// optionalString 空串记为 NULL
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// Record 追加一条审计记录；审计失败只记录日志，不影响业务操作
+func (s *DeviceAudit) Record(ctx context.Context, e DeviceAuditEntry) {
+	if err := s.record(ctx, e); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"device_id": e.DeviceID,
+			"action":    e.Action,
+		}).Error("device audit: record failed")
+	}
+}
+
+func (*DeviceAudit) record(ctx context.Context, e DeviceAuditEntry) error {
+	tenantID := e.TenantID
+	if tenantID == "" {
+		device, err := initialize.GetDeviceCacheById(e.DeviceID)
+		if err != nil {
+			return err
+		}
+		tenantID = device.TenantID
+	}
+	if tenantID == "" {
+		return fmt.Errorf("tenant not resolved for device %s", e.DeviceID)
+	}
+
+	l := &model.DeviceAuditLog{
+		ID:          uuid.New(),
+		TenantID:    tenantID,
+		DeviceID:    e.DeviceID,
+		Action:      e.Action,
+		Source:      e.Source,
+		ActorID:     optionalString(e.ActorID),
+		Target:      optionalString(e.Target),
+		BeforeValue: deviceAuditJSON(e.Before),
+		AfterValue:  deviceAuditJSON(e.After),
+		Result:      model.DeviceAuditSuccess,
+		RefID:       optionalString(e.RefID),
+	}
+	if l.Source == "" {
+		l.Source = model.DeviceAuditSourceManual
+	}
+	if e.ActorID != "" {
+		// 归属组织仅作补充信息，查询失败不影响记录
+		if orgID, err := getUserOrgID(e.ActorID); err == nil {
+			l.ActorOrgID = optionalString(orgID)
+		}
+	}
+	if e.Err != nil {
+		msg := e.Err.Error()
+		if len(msg) > 500 {
+			msg = msg[:500]
+		}
+		l.Result = model.DeviceAuditFailed
+		l.ErrorMessage = &msg
+	}
+
+	// 同一租户的记录串行追加：事务级咨询锁保证序号连续、prev_hash 指向最新记录
+	return global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "device_audit:"+tenantID).Error; err != nil {
+			return err
+		}
+		var last model.DeviceAuditLog
+		if err := tx.Where("tenant_id = ?", tenantID).Order("seq DESC").Limit(1).Find(&last).Error; err != nil {
+			return err
+		}
+		l.Seq = last.Seq + 1
+		l.PrevHash = last.Hash
+		// 与 timestamptz(6) 精度一致，保证读回后哈希可复算
+		l.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+		l.Hash = deviceAuditHash(l)
+		return tx.Create(l).Error
+	})
+}
+
+// deviceAuditCurrentValues 下发前读取设备当前的属性/遥测值，作为审计的变更前值
+func deviceAuditCurrentValues(deviceID, paramType, value string) map[string]interface{} {
+	var values map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &values); err != nil || len(values) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+
+	current := make(map[string]interface{}, len(keys))
+	pick := func(key string, b *bool, n *float64, s *string) {
+		if _, ok := values[key]; !ok {
+			return
+		}
+		switch {
+		case b != nil:
+			current[key] = *b
+		case n != nil:
+			current[key] = *n
+		case s != nil:
+			current[key] = *s
+		}
+	}
+	switch paramType {
+	case model.DeviceParamATTR:
+		data, err := dal.GetAttributeDataList(deviceID)
+		if err != nil {
+			return nil
+		}
+		for _, d := range data {
+			pick(d.Key, d.BoolV, d.NumberV, d.StringV)
+		}
+	case model.DeviceParamTEL:
+		data, err := dal.GetCurrentTelemetryDataEvolutionByKeys(deviceID, keys)
+		if err != nil {
+			return nil
+		}
+		for _, d := range data {
+			pick(d.Key, d.BoolV, d.NumberV, d.StringV)
+		}
+	}
+	return current
+}
+
+// deviceAuditQuery 按筛选条件构造查询
+func deviceAuditQuery(ctx context.Context, tenantID string, f *model.DeviceAuditLogFilter) *gorm.DB {
+	db := global.DB.WithContext(ctx).Table("device_audit_logs AS a").
+		Select("a.*, d.device_number, d.name AS device_name, u.name AS actor_name, o.name AS actor_org_name").
+		Joins("LEFT JOIN devices AS d ON d.id = a.device_id").
+		Joins("LEFT JOIN users AS u ON u.id = a.actor_id").
+		Joins("LEFT JOIN orgs AS o ON o.id = a.actor_org_id").
+		Where("a.tenant_id = ?", tenantID)
+	if f.DeviceID != nil && *f.DeviceID != "" {
+		db = db.Where("a.device_id = ?", *f.DeviceID)
+	}
+	if f.Action != nil && *f.Action != "" {
+		db = db.Where("a.action = ?", *f.Action)
+	}
+	if f.ActorID != nil && *f.ActorID != "" {
+		db = db.Where("a.actor_id = ?", *f.ActorID)
+	}
+	if f.Result != nil && *f.Result != "" {
+		db = db.Where("a.result = ?", *f.Result)
+	}
+	if f.StartTime != nil && *f.StartTime != "" {
+		if t, err := time.ParseInLocation("2006-01-02 15:04:05", *f.StartTime, time.Local); err == nil {
+			db = db.Where("a.created_at >= ?", t)
+		}
+	}
+	if f.EndTime != nil && *f.EndTime != "" {
+		if t, err := time.ParseInLocation("2006-01-02 15:04:05", *f.EndTime, time.Local); err == nil {
+			db = db.Where("a.created_at <= ?", t)
+		}
+	}
+	return db
+}
+
+// GetDeviceAuditLogListByPage 分页查询设备审计日志
+func (*DeviceAudit) GetDeviceAuditLogListByPage(ctx context.Context, req *model.GetDeviceAuditLogListByPageReq, claims *utils.UserClaims) (map[string]interface{}, error) {
+	db := deviceAuditQuery(ctx, claims.TenantID, &req.DeviceAuditLogFilter)
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	list := make([]model.DeviceAuditLogResp, 0)
+	if err := db.Order("a.seq DESC").
+		Offset((req.Page - 1) * req.PageSize).
+		Limit(req.PageSize).
+		Scan(&list).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return map[string]interface{}{"total": total, "list": list}, nil
+}
+
+// ExportDeviceAuditLogs 导出设备审计日志（CSV，含哈希便于离线校验）
+func (*DeviceAudit) ExportDeviceAuditLogs(ctx context.Context, req *model.DeviceAuditLogFilter, claims *utils.UserClaims) (string, error) {
+	const maxExportLimit = 50000
+	rows := make([]model.DeviceAuditLogResp, 0)
+	if err := deviceAuditQuery(ctx, claims.TenantID, req).
+		Order("a.seq ASC").
+		Limit(maxExportLimit).
+		Scan(&rows).Error; err != nil {
+		return "", errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	if len(rows) == 0 {
+		return "", errcode.New(202100) // 导出数据不能为空
+	}
+
+	exportDir := "./files/excel/audit/"
+	if err := os.MkdirAll(exportDir, os.ModePerm); err != nil {
+		return "", errcode.WithVars(errcode.CodeFilePathGenError, map[string]interface{}{"error": err.Error()})
+	}
+	filePath := filepath.Join(exportDir, fmt.Sprintf("设备审计日志_%s_%s.csv", claims.TenantID, time.Now().Format("20060102150405")))
+	file, err := os.Create(filePath)
+	if err != nil {
+		return "", errcode.WithVars(errcode.CodeFileSaveError, map[string]interface{}{"error": err.Error()})
+	}
+	defer file.Close()
+
+	deref := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+	// UTF-8 BOM，便于 Excel 正确识别中文
+	file.WriteString("\xEF\xBB\xBF")
+	writer := csv.NewWriter(file)
+	writer.Write([]string{"序号", "时间", "设备编号", "设备名称", "动作", "来源", "操作人", "操作人组织", "操作对象",
+		"变更前", "变更后", "结果", "错误信息", "关联ID", "prev_hash", "hash"})
+	for _, r := range rows {
+		writer.Write([]string{
+			strconv.FormatInt(r.Seq, 10),
+			r.CreatedAt.In(time.Local).Format("2006-01-02 15:04:05.000"),
+			deref(r.DeviceNumber), deref(r.DeviceName), r.Action, r.Source,
+			deref(r.ActorName), deref(r.ActorOrgName), deref(r.Target),
+			deref(r.BeforeValue), deref(r.AfterValue), r.Result, deref(r.ErrorMessage), deref(r.RefID),
+			r.PrevHash, r.Hash,
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", errcode.WithVars(errcode.CodeFileSaveError, map[string]interface{}{"error": err.Error()})
+	}
+	return filePath, nil
+}
+
+// VerifyDeviceAuditChain 校验租户审计哈希链：序号连续、prev_hash 衔接、内容与哈希一致
+func (*DeviceAudit) VerifyDeviceAuditChain(ctx context.Context, req *model.DeviceAuditVerifyReq, claims *utils.UserClaims) (*model.DeviceAuditVerifyResp, error) {
+	const batchSize = 1000
+	db := global.DB.WithContext(ctx)
+	resp := &model.DeviceAuditVerifyResp{Valid: true}
+
+	// 从指定序号开始时以前一条记录作为起点
+	prevSeq, prevHash := int64(0), ""
+	if req.FromSeq != nil && *req.FromSeq > 1 {
+		var anchor model.DeviceAuditLog
+		if err := db.Where("tenant_id = ? AND seq = ?", claims.TenantID, *req.FromSeq-1).Limit(1).Find(&anchor).Error; err != nil {
+			return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+		}
+		if anchor.ID == "" {
+			broken := *req.FromSeq - 1
+			resp.Valid, resp.BrokenSeq, resp.Reason = false, &broken, "anchor record not found"
+			return resp, nil
+		}
+		prevSeq, prevHash = anchor.Seq, anchor.Hash
+	}
+	resp.LastSeq, resp.LastHash = prevSeq, prevHash
+
+	for {
+		batch := make([]model.DeviceAuditLog, 0, batchSize)
+		q := db.Where("tenant_id = ? AND seq > ?", claims.TenantID, prevSeq)
+		if req.ToSeq != nil {
+			q = q.Where("seq <= ?", *req.ToSeq)
+		}
+		if err := q.Order("seq ASC").Limit(batchSize).Find(&batch).Error; err != nil {
+			return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+		}
+		if len(batch) == 0 {
+			return resp, nil
+		}
+		if broken, reason := verifyDeviceAuditChain(prevSeq, prevHash, batch); reason != "" {
+			resp.Valid, resp.BrokenSeq, resp.Reason = false, &broken, reason
+			for _, l := range batch {
+				if l.Seq >= broken {
+					break
+				}
+				resp.Checked++
+				resp.LastSeq, resp.LastHash = l.Seq, l.Hash
+			}
+			return resp, nil
+		}
+		last := batch[len(batch)-1]
+		prevSeq, prevHash = last.Seq, last.Hash
+		resp.Checked += int64(len(batch))
+		resp.LastSeq, resp.LastHash = prevSeq, prevHash
+		if len(batch) < batchSize {
+			return resp, nil
+		}
+	}
+}
+
+// recordDeviceAudits 批量操作结束后按最终结果记录审计（事务回滚时全部记为失败）
+func recordDeviceAudits(ctx context.Context, entries []*DeviceAuditEntry, err error) {
+	for _, e := range entries {
+		e.Err = err
+		GroupApp.DeviceAudit.Record(ctx, *e)
+	}
+}
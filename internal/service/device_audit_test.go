@@ -0,0 +1,64 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	model "project/internal/model"
+)
+
+func TestVerifyDeviceAuditChain(t *testing.T) {
+	base := time.Date(2026, 10, 19, 8, 0, 0, 123456000, time.UTC)
+	chain := make([]model.DeviceAuditLog, 0, 4)
+	prev := ""
+	for i, action := range []string{model.DeviceAuditCommand, model.DeviceAuditAttributeSet, model.DeviceAuditTransfer, model.DeviceAuditBind} {
+		l := model.DeviceAuditLog{
+			TenantID:   "t1",
+			Seq:        int64(i + 1),
+			DeviceID:   "d1",
+			Action:     action,
+			Source:     model.DeviceAuditSourceManual,
+			AfterValue: deviceAuditJSON(map[string]interface{}{"ovp_threshold": 3.65}),
+			Result:     model.DeviceAuditSuccess,
+			PrevHash:   prev,
+			CreatedAt:  base.Add(time.Duration(i) * time.Minute),
+		}
+		l.Hash = deviceAuditHash(&l)
+		prev = l.Hash
+		chain = append(chain, l)
+	}
+
+	if seq, reason := verifyDeviceAuditChain(0, "", chain); reason != "" {
+		t.Fatalf("intact chain reported broken at %d: %s", seq, reason)
+	}
+	// 从中间锚点继续校验
+	if _, reason := verifyDeviceAuditChain(chain[1].Seq, chain[1].Hash, chain[2:]); reason != "" {
+		t.Fatalf("anchored verification failed: %s", reason)
+	}
+	// 读回的时间为本地时区时哈希不变
+	local := append([]model.DeviceAuditLog(nil), chain...)
+	local[0].CreatedAt = local[0].CreatedAt.In(time.FixedZone("CST", 8*3600))
+	if _, reason := verifyDeviceAuditChain(0, "", local); reason != "" {
+		t.Fatalf("timezone changed the hash: %s", reason)
+	}
+
+	tampered := append([]model.DeviceAuditLog(nil), chain...)
+	v := `{"ovp_threshold":4.2}`
+	tampered[2].AfterValue = &v
+	if seq, reason := verifyDeviceAuditChain(0, "", tampered); seq != 3 || reason == "" {
+		t.Fatalf("tampered record = %d %q; want broken at 3", seq, reason)
+	}
+
+	deleted := append(append([]model.DeviceAuditLog(nil), chain[:1]...), chain[2:]...)
+	if seq, reason := verifyDeviceAuditChain(0, "", deleted); seq != 2 || reason == "" {
+		t.Fatalf("deleted record = %d %q; want gap at 2", seq, reason)
+	}
+
+	// 删除后重排序号并重算本条哈希，仍会因 prev_hash 断链被发现
+	relinked := append([]model.DeviceAuditLog(nil), deleted...)
+	relinked[1].Seq = 2
+	relinked[1].Hash = deviceAuditHash(&relinked[1])
+	if seq, reason := verifyDeviceAuditChain(0, "", relinked); seq != 2 || reason == "" {
+		t.Fatalf("relinked record = %d %q; want broken at 2", seq, reason)
+	}
+}
@@ -38,7 +38,7 @@ func getUserOrgID(userID string) (string, error) {
 // 2. 校验设备是否已绑定当前用户
 // 3. 创建 device_user_bindings 记录
 // 4. 更新 device_batteries 激活状态/流转状态
-func (*DeviceBinding) BindDevice(req model.DeviceBindReq, claims *utils.UserClaims) error {
+func (*DeviceBinding) BindDevice(req model.DeviceBindReq, claims *utils.UserClaims) (err error) {
 	ctx := context.Background()
 	q := query.Use(global.DB)
 
@@ -60,6 +60,19 @@ func (*DeviceBinding) BindDevice(req model.DeviceBindReq, claims *utils.UserClai
 		})
 	}
 
+	// 设备审计：记录本次绑定结果
+	audit := DeviceAuditEntry{
+		TenantID: claims.TenantID,
+		DeviceID: device.ID,
+		Action:   model.DeviceAuditBind,
+		ActorID:  claims.ID,
+		Target:   claims.ID,
+	}
+	defer func() {
+		audit.Err = err
+		GroupApp.DeviceAudit.Record(ctx, audit)
+	}()
+
 	// 校验设备密钥（如果传入）
 	if req.DeviceSecret != nil && *req.DeviceSecret != "" {
 		if *req.DeviceSecret != device.Voucher {
@@ -118,65 +131,53 @@ func (*DeviceBinding) BindDevice(req model.DeviceBindReq, claims *utils.UserClai
 		})
 	}
 	isFirstBinding := len(existBindings) == 0
+	audit.Before = map[string]interface{}{"binding_count": len(existBindings)}
 
 	// 处理 device_batteries 信息：组织校验 + 激活状态更新
 	deviceBattery, err := tx.DeviceBattery.WithContext(ctx).
 		Where(tx.DeviceBattery.DeviceID.Eq(device.ID)).
 		First()
-	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			// 未找到记录时，如果用户有组织归属，则自动写入 owner_org_id
-			newBattery := &model.DeviceBattery{
-				DeviceID:         device.ID,
-				ActivationStatus: StringPtr("ACTIVE"),
-				TransferStatus:   StringPtr("USER"),
-				ActivationDate:   &t,
-				UpdatedAt:        &t,
-			}
-			if userOrgID != "" {
-				newBattery.OwnerOrgID = &userOrgID
-			}
+	if err != nil && err != gorm.ErrRecordNotFound {
+		tx.Rollback()
+		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{
+			"sql_error": err.Error(),
+		})
+	}
 
-			if err := tx.DeviceBattery.Create(newBattery); err != nil {
-				tx.Rollback()
-				return errcode.WithData(errcode.CodeDBError, map[string]interface{}{
-					"sql_error": err.Error(),
-				})
-			}
-		} else {
+	// 校验设备归属组织与当前用户是否匹配（如果用户有组织信息）
+	// 基于组织子树校验：用户的组织必须是设备所属组织的祖先
+	if deviceBattery != nil && userOrgID != "" && deviceBattery.OwnerOrgID != nil && *deviceBattery.OwnerOrgID != "" {
+		var count int64
+		global.DB.Table("org_closure").
+			Where("tenant_id = ? AND ancestor_id = ? AND descendant_id = ?",
+				claims.TenantID, userOrgID, *deviceBattery.OwnerOrgID).
+			Count(&count)
+		if count == 0 {
 			tx.Rollback()
-			return errcode.WithData(errcode.CodeDBError, map[string]interface{}{
-				"sql_error": err.Error(),
+			return errcode.WithData(errcode.CodeParamError, map[string]interface{}{
+				"message": "device does not belong to current organization",
 			})
 		}
-	} else {
-		// 校验设备归属组织与当前用户是否匹配（如果用户有组织信息）
-		// 基于组织子树校验：用户的组织必须是设备所属组织的祖先
-		if userOrgID != "" && deviceBattery.OwnerOrgID != nil && *deviceBattery.OwnerOrgID != "" {
-			var count int64
-			global.DB.Table("org_closure").
-				Where("tenant_id = ? AND ancestor_id = ? AND descendant_id = ?",
-					claims.TenantID, userOrgID, *deviceBattery.OwnerOrgID).
-				Count(&count)
-			if count == 0 {
-				tx.Rollback()
-				return errcode.WithData(errcode.CodeParamError, map[string]interface{}{
-					"message": "device does not belong to current organization",
-				})
-			}
-		}
+	}
 
-		updates := map[string]interface{}{
-			"activation_status": "ACTIVE",
-			"transfer_status":   "USER",
-			"activation_date":   t,
-			"updated_at":        t,
-		}
-		// 如果设备当前没有组织归属而用户有归属，则补充 owner_org_id
-		if deviceBattery.OwnerOrgID == nil && userOrgID != "" {
-			updates["owner_org_id"] = userOrgID
-		}
+	// 如果设备当前没有组织归属而用户有归属，则补充 owner_org_id
+	updates := map[string]interface{}{
+		"updated_at": t,
+	}
+	if (deviceBattery == nil || deviceBattery.OwnerOrgID == nil) && userOrgID != "" {
+		updates["owner_org_id"] = userOrgID
+	}
 
+	db := tx.DeviceBattery.WithContext(ctx).UnderlyingDB()
+	currentStatus, err := getBatteryLifecycleStatus(ctx, db, device.ID)
+	if err != nil {
+		tx.Rollback()
+		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{
+			"sql_error": err.Error(),
+		})
+	}
+	if currentStatus == model.BatteryLifecycleActivated || currentStatus == model.BatteryLifecycleInService {
+		// 已激活的电池（共享给其他用户）不重复激活，仅补充归属
 		if _, err := tx.DeviceBattery.WithContext(ctx).
 			Where(tx.DeviceBattery.DeviceID.Eq(device.ID)).
 			Updates(updates); err != nil {
@@ -185,6 +186,19 @@ func (*DeviceBinding) BindDevice(req model.DeviceBindReq, claims *utils.UserClai
 				"sql_error": err.Error(),
 			})
 		}
+	} else {
+		updates["activation_date"] = t
+		if err := TransitBatteryLifecycle(ctx, db, BatteryLifecycleChange{
+			TenantID:   claims.TenantID,
+			DeviceID:   device.ID,
+			To:         model.BatteryLifecycleActivated,
+			Event:      model.BatteryLifecycleEventBind,
+			OperatorID: &claims.ID,
+			Updates:    updates,
+		}); err != nil {
+			tx.Rollback()
+			return err
+		}
 	}
 
 	// 创建绑定关系
@@ -197,6 +211,9 @@ func (*DeviceBinding) BindDevice(req model.DeviceBindReq, claims *utils.UserClai
 		IsOwner:     &isOwner,
 	}
 
+	audit.After = map[string]interface{}{"user_id": claims.ID, "is_owner": isOwner, "binding_count": len(existBindings) + 1}
+	audit.RefID = binding.ID
+
 	if err := tx.DeviceUserBinding.WithContext(ctx).Create(binding); err != nil {
 		tx.Rollback()
 		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{
@@ -217,8 +234,22 @@ func (*DeviceBinding) BindDevice(req model.DeviceBindReq, claims *utils.UserClai
 // UnbindDevice APP端设备解绑
 // 1. 删除当前用户与设备的绑定关系
 // 2. 当设备不存在其它绑定关系时，重置激活状态
-func (*DeviceBinding) UnbindDevice(req model.DeviceUnbindReq, claims *utils.UserClaims) error {
+func (*DeviceBinding) UnbindDevice(req model.DeviceUnbindReq, claims *utils.UserClaims) (err error) {
 	ctx := context.Background()
+
+	// 设备审计：记录本次解绑结果
+	audit := DeviceAuditEntry{
+		TenantID: claims.TenantID,
+		DeviceID: req.DeviceID,
+		Action:   model.DeviceAuditUnbind,
+		ActorID:  claims.ID,
+		Target:   claims.ID,
+	}
+	defer func() {
+		audit.Err = err
+		GroupApp.DeviceAudit.Record(ctx, audit)
+	}()
+
 	tx := query.Use(global.DB).Begin()
 	defer func() {
 		if r := recover(); r != nil {
@@ -226,8 +257,6 @@ func (*DeviceBinding) UnbindDevice(req model.DeviceUnbindReq, claims *utils.User
 		}
 	}()
 
-	t := time.Now().UTC()
-
 	// 校验绑定关系是否存在
 	binding, err := tx.DeviceUserBinding.WithContext(ctx).
 		Where(
@@ -248,6 +277,9 @@ func (*DeviceBinding) UnbindDevice(req model.DeviceUnbindReq, claims *utils.User
 		})
 	}
 
+	audit.RefID = binding.ID
+	audit.Before = map[string]interface{}{"user_id": binding.UserID, "is_owner": binding.IsOwner}
+
 	// 删除绑定记录
 	if _, err := tx.DeviceUserBinding.WithContext(ctx).
 		Where(tx.DeviceUserBinding.ID.Eq(binding.ID)).
@@ -269,6 +301,8 @@ func (*DeviceBinding) UnbindDevice(req model.DeviceUnbindReq, claims *utils.User
 		})
 	}
 
+	audit.After = map[string]interface{}{"binding_count": remainCount}
+
 	// 如果没有其它绑定关系，则重置激活状态
 	if remainCount == 0 {
 		deviceBattery, err := tx.DeviceBattery.WithContext(ctx).
@@ -282,25 +316,9 @@ func (*DeviceBinding) UnbindDevice(req model.DeviceUnbindReq, claims *utils.User
 		}
 
 		if err == nil {
-			updates := map[string]interface{}{
-				"activation_status": "INACTIVE",
-				"activation_date":   nil,
-				"updated_at":        t,
-			}
-			// 若存在组织归属，则流转状态回退为 DEALER，否则为 FACTORY
-			if deviceBattery.OwnerOrgID != nil && *deviceBattery.OwnerOrgID != "" {
-				updates["transfer_status"] = "DEALER"
-			} else {
-				updates["transfer_status"] = "FACTORY"
-			}
-
-			if _, err := tx.DeviceBattery.WithContext(ctx).
-				Where(tx.DeviceBattery.DeviceID.Eq(req.DeviceID)).
-				Updates(updates); err != nil {
+			if err := resetBatteryActivation(ctx, tx.DeviceBattery.WithContext(ctx).UnderlyingDB(), deviceBattery, claims, nil); err != nil {
 				tx.Rollback()
-				return errcode.WithData(errcode.CodeDBError, map[string]interface{}{
-					"sql_error": err.Error(),
-				})
+				return err
 			}
 		}
 	}
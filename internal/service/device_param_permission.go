@@ -0,0 +1,433 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"project/initialize"
+	dal "project/internal/dal"
+	"project/internal/model"
+	"project/pkg/errcode"
+	"project/pkg/global"
+	"project/pkg/utils"
+
+	"github.com/go-basic/uuid"
+	"gorm.io/gorm"
+)
+
+// DeviceParamPermission 设备参数细粒度权限：按物模型标识符控制读/写/执行，规则分配给角色或机构类型
+type DeviceParamPermission struct{}
+
+// deviceParamGuard 当前用户的设备参数权限判定器
+type deviceParamGuard struct {
+	bypass bool
+	// categories 机构类型允许的参数类型（org_type_permissions.device_param_permissions），nil 表示不限制
+	categories map[string]bool
+	rules      []model.DeviceParamRule
+}
+
+// allowed 判定规则：拒绝优先；同一参数类型与操作存在允许规则时，仅允许匹配的标识符；无规则时默认允许
+func (g *deviceParamGuard) allowed(templateID, paramType, identifier, action string) bool {
+	if g == nil || g.bypass {
+		return true
+	}
+	if g.categories != nil && !g.categories[paramType] {
+		return false
+	}
+	hasAllow, matchedAllow := false, false
+	for _, r := range g.rules {
+		if r.ParamType != paramType || r.Action != action {
+			continue
+		}
+		if r.DeviceTemplateID != nil && *r.DeviceTemplateID != "" && *r.DeviceTemplateID != templateID {
+			continue
+		}
+		matched := r.Identifier == "*" || r.Identifier == identifier
+		if r.Effect == model.DeviceParamDeny {
+			if matched {
+				return false
+			}
+			continue
+		}
+		hasAllow = true
+		if matched {
+			matchedAllow = true
+		}
+	}
+	return !hasAllow || matchedAllow
+}
+
+// parseDeviceParamCategories 解析机构类型的参数类型配置（逗号分割），空配置不限制
+func parseDeviceParamCategories(s string) map[string]bool {
+	var out map[string]bool
+	for _, c := range strings.Split(s, ",") {
+		c = strings.ToUpper(strings.TrimSpace(c))
+		if c == "" {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]bool)
+		}
+		out[c] = true
+	}
+	return out
+}
+
+// guardFor 加载用户的角色与机构类型规则；租户管理员与系统管理员不受限制
+func (s *DeviceParamPermission) guardFor(ctx context.Context, claims *utils.UserClaims) (*deviceParamGuard, error) {
+	if claims == nil || claims.Authority == "SYS_ADMIN" || claims.Authority == "TENANT_ADMIN" {
+		return &deviceParamGuard{bypass: true}, nil
+	}
+
+	g := &deviceParamGuard{}
+	roles, _ := GroupApp.Casbin.GetRoleFromUser(claims.ID)
+	orgType, hasOrgType, err := GroupApp.OrgTypePermission.GetUserOrgType(ctx, claims.TenantID, claims.ID)
+	if err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	if hasOrgType {
+		var row orgTypePermissionPO
+		err := global.DB.WithContext(ctx).
+			Where("tenant_id = ? AND org_type = ?", claims.TenantID, orgType).
+			First(&row).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+		}
+		if err == nil && row.DeviceParamPermissions != nil {
+			g.categories = parseDeviceParamCategories(*row.DeviceParamPermissions)
+		}
+	}
+	if len(roles) == 0 && !hasOrgType {
+		return g, nil
+	}
+
+	db := global.DB.WithContext(ctx).Where("tenant_id = ?", claims.TenantID)
+	switch {
+	case len(roles) > 0 && hasOrgType:
+		db = db.Where("(subject_type = ? AND subject_id IN ?) OR (subject_type = ? AND subject_id = ?)",
+			model.DeviceParamSubjectRole, roles, model.DeviceParamSubjectOrgType, orgType)
+	case len(roles) > 0:
+		db = db.Where("subject_type = ? AND subject_id IN ?", model.DeviceParamSubjectRole, roles)
+	default:
+		db = db.Where("subject_type = ? AND subject_id = ?", model.DeviceParamSubjectOrgType, orgType)
+	}
+	if err := db.Find(&g.rules).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return g, nil
+}
+
+// deviceTemplateID 设备所属物模型（设备模板）ID，未配置时为空
+func deviceTemplateID(deviceID string) string {
+	device, err := initialize.GetDeviceCacheById(deviceID)
+	if err != nil || device.DeviceConfigID == nil || *device.DeviceConfigID == "" {
+		return ""
+	}
+	config, err := dal.GetDeviceConfigByID(*device.DeviceConfigID)
+	if err != nil || config.DeviceTemplateID == nil {
+		return ""
+	}
+	return *config.DeviceTemplateID
+}
+
+// deviceParamWrite 一次下发涉及的参数类型、操作与标识符
+type deviceParamWrite struct {
+	paramType   string
+	action      string
+	identifiers []string
+}
+
+// check 校验设备模板下的全部标识符
+func (g *deviceParamGuard) check(templateID string, w deviceParamWrite) error {
+	for _, id := range w.identifiers {
+		if !g.allowed(templateID, w.paramType, id, w.action) {
+			return errcode.WithVars(errcode.CodeDeviceParamDenied, map[string]interface{}{
+				"action":     w.action,
+				"identifier": id,
+			})
+		}
+	}
+	return nil
+}
+
+// putMessageIdentifiers 下发内容（JSON 对象）中的全部标识符
+func putMessageIdentifiers(value string) ([]string, error) {
+	var values map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &values); err != nil {
+		return nil, errcode.WithData(errcode.CodeParamError, map[string]interface{}{
+			"error": "value must be json object",
+		})
+	}
+	identifiers := make([]string, 0, len(values))
+	for k := range values {
+		identifiers = append(identifiers, k)
+	}
+	sort.Strings(identifiers)
+	return identifiers, nil
+}
+
+// expectedDataParamWrite 预期数据稍后下发的参数：遥测、属性为下发内容中的标识符，命令为命令标识符
+func expectedDataParamWrite(sendType string, identify, payload *string) (deviceParamWrite, error) {
+	switch sendType {
+	case "command":
+		if identify == nil {
+			return deviceParamWrite{}, nil
+		}
+		return deviceParamWrite{model.DeviceParamCMD, model.DeviceParamExecute, []string{*identify}}, nil
+	case "telemetry", "attribute":
+		if payload == nil {
+			return deviceParamWrite{}, nil
+		}
+		identifiers, err := putMessageIdentifiers(*payload)
+		if err != nil {
+			return deviceParamWrite{}, err
+		}
+		paramType := model.DeviceParamTEL
+		if sendType == "attribute" {
+			paramType = model.DeviceParamATTR
+		}
+		return deviceParamWrite{paramType, model.DeviceParamWrite, identifiers}, nil
+	}
+	return deviceParamWrite{}, nil
+}
+
+// actionParamWrite 场景联动、场景中下发到设备的动作涉及的参数，与 AutomateActionDeviceMqttSend 的解析一致；
+// 不下发到设备的动作返回 false
+func actionParamWrite(actionType string, paramType, value *string) (deviceParamWrite, bool, error) {
+	if actionType != model.AUTOMATE_ACTION_TYPE_ONE && actionType != model.AUTOMATE_ACTION_TYPE_MULTIPLE {
+		return deviceParamWrite{}, false, nil
+	}
+	if paramType == nil || value == nil {
+		return deviceParamWrite{}, false, nil
+	}
+	switch *paramType {
+	case AUTOMATE_ACTION_PARAM_TYPE_TEL, AUTOMATE_ACTION_PARAM_TYPE_TELEMETRY, AUTOMATE_ACTION_PARAM_TYPE_C_TELEMETRY:
+		identifiers, err := putMessageIdentifiers(*value)
+		return deviceParamWrite{model.DeviceParamTEL, model.DeviceParamWrite, identifiers}, true, err
+	case AUTOMATE_ACTION_PARAM_TYPE_ATTR, AUTOMATE_ACTION_PARAM_TYPE_ATTRIBUTES, AUTOMATE_ACTION_PARAM_TYPE_C_ATTRIBUTES:
+		identifiers, err := putMessageIdentifiers(*value)
+		return deviceParamWrite{model.DeviceParamATTR, model.DeviceParamWrite, identifiers}, true, err
+	case AUTOMATE_ACTION_PARAM_TYPE_CMD, AUTOMATE_ACTION_PARAM_TYPE_COMMAND, AUTOMATE_ACTION_PARAM_TYPE_C_COMMAND:
+		var info struct {
+			Method string `json:"method"`
+		}
+		if err := json.Unmarshal([]byte(*value), &info); err != nil {
+			return deviceParamWrite{}, true, errcode.WithData(errcode.CodeParamError, map[string]interface{}{
+				"error": "command action value must be json object",
+			})
+		}
+		return deviceParamWrite{model.DeviceParamCMD, model.DeviceParamExecute, []string{info.Method}}, true, nil
+	}
+	return deviceParamWrite{}, false, nil
+}
+
+// deviceParamAction 下发到设备的动作（单个设备为设备ID，单类设备为设备配置ID）
+type deviceParamAction struct {
+	ActionType   string
+	ActionTarget string
+	ParamType    *string
+	Value        *string
+}
+
+// checkActions 校验动作中的全部标识符，templateOf 返回动作目标所属的设备模板
+func (g *deviceParamGuard) checkActions(actions []deviceParamAction, templateOf func(actionType, target string) string) error {
+	for _, a := range actions {
+		w, ok, err := actionParamWrite(a.ActionType, a.ParamType, a.Value)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if err := g.check(templateOf(a.ActionType, a.ActionTarget), w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// actionTemplateID 动作目标所属的设备模板ID，未配置时为空
+func actionTemplateID(actionType, target string) string {
+	if actionType == model.AUTOMATE_ACTION_TYPE_ONE {
+		return deviceTemplateID(target)
+	}
+	config, err := dal.GetDeviceConfigByID(target)
+	if err != nil || config.DeviceTemplateID == nil {
+		return ""
+	}
+	return *config.DeviceTemplateID
+}
+
+// CheckIdentifiers 校验用户对设备的一组标识符是否有指定操作权限
+func (s *DeviceParamPermission) CheckIdentifiers(ctx context.Context, claims *utils.UserClaims, deviceID, paramType, action string, identifiers []string) error {
+	g, err := s.guardFor(ctx, claims)
+	if err != nil {
+		return err
+	}
+	if g.bypass {
+		return nil
+	}
+	return g.check(deviceTemplateID(deviceID), deviceParamWrite{paramType, action, identifiers})
+}
+
+// CheckPutMessage 校验下发内容（JSON 对象）中的全部标识符
+func (s *DeviceParamPermission) CheckPutMessage(ctx context.Context, claims *utils.UserClaims, deviceID, paramType, value string) error {
+	identifiers, err := putMessageIdentifiers(value)
+	if err != nil {
+		return err
+	}
+	return s.CheckIdentifiers(ctx, claims, deviceID, paramType, model.DeviceParamWrite, identifiers)
+}
+
+// CheckExpectedData 校验预期数据中稍后下发的参数
+func (s *DeviceParamPermission) CheckExpectedData(ctx context.Context, claims *utils.UserClaims, req *model.CreateExpectedDataReq) error {
+	w, err := expectedDataParamWrite(req.SendType, req.Identify, req.Payload)
+	if err != nil {
+		return err
+	}
+	return s.CheckIdentifiers(ctx, claims, req.DeviceID, w.paramType, w.action, w.identifiers)
+}
+
+// CheckActions 校验场景联动、场景中下发到设备的动作，动作执行时不再按用户校验
+func (s *DeviceParamPermission) CheckActions(ctx context.Context, claims *utils.UserClaims, actions []deviceParamAction) error {
+	g, err := s.guardFor(ctx, claims)
+	if err != nil {
+		return err
+	}
+	if g.bypass {
+		return nil
+	}
+	return g.checkActions(actions, actionTemplateID)
+}
+
+// resolveSubject 校验授权对象：角色须属于该租户，机构类型须为已知类型
+func (s *DeviceParamPermission) resolveSubject(ctx context.Context, tenantID, subjectType, subjectID string) error {
+	switch subjectType {
+	case model.DeviceParamSubjectOrgType:
+		switch subjectID {
+		case model.OrgTypePACKFactory, model.OrgTypeDealer, model.OrgTypeStore:
+			return nil
+		}
+		return errcode.WithData(errcode.CodeParamError, map[string]interface{}{
+			"subject_id": subjectID,
+			"error":      "org_type must be one of PACK_FACTORY/DEALER/STORE",
+		})
+	case model.DeviceParamSubjectRole:
+		var count int64
+		if err := global.DB.WithContext(ctx).Model(&model.Role{}).
+			Where("id = ? AND tenant_id = ?", subjectID, tenantID).
+			Count(&count).Error; err != nil {
+			return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+		}
+		if count == 0 {
+			return errcode.WithData(errcode.CodeNotFound, map[string]interface{}{"subject_id": subjectID, "error": "role not found"})
+		}
+		return nil
+	}
+	return errcode.WithData(errcode.CodeParamError, map[string]interface{}{
+		"subject_type": subjectType,
+		"error":        "subject_type must be ROLE or ORG_TYPE",
+	})
+}
+
+// validDeviceParamAction 遥测与属性支持读写，命令仅支持执行
+func validDeviceParamAction(paramType, action string) bool {
+	if paramType == model.DeviceParamCMD {
+		return action == model.DeviceParamExecute
+	}
+	return action == model.DeviceParamRead || action == model.DeviceParamWrite
+}
+
+// ListRules 查询设备参数权限规则
+func (s *DeviceParamPermission) ListRules(ctx context.Context, claims *utils.UserClaims, tenantID string, req *model.DeviceParamRuleListReq) ([]model.DeviceParamRule, error) {
+	resolvedTenantID, err := GroupApp.OrgTypePermission.resolveTenantID(claims, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	db := global.DB.WithContext(ctx).Where("tenant_id = ?", resolvedTenantID)
+	if req.SubjectType != nil {
+		db = db.Where("subject_type = ?", *req.SubjectType)
+	}
+	if req.SubjectID != nil {
+		db = db.Where("subject_id = ?", *req.SubjectID)
+	}
+	if req.DeviceTemplateID != nil {
+		db = db.Where("device_template_id = ?", *req.DeviceTemplateID)
+	}
+	rules := make([]model.DeviceParamRule, 0)
+	if err := db.Order("subject_type, subject_id, param_type, identifier, action").Find(&rules).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return rules, nil
+}
+
+// ReplaceRules 整体替换某个角色或机构类型的规则，传空列表即清空
+func (s *DeviceParamPermission) ReplaceRules(ctx context.Context, claims *utils.UserClaims, tenantID, subjectType, subjectID string, req *model.DeviceParamRuleReplaceReq) ([]model.DeviceParamRule, error) {
+	resolvedTenantID, err := GroupApp.OrgTypePermission.resolveTenantID(claims, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.resolveSubject(ctx, resolvedTenantID, subjectType, subjectID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	seen := make(map[string]bool, len(req.Rules))
+	rules := make([]model.DeviceParamRule, 0, len(req.Rules))
+	for _, item := range req.Rules {
+		identifier := strings.TrimSpace(item.Identifier)
+		if identifier == "" || !validDeviceParamAction(item.ParamType, item.Action) {
+			return nil, errcode.WithData(errcode.CodeParamError, map[string]interface{}{
+				"param_type": item.ParamType,
+				"identifier": item.Identifier,
+				"action":     item.Action,
+				"error":      "TEL/ATTR support read/write, CMD supports execute",
+			})
+		}
+		var templateID *string
+		if item.DeviceTemplateID != nil && strings.TrimSpace(*item.DeviceTemplateID) != "" {
+			t := strings.TrimSpace(*item.DeviceTemplateID)
+			templateID = &t
+		}
+		key := SafeDeref(templateID) + "|" + item.ParamType + "|" + identifier + "|" + item.Action
+		if seen[key] {
+			return nil, errcode.WithData(errcode.CodeParamError, map[string]interface{}{
+				"identifier": identifier,
+				"error":      "duplicate rule for the same template, identifier and action",
+			})
+		}
+		seen[key] = true
+		rules = append(rules, model.DeviceParamRule{
+			ID:               uuid.New(),
+			TenantID:         resolvedTenantID,
+			SubjectType:      subjectType,
+			SubjectID:        subjectID,
+			DeviceTemplateID: templateID,
+			ParamType:        item.ParamType,
+			Identifier:       identifier,
+			Action:           item.Action,
+			Effect:           item.Effect,
+			Remark:           item.Remark,
+			CreatedAt:        now,
+			UpdatedAt:        now,
+		})
+	}
+
+	err = global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tenant_id = ? AND subject_type = ? AND subject_id = ?", resolvedTenantID, subjectType, subjectID).
+			Delete(&model.DeviceParamRule{}).Error; err != nil {
+			return err
+		}
+		if len(rules) == 0 {
+			return nil
+		}
+		return tx.Create(&rules).Error
+	})
+	if err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return rules, nil
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"testing"
+
+	model "project/internal/model"
+)
+
+func TestDeviceParamGuardAllowed(t *testing.T) {
+	tpl := "tpl-1"
+	rule := func(templateID *string, paramType, identifier, action, effect string) model.DeviceParamRule {
+		return model.DeviceParamRule{DeviceTemplateID: templateID, ParamType: paramType, Identifier: identifier, Action: action, Effect: effect}
+	}
+	g := &deviceParamGuard{
+		categories: parseDeviceParamCategories("ATTR, cmd"),
+		rules: []model.DeviceParamRule{
+			rule(nil, model.DeviceParamATTR, "*", model.DeviceParamWrite, model.DeviceParamAllow),
+			rule(&tpl, model.DeviceParamATTR, "ovp_threshold", model.DeviceParamWrite, model.DeviceParamDeny),
+			rule(nil, model.DeviceParamCMD, "reboot", model.DeviceParamExecute, model.DeviceParamAllow),
+		},
+	}
+
+	cases := []struct {
+		templateID, paramType, identifier, action string
+		want                                      bool
+	}{
+		{"tpl-1", model.DeviceParamATTR, "charge_current_limit", model.DeviceParamWrite, true},
+		{"tpl-1", model.DeviceParamATTR, "ovp_threshold", model.DeviceParamWrite, false},
+		{"tpl-2", model.DeviceParamATTR, "ovp_threshold", model.DeviceParamWrite, true},
+		{"tpl-1", model.DeviceParamATTR, "ovp_threshold", model.DeviceParamRead, true},
+		{"tpl-1", model.DeviceParamCMD, "reboot", model.DeviceParamExecute, true},
+		{"tpl-1", model.DeviceParamCMD, "factory_reset", model.DeviceParamExecute, false},
+		{"tpl-1", model.DeviceParamTEL, "voltage", model.DeviceParamWrite, false},
+	}
+	for _, c := range cases {
+		if got := g.allowed(c.templateID, c.paramType, c.identifier, c.action); got != c.want {
+			t.Errorf("allowed(%s, %s, %s, %s) = %t; want %t", c.templateID, c.paramType, c.identifier, c.action, got, c.want)
+		}
+	}
+
+	if !(&deviceParamGuard{bypass: true}).allowed("", model.DeviceParamTEL, "x", model.DeviceParamWrite) {
+		t.Fatal("bypass guard should allow everything")
+	}
+}
+
+func TestDeviceParamDeferredWrites(t *testing.T) {
+	g := &deviceParamGuard{
+		rules: []model.DeviceParamRule{
+			{ParamType: model.DeviceParamATTR, Identifier: "ovp_threshold", Action: model.DeviceParamWrite, Effect: model.DeviceParamDeny},
+			{ParamType: model.DeviceParamCMD, Identifier: "factory_reset", Action: model.DeviceParamExecute, Effect: model.DeviceParamDeny},
+		},
+	}
+	str := func(s string) *string { return &s }
+
+	// 预期数据
+	expected := []struct {
+		sendType          string
+		identify, payload *string
+		denied            bool
+	}{
+		{"attribute", nil, str(`{"ovp_threshold":4.2}`), true},
+		{"attribute", nil, str(`{"charge_current_limit":10}`), false},
+		{"telemetry", nil, str(`{"ovp_threshold":4.2}`), false},
+		{"command", str("factory_reset"), nil, true},
+		{"command", str("reboot"), str(`{"delay":1}`), false},
+	}
+	for _, c := range expected {
+		w, err := expectedDataParamWrite(c.sendType, c.identify, c.payload)
+		if err != nil {
+			t.Fatalf("expectedDataParamWrite(%s): %v", c.sendType, err)
+		}
+		if err := g.check("tpl-1", w); (err != nil) != c.denied {
+			t.Errorf("expected data %s %v: err = %v; denied %t", c.sendType, c.payload, err, c.denied)
+		}
+	}
+	if _, err := expectedDataParamWrite("attribute", nil, str("not json")); err == nil {
+		t.Error("invalid attribute payload accepted")
+	}
+
+	// 场景联动、场景动作
+	templates := map[string]string{}
+	templateOf := func(actionType, target string) string {
+		templates[actionType] = target
+		return "tpl-1"
+	}
+	actions := []struct {
+		action deviceParamAction
+		denied bool
+	}{
+		{deviceParamAction{model.AUTOMATE_ACTION_TYPE_ONE, "dev-1", str("ATTR"), str(`{"ovp_threshold":4.2}`)}, true},
+		{deviceParamAction{model.AUTOMATE_ACTION_TYPE_MULTIPLE, "cfg-1", str("c_attribute"), str(`{"ovp_threshold":4.2}`)}, true},
+		{deviceParamAction{model.AUTOMATE_ACTION_TYPE_ONE, "dev-1", str("CMD"), str(`{"method":"factory_reset","params":{}}`)}, true},
+		{deviceParamAction{model.AUTOMATE_ACTION_TYPE_ONE, "dev-1", str("ATTR"), str(`{"charge_current_limit":10}`)}, false},
+		{deviceParamAction{model.AUTOMATE_ACTION_TYPE_ALARM, "alarm-1", nil, nil}, false},
+	}
+	for _, c := range actions {
+		if err := g.checkActions([]deviceParamAction{c.action}, templateOf); (err != nil) != c.denied {
+			t.Errorf("action %+v: err = %v; denied %t", c.action, err, c.denied)
+		}
+	}
+	if templates[model.AUTOMATE_ACTION_TYPE_MULTIPLE] != "cfg-1" {
+		t.Errorf("device config action resolved template from %q", templates[model.AUTOMATE_ACTION_TYPE_MULTIPLE])
+	}
+	if _, ok := templates[model.AUTOMATE_ACTION_TYPE_ALARM]; ok {
+		t.Error("alarm action checked against device parameters")
+	}
+}
@@ -17,7 +17,7 @@ import (
 type DeviceTransfer struct{}
 
 // TransferDevicesToOrg 批量转移设备到指定组织（新版，基于 org）
-func (*DeviceTransfer) TransferDevicesToOrg(ctx context.Context, req model.DeviceOrgTransferReq, claims *utils.UserClaims, operatorOrgID string) error {
+func (*DeviceTransfer) TransferDevicesToOrg(ctx context.Context, req model.DeviceOrgTransferReq, claims *utils.UserClaims, operatorOrgID string) (err error) {
 	if len(req.DeviceIDs) == 0 {
 		return errcode.WithData(errcode.CodeParamError, map[string]interface{}{
 			"message": "device_ids cannot be empty",
@@ -26,10 +26,15 @@ func (*DeviceTransfer) TransferDevicesToOrg(ctx context.Context, req model.Devic
 
 	t := time.Now().UTC()
 
+	// 设备审计：事务整体成功或回滚，按最终结果记录每台已处理的设备
+	audits := make([]*DeviceAuditEntry, 0, len(req.DeviceIDs))
+	defer func() { recordDeviceAudits(ctx, audits, err) }()
+
 	// 开启事务
-	err := global.DB.Transaction(func(tx *gorm.DB) error {
+	err = global.DB.Transaction(func(tx *gorm.DB) error {
 		// 验证目标组织是否存在（如果不为空）
 		var toOrgID *string
+		toDealer := false
 		if req.ToOrgID != nil && *req.ToOrgID != "" {
 			var org model.Org
 			if err := tx.Where("id = ? AND tenant_id = ?", *req.ToOrgID, claims.TenantID).First(&org).Error; err != nil {
@@ -41,10 +46,21 @@ func (*DeviceTransfer) TransferDevicesToOrg(ctx context.Context, req model.Devic
 				return err
 			}
 			toOrgID = &org.ID
+			toDealer = org.OrgType == model.OrgTypeDealer || org.OrgType == model.OrgTypeStore
 		}
 
 		// 批量处理设备转移
 		for _, deviceID := range req.DeviceIDs {
+			audit := &DeviceAuditEntry{
+				TenantID: claims.TenantID,
+				DeviceID: deviceID,
+				Action:   model.DeviceAuditTransfer,
+				ActorID:  claims.ID,
+				Target:   SafeDeref(toOrgID),
+				After:    map[string]interface{}{"owner_org_id": toOrgID},
+			}
+			audits = append(audits, audit)
+
 			// 查询设备是否存在
 			var device model.Device
 			if err := tx.Where("id = ? AND tenant_id = ?", deviceID, claims.TenantID).First(&device).Error; err != nil {
@@ -75,47 +91,35 @@ func (*DeviceTransfer) TransferDevicesToOrg(ctx context.Context, req model.Devic
 				}
 			}
 
-			// 查询设备电池信息
+			// 查询设备电池信息（不存在时由状态机建档）
 			var deviceBattery model.DeviceBattery
+			var fromOrgID *string
+			currentStatus := ""
 			err := tx.Where("device_id = ?", deviceID).First(&deviceBattery).Error
-			if err != nil {
-				if err == gorm.ErrRecordNotFound {
-					// 如果设备电池信息不存在，创建一条
-					deviceBattery = model.DeviceBattery{
-						DeviceID:   deviceID,
-						OwnerOrgID: toOrgID,
-						UpdatedAt:  &t,
-					}
-					if err := tx.Create(&deviceBattery).Error; err != nil {
-						return err
-					}
-					// 记录转移日志
-					transferLog := model.DeviceOrgTransfer{
-						ID:           uuid.New(),
-						DeviceID:     deviceID,
-						FromOrgID:    nil,
-						ToOrgID:      toOrgID,
-						OperatorID:   &claims.ID,
-						TransferTime: &t,
-						Remark:       req.Remark,
-						TenantID:     claims.TenantID,
-						CreatedAt:    &t,
-					}
-					return tx.Create(&transferLog).Error
+			if err == nil {
+				// 记录原组织ID
+				fromOrgID = deviceBattery.OwnerOrgID
+				audit.Before = map[string]interface{}{"owner_org_id": fromOrgID}
+				if currentStatus, err = getBatteryLifecycleStatus(ctx, tx, deviceID); err != nil {
+					return err
 				}
+			} else if err != gorm.ErrRecordNotFound {
 				return err
 			}
 
-			// 记录原组织ID
-			fromOrgID := deviceBattery.OwnerOrgID
-
-			// 更新设备归属组织
-			updates := map[string]interface{}{
-				"owner_org_id": toOrgID,
-				"updated_at":   t,
-			}
-
-			if err := tx.Model(&model.DeviceBattery{}).Where("device_id = ?", deviceID).Updates(updates).Error; err != nil {
+			// 更新设备归属组织：转入经销商/门店为经销商在库，转入厂家为厂家在库
+			if err := TransitBatteryLifecycle(ctx, tx, BatteryLifecycleChange{
+				TenantID:   claims.TenantID,
+				DeviceID:   deviceID,
+				To:         custodyLifecycleStatus(currentStatus, toDealer),
+				Event:      model.BatteryLifecycleEventTransfer,
+				OperatorID: &claims.ID,
+				Reason:     req.Remark,
+				Updates: map[string]interface{}{
+					"owner_org_id": toOrgID,
+					"updated_at":   t,
+				},
+			}); err != nil {
 				return err
 			}
 
@@ -149,6 +153,13 @@ func (*DeviceTransfer) TransferDevicesToOrg(ctx context.Context, req model.Devic
 		})
 	}
 
+	go publishWebhookEvent(claims.TenantID, model.WebhookEventBatteryTransfer, req.DeviceIDs, map[string]interface{}{
+		"device_ids":     req.DeviceIDs,
+		"to_org_id":      req.ToOrgID,
+		"operator_id":    claims.ID,
+		"remark":         req.Remark,
+		"transferred_at": t,
+	})
 	return nil
 }
 
@@ -264,13 +275,19 @@ func (*DeviceTransfer) GetOrgTransferHistory(ctx context.Context, req model.Devi
 }
 
 // TransferDevices 批量转移设备
-func (*DeviceTransfer) TransferDevices(req model.DeviceTransferReq, claims *utils.UserClaims) error {
+func (*DeviceTransfer) TransferDevices(req model.DeviceTransferReq, claims *utils.UserClaims) (err error) {
 	if len(req.DeviceIDs) == 0 {
 		return errcode.WithData(errcode.CodeParamError, map[string]interface{}{
 			"message": "device_ids cannot be empty",
 		})
 	}
 
+	ctx := context.Background()
+
+	// 设备审计：按事务最终结果记录每台已处理的设备
+	audits := make([]*DeviceAuditEntry, 0, len(req.DeviceIDs))
+	defer func() { recordDeviceAudits(ctx, audits, err) }()
+
 	// 开启事务
 	tx := query.Use(global.DB).Begin()
 	defer func() {
@@ -304,6 +321,16 @@ func (*DeviceTransfer) TransferDevices(req model.DeviceTransferReq, claims *util
 
 	// 批量处理设备转移
 	for _, deviceID := range req.DeviceIDs {
+		audit := &DeviceAuditEntry{
+			TenantID: claims.TenantID,
+			DeviceID: deviceID,
+			Action:   model.DeviceAuditTransfer,
+			ActorID:  claims.ID,
+			Target:   SafeDeref(toDealerID),
+			After:    map[string]interface{}{"dealer_id": toDealerID},
+		}
+		audits = append(audits, audit)
+
 		// 查询设备是否存在
 		_, err := tx.Device.Where(
 			tx.Device.ID.Eq(deviceID),
@@ -323,50 +350,44 @@ func (*DeviceTransfer) TransferDevices(req model.DeviceTransferReq, claims *util
 
 		// 查询设备电池信息
 		deviceBattery, err := tx.DeviceBattery.Where(tx.DeviceBattery.DeviceID.Eq(deviceID)).First()
-		if err != nil {
-			if err == gorm.ErrRecordNotFound {
-				// 如果设备电池信息不存在，创建一条
-				deviceBattery = &model.DeviceBattery{
-					DeviceID:  deviceID,
-					DealerID:  toDealerID,
-					UpdatedAt: &t,
-				}
-				if err := tx.DeviceBattery.Create(deviceBattery); err != nil {
-					tx.Rollback()
-					return errcode.WithData(errcode.CodeDBError, map[string]interface{}{
-						"sql_error": err.Error(),
-					})
-				}
-			} else {
+		if err != nil && err != gorm.ErrRecordNotFound {
+			tx.Rollback()
+			return errcode.WithData(errcode.CodeDBError, map[string]interface{}{
+				"sql_error": err.Error(),
+			})
+		}
+
+		// 转移给经销商为经销商在库，转移回厂家为厂家在库（无档案时一并建档）
+		currentStatus := ""
+		if deviceBattery != nil {
+			audit.Before = map[string]interface{}{"dealer_id": deviceBattery.DealerID}
+			currentStatus, err = getBatteryLifecycleStatus(ctx, tx.DeviceBattery.WithContext(ctx).UnderlyingDB(), deviceID)
+			if err != nil {
 				tx.Rollback()
 				return errcode.WithData(errcode.CodeDBError, map[string]interface{}{
 					"sql_error": err.Error(),
 				})
 			}
-		} else {
-			// 记录原经销商ID
-			fromDealerID := deviceBattery.DealerID
-
-			// 更新设备归属经销商
-			updates := map[string]interface{}{
+		}
+		if err := TransitBatteryLifecycle(ctx, tx.DeviceBattery.WithContext(ctx).UnderlyingDB(), BatteryLifecycleChange{
+			TenantID:   claims.TenantID,
+			DeviceID:   deviceID,
+			To:         custodyLifecycleStatus(currentStatus, toDealerID != nil),
+			Event:      model.BatteryLifecycleEventTransfer,
+			OperatorID: &claims.ID,
+			Reason:     req.Remark,
+			Updates: map[string]interface{}{
 				"dealer_id":  toDealerID,
 				"updated_at": t,
-			}
-
-			// 如果转移给经销商，更新流转状态
-			if toDealerID != nil {
-				updates["transfer_status"] = "DEALER"
-			} else {
-				// 转移回厂家
-				updates["transfer_status"] = "FACTORY"
-			}
+			},
+		}); err != nil {
+			tx.Rollback()
+			return err
+		}
 
-			if _, err := tx.DeviceBattery.Where(tx.DeviceBattery.DeviceID.Eq(deviceID)).Updates(updates); err != nil {
-				tx.Rollback()
-				return errcode.WithData(errcode.CodeDBError, map[string]interface{}{
-					"sql_error": err.Error(),
-				})
-			}
+		if deviceBattery != nil {
+			// 记录原经销商ID
+			fromDealerID := deviceBattery.DealerID
 
 			// 记录转移日志
 			transferLog := &model.DeviceTransfer{
@@ -396,6 +417,13 @@ func (*DeviceTransfer) TransferDevices(req model.DeviceTransferReq, claims *util
 		})
 	}
 
+	go publishWebhookEvent(claims.TenantID, model.WebhookEventBatteryTransfer, req.DeviceIDs, map[string]interface{}{
+		"device_ids":     req.DeviceIDs,
+		"to_dealer_id":   toDealerID,
+		"operator_id":    claims.ID,
+		"remark":         req.Remark,
+		"transferred_at": t,
+	})
 	return nil
 }
 
@@ -197,7 +197,7 @@ func (*EndUser) GetEndUserDevices(ctx context.Context, req model.EndUserDeviceLi
 }
 
 // ForceUnbind 强制解绑（管理员/组织用户）
-func (*EndUser) ForceUnbind(ctx context.Context, req model.EndUserForceUnbindReq, claims *utils.UserClaims, orgScopeID string) error {
+func (*EndUser) ForceUnbind(ctx context.Context, req model.EndUserForceUnbindReq, claims *utils.UserClaims, orgScopeID string) (err error) {
 	tx := query.Use(global.DB).Begin()
 	defer func() {
 		if r := recover(); r != nil {
@@ -218,6 +218,21 @@ func (*EndUser) ForceUnbind(ctx context.Context, req model.EndUserForceUnbindReq
 		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
 	}
 
+	// 设备审计：记录本次强制解绑结果
+	audit := DeviceAuditEntry{
+		TenantID: claims.TenantID,
+		DeviceID: binding.DeviceID,
+		Action:   model.DeviceAuditForceUnbind,
+		ActorID:  claims.ID,
+		Target:   binding.UserID,
+		Before:   map[string]interface{}{"user_id": binding.UserID, "is_owner": binding.IsOwner},
+		RefID:    binding.ID,
+	}
+	defer func() {
+		audit.Err = err
+		GroupApp.DeviceAudit.Record(ctx, audit)
+	}()
+
 	// 数据范围校验：组织用户仅能解绑子树名下设备
 	if orgScopeID != "" {
 		dbat, err := tx.DeviceBattery.WithContext(ctx).
@@ -253,8 +268,9 @@ func (*EndUser) ForceUnbind(ctx context.Context, req model.EndUserForceUnbindReq
 		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
 	}
 
+	audit.After = map[string]interface{}{"binding_count": remain}
+
 	if remain == 0 {
-		t := time.Now().UTC()
 		deviceBattery, err := tx.DeviceBattery.WithContext(ctx).
 			Where(tx.DeviceBattery.DeviceID.Eq(binding.DeviceID)).
 			First()
@@ -264,22 +280,10 @@ func (*EndUser) ForceUnbind(ctx context.Context, req model.EndUserForceUnbindReq
 		}
 
 		if err == nil {
-			updates := map[string]interface{}{
-				"activation_status": "INACTIVE",
-				"activation_date":   nil,
-				"updated_at":        t,
-			}
-			if deviceBattery.OwnerOrgID != nil && *deviceBattery.OwnerOrgID != "" {
-				updates["transfer_status"] = "DEALER"
-			} else {
-				updates["transfer_status"] = "FACTORY"
-			}
-
-			if _, err := tx.DeviceBattery.WithContext(ctx).
-				Where(tx.DeviceBattery.DeviceID.Eq(binding.DeviceID)).
-				Updates(updates); err != nil {
+			reason := "管理员强制解绑"
+			if err := resetBatteryActivation(ctx, tx.DeviceBattery.WithContext(ctx).UnderlyingDB(), deviceBattery, claims, &reason); err != nil {
 				tx.Rollback()
-				return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+				return err
 			}
 		}
 	}
@@ -38,6 +38,9 @@ type ServiceGroup struct {
 	NotificationGroup
 	NotificationHisory
 	NotificationServicesConfig
+	NotificationInbox
+	NotificationTemplate
+	WebhookSubscription
 	Alarm
 	Scene
 	SceneAutomation
@@ -50,25 +53,41 @@ type ServiceGroup struct {
 	ServiceAccess
 	ExpectedData
 	OpenAPIKey
+	Oidc
+	UserMfa
+	UserSession
 	MessagePush
 	SystemMonitor
 	DeviceAuth
 	DeviceTopicMapping
-	Dealer             // BMS: 经销商管理
-	BmsDashboard       // BMS: Dashboard
-	Battery            // BMS: 电池管理（电池列表/导入导出等）
-	BatteryModel       // BMS: 电池型号管理
-	DeviceTransfer     // BMS: 设备转移
-	DeviceBinding      // BMS: 设备绑定
-	AppBattery         // BMS: APP电池设备详情/透传
-	Warranty           // BMS: 维保管理
-	EndUser            // BMS: 终端用户（穿透/强制解绑）
-	ActivationLog      // BMS: 激活日志（从操作日志派生）
-	BatteryMaintenance // BMS: 电池维保记录（手动）
-	BatteryTag         // BMS: 电池标签
-	OfflineCommand     // BMS: 离线指令
-	OrgService         // BMS: 组织管理（多层级）
-	OrgTypePermission  // WEB: 机构类型权限配置（菜单权限/设备参数权限）
+	Dealer                 // BMS: 经销商管理
+	BmsDashboard           // BMS: Dashboard
+	Battery                // BMS: 电池管理（电池列表/导入导出等）
+	BatteryModel           // BMS: 电池型号管理
+	DeviceTransfer         // BMS: 设备转移
+	DeviceBinding          // BMS: 设备绑定
+	AppBattery             // BMS: APP电池设备详情/透传
+	Warranty               // BMS: 维保管理
+	EndUser                // BMS: 终端用户（穿透/强制解绑）
+	ActivationLog          // BMS: 激活日志（从操作日志派生）
+	BatteryMaintenance     // BMS: 电池维保记录（手动）
+	BatteryMaintenancePlan // BMS: 预测性维护（维护计划/工单）
+	BatteryHealth          // BMS: 电池健康分析（SOH/循环/寿命预测）
+	BatteryCycle           // BMS: 充放电会话（循环日志）
+	BatteryLifecycle       // BMS: 电池生命周期（状态机/变更记录）
+	BatteryTag             // BMS: 电池标签
+	OfflineCommand         // BMS: 离线指令
+	OrgService             // BMS: 组织管理（多层级）
+	OrgTypePermission      // WEB: 机构类型权限配置（菜单权限/设备参数权限）
+	DeviceParamPermission  // WEB: 设备参数细粒度权限（物模型标识符级别）
+	DeviceAudit            // WEB: 设备操作审计（哈希链防篡改）
+	Approval               // WEB: 高风险批量操作审批（四眼原则）
+	TenantData             // WEB: 租户数据导出与导入（跨部署迁移）
+	TenantOffboarding      // WEB: 租户注销（保留期后清除数据）
+	TenantSetting          // WEB: 租户设置（时区）
+	HolidayCalendar        // WEB: 节假日日历
+	AutomateAggregate      // 场景联动：设备分组/模板聚合条件（定时评估）
+	ConfigBundle           // WEB: 配置即代码（配置包导出、预览与应用）
 }
 
 var GroupApp = new(ServiceGroup)
@@ -42,6 +42,10 @@ func mergeIdentifyAndPayload(identify string, paramsStr *string) (string, error)
 
 // 创建预期数据
 func (e *ExpectedData) Create(ctx context.Context, req *model.CreateExpectedDataReq, userClaims *utils.UserClaims) (*model.ExpectedData, error) {
+	// 预期数据在设备上线后下发，下发时不再校验用户的设备参数权限
+	if err := GroupApp.DeviceParamPermission.CheckExpectedData(ctx, userClaims, req); err != nil {
+		return nil, err
+	}
 	if req.SendType == "command" {
 		if req.Identify == nil {
 			return nil, errcode.WithData(errcode.CodeParamError, map[string]interface{}{
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"project/internal/model"
+	"project/pkg/errcode"
+	"project/pkg/global"
+	"project/pkg/utils"
+
+	"github.com/go-basic/uuid"
+	"gorm.io/gorm"
+)
+
+// HolidayCalendar 节假日日历（场景联动可排除节假日）
+type HolidayCalendar struct{}
+
+func getHolidayCalendar(ctx context.Context, id, tenantID string) (*model.HolidayCalendar, error) {
+	var rows []model.HolidayCalendar
+	if err := global.DB.WithContext(ctx).
+		Where("id = ? AND tenant_id = ?", id, tenantID).
+		Limit(1).
+		Find(&rows).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	if len(rows) == 0 {
+		return nil, errcode.WithData(errcode.CodeNotFound, map[string]interface{}{"message": "holiday calendar not found"})
+	}
+	return &rows[0], nil
+}
+
+func checkHolidayCalendarName(ctx context.Context, tenantID, name, excludeID string) error {
+	var cnt int64
+	db := global.DB.WithContext(ctx).Model(&model.HolidayCalendar{}).
+		Where("tenant_id = ? AND name = ?", tenantID, name)
+	if excludeID != "" {
+		db = db.Where("id <> ?", excludeID)
+	}
+	if err := db.Count(&cnt).Error; err != nil {
+		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	if cnt > 0 {
+		return errcode.WithData(errcode.CodeParamError, map[string]interface{}{"message": "holiday calendar name already exists"})
+	}
+	return nil
+}
+
+func buildHolidayCalendarResp(c *model.HolidayCalendar) model.HolidayCalendarResp {
+	return model.HolidayCalendarResp{
+		ID:        c.ID,
+		Name:      c.Name,
+		Remark:    c.Remark,
+		CreatedAt: c.CreatedAt.In(time.Local).Format("2006-01-02 15:04:05"),
+		UpdatedAt: c.UpdatedAt.In(time.Local).Format("2006-01-02 15:04:05"),
+	}
+}
+
+// List 节假日日历列表
+func (*HolidayCalendar) List(ctx context.Context, claims *utils.UserClaims) ([]model.HolidayCalendarResp, error) {
+	var rows []model.HolidayCalendar
+	if err := global.DB.WithContext(ctx).
+		Where("tenant_id = ?", claims.TenantID).
+		Order("created_at ASC").
+		Find(&rows).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+
+	type countRow struct {
+		CalendarID string `gorm:"column:calendar_id"`
+		Cnt        int64  `gorm:"column:cnt"`
+	}
+	var counts []countRow
+	if err := global.DB.WithContext(ctx).Table("holiday_calendar_dates AS hcd").
+		Select("hcd.calendar_id, COUNT(*) AS cnt").
+		Joins("JOIN holiday_calendars hc ON hc.id = hcd.calendar_id").
+		Where("hc.tenant_id = ?", claims.TenantID).
+		Group("hcd.calendar_id").
+		Scan(&counts).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	countMap := make(map[string]int64, len(counts))
+	for _, c := range counts {
+		countMap[c.CalendarID] = c.Cnt
+	}
+
+	list := make([]model.HolidayCalendarResp, 0, len(rows))
+	for i := range rows {
+		item := buildHolidayCalendarResp(&rows[i])
+		item.DateCount = countMap[rows[i].ID]
+		list = append(list, item)
+	}
+	return list, nil
+}
+
+// Detail 节假日日历详情（year>0 时仅返回该年日期）
+func (*HolidayCalendar) Detail(ctx context.Context, id string, year int, claims *utils.UserClaims) (*model.HolidayCalendarResp, error) {
+	c, err := getHolidayCalendar(ctx, id, claims.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	db := global.DB.WithContext(ctx).Where("calendar_id = ?", id)
+	if year > 0 {
+		db = db.Where("holiday_date >= ? AND holiday_date < ?", fmt.Sprintf("%04d-01-01", year), fmt.Sprintf("%04d-01-01", year+1))
+	}
+	var dates []model.HolidayCalendarDate
+	if err := db.Order("holiday_date ASC").Find(&dates).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+
+	resp := buildHolidayCalendarResp(c)
+	resp.DateCount = int64(len(dates))
+	resp.Dates = make([]model.HolidayDateItem, 0, len(dates))
+	for _, d := range dates {
+		resp.Dates = append(resp.Dates, model.HolidayDateItem{Date: d.HolidayDate.Format("2006-01-02"), Name: d.Name})
+	}
+	return &resp, nil
+}
+
+// Create 新增节假日日历
+func (*HolidayCalendar) Create(ctx context.Context, req *model.HolidayCalendarReq, claims *utils.UserClaims) (*model.HolidayCalendarResp, error) {
+	if err := checkHolidayCalendarName(ctx, claims.TenantID, req.Name, ""); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	c := &model.HolidayCalendar{
+		ID:        uuid.New(),
+		TenantID:  claims.TenantID,
+		Name:      req.Name,
+		Remark:    req.Remark,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := global.DB.WithContext(ctx).Create(c).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	resp := buildHolidayCalendarResp(c)
+	return &resp, nil
+}
+
+// Update 更新节假日日历
+func (*HolidayCalendar) Update(ctx context.Context, id string, req *model.HolidayCalendarReq, claims *utils.UserClaims) error {
+	if _, err := getHolidayCalendar(ctx, id, claims.TenantID); err != nil {
+		return err
+	}
+	if err := checkHolidayCalendarName(ctx, claims.TenantID, req.Name, id); err != nil {
+		return err
+	}
+	if err := global.DB.WithContext(ctx).Model(&model.HolidayCalendar{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"name":       req.Name,
+			"remark":     req.Remark,
+			"updated_at": time.Now().UTC(),
+		}).Error; err != nil {
+		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return nil
+}
+
+// Delete 删除节假日日历（被场景联动引用时不允许删除）
+func (*HolidayCalendar) Delete(ctx context.Context, id string, claims *utils.UserClaims) error {
+	if _, err := getHolidayCalendar(ctx, id, claims.TenantID); err != nil {
+		return err
+	}
+	var used int64
+	if err := global.DB.WithContext(ctx).Table(model.TableNameSceneAutomation).
+		Where("holiday_calendar_id = ?", id).
+		Count(&used).Error; err != nil {
+		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	if used > 0 {
+		return errcode.WithData(errcode.CodeOpDenied, map[string]interface{}{
+			"message": fmt.Sprintf("holiday calendar is used by %d scene automations", used),
+		})
+	}
+	if err := global.DB.WithContext(ctx).Where("id = ?", id).Delete(&model.HolidayCalendar{}).Error; err != nil {
+		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	invalidateAutomateZoneCache()
+	return nil
+}
+
+// SetDates 整体替换日历在指定年份的节假日日期
+func (*HolidayCalendar) SetDates(ctx context.Context, id string, req *model.HolidayCalendarDatesReq, claims *utils.UserClaims) error {
+	if _, err := getHolidayCalendar(ctx, id, claims.TenantID); err != nil {
+		return err
+	}
+	dates := make([]model.HolidayCalendarDate, 0, len(req.Dates))
+	seen := make(map[string]bool, len(req.Dates))
+	for _, item := range req.Dates {
+		d, err := time.ParseInLocation("2006-01-02", item.Date, time.Local)
+		if err != nil || d.Year() != req.Year {
+			return errcode.WithData(errcode.CodeParamError, map[string]interface{}{
+				"message": fmt.Sprintf("date %s must be YYYY-MM-DD within %d", item.Date, req.Year),
+			})
+		}
+		if seen[item.Date] {
+			continue
+		}
+		seen[item.Date] = true
+		dates = append(dates, model.HolidayCalendarDate{CalendarID: id, HolidayDate: d, Name: item.Name})
+	}
+
+	err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("calendar_id = ? AND holiday_date >= ? AND holiday_date < ?",
+			id, fmt.Sprintf("%04d-01-01", req.Year), fmt.Sprintf("%04d-01-01", req.Year+1)).
+			Delete(&model.HolidayCalendarDate{}).Error; err != nil {
+			return err
+		}
+		if len(dates) > 0 {
+			if err := tx.Create(&dates).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&model.HolidayCalendar{}).Where("id = ?", id).Update("updated_at", time.Now().UTC()).Error
+	})
+	if err != nil {
+		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	invalidateAutomateZoneCache()
+	return nil
+}
@@ -119,6 +119,30 @@ func (receiver *MessagePush) AlarmMessagePushSend(triggered, alarmConfigId strin
 	}
 }
 
+// MemberMessagePushSend 成员通知推送到用户的移动端，pushes 为用户id到推送消息的映射
+func (receiver *MessagePush) MemberMessagePushSend(pushes map[string]model.MessagePushSend) {
+	userIds := make([]string, 0, len(pushes))
+	for userId := range pushes {
+		userIds = append(userIds, userId)
+	}
+	if len(userIds) == 0 {
+		return
+	}
+	pushManges, err := dal.GetMessagePushIdByUserIds(userIds)
+	if err != nil {
+		logrus.Error("查询用户pushIs失败:", err)
+		return
+	}
+	for _, v := range pushManges {
+		if v == nil || v.PushID == "" {
+			continue
+		}
+		message := pushes[v.UserID]
+		message.CIds = v.PushID
+		receiver.MessagePushSendAndLog(message, *v, 2)
+	}
+}
+
 func (receiver *MessagePush) MessagePushSendAndLog(message model.MessagePushSend, mange model.MessagePushManage, messageType int64) {
 	res, err := receiver.MessagePushSend(message)
 	contents, _ := json.Marshal(message)
@@ -34,6 +34,8 @@ func (*NotificationGroup) CreateNotificationGroup(createNotificationgroupReq *mo
 	notificationGroup.Status = createNotificationgroupReq.Status
 	notificationGroup.Description = createNotificationgroupReq.Description
 	notificationGroup.Remark = createNotificationgroupReq.Remark
+	notificationGroup.StormLimit = createNotificationgroupReq.StormLimit
+	notificationGroup.StormWindowSeconds = createNotificationgroupReq.StormWindowSeconds
 	notificationGroup.UpdatedAt = time.Now().UTC()
 	notificationGroup.CreatedAt = time.Now().UTC()
 	notificationGroup.TenantID = u.TenantID
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"project/internal/model"
+	"project/pkg/errcode"
+	"project/pkg/global"
+	"project/pkg/utils"
+
+	"github.com/go-basic/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// notificationInboxTitleMax 站内信标题最大长度（与表字段一致）
+const notificationInboxTitleMax = 500
+
+// notificationInboxEvent 站内信实时推送的SSE事件类型
+const notificationInboxEvent = "notification_inbox"
+
+type NotificationInbox struct{}
+
+// parseMemberNotificationConfig 解析成员通知组配置，去掉空值与重复项
+func parseMemberNotificationConfig(config *string) (model.MemberNotificationConfig, error) {
+	var cfg model.MemberNotificationConfig
+	if config == nil || strings.TrimSpace(*config) == "" {
+		return cfg, nil
+	}
+	if err := json.Unmarshal([]byte(*config), &cfg); err != nil {
+		return cfg, err
+	}
+	clean := func(list []string) []string {
+		out := make([]string, 0, len(list))
+		for _, v := range list {
+			if v = strings.TrimSpace(v); v != "" {
+				out = append(out, v)
+			}
+		}
+		return uniqueStrings(out)
+	}
+	cfg.UserIDs = clean(cfg.UserIDs)
+	cfg.RoleIDs = clean(cfg.RoleIDs)
+	return cfg, nil
+}
+
+// memberNotificationText 从标准通知JSON中取站内信标题与正文
+func memberNotificationText(alertJson string) (string, string) {
+	var alertData map[string]interface{}
+	_ = json.Unmarshal([]byte(alertJson), &alertData)
+	subject, _ := alertData["subject"].(string)
+	content, _ := alertData["content"].(string)
+	if subject == "" {
+		subject = "notification"
+	}
+	return memberNotificationTitle(subject), content
+}
+
+// memberNotificationTitle 站内信标题截断到字段长度
+func memberNotificationTitle(title string) string {
+	if r := []rune(title); len(r) > notificationInboxTitleMax {
+		return string(r[:notificationInboxTitleMax])
+	}
+	return title
+}
+
+// memberNotificationRecipients 通知组配置的用户与角色下的用户，仅保留本租户未冻结的用户
+func memberNotificationRecipients(tenantID string, cfg model.MemberNotificationConfig) ([]string, error) {
+	userIDs := append([]string{}, cfg.UserIDs...)
+	if len(cfg.RoleIDs) > 0 {
+		var roleUsers []string
+		if err := global.DB.Table("casbin_rule").
+			Where("ptype = 'g' AND v1 IN ?", cfg.RoleIDs).
+			Distinct().Pluck("v0", &roleUsers).Error; err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, roleUsers...)
+	}
+	userIDs = uniqueStrings(userIDs)
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+	var recipients []string
+	err := global.DB.Table("users").
+		Where("tenant_id = ? AND id IN ?", tenantID, userIDs).
+		Where("status IS NULL OR status <> 'F'").
+		Pluck("id", &recipients).Error
+	return recipients, err
+}
+
+// deliverMemberNotification 成员通知：写入成员站内信，通过SSE实时推送并推送到移动端
+func deliverMemberNotification(notificationGroup *model.NotificationGroup, alertJson string) {
+	cfg, err := parseMemberNotificationConfig(notificationGroup.NotificationConfig)
+	if err != nil {
+		logrus.Error("解析成员通知配置失败:", err)
+		return
+	}
+	recipients, err := memberNotificationRecipients(notificationGroup.TenantID, cfg)
+	if err != nil {
+		logrus.Error("获取成员通知接收人失败:", err)
+		return
+	}
+	if len(recipients) == 0 {
+		logrus.Info("成员通知组没有接收人:", notificationGroup.ID)
+		return
+	}
+	groupID := notificationGroup.ID
+	deliverMemberInbox(notificationGroup.TenantID, &groupID, recipients, alertJson)
+}
+
+// deliverMemberInbox 向指定用户写入站内信并实时推送，groupID 为来源通知组（可为空）
+func deliverMemberInbox(tenantID string, groupID *string, recipients []string, alertJson string) {
+	nsc := &NotificationServicesConfig{}
+	title, content := memberNotificationText(alertJson)
+	// 租户配置了站内信/移动端推送模板时按接收人语言渲染
+	inboxRenderer := newNotificationRenderer(tenantID, model.NoticeType_Member, alertJson)
+	pushRenderer := newNotificationRenderer(tenantID, model.NotificationChannelAppPush, alertJson)
+	var languages map[string]string
+	if inboxRenderer != nil || pushRenderer != nil {
+		languages = notificationUserLanguages(tenantID, recipients)
+	}
+	now := time.Now().UTC()
+	pushes := make(map[string]model.MessagePushSend, len(recipients))
+	rows := make([]model.NotificationInbox, 0, len(recipients))
+	for _, userID := range recipients {
+		userTitle, userContent := title, content
+		if out := inboxRenderer.Render(languages[userID]); out != nil {
+			userContent = out.Body
+			if out.Subject != "" {
+				userTitle = memberNotificationTitle(out.Subject)
+			}
+		}
+		row := model.NotificationInbox{
+			ID:                  uuid.New(),
+			TenantID:            tenantID,
+			UserID:              userID,
+			NotificationGroupID: groupID,
+			Title:               userTitle,
+			Content:             &userContent,
+			CreatedAt:           now,
+		}
+		if json.Valid([]byte(alertJson)) {
+			payload := alertJson
+			row.Payload = &payload
+		}
+		rows = append(rows, row)
+
+		push := model.MessagePushSend{Title: userTitle, Content: userContent}
+		if out := pushRenderer.Render(languages[userID]); out != nil {
+			push.Content = out.Body
+			if out.Subject != "" {
+				push.Title = out.Subject
+			}
+		}
+		push.Payload = model.MessagePushSendPayload{TenantId: tenantID, InboxId: row.ID}
+		pushes[userID] = push
+	}
+	if err := global.DB.CreateInBatches(&rows, 200).Error; err != nil {
+		logrus.Error("写入成员站内信失败:", err)
+		remark := err.Error()
+		for _, userID := range recipients {
+			nsc.saveNotificationHistory(model.NoticeType_Member, tenantID, userID, alertJson, "FAILURE", &remark)
+		}
+		return
+	}
+
+	for i := range rows {
+		message, _ := json.Marshal(rows[i])
+		if err := global.TPSSEManager.BroadcastEventToTenant(tenantID, global.SSEEvent{
+			Type:    notificationInboxEvent,
+			Message: string(message),
+			UserID:  rows[i].UserID,
+		}); err != nil {
+			logrus.Warn("站内信SSE推送失败:", err)
+		}
+		nsc.saveNotificationHistory(model.NoticeType_Member, tenantID, rows[i].UserID, alertJson, "SUCCESS", nil)
+	}
+	GroupApp.MessagePush.MemberMessagePushSend(pushes)
+}
+
+// GetNotificationInboxListByPage 当前用户的站内信列表（含未读数）
+func (*NotificationInbox) GetNotificationInboxListByPage(ctx context.Context, req *model.GetNotificationInboxListByPageReq, claims *utils.UserClaims) (map[string]interface{}, error) {
+	base := global.DB.WithContext(ctx).Model(&model.NotificationInbox{}).Where("user_id = ?", claims.ID)
+	db := base.Session(&gorm.Session{})
+	if req.IsRead != nil {
+		if *req.IsRead {
+			db = db.Where("read_at IS NOT NULL")
+		} else {
+			db = db.Where("read_at IS NULL")
+		}
+	}
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	list := make([]model.NotificationInbox, 0)
+	if err := db.Order("created_at DESC").
+		Offset((req.Page - 1) * req.PageSize).
+		Limit(req.PageSize).
+		Find(&list).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	var unread int64
+	if err := base.Session(&gorm.Session{}).Where("read_at IS NULL").Count(&unread).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return map[string]interface{}{"total": total, "unread": unread, "list": list}, nil
+}
+
+// GetNotificationInboxUnreadCount 当前用户的未读站内信数
+func (*NotificationInbox) GetNotificationInboxUnreadCount(ctx context.Context, claims *utils.UserClaims) (map[string]interface{}, error) {
+	var unread int64
+	if err := global.DB.WithContext(ctx).Model(&model.NotificationInbox{}).
+		Where("user_id = ? AND read_at IS NULL", claims.ID).
+		Count(&unread).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return map[string]interface{}{"unread": unread}, nil
+}
+
+// MarkNotificationInboxRead 将当前用户的指定站内信标记为已读
+func (*NotificationInbox) MarkNotificationInboxRead(ctx context.Context, req *model.NotificationInboxReadReq, claims *utils.UserClaims) (map[string]interface{}, error) {
+	res := global.DB.WithContext(ctx).Model(&model.NotificationInbox{}).
+		Where("user_id = ? AND id IN ? AND read_at IS NULL", claims.ID, req.IDs).
+		Update("read_at", time.Now().UTC())
+	if res.Error != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": res.Error.Error()})
+	}
+	return map[string]interface{}{"updated": res.RowsAffected}, nil
+}
+
+// MarkAllNotificationInboxRead 将当前用户的全部站内信标记为已读
+func (*NotificationInbox) MarkAllNotificationInboxRead(ctx context.Context, claims *utils.UserClaims) (map[string]interface{}, error) {
+	res := global.DB.WithContext(ctx).Model(&model.NotificationInbox{}).
+		Where("user_id = ? AND read_at IS NULL", claims.ID).
+		Update("read_at", time.Now().UTC())
+	if res.Error != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": res.Error.Error()})
+	}
+	return map[string]interface{}{"updated": res.RowsAffected}, nil
+}
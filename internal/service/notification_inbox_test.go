@@ -0,0 +1,46 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseMemberNotificationConfig(t *testing.T) {
+	config := `{"USER_IDS":["u1"," u2 ","","u1"],"ROLE_IDS":["r1","r1"]}`
+	cfg, err := parseMemberNotificationConfig(&config)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if strings.Join(cfg.UserIDs, ",") != "u1,u2" {
+		t.Fatalf("user ids = %v", cfg.UserIDs)
+	}
+	if strings.Join(cfg.RoleIDs, ",") != "r1" {
+		t.Fatalf("role ids = %v", cfg.RoleIDs)
+	}
+
+	empty := " "
+	cfg, err = parseMemberNotificationConfig(&empty)
+	if err != nil || len(cfg.UserIDs) != 0 || len(cfg.RoleIDs) != 0 {
+		t.Fatalf("empty config = %+v, %v", cfg, err)
+	}
+
+	bad := "{"
+	if _, err := parseMemberNotificationConfig(&bad); err == nil {
+		t.Fatal("expected error for invalid config")
+	}
+}
+
+func TestMemberNotificationText(t *testing.T) {
+	title, content := memberNotificationText(`{"subject":"[ALARM] low soc","content":"device A"}`)
+	if title != "[ALARM] low soc" || content != "device A" {
+		t.Fatalf("got %q %q", title, content)
+	}
+	title, content = memberNotificationText("not json")
+	if title != "notification" || content != "" {
+		t.Fatalf("fallback got %q %q", title, content)
+	}
+	title, _ = memberNotificationText(`{"subject":"` + strings.Repeat("告", notificationInboxTitleMax+10) + `"}`)
+	if len([]rune(title)) != notificationInboxTitleMax {
+		t.Fatalf("title length = %d", len([]rune(title)))
+	}
+}
@@ -0,0 +1,299 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"project/internal/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+// robotTextMax 各机器人消息正文长度上限（按字符截断，留出余量）
+var robotTextMax = map[string]int{
+	model.NoticeType_DingTalk: 5000,
+	model.NoticeType_WeCom:    3500, // 企业微信 markdown 上限 4096 字节
+	model.NoticeType_Feishu:   8000,
+	model.NoticeType_Slack:    2900, // Slack section 文本上限 3000 字符
+}
+
+// robotHTTPClient 机器人请求客户端
+var robotHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// robotSign 钉钉与飞书加签：HMAC-SHA256 后 Base64；钉钉以密钥为 key 签名 "timestamp\nsecret"，飞书以 "timestamp\nsecret" 为 key 签名空串
+func robotSign(kind, secret string, timestamp int64) string {
+	stringToSign := strconv.FormatInt(timestamp, 10) + "\n" + secret
+	if kind == model.NoticeType_Feishu {
+		h := hmac.New(sha256.New, []byte(stringToSign))
+		return base64.StdEncoding.EncodeToString(h.Sum(nil))
+	}
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// robotTruncate 正文超过渠道上限时截断
+func robotTruncate(kind, text string) string {
+	limit := robotTextMax[kind]
+	if r := []rune(text); limit > 0 && len(r) > limit {
+		return string(r[:limit]) + "…"
+	}
+	return text
+}
+
+// robotMarkdown 默认通知内容转为各渠道的 markdown：每行“键: 值”的键加粗
+func robotMarkdown(kind, subject, content string) string {
+	lines := make([]string, 0)
+	for _, line := range strings.Split(strings.TrimSpace(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if kind == model.NoticeType_Slack {
+			line = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(line)
+		}
+		if idx := strings.Index(line, ":"); idx > 0 && idx < 40 {
+			bold := "**" + line[:idx] + "**"
+			if kind == model.NoticeType_Slack {
+				bold = "*" + line[:idx] + "*"
+			}
+			line = bold + line[idx:]
+		}
+		lines = append(lines, line)
+	}
+	switch kind {
+	case model.NoticeType_DingTalk:
+		// 钉钉 markdown 需要空行换行，标题放入正文
+		return "#### " + subject + "\n\n" + strings.Join(lines, "\n\n")
+	case model.NoticeType_WeCom:
+		return "**" + subject + "**\n" + strings.Join(lines, "\n")
+	default:
+		// 飞书卡片与 Slack 的标题在消息头中
+		return strings.Join(lines, "\n")
+	}
+}
+
+// feishuCardColor 飞书卡片标题颜色按告警级别区分
+func feishuCardColor(level string) string {
+	switch level {
+	case "H":
+		return "red"
+	case "M":
+		return "orange"
+	case "L":
+		return "yellow"
+	}
+	return "blue"
+}
+
+// buildRobotRequest 构造机器人请求地址与消息体
+func buildRobotRequest(kind string, cfg model.RobotNotificationConfig, title, text, level string, now time.Time) (string, []byte, error) {
+	webhookURL := strings.TrimSpace(cfg.WebhookURL)
+	if webhookURL == "" {
+		return "", nil, fmt.Errorf("WEBHOOK_URL is empty")
+	}
+	text = robotTruncate(kind, text)
+	var payload map[string]interface{}
+	switch kind {
+	case model.NoticeType_DingTalk:
+		if cfg.Secret != "" {
+			u, err := url.Parse(webhookURL)
+			if err != nil {
+				return "", nil, err
+			}
+			timestamp := now.UnixMilli()
+			q := u.Query()
+			q.Set("timestamp", strconv.FormatInt(timestamp, 10))
+			q.Set("sign", robotSign(kind, cfg.Secret, timestamp))
+			u.RawQuery = q.Encode()
+			webhookURL = u.String()
+		}
+		payload = map[string]interface{}{
+			"msgtype":  "markdown",
+			"markdown": map[string]interface{}{"title": title, "text": text},
+		}
+	case model.NoticeType_WeCom:
+		payload = map[string]interface{}{
+			"msgtype":  "markdown",
+			"markdown": map[string]interface{}{"content": text},
+		}
+	case model.NoticeType_Feishu:
+		payload = map[string]interface{}{
+			"msg_type": "interactive",
+			"card": map[string]interface{}{
+				"config": map[string]interface{}{"wide_screen_mode": true},
+				"header": map[string]interface{}{
+					"title":    map[string]interface{}{"tag": "plain_text", "content": title},
+					"template": feishuCardColor(level),
+				},
+				"elements": []interface{}{
+					map[string]interface{}{"tag": "markdown", "content": text},
+				},
+			},
+		}
+		if cfg.Secret != "" {
+			timestamp := now.Unix()
+			payload["timestamp"] = strconv.FormatInt(timestamp, 10)
+			payload["sign"] = robotSign(kind, cfg.Secret, timestamp)
+		}
+	case model.NoticeType_Slack:
+		header := []rune(title)
+		if len(header) > 150 {
+			header = header[:150]
+		}
+		payload = map[string]interface{}{
+			"text": title,
+			"blocks": []interface{}{
+				map[string]interface{}{
+					"type": "header",
+					"text": map[string]interface{}{"type": "plain_text", "text": string(header)},
+				},
+				map[string]interface{}{
+					"type": "section",
+					"text": map[string]interface{}{"type": "mrkdwn", "text": text},
+				},
+			},
+		}
+	default:
+		return "", nil, fmt.Errorf("unsupported robot type: %s", kind)
+	}
+
+	buffer := &bytes.Buffer{}
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(payload); err != nil {
+		return "", nil, err
+	}
+	return webhookURL, bytes.TrimSpace(buffer.Bytes()), nil
+}
+
+// checkRobotResponse 判断机器人响应：钉钉/企业微信 errcode=0，飞书 code=0，Slack 返回 ok
+func checkRobotResponse(kind string, status int, body []byte) error {
+	if status >= 400 {
+		return fmt.Errorf("HTTP请求失败，状态码: %d, 响应: %s", status, string(body))
+	}
+	switch kind {
+	case model.NoticeType_DingTalk, model.NoticeType_WeCom:
+		var res struct {
+			ErrCode int    `json:"errcode"`
+			ErrMsg  string `json:"errmsg"`
+		}
+		if err := json.Unmarshal(body, &res); err != nil {
+			return fmt.Errorf("解析响应失败: %s", string(body))
+		}
+		if res.ErrCode != 0 {
+			return fmt.Errorf("errcode %d: %s", res.ErrCode, res.ErrMsg)
+		}
+	case model.NoticeType_Feishu:
+		var res struct {
+			Code       *int   `json:"code"`
+			Msg        string `json:"msg"`
+			StatusCode *int   `json:"StatusCode"`
+		}
+		if err := json.Unmarshal(body, &res); err != nil {
+			return fmt.Errorf("解析响应失败: %s", string(body))
+		}
+		if res.Code != nil && *res.Code != 0 {
+			return fmt.Errorf("code %d: %s", *res.Code, res.Msg)
+		}
+		if res.StatusCode != nil && *res.StatusCode != 0 {
+			return fmt.Errorf("StatusCode %d: %s", *res.StatusCode, res.Msg)
+		}
+	case model.NoticeType_Slack:
+		if s := strings.TrimSpace(string(body)); s != "" && s != "ok" {
+			return fmt.Errorf("slack: %s", s)
+		}
+	}
+	return nil
+}
+
+// sendRobotMessage 发送机器人消息
+func sendRobotMessage(ctx context.Context, kind string, cfg model.RobotNotificationConfig, title, text, level string) error {
+	webhookURL, body, err := buildRobotRequest(kind, cfg, title, text, level, time.Now())
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	resp, err := robotHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	return checkRobotResponse(kind, resp.StatusCode, respBody)
+}
+
+// robotHistoryTarget 通知历史中记录的发送目标，去掉地址中的 token 等查询参数
+func robotHistoryTarget(webhookURL string) string {
+	u, err := url.Parse(webhookURL)
+	if err != nil || u.Host == "" {
+		return "invalid url"
+	}
+	path := u.Path
+	// Slack 的令牌在路径中
+	if strings.HasPrefix(path, "/services/") {
+		path = "/services/***"
+	}
+	return u.Scheme + "://" + u.Host + path
+}
+
+// deliverRobotNotification 群机器人通知（钉钉/企业微信/飞书/Slack），失败重试一次
+func deliverRobotNotification(notificationGroup *model.NotificationGroup, alertJson string) {
+	kind := notificationGroup.NotificationType
+	nsc := &NotificationServicesConfig{}
+	var cfg model.RobotNotificationConfig
+	if notificationGroup.NotificationConfig != nil {
+		if err := json.Unmarshal([]byte(*notificationGroup.NotificationConfig), &cfg); err != nil {
+			logrus.Error("解析机器人通知配置失败:", err)
+			return
+		}
+	}
+
+	var alertData map[string]interface{}
+	_ = json.Unmarshal([]byte(alertJson), &alertData)
+	title := notificationString(alertData["subject"])
+	if title == "" {
+		title = "notification"
+	}
+	text := robotMarkdown(kind, title, notificationString(alertData["content"]))
+	// 租户配置了该渠道的通知模板时，正文模板即为 markdown
+	if renderer := newNotificationRenderer(notificationGroup.TenantID, kind, alertJson); renderer != nil {
+		if out := renderer.Render(notificationTenantLanguage(notificationGroup.TenantID)); out != nil {
+			text = out.Body
+			if out.Subject != "" {
+				title = out.Subject
+			}
+		}
+	}
+	level := notificationString(alertData["alarm_level"])
+
+	target := robotHistoryTarget(cfg.WebhookURL)
+	var lastErr error
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		lastErr = sendRobotMessage(ctx, kind, cfg, title, text, level)
+		cancel()
+		if lastErr == nil {
+			nsc.saveNotificationHistory(kind, notificationGroup.TenantID, target, text, "SUCCESS", nil)
+			return
+		}
+		logrus.Error(fmt.Sprintf("%s机器人通知发送失败，第%d次尝试:", kind, i+1), lastErr)
+	}
+	remark := lastErr.Error()
+	nsc.saveNotificationHistory(kind, notificationGroup.TenantID, target, text, "FAILURE", &remark)
+}
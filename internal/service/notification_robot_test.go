@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"project/internal/model"
+)
+
+// robotStub 记录收到的请求并返回固定响应
+func robotStub(t *testing.T, status int, response string) (*httptest.Server, *http.Request, *[]byte) {
+	t.Helper()
+	var got http.Request
+	body := new([]byte)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = *r.Clone(context.Background())
+		*body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &got, body
+}
+
+func TestSendRobotMessageDingTalk(t *testing.T) {
+	srv, req, body := robotStub(t, 200, `{"errcode":0,"errmsg":"ok"}`)
+	cfg := model.RobotNotificationConfig{WebhookURL: srv.URL + "/robot/send?access_token=abc", Secret: "SEC123"}
+	if err := sendRobotMessage(context.Background(), model.NoticeType_DingTalk, cfg, "title", "#### title", "H"); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	q := req.URL.Query()
+	ts, _ := strconv.ParseInt(q.Get("timestamp"), 10, 64)
+	if q.Get("access_token") != "abc" || q.Get("sign") != robotSign(model.NoticeType_DingTalk, "SEC123", ts) {
+		t.Fatalf("query = %v", q)
+	}
+	var payload map[string]map[string]string
+	_ = json.Unmarshal(*body, &payload)
+	if payload["markdown"]["title"] != "title" || payload["markdown"]["text"] != "#### title" {
+		t.Fatalf("body = %s", *body)
+	}
+
+	srv2, _, _ := robotStub(t, 200, `{"errcode":310000,"errmsg":"sign not match"}`)
+	cfg.WebhookURL = srv2.URL
+	if err := sendRobotMessage(context.Background(), model.NoticeType_DingTalk, cfg, "t", "x", ""); err == nil || !strings.Contains(err.Error(), "310000") {
+		t.Fatalf("expected errcode error, got %v", err)
+	}
+}
+
+func TestSendRobotMessageFeishu(t *testing.T) {
+	srv, _, body := robotStub(t, 200, `{"code":0,"msg":"success"}`)
+	cfg := model.RobotNotificationConfig{WebhookURL: srv.URL, Secret: "s"}
+	if err := sendRobotMessage(context.Background(), model.NoticeType_Feishu, cfg, "title", "text", "M"); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	var payload struct {
+		Timestamp string `json:"timestamp"`
+		Sign      string `json:"sign"`
+		Card      struct {
+			Header struct {
+				Template string `json:"template"`
+			} `json:"header"`
+		} `json:"card"`
+	}
+	_ = json.Unmarshal(*body, &payload)
+	ts, _ := strconv.ParseInt(payload.Timestamp, 10, 64)
+	if payload.Sign != robotSign(model.NoticeType_Feishu, "s", ts) || payload.Card.Header.Template != "orange" {
+		t.Fatalf("body = %s", *body)
+	}
+
+	srv2, _, _ := robotStub(t, 200, `{"code":19021,"msg":"sign match fail"}`)
+	cfg.WebhookURL = srv2.URL
+	if err := sendRobotMessage(context.Background(), model.NoticeType_Feishu, cfg, "t", "x", ""); err == nil {
+		t.Fatal("expected feishu error")
+	}
+}
+
+func TestSendRobotMessageWeComAndSlack(t *testing.T) {
+	srv, _, body := robotStub(t, 200, `{"errcode":0,"errmsg":"ok"}`)
+	if err := sendRobotMessage(context.Background(), model.NoticeType_WeCom, model.RobotNotificationConfig{WebhookURL: srv.URL}, "t", "**t**", ""); err != nil {
+		t.Fatalf("wecom: %v", err)
+	}
+	if !strings.Contains(string(*body), `"content":"**t**"`) {
+		t.Fatalf("wecom body = %s", *body)
+	}
+
+	slack, _, slackBody := robotStub(t, 200, "ok")
+	if err := sendRobotMessage(context.Background(), model.NoticeType_Slack, model.RobotNotificationConfig{WebhookURL: slack.URL}, "t", "*Level*: H", ""); err != nil {
+		t.Fatalf("slack: %v", err)
+	}
+	if !strings.Contains(string(*slackBody), `"type":"mrkdwn"`) {
+		t.Fatalf("slack body = %s", *slackBody)
+	}
+	bad, _, _ := robotStub(t, 404, "no_service")
+	if err := sendRobotMessage(context.Background(), model.NoticeType_Slack, model.RobotNotificationConfig{WebhookURL: bad.URL}, "t", "x", ""); err == nil {
+		t.Fatal("expected slack error")
+	}
+}
+
+func TestRobotMarkdownAndTarget(t *testing.T) {
+	text := robotMarkdown(model.NoticeType_DingTalk, "[ALERT] a", "Alert: a\nLevel: H")
+	if text != "#### [ALERT] a\n\n**Alert**: a\n\n**Level**: H" {
+		t.Fatalf("dingtalk markdown = %q", text)
+	}
+	if got := robotMarkdown(model.NoticeType_Slack, "x", "Details: a<b"); got != "*Details*: a&lt;b" {
+		t.Fatalf("slack markdown = %q", got)
+	}
+	if got := robotHistoryTarget("https://oapi.dingtalk.com/robot/send?access_token=secret"); got != "https://oapi.dingtalk.com/robot/send" {
+		t.Fatalf("target = %s", got)
+	}
+	if got := robotHistoryTarget("https://hooks.slack.com/services/T0/B0/XYZ"); got != "https://hooks.slack.com/services/***" {
+		t.Fatalf("slack target = %s", got)
+	}
+	if _, _, err := buildRobotRequest(model.NoticeType_WeCom, model.RobotNotificationConfig{}, "t", "x", "", time.Now()); err == nil {
+		t.Fatal("expected error for empty url")
+	}
+}
@@ -12,7 +12,6 @@ import (
 	model "project/internal/model"
 	"project/pkg/errcode"
 	utils "project/pkg/utils"
-	"project/third_party/others/http_client"
 
 	openapi "github.com/alibabacloud-go/darabonba-openapi/v2/client"
 	dysmsapi "github.com/alibabacloud-go/dysmsapi-20170525/v4/client"
@@ -45,8 +44,8 @@ func (n *NotificationServicesConfig) saveNotificationHistory(notificationType, t
 	return nil
 }
 
-// 发送webhook通知的方法
-func (n *NotificationServicesConfig) sendWebhookMessage(payloadURL, secret, alertJson, tenantID string) error {
+// 发送webhook通知的方法：写入通知历史与投递队列后立即投递一次，失败由定时任务按指数退避重试，签名密钥在投递时从通知组配置读取
+func (n *NotificationServicesConfig) sendWebhookMessage(payloadURL, alertJson, tenantID, notificationGroupID string) error {
 	// 验证JSON格式并确保不转义
 	var alertData map[string]interface{}
 	err := json.Unmarshal([]byte(alertJson), &alertData)
@@ -86,46 +85,31 @@ func (n *NotificationServicesConfig) sendWebhookMessage(payloadURL, secret, aler
 		return err
 	}
 
-	// 发送webhook，带重试机制
-	var lastErr error
-	maxRetries := 2 // 总共尝试2次（第一次+重试1次）
-
-	for i := 0; i < maxRetries; i++ {
-		if i > 0 {
-			logrus.Info(fmt.Sprintf("Webhook发送重试，第%d次", i))
-		}
-
-		// 创建带超时的context
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-
-		err = http_client.SendSignedRequestWithTimeout(ctx, payloadURL, cleanJson, secret)
-		if err == nil {
-			// 发送成功，更新记录
-			successStatus := "SUCCESS"
-			_, updateErr := dal.UpdateNotificationHistory(historyID, &successStatus, nil)
-			if updateErr != nil {
-				logrus.Error("更新webhook通知历史记录失败:", updateErr)
-			}
-			logrus.Info("Webhook发送成功:", payloadURL)
-			return nil
+	// 写入投递队列
+	delivery := &model.WebhookDelivery{
+		ID:                    uuid.New(),
+		TenantID:              tenantID,
+		NotificationGroupID:   &notificationGroupID,
+		NotificationHistoryID: &historyID,
+		EventType:             model.WebhookEventNotification,
+		TargetURL:             payloadURL,
+		Payload:               cleanJson,
+	}
+	if err := enqueueWebhookDelivery(delivery); err != nil {
+		logrus.Error("写入webhook投递队列失败:", err)
+		failureStatus := "FAILURE"
+		remarkText := err.Error()
+		if _, updateErr := dal.UpdateNotificationHistory(historyID, &failureStatus, &remarkText); updateErr != nil {
+			logrus.Error("更新webhook通知历史记录失败:", updateErr)
 		}
-		lastErr = err
-		logrus.Error(fmt.Sprintf("Webhook发送失败，第%d次尝试:", i+1), err)
+		return err
 	}
 
-	// 所有重试都失败，更新记录，在JSON后追加错误信息
-	failureStatus := "FAILURE"
-	errorContent := cleanJson + "; Webhook发送失败: " + lastErr.Error()
-	remarkText := lastErr.Error()
-
-	// 更新记录的内容和状态
-	_, updateErr := dal.UpdateNotificationHistoryWithContent(historyID, &failureStatus, &remarkText, &errorContent)
-	if updateErr != nil {
-		logrus.Error("更新webhook通知历史记录失败:", updateErr)
+	if err := attemptWebhookDelivery(delivery); err != nil {
+		return fmt.Errorf("首次投递失败，已加入重试队列: %v", err)
 	}
-
-	return lastErr
+	logrus.Info("Webhook发送成功:", payloadURL)
+	return nil
 }
 
 func (*NotificationServicesConfig) SaveNotificationServicesConfig(req *model.SaveNotificationServicesConfigReq) (*model.NotificationServicesConfig, error) {
@@ -332,6 +316,11 @@ func (*NotificationServicesConfig) SendSMSByTemplate(ctx context.Context, tenant
 
 // Send email message
 func sendEmailMessage(message string, subject string, tenantId string, to ...string) (err error) {
+	return sendEmailMessageWithType("text/plain", message, subject, tenantId, to...)
+}
+
+// sendEmailMessageWithType 发送邮件，contentType 为 text/plain 或 text/html（通知模板）
+func sendEmailMessageWithType(contentType, message, subject, tenantId string, to ...string) (err error) {
 	c, err := dal.GetNotificationServicesConfigByType(model.NoticeType_Email)
 	if err != nil {
 		return err
@@ -353,7 +342,7 @@ func sendEmailMessage(message string, subject string, tenantId string, to ...str
 	m := gomail.NewMessage()
 	m.SetHeader("From", emailConf.FromEmail)
 	m.SetHeader("To", to...)
-	m.SetBody("text/plain", message)
+	m.SetBody(contentType, message)
 	m.SetHeader("Subject", subject)
 
 	// 使用统一的通知历史记录方法
@@ -385,10 +374,17 @@ func (*NotificationServicesConfig) ExecuteNotification(notificationGroupId, aler
 		return
 	}
 
+	if !notificationStormAllow(notificationGroup, alertJson) {
+		return
+	}
+	deliverNotification(notificationGroup, alertJson)
+}
+
+// deliverNotification 按通知组类型发送通知（不经过通知风暴限制）
+func deliverNotification(notificationGroup *model.NotificationGroup, alertJson string) {
 	switch notificationGroup.NotificationType {
 	case model.NoticeType_Member:
-		// TODO: SEND TO MEMBER - 成员通知功能待实现
-		logrus.Info("成员通知功能尚未实现:", notificationGroupId)
+		deliverMemberNotification(notificationGroup, alertJson)
 
 	case model.NoticeType_Email:
 		nConfig := make(map[string]string)
@@ -412,11 +408,22 @@ func (*NotificationServicesConfig) ExecuteNotification(notificationGroupId, aler
 		// 邮件特定格式：添加邮件签名
 		emailBody := content + "\n\n---\nThis email was sent by ThingsPanel"
 
+		// 租户配置了邮件模板时按收件人语言渲染HTML邮件
+		renderer := newNotificationRenderer(notificationGroup.TenantID, model.NoticeType_Email, alertJson)
 		emailList := strings.Split(nConfig["EMAIL"], ",")
 		for _, emailAddr := range emailList {
 			emailAddr = strings.TrimSpace(emailAddr)
 			if emailAddr != "" {
-				err := sendEmailMessage(emailBody, subject, notificationGroup.TenantID, emailAddr)
+				contentType, mailSubject, mailBody := "text/plain", subject, emailBody
+				if renderer != nil {
+					if out := renderer.Render(notificationContactLanguage(notificationGroup.TenantID, "email", emailAddr)); out != nil {
+						contentType, mailBody = "text/html", out.Body
+						if out.Subject != "" {
+							mailSubject = out.Subject
+						}
+					}
+				}
+				err := sendEmailMessageWithType(contentType, mailBody, mailSubject, notificationGroup.TenantID, emailAddr)
 				if err != nil {
 					// 在JSON后追加错误信息
 					errorContent := alertJson + "; 邮件发送失败: " + err.Error()
@@ -433,21 +440,74 @@ func (*NotificationServicesConfig) ExecuteNotification(notificationGroupId, aler
 			Secret     string
 		}
 		var nConfig WebhookConfig
-		err = json.Unmarshal([]byte(*notificationGroup.NotificationConfig), &nConfig)
+		err := json.Unmarshal([]byte(*notificationGroup.NotificationConfig), &nConfig)
 		if err != nil {
 			logrus.Error("解析Webhook配置失败:", err)
 			return
 		}
 
-		// 使用新的webhook发送方法，传递原始JSON
+		// 租户配置了Webhook模板时发送渲染后的JSON，否则传递原始JSON
+		payload := alertJson
+		if renderer := newNotificationRenderer(notificationGroup.TenantID, model.NoticeType_Webhook, alertJson); renderer != nil {
+			if out := renderer.Render(notificationTenantLanguage(notificationGroup.TenantID)); out != nil {
+				payload = out.Body
+			}
+		}
 		nsc := &NotificationServicesConfig{}
-		err = nsc.sendWebhookMessage(nConfig.PayloadURL, nConfig.Secret, alertJson, notificationGroup.TenantID)
+		err = nsc.sendWebhookMessage(nConfig.PayloadURL, payload, notificationGroup.TenantID, notificationGroup.ID)
 		if err != nil {
 			logrus.Error("Webhook通知发送失败:", err)
 		}
 
+	case model.NoticeType_SME:
+		deliverSmsNotification(notificationGroup, alertJson)
+
+	case model.NoticeType_DingTalk, model.NoticeType_WeCom, model.NoticeType_Feishu, model.NoticeType_Slack:
+		deliverRobotNotification(notificationGroup, alertJson)
+
 	default:
 		logrus.Warn("未支持的通知类型:", notificationGroup.NotificationType)
 		return
 	}
 }
+
+// deliverSmsNotification 短信通知：短信只能按服务商模板发送，需配置对应事件类型的短信通知模板
+func deliverSmsNotification(notificationGroup *model.NotificationGroup, alertJson string) {
+	nConfig := make(map[string]string)
+	if notificationGroup.NotificationConfig != nil {
+		if err := json.Unmarshal([]byte(*notificationGroup.NotificationConfig), &nConfig); err != nil {
+			logrus.Error("解析短信通知配置失败:", err)
+			return
+		}
+	}
+	nsc := &NotificationServicesConfig{}
+	renderer := newNotificationRenderer(notificationGroup.TenantID, model.NoticeType_SME, alertJson)
+	for _, phone := range strings.Split(nConfig["PHONE"], ",") {
+		phone = strings.TrimSpace(phone)
+		if phone == "" {
+			continue
+		}
+		var out *renderedNotification
+		if renderer != nil {
+			out = renderer.Render(notificationContactLanguage(notificationGroup.TenantID, "phone_number", phone))
+		}
+		if out == nil || out.SmsTemplateCode == "" {
+			remark := "未配置短信通知模板"
+			nsc.saveNotificationHistory(model.NoticeType_SME, notificationGroup.TenantID, phone, alertJson, "FAILURE", &remark)
+			continue
+		}
+		var raw map[string]interface{}
+		_ = json.Unmarshal([]byte(out.Body), &raw)
+		params := make(map[string]string, len(raw))
+		for k, v := range raw {
+			params[k] = notificationString(v)
+		}
+		if err := nsc.SendSMSByTemplate(context.Background(), notificationGroup.TenantID, phone, out.SmsTemplateCode, params); err != nil {
+			logrus.Error("发送短信通知失败:", err)
+			remark := err.Error()
+			nsc.saveNotificationHistory(model.NoticeType_SME, notificationGroup.TenantID, phone, out.Body, "FAILURE", &remark)
+			continue
+		}
+		nsc.saveNotificationHistory(model.NoticeType_SME, notificationGroup.TenantID, phone, out.Body, "SUCCESS", nil)
+	}
+}
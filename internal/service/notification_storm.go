@@ -0,0 +1,121 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"project/initialize"
+	"project/internal/dal"
+	"project/internal/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+// notificationDigestShown 摘要正文中列出的通知条数
+const notificationDigestShown = 50
+
+// notificationStormAllow 通知组配置了风暴限制时计数，超出限制的通知转入摘要；Redis 异常时照常发送
+func notificationStormAllow(group *model.NotificationGroup, alertJson string) bool {
+	if group.StormLimit == nil || *group.StormLimit <= 0 {
+		return true
+	}
+	window := time.Minute
+	if group.StormWindowSeconds != nil && *group.StormWindowSeconds > 0 {
+		window = time.Duration(*group.StormWindowSeconds) * time.Second
+	}
+	now := time.Now()
+	cache := initialize.NewNotificationStormCache()
+	ok, err := cache.Allow(group.ID, int(*group.StormLimit), window, now)
+	if err != nil {
+		logrus.WithError(err).Warn("通知风暴计数失败")
+		return true
+	}
+	if ok {
+		return true
+	}
+	if err := cache.Defer(group.ID, notificationDigestItem(alertJson, now)); err != nil {
+		logrus.WithError(err).Warn("暂存通知摘要失败")
+		return true
+	}
+	logrus.Debug("通知组超出风暴限制，转入摘要:", group.ID)
+	return false
+}
+
+// notificationDigestItem 摘要中的一行：时间 + 通知标题
+func notificationDigestItem(alertJson string, now time.Time) string {
+	var alertData map[string]interface{}
+	_ = json.Unmarshal([]byte(alertJson), &alertData)
+	subject, _ := alertData["subject"].(string)
+	if subject == "" {
+		subject = "notification"
+	}
+	return now.Format("2006-01-02 15:04:05") + " " + subject
+}
+
+// buildNotificationDigest 合并被限制的通知为一条摘要
+func buildNotificationDigest(group *model.NotificationGroup, items []string, total int64) map[string]interface{} {
+	if total < int64(len(items)) {
+		total = int64(len(items))
+	}
+	shown := items
+	if len(shown) > notificationDigestShown {
+		shown = shown[len(shown)-notificationDigestShown:]
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d notifications were suppressed by the storm limit of group %s.\n", total, group.Name)
+	if int64(len(shown)) < total {
+		fmt.Fprintf(&sb, "Latest %d:\n", len(shown))
+	}
+	for _, item := range shown {
+		sb.WriteString(item)
+		sb.WriteString("\n")
+	}
+	return map[string]interface{}{
+		"event_type": model.NotificationEventDigest,
+		"subject":    fmt.Sprintf("[DIGEST] %d notifications suppressed", total),
+		"content":    strings.TrimSpace(sb.String()),
+		"timestamp":  time.Now().Format(time.RFC3339),
+		"tenant_id":  group.TenantID,
+		"digest":     true,
+		"count":      total,
+	}
+}
+
+// FlushNotificationDigestsByCron 定时任务：发送各通知组暂存的通知摘要
+func (*NotificationServicesConfig) FlushNotificationDigestsByCron() {
+	cache := initialize.NewNotificationStormCache()
+	groupIds, err := cache.Pending()
+	if err != nil {
+		logrus.WithError(err).Error("获取待发送通知摘要失败")
+		return
+	}
+	for _, groupId := range groupIds {
+		items, total, err := cache.Drain(groupId)
+		if err != nil {
+			logrus.WithError(err).Error("读取通知摘要失败")
+			continue
+		}
+		if len(items) == 0 && total == 0 {
+			continue
+		}
+		group, err := dal.GetNotificationGroupById(groupId)
+		if err != nil {
+			logrus.Error("获取通知组失败:", err)
+			continue
+		}
+		if group.Status != "OPEN" {
+			continue
+		}
+		buffer := &bytes.Buffer{}
+		encoder := json.NewEncoder(buffer)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(buildNotificationDigest(group, items, total)); err != nil {
+			logrus.Error("构建通知摘要JSON失败:", err)
+			continue
+		}
+		deliverNotification(group, strings.TrimSpace(buffer.String()))
+	}
+}
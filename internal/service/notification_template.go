@@ -0,0 +1,551 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"project/internal/dal"
+	"project/internal/model"
+	"project/pkg/errcode"
+	"project/pkg/global"
+	"project/pkg/utils"
+
+	"github.com/go-basic/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+type NotificationTemplate struct{}
+
+// notificationTemplateVariables 模板可用变量及说明（未取到的变量为空字符串）
+var notificationTemplateVariables = map[string]string{
+	"event_type":        "事件类型",
+	"subject":           "默认标题",
+	"content":           "默认正文",
+	"alarm_id":          "告警id",
+	"alarm_name":        "告警名称",
+	"alarm_level":       "告警级别 H/M/L",
+	"alarm_level_label": "告警级别（按接收人语言翻译）",
+	"description":       "告警描述",
+	"details":           "触发详情",
+	"value":             "触发值",
+	"threshold":         "阈值",
+	"time":              "触发时间",
+	"device_name":       "设备名称（多设备时为第一个）",
+	"device_number":     "设备编号（多设备时为第一个）",
+	"device_count":      "设备数量",
+	"dealer_name":       "设备所属经销商",
+	"incident_id":       "告警事件id",
+	"link":              "告警详情链接（需配置 notification.link_base_url）",
+	"tenant_id":         "租户id",
+	"approval_id":       "审批申请id",
+	"operation_type":    "审批操作类型",
+	"approval_status":   "审批状态",
+	"summary":           "审批摘要",
+	"expires_at":        "审批截止时间",
+}
+
+// alarmConditionValuePattern 触发详情中的“实际值 运算符 阈值”，如 "设备(A)遥测 [温度]: 35 > 30"
+var alarmConditionValuePattern = regexp.MustCompile(`:\s*(\S+)\s+(>=|<=|!=|==|=|>|<|between|in|not in)\s+(.+)$`)
+
+// renderedNotification 渲染后的通知
+type renderedNotification struct {
+	Subject         string
+	Body            string
+	SmsTemplateCode string
+}
+
+// notificationLanguageCandidates 语言匹配顺序：完整语言代码、基础语言、通用模板
+func notificationLanguageCandidates(lang string) []string {
+	lang = errcode.NormalizeLanguage(strings.TrimSpace(lang))
+	candidates := make([]string, 0, 3)
+	if lang != "" {
+		candidates = append(candidates, lang)
+		if idx := strings.Index(lang, "_"); idx > 0 {
+			candidates = append(candidates, lang[:idx])
+		}
+	}
+	return append(candidates, "")
+}
+
+// matchNotificationTemplate 按接收人语言选择模板
+func matchNotificationTemplate(templates []model.NotificationTemplate, lang string) *model.NotificationTemplate {
+	for _, candidate := range notificationLanguageCandidates(lang) {
+		for i := range templates {
+			if errcode.NormalizeLanguage(templates[i].Language) == candidate {
+				return &templates[i]
+			}
+		}
+	}
+	return nil
+}
+
+// alarmConditionValues 从触发详情中取第一个条件的实际值与阈值
+func alarmConditionValues(details string) (string, string) {
+	for _, part := range strings.Split(details, ";") {
+		if m := alarmConditionValuePattern.FindStringSubmatch(strings.TrimSpace(part)); m != nil {
+			return m[1], strings.TrimSpace(m[3])
+		}
+	}
+	return "", ""
+}
+
+// notificationString 通知JSON中的值转为字符串
+func notificationString(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return fmt.Sprintf("%v", val)
+	default:
+		b, _ := json.Marshal(val)
+		return string(b)
+	}
+}
+
+// notificationTemplateVars 由标准通知JSON构建模板变量，原始字段同样可用
+func notificationTemplateVars(data map[string]interface{}) map[string]interface{} {
+	vars := make(map[string]interface{}, len(data)+len(notificationTemplateVariables))
+	for k := range notificationTemplateVariables {
+		vars[k] = ""
+	}
+	for k, v := range data {
+		vars[k] = v
+	}
+	vars["alarm_id"] = notificationString(data["id"])
+	if name := notificationString(data["alarm_config_name"]); name != "" {
+		vars["alarm_name"] = name
+	}
+	if ts, err := time.Parse(time.RFC3339, notificationString(data["timestamp"])); err == nil {
+		vars["time"] = ts.Format("2006-01-02 15:04:05")
+	}
+	details := notificationString(data["details"])
+	vars["value"], vars["threshold"] = alarmConditionValues(details)
+
+	deviceIDs, _ := data["device_ids"].([]interface{})
+	// 审批等非告警通知直接携带设备数量
+	if _, ok := data["device_count"]; !ok {
+		vars["device_count"] = len(deviceIDs)
+	}
+	if devices, _ := data["devices"].([]interface{}); len(devices) > 0 {
+		if device, ok := devices[0].(map[string]interface{}); ok {
+			vars["device_number"] = notificationString(device["device_number"])
+			vars["device_name"] = notificationString(device["name"])
+		}
+	}
+	if vars["device_name"] == "" {
+		vars["device_name"] = vars["device_number"]
+	}
+	if len(deviceIDs) > 0 {
+		vars["device_id"] = notificationString(deviceIDs[0])
+	}
+	return vars
+}
+
+// enrichNotificationTemplateVars 补充需查询的变量：设备、经销商、详情链接
+func enrichNotificationTemplateVars(vars map[string]interface{}) {
+	deviceID, _ := vars["device_id"].(string)
+	if deviceID != "" {
+		if vars["device_number"] == "" {
+			if device, err := dal.GetDeviceByID(deviceID); err == nil && device != nil {
+				vars["device_number"] = device.DeviceNumber
+				vars["device_name"] = device.DeviceNumber
+				if device.Name != nil && *device.Name != "" {
+					vars["device_name"] = *device.Name
+				}
+			}
+		}
+		var dealerName string
+		if err := global.DB.Table("device_batteries dbat").
+			Select("o.name").
+			Joins("JOIN orgs o ON o.id = dbat.owner_org_id").
+			Where("dbat.device_id = ?", deviceID).
+			Limit(1).
+			Scan(&dealerName).Error; err == nil && dealerName != "" {
+			vars["dealer_name"] = dealerName
+		}
+	}
+	if base := strings.TrimRight(viper.GetString("notification.link_base_url"), "/"); base != "" {
+		if alarmID, _ := vars["alarm_id"].(string); alarmID != "" {
+			vars["link"] = base + "/alarm/history?id=" + alarmID
+		}
+	}
+}
+
+// alarmLevelLabel 告警级别按语言翻译（sys_dict ALARM_LEVEL）
+func alarmLevelLabel(level, lang string) string {
+	if level == "" {
+		return ""
+	}
+	for _, candidate := range notificationLanguageCandidates(lang) {
+		if candidate == "" {
+			break
+		}
+		rows, err := dal.GetDictLanguageByDictCodeAndLanguageCode("ALARM_LEVEL", candidate)
+		if err != nil {
+			return level
+		}
+		for _, row := range rows {
+			if notificationString(row["dict_value"]) == level {
+				if t := notificationString(row["translation"]); t != "" {
+					return t
+				}
+			}
+		}
+	}
+	return level
+}
+
+// notificationTemplateFuncs 模板函数：json 输出JSON字面量（用于Webhook/短信参数），default 为空时取默认值
+var notificationTemplateFuncs = map[string]interface{}{
+	"json": func(v interface{}) string {
+		buffer := &bytes.Buffer{}
+		encoder := json.NewEncoder(buffer)
+		encoder.SetEscapeHTML(false)
+		_ = encoder.Encode(v)
+		return strings.TrimSpace(buffer.String())
+	},
+	"default": func(def, v interface{}) interface{} {
+		if v == nil || v == "" {
+			return def
+		}
+		return v
+	},
+}
+
+// renderNotificationTemplate 渲染标题与正文；邮件正文按HTML转义，Webhook与短信正文须为JSON对象
+func renderNotificationTemplate(channel string, subject *string, body string, vars map[string]interface{}) (*renderedNotification, error) {
+	out := &renderedNotification{}
+	if subject != nil && *subject != "" {
+		t, err := template.New("subject").Funcs(notificationTemplateFuncs).Parse(*subject)
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, vars); err != nil {
+			return nil, err
+		}
+		out.Subject = strings.TrimSpace(buf.String())
+	}
+
+	var buf bytes.Buffer
+	if channel == model.NoticeType_Email {
+		t, err := htmltemplate.New("body").Funcs(notificationTemplateFuncs).Parse(body)
+		if err != nil {
+			return nil, err
+		}
+		if err := t.Execute(&buf, vars); err != nil {
+			return nil, err
+		}
+	} else {
+		t, err := template.New("body").Funcs(notificationTemplateFuncs).Parse(body)
+		if err != nil {
+			return nil, err
+		}
+		if err := t.Execute(&buf, vars); err != nil {
+			return nil, err
+		}
+	}
+	out.Body = strings.TrimSpace(buf.String())
+
+	if channel == model.NoticeType_Webhook || channel == model.NoticeType_SME {
+		var obj map[string]interface{}
+		if err := json.Unmarshal([]byte(out.Body), &obj); err != nil {
+			return nil, fmt.Errorf("rendered body is not a JSON object: %w", err)
+		}
+	}
+	return out, nil
+}
+
+// notificationRenderer 一次通知投递内按接收人语言渲染租户模板
+type notificationRenderer struct {
+	vars      map[string]interface{}
+	templates []model.NotificationTemplate
+	cache     map[string]*renderedNotification
+}
+
+// newNotificationRenderer 租户没有对应事件类型与渠道的启用模板时返回 nil，调用方使用原始通知内容
+func newNotificationRenderer(tenantID, channel, alertJson string) *notificationRenderer {
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(alertJson), &data); err != nil {
+		return nil
+	}
+	eventType := notificationString(data["event_type"])
+	if eventType == "" {
+		return nil
+	}
+	var templates []model.NotificationTemplate
+	if err := global.DB.Where("tenant_id = ? AND event_type = ? AND channel = ? AND enabled = true", tenantID, eventType, channel).
+		Find(&templates).Error; err != nil {
+		logrus.WithError(err).Warn("查询通知模板失败")
+		return nil
+	}
+	if len(templates) == 0 {
+		return nil
+	}
+	vars := notificationTemplateVars(data)
+	enrichNotificationTemplateVars(vars)
+	return &notificationRenderer{vars: vars, templates: templates, cache: make(map[string]*renderedNotification)}
+}
+
+// Render 按语言渲染，没有匹配模板或渲染失败时返回 nil
+func (r *notificationRenderer) Render(lang string) *renderedNotification {
+	if r == nil {
+		return nil
+	}
+	if out, ok := r.cache[lang]; ok {
+		return out
+	}
+	var out *renderedNotification
+	if tpl := matchNotificationTemplate(r.templates, lang); tpl != nil {
+		vars := make(map[string]interface{}, len(r.vars)+1)
+		for k, v := range r.vars {
+			vars[k] = v
+		}
+		vars["alarm_level_label"] = alarmLevelLabel(notificationString(vars["alarm_level"]), lang)
+		rendered, err := renderNotificationTemplate(tpl.Channel, tpl.Subject, tpl.Body, vars)
+		if err != nil {
+			logrus.WithError(err).WithField("template_id", tpl.ID).Warn("渲染通知模板失败，使用原始通知内容")
+		} else {
+			if tpl.SmsTemplateCode != nil {
+				rendered.SmsTemplateCode = *tpl.SmsTemplateCode
+			}
+			out = rendered
+		}
+	}
+	r.cache[lang] = out
+	return out
+}
+
+// notificationTenantLanguage 租户默认语言（租户管理员的默认语言）
+func notificationTenantLanguage(tenantID string) string {
+	if admin, err := dal.GetTenantAdmin(tenantID); err == nil && admin != nil && admin.DefaultLanguage != nil {
+		return *admin.DefaultLanguage
+	}
+	return ""
+}
+
+// notificationUserLanguages 用户的默认语言，未设置时使用租户默认语言
+func notificationUserLanguages(tenantID string, userIDs []string) map[string]string {
+	type userLanguage struct {
+		ID              string
+		DefaultLanguage *string
+	}
+	var rows []userLanguage
+	if len(userIDs) > 0 {
+		_ = global.DB.Table("users").Select("id, default_language").Where("id IN ?", userIDs).Scan(&rows).Error
+	}
+	tenantLang := notificationTenantLanguage(tenantID)
+	languages := make(map[string]string, len(userIDs))
+	for _, id := range userIDs {
+		languages[id] = tenantLang
+	}
+	for _, row := range rows {
+		if row.DefaultLanguage != nil && *row.DefaultLanguage != "" {
+			languages[row.ID] = *row.DefaultLanguage
+		}
+	}
+	return languages
+}
+
+// notificationContactLanguage 按邮箱或手机号匹配租户用户的默认语言，未匹配时使用租户默认语言
+func notificationContactLanguage(tenantID, column, contact string) string {
+	var lang string
+	_ = global.DB.Table("users").
+		Select("default_language").
+		Where("tenant_id = ? AND "+column+" = ? AND default_language IS NOT NULL AND default_language <> ''", tenantID, contact).
+		Limit(1).
+		Scan(&lang).Error
+	if lang != "" {
+		return lang
+	}
+	return notificationTenantLanguage(tenantID)
+}
+
+// validateNotificationTemplate 保存前用示例数据试渲染，提前发现语法错误
+func validateNotificationTemplate(channel string, subject *string, body string) error {
+	vars := notificationTemplateVars(notificationTemplateSample())
+	vars["alarm_level_label"] = "High"
+	if _, err := renderNotificationTemplate(channel, subject, body, vars); err != nil {
+		return errcode.WithData(errcode.CodeParamError, map[string]interface{}{"message": err.Error()})
+	}
+	return nil
+}
+
+// notificationTemplateSample 预览与校验使用的示例告警
+func notificationTemplateSample() map[string]interface{} {
+	return map[string]interface{}{
+		"event_type":        model.NotificationEventAlarm,
+		"id":                "00000000-0000-0000-0000-000000000000",
+		"alarm_config_name": "High temperature",
+		"subject":           "[ALERT] High temperature [H]",
+		"content":           "Alert: High temperature\nLevel: H",
+		"description":       "Cell temperature too high",
+		"details":           "设备(BAT-001)遥测 [temperature]: 58 > 55",
+		"timestamp":         time.Now().Format(time.RFC3339),
+		"alarm_level":       "H",
+		"device_ids":        []interface{}{"sample-device"},
+		"devices":           []interface{}{map[string]interface{}{"name": "BAT-001", "device_number": "BAT-001"}},
+		"dealer_name":       "Sample dealer",
+	}
+}
+
+// CreateNotificationTemplate 新建通知模板
+func (*NotificationTemplate) CreateNotificationTemplate(ctx context.Context, req *model.CreateNotificationTemplateReq, claims *utils.UserClaims) (*model.NotificationTemplate, error) {
+	if err := validateNotificationTemplate(req.Channel, req.Subject, req.Body); err != nil {
+		return nil, err
+	}
+	if req.Channel == model.NoticeType_SME && (req.SmsTemplateCode == nil || strings.TrimSpace(*req.SmsTemplateCode) == "") {
+		return nil, errcode.WithData(errcode.CodeParamError, map[string]interface{}{"message": "sms_template_code is required for SME templates"})
+	}
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+	now := time.Now().UTC()
+	tpl := &model.NotificationTemplate{
+		ID:              uuid.New(),
+		TenantID:        claims.TenantID,
+		EventType:       req.EventType,
+		Channel:         req.Channel,
+		Language:        errcode.NormalizeLanguage(strings.TrimSpace(req.Language)),
+		Subject:         req.Subject,
+		Body:            req.Body,
+		SmsTemplateCode: req.SmsTemplateCode,
+		Enabled:         enabled,
+		Remark:          req.Remark,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	var cnt int64
+	if err := global.DB.WithContext(ctx).Model(&model.NotificationTemplate{}).
+		Where("tenant_id = ? AND event_type = ? AND channel = ? AND language = ?", tpl.TenantID, tpl.EventType, tpl.Channel, tpl.Language).
+		Count(&cnt).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	if cnt > 0 {
+		return nil, errcode.WithData(errcode.CodeParamError, map[string]interface{}{"message": "template for this event type, channel and language already exists"})
+	}
+	if err := global.DB.WithContext(ctx).Create(tpl).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return tpl, nil
+}
+
+// getNotificationTemplate 本租户的通知模板
+func getNotificationTemplate(ctx context.Context, id, tenantID string) (*model.NotificationTemplate, error) {
+	var tpl model.NotificationTemplate
+	err := global.DB.WithContext(ctx).Where("id = ? AND tenant_id = ?", id, tenantID).First(&tpl).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errcode.New(errcode.CodeNotFound)
+	}
+	if err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return &tpl, nil
+}
+
+// UpdateNotificationTemplate 修改通知模板
+func (*NotificationTemplate) UpdateNotificationTemplate(ctx context.Context, id string, req *model.UpdateNotificationTemplateReq, claims *utils.UserClaims) (*model.NotificationTemplate, error) {
+	tpl, err := getNotificationTemplate(ctx, id, claims.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Subject != nil {
+		tpl.Subject = req.Subject
+	}
+	if req.Body != nil {
+		tpl.Body = *req.Body
+	}
+	if req.SmsTemplateCode != nil {
+		tpl.SmsTemplateCode = req.SmsTemplateCode
+	}
+	if req.Enabled != nil {
+		tpl.Enabled = *req.Enabled
+	}
+	if req.Remark != nil {
+		tpl.Remark = req.Remark
+	}
+	if err := validateNotificationTemplate(tpl.Channel, tpl.Subject, tpl.Body); err != nil {
+		return nil, err
+	}
+	tpl.UpdatedAt = time.Now().UTC()
+	if err := global.DB.WithContext(ctx).Save(tpl).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return tpl, nil
+}
+
+// DeleteNotificationTemplate 删除通知模板
+func (*NotificationTemplate) DeleteNotificationTemplate(ctx context.Context, id string, claims *utils.UserClaims) error {
+	res := global.DB.WithContext(ctx).Where("id = ? AND tenant_id = ?", id, claims.TenantID).Delete(&model.NotificationTemplate{})
+	if res.Error != nil {
+		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": res.Error.Error()})
+	}
+	if res.RowsAffected == 0 {
+		return errcode.New(errcode.CodeNotFound)
+	}
+	return nil
+}
+
+// GetNotificationTemplateListByPage 通知模板列表
+func (*NotificationTemplate) GetNotificationTemplateListByPage(ctx context.Context, req *model.GetNotificationTemplateListByPageReq, claims *utils.UserClaims) (map[string]interface{}, error) {
+	db := global.DB.WithContext(ctx).Model(&model.NotificationTemplate{}).Where("tenant_id = ?", claims.TenantID)
+	if req.EventType != nil && *req.EventType != "" {
+		db = db.Where("event_type = ?", *req.EventType)
+	}
+	if req.Channel != nil && *req.Channel != "" {
+		db = db.Where("channel = ?", *req.Channel)
+	}
+	if req.Language != nil {
+		db = db.Where("language = ?", errcode.NormalizeLanguage(*req.Language))
+	}
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	list := make([]model.NotificationTemplate, 0)
+	if err := db.Order("event_type, channel, language").
+		Offset((req.Page - 1) * req.PageSize).
+		Limit(req.PageSize).
+		Find(&list).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return map[string]interface{}{"total": total, "list": list}, nil
+}
+
+// PreviewNotificationTemplate 用示例数据或传入数据预览模板
+func (*NotificationTemplate) PreviewNotificationTemplate(req *model.PreviewNotificationTemplateReq) (map[string]interface{}, error) {
+	data := req.Data
+	if len(data) == 0 {
+		data = notificationTemplateSample()
+	}
+	vars := notificationTemplateVars(data)
+	vars["alarm_level_label"] = alarmLevelLabel(notificationString(vars["alarm_level"]), req.Language)
+	out, err := renderNotificationTemplate(req.Channel, req.Subject, req.Body, vars)
+	if err != nil {
+		return nil, errcode.WithData(errcode.CodeParamError, map[string]interface{}{"message": err.Error()})
+	}
+	return map[string]interface{}{"subject": out.Subject, "body": out.Body}, nil
+}
+
+// GetNotificationTemplateVariables 模板可用变量
+func (*NotificationTemplate) GetNotificationTemplateVariables() map[string]interface{} {
+	return map[string]interface{}{
+		"variables":   notificationTemplateVariables,
+		"event_types": []string{model.NotificationEventAlarm, model.NotificationEventAlarmEscalation, model.NotificationEventDigest, model.NotificationEventMaintenance, model.NotificationEventApproval},
+		"channels": []string{model.NoticeType_Email, model.NoticeType_SME, model.NoticeType_Webhook, model.NoticeType_Member, model.NotificationChannelAppPush,
+			model.NoticeType_DingTalk, model.NoticeType_WeCom, model.NoticeType_Feishu, model.NoticeType_Slack},
+	}
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"project/internal/model"
+)
+
+func TestNotificationLanguageCandidates(t *testing.T) {
+	got := strings.Join(notificationLanguageCandidates("zh-CN"), ",")
+	if got != "zh_CN,zh," {
+		t.Fatalf("candidates = %q", got)
+	}
+	if got := notificationLanguageCandidates(""); len(got) != 1 || got[0] != "" {
+		t.Fatalf("empty candidates = %v", got)
+	}
+}
+
+func TestMatchNotificationTemplate(t *testing.T) {
+	templates := []model.NotificationTemplate{
+		{ID: "generic", Language: ""},
+		{ID: "zh", Language: "zh"},
+		{ID: "en", Language: "en_US"},
+	}
+	cases := map[string]string{
+		"zh-CN": "zh",
+		"en_US": "en",
+		"en-GB": "generic",
+		"":      "generic",
+	}
+	for lang, want := range cases {
+		tpl := matchNotificationTemplate(templates, lang)
+		if tpl == nil || tpl.ID != want {
+			t.Fatalf("lang %q: got %+v, want %s", lang, tpl, want)
+		}
+	}
+	if matchNotificationTemplate(templates[1:], "fr") != nil {
+		t.Fatal("expected no template without generic fallback")
+	}
+}
+
+func TestAlarmConditionValues(t *testing.T) {
+	value, threshold := alarmConditionValues("场景自动化触发告警;设备(BAT-1)遥测 [温度]: 58.5 > 55")
+	if value != "58.5" || threshold != "55" {
+		t.Fatalf("got %q %q", value, threshold)
+	}
+	value, threshold = alarmConditionValues("设备(BAT-1)已上线")
+	if value != "" || threshold != "" {
+		t.Fatalf("got %q %q", value, threshold)
+	}
+}
+
+func TestNotificationTemplateVars(t *testing.T) {
+	vars := notificationTemplateVars(map[string]interface{}{
+		"id":                "a1",
+		"alarm_config_name": "High temp",
+		"timestamp":         "2026-01-02T03:04:05Z",
+		"details":           "设备(BAT-1)遥测 [温度]: 58 > 55",
+		"device_ids":        []interface{}{"d1", "d2"},
+		"devices":           []interface{}{map[string]interface{}{"device_number": "BAT-1"}},
+	})
+	if vars["alarm_id"] != "a1" || vars["alarm_name"] != "High temp" || vars["time"] != "2026-01-02 03:04:05" {
+		t.Fatalf("vars = %v", vars)
+	}
+	if vars["device_name"] != "BAT-1" || vars["device_count"] != 2 || vars["device_id"] != "d1" {
+		t.Fatalf("device vars = %v", vars)
+	}
+	if vars["value"] != "58" || vars["threshold"] != "55" || vars["dealer_name"] != "" {
+		t.Fatalf("value vars = %v", vars)
+	}
+}
+
+func TestRenderNotificationTemplate(t *testing.T) {
+	vars := map[string]interface{}{"device_name": `<b>"A"</b>`, "value": "58"}
+	subject := "{{.device_name}} {{.value}}"
+
+	out, err := renderNotificationTemplate(model.NoticeType_Email, &subject, "<p>{{.device_name}}</p>", vars)
+	if err != nil {
+		t.Fatalf("email: %v", err)
+	}
+	if out.Subject != `<b>"A"</b> 58` || strings.Contains(out.Body, "<b>") {
+		t.Fatalf("email out = %+v", out)
+	}
+
+	out, err = renderNotificationTemplate(model.NoticeType_Webhook, nil, `{"text": {{json .device_name}}, "v": {{default "0" .missing | json}}}`, vars)
+	if err != nil {
+		t.Fatalf("webhook: %v", err)
+	}
+	if out.Body != `{"text": "<b>\"A\"</b>", "v": "0"}` {
+		t.Fatalf("webhook body = %s", out.Body)
+	}
+
+	if _, err := renderNotificationTemplate(model.NoticeType_Webhook, nil, `text {{.value}}`, vars); err == nil {
+		t.Fatal("expected error for non-JSON webhook body")
+	}
+	if _, err := renderNotificationTemplate(model.NoticeType_Member, nil, `{{.value`, vars); err == nil {
+		t.Fatal("expected parse error")
+	}
+}
@@ -0,0 +1,538 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	oidcDiscoveryTTL      = time.Hour        // 发现文档缓存时间
+	oidcJWKSTTL           = time.Hour        // 签名公钥缓存时间
+	oidcJWKSRefreshMin    = 30 * time.Second // 遇到未知 kid 时重新拉取公钥的最小间隔
+	oidcClockSkew         = 2 * time.Minute  // 校验令牌时间允许的时钟偏差
+	oidcBackchannelEvent  = "http://schemas.openid.net/event/backchannel-logout"
+	oidcResponseBodyLimit = 1 << 20
+)
+
+// oidcHTTPClient 访问身份提供方的客户端
+var oidcHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// oidcDiscovery 身份提供方发现文档中用到的字段
+type oidcDiscovery struct {
+	Issuer                   string   `json:"issuer"`
+	AuthorizationEndpoint    string   `json:"authorization_endpoint"`
+	TokenEndpoint            string   `json:"token_endpoint"`
+	UserinfoEndpoint         string   `json:"userinfo_endpoint"`
+	JwksURI                  string   `json:"jwks_uri"`
+	EndSessionEndpoint       string   `json:"end_session_endpoint"`
+	TokenEndpointAuthMethods []string `json:"token_endpoint_auth_methods_supported"`
+}
+
+// oidcTokenResponse 令牌端点响应
+type oidcTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+type oidcDiscoveryEntry struct {
+	doc       *oidcDiscovery
+	expiresAt time.Time
+}
+
+type oidcJWKSEntry struct {
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+var oidcCache = struct {
+	sync.Mutex
+	discovery map[string]oidcDiscoveryEntry
+	jwks      map[string]oidcJWKSEntry
+}{discovery: make(map[string]oidcDiscoveryEntry), jwks: make(map[string]oidcJWKSEntry)}
+
+// oidcGetJSON GET 并解析 JSON 响应
+func oidcGetJSON(ctx context.Context, rawURL, bearer string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	return oidcDo(req, out)
+}
+
+func oidcDo(req *http.Request, out interface{}) error {
+	resp, err := oidcHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, oidcResponseBodyLimit))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s: HTTP %d: %s", req.Method, req.URL.Redacted(), resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("%s %s: invalid JSON: %v", req.Method, req.URL.Redacted(), err)
+	}
+	return nil
+}
+
+// oidcIssuerEqual 颁发者比较，忽略末尾斜杠
+func oidcIssuerEqual(a, b string) bool {
+	return strings.TrimRight(a, "/") == strings.TrimRight(b, "/")
+}
+
+// fetchOidcDiscovery 读取发现文档，按颁发者缓存
+func fetchOidcDiscovery(ctx context.Context, issuer string) (*oidcDiscovery, error) {
+	oidcCache.Lock()
+	if e, ok := oidcCache.discovery[issuer]; ok && time.Now().Before(e.expiresAt) {
+		oidcCache.Unlock()
+		return e.doc, nil
+	}
+	oidcCache.Unlock()
+
+	var doc oidcDiscovery
+	if err := oidcGetJSON(ctx, strings.TrimRight(issuer, "/")+"/.well-known/openid-configuration", "", &doc); err != nil {
+		return nil, err
+	}
+	if !oidcIssuerEqual(doc.Issuer, issuer) {
+		return nil, fmt.Errorf("issuer mismatch: configured %s, discovery %s", issuer, doc.Issuer)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JwksURI == "" {
+		return nil, errors.New("discovery document is missing authorization_endpoint, token_endpoint or jwks_uri")
+	}
+
+	oidcCache.Lock()
+	oidcCache.discovery[issuer] = oidcDiscoveryEntry{doc: &doc, expiresAt: time.Now().Add(oidcDiscoveryTTL)}
+	oidcCache.Unlock()
+	return &doc, nil
+}
+
+// oidcJWK 签名公钥
+type oidcJWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func oidcB64Int(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// publicKey 解析 RSA / EC 公钥
+func (k oidcJWK) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := oidcB64Int(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := oidcB64Int(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := oidcB64Int(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := oidcB64Int(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+}
+
+// fetchOidcJWKS 读取签名公钥；forceRefresh 用于身份提供方轮换密钥后出现未知 kid 的情况
+func fetchOidcJWKS(ctx context.Context, jwksURI string, forceRefresh bool) (map[string]crypto.PublicKey, error) {
+	oidcCache.Lock()
+	e, ok := oidcCache.jwks[jwksURI]
+	oidcCache.Unlock()
+	if ok {
+		age := time.Since(e.fetchedAt)
+		if age < oidcJWKSTTL && (!forceRefresh || age < oidcJWKSRefreshMin) {
+			return e.keys, nil
+		}
+	}
+
+	var set struct {
+		Keys []oidcJWK `json:"keys"`
+	}
+	if err := oidcGetJSON(ctx, jwksURI, "", &set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = pub
+	}
+
+	oidcCache.Lock()
+	oidcCache.jwks[jwksURI] = oidcJWKSEntry{keys: keys, fetchedAt: time.Now()}
+	oidcCache.Unlock()
+	return keys, nil
+}
+
+// oidcSigningMethods 接受的签名算法，拒绝 none 与对称算法
+var oidcSigningMethods = map[string]bool{
+	"RS256": true, "RS384": true, "RS512": true,
+	"PS256": true, "PS384": true, "PS512": true,
+	"ES256": true, "ES384": true, "ES512": true,
+}
+
+// parseOidcJWT 校验签名并返回声明，时间类声明由调用方校验
+func parseOidcJWT(ctx context.Context, jwksURI, token string) (jwt.MapClaims, error) {
+	lookup := func(forceRefresh bool) func(*jwt.Token) (interface{}, error) {
+		return func(t *jwt.Token) (interface{}, error) {
+			if !oidcSigningMethods[t.Method.Alg()] {
+				return nil, fmt.Errorf("unexpected signing method %s", t.Method.Alg())
+			}
+			keys, err := fetchOidcJWKS(ctx, jwksURI, forceRefresh)
+			if err != nil {
+				return nil, err
+			}
+			kid, _ := t.Header["kid"].(string)
+			if key, ok := keys[kid]; ok {
+				return key, nil
+			}
+			if kid == "" && len(keys) == 1 {
+				for _, key := range keys {
+					return key, nil
+				}
+			}
+			return nil, errOidcUnknownKey
+		}
+	}
+	parser := &jwt.Parser{SkipClaimsValidation: true}
+	claims := jwt.MapClaims{}
+	_, err := parser.ParseWithClaims(token, claims, lookup(false))
+	if err != nil && errors.Is(unwrapJWTError(err), errOidcUnknownKey) {
+		claims = jwt.MapClaims{}
+		_, err = parser.ParseWithClaims(token, claims, lookup(true))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %v", unwrapJWTError(err))
+	}
+	return claims, nil
+}
+
+var errOidcUnknownKey = errors.New("signing key not found")
+
+func unwrapJWTError(err error) error {
+	var ve *jwt.ValidationError
+	if errors.As(err, &ve) && ve.Inner != nil {
+		return ve.Inner
+	}
+	return err
+}
+
+// oidcAudienceContains aud 可以是字符串或数组
+func oidcAudienceContains(claims jwt.MapClaims, clientID string) (bool, int) {
+	switch aud := claims["aud"].(type) {
+	case string:
+		return aud == clientID, 1
+	case []interface{}:
+		found := false
+		for _, a := range aud {
+			if s, _ := a.(string); s == clientID {
+				found = true
+			}
+		}
+		return found, len(aud)
+	}
+	return false, 0
+}
+
+// oidcNumericTime 数值型时间声明
+func oidcNumericTime(claims jwt.MapClaims, name string) (time.Time, bool) {
+	switch v := claims[name].(type) {
+	case float64:
+		return time.Unix(int64(v), 0), true
+	case json.Number:
+		n, err := v.Int64()
+		return time.Unix(n, 0), err == nil
+	}
+	return time.Time{}, false
+}
+
+// validateOidcTokenClaims 校验颁发者、受众与时间
+func validateOidcTokenClaims(claims jwt.MapClaims, issuer, clientID string, now time.Time, requireExp bool) error {
+	iss, _ := claims["iss"].(string)
+	if !oidcIssuerEqual(iss, issuer) {
+		return fmt.Errorf("issuer mismatch: %s", iss)
+	}
+	ok, n := oidcAudienceContains(claims, clientID)
+	if !ok {
+		return errors.New("audience does not contain client_id")
+	}
+	if n > 1 {
+		if azp, _ := claims["azp"].(string); azp != clientID {
+			return errors.New("azp does not match client_id")
+		}
+	}
+	if exp, ok := oidcNumericTime(claims, "exp"); ok {
+		if !now.Before(exp.Add(oidcClockSkew)) {
+			return errors.New("token has expired")
+		}
+	} else if requireExp {
+		return errors.New("token has no exp")
+	}
+	if iat, ok := oidcNumericTime(claims, "iat"); ok && iat.After(now.Add(oidcClockSkew)) {
+		return errors.New("token issued in the future")
+	}
+	if nbf, ok := oidcNumericTime(claims, "nbf"); ok && nbf.After(now.Add(oidcClockSkew)) {
+		return errors.New("token not yet valid")
+	}
+	return nil
+}
+
+// verifyOidcIDToken 校验 ID Token：签名、颁发者、受众、有效期与 nonce
+func verifyOidcIDToken(ctx context.Context, disc *oidcDiscovery, clientID, nonce, idToken string, now time.Time) (jwt.MapClaims, error) {
+	claims, err := parseOidcJWT(ctx, disc.JwksURI, idToken)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateOidcTokenClaims(claims, disc.Issuer, clientID, now, true); err != nil {
+		return nil, err
+	}
+	if got, _ := claims["nonce"].(string); got != nonce {
+		return nil, errors.New("nonce mismatch")
+	}
+	if sub, _ := claims["sub"].(string); sub == "" {
+		return nil, errors.New("id token has no sub")
+	}
+	return claims, nil
+}
+
+// verifyOidcLogoutToken 校验后端通道退出令牌（OpenID Connect Back-Channel Logout 1.0）
+func verifyOidcLogoutToken(ctx context.Context, disc *oidcDiscovery, clientID, logoutToken string, now time.Time) (sub, sid string, err error) {
+	claims, err := parseOidcJWT(ctx, disc.JwksURI, logoutToken)
+	if err != nil {
+		return "", "", err
+	}
+	if err := validateOidcTokenClaims(claims, disc.Issuer, clientID, now, false); err != nil {
+		return "", "", err
+	}
+	if _, ok := claims["nonce"]; ok {
+		return "", "", errors.New("logout token must not contain nonce")
+	}
+	events, _ := claims["events"].(map[string]interface{})
+	if _, ok := events[oidcBackchannelEvent]; !ok {
+		return "", "", errors.New("logout token has no back-channel logout event")
+	}
+	sub, _ = claims["sub"].(string)
+	sid, _ = claims["sid"].(string)
+	if sub == "" && sid == "" {
+		return "", "", errors.New("logout token has neither sub nor sid")
+	}
+	return sub, sid, nil
+}
+
+// oidcRandomString URL 安全的随机串，用于 state、nonce 与 PKCE
+func oidcRandomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// oidcCodeChallenge PKCE S256
+func oidcCodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// buildOidcAuthorizeURL 授权码 + PKCE 登录地址
+func buildOidcAuthorizeURL(disc *oidcDiscovery, clientID, scopes, redirectURI, state, nonce, verifier string) string {
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", clientID)
+	q.Set("redirect_uri", redirectURI)
+	q.Set("scope", scopes)
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", oidcCodeChallenge(verifier))
+	q.Set("code_challenge_method", "S256")
+	sep := "?"
+	if strings.Contains(disc.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return disc.AuthorizationEndpoint + sep + q.Encode()
+}
+
+// exchangeOidcCode 用授权码换取令牌；有客户端密钥时优先 client_secret_basic
+func exchangeOidcCode(ctx context.Context, disc *oidcDiscovery, clientID, clientSecret, code, redirectURI, verifier string) (*oidcTokenResponse, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectURI)
+	form.Set("code_verifier", verifier)
+	useBasic := false
+	if clientSecret != "" {
+		useBasic = len(disc.TokenEndpointAuthMethods) == 0
+		for _, m := range disc.TokenEndpointAuthMethods {
+			if m == "client_secret_basic" {
+				useBasic = true
+			}
+		}
+	}
+	if !useBasic {
+		form.Set("client_id", clientID)
+		if clientSecret != "" {
+			form.Set("client_secret", clientSecret)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, disc.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if useBasic {
+		req.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))
+	}
+	var tok oidcTokenResponse
+	if err := oidcDo(req, &tok); err != nil {
+		return nil, err
+	}
+	if tok.IDToken == "" {
+		return nil, errors.New("token response has no id_token")
+	}
+	return &tok, nil
+}
+
+// fetchOidcUserinfo 读取用户信息端点，sub 必须与 ID Token 一致
+func fetchOidcUserinfo(ctx context.Context, disc *oidcDiscovery, accessToken, sub string) (map[string]interface{}, error) {
+	if disc.UserinfoEndpoint == "" || accessToken == "" {
+		return nil, nil
+	}
+	info := make(map[string]interface{})
+	if err := oidcGetJSON(ctx, disc.UserinfoEndpoint, accessToken, &info); err != nil {
+		return nil, err
+	}
+	if got, _ := info["sub"].(string); got != sub {
+		return nil, errors.New("userinfo sub does not match id token")
+	}
+	return info, nil
+}
+
+// oidcClaimStrings 读取声明值，支持字符串、字符串数组与点号分隔的嵌套路径（如 realm_access.roles）
+func oidcClaimStrings(claims map[string]interface{}, path string) []string {
+	if path == "" {
+		return nil
+	}
+	var cur interface{} = claims
+	if v, ok := claims[path]; ok {
+		cur = v
+	} else {
+		for _, part := range strings.Split(path, ".") {
+			m, ok := cur.(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			cur = m[part]
+		}
+	}
+	switch v := cur.(type) {
+	case string:
+		if v == "" {
+			return nil
+		}
+		return []string{v}
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	case bool, float64:
+		return []string{fmt.Sprint(v)}
+	}
+	return nil
+}
+
+// oidcClaimString 单值声明
+func oidcClaimString(claims map[string]interface{}, path string) string {
+	if list := oidcClaimStrings(claims, path); len(list) > 0 {
+		return list[0]
+	}
+	return ""
+}
+
+// buildOidcEndSessionURL 退出登录时跳转身份提供方的地址（RP-Initiated Logout）
+func buildOidcEndSessionURL(disc *oidcDiscovery, clientID, idToken, postLogoutRedirectURI string) string {
+	if disc.EndSessionEndpoint == "" {
+		return ""
+	}
+	q := url.Values{}
+	q.Set("client_id", clientID)
+	if idToken != "" {
+		q.Set("id_token_hint", idToken)
+	}
+	if postLogoutRedirectURI != "" {
+		q.Set("post_logout_redirect_uri", postLogoutRedirectURI)
+	}
+	sep := "?"
+	if strings.Contains(disc.EndSessionEndpoint, "?") {
+		sep = "&"
+	}
+	return disc.EndSessionEndpoint + sep + q.Encode()
+}
@@ -0,0 +1,301 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"project/internal/model"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// mockOidcServer 本地模拟的身份提供方：发现文档、JWKS、令牌与用户信息端点
+type mockOidcServer struct {
+	*httptest.Server
+	t         *testing.T
+	key       *rsa.PrivateKey
+	kid       string
+	challenge string // 授权请求中的 code_challenge
+	nonce     string
+	claims    jwt.MapClaims // 追加到 ID Token 的声明
+}
+
+func newMockOidcServer(t *testing.T) *mockOidcServer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockOidcServer{t: t, key: key, kid: "k1"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                m.URL,
+			"authorization_endpoint":                m.URL + "/authorize",
+			"token_endpoint":                        m.URL + "/token",
+			"userinfo_endpoint":                     m.URL + "/userinfo",
+			"jwks_uri":                              m.URL + "/jwks",
+			"end_session_endpoint":                  m.URL + "/logout",
+			"token_endpoint_auth_methods_supported": []string{"client_secret_basic"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": m.kid,
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(m.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(m.key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "console" || pass != "s3cret" {
+			http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+			return
+		}
+		if r.Form.Get("code") != "code-1" || oidcCodeChallenge(r.Form.Get("code_verifier")) != m.challenge {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "at-1",
+			"token_type":   "Bearer",
+			"id_token":     m.sign(m.idTokenClaims(), m.kid),
+		})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer at-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"sub": "user-1", "groups": []string{"iot-admins"}})
+	})
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+	return m
+}
+
+func (m *mockOidcServer) idTokenClaims() jwt.MapClaims {
+	now := time.Now()
+	c := jwt.MapClaims{
+		"iss":   m.URL,
+		"aud":   "console",
+		"sub":   "user-1",
+		"email": "Alice@Example.com",
+		"nonce": m.nonce,
+		"iat":   now.Unix(),
+		"exp":   now.Add(5 * time.Minute).Unix(),
+	}
+	for k, v := range m.claims {
+		c[k] = v
+	}
+	return c
+}
+
+func (m *mockOidcServer) sign(claims jwt.MapClaims, kid string) string {
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	tok.Header["kid"] = kid
+	s, err := tok.SignedString(m.key)
+	if err != nil {
+		m.t.Fatal(err)
+	}
+	return s
+}
+
+func TestOidcAuthorizationCodeFlow(t *testing.T) {
+	m := newMockOidcServer(t)
+	ctx := context.Background()
+	disc, err := fetchOidcDiscovery(ctx, m.URL+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authURL := buildOidcAuthorizeURL(disc, "console", "openid email", "https://iot.example.com/cb", "st", "n-1", "verifier-123")
+	u, _ := url.Parse(authURL)
+	q := u.Query()
+	if !strings.HasPrefix(authURL, m.URL+"/authorize?") || q.Get("code_challenge_method") != "S256" || q.Get("state") != "st" || q.Get("response_type") != "code" {
+		t.Fatalf("authorize url = %s", authURL)
+	}
+	m.challenge = q.Get("code_challenge")
+	m.nonce = q.Get("nonce")
+	m.claims = jwt.MapClaims{"sid": "s-1", "realm_access": map[string]interface{}{"roles": []interface{}{"ops"}}}
+
+	if _, err := exchangeOidcCode(ctx, disc, "console", "s3cret", "code-1", "https://iot.example.com/cb", "wrong-verifier"); err == nil {
+		t.Fatal("PKCE verifier mismatch must fail")
+	}
+	tok, err := exchangeOidcCode(ctx, disc, "console", "s3cret", "code-1", "https://iot.example.com/cb", "verifier-123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := verifyOidcIDToken(ctx, disc, "console", "n-1", tok.IDToken, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims["sid"] != "s-1" || oidcClaimString(claims, "realm_access.roles") != "ops" {
+		t.Fatalf("claims = %v", claims)
+	}
+	if _, err := verifyOidcIDToken(ctx, disc, "console", "other-nonce", tok.IDToken, time.Now()); err == nil {
+		t.Fatal("nonce mismatch must fail")
+	}
+	if _, err := verifyOidcIDToken(ctx, disc, "other-client", "n-1", tok.IDToken, time.Now()); err == nil {
+		t.Fatal("audience mismatch must fail")
+	}
+	if _, err := verifyOidcIDToken(ctx, disc, "console", "n-1", tok.IDToken, time.Now().Add(time.Hour)); err == nil {
+		t.Fatal("expired token must fail")
+	}
+
+	info, err := fetchOidcUserinfo(ctx, disc, tok.AccessToken, "user-1")
+	if err != nil || oidcClaimStrings(info, "groups")[0] != "iot-admins" {
+		t.Fatalf("userinfo = %v, %v", info, err)
+	}
+	if _, err := fetchOidcUserinfo(ctx, disc, tok.AccessToken, "user-2"); err == nil {
+		t.Fatal("userinfo sub mismatch must fail")
+	}
+
+	end := buildOidcEndSessionURL(disc, "console", tok.IDToken, "https://iot.example.com/login")
+	if !strings.HasPrefix(end, m.URL+"/logout?") || !strings.Contains(end, "id_token_hint=") {
+		t.Fatalf("end session url = %s", end)
+	}
+}
+
+func TestOidcRejectsForgedTokens(t *testing.T) {
+	m := newMockOidcServer(t)
+	ctx := context.Background()
+	disc, err := fetchOidcDiscovery(ctx, m.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.nonce = "n"
+
+	// 对称算法：用公钥字节作为 HMAC 密钥伪造
+	hs := jwt.NewWithClaims(jwt.SigningMethodHS256, m.idTokenClaims())
+	forged, _ := hs.SignedString(m.key.N.Bytes())
+	if _, err := verifyOidcIDToken(ctx, disc, "console", "n", forged, time.Now()); err == nil {
+		t.Fatal("HS256 token must be rejected")
+	}
+
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, m.idTokenClaims())
+	tok.Header["kid"] = m.kid
+	forged, _ = tok.SignedString(other)
+	if _, err := verifyOidcIDToken(ctx, disc, "console", "n", forged, time.Now()); err == nil {
+		t.Fatal("token signed by another key must be rejected")
+	}
+
+	// 身份提供方轮换密钥后未知 kid 触发重新拉取
+	m.kid = "k2"
+	oidcCache.Lock()
+	if e, ok := oidcCache.jwks[disc.JwksURI]; ok {
+		e.fetchedAt = time.Now().Add(-time.Minute)
+		oidcCache.jwks[disc.JwksURI] = e
+	}
+	oidcCache.Unlock()
+	if _, err := verifyOidcIDToken(ctx, disc, "console", "n", m.sign(m.idTokenClaims(), "k2"), time.Now()); err != nil {
+		t.Fatalf("rotated key: %v", err)
+	}
+}
+
+func TestOidcLogoutToken(t *testing.T) {
+	m := newMockOidcServer(t)
+	ctx := context.Background()
+	disc, err := fetchOidcDiscovery(ctx, m.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := jwt.MapClaims{
+		"iss":    m.URL,
+		"aud":    "console",
+		"iat":    time.Now().Unix(),
+		"sid":    "s-1",
+		"events": map[string]interface{}{oidcBackchannelEvent: map[string]interface{}{}},
+	}
+	sub, sid, err := verifyOidcLogoutToken(ctx, disc, "console", m.sign(claims, m.kid), time.Now())
+	if err != nil || sub != "" || sid != "s-1" {
+		t.Fatalf("logout token = %q %q %v", sub, sid, err)
+	}
+	claims["nonce"] = "n"
+	if _, _, err := verifyOidcLogoutToken(ctx, disc, "console", m.sign(claims, m.kid), time.Now()); err == nil {
+		t.Fatal("logout token with nonce must be rejected")
+	}
+	delete(claims, "nonce")
+	delete(claims, "events")
+	if _, _, err := verifyOidcLogoutToken(ctx, disc, "console", m.sign(claims, m.kid), time.Now()); err == nil {
+		t.Fatal("logout token without event must be rejected")
+	}
+}
+
+func TestResolveOidcMapping(t *testing.T) {
+	roleClaim, orgClaim, defaultOrg := "groups", "department", "org-default"
+	p := &model.OidcProvider{
+		DefaultAuthority: "TENANT_USER",
+		RoleClaim:        &roleClaim,
+		RoleMappings:     oidcJSON([]model.OidcRoleMapping{{Value: "viewers", Authority: "TENANT_USER", RoleIDs: []string{"r1"}}, {Value: "iot-admins", Authority: "TENANT_ADMIN", RoleIDs: []string{"r2", "r1"}}}),
+		OrgClaim:         &orgClaim,
+		OrgMappings:      oidcJSON([]model.OidcOrgMapping{{Value: "east", OrgID: "org-east"}, {Value: "west", OrgID: "org-west"}}),
+		DefaultOrgID:     &defaultOrg,
+	}
+	m := resolveOidcMapping(p, map[string]interface{}{"groups": []interface{}{"viewers", "iot-admins"}, "department": "west"})
+	if m.Authority != "TENANT_ADMIN" || !m.RoleMatched || strings.Join(m.RoleIDs, ",") != "r1,r2" || m.OrgID != "org-west" {
+		t.Fatalf("mapping = %+v", m)
+	}
+	m = resolveOidcMapping(p, map[string]interface{}{"groups": "other"})
+	if m.Authority != "TENANT_USER" || m.RoleMatched || m.OrgID != "org-default" {
+		t.Fatalf("default mapping = %+v", m)
+	}
+
+	if m.AuthorityMatched || m.OrgMatched {
+		t.Fatalf("default mapping marked as matched: %+v", m)
+	}
+
+	for in, want := range map[string]string{"/devices?id=1": "/devices?id=1", "//evil.com": "", "https://evil.com": "", "/\\evil.com": ""} {
+		if got := oidcSafeRedirect(in); got != want {
+			t.Errorf("oidcSafeRedirect(%q) = %q", in, got)
+		}
+	}
+}
+
+func TestOidcSyncUpdates(t *testing.T) {
+	p := &model.OidcProvider{}
+	now := time.Now()
+	matched := oidcMapping{Authority: "TENANT_ADMIN", AuthorityMatched: true, OrgID: "org-west", OrgMatched: true}
+
+	// 按邮箱关联的已有账号默认不同步
+	if u := oidcSyncUpdates(p, &model.UserIdentity{}, matched, now); u != nil {
+		t.Fatalf("linked account updated: %v", u)
+	}
+	u := oidcSyncUpdates(p, &model.UserIdentity{Provisioned: true}, matched, now)
+	if u["authority"] != "TENANT_ADMIN" || u["org_id"] != "org-west" {
+		t.Fatalf("provisioned updates = %v", u)
+	}
+	// 未命中映射时不以默认值覆盖
+	u = oidcSyncUpdates(p, &model.UserIdentity{Provisioned: true}, oidcMapping{Authority: "TENANT_USER", OrgID: "org-default"}, now)
+	if _, ok := u["authority"]; ok {
+		t.Fatalf("default authority overwrote: %v", u)
+	}
+	if _, ok := u["org_id"]; ok {
+		t.Fatalf("default org overwrote: %v", u)
+	}
+	p.SyncLinkedUsers = true
+	if u := oidcSyncUpdates(p, &model.UserIdentity{}, matched, now); u["authority"] != "TENANT_ADMIN" {
+		t.Fatalf("linked account not synced when enabled: %v", u)
+	}
+
+	for claims, want := range map[string]bool{`{"email_verified":true}`: true, `{"email_verified":false}`: false, `{}`: false, `{"email_verified":"true"}`: false} {
+		var m map[string]interface{}
+		_ = json.Unmarshal([]byte(claims), &m)
+		if got := oidcEmailVerified(m); got != want {
+			t.Errorf("oidcEmailVerified(%s) = %v", claims, got)
+		}
+	}
+}
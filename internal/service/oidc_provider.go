@@ -0,0 +1,854 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	dal "project/internal/dal"
+	"project/internal/model"
+	"project/pkg/errcode"
+	"project/pkg/global"
+	"project/pkg/utils"
+
+	"github.com/go-basic/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	oidcStateTTL        = 10 * time.Minute // 发起登录到回调的最长时间
+	oidcTicketTTL       = 2 * time.Minute  // 登录票据有效期
+	oidcDefaultScopes   = "openid profile email"
+	oidcDefaultAuthUser = "TENANT_USER"
+)
+
+// Oidc 控制台 OIDC 单点登录
+type Oidc struct{}
+
+// oidcLoginState 发起登录时保存的状态，回调时一次性取出
+type oidcLoginState struct {
+	ProviderID  string `json:"provider_id"`
+	Verifier    string `json:"verifier"`
+	Nonce       string `json:"nonce"`
+	RedirectURI string `json:"redirect_uri"`
+	Redirect    string `json:"redirect,omitempty"`
+}
+
+// oidcSession 单点登录产生的会话，用于退出登录时通知身份提供方及接收后端通道退出；按登录会话ID登记（旧版按令牌）
+type oidcSession struct {
+	ProviderID string `json:"provider_id"`
+	IDToken    string `json:"id_token"`
+	Sub        string `json:"sub"`
+	Sid        string `json:"sid,omitempty"`
+}
+
+// oidcMapping 按声明映射出的权限类型、角色与组织；Matched 标记是否由映射命中（否则为默认值）
+type oidcMapping struct {
+	Authority        string
+	AuthorityMatched bool
+	RoleIDs          []string
+	RoleMatched      bool
+	OrgID            string
+	OrgMatched       bool
+}
+
+func oidcStateKey(state string) string       { return "oidc:state:" + state }
+func oidcTicketKey(ticket string) string     { return "oidc:ticket:" + ticket }
+func oidcSessionKey(sessionID string) string { return "oidc:session:" + sessionID }
+func oidcSidKey(providerID, sid string) string {
+	return "oidc:sid:" + providerID + ":" + sid
+}
+func oidcSubKey(providerID, sub string) string {
+	return "oidc:sub:" + providerID + ":" + sub
+}
+
+// resolveOidcMapping 按角色/组织声明匹配映射；多个角色映射命中时取最高权限并合并角色，组织按配置顺序取首个命中
+func resolveOidcMapping(p *model.OidcProvider, claims map[string]interface{}) oidcMapping {
+	m := oidcMapping{Authority: p.DefaultAuthority}
+	if m.Authority == "" {
+		m.Authority = oidcDefaultAuthUser
+	}
+	if p.RoleClaim != nil && p.RoleMappings != nil {
+		values := make(map[string]bool)
+		for _, v := range oidcClaimStrings(claims, *p.RoleClaim) {
+			values[v] = true
+		}
+		var mappings []model.OidcRoleMapping
+		_ = json.Unmarshal([]byte(*p.RoleMappings), &mappings)
+		authority := ""
+		seen := make(map[string]bool)
+		for _, rm := range mappings {
+			if !values[rm.Value] {
+				continue
+			}
+			m.RoleMatched = true
+			if rm.Authority == "TENANT_ADMIN" || (rm.Authority == "TENANT_USER" && authority == "") {
+				authority = rm.Authority
+			}
+			for _, id := range rm.RoleIDs {
+				if !seen[id] {
+					seen[id] = true
+					m.RoleIDs = append(m.RoleIDs, id)
+				}
+			}
+		}
+		if authority != "" {
+			m.Authority = authority
+			m.AuthorityMatched = true
+		}
+	}
+	if p.OrgClaim != nil && p.OrgMappings != nil {
+		values := make(map[string]bool)
+		for _, v := range oidcClaimStrings(claims, *p.OrgClaim) {
+			values[v] = true
+		}
+		var mappings []model.OidcOrgMapping
+		_ = json.Unmarshal([]byte(*p.OrgMappings), &mappings)
+		for _, om := range mappings {
+			if values[om.Value] {
+				m.OrgID = om.OrgID
+				m.OrgMatched = true
+				break
+			}
+		}
+	}
+	if m.OrgID == "" && p.DefaultOrgID != nil {
+		m.OrgID = *p.DefaultOrgID
+	}
+	return m
+}
+
+// oidcSafeRedirect 只允许控制台内的相对路径，防止开放重定向
+func oidcSafeRedirect(redirect string) string {
+	if !strings.HasPrefix(redirect, "/") || strings.HasPrefix(redirect, "//") || strings.HasPrefix(redirect, "/\\") {
+		return ""
+	}
+	return redirect
+}
+
+// oidcFrontendRedirect 回调完成后跳转控制台，携带一次性票据或错误
+func oidcFrontendRedirect(frontendURL string, params map[string]string) string {
+	u, err := url.Parse(frontendURL)
+	if err != nil {
+		return frontendURL
+	}
+	q := u.Query()
+	for k, v := range params {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// oidcRedirectURI 回调地址，未配置时按请求地址生成
+func oidcRedirectURI(p *model.OidcProvider, baseURL string) string {
+	if p.RedirectURI != nil && *p.RedirectURI != "" {
+		return *p.RedirectURI
+	}
+	return strings.TrimRight(baseURL, "/") + "/api/v1/sso/oidc/" + p.ID + "/callback"
+}
+
+func maskOidcClientSecret(p *model.OidcProvider) {
+	if p.ClientSecret != nil && *p.ClientSecret != "" {
+		masked := "****"
+		if r := []rune(*p.ClientSecret); len(r) > 4 {
+			masked += string(r[len(r)-4:])
+		}
+		p.ClientSecret = &masked
+	}
+}
+
+func oidcRequireTenantAdmin(claims *utils.UserClaims) error {
+	if claims.Authority != "TENANT_ADMIN" {
+		return errcode.WithVars(errcode.CodeNoPermission, map[string]interface{}{
+			"required_role": "TENANT_ADMIN",
+			"current_role":  claims.Authority,
+		})
+	}
+	return nil
+}
+
+// validateOidcMappings 映射的组织与角色必须属于本租户
+func validateOidcMappings(ctx context.Context, tenantID string, roles []model.OidcRoleMapping, orgs []model.OidcOrgMapping, defaultOrgID *string) error {
+	orgIDs := make([]string, 0, len(orgs)+1)
+	for _, om := range orgs {
+		orgIDs = append(orgIDs, om.OrgID)
+	}
+	if defaultOrgID != nil && *defaultOrgID != "" {
+		orgIDs = append(orgIDs, *defaultOrgID)
+	}
+	if err := oidcCheckTenantIDs(ctx, &model.Org{}, tenantID, orgIDs, "org not found in tenant"); err != nil {
+		return err
+	}
+	roleIDs := make([]string, 0)
+	for _, rm := range roles {
+		roleIDs = append(roleIDs, rm.RoleIDs...)
+	}
+	return oidcCheckTenantIDs(ctx, &model.Role{}, tenantID, roleIDs, "role not found in tenant")
+}
+
+func oidcCheckTenantIDs(ctx context.Context, table interface{}, tenantID string, ids []string, message string) error {
+	set := make(map[string]bool)
+	for _, id := range ids {
+		set[id] = true
+	}
+	if len(set) == 0 {
+		return nil
+	}
+	unique := make([]string, 0, len(set))
+	for id := range set {
+		unique = append(unique, id)
+	}
+	var count int64
+	if err := global.DB.WithContext(ctx).Model(table).Where("tenant_id = ? AND id IN ?", tenantID, unique).Count(&count).Error; err != nil {
+		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	if count != int64(len(unique)) {
+		return errcode.WithData(errcode.CodeParamError, map[string]interface{}{"message": message})
+	}
+	return nil
+}
+
+func oidcJSON(v interface{}) *string {
+	b, _ := json.Marshal(v)
+	s := string(b)
+	return &s
+}
+
+// CreateOidcProvider 新建身份提供方
+func (*Oidc) CreateOidcProvider(ctx context.Context, req *model.CreateOidcProviderReq, claims *utils.UserClaims) (*model.OidcProvider, error) {
+	if err := oidcRequireTenantAdmin(claims); err != nil {
+		return nil, err
+	}
+	if err := validateOidcMappings(ctx, claims.TenantID, req.RoleMappings, req.OrgMappings, req.DefaultOrgID); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	p := &model.OidcProvider{
+		ID:                    uuid.New(),
+		TenantID:              claims.TenantID,
+		Name:                  req.Name,
+		Issuer:                strings.TrimSpace(req.Issuer),
+		ClientID:              req.ClientID,
+		ClientSecret:          emptyToNil(req.ClientSecret),
+		Scopes:                oidcDefaultScopes,
+		RedirectURI:           emptyToNil(req.RedirectURI),
+		FrontendURL:           req.FrontendURL,
+		PostLogoutRedirectURI: emptyToNil(req.PostLogoutRedirectURI),
+		EmailClaim:            "email",
+		NameClaim:             "name",
+		RoleClaim:             emptyToNil(req.RoleClaim),
+		OrgClaim:              emptyToNil(req.OrgClaim),
+		DefaultAuthority:      oidcDefaultAuthUser,
+		DefaultOrgID:          emptyToNil(req.DefaultOrgID),
+		AutoProvision:         req.AutoProvision == nil || *req.AutoProvision,
+		SyncLinkedUsers:       req.SyncLinkedUsers != nil && *req.SyncLinkedUsers,
+		Enabled:               req.Enabled == nil || *req.Enabled,
+		Remark:                req.Remark,
+		CreatedAt:             now,
+		UpdatedAt:             now,
+	}
+	if req.Scopes != nil && strings.TrimSpace(*req.Scopes) != "" {
+		p.Scopes = strings.TrimSpace(*req.Scopes)
+	}
+	if req.EmailClaim != nil && *req.EmailClaim != "" {
+		p.EmailClaim = *req.EmailClaim
+	}
+	if req.NameClaim != nil && *req.NameClaim != "" {
+		p.NameClaim = *req.NameClaim
+	}
+	if req.DefaultAuthority != nil {
+		p.DefaultAuthority = *req.DefaultAuthority
+	}
+	if len(req.RoleMappings) > 0 {
+		p.RoleMappings = oidcJSON(req.RoleMappings)
+	}
+	if len(req.OrgMappings) > 0 {
+		p.OrgMappings = oidcJSON(req.OrgMappings)
+	}
+	if err := oidcCheckScopes(p.Scopes); err != nil {
+		return nil, err
+	}
+	if err := global.DB.WithContext(ctx).Create(p).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	maskOidcClientSecret(p)
+	return p, nil
+}
+
+func oidcCheckScopes(scopes string) error {
+	for _, s := range strings.Fields(scopes) {
+		if s == "openid" {
+			return nil
+		}
+	}
+	return errcode.WithData(errcode.CodeParamError, map[string]interface{}{"message": "scopes must include openid"})
+}
+
+func getOidcProvider(ctx context.Context, id, tenantID string) (*model.OidcProvider, error) {
+	var p model.OidcProvider
+	db := global.DB.WithContext(ctx).Where("id = ?", id)
+	if tenantID != "" {
+		db = db.Where("tenant_id = ?", tenantID)
+	}
+	err := db.First(&p).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, errcode.New(errcode.CodeNotFound)
+	}
+	if err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return &p, nil
+}
+
+// UpdateOidcProvider 修改身份提供方
+func (*Oidc) UpdateOidcProvider(ctx context.Context, id string, req *model.UpdateOidcProviderReq, claims *utils.UserClaims) (*model.OidcProvider, error) {
+	if err := oidcRequireTenantAdmin(claims); err != nil {
+		return nil, err
+	}
+	p, err := getOidcProvider(ctx, id, claims.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	var roles []model.OidcRoleMapping
+	var orgs []model.OidcOrgMapping
+	if req.RoleMappings != nil {
+		roles = *req.RoleMappings
+	}
+	if req.OrgMappings != nil {
+		orgs = *req.OrgMappings
+	}
+	if err := validateOidcMappings(ctx, claims.TenantID, roles, orgs, req.DefaultOrgID); err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{"updated_at": time.Now().UTC()}
+	setString := func(column string, v *string) {
+		if v != nil {
+			updates[column] = strings.TrimSpace(*v)
+		}
+	}
+	setNullable := func(column string, v *string) {
+		if v != nil {
+			updates[column] = emptyToNil(v)
+		}
+	}
+	setString("name", req.Name)
+	setString("issuer", req.Issuer)
+	setString("client_id", req.ClientID)
+	setString("frontend_url", req.FrontendURL)
+	setString("default_authority", req.DefaultAuthority)
+	setNullable("client_secret", req.ClientSecret)
+	setNullable("redirect_uri", req.RedirectURI)
+	setNullable("post_logout_redirect_uri", req.PostLogoutRedirectURI)
+	setNullable("role_claim", req.RoleClaim)
+	setNullable("org_claim", req.OrgClaim)
+	setNullable("default_org_id", req.DefaultOrgID)
+	if req.Scopes != nil {
+		if err := oidcCheckScopes(*req.Scopes); err != nil {
+			return nil, err
+		}
+		updates["scopes"] = strings.TrimSpace(*req.Scopes)
+	}
+	if req.EmailClaim != nil && *req.EmailClaim != "" {
+		updates["email_claim"] = *req.EmailClaim
+	}
+	if req.NameClaim != nil && *req.NameClaim != "" {
+		updates["name_claim"] = *req.NameClaim
+	}
+	if req.RoleMappings != nil {
+		updates["role_mappings"] = oidcJSON(roles)
+	}
+	if req.OrgMappings != nil {
+		updates["org_mappings"] = oidcJSON(orgs)
+	}
+	if req.AutoProvision != nil {
+		updates["auto_provision"] = *req.AutoProvision
+	}
+	if req.SyncLinkedUsers != nil {
+		updates["sync_linked_users"] = *req.SyncLinkedUsers
+	}
+	if req.Enabled != nil {
+		updates["enabled"] = *req.Enabled
+	}
+	if req.Remark != nil {
+		updates["remark"] = *req.Remark
+	}
+	if err := global.DB.WithContext(ctx).Model(p).Updates(updates).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return getOidcProviderMasked(ctx, id, claims.TenantID)
+}
+
+func getOidcProviderMasked(ctx context.Context, id, tenantID string) (*model.OidcProvider, error) {
+	p, err := getOidcProvider(ctx, id, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	maskOidcClientSecret(p)
+	return p, nil
+}
+
+// DeleteOidcProvider 删除身份提供方，已绑定的外部身份一并删除，用户账号保留
+func (*Oidc) DeleteOidcProvider(ctx context.Context, id string, claims *utils.UserClaims) error {
+	if err := oidcRequireTenantAdmin(claims); err != nil {
+		return err
+	}
+	res := global.DB.WithContext(ctx).Where("id = ? AND tenant_id = ?", id, claims.TenantID).Delete(&model.OidcProvider{})
+	if res.Error != nil {
+		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": res.Error.Error()})
+	}
+	if res.RowsAffected == 0 {
+		return errcode.New(errcode.CodeNotFound)
+	}
+	return nil
+}
+
+// GetOidcProviderListByPage 身份提供方列表
+func (*Oidc) GetOidcProviderListByPage(ctx context.Context, req *model.GetOidcProviderListByPageReq, claims *utils.UserClaims) (map[string]interface{}, error) {
+	db := global.DB.WithContext(ctx).Model(&model.OidcProvider{}).Where("tenant_id = ?", claims.TenantID)
+	if req.Enabled != nil {
+		db = db.Where("enabled = ?", *req.Enabled)
+	}
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	list := make([]model.OidcProvider, 0)
+	if err := db.Order("created_at DESC").
+		Offset((req.Page - 1) * req.PageSize).
+		Limit(req.PageSize).
+		Find(&list).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	for i := range list {
+		maskOidcClientSecret(&list[i])
+	}
+	return map[string]interface{}{"total": total, "list": list}, nil
+}
+
+// TestOidcProvider 读取发现文档与签名公钥，检查颁发者配置是否可用
+func (*Oidc) TestOidcProvider(ctx context.Context, id string, claims *utils.UserClaims) (map[string]interface{}, error) {
+	p, err := getOidcProvider(ctx, id, claims.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	disc, err := fetchOidcDiscovery(ctx, p.Issuer)
+	if err != nil {
+		return nil, errcode.WithData(errcode.CodeParamError, map[string]interface{}{"message": "discovery failed: " + err.Error()})
+	}
+	keys, err := fetchOidcJWKS(ctx, disc.JwksURI, true)
+	if err != nil {
+		return nil, errcode.WithData(errcode.CodeParamError, map[string]interface{}{"message": "jwks failed: " + err.Error()})
+	}
+	return map[string]interface{}{
+		"issuer":                 disc.Issuer,
+		"authorization_endpoint": disc.AuthorizationEndpoint,
+		"token_endpoint":         disc.TokenEndpoint,
+		"userinfo_endpoint":      disc.UserinfoEndpoint,
+		"end_session_endpoint":   disc.EndSessionEndpoint,
+		"signing_keys":           len(keys),
+	}, nil
+}
+
+// GetLoginOidcProviders 登录页可用的身份提供方
+func (*Oidc) GetLoginOidcProviders(ctx context.Context, tenantID string) ([]model.OidcLoginProviderRsp, error) {
+	list := make([]model.OidcLoginProviderRsp, 0)
+	if err := global.DB.WithContext(ctx).Model(&model.OidcProvider{}).
+		Select("id, name").
+		Where("tenant_id = ? AND enabled = ?", tenantID, true).
+		Order("created_at").
+		Scan(&list).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return list, nil
+}
+
+func getEnabledOidcProvider(ctx context.Context, id string) (*model.OidcProvider, error) {
+	p, err := getOidcProvider(ctx, id, "")
+	if err != nil {
+		return nil, err
+	}
+	if !p.Enabled {
+		return nil, errcode.New(errcode.CodeNotFound)
+	}
+	return p, nil
+}
+
+// OidcAuthorize 发起授权码 + PKCE 登录，返回身份提供方登录地址
+func (*Oidc) OidcAuthorize(ctx context.Context, id string, req *model.OidcAuthorizeReq, baseURL string) (string, error) {
+	p, err := getEnabledOidcProvider(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	disc, err := fetchOidcDiscovery(ctx, p.Issuer)
+	if err != nil {
+		logrus.Errorf("OIDC发现文档读取失败(%s): %v", p.Issuer, err)
+		return "", errcode.WithData(errcode.CodeSystemError, map[string]interface{}{"error": "identity provider unavailable"})
+	}
+
+	state, err1 := oidcRandomString(32)
+	nonce, err2 := oidcRandomString(32)
+	verifier, err3 := oidcRandomString(48)
+	if err := errors.Join(err1, err2, err3); err != nil {
+		return "", errcode.WithData(errcode.CodeSystemError, map[string]interface{}{"error": err.Error()})
+	}
+	st := oidcLoginState{
+		ProviderID:  p.ID,
+		Verifier:    verifier,
+		Nonce:       nonce,
+		RedirectURI: oidcRedirectURI(p, baseURL),
+	}
+	if req.Redirect != nil {
+		st.Redirect = oidcSafeRedirect(*req.Redirect)
+	}
+	data, _ := json.Marshal(st)
+	if err := global.REDIS.Set(ctx, oidcStateKey(state), data, oidcStateTTL).Err(); err != nil {
+		return "", errcode.WithData(errcode.CodeSystemError, map[string]interface{}{"error": err.Error()})
+	}
+	return buildOidcAuthorizeURL(disc, p.ClientID, p.Scopes, st.RedirectURI, state, nonce, verifier), nil
+}
+
+// OidcCallback 处理身份提供方回调：校验 state、换取并校验令牌、映射并同步用户、签发登录令牌。
+// 返回跳转控制台的地址，成功时携带一次性票据，失败时携带错误码；身份提供方不存在时返回错误
+func (*Oidc) OidcCallback(ctx context.Context, id string, req *model.OidcCallbackReq, meta *model.RequestMeta) (string, error) {
+	p, err := getEnabledOidcProvider(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	fail := func(code string, err error) (string, error) {
+		if err != nil {
+			logrus.Warnf("OIDC登录失败(provider=%s, %s): %v", p.ID, code, err)
+		}
+		return oidcFrontendRedirect(p.FrontendURL, map[string]string{"error": code}), nil
+	}
+	if req.Error != "" {
+		return fail("access_denied", errors.New(req.Error+": "+req.ErrorDescription))
+	}
+	if req.State == "" || req.Code == "" {
+		return fail("invalid_request", nil)
+	}
+
+	raw, err := global.REDIS.GetDel(ctx, oidcStateKey(req.State)).Result()
+	if err != nil {
+		return fail("invalid_state", err)
+	}
+	var st oidcLoginState
+	if err := json.Unmarshal([]byte(raw), &st); err != nil || st.ProviderID != p.ID {
+		return fail("invalid_state", err)
+	}
+
+	disc, err := fetchOidcDiscovery(ctx, p.Issuer)
+	if err != nil {
+		return fail("provider_unavailable", err)
+	}
+	clientSecret := ""
+	if p.ClientSecret != nil {
+		clientSecret = *p.ClientSecret
+	}
+	tok, err := exchangeOidcCode(ctx, disc, p.ClientID, clientSecret, req.Code, st.RedirectURI, st.Verifier)
+	if err != nil {
+		return fail("token_exchange_failed", err)
+	}
+	claims, err := verifyOidcIDToken(ctx, disc, p.ClientID, st.Nonce, tok.IDToken, time.Now())
+	if err != nil {
+		return fail("invalid_id_token", err)
+	}
+	sub, _ := claims["sub"].(string)
+	merged := make(map[string]interface{}, len(claims))
+	if info, err := fetchOidcUserinfo(ctx, disc, tok.AccessToken, sub); err != nil {
+		logrus.Warnf("OIDC用户信息读取失败(provider=%s): %v", p.ID, err)
+	} else {
+		for k, v := range info {
+			merged[k] = v
+		}
+	}
+	// ID Token 中的声明优先
+	for k, v := range claims {
+		merged[k] = v
+	}
+
+	user, err := syncOidcUser(ctx, p, sub, merged)
+	if err != nil {
+		var e *errcode.Error
+		if errors.As(err, &e) && e.Code == errcode.CodeUserDisabled {
+			return fail("user_disabled", err)
+		}
+		return fail("user_not_allowed", err)
+	}
+
+	sid, _ := claims["sid"].(string)
+	sso := oidcSession{
+		ProviderID: p.ID,
+		IDToken:    tok.IDToken,
+		Sub:        sub,
+		Sid:        sid,
+	}
+	// 与密码登录一致，已启用或租户强制的双因素认证需完成二次验证：票据换取到的是临时令牌，验证通过后再签发并登记单点登录会话
+	loginRsp, err := loginChallenge(ctx, user, nil, meta, &sso)
+	if err != nil {
+		return fail("login_failed", err)
+	}
+	if loginRsp == nil {
+		var session *model.UserSession
+		session, loginRsp, err = newSession(ctx, user, meta, true)
+		if err != nil {
+			return fail("login_failed", err)
+		}
+		_ = dal.UserQuery{}.UpdateLastVisitTime(ctx, user.ID)
+		saveOidcSession(ctx, session.ID, time.Duration(loginRsp.RefreshExpiresIn)*time.Second, sso)
+	}
+
+	ticket, err := oidcRandomString(32)
+	if err != nil {
+		return fail("login_failed", err)
+	}
+	data, _ := json.Marshal(loginRsp)
+	if err := global.REDIS.Set(ctx, oidcTicketKey(ticket), data, oidcTicketTTL).Err(); err != nil {
+		return fail("login_failed", err)
+	}
+	return oidcFrontendRedirect(p.FrontendURL, map[string]string{"ticket": ticket, "redirect": st.Redirect}), nil
+}
+
+// syncOidcUser 查找或创建外部身份对应的用户，并按映射同步权限类型、组织与角色
+func syncOidcUser(ctx context.Context, p *model.OidcProvider, sub string, claims map[string]interface{}) (*model.User, error) {
+	email := strings.ToLower(oidcClaimString(claims, p.EmailClaim))
+	name := oidcClaimString(claims, p.NameClaim)
+	mapping := resolveOidcMapping(p, claims)
+	now := time.Now().UTC()
+
+	var user model.User
+	var identity model.UserIdentity
+	err := global.DB.WithContext(ctx).Where("provider_id = ? AND subject = ?", p.ID, sub).First(&identity).Error
+	switch {
+	case err == nil:
+		if err := global.DB.WithContext(ctx).Where("id = ?", identity.UserID).First(&user).Error; err != nil {
+			return nil, err
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		// 首次登录：按邮箱关联已有账号或自动创建。关联已有账号要求身份提供方明确声明邮箱已验证，防止冒用
+		if email == "" {
+			return nil, errors.New("id token has no email claim")
+		}
+		provisioned := false
+		err = global.DB.WithContext(ctx).Where("LOWER(email) = ?", email).First(&user).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if !p.AutoProvision {
+				return nil, errors.New("user is not provisioned and auto provisioning is disabled")
+			}
+			user, err = provisionOidcUser(ctx, p, email, name, mapping, now)
+			provisioned = true
+		case err == nil && !oidcEmailVerified(claims):
+			return nil, errors.New("email is not verified by identity provider")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if user.TenantID == nil || *user.TenantID != p.TenantID || (user.Authority != nil && *user.Authority == "SYS_ADMIN") {
+			return nil, errors.New("email belongs to an account outside this tenant")
+		}
+		identity = model.UserIdentity{
+			ID:          uuid.New(),
+			UserID:      user.ID,
+			ProviderID:  p.ID,
+			TenantID:    p.TenantID,
+			Subject:     sub,
+			Provisioned: provisioned,
+			CreatedAt:   now,
+		}
+		if err := global.DB.WithContext(ctx).Create(&identity).Error; err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	if user.Status == nil || *user.Status != "N" {
+		return nil, errcode.New(errcode.CodeUserDisabled)
+	}
+
+	// 每次登录按身份提供方的声明同步：仅同步本身份提供方自动创建的账号，按邮箱关联的已有账号需显式开启
+	if updates := oidcSyncUpdates(p, &identity, mapping, now); updates != nil {
+		if err := global.DB.WithContext(ctx).Model(&model.User{}).Where("id = ?", user.ID).Updates(updates).Error; err != nil {
+			return nil, err
+		}
+		if mapping.AuthorityMatched {
+			user.Authority = &mapping.Authority
+		}
+		if mapping.OrgMatched {
+			user.OrgID = &mapping.OrgID
+		}
+		if mapping.RoleMatched && len(mapping.RoleIDs) > 0 {
+			GroupApp.Casbin.RemoveUserAndRole(user.ID)
+			GroupApp.Casbin.AddRolesToUser(user.ID, mapping.RoleIDs)
+		}
+	}
+	identityUpdates := map[string]interface{}{"last_login_at": now}
+	if email != "" {
+		identityUpdates["email"] = email
+	}
+	global.DB.WithContext(ctx).Model(&model.UserIdentity{}).Where("id = ?", identity.ID).Updates(identityUpdates)
+	return &user, nil
+}
+
+// oidcEmailVerified 身份提供方是否明确声明邮箱已验证，缺失按未验证处理
+func oidcEmailVerified(claims map[string]interface{}) bool {
+	verified, _ := claims["email_verified"].(bool)
+	return verified
+}
+
+// oidcSyncUpdates 登录时需同步到用户的字段；权限类型与组织仅在映射命中时覆盖，不同步的账号返回 nil
+func oidcSyncUpdates(p *model.OidcProvider, identity *model.UserIdentity, mapping oidcMapping, now time.Time) map[string]interface{} {
+	if !identity.Provisioned && !p.SyncLinkedUsers {
+		return nil
+	}
+	updates := map[string]interface{}{"updated_at": now}
+	if mapping.AuthorityMatched {
+		updates["authority"] = mapping.Authority
+	}
+	if mapping.OrgMatched {
+		updates["org_id"] = mapping.OrgID
+		updates["user_kind"] = model.UserKindOrgUser
+	}
+	return updates
+}
+
+// provisionOidcUser 自动创建用户，密码随机，只能通过单点登录或重置密码后登录
+func provisionOidcUser(ctx context.Context, p *model.OidcProvider, email, name string, mapping oidcMapping, now time.Time) (model.User, error) {
+	password, err := oidcRandomString(32)
+	if err != nil {
+		return model.User{}, err
+	}
+	if name == "" {
+		name = email
+	}
+	user := model.User{
+		ID:                  uuid.New(),
+		Name:                &name,
+		Email:               email,
+		Status:              StringPtr("N"),
+		Authority:           StringPtr(mapping.Authority),
+		Password:            utils.BcryptHash(password),
+		TenantID:            StringPtr(p.TenantID),
+		UserKind:            StringPtr(model.UserKindOrgUser),
+		Remark:              StringPtr("OIDC: " + p.Name),
+		AdditionalInfo:      StringPtr("{}"),
+		CreatedAt:           &now,
+		UpdatedAt:           &now,
+		PasswordLastUpdated: &now,
+	}
+	if mapping.OrgID != "" {
+		user.OrgID = StringPtr(mapping.OrgID)
+	}
+	if err := global.DB.WithContext(ctx).Create(&user).Error; err != nil {
+		return model.User{}, err
+	}
+	logrus.Infof("OIDC自动创建用户: tenant=%s provider=%s user=%s", p.TenantID, p.ID, user.ID)
+	return user, nil
+}
+
+// saveOidcSession 记录单点登录会话，生命周期与登录会话一致
+func saveOidcSession(ctx context.Context, sessionID string, ttl time.Duration, s oidcSession) {
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	data, _ := json.Marshal(s)
+	pipe := global.REDIS.Pipeline()
+	pipe.Set(ctx, oidcSessionKey(sessionID), data, ttl)
+	pipe.SAdd(ctx, oidcSubKey(s.ProviderID, s.Sub), sessionID)
+	pipe.Expire(ctx, oidcSubKey(s.ProviderID, s.Sub), ttl)
+	if s.Sid != "" {
+		pipe.SAdd(ctx, oidcSidKey(s.ProviderID, s.Sid), sessionID)
+		pipe.Expire(ctx, oidcSidKey(s.ProviderID, s.Sid), ttl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		logrus.Warnf("保存OIDC会话失败: %v", err)
+	}
+}
+
+// ExchangeOidcTicket 控制台用一次性票据换取登录令牌；需要二次验证时换取到的是临时令牌
+func (*Oidc) ExchangeOidcTicket(ctx context.Context, ticket string) (*model.LoginRsp, error) {
+	raw, err := global.REDIS.GetDel(ctx, oidcTicketKey(ticket)).Result()
+	if err != nil {
+		return nil, errcode.New(errcode.CodeInvalidAuth)
+	}
+	var rsp model.LoginRsp
+	if err := json.Unmarshal([]byte(raw), &rsp); err != nil {
+		return nil, errcode.New(errcode.CodeInvalidAuth)
+	}
+	return &rsp, nil
+}
+
+// OidcLogoutURL 退出登录：会话来自单点登录时清理登记，并返回通知身份提供方退出的地址
+func (*Oidc) OidcLogoutURL(ctx context.Context, sessionID string) string {
+	raw, err := global.REDIS.GetDel(ctx, oidcSessionKey(sessionID)).Result()
+	if err != nil {
+		return ""
+	}
+	var s oidcSession
+	if err := json.Unmarshal([]byte(raw), &s); err != nil {
+		return ""
+	}
+	global.REDIS.SRem(ctx, oidcSubKey(s.ProviderID, s.Sub), sessionID)
+	if s.Sid != "" {
+		global.REDIS.SRem(ctx, oidcSidKey(s.ProviderID, s.Sid), sessionID)
+	}
+	p, err := getOidcProvider(ctx, s.ProviderID, "")
+	if err != nil {
+		return ""
+	}
+	disc, err := fetchOidcDiscovery(ctx, p.Issuer)
+	if err != nil {
+		logrus.Warnf("OIDC发现文档读取失败(%s): %v", p.Issuer, err)
+		return ""
+	}
+	postLogout := ""
+	if p.PostLogoutRedirectURI != nil {
+		postLogout = *p.PostLogoutRedirectURI
+	}
+	return buildOidcEndSessionURL(disc, p.ClientID, s.IDToken, postLogout)
+}
+
+// OidcBackchannelLogout 身份提供方通知退出：按 sid 或 sub 撤销对应的登录会话
+func (*Oidc) OidcBackchannelLogout(ctx context.Context, id, logoutToken string) error {
+	p, err := getEnabledOidcProvider(ctx, id)
+	if err != nil {
+		return err
+	}
+	disc, err := fetchOidcDiscovery(ctx, p.Issuer)
+	if err != nil {
+		return errcode.WithData(errcode.CodeSystemError, map[string]interface{}{"error": "identity provider unavailable"})
+	}
+	sub, sid, err := verifyOidcLogoutToken(ctx, disc, p.ClientID, logoutToken, time.Now())
+	if err != nil {
+		return errcode.WithData(errcode.CodeParamError, map[string]interface{}{"message": err.Error()})
+	}
+	setKey := oidcSubKey(p.ID, sub)
+	if sid != "" {
+		setKey = oidcSidKey(p.ID, sid)
+	}
+	sessionIDs, err := global.REDIS.SMembers(ctx, setKey).Result()
+	if err != nil {
+		return errcode.WithData(errcode.CodeSystemError, map[string]interface{}{"error": err.Error()})
+	}
+	if _, err := revokeSessionIDs(ctx, sessionIDs, SessionRevokeBackchannel); err != nil {
+		return err
+	}
+	// 旧版登记的是令牌本身，一并删除
+	keys := []string{setKey}
+	for _, id := range sessionIDs {
+		keys = append(keys, id, oidcSessionKey(id))
+	}
+	if err := global.REDIS.Del(ctx, keys...).Err(); err != nil {
+		return errcode.WithData(errcode.CodeSystemError, map[string]interface{}{"error": err.Error()})
+	}
+	logrus.Infof("OIDC后端通道退出: provider=%s sub=%s sid=%s sessions=%d", p.ID, sub, sid, len(sessionIDs))
+	return nil
+}
@@ -2,6 +2,13 @@
 package service
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-basic/uuid"
@@ -10,7 +17,11 @@ import (
 	"project/internal/dal"
 	"project/internal/model"
 	"project/pkg/errcode"
+	"project/pkg/global"
 	"project/pkg/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type OpenAPIKey struct{}
@@ -33,6 +44,23 @@ func (o *OpenAPIKey) CreateOpenAPIKey(req *model.CreateOpenAPIKeyReq, claims *ut
 		})
 	}
 
+	scopes, err := normalizeAPIKeyScopes(req.Scopes)
+	if err != nil {
+		return err
+	}
+	ipAllowlist, err := normalizeAPIKeyIPAllowlist(req.IPAllowlist)
+	if err != nil {
+		return err
+	}
+	if err := validateAPIKeyRestriction(req.TenantID, req.OrgID, req.DeviceGroupID); err != nil {
+		return err
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return errcode.WithData(errcode.CodeParamError, map[string]interface{}{
+			"message": "expires_at must be in the future",
+		})
+	}
+
 	// 生成APIKey
 	apikey, err := utils.GenerateAPIKey()
 	if err != nil {
@@ -43,12 +71,18 @@ func (o *OpenAPIKey) CreateOpenAPIKey(req *model.CreateOpenAPIKeyReq, claims *ut
 	status := int16(1) // 默认启用
 	// 创建OpenAPI密钥记录
 	key := &model.OpenAPIKey{
-		ID:        uuid.New(),
-		TenantID:  req.TenantID,
-		APIKey:    apikey,
-		Status:    &status,
-		Name:      req.Name,
-		CreatedID: &claims.ID,
+		ID:            uuid.New(),
+		TenantID:      req.TenantID,
+		APIKey:        apikey,
+		Status:        &status,
+		Name:          req.Name,
+		CreatedID:     &claims.ID,
+		Scopes:        scopes,
+		OrgID:         emptyToNil(req.OrgID),
+		DeviceGroupID: emptyToNil(req.DeviceGroupID),
+		IPAllowlist:   ipAllowlist,
+		ExpiresAt:     req.ExpiresAt,
+		RateLimit:     apiKeyRateLimit(req.RateLimit),
 	}
 
 	t := time.Now().UTC()
@@ -118,6 +152,39 @@ func (o *OpenAPIKey) UpdateOpenAPIKey(req *model.UpdateOpenAPIKeyReq, claims *ut
 	if req.Name != nil {
 		updates["name"] = *req.Name
 	}
+	if req.Scopes != nil {
+		scopes, err := normalizeAPIKeyScopes(*req.Scopes)
+		if err != nil {
+			return err
+		}
+		updates["scopes"] = scopes
+	}
+	if req.IPAllowlist != nil {
+		ipAllowlist, err := normalizeAPIKeyIPAllowlist(*req.IPAllowlist)
+		if err != nil {
+			return err
+		}
+		updates["ip_allowlist"] = ipAllowlist
+	}
+	if req.OrgID != nil || req.DeviceGroupID != nil {
+		if err := validateAPIKeyRestriction(key.TenantID, req.OrgID, req.DeviceGroupID); err != nil {
+			return err
+		}
+		if req.OrgID != nil {
+			updates["org_id"] = emptyToNil(req.OrgID)
+		}
+		if req.DeviceGroupID != nil {
+			updates["device_group_id"] = emptyToNil(req.DeviceGroupID)
+		}
+	}
+	if req.ClearExpires {
+		updates["expires_at"] = nil
+	} else if req.ExpiresAt != nil {
+		updates["expires_at"] = *req.ExpiresAt
+	}
+	if req.RateLimit != nil {
+		updates["rate_limit"] = apiKeyRateLimit(req.RateLimit)
+	}
 
 	// 执行更新
 	if err := dal.UpdateOpenAPIKey(req.ID, updates); err != nil {
@@ -127,6 +194,7 @@ func (o *OpenAPIKey) UpdateOpenAPIKey(req *model.UpdateOpenAPIKeyReq, claims *ut
 			"id":    req.ID,
 		})
 	}
+	invalidateOpenAPIKeyCache(key)
 
 	return nil
 }
@@ -164,3 +232,332 @@ func (o *OpenAPIKey) DeleteOpenAPIKey(id string, claims *utils.UserClaims) error
 
 	return nil
 }
+
+// RotateOpenAPIKey 轮换OpenAPI密钥，旧密钥在宽限期内仍可使用，返回新密钥
+func (o *OpenAPIKey) RotateOpenAPIKey(id string, req *model.RotateOpenAPIKeyReq, claims *utils.UserClaims) (map[string]interface{}, error) {
+	key, err := getOpenAPIKeyForAdmin(id, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	apikey, err := utils.GenerateAPIKey()
+	if err != nil {
+		logrus.Errorf("生成AppSecret失败: %v", err)
+		return nil, errcode.New(errcode.CodeSystemError)
+	}
+
+	now := time.Now().UTC()
+	updates := map[string]interface{}{
+		"api_key":    apikey,
+		"rotated_at": now,
+	}
+	var graceUntil *time.Time
+	if req.GraceHours > 0 {
+		t := now.Add(time.Duration(req.GraceHours) * time.Hour)
+		graceUntil = &t
+		updates["previous_api_key"] = key.APIKey
+		updates["previous_key_expires_at"] = t
+	} else {
+		updates["previous_api_key"] = nil
+		updates["previous_key_expires_at"] = nil
+	}
+
+	if err := dal.UpdateOpenAPIKey(id, updates); err != nil {
+		logrus.Errorf("轮换OpenAPI密钥失败: %v", err)
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{
+			"error": err.Error(),
+			"id":    id,
+		})
+	}
+	invalidateOpenAPIKeyCache(key)
+
+	return map[string]interface{}{
+		"id":                      id,
+		"api_key":                 apikey,
+		"previous_key_expires_at": graceUntil,
+		"rotated_at":              now,
+	}, nil
+}
+
+// GetOpenAPIKeyUsage 查询OpenAPI密钥按日调用统计，默认最近30天
+func (o *OpenAPIKey) GetOpenAPIKeyUsage(id string, req *model.OpenAPIKeyUsageReq, claims *utils.UserClaims) (map[string]interface{}, error) {
+	key, err := getOpenAPIKeyForAdmin(id, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	end := time.Now().UTC()
+	if req.EndDate != "" {
+		end, _ = time.Parse("2006-01-02", req.EndDate)
+	}
+	start := end.AddDate(0, 0, -29)
+	if req.StartDate != "" {
+		start, _ = time.Parse("2006-01-02", req.StartDate)
+	}
+	if start.After(end) {
+		return nil, errcode.WithData(errcode.CodeParamError, map[string]interface{}{
+			"message": "start_date must not be after end_date",
+		})
+	}
+
+	list := make([]model.OpenAPIKeyUsage, 0)
+	if err := global.DB.Where("key_id = ? AND usage_date BETWEEN ? AND ?", id, start.Format("2006-01-02"), end.Format("2006-01-02")).
+		Order("usage_date").Find(&list).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{
+			"sql_error": err.Error(),
+		})
+	}
+
+	var requests, rejected int64
+	for _, u := range list {
+		requests += u.RequestCount
+		rejected += u.RejectedCount
+	}
+	return map[string]interface{}{
+		"list":           list,
+		"request_count":  requests,
+		"rejected_count": rejected,
+		"total_requests": key.RequestCount,
+		"last_used_at":   key.LastUsedAt,
+		"last_used_ip":   key.LastUsedIP,
+	}, nil
+}
+
+// FlushOpenAPIKeyUsageByCron 将Redis中累计的调用计量写入数据库
+func (o *OpenAPIKey) FlushOpenAPIKeyUsageByCron() {
+	ctx := context.Background()
+	for {
+		member, err := global.REDIS.SPop(ctx, dal.OpenAPIKeyUsageDirtySet).Result()
+		if err != nil {
+			// 集合为空
+			return
+		}
+		usage, err := takeOpenAPIKeyUsage(ctx, member)
+		if err != nil {
+			logrus.Warnf("读取OpenAPI密钥调用计量失败(%s): %v", member, err)
+			continue
+		}
+		if usage == nil {
+			continue
+		}
+		if err := saveOpenAPIKeyUsage(usage); err != nil {
+			logrus.Errorf("保存OpenAPI密钥调用计量失败(%s): %v", member, err)
+		}
+	}
+}
+
+// openAPIKeyUsageDelta 一个密钥一天内待落库的计量增量
+type openAPIKeyUsageDelta struct {
+	KeyID      string
+	TenantID   string
+	Date       time.Time
+	Total      int64
+	Rejected   int64
+	LastUsedAt *time.Time
+	LastUsedIP string
+}
+
+// parseOpenAPIKeyUsage 解析计量成员与计数
+func parseOpenAPIKeyUsage(member string, fields map[string]string) (*openAPIKeyUsageDelta, error) {
+	parts := strings.Split(member, "|")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid usage member: %s", member)
+	}
+	date, err := time.Parse("2006-01-02", parts[2])
+	if err != nil {
+		return nil, err
+	}
+	u := &openAPIKeyUsageDelta{KeyID: parts[0], TenantID: parts[1], Date: date, LastUsedIP: fields["last_used_ip"]}
+	u.Total, _ = strconv.ParseInt(fields["total"], 10, 64)
+	u.Rejected, _ = strconv.ParseInt(fields["rejected"], 10, 64)
+	if sec, err := strconv.ParseInt(fields["last_used_at"], 10, 64); err == nil && sec > 0 {
+		t := time.Unix(sec, 0).UTC()
+		u.LastUsedAt = &t
+	}
+	return u, nil
+}
+
+// takeOpenAPIKeyUsage 取出并清空一个计量计数
+func takeOpenAPIKeyUsage(ctx context.Context, member string) (*openAPIKeyUsageDelta, error) {
+	key := dal.OpenAPIKeyUsageKey(member)
+	pipe := global.REDIS.TxPipeline()
+	get := pipe.HGetAll(ctx, key)
+	pipe.Del(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	fields := get.Val()
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return parseOpenAPIKeyUsage(member, fields)
+}
+
+// saveOpenAPIKeyUsage 累加按日统计并更新密钥的总调用数与最近使用信息
+func saveOpenAPIKeyUsage(u *openAPIKeyUsageDelta) error {
+	now := time.Now().UTC()
+	row := model.OpenAPIKeyUsage{
+		KeyID:         u.KeyID,
+		UsageDate:     u.Date,
+		TenantID:      u.TenantID,
+		RequestCount:  u.Total,
+		RejectedCount: u.Rejected,
+		UpdatedAt:     now,
+	}
+	// 密钥已删除时外键约束失败，计量随之丢弃
+	if err := global.DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "key_id"}, {Name: "usage_date"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"request_count":  gorm.Expr("open_api_key_usage.request_count + ?", u.Total),
+			"rejected_count": gorm.Expr("open_api_key_usage.rejected_count + ?", u.Rejected),
+			"updated_at":     now,
+		}),
+	}).Create(&row).Error; err != nil {
+		return err
+	}
+
+	updates := map[string]interface{}{
+		"request_count": gorm.Expr("request_count + ?", u.Total),
+	}
+	if u.LastUsedAt != nil {
+		updates["last_used_at"] = gorm.Expr("GREATEST(COALESCE(last_used_at, ?), ?)", *u.LastUsedAt, *u.LastUsedAt)
+		if u.LastUsedIP != "" {
+			updates["last_used_ip"] = u.LastUsedIP
+		}
+	}
+	return global.DB.Model(&model.OpenAPIKey{}).Where("id = ?", u.KeyID).UpdateColumns(updates).Error
+}
+
+// getOpenAPIKeyForAdmin 获取密钥并校验当前用户可管理
+func getOpenAPIKeyForAdmin(id string, claims *utils.UserClaims) (*model.OpenAPIKey, error) {
+	key, err := dal.GetOpenAPIKeyByID(id)
+	if err != nil {
+		logrus.Errorf("获取OpenAPI密钥信息失败: %v", err)
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{
+			"error": err.Error(),
+			"id":    id,
+		})
+	}
+	if claims.Authority != "SYS_ADMIN" {
+		if claims.Authority != "TENANT_ADMIN" || key.TenantID != claims.TenantID {
+			return nil, errcode.WithVars(errcode.CodeNoPermission, map[string]interface{}{
+				"required_role": "SYS_ADMIN or TENANT_ADMIN",
+				"current_role":  claims.Authority,
+			})
+		}
+	}
+	return key, nil
+}
+
+// invalidateOpenAPIKeyCache 清理密钥（含轮换宽限期内的旧密钥）的鉴权缓存
+func invalidateOpenAPIKeyCache(key *model.OpenAPIKey) {
+	apiKeys := []string{key.APIKey}
+	if key.PreviousAPIKey != nil {
+		apiKeys = append(apiKeys, *key.PreviousAPIKey)
+	}
+	dal.DeleteOpenAPIKeyCache(context.Background(), apiKeys...)
+}
+
+// normalizeAPIKeyScopes 校验并规范化权限范围，未指定或包含 * 时为全部权限
+func normalizeAPIKeyScopes(scopes []string) (string, error) {
+	allowed := make(map[string]bool, len(model.APIKeyScopes))
+	for _, s := range model.APIKeyScopes {
+		allowed[s] = true
+	}
+	seen := make(map[string]bool)
+	list := make([]string, 0, len(scopes))
+	for _, s := range scopes {
+		s = strings.TrimSpace(s)
+		if s == model.APIKeyScopeAll {
+			list = []string{model.APIKeyScopeAll}
+			break
+		}
+		if !allowed[s] {
+			return "", errcode.WithData(errcode.CodeParamError, map[string]interface{}{
+				"message": "unsupported scope: " + s,
+			})
+		}
+		if !seen[s] {
+			seen[s] = true
+			list = append(list, s)
+		}
+	}
+	if len(list) == 0 {
+		list = []string{model.APIKeyScopeAll}
+	}
+	sort.Strings(list)
+	b, _ := json.Marshal(list)
+	return string(b), nil
+}
+
+// normalizeAPIKeyIPAllowlist 校验IP白名单，每项为IP或CIDR，为空时不限制
+func normalizeAPIKeyIPAllowlist(list []string) (*string, error) {
+	out := make([]string, 0, len(list))
+	for _, item := range list {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if strings.Contains(item, "/") {
+			_, ipNet, err := net.ParseCIDR(item)
+			if err != nil {
+				return nil, errcode.WithData(errcode.CodeParamError, map[string]interface{}{
+					"message": "invalid CIDR in ip_allowlist: " + item,
+				})
+			}
+			item = ipNet.String()
+		} else if ip := net.ParseIP(item); ip == nil {
+			return nil, errcode.WithData(errcode.CodeParamError, map[string]interface{}{
+				"message": "invalid IP in ip_allowlist: " + item,
+			})
+		} else {
+			item = ip.String()
+		}
+		out = append(out, item)
+	}
+	if len(out) == 0 {
+		return nil, nil
+	}
+	b, _ := json.Marshal(out)
+	str := string(b)
+	return &str, nil
+}
+
+// validateAPIKeyRestriction 限制的组织、设备分组必须属于密钥所在租户
+func validateAPIKeyRestriction(tenantID string, orgID, deviceGroupID *string) error {
+	if orgID != nil && *orgID != "" {
+		var count int64
+		if err := global.DB.Model(&model.Org{}).Where("id = ? AND tenant_id = ?", *orgID, tenantID).Count(&count).Error; err != nil {
+			return errcode.WithData(errcode.CodeDBError, map[string]interface{}{
+				"sql_error": err.Error(),
+			})
+		}
+		if count == 0 {
+			return errcode.WithData(errcode.CodeParamError, map[string]interface{}{
+				"message": "org not found in tenant",
+			})
+		}
+	}
+	if deviceGroupID != nil && *deviceGroupID != "" {
+		var count int64
+		if err := global.DB.Model(&model.Group{}).Where("id = ? AND tenant_id = ?", *deviceGroupID, tenantID).Count(&count).Error; err != nil {
+			return errcode.WithData(errcode.CodeDBError, map[string]interface{}{
+				"sql_error": err.Error(),
+			})
+		}
+		if count == 0 {
+			return errcode.WithData(errcode.CodeParamError, map[string]interface{}{
+				"message": "device group not found in tenant",
+			})
+		}
+	}
+	return nil
+}
+
+// apiKeyRateLimit 每分钟请求上限，0 表示不限制
+func apiKeyRateLimit(limit *int32) *int32 {
+	if limit == nil || *limit <= 0 {
+		return nil
+	}
+	return limit
+}
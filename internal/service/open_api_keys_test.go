@@ -0,0 +1,66 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNormalizeAPIKeyScopes(t *testing.T) {
+	cases := []struct {
+		in      []string
+		want    string
+		wantErr bool
+	}{
+		{nil, `["*"]`, false},
+		{[]string{"device:read", "telemetry:read", "device:read"}, `["device:read","telemetry:read"]`, false},
+		{[]string{"telemetry:read", "*"}, `["*"]`, false},
+		{[]string{"telemetry:write"}, "", true},
+	}
+	for _, tc := range cases {
+		got, err := normalizeAPIKeyScopes(tc.in)
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Errorf("normalizeAPIKeyScopes(%v) = %q, %v", tc.in, got, err)
+		}
+	}
+}
+
+func TestNormalizeAPIKeyIPAllowlist(t *testing.T) {
+	got, err := normalizeAPIKeyIPAllowlist([]string{" 10.0.0.1", "192.168.1.7/24", "", "2001:db8::1"})
+	if err != nil || got == nil || *got != `["10.0.0.1","192.168.1.0/24","2001:db8::1"]` {
+		t.Fatalf("allowlist = %v, %v", got, err)
+	}
+	if got, err := normalizeAPIKeyIPAllowlist([]string{" "}); err != nil || got != nil {
+		t.Fatalf("empty allowlist = %v, %v", got, err)
+	}
+	for _, bad := range []string{"10.0.0.300", "10.0.0.0/33", "example.com"} {
+		if _, err := normalizeAPIKeyIPAllowlist([]string{bad}); err == nil {
+			t.Errorf("%s should be rejected", bad)
+		}
+	}
+}
+
+func TestParseOpenAPIKeyUsage(t *testing.T) {
+	u, err := parseOpenAPIKeyUsage("k1|t1|2026-03-04", map[string]string{
+		"total":        "12",
+		"rejected":     "3",
+		"last_used_at": "1772600000",
+		"last_used_ip": "10.0.0.1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.KeyID != "k1" || u.TenantID != "t1" || !u.Date.Equal(time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("usage = %+v", u)
+	}
+	if u.Total != 12 || u.Rejected != 3 || u.LastUsedIP != "10.0.0.1" || u.LastUsedAt == nil || u.LastUsedAt.Unix() != 1772600000 {
+		t.Fatalf("usage = %+v", u)
+	}
+	// 只有被拒绝的请求
+	u, err = parseOpenAPIKeyUsage("k1|t1|2026-03-04", map[string]string{"rejected": "1"})
+	if err != nil || u.Total != 0 || u.Rejected != 1 || u.LastUsedAt != nil {
+		t.Fatalf("usage = %+v, %v", u, err)
+	}
+	if _, err := parseOpenAPIKeyUsage("k1|2026-03-04", nil); err == nil {
+		t.Fatal("malformed member should be rejected")
+	}
+}
@@ -1,11 +1,13 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
 
+	"project/initialize"
 	dal "project/internal/dal"
 	model "project/internal/model"
 	query "project/internal/query"
@@ -120,12 +122,12 @@ func (*OTA) GetOTAUpgradePackageListByPage(req *model.GetOTAUpgradePackageLisyBy
 
 }
 
-func (o *OTA) CreateOTAUpgradeTask(req *model.CreateOTAUpgradeTaskReq) error {
+func (o *OTA) CreateOTAUpgradeTask(req *model.CreateOTAUpgradeTaskReq, operatorID string) error {
 	tasks, err := dal.CreateOTAUpgradeTaskWithDetail(req)
 	if err == nil {
 		go func() {
 			for _, t := range tasks {
-				o.PushOTAUpgradePackage(t)
+				o.PushOTAUpgradePackage(t, operatorID)
 			}
 		}()
 	}
@@ -164,7 +166,7 @@ func (*OTA) GetOTAUpgradeTaskDetailListByPage(req *model.GetOTAUpgradeTaskDetail
 // 1-待推送 2-已推送 3-升级中 修改为已取消
 // 5-升级失败 修改为待推送
 // 4-升级成功 6-已取消 不修改
-func (o *OTA) UpdateOTAUpgradeTaskStatus(req *model.UpdateOTAUpgradeTaskStatusReq) error {
+func (o *OTA) UpdateOTAUpgradeTaskStatus(req *model.UpdateOTAUpgradeTaskStatusReq, operatorID string) error {
 	taskDetail, err := query.OtaUpgradeTaskDetail.Where(query.OtaUpgradeTaskDetail.ID.Eq(req.Id)).First()
 	if err != nil {
 		return err
@@ -189,6 +191,9 @@ func (o *OTA) UpdateOTAUpgradeTaskStatus(req *model.UpdateOTAUpgradeTaskStatusRe
 		desc := "手动取消升级"
 		taskDetail.StatusDescription = &desc
 		_, err := query.OtaUpgradeTaskDetail.Updates(taskDetail)
+		if err == nil {
+			go GroupApp.WebhookSubscription.PublishOtaProgress(taskDetail)
+		}
 		return err
 	}
 	if req.Action == 1 {
@@ -205,18 +210,46 @@ func (o *OTA) UpdateOTAUpgradeTaskStatus(req *model.UpdateOTAUpgradeTaskStatusRe
 			return err
 		}
 		// 重新升级后推送升级包
-		err = o.PushOTAUpgradePackage(taskDetail)
+		err = o.PushOTAUpgradePackage(taskDetail, operatorID)
 		return err
 	}
 
 	return err
 }
-func (*OTA) PushOTAUpgradePackage(taskDetail *model.OtaUpgradeTaskDetail) error {
+
+// PushOTAUpgradePackage 向设备推送升级包，推送结果写入设备审计
+func (o *OTA) PushOTAUpgradePackage(taskDetail *model.OtaUpgradeTaskDetail, operatorID string) error {
+	var before interface{}
+	if device, err := initialize.GetDeviceCacheById(taskDetail.DeviceID); err == nil {
+		before = map[string]interface{}{"version": device.CurrentVersion}
+	}
+	pkg, err := o.pushOTAUpgradePackage(taskDetail)
+
+	after := map[string]interface{}{"ota_upgrade_task_id": taskDetail.OtaUpgradeTaskID}
+	if pkg != nil {
+		after["ota_upgrade_package_id"] = pkg.ID
+		after["version"] = pkg.Version
+	}
+	GroupApp.DeviceAudit.Record(context.Background(), DeviceAuditEntry{
+		DeviceID: taskDetail.DeviceID,
+		Action:   model.DeviceAuditOtaPush,
+		ActorID:  operatorID,
+		Target:   taskDetail.OtaUpgradeTaskID,
+		Before:   before,
+		After:    after,
+		Err:      err,
+		RefID:    taskDetail.ID,
+	})
+	return err
+}
+
+// pushOTAUpgradePackage 推送升级包，返回本次推送的升级包（未查到时为 nil）
+func (*OTA) pushOTAUpgradePackage(taskDetail *model.OtaUpgradeTaskDetail) (*model.OtaUpgradePackage, error) {
 	// 查看设备是否在线
 	device := &model.Device{}
 	device, err := query.Device.Where(query.Device.ID.Eq(taskDetail.DeviceID)).First()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if device.IsOnline != 1 {
 		//修改设备升级任务信息
@@ -227,14 +260,15 @@ func (*OTA) PushOTAUpgradePackage(taskDetail *model.OtaUpgradeTaskDetail) error
 		taskDetail.UpdatedAt = &t
 		_, err := query.OtaUpgradeTaskDetail.Updates(taskDetail)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		return fmt.Errorf("the device is offline")
+		go GroupApp.WebhookSubscription.PublishOtaProgress(taskDetail)
+		return nil, fmt.Errorf("the device is offline")
 	}
 	// 查看设备是否有其他升级中的任务
 	count, err := query.OtaUpgradeTaskDetail.Where(query.OtaUpgradeTaskDetail.DeviceID.Eq(taskDetail.DeviceID), query.OtaUpgradeTaskDetail.Status.Lt(4)).Count()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if count > 0 {
 		//修改设备升级任务信息
@@ -245,9 +279,10 @@ func (*OTA) PushOTAUpgradePackage(taskDetail *model.OtaUpgradeTaskDetail) error
 		taskDetail.UpdatedAt = &t
 		_, err := query.OtaUpgradeTaskDetail.Updates(taskDetail)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		return fmt.Errorf("the device is upgrading")
+		go GroupApp.WebhookSubscription.PublishOtaProgress(taskDetail)
+		return nil, fmt.Errorf("the device is upgrading")
 	}
 	// 推送升级包
 	taskQuery, err := query.OtaUpgradeTask.
@@ -255,18 +290,18 @@ func (*OTA) PushOTAUpgradePackage(taskDetail *model.OtaUpgradeTaskDetail) error
 		Where(query.OtaUpgradeTask.ID.Eq(taskDetail.OtaUpgradeTaskID)).
 		First()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	packageID := taskQuery.OtaUpgradePackageID
 	otapackage, err := query.OtaUpgradePackage.Where(query.OtaUpgradePackage.ID.Eq(packageID)).First()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	var otamsg = make(map[string]interface{})
 	// 获取随机九位数字并转换为字符串
 	randNum, err := common.GetRandomNineDigits()
 	if err != nil {
-		return err
+		return otapackage, err
 	}
 	otamsg["id"] = randNum
 	otamsg["code"] = "200"
@@ -297,10 +332,11 @@ func (*OTA) PushOTAUpgradePackage(taskDetail *model.OtaUpgradeTaskDetail) error
 		taskDetail.UpdatedAt = &t
 		_, err := query.OtaUpgradeTaskDetail.Updates(taskDetail)
 		if err != nil {
-			return err
+			return otapackage, err
 		}
 		go publish.PublishOtaAdress(device.DeviceNumber, palyload)
+		go GroupApp.WebhookSubscription.PublishOtaProgress(taskDetail)
 	}
 
-	return nil
+	return otapackage, nil
 }
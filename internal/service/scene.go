@@ -1,6 +1,8 @@
 package service
 
 import (
+	"context"
+
 	"project/internal/dal"
 	model "project/internal/model"
 	"project/pkg/errcode"
@@ -10,6 +12,9 @@ import (
 type Scene struct{}
 
 func (*Scene) CreateScene(req model.CreateSceneReq, claims *utils.UserClaims) (string, error) {
+	if err := GroupApp.DeviceParamPermission.CheckActions(context.Background(), claims, sceneParamActions(req.Actions)); err != nil {
+		return "", err
+	}
 	id, err := dal.CreateSceneInfo(req, claims)
 	if err != nil {
 		return "", errcode.WithData(errcode.CodeDBError, map[string]interface{}{
@@ -20,6 +25,9 @@ func (*Scene) CreateScene(req model.CreateSceneReq, claims *utils.UserClaims) (s
 }
 
 func (*Scene) UpdateScene(req model.UpdateSceneReq, claims *utils.UserClaims) (string, error) {
+	if err := GroupApp.DeviceParamPermission.CheckActions(context.Background(), claims, sceneParamActions(req.Actions)); err != nil {
+		return "", err
+	}
 	id, err := dal.UpdateSceneInfo(req, claims)
 	if err != nil {
 		return "", errcode.WithData(errcode.CodeDBError, map[string]interface{}{
@@ -29,6 +37,20 @@ func (*Scene) UpdateScene(req model.UpdateSceneReq, claims *utils.UserClaims) (s
 	return id, err
 }
 
+// sceneParamActions 场景中下发到设备的动作
+func sceneParamActions(actions []model.SceneActionsReq) []deviceParamAction {
+	out := make([]deviceParamAction, 0, len(actions))
+	for _, a := range actions {
+		out = append(out, deviceParamAction{
+			ActionType:   a.ActionType,
+			ActionTarget: a.ActionTarget,
+			ParamType:    a.ActionParamType,
+			Value:        a.ActionValue,
+		})
+	}
+	return out
+}
+
 func (*Scene) DeleteScene(scene_id string) error {
 	err := dal.DeleteSceneInfo(scene_id)
 	if err != nil {
@@ -1,6 +1,8 @@
 package service
 
 import (
+	"context"
+
 	"project/initialize"
 	"project/internal/dal"
 	model "project/internal/model"
@@ -16,6 +18,16 @@ type SceneAutomation struct{}
 func (s *SceneAutomation) CreateSceneAutomation(req *model.CreateSceneAutomationReq, u *utils.UserClaims) (string, error) {
 	var scene_automation_id string
 
+	if err := validateConditionGroups(req.TriggerConditionGroups); err != nil {
+		return scene_automation_id, err
+	}
+	if err := validateSceneZone(req.Timezone, req.HolidayCalendarID, u.TenantID); err != nil {
+		return scene_automation_id, err
+	}
+	if err := GroupApp.DeviceParamPermission.CheckActions(context.Background(), u, sceneAutomationParamActions(req.Actions)); err != nil {
+		return scene_automation_id, err
+	}
+
 	// 开启事物
 	logrus.Info("开启事物")
 	tx, err := dal.StartTransaction()
@@ -41,6 +53,8 @@ func (s *SceneAutomation) CreateSceneAutomation(req *model.CreateSceneAutomation
 	sceneAutomation.CreatedAt = utils.GetUTCTime()
 	sceneAutomation.UpdatedAt = &sceneAutomation.CreatedAt
 	sceneAutomation.Remark = &req.Remark
+	sceneAutomation.Timezone = emptyToNil(req.Timezone)
+	sceneAutomation.HolidayCalendarID = emptyToNil(req.HolidayCalendarID)
 	// 创建场景联动
 	logrus.Info("创建场景联动信息")
 	err = dal.CreateSceneAutomation(&sceneAutomation, tx)
@@ -59,7 +73,7 @@ func (s *SceneAutomation) CreateSceneAutomation(req *model.CreateSceneAutomation
 		)
 		for _, v2 := range v {
 			switch v2.TriggerConditionsType {
-			case "10", "11", "22":
+			case "10", "11", "12", "13", "22":
 				if v2.TriggerConditionsType == "10" {
 					oneCondition = true
 				}
@@ -80,6 +94,16 @@ func (s *SceneAutomation) CreateSceneAutomation(req *model.CreateSceneAutomation
 				if v2.TriggerValue != nil {
 					dtc.TriggerValue = *v2.TriggerValue
 				}
+				dtc.HoldDuration = v2.HoldDuration
+				dtc.SampleWindow = v2.SampleWindow
+				dtc.SampleHits = v2.SampleHits
+				dtc.ClearValue = v2.ClearValue
+				dtc.WindowFunc = v2.WindowFunc
+				dtc.WindowSeconds = v2.WindowSeconds
+				dtc.AggregateFunc = v2.AggregateFunc
+				dtc.MemberOperator = v2.MemberOperator
+				dtc.MemberValue = v2.MemberValue
+				dtc.AggregateBySubgroup = v2.AggregateBySubgroup
 				dtc.Enabled = req.Enabled
 				dtc.TenantID = u.TenantID
 				// 创建设备触发条件
@@ -287,6 +311,8 @@ func (*SceneAutomation) GetSceneAutomation(scene_automation_id string) (interfac
 	res["tenant_id"] = sceneAutomation.TenantID
 	res["creator"] = sceneAutomation.Creator
 	res["updator"] = sceneAutomation.Updator
+	res["timezone"] = sceneAutomation.Timezone
+	res["holiday_calendar_id"] = sceneAutomation.HolidayCalendarID
 
 	triggerConditionGroups := make([][]map[string]interface{}, 0)
 
@@ -340,6 +366,16 @@ func (*SceneAutomation) GetSceneAutomation(scene_automation_id string) (interfac
 				deviceTriggerConditionMap["trigger_param"] = v2.TriggerParam
 				deviceTriggerConditionMap["trigger_operator"] = v2.TriggerOperator
 				deviceTriggerConditionMap["trigger_value"] = v2.TriggerValue
+				deviceTriggerConditionMap["hold_duration"] = v2.HoldDuration
+				deviceTriggerConditionMap["sample_window"] = v2.SampleWindow
+				deviceTriggerConditionMap["sample_hits"] = v2.SampleHits
+				deviceTriggerConditionMap["clear_value"] = v2.ClearValue
+				deviceTriggerConditionMap["window_func"] = v2.WindowFunc
+				deviceTriggerConditionMap["window_seconds"] = v2.WindowSeconds
+				deviceTriggerConditionMap["aggregate_func"] = v2.AggregateFunc
+				deviceTriggerConditionMap["member_operator"] = v2.MemberOperator
+				deviceTriggerConditionMap["member_value"] = v2.MemberValue
+				deviceTriggerConditionMap["aggregate_by_subgroup"] = v2.AggregateBySubgroup
 				mapList = append(mapList, deviceTriggerConditionMap)
 			}
 			tmp = append(tmp, mapList)
@@ -472,6 +508,16 @@ func (*SceneAutomation) GetSceneAutomationWithAlarmByPageReq(req *model.GetScene
 func (*SceneAutomation) UpdateSceneAutomation(req *model.UpdateSceneAutomationReq, u *utils.UserClaims) (string, error) {
 	var scene_automation_id string
 
+	if err := validateConditionGroups(req.TriggerConditionGroups); err != nil {
+		return scene_automation_id, err
+	}
+	if err := validateSceneZone(req.Timezone, req.HolidayCalendarID, u.TenantID); err != nil {
+		return scene_automation_id, err
+	}
+	if err := GroupApp.DeviceParamPermission.CheckActions(context.Background(), u, sceneAutomationParamActions(req.Actions)); err != nil {
+		return scene_automation_id, err
+	}
+
 	// 开启事物
 	tx, err := dal.StartTransaction()
 	if err != nil {
@@ -492,6 +538,8 @@ func (*SceneAutomation) UpdateSceneAutomation(req *model.UpdateSceneAutomationRe
 	sceneAutomation.Updator = u.ID
 	sceneAutomation.UpdatedAt = &t
 	sceneAutomation.Remark = &req.Remark
+	sceneAutomation.Timezone = emptyToNil(req.Timezone)
+	sceneAutomation.HolidayCalendarID = emptyToNil(req.HolidayCalendarID)
 
 	err = dal.SaveSceneAutomation(&sceneAutomation, tx)
 	if err != nil {
@@ -550,7 +598,7 @@ func (*SceneAutomation) UpdateSceneAutomation(req *model.UpdateSceneAutomationRe
 				multipleCondition = true
 			}
 			switch v2.TriggerConditionsType {
-			case "10", "11", "22":
+			case "10", "11", "12", "13", "22":
 				// 写入 device_trigger_condition
 				dtc := model.DeviceTriggerCondition{}
 				dtc.ID = uuid.New()
@@ -565,6 +613,16 @@ func (*SceneAutomation) UpdateSceneAutomation(req *model.UpdateSceneAutomationRe
 				if v2.TriggerValue != nil {
 					dtc.TriggerValue = *v2.TriggerValue
 				}
+				dtc.HoldDuration = v2.HoldDuration
+				dtc.SampleWindow = v2.SampleWindow
+				dtc.SampleHits = v2.SampleHits
+				dtc.ClearValue = v2.ClearValue
+				dtc.WindowFunc = v2.WindowFunc
+				dtc.WindowSeconds = v2.WindowSeconds
+				dtc.AggregateFunc = v2.AggregateFunc
+				dtc.MemberOperator = v2.MemberOperator
+				dtc.MemberValue = v2.MemberValue
+				dtc.AggregateBySubgroup = v2.AggregateBySubgroup
 				dtc.Enabled = req.Enabled
 				dtc.TenantID = u.TenantID
 				// 创建设备触发条件
@@ -667,36 +725,76 @@ func (*SceneAutomation) UpdateSceneAutomation(req *model.UpdateSceneAutomationRe
 	}
 
 	dal.Commit(tx)
+	invalidateAutomateZoneCache()
 
 	// 更新后清除缓存并重建（如果启用）
-	go func() {
-		// 清除自动化缓存
-		err := initialize.NewAutomateCache().DeleteCacheBySceneAutomationId(scene_automation_id)
-		if err != nil {
-			logrus.Error("删除自动化缓存失败: ", err)
-		}
+	go resetSceneAutomationCache(scene_automation_id, req.Enabled == "Y")
 
-		// 清除告警缓存
-		alarmCache := initialize.NewAlarmCache()
-		groupIds, err := alarmCache.GetBySceneAutomationId(scene_automation_id)
-		if err == nil && len(groupIds) > 0 {
-			for _, group_id := range groupIds {
-				err = alarmCache.DeleteBygroupId(group_id)
-				if err != nil {
-					logrus.Error("删除告警缓存失败: ", err)
-				}
-			}
-		}
+	return scene_automation_id, nil
+}
 
-		// 如果场景联动是启用状态，重建缓存
-		if req.Enabled == "Y" {
-			sa := SceneAutomation{}
-			err = sa.AutomateCacheSet(scene_automation_id)
+// resetSceneAutomationCache 清除场景联动的自动化缓存与告警缓存，启用时重建自动化缓存
+func resetSceneAutomationCache(scene_automation_id string, enabled bool) {
+	// 清除自动化缓存
+	err := initialize.NewAutomateCache().DeleteCacheBySceneAutomationId(scene_automation_id)
+	if err != nil {
+		logrus.Error("删除自动化缓存失败: ", err)
+	}
+
+	// 清除告警缓存
+	alarmCache := initialize.NewAlarmCache()
+	groupIds, err := alarmCache.GetBySceneAutomationId(scene_automation_id)
+	if err == nil && len(groupIds) > 0 {
+		for _, group_id := range groupIds {
+			err = alarmCache.DeleteBygroupId(group_id)
 			if err != nil {
-				logrus.Error("更新场景联动重建缓存失败，err: ", err)
+				logrus.Error("删除告警缓存失败: ", err)
 			}
 		}
-	}()
+	}
 
-	return scene_automation_id, nil
+	// 如果场景联动是启用状态，重建缓存
+	if enabled {
+		sa := SceneAutomation{}
+		err = sa.AutomateCacheSet(scene_automation_id)
+		if err != nil {
+			logrus.Error("更新场景联动重建缓存失败，err: ", err)
+		}
+	}
+}
+
+// sceneAutomationParamActions 场景联动中下发到设备的动作
+func sceneAutomationParamActions(actions []model.Action) []deviceParamAction {
+	out := make([]deviceParamAction, 0, len(actions))
+	for _, a := range actions {
+		out = append(out, deviceParamAction{
+			ActionType:   a.ActionType,
+			ActionTarget: a.ActionTarget,
+			ParamType:    StringPtr(a.ActionParamType),
+			Value:        StringPtr(a.ActionValue),
+		})
+	}
+	return out
+}
+
+// validateSceneZone 校验场景联动时区与节假日日历
+func validateSceneZone(timezone, holidayCalendarID *string, tenantID string) error {
+	if timezone != nil && *timezone != "" {
+		if _, err := parseTimezone(*timezone); err != nil {
+			return err
+		}
+	}
+	if holidayCalendarID != nil && *holidayCalendarID != "" {
+		if _, err := getHolidayCalendar(context.Background(), *holidayCalendarID, tenantID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func emptyToNil(s *string) *string {
+	if s == nil || *s == "" {
+		return nil
+	}
+	return s
 }
@@ -23,7 +23,6 @@ import (
 
 	"github.com/go-basic/uuid"
 	"github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
 )
 
 type User struct{}
@@ -163,8 +162,8 @@ func (u *User) CreateUser(createUserReq *model.CreateUserReq, claims *utils.User
 	return err
 }
 
-// @description  用户登录
-func (u *User) Login(ctx context.Context, loginReq *model.LoginReq) (*model.LoginRsp, error) {
+// @description  用户登录；启用或租户强制双因素认证时返回二次验证临时令牌而非登录凭证
+func (u *User) Login(ctx context.Context, loginReq *model.LoginReq, meta *model.RequestMeta) (*model.LoginRsp, error) {
 	// 通过邮箱获取用户信息
 	user, err := dal.GetUsersByEmail(loginReq.Email)
 	if err != nil {
@@ -194,7 +193,16 @@ func (u *User) Login(ctx context.Context, loginReq *model.LoginReq) (*model.Logi
 		return nil, errcode.New(errcode.CodeUserDisabled)
 	}
 
-	logrsp, err := u.UserLoginAfter(user)
+	// 双因素认证：受信任设备之外需完成二次验证后再签发登录凭证
+	challenge, err := GroupApp.UserMfa.LoginChallenge(ctx, user, loginReq.TrustedDeviceToken, meta)
+	if err != nil {
+		return nil, err
+	}
+	if challenge != nil {
+		return challenge, nil
+	}
+
+	logrsp, err := u.UserLoginAfter(ctx, user, meta)
 	if err != nil {
 		return nil, err
 	}
@@ -209,97 +217,9 @@ func (u *User) Login(ctx context.Context, loginReq *model.LoginReq) (*model.Logi
 }
 
 // UserLoginAfter
-// @description 用户登录后token获取保存
-func (*User) UserLoginAfter(user *model.User) (*model.LoginRsp, error) {
-	key := viper.GetString("jwt.key")
-	// 生成token
-	jwt := utils.NewJWT([]byte(key))
-	claims := utils.UserClaims{
-		ID:         user.ID,
-		Email:      user.Email,
-		Authority:  *user.Authority,
-		CreateTime: time.Now().UTC(),
-		TenantID:   *user.TenantID,
-	}
-	token, err := jwt.GenerateToken(claims)
-	if err != nil {
-		return nil, errcode.New(errcode.CodeTokenGenerateError)
-	}
-	timeout := viper.GetInt("session.timeout")
-	reset_on_request := viper.GetBool("session.reset_on_request")
-	if reset_on_request {
-		if timeout == 0 {
-			// 过期时间为1小时
-			timeout = 60
-		}
-	}
-	// 保存token到redis
-	global.REDIS.Set(context.Background(), token, "1", time.Duration(timeout)*time.Minute)
-	// 禁止共享token，这里永久存储账号和token的关系，是可以保证一个账号只能在一个地方登录
-	if !logic.UserIsShare(context.Background()) {
-		oldToken, err := global.REDIS.Get(context.Background(), user.Email+"_token").Result()
-		if err != nil {
-			logrus.Error(err)
-		} else {
-			global.REDIS.Del(context.Background(), oldToken)
-		}
-		global.REDIS.Set(context.Background(), user.Email+"_token", token, 0)
-	}
-
-	loginRsp := &model.LoginRsp{
-		Token:     &token,
-		ExpiresIn: int64(timeout * 60), // 转换为秒数
-	}
-	return loginRsp, nil
-}
-
-// @description 退出登录
-func (*User) Logout(token string) error {
-	if err := global.REDIS.Del(context.Background(), token).Err(); err != nil {
-		return errcode.New(errcode.CodeTokenDeleteError)
-	}
-	return nil
-}
-
-// @description 刷新token
-func (*User) RefreshToken(userClaims *utils.UserClaims) (*model.LoginRsp, error) {
-	// 通过邮箱获取用户信息
-	user, err := dal.GetUsersByEmail(userClaims.Email)
-	if err != nil {
-		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{
-			"operation": "query_user",
-			"email":     userClaims.Email,
-			"error":     err.Error(),
-		})
-	}
-
-	// 判断用户状态
-	if *user.Status != "N" {
-		return nil, errcode.New(errcode.CodeUserDisabled)
-	}
-
-	key := viper.GetString("jwt.key")
-	// 生成token
-	jwt := utils.NewJWT([]byte(key))
-	claims := utils.UserClaims{
-		ID:         user.ID,
-		Email:      user.Email,
-		Authority:  *user.Authority,
-		CreateTime: time.Now().UTC(),
-		TenantID:   *user.TenantID,
-	}
-	token, err := jwt.GenerateToken(claims)
-	if err != nil {
-		return nil, errcode.New(errcode.CodeTokenGenerateError)
-	}
-
-	global.REDIS.Set(context.Background(), token, "1", 24*7*time.Hour)
-
-	loginRsp := &model.LoginRsp{
-		Token:     &token,
-		ExpiresIn: int64(24 * 7 * time.Hour.Seconds()),
-	}
-	return loginRsp, nil
+// @description 用户登录后新建会话，签发访问令牌与刷新令牌
+func (*User) UserLoginAfter(ctx context.Context, user *model.User, meta *model.RequestMeta) (*model.LoginRsp, error) {
+	return issueSession(ctx, user, meta, true)
 }
 
 // @description 发送验证码
@@ -680,7 +600,7 @@ func (*User) UpdateUserInfo(ctx context.Context, updateUserReq *model.UpdateUser
 }
 
 // @description SuperAdmin Become Other admin
-func (*User) TransformUser(transformUserReq *model.TransformUserReq, claims *utils.UserClaims) (*model.LoginRsp, error) {
+func (*User) TransformUser(transformUserReq *model.TransformUserReq, claims *utils.UserClaims, meta *model.RequestMeta) (*model.LoginRsp, error) {
 	// 权限检查
 	if claims.Authority != "SYS_ADMIN" && claims.Authority != "TENANT_ADMIN" {
 		return nil, errcode.WithVars(errcode.CodeNoPermission, map[string]interface{}{
@@ -707,51 +627,12 @@ func (*User) TransformUser(transformUserReq *model.TransformUserReq, claims *uti
 		})
 	}
 
-	// 获取JWT密钥
-	key := viper.GetString("jwt.key")
-	if key == "" {
-		return nil, errcode.New(errcode.CodeSystemError)
-	}
-
-	// 生成用户Claims
-	becomeUserClaims := utils.UserClaims{
-		ID:         becomeUser.ID,
-		Email:      becomeUser.Email,
-		Authority:  *becomeUser.Authority,
-		CreateTime: time.Now().UTC(),
-		TenantID:   *becomeUser.TenantID,
-	}
-
-	// 生成token
-	jwt := utils.NewJWT([]byte(key))
-	token, err := jwt.GenerateToken(becomeUserClaims)
-	if err != nil {
-		return nil, errcode.WithData(errcode.CodeTokenGenerateError, map[string]interface{}{
-			"error":   err.Error(),
-			"user_id": becomeUser.ID,
-		})
-	}
-
-	// 保存token到Redis
-	err = global.REDIS.Set(context.Background(), token, "1", 24*7*time.Hour).Err()
-	if err != nil {
-		return nil, errcode.WithData(errcode.CodeTokenSaveError, map[string]interface{}{
-			"error":   err.Error(),
-			"user_id": becomeUser.ID,
-		})
-	}
-
-	// 返回登录响应
-	loginRsp := &model.LoginRsp{
-		Token:     &token,
-		ExpiresIn: int64(24 * 7 * time.Hour.Seconds()),
-	}
-
-	return loginRsp, nil
+	// 以目标用户身份新建会话，不影响目标用户自己的登录
+	return issueSession(context.Background(), becomeUser, meta, false)
 }
 
 // EmailRegister 邮箱注册
-func (u *User) EmailRegister(ctx context.Context, req *model.EmailRegisterReq) (*model.LoginRsp, error) {
+func (u *User) EmailRegister(ctx context.Context, req *model.EmailRegisterReq, meta *model.RequestMeta) (*model.LoginRsp, error) {
 	// 检查手机号是否重复
 	phoneNumber := fmt.Sprintf("%s %s", req.PhonePrefix, req.PhoneNumber)
 	if exists, err := dal.CheckPhoneNumberExists(phoneNumber); err != nil {
@@ -851,7 +732,7 @@ func (u *User) EmailRegister(ctx context.Context, req *model.EmailRegisterReq) (
 		})
 	}
 
-	return u.UserLoginAfter(userInfo)
+	return u.UserLoginAfter(ctx, userInfo, meta)
 }
 
 // 通过用户手机号获取用户邮箱
@@ -969,11 +969,34 @@ func formatTime(timestamp int64) string {
 //
 //	error: 处理过程中的错误
 func (t *TelemetryData) TelemetryPutMessage(ctx context.Context, userID string, param *model.PutMessage, operationType string) error {
+	value := param.Value
+	before := deviceAuditCurrentValues(param.DeviceID, model.DeviceParamTEL, value)
+	logID, err := t.telemetryPutMessage(ctx, userID, param, operationType)
+
+	var after interface{} = value
+	if json.Valid([]byte(value)) {
+		after = json.RawMessage(value)
+	}
+	GroupApp.DeviceAudit.Record(ctx, DeviceAuditEntry{
+		DeviceID: param.DeviceID,
+		Action:   model.DeviceAuditTelemetrySet,
+		Source:   deviceAuditSource(operationType),
+		ActorID:  userID,
+		Before:   before,
+		After:    after,
+		Err:      err,
+		RefID:    logID,
+	})
+	return err
+}
+
+// telemetryPutMessage 下发遥测数据，返回遥测下发日志ID（即下行 MessageID）
+func (t *TelemetryData) telemetryPutMessage(ctx context.Context, userID string, param *model.PutMessage, operationType string) (string, error) {
 	// 步骤1: 校验入参
 	// ---------------------------------------------
 	// 校验参数值必须是有效的JSON
 	if !json.Valid([]byte(param.Value)) {
-		return errcode.WithData(errcode.CodeParamError, map[string]interface{}{
+		return "", errcode.WithData(errcode.CodeParamError, map[string]interface{}{
 			"error": "value must be json",
 		})
 	}
@@ -983,7 +1006,7 @@ func (t *TelemetryData) TelemetryPutMessage(ctx context.Context, userID string,
 	deviceInfo, err := initialize.GetDeviceCacheById(param.DeviceID)
 	if err != nil {
 		logrus.Error(ctx, "[TelemetryPutMessage][GetDeviceCacheById]failed:", err)
-		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{
+		return "", errcode.WithData(errcode.CodeDBError, map[string]interface{}{
 			"error": err.Error(),
 		})
 	}
@@ -1001,7 +1024,7 @@ func (t *TelemetryData) TelemetryPutMessage(ctx context.Context, userID string,
 		deviceConfig, err = dal.GetDeviceConfigByID(*deviceInfo.DeviceConfigID)
 		if err != nil {
 			logrus.Error(ctx, "[TelemetryPutMessage][GetDeviceConfigByID]failed:", err)
-			return errcode.WithData(errcode.CodeDBError, map[string]interface{}{
+			return "", errcode.WithData(errcode.CodeDBError, map[string]interface{}{
 				"error": err.Error(),
 			})
 		}
@@ -1011,7 +1034,7 @@ func (t *TelemetryData) TelemetryPutMessage(ctx context.Context, userID string,
 		if deviceConfig.ProtocolType != nil {
 			protocolType = *deviceConfig.ProtocolType
 		} else {
-			return errcode.WithData(errcode.CodeParamError, map[string]interface{}{
+			return "", errcode.WithData(errcode.CodeParamError, map[string]interface{}{
 				"error": "protocolType is nil",
 			})
 		}
@@ -1031,7 +1054,7 @@ func (t *TelemetryData) TelemetryPutMessage(ctx context.Context, userID string,
 		subTopicPrefix, err := dal.GetServicePluginSubTopicPrefixByDeviceConfigID(*deviceInfo.DeviceConfigID)
 		if err != nil {
 			logrus.Error(ctx, "failed to get sub topic prefix", err)
-			return errcode.WithData(errcode.CodeParamError, map[string]interface{}{
+			return "", errcode.WithData(errcode.CodeParamError, map[string]interface{}{
 				"error": err.Error(),
 			})
 		}
@@ -1046,7 +1069,7 @@ func (t *TelemetryData) TelemetryPutMessage(ctx context.Context, userID string,
 		topGateway, err := findTopLevelGateway(deviceInfo, deviceType)
 		if err != nil {
 			logrus.Error(ctx, "failed to find top level gateway", err)
-			return errcode.WithData(errcode.CodeParamError, map[string]interface{}{
+			return "", errcode.WithData(errcode.CodeParamError, map[string]interface{}{
 				"error": err.Error(),
 			})
 		}
@@ -1066,7 +1089,7 @@ func (t *TelemetryData) TelemetryPutMessage(ctx context.Context, userID string,
 		// 解析JSON
 		var inputData map[string]interface{}
 		if err := json.Unmarshal([]byte(param.Value), &inputData); err != nil {
-			return errcode.WithData(errcode.CodeParamError, map[string]interface{}{
+			return "", errcode.WithData(errcode.CodeParamError, map[string]interface{}{
 				"error": err.Error(),
 			})
 		}
@@ -1075,7 +1098,7 @@ func (t *TelemetryData) TelemetryPutMessage(ctx context.Context, userID string,
 		var outputData map[string]interface{}
 		if deviceType == "3" { // 子设备
 			if deviceInfo.SubDeviceAddr == nil {
-				return errcode.WithData(errcode.CodeParamError, map[string]interface{}{
+				return "", errcode.WithData(errcode.CodeParamError, map[string]interface{}{
 					"error": "subDeviceAddr is nil",
 				})
 			}
@@ -1083,7 +1106,7 @@ func (t *TelemetryData) TelemetryPutMessage(ctx context.Context, userID string,
 			// 查找子设备的直接父网关（可能是子网关）
 			parentGateway, err := initialize.GetDeviceCacheById(*deviceInfo.ParentID)
 			if err != nil {
-				return errcode.WithData(errcode.CodeDBError, map[string]interface{}{
+				return "", errcode.WithData(errcode.CodeDBError, map[string]interface{}{
 					"error": err.Error(),
 				})
 			}
@@ -1092,7 +1115,7 @@ func (t *TelemetryData) TelemetryPutMessage(ctx context.Context, userID string,
 			if parentGateway.ParentID != nil {
 				// 父网关是子网关，需要构建嵌套的sub_gateway_data结构
 				if parentGateway.SubDeviceAddr == nil {
-					return errcode.WithData(errcode.CodeParamError, map[string]interface{}{
+					return "", errcode.WithData(errcode.CodeParamError, map[string]interface{}{
 						"error": "parent gateway subDeviceAddr is nil",
 					})
 				}
@@ -1109,7 +1132,7 @@ func (t *TelemetryData) TelemetryPutMessage(ctx context.Context, userID string,
 			if deviceInfo.ParentID != nil {
 				// 子网关：构建为sub_gateway_data格式
 				if deviceInfo.SubDeviceAddr == nil {
-					return errcode.WithData(errcode.CodeParamError, map[string]interface{}{
+					return "", errcode.WithData(errcode.CodeParamError, map[string]interface{}{
 						"error": "sub gateway subDeviceAddr is nil",
 					})
 				}
@@ -1131,7 +1154,7 @@ func (t *TelemetryData) TelemetryPutMessage(ctx context.Context, userID string,
 		// 重新构建payload
 		output, err := json.Marshal(outputData)
 		if err != nil {
-			return errcode.WithData(errcode.CodeParamError, map[string]interface{}{
+			return "", errcode.WithData(errcode.CodeParamError, map[string]interface{}{
 				"error": err.Error(),
 			})
 		}
@@ -1163,7 +1186,7 @@ func (t *TelemetryData) TelemetryPutMessage(ctx context.Context, userID string,
 	_, err = dal.TelemetrySetLogsQuery{}.Create(ctx, logInfo)
 	if err != nil {
 		logrus.Error(ctx, "failed to create telemetry set log", err)
-		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{
+		return "", errcode.WithData(errcode.CodeDBError, map[string]interface{}{
 			"error": err.Error(),
 		})
 	}
@@ -1182,7 +1205,7 @@ func (t *TelemetryData) TelemetryPutMessage(ctx context.Context, userID string,
 			logrus.Error(ctx, "failed to update telemetry set log", updateErr)
 		}
 
-		return fmt.Errorf(errorMessage)
+		return "", fmt.Errorf(errorMessage)
 	}
 
 	// 构造下行消息（使用日志ID作为MessageID）
@@ -1201,7 +1224,7 @@ func (t *TelemetryData) TelemetryPutMessage(ctx context.Context, userID string,
 	// 发送到 Bus（异步处理，Handler 会更新日志状态）
 	t.downlinkBus.PublishTelemetry(msg)
 
-	return nil
+	return logID, nil
 }
 
 // getTopicByDevice 函数已废弃，Topic构造逻辑已移至Adapter层
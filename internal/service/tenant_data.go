@@ -0,0 +1,888 @@
+package service
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"project/internal/model"
+	"project/pkg/errcode"
+	"project/pkg/global"
+	"project/pkg/utils"
+
+	"github.com/go-basic/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// TenantData 租户数据导出与导入：导出为可移植的归档（zip），在另一部署中导入，用于跨区域迁移客户
+type TenantData struct{}
+
+const (
+	tenantArchiveFormat        = "tenant-archive"
+	tenantArchiveFormatVersion = 1
+	tenantArchiveDir           = "./files/tenant_archive/"
+	tenantArchiveManifestFile  = "manifest.json"
+	// tenantArchiveCasbin 归档中的角色权限与用户角色（通过 casbin 读写，不直接导出 casbin_rule 表）
+	tenantArchiveCasbin = "casbin_rule"
+	// tenantImportBatchSize 导入时每批写入的行数
+	tenantImportBatchSize = 500
+)
+
+// 归档表的租户过滤条件（命名参数 @tenant）
+const (
+	tenantWhere           = "tenant_id = @tenant"
+	tenantDeviceWhere     = "device_id IN (SELECT id FROM devices WHERE tenant_id = @tenant)"
+	tenantConfigWhere     = "device_config_id IN (SELECT id FROM device_configs WHERE tenant_id = @tenant)"
+	tenantAutomationWhere = "scene_automation_id IN (SELECT id FROM scene_automations WHERE tenant_id = @tenant)"
+	tenantCalendarWhere   = "calendar_id IN (SELECT id FROM holiday_calendars WHERE tenant_id = @tenant)"
+)
+
+// tenantArchiveTable 归档中的一张表，Omit 为导出时剔除的列（如密码）
+type tenantArchiveTable struct {
+	Name  string
+	Where string
+	Omit  []string
+}
+
+// tenantArchiveTables 导出顺序即导入顺序，被引用的表在前
+var tenantArchiveTables = []tenantArchiveTable{
+	{Name: "orgs", Where: tenantWhere},
+	{Name: "org_closure", Where: tenantWhere},
+	{Name: "dealers", Where: tenantWhere},
+	{Name: "roles", Where: tenantWhere},
+	{Name: "users", Where: tenantWhere, Omit: []string{"password"}},
+	{Name: "tenant_settings", Where: tenantWhere},
+	{Name: "holiday_calendars", Where: tenantWhere},
+	{Name: "holiday_calendar_dates", Where: tenantCalendarWhere},
+	{Name: "notification_groups", Where: tenantWhere},
+	{Name: "notification_templates", Where: tenantWhere},
+	{Name: "device_templates", Where: tenantWhere},
+	{Name: "device_model_telemetry", Where: tenantWhere},
+	{Name: "device_model_attributes", Where: tenantWhere},
+	{Name: "device_model_events", Where: tenantWhere},
+	{Name: "device_model_commands", Where: tenantWhere},
+	{Name: "device_model_custom_commands", Where: tenantWhere},
+	{Name: "device_model_custom_control", Where: tenantWhere},
+	{Name: "device_configs", Where: tenantWhere},
+	{Name: "data_scripts", Where: tenantConfigWhere},
+	{Name: "device_topic_mappings", Where: tenantConfigWhere},
+	{Name: "products", Where: tenantWhere},
+	{Name: "battery_models", Where: tenantWhere},
+	{Name: "groups", Where: tenantWhere},
+	{Name: "devices", Where: tenantWhere},
+	{Name: "r_group_device", Where: tenantWhere},
+	{Name: "device_batteries", Where: tenantDeviceWhere},
+	{Name: "battery_tags", Where: tenantWhere},
+	{Name: "device_battery_tags", Where: tenantWhere},
+	{Name: "scene_info", Where: tenantWhere},
+	{Name: "scene_action_info", Where: tenantWhere},
+	{Name: "scene_automations", Where: tenantWhere},
+	{Name: "device_trigger_condition", Where: tenantWhere},
+	{Name: "one_time_tasks", Where: tenantAutomationWhere},
+	{Name: "periodic_tasks", Where: tenantAutomationWhere},
+	{Name: "action_info", Where: tenantAutomationWhere},
+	{Name: "alarm_config", Where: tenantWhere},
+	{Name: "config_bundle_bindings", Where: tenantWhere},
+	{Name: "boards", Where: tenantWhere},
+	{Name: "device_param_rules", Where: tenantWhere},
+	{Name: "org_type_permissions", Where: tenantWhere},
+	{Name: "approval_policies", Where: tenantWhere},
+	{Name: "warranty_eligibility_rules", Where: tenantWhere},
+	{Name: "battery_maintenance_plans", Where: tenantWhere},
+	{Name: "attribute_datas", Where: tenantWhere},
+	{Name: "telemetry_current_datas", Where: tenantWhere},
+}
+
+// tenantArchiveTelemetry 遥测历史按时间范围导出（ts 为毫秒时间戳），部分历史数据 tenant_id 为空，按设备过滤
+var tenantArchiveTelemetry = tenantArchiveTable{
+	Name:  "telemetry_datas",
+	Where: tenantDeviceWhere + " AND ts >= @start AND ts < @end",
+}
+
+// tenantArchiveManifest 归档清单
+type tenantArchiveManifest struct {
+	Format         string               `json:"format"`
+	FormatVersion  int                  `json:"format_version"`
+	SchemaVersion  int                  `json:"schema_version"` // 导出实例的数据库版本
+	SystemVersion  string               `json:"system_version"`
+	TenantID       string               `json:"tenant_id"`
+	ExportedAt     time.Time            `json:"exported_at"`
+	TelemetryStart *time.Time           `json:"telemetry_start,omitempty"`
+	TelemetryEnd   *time.Time           `json:"telemetry_end,omitempty"`
+	Tables         []tenantArchiveEntry `json:"tables"`
+}
+
+// tenantArchiveEntry 归档中的一个数据文件（JSON Lines，每行一条记录）
+type tenantArchiveEntry struct {
+	Name   string `json:"name"`
+	File   string `json:"file"`
+	Rows   int64  `json:"rows"`
+	SHA256 string `json:"sha256"`
+}
+
+// tenantCasbinRule 归档中的一条 casbin 规则
+type tenantCasbinRule struct {
+	PType string   `json:"ptype"`
+	Rule  []string `json:"rule"`
+}
+
+// tenantImportResult 单表导入结果
+type tenantImportResult struct {
+	Inserted int64 `json:"inserted"`
+	Skipped  int64 `json:"skipped"` // 目标租户已有的同一记录（重复导入）而跳过的行
+}
+
+// quoteIdent SQL 标识符加引号
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// tenantArchiveTableByName 归档允许的表，导入时拒绝清单之外的表
+func tenantArchiveTableByName(name string) (tenantArchiveTable, bool) {
+	if name == tenantArchiveTelemetry.Name {
+		return tenantArchiveTelemetry, true
+	}
+	for _, t := range tenantArchiveTables {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return tenantArchiveTable{}, false
+}
+
+// checkTenantArchiveManifest 校验归档格式与版本：不接受比本实例数据库版本更新的归档
+func checkTenantArchiveManifest(m *tenantArchiveManifest, localSchemaVersion int) error {
+	if m.Format != tenantArchiveFormat || m.FormatVersion != tenantArchiveFormatVersion {
+		return fmt.Errorf("unsupported archive format %s v%d", m.Format, m.FormatVersion)
+	}
+	if m.SchemaVersion > localSchemaVersion {
+		return fmt.Errorf("archive schema version %d is newer than this instance (%d)", m.SchemaVersion, localSchemaVersion)
+	}
+	if strings.TrimSpace(m.TenantID) == "" {
+		return fmt.Errorf("archive has no tenant_id")
+	}
+	for _, e := range m.Tables {
+		if e.Name == tenantArchiveCasbin {
+			continue
+		}
+		if _, ok := tenantArchiveTableByName(e.Name); !ok {
+			return fmt.Errorf("archive contains unsupported table %s", e.Name)
+		}
+	}
+	return nil
+}
+
+// rewriteTenantArchiveRow 将一行归档数据转换为目标表可写入的记录：去掉目标表没有的列，tenant_id 改为目标租户，补充缺失列的默认值
+func rewriteTenantArchiveRow(line []byte, columns map[string]bool, tenantID string, defaults map[string]json.RawMessage) (map[string]json.RawMessage, error) {
+	var row map[string]json.RawMessage
+	if err := json.Unmarshal(line, &row); err != nil {
+		return nil, err
+	}
+	for k := range row {
+		if !columns[k] {
+			delete(row, k)
+		}
+	}
+	if columns["tenant_id"] {
+		b, _ := json.Marshal(tenantID)
+		row["tenant_id"] = b
+	}
+	for k, v := range defaults {
+		if _, ok := row[k]; !ok && columns[k] {
+			row[k] = v
+		}
+	}
+	return row, nil
+}
+
+// sortedRowColumns 记录的列名（排序后），同一批写入的记录列必须一致
+func sortedRowColumns(row map[string]json.RawMessage) []string {
+	cols := make([]string, 0, len(row))
+	for k := range row {
+		cols = append(cols, k)
+	}
+	sort.Strings(cols)
+	return cols
+}
+
+// tenantTableColumns 目标库中表的列
+func tenantTableColumns(db *gorm.DB, table string) (map[string]bool, error) {
+	var names []string
+	if err := db.Raw(`SELECT column_name FROM information_schema.columns WHERE table_schema = 'public' AND table_name = ?`, table).
+		Scan(&names).Error; err != nil {
+		return nil, err
+	}
+	cols := make(map[string]bool, len(names))
+	for _, n := range names {
+		cols[n] = true
+	}
+	return cols, nil
+}
+
+// CreateExportJob 创建租户导出任务（异步执行）；系统管理员可导出任意租户，租户管理员导出本租户
+func (s *TenantData) CreateExportJob(ctx context.Context, claims *utils.UserClaims, req *model.TenantExportReq) (*model.TenantDataJob, error) {
+	tenantID, err := GroupApp.OrgTypePermission.resolveTenantID(claims, req.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	if (req.TelemetryStart == nil) != (req.TelemetryEnd == nil) {
+		return nil, errcode.WithData(errcode.CodeParamError, map[string]interface{}{"error": "telemetry_start and telemetry_end must be set together"})
+	}
+	if req.TelemetryStart != nil && !req.TelemetryEnd.After(*req.TelemetryStart) {
+		return nil, errcode.WithData(errcode.CodeParamError, map[string]interface{}{"error": "telemetry_end must be after telemetry_start"})
+	}
+	if err := s.checkNoRunningJob(ctx, tenantID); err != nil {
+		return nil, err
+	}
+
+	job := &model.TenantDataJob{
+		ID:             uuid.New(),
+		JobType:        model.TenantDataJobExport,
+		TenantID:       tenantID,
+		Status:         model.TenantDataJobPending,
+		TelemetryStart: req.TelemetryStart,
+		TelemetryEnd:   req.TelemetryEnd,
+		RequestedBy:    claims.ID,
+		CreatedAt:      time.Now().UTC(),
+	}
+	if err := global.DB.WithContext(ctx).Create(job).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	go s.runJob(job.ID)
+	return job, nil
+}
+
+// CreateImportJob 创建租户导入任务（仅系统管理员）；目标租户为空时沿用归档中的租户ID
+func (s *TenantData) CreateImportJob(ctx context.Context, claims *utils.UserClaims, filePath, targetTenantID string) (*model.TenantDataJob, error) {
+	if claims.Authority != "SYS_ADMIN" {
+		return nil, errcode.WithVars(errcode.CodeNoPermission, map[string]interface{}{
+			"required_role": "SYS_ADMIN",
+			"current_role":  claims.Authority,
+		})
+	}
+	manifest, err := readTenantArchiveManifest(filePath)
+	if err != nil {
+		return nil, errcode.WithData(errcode.CodeParamError, map[string]interface{}{"error": err.Error()})
+	}
+	if err := checkTenantArchiveManifest(manifest, global.VERSION_NUMBER); err != nil {
+		return nil, errcode.WithData(errcode.CodeParamError, map[string]interface{}{"error": err.Error()})
+	}
+	tenantID := strings.TrimSpace(targetTenantID)
+	if tenantID == "" {
+		tenantID = manifest.TenantID
+	}
+	if active, err := tenantOffboardingActive(ctx, tenantID); err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	} else if active {
+		return nil, errcode.WithData(errcode.CodeParamError, map[string]interface{}{"tenant_id": tenantID, "error": "tenant is being offboarded"})
+	}
+	if err := s.checkNoRunningJob(ctx, tenantID); err != nil {
+		return nil, err
+	}
+
+	sourceTenantID := manifest.TenantID
+	job := &model.TenantDataJob{
+		ID:             uuid.New(),
+		JobType:        model.TenantDataJobImport,
+		TenantID:       tenantID,
+		SourceTenantID: &sourceTenantID,
+		Status:         model.TenantDataJobPending,
+		TelemetryStart: manifest.TelemetryStart,
+		TelemetryEnd:   manifest.TelemetryEnd,
+		FilePath:       &filePath,
+		RequestedBy:    claims.ID,
+		CreatedAt:      time.Now().UTC(),
+	}
+	if err := global.DB.WithContext(ctx).Create(job).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	go s.runJob(job.ID)
+	return job, nil
+}
+
+// checkNoRunningJob 同一租户同时只能有一个进行中的导出或导入任务
+func (s *TenantData) checkNoRunningJob(ctx context.Context, tenantID string) error {
+	var count int64
+	if err := global.DB.WithContext(ctx).Model(&model.TenantDataJob{}).
+		Where("tenant_id = ? AND status IN ?", tenantID, []string{model.TenantDataJobPending, model.TenantDataJobRunning}).
+		Count(&count).Error; err != nil {
+		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	if count > 0 {
+		return errcode.WithData(errcode.CodeParamError, map[string]interface{}{
+			"tenant_id": tenantID,
+			"error":     "another export or import job is in progress for this tenant",
+		})
+	}
+	return nil
+}
+
+// runJob 执行导出或导入任务并记录结果
+func (s *TenantData) runJob(id string) {
+	ctx := context.Background()
+	var job model.TenantDataJob
+	if err := global.DB.WithContext(ctx).Where("id = ?", id).First(&job).Error; err != nil {
+		logrus.WithError(err).WithField("job_id", id).Error("tenant data job: load failed")
+		return
+	}
+	now := time.Now().UTC()
+	if err := global.DB.WithContext(ctx).Model(&job).Updates(map[string]interface{}{
+		"status":     model.TenantDataJobRunning,
+		"started_at": now,
+	}).Error; err != nil {
+		logrus.WithError(err).WithField("job_id", id).Error("tenant data job: update failed")
+		return
+	}
+
+	updates := map[string]interface{}{}
+	var summary interface{}
+	var err error
+	if job.JobType == model.TenantDataJobExport {
+		var path string
+		path, summary, err = exportTenantArchive(ctx, &job)
+		if err == nil {
+			updates["file_path"] = path
+			if size, sum, hashErr := fileSHA256(path); hashErr == nil {
+				updates["file_size"] = size
+				updates["checksum"] = sum
+			}
+		}
+	} else {
+		summary, err = importTenantArchive(ctx, &job)
+	}
+
+	updates["status"] = model.TenantDataJobSucceeded
+	updates["finished_at"] = time.Now().UTC()
+	if summary != nil {
+		if b, mErr := json.Marshal(summary); mErr == nil {
+			updates["summary"] = string(b)
+		}
+	}
+	if err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{"job_id": id, "tenant_id": job.TenantID}).Error("tenant data job failed")
+		updates["status"] = model.TenantDataJobFailed
+		updates["error_message"] = err.Error()
+	}
+	if err := global.DB.WithContext(ctx).Model(&job).Updates(updates).Error; err != nil {
+		logrus.WithError(err).WithField("job_id", id).Error("tenant data job: save result failed")
+	}
+}
+
+// fileSHA256 文件大小与 SHA-256
+func fileSHA256(path string) (int64, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return 0, "", err
+	}
+	return n, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// exportTenantArchive 写出租户归档，返回文件路径与各表行数
+func exportTenantArchive(ctx context.Context, job *model.TenantDataJob) (string, map[string]int64, error) {
+	if err := os.MkdirAll(tenantArchiveDir, os.ModePerm); err != nil {
+		return "", nil, err
+	}
+	path := filepath.Join(tenantArchiveDir, fmt.Sprintf("tenant_%s_%s.zip", job.TenantID, time.Now().Format("20060102150405")))
+	f, err := os.Create(path)
+	if err != nil {
+		return "", nil, err
+	}
+	defer f.Close()
+
+	manifest := &tenantArchiveManifest{
+		Format:         tenantArchiveFormat,
+		FormatVersion:  tenantArchiveFormatVersion,
+		SchemaVersion:  global.VERSION_NUMBER,
+		SystemVersion:  global.SYSTEM_VERSION,
+		TenantID:       job.TenantID,
+		ExportedAt:     time.Now().UTC(),
+		TelemetryStart: job.TelemetryStart,
+		TelemetryEnd:   job.TelemetryEnd,
+	}
+	params := map[string]interface{}{"tenant": job.TenantID}
+	tables := append([]tenantArchiveTable{}, tenantArchiveTables...)
+	if job.TelemetryStart != nil && job.TelemetryEnd != nil {
+		params["start"] = job.TelemetryStart.UnixMilli()
+		params["end"] = job.TelemetryEnd.UnixMilli()
+		tables = append(tables, tenantArchiveTelemetry)
+	}
+
+	zw := zip.NewWriter(f)
+	summary := make(map[string]int64, len(tables)+1)
+	for _, t := range tables {
+		entry, err := exportTenantTable(ctx, zw, t, params)
+		if err != nil {
+			zw.Close()
+			os.Remove(path)
+			return "", nil, fmt.Errorf("export %s: %w", t.Name, err)
+		}
+		manifest.Tables = append(manifest.Tables, *entry)
+		summary[t.Name] = entry.Rows
+	}
+	entry, err := exportTenantCasbin(ctx, zw, job.TenantID)
+	if err != nil {
+		zw.Close()
+		os.Remove(path)
+		return "", nil, fmt.Errorf("export %s: %w", tenantArchiveCasbin, err)
+	}
+	manifest.Tables = append(manifest.Tables, *entry)
+	summary[tenantArchiveCasbin] = entry.Rows
+
+	w, err := zw.Create(tenantArchiveManifestFile)
+	if err == nil {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(manifest)
+	}
+	if err == nil {
+		err = zw.Close()
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", nil, err
+	}
+	return path, summary, nil
+}
+
+// exportTenantTable 逐行导出一张表（由数据库生成 JSON，保证类型可原样导入）
+func exportTenantTable(ctx context.Context, zw *zip.Writer, t tenantArchiveTable, params map[string]interface{}) (*tenantArchiveEntry, error) {
+	entry := &tenantArchiveEntry{Name: t.Name, File: "data/" + t.Name + ".jsonl"}
+	w, err := zw.Create(entry.File)
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	out := bufio.NewWriter(io.MultiWriter(w, h))
+
+	sel := "row_to_json(t)::text"
+	if len(t.Omit) > 0 {
+		sel = "(to_jsonb(t)"
+		for _, c := range t.Omit {
+			sel += " - '" + c + "'"
+		}
+		sel += ")::text"
+	}
+	rows, err := global.DB.WithContext(ctx).
+		Raw(fmt.Sprintf("SELECT %s FROM %s t WHERE %s", sel, quoteIdent(t.Name), t.Where), params).
+		Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, err
+		}
+		out.WriteString(line)
+		out.WriteByte('\n')
+		entry.Rows++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := out.Flush(); err != nil {
+		return nil, err
+	}
+	entry.SHA256 = hex.EncodeToString(h.Sum(nil))
+	return entry, nil
+}
+
+// exportTenantCasbin 导出租户角色的权限与租户用户的角色
+func exportTenantCasbin(ctx context.Context, zw *zip.Writer, tenantID string) (*tenantArchiveEntry, error) {
+	var roleIDs, userIDs []string
+	if err := global.DB.WithContext(ctx).Table("roles").Where("tenant_id = ?", tenantID).Pluck("id", &roleIDs).Error; err != nil {
+		return nil, err
+	}
+	if err := global.DB.WithContext(ctx).Table("users").Where("tenant_id = ?", tenantID).Pluck("id", &userIDs).Error; err != nil {
+		return nil, err
+	}
+	rules := make([]tenantCasbinRule, 0)
+	for _, id := range roleIDs {
+		for _, p := range global.CasbinEnforcer.GetFilteredPolicy(0, id) {
+			rules = append(rules, tenantCasbinRule{PType: "p", Rule: p})
+		}
+	}
+	for _, id := range userIDs {
+		for _, g := range global.CasbinEnforcer.GetFilteredNamedGroupingPolicy("g", 0, id) {
+			rules = append(rules, tenantCasbinRule{PType: "g", Rule: g})
+		}
+	}
+
+	entry := &tenantArchiveEntry{Name: tenantArchiveCasbin, File: "data/" + tenantArchiveCasbin + ".jsonl"}
+	w, err := zw.Create(entry.File)
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	encoder := json.NewEncoder(io.MultiWriter(w, h))
+	for _, r := range rules {
+		if err := encoder.Encode(r); err != nil {
+			return nil, err
+		}
+		entry.Rows++
+	}
+	entry.SHA256 = hex.EncodeToString(h.Sum(nil))
+	return entry, nil
+}
+
+// readTenantArchiveManifest 读取归档清单
+func readTenantArchiveManifest(path string) (*tenantArchiveManifest, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+	defer zr.Close()
+	return readManifestFromZip(&zr.Reader)
+}
+
+func readManifestFromZip(zr *zip.Reader) (*tenantArchiveManifest, error) {
+	f, err := zr.Open(tenantArchiveManifestFile)
+	if err != nil {
+		return nil, fmt.Errorf("archive has no %s", tenantArchiveManifestFile)
+	}
+	defer f.Close()
+	var m tenantArchiveManifest
+	if err := json.NewDecoder(f).Decode(&m); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", tenantArchiveManifestFile, err)
+	}
+	return &m, nil
+}
+
+// verifyTenantArchiveEntry 校验数据文件摘要
+func verifyTenantArchiveEntry(zr *zip.Reader, e tenantArchiveEntry) error {
+	f, err := zr.Open(e.File)
+	if err != nil {
+		return fmt.Errorf("archive has no %s", e.File)
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	if hex.EncodeToString(h.Sum(nil)) != e.SHA256 {
+		return fmt.Errorf("checksum mismatch for %s", e.File)
+	}
+	return nil
+}
+
+// importTenantArchive 导入租户归档：校验全部摘要后在一个事务中按清单顺序写入，目标租户已有的记录跳过；最后写入角色权限。
+// 导入到其他租户时保留原记录ID，与本实例已有记录冲突（如源租户仍在本实例）则整体失败回滚
+func importTenantArchive(ctx context.Context, job *model.TenantDataJob) (map[string]tenantImportResult, error) {
+	if job.FilePath == nil {
+		return nil, fmt.Errorf("import job has no archive file")
+	}
+	zr, err := zip.OpenReader(*job.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+	defer zr.Close()
+	manifest, err := readManifestFromZip(&zr.Reader)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTenantArchiveManifest(manifest, global.VERSION_NUMBER); err != nil {
+		return nil, err
+	}
+	for _, e := range manifest.Tables {
+		if err := verifyTenantArchiveEntry(&zr.Reader, e); err != nil {
+			return nil, err
+		}
+	}
+
+	// 归档不含密码，导入的用户需重置密码后登录
+	defaults := map[string]map[string]json.RawMessage{}
+	if hash := utils.BcryptHash(uuid.New()); hash != "" {
+		b, _ := json.Marshal(hash)
+		defaults["users"] = map[string]json.RawMessage{"password": b}
+	}
+
+	retarget := manifest.TenantID != job.TenantID
+	summary := make(map[string]tenantImportResult, len(manifest.Tables))
+	var casbinEntry *tenantArchiveEntry
+	err = global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i := range manifest.Tables {
+			e := manifest.Tables[i]
+			if e.Name == tenantArchiveCasbin {
+				casbinEntry = &e
+				continue
+			}
+			res, err := importTenantTable(tx, &zr.Reader, e, job.TenantID, retarget, defaults[e.Name])
+			if err != nil {
+				return fmt.Errorf("import %s: %w", e.Name, err)
+			}
+			summary[e.Name] = *res
+		}
+		return nil
+	})
+	if err != nil {
+		// 已回滚，不返回部分结果
+		return nil, err
+	}
+	if casbinEntry != nil {
+		res, err := importTenantCasbin(&zr.Reader, *casbinEntry)
+		if err != nil {
+			return summary, fmt.Errorf("import %s: %w", tenantArchiveCasbin, err)
+		}
+		summary[tenantArchiveCasbin] = *res
+	}
+	return summary, nil
+}
+
+// importTenantTable 分批写入一张表，列以目标库为准（兼容低版本归档缺少的列）
+func importTenantTable(tx *gorm.DB, zr *zip.Reader, e tenantArchiveEntry, tenantID string, retarget bool, defaults map[string]json.RawMessage) (*tenantImportResult, error) {
+	columns, err := tenantTableColumns(tx, e.Name)
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("table %s does not exist", e.Name)
+	}
+	f, err := zr.Open(e.File)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	res := &tenantImportResult{}
+	table := quoteIdent(e.Name)
+	var batchCols []string
+	batch := make([]map[string]json.RawMessage, 0, tenantImportBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		payload, err := json.Marshal(batch)
+		if err != nil {
+			return err
+		}
+		quoted := make([]string, len(batchCols))
+		for i, c := range batchCols {
+			quoted[i] = quoteIdent(c)
+		}
+		colList := strings.Join(quoted, ", ")
+		r := tx.Exec(fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM json_populate_recordset(NULL::%s, ?::json) ON CONFLICT DO NOTHING",
+			table, colList, colList, table), string(payload))
+		if r.Error != nil {
+			return r.Error
+		}
+		if conflicts := int64(len(batch)) - r.RowsAffected; conflicts > 0 {
+			if err := checkTenantImportConflicts(tx, e.Name, columns, batch, tenantID, retarget, conflicts); err != nil {
+				return err
+			}
+		}
+		res.Inserted += r.RowsAffected
+		res.Skipped += int64(len(batch)) - r.RowsAffected
+		batch = batch[:0]
+		return nil
+	}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 1024*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+		row, err := rewriteTenantArchiveRow(line, columns, tenantID, defaults)
+		if err != nil {
+			return nil, err
+		}
+		cols := sortedRowColumns(row)
+		if len(batch) > 0 && strings.Join(cols, ",") != strings.Join(batchCols, ",") {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+		batchCols = cols
+		batch = append(batch, row)
+		if len(batch) >= tenantImportBatchSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// checkTenantImportConflicts 冲突的行只允许是目标租户已有的同一记录（按 id 判断）；导入到其他租户时不允许任何冲突，
+// 否则子表会引用其他租户的记录
+func checkTenantImportConflicts(tx *gorm.DB, table string, columns map[string]bool, batch []map[string]json.RawMessage, tenantID string, retarget bool, conflicts int64) error {
+	if retarget {
+		return fmt.Errorf("%d rows conflict with existing records, the archive cannot be imported into another tenant on an instance that holds the source tenant's data", conflicts)
+	}
+	if !columns["id"] {
+		// 无 id 的关联表，冲突即同一关联
+		return nil
+	}
+	ids := make([]interface{}, 0, len(batch))
+	for _, row := range batch {
+		var id interface{}
+		if err := json.Unmarshal(row["id"], &id); err == nil && id != nil {
+			ids = append(ids, id)
+		}
+	}
+	query := tx.Table(table).Where("id IN ?", ids)
+	if columns["tenant_id"] {
+		query = query.Where("tenant_id = ?", tenantID)
+	}
+	var existing int64
+	if err := query.Count(&existing).Error; err != nil {
+		return err
+	}
+	if existing < int64(len(batch)) {
+		return fmt.Errorf("%d rows conflict with records outside the target tenant", int64(len(batch))-existing)
+	}
+	return nil
+}
+
+// importTenantCasbin 写入角色权限与用户角色，已存在的规则跳过
+func importTenantCasbin(zr *zip.Reader, e tenantArchiveEntry) (*tenantImportResult, error) {
+	f, err := zr.Open(e.File)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	res := &tenantImportResult{}
+	decoder := json.NewDecoder(f)
+	for decoder.More() {
+		var r tenantCasbinRule
+		if err := decoder.Decode(&r); err != nil {
+			return nil, err
+		}
+		var added bool
+		switch r.PType {
+		case "p":
+			added, err = global.CasbinEnforcer.AddNamedPolicy("p", r.Rule)
+		case "g":
+			added, err = global.CasbinEnforcer.AddNamedGroupingPolicy("g", r.Rule)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if added {
+			res.Inserted++
+		} else {
+			res.Skipped++
+		}
+	}
+	return res, nil
+}
+
+// tenantDataJobScope 任务可见范围：系统管理员可见全部，租户管理员可见本租户
+func tenantDataJobScope(db *gorm.DB, claims *utils.UserClaims) (*gorm.DB, error) {
+	switch claims.Authority {
+	case "SYS_ADMIN":
+		return db, nil
+	case "TENANT_ADMIN":
+		return db.Where("tenant_id = ?", claims.TenantID), nil
+	}
+	return nil, errcode.New(errcode.CodeNoPermission)
+}
+
+// GetJobListByPage 租户数据任务列表
+func (s *TenantData) GetJobListByPage(ctx context.Context, req *model.GetTenantDataJobListByPageReq, claims *utils.UserClaims) (map[string]interface{}, error) {
+	db, err := tenantDataJobScope(global.DB.WithContext(ctx).Model(&model.TenantDataJob{}), claims)
+	if err != nil {
+		return nil, err
+	}
+	if req.TenantID != nil {
+		db = db.Where("tenant_id = ?", *req.TenantID)
+	}
+	if req.JobType != nil {
+		db = db.Where("job_type = ?", *req.JobType)
+	}
+	if req.Status != nil {
+		db = db.Where("status = ?", *req.Status)
+	}
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	list := make([]model.TenantDataJob, 0)
+	if err := db.Order("created_at DESC").
+		Offset((req.Page - 1) * req.PageSize).
+		Limit(req.PageSize).
+		Find(&list).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return map[string]interface{}{"total": total, "list": list}, nil
+}
+
+// GetJob 租户数据任务详情
+func (s *TenantData) GetJob(ctx context.Context, id string, claims *utils.UserClaims) (*model.TenantDataJob, error) {
+	db, err := tenantDataJobScope(global.DB.WithContext(ctx), claims)
+	if err != nil {
+		return nil, err
+	}
+	var job model.TenantDataJob
+	err = db.Where("id = ?", id).First(&job).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, errcode.WithData(errcode.CodeNotFound, map[string]interface{}{"id": id, "error": "job not found"})
+	}
+	if err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return &job, nil
+}
+
+// GetExportFile 已完成导出任务的归档文件路径
+func (s *TenantData) GetExportFile(ctx context.Context, id string, claims *utils.UserClaims) (string, error) {
+	job, err := s.GetJob(ctx, id, claims)
+	if err != nil {
+		return "", err
+	}
+	if job.JobType != model.TenantDataJobExport || job.Status != model.TenantDataJobSucceeded || job.FilePath == nil {
+		return "", errcode.WithData(errcode.CodeParamError, map[string]interface{}{"id": id, "error": "export is not finished"})
+	}
+	if _, err := os.Stat(*job.FilePath); err != nil {
+		return "", errcode.WithData(errcode.CodeNotFound, map[string]interface{}{"id": id, "error": "archive file not found"})
+	}
+	return *job.FilePath, nil
+}
+
+// FailStaleJobsByCron 定时任务：服务重启会中断进行中的任务，超时未完成的任务置为失败，避免阻塞该租户的新任务
+func (s *TenantData) FailStaleJobsByCron() {
+	now := time.Now().UTC()
+	res := global.DB.Model(&model.TenantDataJob{}).
+		Where("(status = ? AND created_at < ?) OR (status = ? AND started_at < ?)",
+			model.TenantDataJobPending, now.Add(-10*time.Minute),
+			model.TenantDataJobRunning, now.Add(-6*time.Hour)).
+		Updates(map[string]interface{}{
+			"status":        model.TenantDataJobFailed,
+			"error_message": "job interrupted or timed out",
+			"finished_at":   now,
+		})
+	if res.Error != nil {
+		logrus.WithError(res.Error).Error("tenant data job: fail stale jobs failed")
+		return
+	}
+	if res.RowsAffected > 0 {
+		logrus.Warnf("tenant data job: %d stale jobs marked as failed", res.RowsAffected)
+	}
+}
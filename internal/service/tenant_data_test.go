@@ -0,0 +1,136 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCheckTenantArchiveManifest(t *testing.T) {
+	valid := func() *tenantArchiveManifest {
+		return &tenantArchiveManifest{
+			Format:        tenantArchiveFormat,
+			FormatVersion: tenantArchiveFormatVersion,
+			SchemaVersion: 53,
+			TenantID:      "t1",
+			Tables: []tenantArchiveEntry{
+				{Name: "devices"},
+				{Name: tenantArchiveTelemetry.Name},
+				{Name: tenantArchiveCasbin},
+			},
+		}
+	}
+
+	if err := checkTenantArchiveManifest(valid(), 53); err != nil {
+		t.Fatalf("valid manifest rejected: %v", err)
+	}
+
+	older := valid()
+	older.SchemaVersion = 40
+	if err := checkTenantArchiveManifest(older, 53); err != nil {
+		t.Errorf("older schema rejected: %v", err)
+	}
+
+	cases := map[string]func(m *tenantArchiveManifest){
+		"newer schema":  func(m *tenantArchiveManifest) { m.SchemaVersion = 54 },
+		"wrong format":  func(m *tenantArchiveManifest) { m.Format = "other" },
+		"wrong version": func(m *tenantArchiveManifest) { m.FormatVersion = 2 },
+		"no tenant":     func(m *tenantArchiveManifest) { m.TenantID = " " },
+		"unknown table": func(m *tenantArchiveManifest) { m.Tables = append(m.Tables, tenantArchiveEntry{Name: "sys_function"}) },
+	}
+	for name, mutate := range cases {
+		m := valid()
+		mutate(m)
+		if err := checkTenantArchiveManifest(m, 53); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestRewriteTenantArchiveRow(t *testing.T) {
+	columns := map[string]bool{"id": true, "tenant_id": true, "name": true, "password": true}
+	defaults := map[string]json.RawMessage{
+		"password": json.RawMessage(`"hashed"`),
+		"absent":   json.RawMessage(`1`),
+	}
+	row, err := rewriteTenantArchiveRow([]byte(`{"id":"d1","tenant_id":"src","name":"n","legacy":true}`), columns, "dst", defaults)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(row["tenant_id"]) != `"dst"` {
+		t.Errorf("tenant_id = %s; want \"dst\"", row["tenant_id"])
+	}
+	if _, ok := row["legacy"]; ok {
+		t.Error("column missing in target table was kept")
+	}
+	if string(row["password"]) != `"hashed"` {
+		t.Errorf("password default = %s", row["password"])
+	}
+	if _, ok := row["absent"]; ok {
+		t.Error("default for column missing in target table was added")
+	}
+	if got := sortedRowColumns(row); len(got) != 4 || got[0] != "id" || got[3] != "tenant_id" {
+		t.Errorf("sortedRowColumns = %v", got)
+	}
+
+	// 已有值不被默认值覆盖
+	row, err = rewriteTenantArchiveRow([]byte(`{"id":"u1","password":"keep"}`), columns, "dst", defaults)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(row["password"]) != `"keep"` {
+		t.Errorf("password = %s; want \"keep\"", row["password"])
+	}
+
+	if _, err := rewriteTenantArchiveRow([]byte(`not json`), columns, "dst", nil); err == nil {
+		t.Error("expected error for invalid row")
+	}
+}
+
+func TestTenantPurgePlan(t *testing.T) {
+	columns := map[string]map[string]bool{
+		"devices":            {"tenant_id": true},
+		"telemetry_datas":    {"tenant_id": true, "device_id": true},
+		"data_scripts":       {},
+		"device_batteries":   {"device_id": true},
+		"user_sessions":      {"user_id": true},
+		"device_audit_logs":  {"tenant_id": true, "device_id": true},
+		"tenant_offboarding": {},
+		"sys_dict":           {},
+	}
+	children, tenantTables := tenantPurgePlan(columns)
+
+	childWhere := make(map[string]string)
+	for _, c := range children {
+		childWhere[c.Name] = c.Where
+	}
+	want := map[string]string{
+		"data_scripts":     tenantConfigWhere,
+		"device_batteries": tenantDeviceWhere,
+		"telemetry_datas":  tenantDeviceWhere,
+		"user_sessions":    tenantPurgeUserWhere,
+	}
+	if len(childWhere) != len(want) {
+		t.Errorf("children = %v", childWhere)
+	}
+	for name, where := range want {
+		if childWhere[name] != where {
+			t.Errorf("child %s where = %q; want %q", name, childWhere[name], where)
+		}
+	}
+	if len(tenantTables) != 2 || tenantTables[0] != "devices" || tenantTables[1] != "telemetry_datas" {
+		t.Errorf("tenantTables = %v", tenantTables)
+	}
+}
+
+func TestCheckTenantImportConflictsRetarget(t *testing.T) {
+	columns := map[string]bool{"id": true, "tenant_id": true}
+	batch := []map[string]json.RawMessage{{"id": json.RawMessage(`"d1"`), "tenant_id": json.RawMessage(`"dst"`)}}
+	// 导入到其他租户时任何冲突都失败，不计为跳过
+	if err := checkTenantImportConflicts(nil, "devices", columns, batch, "dst", true, 1); err == nil {
+		t.Error("expected conflict error for retargeted import")
+	}
+	// 无 id 的关联表按同一关联跳过
+	if err := checkTenantImportConflicts(nil, "org_closure", map[string]bool{"tenant_id": true}, batch, "dst", false, 1); err != nil {
+		t.Errorf("link table conflict: %v", err)
+	}
+}
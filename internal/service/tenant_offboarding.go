@@ -0,0 +1,443 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"project/internal/model"
+	"project/pkg/errcode"
+	"project/pkg/global"
+	"project/pkg/utils"
+
+	"github.com/go-basic/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// TenantOffboarding 租户分阶段注销：冻结账号并进入保留期，保留期内可撤销，到期后由定时任务清除租户全部数据
+type TenantOffboarding struct{}
+
+// tenantOffboardingDefaultGraceDays 默认保留期（天）
+const tenantOffboardingDefaultGraceDays = 30
+
+// tenantPurgeStaleAfter 清除中断（如服务重启）后重新执行的等待时间
+const tenantPurgeStaleAfter = 6 * time.Hour
+
+// tenantPurgeExcluded 清除时保留的表：注销与迁移记录留档；设备审计日志只允许追加，单独清除
+var tenantPurgeExcluded = map[string]bool{
+	model.TableNameTenantOffboarding: true,
+	model.TableNameTenantDataJob:     true,
+	model.TableNameDeviceAuditLog:    true,
+}
+
+// tenantPurgeUserWhere 没有 tenant_id、按用户关联的表
+const tenantPurgeUserWhere = "user_id IN (SELECT id FROM users WHERE tenant_id = @tenant)"
+
+// tenantPurgePlan 确定清除顺序：先删没有 tenant_id 的子表（按父表、设备或用户关联），再删有 tenant_id 的表
+func tenantPurgePlan(tableColumns map[string]map[string]bool) ([]tenantArchiveTable, []string) {
+	names := make([]string, 0, len(tableColumns))
+	for name := range tableColumns {
+		if !tenantPurgeExcluded[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	explicit := make(map[string]string)
+	for _, t := range tenantArchiveTables {
+		if t.Where != tenantWhere {
+			explicit[t.Name] = t.Where
+		}
+	}
+
+	var children []tenantArchiveTable
+	var tenantTables []string
+	for _, name := range names {
+		cols := tableColumns[name]
+		switch {
+		case cols["tenant_id"]:
+			// 早期数据可能缺少 tenant_id，设备相关的表同时按设备清除
+			if cols["device_id"] {
+				children = append(children, tenantArchiveTable{Name: name, Where: tenantDeviceWhere})
+			}
+			tenantTables = append(tenantTables, name)
+		case explicit[name] != "":
+			children = append(children, tenantArchiveTable{Name: name, Where: explicit[name]})
+		case cols["device_id"]:
+			children = append(children, tenantArchiveTable{Name: name, Where: tenantDeviceWhere})
+		case cols["user_id"]:
+			children = append(children, tenantArchiveTable{Name: name, Where: tenantPurgeUserWhere})
+		}
+	}
+	return children, tenantTables
+}
+
+// tenantOffboardingActive 租户是否处于注销流程中（保留期、清除中或清除失败待重试）
+func tenantOffboardingActive(ctx context.Context, tenantID string) (bool, error) {
+	var count int64
+	err := global.DB.WithContext(ctx).Model(&model.TenantOffboarding{}).
+		Where("tenant_id = ? AND status IN ?", tenantID, []string{model.TenantOffboardingScheduled, model.TenantOffboardingPurging, model.TenantOffboardingFailed}).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// requireSysAdmin 租户注销仅系统管理员可操作
+func requireSysAdmin(claims *utils.UserClaims) error {
+	if claims.Authority != "SYS_ADMIN" {
+		return errcode.WithVars(errcode.CodeNoPermission, map[string]interface{}{
+			"required_role": "SYS_ADMIN",
+			"current_role":  claims.Authority,
+		})
+	}
+	return nil
+}
+
+// Schedule 发起租户注销：冻结租户全部账号、撤销登录会话、停用 API Key，保留期后清除数据
+func (s *TenantOffboarding) Schedule(ctx context.Context, claims *utils.UserClaims, req *model.TenantOffboardingReq) (*model.TenantOffboarding, error) {
+	if err := requireSysAdmin(claims); err != nil {
+		return nil, err
+	}
+	tenantID := strings.TrimSpace(req.TenantID)
+	if tenantID == claims.TenantID {
+		return nil, errcode.WithVars(errcode.CodeOpDenied, map[string]interface{}{"reason": "cannot_offboard_own_tenant"})
+	}
+	var users int64
+	if err := global.DB.WithContext(ctx).Table("users").Where("tenant_id = ?", tenantID).Count(&users).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	if users == 0 {
+		return nil, errcode.WithData(errcode.CodeNotFound, map[string]interface{}{"tenant_id": tenantID, "error": "tenant not found"})
+	}
+	if active, err := tenantOffboardingActive(ctx, tenantID); err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	} else if active {
+		return nil, errcode.WithData(errcode.CodeParamError, map[string]interface{}{"tenant_id": tenantID, "error": "tenant offboarding already in progress"})
+	}
+
+	graceDays := req.GraceDays
+	if graceDays <= 0 {
+		graceDays = tenantOffboardingDefaultGraceDays
+	}
+	now := time.Now().UTC()
+	row := &model.TenantOffboarding{
+		ID:          uuid.New(),
+		TenantID:    tenantID,
+		Status:      model.TenantOffboardingScheduled,
+		Reason:      req.Reason,
+		RequestedBy: claims.ID,
+		PurgeAfter:  now.AddDate(0, 0, graceDays),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	var userIDs, keyIDs []string
+	err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table("users").
+			Where("tenant_id = ? AND (status IS NULL OR status <> 'F')", tenantID).
+			Pluck("id", &userIDs).Error; err != nil {
+			return err
+		}
+		if len(userIDs) > 0 {
+			if err := tx.Table("users").Where("id IN ?", userIDs).
+				Updates(map[string]interface{}{"status": "F", "updated_at": now}).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&model.OpenAPIKey{}).
+			Where("tenant_id = ? AND status = ?", tenantID, 1).
+			Pluck("id", &keyIDs).Error; err != nil {
+			return err
+		}
+		if len(keyIDs) > 0 {
+			if err := tx.Model(&model.OpenAPIKey{}).Where("id IN ?", keyIDs).
+				Updates(map[string]interface{}{"status": 0, "updated_at": now}).Error; err != nil {
+				return err
+			}
+		}
+		row.FrozenUserIDs = approvalJSONList(userIDs)
+		row.DisabledAPIKeyIDs = approvalJSONList(keyIDs)
+		return tx.Create(row).Error
+	})
+	if err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+
+	// 冻结只阻止登录与刷新，已签发的令牌通过撤销会话立即失效
+	for _, id := range userIDs {
+		if _, err := revokeUserSessions(ctx, id, "", "tenant_offboarding"); err != nil {
+			logrus.WithError(err).WithField("user_id", id).Warn("tenant offboarding: revoke sessions failed")
+		}
+	}
+	invalidateOpenAPIKeyCaches(ctx, keyIDs)
+	return row, nil
+}
+
+// invalidateOpenAPIKeyCaches 清理一组 API Key 的鉴权缓存
+func invalidateOpenAPIKeyCaches(ctx context.Context, ids []string) {
+	if len(ids) == 0 {
+		return
+	}
+	var keys []model.OpenAPIKey
+	if err := global.DB.WithContext(ctx).Where("id IN ?", ids).Find(&keys).Error; err != nil {
+		logrus.WithError(err).Warn("tenant offboarding: load api keys failed")
+		return
+	}
+	for i := range keys {
+		invalidateOpenAPIKeyCache(&keys[i])
+	}
+}
+
+// Cancel 保留期内撤销注销，恢复注销时冻结的账号与停用的 API Key
+func (s *TenantOffboarding) Cancel(ctx context.Context, claims *utils.UserClaims, id string) (*model.TenantOffboarding, error) {
+	if err := requireSysAdmin(claims); err != nil {
+		return nil, err
+	}
+	var row model.TenantOffboarding
+	err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", id).First(&row).Error; err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		res := tx.Model(&model.TenantOffboarding{}).
+			Where("id = ? AND status = ?", id, model.TenantOffboardingScheduled).
+			Updates(map[string]interface{}{
+				"status":       model.TenantOffboardingCancelled,
+				"cancelled_by": claims.ID,
+				"updated_at":   now,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errcode.WithData(errcode.CodeParamError, map[string]interface{}{
+				"id":     id,
+				"status": row.Status,
+				"error":  "only scheduled offboarding can be cancelled",
+			})
+		}
+		if ids := approvalStringList(row.FrozenUserIDs); len(ids) > 0 {
+			if err := tx.Table("users").Where("id IN ? AND status = 'F'", ids).
+				Updates(map[string]interface{}{"status": "N", "updated_at": now}).Error; err != nil {
+				return err
+			}
+		}
+		if ids := approvalStringList(row.DisabledAPIKeyIDs); len(ids) > 0 {
+			if err := tx.Model(&model.OpenAPIKey{}).Where("id IN ? AND status = ?", ids, 0).
+				Updates(map[string]interface{}{"status": 1, "updated_at": now}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == gorm.ErrRecordNotFound {
+		return nil, errcode.WithData(errcode.CodeNotFound, map[string]interface{}{"id": id, "error": "offboarding not found"})
+	}
+	if err != nil {
+		if _, ok := err.(*errcode.Error); ok {
+			return nil, err
+		}
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	invalidateOpenAPIKeyCaches(ctx, approvalStringList(row.DisabledAPIKeyIDs))
+
+	if err := global.DB.WithContext(ctx).Where("id = ?", id).First(&row).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return &row, nil
+}
+
+// GetListByPage 租户注销记录
+func (s *TenantOffboarding) GetListByPage(ctx context.Context, claims *utils.UserClaims, req *model.GetTenantOffboardingListReq) (map[string]interface{}, error) {
+	if err := requireSysAdmin(claims); err != nil {
+		return nil, err
+	}
+	db := global.DB.WithContext(ctx).Model(&model.TenantOffboarding{})
+	if req.TenantID != nil {
+		db = db.Where("tenant_id = ?", *req.TenantID)
+	}
+	if req.Status != nil {
+		db = db.Where("status = ?", *req.Status)
+	}
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	list := make([]model.TenantOffboarding, 0)
+	if err := db.Order("created_at DESC").
+		Offset((req.Page - 1) * req.PageSize).
+		Limit(req.PageSize).
+		Find(&list).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return map[string]interface{}{"total": total, "list": list}, nil
+}
+
+// PurgeByCron 定时任务：保留期已过的租户清除数据；清除失败或中断的租户下次重试（清除可重复执行）
+func (s *TenantOffboarding) PurgeByCron() {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	var rows []model.TenantOffboarding
+	if err := global.DB.WithContext(ctx).
+		Where("purge_after <= ?", now).
+		Where("status IN ? OR (status = ? AND updated_at < ?)",
+			[]string{model.TenantOffboardingScheduled, model.TenantOffboardingFailed},
+			model.TenantOffboardingPurging, now.Add(-tenantPurgeStaleAfter)).
+		Find(&rows).Error; err != nil {
+		logrus.WithError(err).Error("tenant purge: query failed")
+		return
+	}
+
+	for _, row := range rows {
+		// 多实例部署时只有抢到更新的实例执行清除
+		res := global.DB.WithContext(ctx).Model(&model.TenantOffboarding{}).
+			Where("id = ? AND status = ? AND updated_at = ?", row.ID, row.Status, row.UpdatedAt).
+			Updates(map[string]interface{}{"status": model.TenantOffboardingPurging, "updated_at": time.Now().UTC()})
+		if res.Error != nil || res.RowsAffected == 0 {
+			continue
+		}
+
+		summary, err := purgeTenantData(ctx, row.TenantID)
+		updates := map[string]interface{}{
+			"status":     model.TenantOffboardingPurged,
+			"updated_at": time.Now().UTC(),
+		}
+		if b, mErr := json.Marshal(summary); mErr == nil {
+			updates["summary"] = string(b)
+		}
+		if err != nil {
+			logrus.WithError(err).WithField("tenant_id", row.TenantID).Error("tenant purge failed")
+			updates["status"] = model.TenantOffboardingFailed
+			updates["error_message"] = err.Error()
+		} else {
+			updates["purged_at"] = time.Now().UTC()
+			updates["error_message"] = nil
+			logrus.WithField("tenant_id", row.TenantID).Info("tenant data purged")
+		}
+		if err := global.DB.WithContext(ctx).Model(&model.TenantOffboarding{}).Where("id = ?", row.ID).
+			Updates(updates).Error; err != nil {
+			logrus.WithError(err).WithField("tenant_id", row.TenantID).Error("tenant purge: save result failed")
+		}
+	}
+}
+
+// purgeTenantData 清除租户全部数据，返回各表删除行数
+func purgeTenantData(ctx context.Context, tenantID string) (map[string]int64, error) {
+	db := global.DB.WithContext(ctx)
+	summary := make(map[string]int64)
+
+	var tableColumns []struct {
+		TableName  string `gorm:"column:table_name"`
+		ColumnName string `gorm:"column:column_name"`
+	}
+	if err := db.Raw(`SELECT c.table_name, c.column_name FROM information_schema.columns c
+		JOIN information_schema.tables t ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+		WHERE c.table_schema = 'public' AND t.table_type = 'BASE TABLE'
+		AND c.column_name IN ('tenant_id', 'device_id', 'user_id')`).Scan(&tableColumns).Error; err != nil {
+		return summary, err
+	}
+	columns := make(map[string]map[string]bool)
+	for _, tc := range tableColumns {
+		if columns[tc.TableName] == nil {
+			columns[tc.TableName] = make(map[string]bool)
+		}
+		columns[tc.TableName][tc.ColumnName] = true
+	}
+	for _, t := range tenantArchiveTables {
+		if columns[t.Name] == nil {
+			columns[t.Name] = make(map[string]bool)
+		}
+	}
+	children, tenantTables := tenantPurgePlan(columns)
+
+	// 角色权限与用户角色
+	var roleIDs, userIDs []string
+	if err := db.Table("roles").Where("tenant_id = ?", tenantID).Pluck("id", &roleIDs).Error; err != nil {
+		return summary, err
+	}
+	if err := db.Table("users").Where("tenant_id = ?", tenantID).Pluck("id", &userIDs).Error; err != nil {
+		return summary, err
+	}
+	for _, id := range roleIDs {
+		if _, err := global.CasbinEnforcer.RemoveFilteredPolicy(0, id); err != nil {
+			return summary, fmt.Errorf("remove role policies: %w", err)
+		}
+	}
+	for _, id := range userIDs {
+		if _, err := global.CasbinEnforcer.RemoveFilteredNamedGroupingPolicy("g", 0, id); err != nil {
+			return summary, fmt.Errorf("remove user roles: %w", err)
+		}
+	}
+
+	// 设备审计日志只允许追加，在事务内声明清除的租户后删除
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT set_config('app.tenant_purge', ?, true)", tenantID).Error; err != nil {
+			return err
+		}
+		res := tx.Where("tenant_id = ?", tenantID).Delete(&model.DeviceAuditLog{})
+		summary[model.TableNameDeviceAuditLog] = res.RowsAffected
+		return res.Error
+	})
+	if err != nil {
+		return summary, fmt.Errorf("purge %s: %w", model.TableNameDeviceAuditLog, err)
+	}
+
+	params := map[string]interface{}{"tenant": tenantID}
+	childStmts := make([]tenantPurgeStmt, 0, len(children))
+	for _, t := range children {
+		childStmts = append(childStmts, tenantPurgeStmt{Table: t.Name, SQL: fmt.Sprintf("DELETE FROM %s WHERE %s", quoteIdent(t.Name), t.Where), Args: []interface{}{params}})
+	}
+	if err := execTenantPurge(db, childStmts, summary); err != nil {
+		return summary, err
+	}
+	tenantStmts := make([]tenantPurgeStmt, 0, len(tenantTables))
+	for _, name := range tenantTables {
+		tenantStmts = append(tenantStmts, tenantPurgeStmt{Table: name, SQL: fmt.Sprintf("DELETE FROM %s WHERE tenant_id = ?", quoteIdent(name)), Args: []interface{}{tenantID}})
+	}
+	if err := execTenantPurge(db, tenantStmts, summary); err != nil {
+		return summary, err
+	}
+	return summary, nil
+}
+
+// tenantPurgeStmt 一条清除语句
+type tenantPurgeStmt struct {
+	Table string
+	SQL   string
+	Args  []interface{}
+}
+
+// execTenantPurge 执行清除语句：表之间的外键顺序不固定，失败的语句在其他语句执行后重试，直到没有进展
+func execTenantPurge(db *gorm.DB, stmts []tenantPurgeStmt, summary map[string]int64) error {
+	remaining := stmts
+	failures := make(map[string]error)
+	for len(remaining) > 0 {
+		var failed []tenantPurgeStmt
+		for _, st := range remaining {
+			res := db.Exec(st.SQL, st.Args...)
+			if res.Error != nil {
+				failed = append(failed, st)
+				failures[st.Table] = res.Error
+				continue
+			}
+			summary[st.Table] += res.RowsAffected
+			delete(failures, st.Table)
+		}
+		if len(failed) == len(remaining) {
+			break
+		}
+		remaining = failed
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	msgs := make([]string, 0, len(failures))
+	for name, e := range failures {
+		msgs = append(msgs, name+": "+e.Error())
+	}
+	sort.Strings(msgs)
+	return fmt.Errorf("purge failed for %d tables: %s", len(failures), strings.Join(msgs, "; "))
+}
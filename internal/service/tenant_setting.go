@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"project/internal/model"
+	"project/pkg/errcode"
+	"project/pkg/global"
+	"project/pkg/utils"
+
+	"gorm.io/gorm/clause"
+)
+
+// TenantSetting 租户设置（时区、双因素认证策略等）
+type TenantSetting struct{}
+
+// Get 查询租户设置；SYS_ADMIN 需指定 tenant_id
+func (*TenantSetting) Get(ctx context.Context, claims *utils.UserClaims, tenantID string) (*model.TenantSettingResp, error) {
+	resolvedTenantID := claims.TenantID
+	if claims.Authority == "SYS_ADMIN" {
+		if strings.TrimSpace(tenantID) == "" {
+			return nil, errcode.WithData(errcode.CodeParamError, map[string]interface{}{
+				"tenant_id": "tenant_id is required for SYS_ADMIN",
+			})
+		}
+		resolvedTenantID = strings.TrimSpace(tenantID)
+	}
+
+	var rows []model.TenantSetting
+	if err := global.DB.WithContext(ctx).
+		Where("tenant_id = ?", resolvedTenantID).
+		Limit(1).
+		Find(&rows).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	resp := &model.TenantSettingResp{TenantID: resolvedTenantID}
+	if len(rows) > 0 {
+		resp.Timezone = rows[0].Timezone
+		resp.MfaEnforced = rows[0].MfaEnforced
+	}
+	return resp, nil
+}
+
+// Update 更新租户设置（租户管理员/系统管理员）
+func (s *TenantSetting) Update(ctx context.Context, claims *utils.UserClaims, tenantID string, req *model.TenantSettingUpdateReq) (*model.TenantSettingResp, error) {
+	resolvedTenantID, err := GroupApp.OrgTypePermission.resolveTenantID(claims, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	// 仅更新请求中出现的字段
+	now := time.Now().UTC()
+	row := &model.TenantSetting{
+		TenantID:  resolvedTenantID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	columns := []string{"updated_at"}
+	if req.Timezone != nil {
+		row.Timezone = emptyToNil(req.Timezone)
+		if row.Timezone != nil {
+			if _, err := parseTimezone(*row.Timezone); err != nil {
+				return nil, err
+			}
+		}
+		columns = append(columns, "timezone")
+	}
+	if req.MfaEnforced != nil {
+		row.MfaEnforced = *req.MfaEnforced
+		columns = append(columns, "mfa_enforced")
+	}
+
+	if err := global.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}},
+		DoUpdates: clause.AssignmentColumns(columns),
+	}).Create(row).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	if req.Timezone != nil {
+		invalidateAutomateZoneCache()
+	}
+
+	var saved model.TenantSetting
+	if err := global.DB.WithContext(ctx).Where("tenant_id = ?", resolvedTenantID).First(&saved).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return &model.TenantSettingResp{TenantID: resolvedTenantID, Timezone: saved.Timezone, MfaEnforced: saved.MfaEnforced}, nil
+}
@@ -0,0 +1,863 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	dal "project/internal/dal"
+	model "project/internal/model"
+	"project/pkg/errcode"
+	global "project/pkg/global"
+	utils "project/pkg/utils"
+
+	"github.com/go-basic/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UserMfa 控制台账号双因素认证（TOTP）、恢复码与受信任设备
+type UserMfa struct{}
+
+const (
+	totpPeriod = 30 // 时间步长（秒）
+	totpDigits = 6
+	totpSkew   = 1 // 允许前后各一个时间步的时钟偏差
+
+	mfaRecoveryCodeCount    = 10
+	mfaRecoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789" // 去掉易混淆的 i l o 0 1
+	mfaChallengeTTL         = 5 * time.Minute
+	mfaChallengeMaxFails    = 5 // 同一临时令牌允许的错误次数
+	mfaDefaultIssuer        = "IoT Platform"
+)
+
+// 审计事件，记录在 operation_logs.name
+const (
+	mfaEventEnabled           = "MFA_ENABLED"
+	mfaEventDisabled          = "MFA_DISABLED"
+	mfaEventReset             = "MFA_RESET"
+	mfaEventRecoveryRegen     = "MFA_RECOVERY_CODES_REGENERATED"
+	mfaEventLoginVerified     = "MFA_LOGIN_VERIFIED"
+	mfaEventLoginFailed       = "MFA_LOGIN_FAILED"
+	mfaEventTrustedDeviceAdd  = "MFA_TRUSTED_DEVICE_ADDED"
+	mfaEventTrustedDeviceUsed = "MFA_TRUSTED_DEVICE_USED"
+	mfaEventTrustedDeviceDel  = "MFA_TRUSTED_DEVICE_REVOKED"
+)
+
+// mfaChallenge 密码校验通过、二次验证完成前的登录状态
+type mfaChallenge struct {
+	UserID string       `json:"user_id"`
+	Setup  bool         `json:"setup"`          // 租户强制启用但尚未绑定
+	Oidc   *oidcSession `json:"oidc,omitempty"` // 单点登录发起，验证完成后登记单点登录会话
+}
+
+func mfaChallengeKey(token string) string {
+	return "mfa:challenge:" + token
+}
+
+func mfaChallengeFailKey(token string) string {
+	return "mfa:challenge:" + token + ":fails"
+}
+
+// randomURLToken 随机令牌（URL 安全），用于临时令牌、受信任设备与刷新令牌
+func randomURLToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// sha256Hex 恢复码、受信任设备与刷新令牌只保存摘要
+func sha256Hex(v string) string {
+	sum := sha256.Sum256([]byte(v))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateTotpSecret 160 位随机密钥（Base32，无填充）
+func generateTotpSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b), nil
+}
+
+func decodeTotpSecret(secret string) ([]byte, error) {
+	s := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(secret), " ", ""))
+	return base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.TrimRight(s, "="))
+}
+
+// totpCode RFC 6238（HMAC-SHA1）指定时间步的验证码
+func totpCode(key []byte, step int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	v := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", v%1000000)
+}
+
+// verifyTotp 校验验证码并返回匹配的时间步；不晚于 lastStep 的时间步视为重放
+func verifyTotp(secret, code string, now time.Time, lastStep int64) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != totpDigits {
+		return 0, false
+	}
+	key, err := decodeTotpSecret(secret)
+	if err != nil || len(key) == 0 {
+		return 0, false
+	}
+	current := now.Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= lastStep {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// totpOtpauthURL 身份验证器App扫码绑定的地址
+func totpOtpauthURL(issuer, account, secret string) string {
+	q := url.Values{}
+	q.Set("secret", secret)
+	q.Set("issuer", issuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", strconv.Itoa(totpDigits))
+	q.Set("period", strconv.Itoa(totpPeriod))
+	return "otpauth://totp/" + url.PathEscape(issuer+":"+account) + "?" + q.Encode()
+}
+
+// normalizeRecoveryCode 忽略大小写、空格与连字符
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// generateRecoveryCodes 生成恢复码，返回明文（xxxxx-xxxxx）与摘要 JSON
+func generateRecoveryCodes(n int) ([]string, string, error) {
+	codes := make([]string, 0, n)
+	hashes := make([]string, 0, n)
+	max := big.NewInt(int64(len(mfaRecoveryCodeAlphabet)))
+	for i := 0; i < n; i++ {
+		var b strings.Builder
+		for j := 0; j < 10; j++ {
+			if j == 5 {
+				b.WriteByte('-')
+			}
+			idx, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return nil, "", err
+			}
+			b.WriteByte(mfaRecoveryCodeAlphabet[idx.Int64()])
+		}
+		codes = append(codes, b.String())
+		hashes = append(hashes, sha256Hex(normalizeRecoveryCode(b.String())))
+	}
+	raw, err := json.Marshal(hashes)
+	if err != nil {
+		return nil, "", err
+	}
+	return codes, string(raw), nil
+}
+
+// consumeRecoveryCode 匹配恢复码，成功时返回移除该码后的摘要 JSON
+func consumeRecoveryCode(stored, code string) (string, bool) {
+	var hashes []string
+	if err := json.Unmarshal([]byte(stored), &hashes); err != nil {
+		return stored, false
+	}
+	h := sha256Hex(normalizeRecoveryCode(code))
+	for i, v := range hashes {
+		if subtle.ConstantTimeCompare([]byte(v), []byte(h)) == 1 {
+			rest := append(hashes[:i:i], hashes[i+1:]...)
+			raw, err := json.Marshal(rest)
+			if err != nil {
+				return stored, false
+			}
+			return string(raw), true
+		}
+	}
+	return stored, false
+}
+
+func countRecoveryCodes(stored string) int {
+	var hashes []string
+	if err := json.Unmarshal([]byte(stored), &hashes); err != nil {
+		return 0
+	}
+	return len(hashes)
+}
+
+func mfaIssuer() string {
+	if v := strings.TrimSpace(viper.GetString("mfa.issuer")); v != "" {
+		return v
+	}
+	return mfaDefaultIssuer
+}
+
+// mfaTrustedDeviceTTL 受信任设备有效期，0 表示不允许信任设备
+func mfaTrustedDeviceTTL() time.Duration {
+	days := 30
+	if viper.IsSet("mfa.trusted_device_days") {
+		days = viper.GetInt("mfa.trusted_device_days")
+	}
+	if days <= 0 {
+		return 0
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// writeSecurityAuditLog 记录安全事件（双因素认证、会话）到操作日志；登录阶段尚无 claims，操作日志中间件不会记录
+func writeSecurityAuditLog(ctx context.Context, meta *model.RequestMeta, actorID, tenantID, event string, detail map[string]interface{}) {
+	if meta == nil {
+		meta = &model.RequestMeta{}
+	}
+	raw, _ := json.Marshal(detail)
+	request := string(raw)
+	path := meta.Path
+	userAgent := meta.UserAgent
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+	log := &model.OperationLog{
+		ID:             uuid.New(),
+		IP:             meta.IP,
+		Path:           &path,
+		UserID:         actorID,
+		Name:           &event,
+		CreatedAt:      time.Now().UTC(),
+		RequestMessage: &request,
+		TenantID:       tenantID,
+		Remark:         &userAgent,
+	}
+	if err := global.DB.WithContext(ctx).Create(log).Error; err != nil {
+		logrus.WithError(err).WithField("event", event).Error("failed to write mfa audit log")
+	}
+}
+
+func userTenantID(user *model.User) string {
+	if user.TenantID == nil {
+		return ""
+	}
+	return *user.TenantID
+}
+
+// getUserMfa 查询双因素认证记录，不存在返回 nil
+func getUserMfa(ctx context.Context, userID string) (*model.UserMfa, error) {
+	var rows []model.UserMfa
+	if err := global.DB.WithContext(ctx).Where("user_id = ?", userID).Limit(1).Find(&rows).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return &rows[0], nil
+}
+
+// mfaEnforced 租户是否强制该账号启用：租户管理员与组织用户（经销商等业务账号），终端用户不受影响
+func mfaEnforced(ctx context.Context, user *model.User) (bool, error) {
+	tenantID := userTenantID(user)
+	if tenantID == "" {
+		return false, nil
+	}
+	isAdmin := user.Authority != nil && *user.Authority == "TENANT_ADMIN"
+	isOrgUser := user.UserKind != nil && *user.UserKind == model.UserKindOrgUser
+	if !isAdmin && !isOrgUser {
+		return false, nil
+	}
+	var rows []model.TenantSetting
+	if err := global.DB.WithContext(ctx).Where("tenant_id = ?", tenantID).Limit(1).Find(&rows).Error; err != nil {
+		return false, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return len(rows) > 0 && rows[0].MfaEnforced, nil
+}
+
+// LoginChallenge 密码校验通过后判断是否需要二次验证；需要时返回携带临时令牌的响应，否则返回 nil 继续签发登录凭证
+func (*UserMfa) LoginChallenge(ctx context.Context, user *model.User, trustedDeviceToken *string, meta *model.RequestMeta) (*model.LoginRsp, error) {
+	return loginChallenge(ctx, user, trustedDeviceToken, meta, nil)
+}
+
+// loginChallenge 同 LoginChallenge；sso 非空时为单点登录，二次验证完成后按其登记单点登录会话
+func loginChallenge(ctx context.Context, user *model.User, trustedDeviceToken *string, meta *model.RequestMeta, sso *oidcSession) (*model.LoginRsp, error) {
+	m, err := getUserMfa(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	enabled := m != nil && m.Enabled
+	if !enabled {
+		enforced, err := mfaEnforced(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		if !enforced {
+			return nil, nil
+		}
+	}
+
+	if enabled && trustedDeviceToken != nil && *trustedDeviceToken != "" {
+		if device := useTrustedDevice(ctx, user.ID, *trustedDeviceToken); device != nil {
+			writeSecurityAuditLog(ctx, meta, user.ID, userTenantID(user), mfaEventTrustedDeviceUsed, map[string]interface{}{"device_id": device.ID})
+			return nil, nil
+		}
+	}
+
+	token, err := randomURLToken()
+	if err != nil {
+		return nil, errcode.WithData(errcode.CodeSystemError, map[string]interface{}{"error": err.Error()})
+	}
+	raw, _ := json.Marshal(mfaChallenge{UserID: user.ID, Setup: !enabled, Oidc: sso})
+	if err := global.REDIS.Set(ctx, mfaChallengeKey(token), raw, mfaChallengeTTL).Err(); err != nil {
+		return nil, errcode.WithData(errcode.CodeSystemError, map[string]interface{}{"error": err.Error()})
+	}
+	return &model.LoginRsp{
+		MfaRequired:      enabled,
+		MfaSetupRequired: !enabled,
+		MfaToken:         &token,
+		ExpiresIn:        int64(mfaChallengeTTL / time.Second),
+	}, nil
+}
+
+// loadMfaChallenge 读取临时令牌对应的用户
+func loadMfaChallenge(ctx context.Context, token string, setup bool) (*model.User, error) {
+	raw, err := global.REDIS.Get(ctx, mfaChallengeKey(token)).Result()
+	if err != nil {
+		return nil, errcode.New(errcode.CodeMfaTokenExpired)
+	}
+	var ch mfaChallenge
+	if err := json.Unmarshal([]byte(raw), &ch); err != nil || ch.Setup != setup {
+		return nil, errcode.New(errcode.CodeMfaTokenExpired)
+	}
+	user, err := dal.GetUsersById(ch.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errcode.New(errcode.CodeMfaTokenExpired)
+		}
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	if user.Status == nil || *user.Status != "N" {
+		return nil, errcode.New(errcode.CodeUserDisabled)
+	}
+	lock := NewLoginLock()
+	if lock.MaxFailedAttempts > 0 {
+		if err := lock.GetAllowLogin(ctx, user.Email); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+// failMfaChallenge 验证失败：累计错误次数，超过上限作废临时令牌，并计入账号登录失败次数
+func failMfaChallenge(ctx context.Context, token string, user *model.User) {
+	fails, err := global.REDIS.Incr(ctx, mfaChallengeFailKey(token)).Result()
+	if err == nil {
+		global.REDIS.Expire(ctx, mfaChallengeFailKey(token), mfaChallengeTTL)
+	}
+	if err != nil || fails >= mfaChallengeMaxFails {
+		global.REDIS.Del(ctx, mfaChallengeKey(token), mfaChallengeFailKey(token))
+	}
+	lock := NewLoginLock()
+	if lock.MaxFailedAttempts > 0 {
+		_ = lock.LoginFail(ctx, user.Email)
+	}
+}
+
+// completeMfaLogin 二次验证通过后签发登录凭证，按需信任当前浏览器
+func completeMfaLogin(ctx context.Context, token string, user *model.User, remember bool, deviceName *string, meta *model.RequestMeta) (*model.LoginRsp, error) {
+	// 取出即作废，并发请求只有一个能签发
+	raw, err := global.REDIS.GetDel(ctx, mfaChallengeKey(token)).Result()
+	global.REDIS.Del(ctx, mfaChallengeFailKey(token))
+	if err != nil {
+		return nil, errcode.New(errcode.CodeMfaTokenExpired)
+	}
+	var ch mfaChallenge
+	_ = json.Unmarshal([]byte(raw), &ch)
+	lock := NewLoginLock()
+	if lock.MaxFailedAttempts > 0 {
+		_ = lock.LoginSuccess(ctx, user.Email)
+	}
+
+	var rsp *model.LoginRsp
+	if ch.Oidc != nil {
+		var session *model.UserSession
+		session, rsp, err = newSession(ctx, user, meta, true)
+		if err == nil {
+			saveOidcSession(ctx, session.ID, time.Duration(rsp.RefreshExpiresIn)*time.Second, *ch.Oidc)
+		}
+	} else {
+		rsp, err = GroupApp.User.UserLoginAfter(ctx, user, meta)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := (dal.UserQuery{}).UpdateLastVisitTime(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	if remember {
+		trusted, err := addTrustedDevice(ctx, user, deviceName, meta)
+		if err != nil {
+			logrus.WithError(err).Error("failed to save trusted device")
+		} else if trusted != "" {
+			rsp.TrustedDeviceToken = &trusted
+		}
+	}
+	return rsp, nil
+}
+
+// checkSecondFactor 校验动态验证码或恢复码并落库（时间步或剩余恢复码），返回验证方式
+func checkSecondFactor(ctx context.Context, m *model.UserMfa, code, recoveryCode *string) (string, bool, error) {
+	now := time.Now()
+	if code != nil && strings.TrimSpace(*code) != "" && m.TotpSecret != nil {
+		step, ok := verifyTotp(*m.TotpSecret, *code, now, m.LastUsedStep)
+		if !ok {
+			return "totp", false, nil
+		}
+		// 条件更新防止并发请求重复使用同一验证码
+		res := global.DB.WithContext(ctx).Model(&model.UserMfa{}).
+			Where("user_id = ? AND last_used_step < ?", m.UserID, step).
+			Updates(map[string]interface{}{"last_used_step": step, "updated_at": now.UTC()})
+		if res.Error != nil {
+			return "totp", false, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": res.Error.Error()})
+		}
+		return "totp", res.RowsAffected == 1, nil
+	}
+	if recoveryCode != nil && strings.TrimSpace(*recoveryCode) != "" {
+		rest, ok := consumeRecoveryCode(m.RecoveryCodes, *recoveryCode)
+		if !ok {
+			return "recovery_code", false, nil
+		}
+		res := global.DB.WithContext(ctx).Model(&model.UserMfa{}).
+			Where("user_id = ? AND recovery_codes = ?::jsonb", m.UserID, m.RecoveryCodes).
+			Updates(map[string]interface{}{"recovery_codes": rest, "updated_at": now.UTC()})
+		if res.Error != nil {
+			return "recovery_code", false, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": res.Error.Error()})
+		}
+		m.RecoveryCodes = rest
+		return "recovery_code", res.RowsAffected == 1, nil
+	}
+	return "", false, errcode.WithData(errcode.CodeParamError, map[string]interface{}{
+		"message": "code or recovery_code is required",
+	})
+}
+
+// VerifyLogin 登录第二步：校验动态验证码或恢复码后签发登录凭证
+func (*UserMfa) VerifyLogin(ctx context.Context, req *model.MfaVerifyReq, meta *model.RequestMeta) (*model.LoginRsp, error) {
+	user, err := loadMfaChallenge(ctx, req.MfaToken, false)
+	if err != nil {
+		return nil, err
+	}
+	m, err := getUserMfa(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil || !m.Enabled {
+		// 验证期间被管理员重置
+		global.REDIS.Del(ctx, mfaChallengeKey(req.MfaToken))
+		return nil, errcode.New(errcode.CodeMfaTokenExpired)
+	}
+
+	method, ok, err := checkSecondFactor(ctx, m, req.Code, req.RecoveryCode)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		failMfaChallenge(ctx, req.MfaToken, user)
+		writeSecurityAuditLog(ctx, meta, user.ID, userTenantID(user), mfaEventLoginFailed, map[string]interface{}{"method": method})
+		return nil, errcode.New(errcode.CodeMfaCodeInvalid)
+	}
+
+	detail := map[string]interface{}{"method": method}
+	if method == "recovery_code" {
+		detail["recovery_codes_remaining"] = countRecoveryCodes(m.RecoveryCodes)
+	}
+	writeSecurityAuditLog(ctx, meta, user.ID, userTenantID(user), mfaEventLoginVerified, detail)
+	return completeMfaLogin(ctx, req.MfaToken, user, req.RememberDevice, req.DeviceName, meta)
+}
+
+// LoginSetup 租户强制启用但尚未绑定：凭临时令牌获取绑定密钥
+func (s *UserMfa) LoginSetup(ctx context.Context, req *model.MfaLoginSetupReq) (*model.MfaSetupRsp, error) {
+	user, err := loadMfaChallenge(ctx, req.MfaToken, true)
+	if err != nil {
+		return nil, err
+	}
+	return s.setup(ctx, user)
+}
+
+// LoginEnable 租户强制启用但尚未绑定：确认绑定后返回恢复码与登录凭证
+func (s *UserMfa) LoginEnable(ctx context.Context, req *model.MfaLoginEnableReq, meta *model.RequestMeta) (*model.MfaEnableRsp, error) {
+	user, err := loadMfaChallenge(ctx, req.MfaToken, true)
+	if err != nil {
+		return nil, err
+	}
+	codes, err := s.enable(ctx, user, req.Code, meta)
+	if err != nil {
+		var e *errcode.Error
+		if errors.As(err, &e) && e.Code == errcode.CodeMfaCodeInvalid {
+			failMfaChallenge(ctx, req.MfaToken, user)
+		}
+		return nil, err
+	}
+	rsp, err := completeMfaLogin(ctx, req.MfaToken, user, req.RememberDevice, req.DeviceName, meta)
+	if err != nil {
+		return nil, err
+	}
+	return &model.MfaEnableRsp{MfaRecoveryCodesRsp: model.MfaRecoveryCodesRsp{RecoveryCodes: codes}, LoginRsp: rsp}, nil
+}
+
+// setup 生成待确认的绑定密钥，已启用时不允许覆盖
+func (*UserMfa) setup(ctx context.Context, user *model.User) (*model.MfaSetupRsp, error) {
+	m, err := getUserMfa(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if m != nil && m.Enabled {
+		return nil, errcode.New(errcode.CodeMfaAlreadyEnabled)
+	}
+	secret, err := generateTotpSecret()
+	if err != nil {
+		return nil, errcode.WithData(errcode.CodeSystemError, map[string]interface{}{"error": err.Error()})
+	}
+	now := time.Now().UTC()
+	row := &model.UserMfa{
+		UserID:        user.ID,
+		TenantID:      user.TenantID,
+		PendingSecret: &secret,
+		RecoveryCodes: "[]",
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := global.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"pending_secret", "updated_at"}),
+	}).Create(row).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return &model.MfaSetupRsp{
+		Secret:     secret,
+		OtpauthURL: totpOtpauthURL(mfaIssuer(), user.Email, secret),
+	}, nil
+}
+
+// enable 用待确认密钥的验证码完成绑定，返回恢复码明文
+func (*UserMfa) enable(ctx context.Context, user *model.User, code string, meta *model.RequestMeta) ([]string, error) {
+	m, err := getUserMfa(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if m != nil && m.Enabled {
+		return nil, errcode.New(errcode.CodeMfaAlreadyEnabled)
+	}
+	if m == nil || m.PendingSecret == nil {
+		return nil, errcode.WithData(errcode.CodeParamError, map[string]interface{}{
+			"message": "call setup to get a secret first",
+		})
+	}
+	now := time.Now()
+	step, ok := verifyTotp(*m.PendingSecret, code, now, 0)
+	if !ok {
+		return nil, errcode.New(errcode.CodeMfaCodeInvalid)
+	}
+	codes, hashes, err := generateRecoveryCodes(mfaRecoveryCodeCount)
+	if err != nil {
+		return nil, errcode.WithData(errcode.CodeSystemError, map[string]interface{}{"error": err.Error()})
+	}
+	res := global.DB.WithContext(ctx).Model(&model.UserMfa{}).
+		Where("user_id = ? AND enabled = false", user.ID).
+		Updates(map[string]interface{}{
+			"enabled":        true,
+			"totp_secret":    *m.PendingSecret,
+			"pending_secret": nil,
+			"recovery_codes": hashes,
+			"last_used_step": step,
+			"enabled_at":     now.UTC(),
+			"updated_at":     now.UTC(),
+		})
+	if res.Error != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": res.Error.Error()})
+	}
+	if res.RowsAffected == 0 {
+		return nil, errcode.New(errcode.CodeMfaAlreadyEnabled)
+	}
+	writeSecurityAuditLog(ctx, meta, user.ID, userTenantID(user), mfaEventEnabled, nil)
+	return codes, nil
+}
+
+func getClaimsUser(claims *utils.UserClaims) (*model.User, error) {
+	user, err := dal.GetUsersById(claims.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errcode.New(errcode.CodeNotFound)
+		}
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return user, nil
+}
+
+// GetStatus 当前账号的双因素认证状态
+func (*UserMfa) GetStatus(ctx context.Context, claims *utils.UserClaims) (*model.MfaStatusRsp, error) {
+	user, err := getClaimsUser(claims)
+	if err != nil {
+		return nil, err
+	}
+	enforced, err := mfaEnforced(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	rsp := &model.MfaStatusRsp{Enforced: enforced}
+	m, err := getUserMfa(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if m != nil && m.Enabled {
+		rsp.Enabled = true
+		rsp.EnabledAt = m.EnabledAt
+		rsp.RecoveryCodesRemaining = countRecoveryCodes(m.RecoveryCodes)
+	}
+	if err := global.DB.WithContext(ctx).Model(&model.UserTrustedDevice{}).
+		Where("user_id = ? AND expires_at > ?", user.ID, time.Now().UTC()).
+		Count(&rsp.TrustedDevices).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return rsp, nil
+}
+
+// Setup 当前账号开始绑定身份验证器
+func (s *UserMfa) Setup(ctx context.Context, claims *utils.UserClaims) (*model.MfaSetupRsp, error) {
+	user, err := getClaimsUser(claims)
+	if err != nil {
+		return nil, err
+	}
+	return s.setup(ctx, user)
+}
+
+// Enable 当前账号确认绑定，返回恢复码
+func (s *UserMfa) Enable(ctx context.Context, req *model.MfaCodeReq, claims *utils.UserClaims, meta *model.RequestMeta) (*model.MfaRecoveryCodesRsp, error) {
+	user, err := getClaimsUser(claims)
+	if err != nil {
+		return nil, err
+	}
+	codes, err := s.enable(ctx, user, req.Code, meta)
+	if err != nil {
+		return nil, err
+	}
+	return &model.MfaRecoveryCodesRsp{RecoveryCodes: codes}, nil
+}
+
+// Disable 当前账号关闭双因素认证，租户强制启用时不允许
+func (*UserMfa) Disable(ctx context.Context, req *model.MfaDisableReq, claims *utils.UserClaims, meta *model.RequestMeta) error {
+	user, err := getClaimsUser(claims)
+	if err != nil {
+		return err
+	}
+	enforced, err := mfaEnforced(ctx, user)
+	if err != nil {
+		return err
+	}
+	if enforced {
+		return errcode.New(errcode.CodeMfaEnforced)
+	}
+	m, err := getUserMfa(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if m == nil || !m.Enabled {
+		return errcode.New(errcode.CodeMfaNotEnabled)
+	}
+	method, ok, err := checkSecondFactor(ctx, m, req.Code, req.RecoveryCode)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errcode.New(errcode.CodeMfaCodeInvalid)
+	}
+	if err := deleteUserMfa(ctx, user.ID); err != nil {
+		return err
+	}
+	writeSecurityAuditLog(ctx, meta, user.ID, userTenantID(user), mfaEventDisabled, map[string]interface{}{"method": method})
+	return nil
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，原恢复码全部作废
+func (*UserMfa) RegenerateRecoveryCodes(ctx context.Context, req *model.MfaCodeReq, claims *utils.UserClaims, meta *model.RequestMeta) (*model.MfaRecoveryCodesRsp, error) {
+	user, err := getClaimsUser(claims)
+	if err != nil {
+		return nil, err
+	}
+	m, err := getUserMfa(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil || !m.Enabled {
+		return nil, errcode.New(errcode.CodeMfaNotEnabled)
+	}
+	_, ok, err := checkSecondFactor(ctx, m, &req.Code, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errcode.New(errcode.CodeMfaCodeInvalid)
+	}
+	codes, hashes, err := generateRecoveryCodes(mfaRecoveryCodeCount)
+	if err != nil {
+		return nil, errcode.WithData(errcode.CodeSystemError, map[string]interface{}{"error": err.Error()})
+	}
+	if err := global.DB.WithContext(ctx).Model(&model.UserMfa{}).
+		Where("user_id = ?", user.ID).
+		Updates(map[string]interface{}{"recovery_codes": hashes, "updated_at": time.Now().UTC()}).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	writeSecurityAuditLog(ctx, meta, user.ID, userTenantID(user), mfaEventRecoveryRegen, nil)
+	return &model.MfaRecoveryCodesRsp{RecoveryCodes: codes}, nil
+}
+
+// deleteUserMfa 清除双因素认证与全部受信任设备
+func deleteUserMfa(ctx context.Context, userID string) error {
+	err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.UserTrustedDevice{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&model.UserMfa{}).Error
+	})
+	if err != nil {
+		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return nil
+}
+
+// ResetUserMfa 管理员重置用户的双因素认证（如丢失手机）；租户强制启用时用户下次登录需重新绑定
+func (*UserMfa) ResetUserMfa(ctx context.Context, userID string, claims *utils.UserClaims, meta *model.RequestMeta) error {
+	user, err := getManagedUser(userID, claims, "reset_user_mfa")
+	if err != nil {
+		return err
+	}
+
+	m, err := getUserMfa(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if m == nil {
+		return errcode.New(errcode.CodeMfaNotEnabled)
+	}
+	if err := deleteUserMfa(ctx, user.ID); err != nil {
+		return err
+	}
+	writeSecurityAuditLog(ctx, meta, claims.ID, userTenantID(user), mfaEventReset, map[string]interface{}{
+		"target_user_id": user.ID,
+		"target_email":   user.Email,
+		"was_enabled":    m.Enabled,
+	})
+	return nil
+}
+
+// addTrustedDevice 信任当前浏览器，返回令牌明文；未开启该功能时返回空
+func addTrustedDevice(ctx context.Context, user *model.User, name *string, meta *model.RequestMeta) (string, error) {
+	ttl := mfaTrustedDeviceTTL()
+	if ttl == 0 {
+		return "", nil
+	}
+	token, err := randomURLToken()
+	if err != nil {
+		return "", err
+	}
+	if meta == nil {
+		meta = &model.RequestMeta{}
+	}
+	deviceName := meta.UserAgent
+	if name != nil && strings.TrimSpace(*name) != "" {
+		deviceName = strings.TrimSpace(*name)
+	}
+	if len(deviceName) > 255 {
+		deviceName = deviceName[:255]
+	}
+	now := time.Now().UTC()
+	device := &model.UserTrustedDevice{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TenantID:  user.TenantID,
+		TokenHash: sha256Hex(token),
+		Name:      emptyToNil(&deviceName),
+		IP:        emptyToNil(&meta.IP),
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}
+	if err := global.DB.WithContext(ctx).Create(device).Error; err != nil {
+		return "", err
+	}
+	writeSecurityAuditLog(ctx, meta, user.ID, userTenantID(user), mfaEventTrustedDeviceAdd, map[string]interface{}{
+		"device_id":  device.ID,
+		"expires_at": device.ExpiresAt,
+	})
+	return token, nil
+}
+
+// useTrustedDevice 校验受信任设备令牌，有效时更新最近使用时间
+func useTrustedDevice(ctx context.Context, userID, token string) *model.UserTrustedDevice {
+	var rows []model.UserTrustedDevice
+	now := time.Now().UTC()
+	if err := global.DB.WithContext(ctx).
+		Where("token_hash = ? AND user_id = ? AND expires_at > ?", sha256Hex(token), userID, now).
+		Limit(1).Find(&rows).Error; err != nil {
+		logrus.WithError(err).Error("failed to query trusted device")
+		return nil
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	global.DB.WithContext(ctx).Model(&model.UserTrustedDevice{}).Where("id = ?", rows[0].ID).Update("last_used_at", now)
+	return &rows[0]
+}
+
+// ListTrustedDevices 当前账号有效的受信任设备
+func (*UserMfa) ListTrustedDevices(ctx context.Context, claims *utils.UserClaims) ([]model.UserTrustedDevice, error) {
+	rows := make([]model.UserTrustedDevice, 0)
+	if err := global.DB.WithContext(ctx).
+		Where("user_id = ? AND expires_at > ?", claims.ID, time.Now().UTC()).
+		Order("created_at DESC").
+		Find(&rows).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return rows, nil
+}
+
+// RevokeTrustedDevices 撤销当前账号的受信任设备，id 为空时撤销全部
+func (*UserMfa) RevokeTrustedDevices(ctx context.Context, id string, claims *utils.UserClaims, meta *model.RequestMeta) error {
+	db := global.DB.WithContext(ctx).Where("user_id = ?", claims.ID)
+	if id != "" {
+		db = db.Where("id = ?", id)
+	}
+	res := db.Delete(&model.UserTrustedDevice{})
+	if res.Error != nil {
+		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": res.Error.Error()})
+	}
+	if id != "" && res.RowsAffected == 0 {
+		return errcode.New(errcode.CodeNotFound)
+	}
+	detail := map[string]interface{}{"revoked": res.RowsAffected}
+	if id != "" {
+		detail["device_id"] = id
+	}
+	writeSecurityAuditLog(ctx, meta, claims.ID, claims.TenantID, mfaEventTrustedDeviceDel, detail)
+	return nil
+}
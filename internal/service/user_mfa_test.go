@@ -0,0 +1,102 @@
+package service
+
+import (
+	"encoding/base32"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTotpCodeRFC6238(t *testing.T) {
+	// RFC 6238 附录B SHA1 测试向量（取低6位）
+	key := []byte("12345678901234567890")
+	cases := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+	for ts, want := range cases {
+		if got := totpCode(key, ts/totpPeriod); got != want {
+			t.Errorf("totpCode(%d) = %s, want %s", ts, got, want)
+		}
+	}
+}
+
+func TestVerifyTotp(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1111111111, 0)
+	step := now.Unix() / totpPeriod
+
+	got, ok := verifyTotp(secret, "050471", now, 0)
+	if !ok || got != step {
+		t.Fatalf("verifyTotp = %d, %v", got, ok)
+	}
+	// 已使用的时间步不能重放
+	if _, ok := verifyTotp(secret, "050471", now, step); ok {
+		t.Fatal("replayed code must be rejected")
+	}
+	// 允许一个时间步的时钟偏差，小写与空格分组的密钥同样可用
+	spaced := strings.ToLower(secret[:4] + " " + secret[4:])
+	if _, ok := verifyTotp(spaced, "050471", now.Add(totpPeriod*time.Second), 0); !ok {
+		t.Fatal("code from previous step should be accepted")
+	}
+	if _, ok := verifyTotp(secret, "050471", now.Add(3*totpPeriod*time.Second), 0); ok {
+		t.Fatal("stale code must be rejected")
+	}
+	for _, bad := range []string{"", "05047", "0504710", "abcdef"} {
+		if _, ok := verifyTotp(secret, bad, now, 0); ok {
+			t.Errorf("%q should be rejected", bad)
+		}
+	}
+}
+
+func TestGenerateTotpSecret(t *testing.T) {
+	secret, err := generateTotpSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := decodeTotpSecret(secret)
+	if err != nil || len(key) != 20 {
+		t.Fatalf("secret %q decoded to %d bytes, %v", secret, len(key), err)
+	}
+	u, err := url.Parse(totpOtpauthURL("IoT Platform", "admin@example.com", secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/IoT Platform:admin@example.com" || u.Query().Get("secret") != secret || u.Query().Get("issuer") != "IoT Platform" {
+		t.Fatalf("otpauth url = %s", u)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, stored, err := generateRecoveryCodes(mfaRecoveryCodeCount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != mfaRecoveryCodeCount || countRecoveryCodes(stored) != mfaRecoveryCodeCount {
+		t.Fatalf("codes = %v", codes)
+	}
+	if strings.Contains(stored, codes[0]) || strings.Contains(stored, normalizeRecoveryCode(codes[0])) {
+		t.Fatal("recovery codes must not be stored in plain text")
+	}
+
+	// 忽略大小写与连字符
+	rest, ok := consumeRecoveryCode(stored, strings.ToUpper(strings.ReplaceAll(codes[3], "-", " ")))
+	if !ok || countRecoveryCodes(rest) != mfaRecoveryCodeCount-1 {
+		t.Fatalf("consume = %v, remaining %d", ok, countRecoveryCodes(rest))
+	}
+	// 每个恢复码只能使用一次
+	if _, ok := consumeRecoveryCode(rest, codes[3]); ok {
+		t.Fatal("used recovery code must be rejected")
+	}
+	if _, ok := consumeRecoveryCode(rest, "aaaaa-aaaaa"); ok {
+		t.Fatal("unknown recovery code must be rejected")
+	}
+	if _, ok := consumeRecoveryCode(rest, codes[4]); !ok {
+		t.Fatal("other recovery codes stay valid")
+	}
+}
@@ -0,0 +1,524 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	dal "project/internal/dal"
+	"project/internal/logic"
+	model "project/internal/model"
+	"project/pkg/errcode"
+	global "project/pkg/global"
+	utils "project/pkg/utils"
+
+	"github.com/go-basic/uuid"
+	"github.com/golang-jwt/jwt"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+// UserSession 登录会话：短期访问令牌 + 轮换的刷新令牌；
+// 请求鉴权只校验令牌签名并读取拒绝列表，撤销会话时写入拒绝列表，拒绝列表随访问令牌有效期自动过期
+type UserSession struct{}
+
+const (
+	sessionDefaultAccessTTL = 15 * time.Minute
+	// 并发刷新（如多个标签页同时刷新）时旧刷新令牌在该时间内再次出现不视为泄露
+	sessionRefreshReuseGrace = 30 * time.Second
+	// 撤销或过期的会话保留天数，便于审计
+	sessionRetentionDays = 30
+)
+
+// 撤销原因
+const (
+	SessionRevokeLogout      = "logout"
+	SessionRevokeRevoked     = "revoked"
+	SessionRevokeForceLogout = "force_logout"
+	SessionRevokeSingleLogin = "single_login"
+	SessionRevokeTokenReuse  = "refresh_token_reuse"
+	SessionRevokeBackchannel = "oidc_backchannel"
+)
+
+// 审计事件，记录在 operation_logs.name
+const (
+	sessionEventRevoked     = "SESSION_REVOKED"
+	sessionEventForceLogout = "SESSION_FORCE_LOGOUT"
+	sessionEventTokenReuse  = "SESSION_REFRESH_TOKEN_REUSE"
+)
+
+var (
+	ErrAccessTokenInvalid = errors.New("invalid token")
+	ErrAccessTokenExpired = errors.New("token has expired")
+	ErrSessionRevoked     = errors.New("session has been revoked")
+)
+
+// sessionDenyKey 已撤销会话的拒绝列表
+func sessionDenyKey(sessionID string) string {
+	return "session:deny:" + sessionID
+}
+
+// sessionUserDenyKey 强制下线：记录时间点（纳秒），此前签发的访问令牌全部失效
+func sessionUserDenyKey(userID string) string {
+	return "session:deny:user:" + userID
+}
+
+// sessionAccessTTL 访问令牌有效期
+func sessionAccessTTL() time.Duration {
+	if m := viper.GetInt("session.access_token_ttl"); m > 0 {
+		return time.Duration(m) * time.Minute
+	}
+	return sessionDefaultAccessTTL
+}
+
+// sessionLifetime 会话（刷新令牌）有效期，沿用 session.timeout；0 表示不过期
+func sessionLifetime() time.Duration {
+	timeout := viper.GetInt("session.timeout")
+	if timeout <= 0 && viper.GetBool("session.reset_on_request") {
+		timeout = 60
+	}
+	if timeout <= 0 {
+		return 0
+	}
+	return time.Duration(timeout) * time.Minute
+}
+
+// signAccessToken 签发会话的访问令牌
+func signAccessToken(user *model.User, sessionID string, now time.Time) (string, error) {
+	claims := utils.UserClaims{
+		ID:         user.ID,
+		Email:      user.Email,
+		Authority:  *user.Authority,
+		CreateTime: now,
+		TenantID:   *user.TenantID,
+		SessionID:  sessionID,
+	}
+	claims.IssuedAt = now.Unix()
+	claims.ExpiresAt = now.Add(sessionAccessTTL()).Unix()
+	return utils.NewJWT([]byte(viper.GetString("jwt.key"))).GenerateToken(claims)
+}
+
+// sessionLoginRsp 组装登录响应：访问令牌与刷新令牌（会话ID.随机串）
+func sessionLoginRsp(user *model.User, s *model.UserSession, secret string, now time.Time) (*model.LoginRsp, error) {
+	token, err := signAccessToken(user, s.ID, now)
+	if err != nil {
+		return nil, errcode.New(errcode.CodeTokenGenerateError)
+	}
+	refresh := s.ID + "." + secret
+	rsp := &model.LoginRsp{
+		Token:        &token,
+		ExpiresIn:    int64(sessionAccessTTL() / time.Second),
+		RefreshToken: &refresh,
+	}
+	if s.ExpiresAt != nil {
+		rsp.RefreshExpiresIn = int64(s.ExpiresAt.Sub(now) / time.Second)
+	}
+	return rsp, nil
+}
+
+func truncateString(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+// issueSession 新建会话并签发令牌；exclusive 为真且系统禁止多端登录时撤销该用户的其他会话
+func issueSession(ctx context.Context, user *model.User, meta *model.RequestMeta, exclusive bool) (*model.LoginRsp, error) {
+	_, rsp, err := newSession(ctx, user, meta, exclusive)
+	return rsp, err
+}
+
+// newSession 同 issueSession，同时返回会话（单点登录需按会话ID登记）
+func newSession(ctx context.Context, user *model.User, meta *model.RequestMeta, exclusive bool) (*model.UserSession, *model.LoginRsp, error) {
+	secret, err := randomURLToken()
+	if err != nil {
+		return nil, nil, errcode.New(errcode.CodeTokenGenerateError)
+	}
+	if meta == nil {
+		meta = &model.RequestMeta{}
+	}
+	userAgent := truncateString(meta.UserAgent, 255)
+	now := time.Now().UTC()
+	s := &model.UserSession{
+		ID:               uuid.New(),
+		UserID:           user.ID,
+		TenantID:         user.TenantID,
+		RefreshTokenHash: sha256Hex(secret),
+		UserAgent:        emptyToNil(&userAgent),
+		IP:               emptyToNil(&meta.IP),
+		CreatedAt:        now,
+		LastSeenAt:       now,
+	}
+	if life := sessionLifetime(); life > 0 {
+		expiresAt := now.Add(life)
+		s.ExpiresAt = &expiresAt
+	}
+	if err := global.DB.WithContext(ctx).Create(s).Error; err != nil {
+		return nil, nil, errcode.WithData(errcode.CodeTokenSaveError, map[string]interface{}{"error": err.Error()})
+	}
+
+	// 禁止共享账号：保证一个账号只能在一个地方登录
+	if exclusive && !logic.UserIsShare(ctx) {
+		if _, err := revokeUserSessions(ctx, user.ID, s.ID, SessionRevokeSingleLogin); err != nil {
+			logrus.WithError(err).Error("failed to revoke other sessions")
+		}
+		revokeLegacyToken(ctx, user.Email)
+	}
+	rsp, err := sessionLoginRsp(user, s, secret, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s, rsp, nil
+}
+
+// revokeLegacyToken 撤销升级前签发、登记在 Redis 中的旧版令牌
+func revokeLegacyToken(ctx context.Context, email string) {
+	if oldToken, err := global.REDIS.GetDel(ctx, email+"_token").Result(); err == nil && oldToken != "" {
+		global.REDIS.Del(ctx, oldToken)
+	}
+}
+
+// revokeSessionIDs 撤销指定会话并写入拒绝列表，返回实际撤销的数量
+func revokeSessionIDs(ctx context.Context, ids []string, reason string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	now := time.Now().UTC()
+	res := global.DB.WithContext(ctx).Model(&model.UserSession{}).
+		Where("id IN ? AND revoked_at IS NULL", ids).
+		Updates(map[string]interface{}{"revoked_at": now, "revoked_reason": reason})
+	if res.Error != nil {
+		return 0, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": res.Error.Error()})
+	}
+	pipe := global.REDIS.Pipeline()
+	for _, id := range ids {
+		pipe.Set(ctx, sessionDenyKey(id), "1", sessionAccessTTL())
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return res.RowsAffected, errcode.WithData(errcode.CodeCacheError, map[string]interface{}{"error": err.Error()})
+	}
+	return res.RowsAffected, nil
+}
+
+// revokeUserSessions 撤销用户的全部有效会话（可排除一个），返回撤销的数量
+func revokeUserSessions(ctx context.Context, userID, exceptID, reason string) (int64, error) {
+	var ids []string
+	db := global.DB.WithContext(ctx).Model(&model.UserSession{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID)
+	if exceptID != "" {
+		db = db.Where("id <> ?", exceptID)
+	}
+	if err := db.Pluck("id", &ids).Error; err != nil {
+		return 0, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return revokeSessionIDs(ctx, ids, reason)
+}
+
+// ValidateAccessToken 校验访问令牌：签名与有效期、会话拒绝列表；
+// 升级前签发的旧版令牌（无会话ID）仍按 Redis 登记校验，不再刷新过期时间
+func (*UserSession) ValidateAccessToken(ctx context.Context, token string) (*utils.UserClaims, error) {
+	claims, err := utils.NewJWT([]byte(viper.GetString("jwt.key"))).ParseToken(token)
+	if err != nil {
+		var ve *jwt.ValidationError
+		if errors.As(err, &ve) && ve.Errors&jwt.ValidationErrorExpired != 0 {
+			return nil, ErrAccessTokenExpired
+		}
+		return nil, ErrAccessTokenInvalid
+	}
+	if claims.SessionID == "" {
+		if global.REDIS.Get(ctx, token).Val() != "1" {
+			return nil, ErrAccessTokenExpired
+		}
+		return claims, nil
+	}
+
+	vals, err := global.REDIS.MGet(ctx, sessionDenyKey(claims.SessionID), sessionUserDenyKey(claims.ID)).Result()
+	if err != nil {
+		logrus.WithError(err).Error("failed to read session denylist")
+		return nil, ErrAccessTokenInvalid
+	}
+	if vals[0] != nil {
+		return nil, ErrSessionRevoked
+	}
+	if v, ok := vals[1].(string); ok {
+		if at, err := strconv.ParseInt(v, 10, 64); err == nil && claims.CreateTime.UnixNano() <= at {
+			return nil, ErrSessionRevoked
+		}
+	}
+	return claims, nil
+}
+
+// Refresh 用刷新令牌换取新的访问令牌，刷新令牌同时轮换；已轮换的旧令牌再次出现视为泄露，撤销整个会话
+func (*UserSession) Refresh(ctx context.Context, req *model.RefreshTokenReq, meta *model.RequestMeta) (*model.LoginRsp, error) {
+	invalid := errcode.New(errcode.CodeSessionInvalid)
+	sessionID, secret, ok := strings.Cut(req.RefreshToken, ".")
+	if !ok || sessionID == "" || secret == "" {
+		return nil, invalid
+	}
+	var s model.UserSession
+	if err := global.DB.WithContext(ctx).Where("id = ?", sessionID).First(&s).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, invalid
+		}
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	now := time.Now().UTC()
+	if s.RevokedAt != nil || (s.ExpiresAt != nil && !s.ExpiresAt.After(now)) {
+		return nil, invalid
+	}
+
+	hash := sha256Hex(secret)
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(s.RefreshTokenHash)) != 1 {
+		if s.PreviousTokenHash != nil && subtle.ConstantTimeCompare([]byte(hash), []byte(*s.PreviousTokenHash)) == 1 &&
+			(s.RotatedAt == nil || now.Sub(*s.RotatedAt) > sessionRefreshReuseGrace) {
+			if _, err := revokeSessionIDs(ctx, []string{s.ID}, SessionRevokeTokenReuse); err != nil {
+				logrus.WithError(err).Error("failed to revoke session after refresh token reuse")
+			}
+			tenantID := ""
+			if s.TenantID != nil {
+				tenantID = *s.TenantID
+			}
+			writeSecurityAuditLog(ctx, meta, s.UserID, tenantID, sessionEventTokenReuse, map[string]interface{}{"session_id": s.ID})
+			logrus.Warnf("refresh token reuse detected, session %s revoked", s.ID)
+		}
+		return nil, invalid
+	}
+
+	user, err := dal.GetUsersById(s.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, invalid
+		}
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	if user.Status == nil || *user.Status != "N" {
+		return nil, errcode.New(errcode.CodeUserDisabled)
+	}
+
+	newSecret, err := randomURLToken()
+	if err != nil {
+		return nil, errcode.New(errcode.CodeTokenGenerateError)
+	}
+	updates := map[string]interface{}{
+		"refresh_token_hash":  sha256Hex(newSecret),
+		"previous_token_hash": hash,
+		"rotated_at":          now,
+		"last_seen_at":        now,
+	}
+	if meta != nil && meta.IP != "" {
+		updates["ip"] = meta.IP
+	}
+	if viper.GetBool("session.reset_on_request") && s.ExpiresAt != nil {
+		expiresAt := now.Add(sessionLifetime())
+		updates["expires_at"] = expiresAt
+		s.ExpiresAt = &expiresAt
+	}
+	// 条件更新保证同一刷新令牌只能成功轮换一次
+	res := global.DB.WithContext(ctx).Model(&model.UserSession{}).
+		Where("id = ? AND refresh_token_hash = ? AND revoked_at IS NULL", s.ID, hash).
+		Updates(updates)
+	if res.Error != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": res.Error.Error()})
+	}
+	if res.RowsAffected == 0 {
+		return nil, invalid
+	}
+	return sessionLoginRsp(user, &s, newSecret, now)
+}
+
+// Reissue 旧版令牌（无会话）升级为带刷新令牌的会话。
+// 会话令牌只能凭刷新令牌续签（POST /api/v1/login/refresh），否则被盗的访问令牌可不断续签，刷新令牌轮换与重放检测失效
+// 旧版令牌升级时即作废，只能升级一次，不能凭同一令牌反复创建会话
+func (*UserSession) Reissue(ctx context.Context, claims *utils.UserClaims, token string, meta *model.RequestMeta) (*model.LoginRsp, error) {
+	if claims.SessionID != "" {
+		return nil, errcode.New(errcode.CodeSessionInvalid)
+	}
+	if err := consumeLegacyToken(ctx, token); err != nil {
+		return nil, err
+	}
+	user, err := dal.GetUsersById(claims.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errcode.New(errcode.CodeSessionInvalid)
+		}
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	if user.Status == nil || *user.Status != "N" {
+		return nil, errcode.New(errcode.CodeUserDisabled)
+	}
+	return issueSession(ctx, user, meta, false)
+}
+
+// consumeLegacyToken 原子取出并删除旧版令牌的 Redis 登记，登记不存在（已升级、已退出或已过期）时失败
+func consumeLegacyToken(ctx context.Context, token string) error {
+	if token == "" {
+		return errcode.New(errcode.CodeSessionInvalid)
+	}
+	if v, err := global.REDIS.GetDel(ctx, token).Result(); err != nil || v != "1" {
+		return errcode.New(errcode.CodeSessionInvalid)
+	}
+	return nil
+}
+
+// Logout 退出登录：撤销当前会话；旧版令牌删除 Redis 登记
+func (*UserSession) Logout(ctx context.Context, claims *utils.UserClaims, token string) error {
+	if claims.SessionID == "" {
+		if err := global.REDIS.Del(ctx, token).Err(); err != nil {
+			return errcode.New(errcode.CodeTokenDeleteError)
+		}
+		return nil
+	}
+	if _, err := revokeSessionIDs(ctx, []string{claims.SessionID}, SessionRevokeLogout); err != nil {
+		return errcode.New(errcode.CodeTokenDeleteError)
+	}
+	return nil
+}
+
+// listActiveSessions 用户未撤销且未过期的会话，按最近活跃排序
+func listActiveSessions(ctx context.Context, userID, currentID string) ([]model.UserSessionRsp, error) {
+	var rows []model.UserSession
+	now := time.Now().UTC()
+	if err := global.DB.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", userID, now).
+		Order("last_seen_at DESC").
+		Find(&rows).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	list := make([]model.UserSessionRsp, 0, len(rows))
+	for _, r := range rows {
+		list = append(list, model.UserSessionRsp{
+			ID:         r.ID,
+			UserAgent:  r.UserAgent,
+			IP:         r.IP,
+			CreatedAt:  r.CreatedAt,
+			LastSeenAt: r.LastSeenAt,
+			ExpiresAt:  r.ExpiresAt,
+			Current:    r.ID == currentID,
+		})
+	}
+	return list, nil
+}
+
+// ListSessions 当前用户的登录会话
+func (*UserSession) ListSessions(ctx context.Context, claims *utils.UserClaims) ([]model.UserSessionRsp, error) {
+	return listActiveSessions(ctx, claims.ID, claims.SessionID)
+}
+
+// RevokeSession 撤销当前用户的指定会话
+func (*UserSession) RevokeSession(ctx context.Context, id string, claims *utils.UserClaims, meta *model.RequestMeta) error {
+	var count int64
+	if err := global.DB.WithContext(ctx).Model(&model.UserSession{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, claims.ID).
+		Count(&count).Error; err != nil {
+		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	if count == 0 {
+		return errcode.New(errcode.CodeNotFound)
+	}
+	if _, err := revokeSessionIDs(ctx, []string{id}, SessionRevokeRevoked); err != nil {
+		return err
+	}
+	writeSecurityAuditLog(ctx, meta, claims.ID, claims.TenantID, sessionEventRevoked, map[string]interface{}{"session_id": id})
+	return nil
+}
+
+// RevokeOtherSessions 撤销当前用户除当前会话外的全部会话
+func (*UserSession) RevokeOtherSessions(ctx context.Context, claims *utils.UserClaims, meta *model.RequestMeta) error {
+	n, err := revokeUserSessions(ctx, claims.ID, claims.SessionID, SessionRevokeRevoked)
+	if err != nil {
+		return err
+	}
+	writeSecurityAuditLog(ctx, meta, claims.ID, claims.TenantID, sessionEventRevoked, map[string]interface{}{"revoked": n, "scope": "others"})
+	return nil
+}
+
+// getManagedUser 管理员可管理的用户：系统管理员可管理全部，租户管理员仅本租户且不含系统管理员，不能操作自己
+func getManagedUser(userID string, claims *utils.UserClaims, operation string) (*model.User, error) {
+	if claims.Authority != "SYS_ADMIN" && claims.Authority != "TENANT_ADMIN" {
+		return nil, errcode.WithVars(errcode.CodeNoPermission, map[string]interface{}{
+			"operation": operation,
+		})
+	}
+	if userID == claims.ID {
+		return nil, errcode.WithVars(errcode.CodeOpDenied, map[string]interface{}{
+			"reason":  "cannot_" + operation + "_self",
+			"user_id": userID,
+		})
+	}
+	user, err := dal.GetUsersById(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errcode.New(errcode.CodeNotFound)
+		}
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	if claims.Authority == "TENANT_ADMIN" {
+		if userTenantID(user) != claims.TenantID {
+			return nil, errcode.WithVars(errcode.CodeNoPermission, map[string]interface{}{
+				"required_tenant": userTenantID(user),
+				"current_tenant":  claims.TenantID,
+				"operation":       operation,
+			})
+		}
+		if user.Authority != nil && *user.Authority == "SYS_ADMIN" {
+			return nil, errcode.WithVars(errcode.CodeOpDenied, map[string]interface{}{
+				"reason":  "cannot_" + operation + "_sys_admin",
+				"user_id": userID,
+			})
+		}
+	}
+	return user, nil
+}
+
+// ListUserSessions 管理员查看用户的登录会话
+func (*UserSession) ListUserSessions(ctx context.Context, userID string, claims *utils.UserClaims) ([]model.UserSessionRsp, error) {
+	user, err := getManagedUser(userID, claims, "list_user_sessions")
+	if err != nil {
+		return nil, err
+	}
+	return listActiveSessions(ctx, user.ID, "")
+}
+
+// ForceLogout 管理员强制用户下线：撤销全部会话，并使此前签发的访问令牌立即失效
+func (*UserSession) ForceLogout(ctx context.Context, userID string, claims *utils.UserClaims, meta *model.RequestMeta) error {
+	user, err := getManagedUser(userID, claims, "force_logout")
+	if err != nil {
+		return err
+	}
+	// 先写入用户级拒绝列表，单个键即可覆盖该用户此前签发的全部访问令牌
+	if err := global.REDIS.Set(ctx, sessionUserDenyKey(user.ID), strconv.FormatInt(time.Now().UnixNano(), 10), sessionAccessTTL()).Err(); err != nil {
+		return errcode.WithData(errcode.CodeCacheError, map[string]interface{}{"error": err.Error()})
+	}
+	now := time.Now().UTC()
+	res := global.DB.WithContext(ctx).Model(&model.UserSession{}).
+		Where("user_id = ? AND revoked_at IS NULL", user.ID).
+		Updates(map[string]interface{}{"revoked_at": now, "revoked_reason": SessionRevokeForceLogout})
+	if res.Error != nil {
+		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": res.Error.Error()})
+	}
+	revokeLegacyToken(ctx, user.Email)
+	writeSecurityAuditLog(ctx, meta, claims.ID, userTenantID(user), sessionEventForceLogout, map[string]interface{}{
+		"target_user_id": user.ID,
+		"target_email":   user.Email,
+		"revoked":        res.RowsAffected,
+	})
+	return nil
+}
+
+// CleanSessionsByCron 清理撤销或过期超过保留期的会话
+func (*UserSession) CleanSessionsByCron() {
+	before := time.Now().UTC().AddDate(0, 0, -sessionRetentionDays)
+	res := global.DB.Where("revoked_at < ? OR expires_at < ?", before, before).Delete(&model.UserSession{})
+	if res.Error != nil {
+		logrus.WithError(res.Error).Error("failed to clean user sessions")
+		return
+	}
+	if res.RowsAffected > 0 {
+		logrus.Infof("cleaned %d user sessions", res.RowsAffected)
+	}
+}
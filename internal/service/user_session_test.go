@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"project/internal/model"
+	"project/pkg/errcode"
+	"project/pkg/global"
+	"project/pkg/utils"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/spf13/viper"
+)
+
+func TestSessionTTLs(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	if got := sessionAccessTTL(); got != sessionDefaultAccessTTL {
+		t.Fatalf("default access ttl = %v", got)
+	}
+	viper.Set("session.access_token_ttl", 5)
+	if got := sessionAccessTTL(); got != 5*time.Minute {
+		t.Fatalf("access ttl = %v", got)
+	}
+
+	// 未配置超时且不滑动续期：会话不过期
+	if got := sessionLifetime(); got != 0 {
+		t.Fatalf("default lifetime = %v", got)
+	}
+	viper.Set("session.reset_on_request", true)
+	if got := sessionLifetime(); got != time.Hour {
+		t.Fatalf("sliding lifetime = %v", got)
+	}
+	viper.Set("session.timeout", 30)
+	if got := sessionLifetime(); got != 30*time.Minute {
+		t.Fatalf("lifetime = %v", got)
+	}
+}
+
+func TestSessionAccessToken(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("jwt.key", "test-key")
+	viper.Set("session.access_token_ttl", 15)
+
+	authority, tenantID := "TENANT_ADMIN", "t1"
+	user := &model.User{ID: "u1", Email: "a@example.com", Authority: &authority, TenantID: &tenantID}
+	expiresAt := time.Now().Add(time.Hour)
+	s := &model.UserSession{ID: "s1", ExpiresAt: &expiresAt}
+
+	rsp, err := sessionLoginRsp(user, s, "secret", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *rsp.RefreshToken != "s1.secret" || rsp.ExpiresIn != 900 || rsp.RefreshExpiresIn <= 0 {
+		t.Fatalf("login rsp = %+v", rsp)
+	}
+
+	// 签名错误与过期在读取拒绝列表之前即被拒绝
+	svc := &UserSession{}
+	if _, err := svc.ValidateAccessToken(context.Background(), *rsp.Token+"x"); !errors.Is(err, ErrAccessTokenInvalid) {
+		t.Fatalf("tampered token err = %v", err)
+	}
+	expired, err := signAccessToken(user, "s1", time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.ValidateAccessToken(context.Background(), expired); !errors.Is(err, ErrAccessTokenExpired) {
+		t.Fatalf("expired token err = %v", err)
+	}
+	viper.Set("jwt.key", "other-key")
+	if _, err := svc.ValidateAccessToken(context.Background(), *rsp.Token); !errors.Is(err, ErrAccessTokenInvalid) {
+		t.Fatalf("foreign key err = %v", err)
+	}
+
+	if got := truncateString(strings.Repeat("a", 10), 4); got != "aaaa" {
+		t.Fatalf("truncateString = %q", got)
+	}
+}
+
+func TestReissueRejectsSessionTokens(t *testing.T) {
+	// 会话令牌不能凭访问令牌续签，须使用刷新令牌
+	_, err := (&UserSession{}).Reissue(context.Background(), &utils.UserClaims{ID: "u1", SessionID: "s1"}, "token", nil)
+	var e *errcode.Error
+	if !errors.As(err, &e) || e.Code != errcode.CodeSessionInvalid {
+		t.Fatalf("Reissue with session token = %v; want CodeSessionInvalid", err)
+	}
+}
+
+// fakeGetDelHook 以内存数据响应 GETDEL，测试无需连接 Redis
+type fakeGetDelHook struct {
+	data map[string]string
+}
+
+func (h *fakeGetDelHook) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h *fakeGetDelHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func (h *fakeGetDelHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		c, ok := cmd.(*redis.StringCmd)
+		if !ok || cmd.Name() != "getdel" {
+			return errors.New("unsupported command " + cmd.Name())
+		}
+		key, _ := cmd.Args()[1].(string)
+		v, ok := h.data[key]
+		if !ok {
+			c.SetErr(redis.Nil)
+			return redis.Nil
+		}
+		delete(h.data, key)
+		c.SetVal(v)
+		return nil
+	}
+}
+
+func TestReissueConsumesLegacyToken(t *testing.T) {
+	prev := global.REDIS
+	t.Cleanup(func() { global.REDIS = prev })
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:0"})
+	client.AddHook(&fakeGetDelHook{data: map[string]string{"legacy": "1"}})
+	global.REDIS = client
+
+	ctx := context.Background()
+	// 首次升级取出并作废登记
+	if err := consumeLegacyToken(ctx, "legacy"); err != nil {
+		t.Fatalf("first consume: %v", err)
+	}
+	// 同一旧版令牌再次升级失败
+	_, err := (&UserSession{}).Reissue(ctx, &utils.UserClaims{ID: "u1"}, "legacy", nil)
+	var e *errcode.Error
+	if !errors.As(err, &e) || e.Code != errcode.CodeSessionInvalid {
+		t.Fatalf("second Reissue = %v; want CodeSessionInvalid", err)
+	}
+	if err := consumeLegacyToken(ctx, ""); err == nil {
+		t.Fatal("empty token accepted")
+	}
+}
@@ -80,6 +80,9 @@ func (*Warranty) CreateWarrantyApplication(req model.WarrantyApplicationCreateRe
 		return nil, err
 	}
 
+	// 自动评估质保资格并附带证据报告
+	attachWarrantyEvaluation(ctx, app, resp)
+
 	return resp, nil
 }
 
@@ -291,6 +294,15 @@ func (*Warranty) GetWarrantyList(req model.WarrantyApplicationListReq, claims *u
 		userMap[u.ID] = u
 	}
 
+	appIDs := make([]string, 0, len(apps))
+	for _, a := range apps {
+		appIDs = append(appIDs, a.ID)
+	}
+	recommendations, err := loadWarrantyRecommendations(ctx, appIDs)
+	if err != nil {
+		return nil, err
+	}
+
 	// 组装响应
 	list := make([]model.WarrantyApplicationResp, 0, len(apps))
 	for _, a := range apps {
@@ -298,6 +310,9 @@ func (*Warranty) GetWarrantyList(req model.WarrantyApplicationListReq, claims *u
 		if err != nil {
 			return nil, err
 		}
+		if r, ok := recommendations[a.ID]; ok {
+			resp.Recommendation = &r
+		}
 		list = append(list, *resp)
 	}
 
@@ -345,6 +360,16 @@ func (*Warranty) GetWarrantyDetail(id string, claims *utils.UserClaims) (*model.
 		return nil, err
 	}
 
+	// 自动评估证据报告
+	report, err := loadWarrantyEvaluation(ctx, app.ID)
+	if err != nil {
+		return nil, err
+	}
+	if report != nil {
+		resp.Recommendation = &report.Recommendation
+		resp.Evaluation = report
+	}
+
 	return resp, nil
 }
 
@@ -0,0 +1,489 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"project/internal/model"
+	"project/pkg/errcode"
+	"project/pkg/global"
+	"project/pkg/utils"
+
+	"github.com/go-basic/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 质保评估检查项
+const (
+	warrantyCheckPeriod          = "WARRANTY_PERIOD"
+	warrantyCheckActivation      = "ACTIVATION"
+	warrantyCheckCycleCount      = "CYCLE_COUNT"
+	warrantyCheckSoh             = "SOH"
+	warrantyCheckOverTemperature = "OVER_TEMPERATURE"
+	warrantyCheckDeepDischarge   = "DEEP_DISCHARGE"
+	warrantyCheckOverCurrent     = "OVER_CURRENT"
+)
+
+// defaultWarrantyEligibilityRule 未单独配置电池型号时使用的默认规则
+func defaultWarrantyEligibilityRule() model.WarrantyEligibilityRule {
+	return model.WarrantyEligibilityRule{
+		GraceDays:                0,
+		RequireActivation:        true,
+		OverTemperatureC:         60,
+		MaxOverTemperatureEvents: 3,
+		DeepDischargeSoc:         5,
+		MaxDeepDischargeEvents:   5,
+		MaxOverCurrentEvents:     3,
+	}
+}
+
+// warrantyEvidence 质保评估所需的电池事实数据
+type warrantyEvidence struct {
+	ClaimAt            time.Time
+	WarrantyExpireDate *time.Time
+	ActivationDate     *time.Time
+	CycleCount         *float64
+	Soh                *float64
+
+	// 以下来自 battery_cycle_sessions（激活后至申请时）
+	SessionCount          int64
+	OverTemperatureEvents int64
+	DeepDischargeEvents   int64
+	OverCurrentEvents     int64
+	MaxTemperature        *float64
+	MinDischargeSoc       *float64
+	PeakCurrent           *float64
+}
+
+// evaluateWarrantyEligibility 按规则逐项检查并给出建议：
+// 任一项 FAIL 建议驳回；否则任一项 UNKNOWN 建议人工复核；全部通过建议受理
+func evaluateWarrantyEligibility(ev warrantyEvidence, rule model.WarrantyEligibilityRule) (string, []string, []model.WarrantyEvaluationCheck) {
+	checks := make([]model.WarrantyEvaluationCheck, 0, 7)
+	add := func(code, result string, actual, threshold interface{}, msg string) {
+		checks = append(checks, model.WarrantyEvaluationCheck{
+			Code: code, Result: result, Actual: actual, Threshold: threshold, Message: msg,
+		})
+	}
+
+	// 1. 质保期
+	if ev.WarrantyExpireDate == nil {
+		add(warrantyCheckPeriod, model.WarrantyCheckUnknown, nil, nil, "未登记质保到期日")
+	} else {
+		deadline := ev.WarrantyExpireDate.AddDate(0, 0, int(rule.GraceDays)+1)
+		expire := ev.WarrantyExpireDate.Format("2006-01-02")
+		claim := ev.ClaimAt.In(time.Local).Format("2006-01-02")
+		if ev.ClaimAt.Before(deadline) {
+			add(warrantyCheckPeriod, model.WarrantyCheckPass, claim, expire, fmt.Sprintf("申请日期在质保期内（到期日 %s）", expire))
+		} else {
+			add(warrantyCheckPeriod, model.WarrantyCheckFail, claim, expire, fmt.Sprintf("申请日期已超出质保期（到期日 %s，宽限 %d 天）", expire, rule.GraceDays))
+		}
+	}
+
+	// 2. 激活状态
+	switch {
+	case !rule.RequireActivation:
+		add(warrantyCheckActivation, model.WarrantyCheckInfo, formatDatePtr(ev.ActivationDate), nil, "该型号不要求激活")
+	case ev.ActivationDate == nil:
+		add(warrantyCheckActivation, model.WarrantyCheckFail, nil, nil, "电池未激活")
+	case ev.ActivationDate.After(ev.ClaimAt):
+		add(warrantyCheckActivation, model.WarrantyCheckFail, formatDatePtr(ev.ActivationDate), nil, "申请时电池尚未激活")
+	default:
+		add(warrantyCheckActivation, model.WarrantyCheckPass, formatDatePtr(ev.ActivationDate), nil, "电池已激活")
+	}
+
+	// 3. 循环次数
+	switch {
+	case rule.MaxCycleCount == nil:
+		add(warrantyCheckCycleCount, model.WarrantyCheckInfo, ev.CycleCount, nil, "该型号未限制质保循环次数")
+	case ev.CycleCount == nil:
+		add(warrantyCheckCycleCount, model.WarrantyCheckUnknown, nil, *rule.MaxCycleCount, "暂无循环次数数据")
+	case *ev.CycleCount > *rule.MaxCycleCount:
+		add(warrantyCheckCycleCount, model.WarrantyCheckFail, *ev.CycleCount, *rule.MaxCycleCount,
+			fmt.Sprintf("累计循环 %.0f 次，超出质保上限 %.0f 次", *ev.CycleCount, *rule.MaxCycleCount))
+	default:
+		add(warrantyCheckCycleCount, model.WarrantyCheckPass, *ev.CycleCount, *rule.MaxCycleCount,
+			fmt.Sprintf("累计循环 %.0f 次，未超出质保上限", *ev.CycleCount))
+	}
+
+	// 4. SOH：低于承诺值说明容量缺陷成立；不低于时仅作参考（可能是其他故障）
+	var sohReason string
+	switch {
+	case ev.Soh == nil:
+		add(warrantyCheckSoh, model.WarrantyCheckInfo, nil, rule.SohGuarantee, "暂无SOH数据")
+	case rule.SohGuarantee == nil:
+		add(warrantyCheckSoh, model.WarrantyCheckInfo, *ev.Soh, nil, fmt.Sprintf("当前SOH %.1f%%", *ev.Soh))
+	case *ev.Soh < *rule.SohGuarantee:
+		sohReason = fmt.Sprintf("SOH %.1f%% 低于质保承诺 %.1f%%，容量缺陷成立", *ev.Soh, *rule.SohGuarantee)
+		add(warrantyCheckSoh, model.WarrantyCheckPass, *ev.Soh, *rule.SohGuarantee, sohReason)
+	default:
+		add(warrantyCheckSoh, model.WarrantyCheckInfo, *ev.Soh, *rule.SohGuarantee,
+			fmt.Sprintf("SOH %.1f%% 不低于质保承诺，需确认故障现象", *ev.Soh))
+	}
+
+	// 5-7. 滥用迹象
+	abuse := func(code string, events int64, maxEvents int32, actual, threshold interface{}, name string) {
+		switch {
+		case ev.SessionCount == 0:
+			add(code, model.WarrantyCheckUnknown, nil, threshold, "暂无充放电会话数据，无法判断"+name)
+		case events > int64(maxEvents):
+			add(code, model.WarrantyCheckFail, actual, threshold, fmt.Sprintf("%s %d 次，超出允许的 %d 次", name, events, maxEvents))
+		default:
+			add(code, model.WarrantyCheckPass, actual, threshold, fmt.Sprintf("%s %d 次，在允许范围内", name, events))
+		}
+	}
+	abuse(warrantyCheckOverTemperature, ev.OverTemperatureEvents, rule.MaxOverTemperatureEvents,
+		ev.MaxTemperature, rule.OverTemperatureC, fmt.Sprintf("过温(≥%.0f℃)", rule.OverTemperatureC))
+	abuse(warrantyCheckDeepDischarge, ev.DeepDischargeEvents, rule.MaxDeepDischargeEvents,
+		ev.MinDischargeSoc, rule.DeepDischargeSoc, fmt.Sprintf("深度放电(SOC≤%.0f%%)", rule.DeepDischargeSoc))
+	if rule.OverCurrentA == nil {
+		add(warrantyCheckOverCurrent, model.WarrantyCheckInfo, ev.PeakCurrent, nil, "该型号未配置过流阈值")
+	} else {
+		abuse(warrantyCheckOverCurrent, ev.OverCurrentEvents, rule.MaxOverCurrentEvents,
+			ev.PeakCurrent, *rule.OverCurrentA, fmt.Sprintf("过流(≥%.0fA)", *rule.OverCurrentA))
+	}
+
+	var failed, unknown []string
+	for _, c := range checks {
+		switch c.Result {
+		case model.WarrantyCheckFail:
+			failed = append(failed, c.Message)
+		case model.WarrantyCheckUnknown:
+			unknown = append(unknown, c.Message)
+		}
+	}
+	if len(failed) > 0 {
+		return model.WarrantyRecommendReject, failed, checks
+	}
+	if len(unknown) > 0 {
+		return model.WarrantyRecommendManualReview, unknown, checks
+	}
+	reasons := []string{"质保期内且未发现滥用迹象"}
+	if sohReason != "" {
+		reasons = append(reasons, sohReason)
+	}
+	return model.WarrantyRecommendApprove, reasons, checks
+}
+
+// loadWarrantyRule 获取电池型号的评估规则，未配置时返回默认规则
+func loadWarrantyRule(ctx context.Context, tenantID string, batteryModelID *string) (model.WarrantyEligibilityRule, string, error) {
+	if batteryModelID != nil && *batteryModelID != "" {
+		var rule model.WarrantyEligibilityRule
+		err := global.DB.WithContext(ctx).
+			Where("tenant_id = ? AND battery_model_id = ?", tenantID, *batteryModelID).
+			First(&rule).Error
+		if err == nil {
+			return rule, "MODEL", nil
+		}
+		if err != gorm.ErrRecordNotFound {
+			return rule, "", err
+		}
+	}
+	return defaultWarrantyEligibilityRule(), "DEFAULT", nil
+}
+
+// evaluateWarrantyApplication 汇总电池事实数据、执行规则并保存报告
+func evaluateWarrantyApplication(ctx context.Context, app *model.WarrantyApplication) (*model.WarrantyEvaluationReport, error) {
+	db := global.DB.WithContext(ctx)
+
+	claimAt := time.Now().UTC()
+	if app.CreatedAt != nil {
+		claimAt = *app.CreatedAt
+	}
+
+	var battery struct {
+		BatteryModelID     *string    `gorm:"column:battery_model_id"`
+		WarrantyExpireDate *time.Time `gorm:"column:warranty_expire_date"`
+		ActivationDate     *time.Time `gorm:"column:activation_date"`
+		CycleCount         *float64   `gorm:"column:cycle_count"`
+		Soh                *float64   `gorm:"column:soh"`
+	}
+	if err := db.Table("device_batteries").
+		Select("battery_model_id, warranty_expire_date, activation_date, cycle_count, soh").
+		Where("device_id = ?", app.DeviceID).
+		Limit(1).
+		Scan(&battery).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+
+	rule, source, err := loadWarrantyRule(ctx, app.TenantID, battery.BatteryModelID)
+	if err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+
+	ev := warrantyEvidence{
+		ClaimAt:            claimAt,
+		WarrantyExpireDate: battery.WarrantyExpireDate,
+		ActivationDate:     battery.ActivationDate,
+		CycleCount:         battery.CycleCount,
+		Soh:                battery.Soh,
+	}
+
+	// 滥用迹象统计：激活后至申请时的充放电会话
+	since := time.Time{}
+	if battery.ActivationDate != nil {
+		since = *battery.ActivationDate
+	}
+	var stats struct {
+		SessionCount          int64    `gorm:"column:session_count"`
+		OverTemperatureEvents int64    `gorm:"column:over_temperature_events"`
+		DeepDischargeEvents   int64    `gorm:"column:deep_discharge_events"`
+		OverCurrentEvents     int64    `gorm:"column:over_current_events"`
+		MaxTemperature        *float64 `gorm:"column:max_temperature"`
+		MinDischargeSoc       *float64 `gorm:"column:min_discharge_soc"`
+		PeakCurrent           *float64 `gorm:"column:peak_current"`
+	}
+	if err := db.Table("battery_cycle_sessions").
+		Select(`COUNT(*) AS session_count,
+			COUNT(*) FILTER (WHERE max_temperature >= ?) AS over_temperature_events,
+			COUNT(*) FILTER (WHERE session_type = ? AND end_soc <= ?) AS deep_discharge_events,
+			COUNT(*) FILTER (WHERE peak_current >= ?) AS over_current_events,
+			MAX(max_temperature) AS max_temperature,
+			MIN(end_soc) FILTER (WHERE session_type = ?) AS min_discharge_soc,
+			MAX(peak_current) AS peak_current`,
+			rule.OverTemperatureC, model.BatterySessionDischarge, rule.DeepDischargeSoc, rule.OverCurrentA, model.BatterySessionDischarge).
+		Where("device_id = ? AND start_at >= ? AND start_at <= ?", app.DeviceID, since, claimAt).
+		Scan(&stats).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	ev.SessionCount = stats.SessionCount
+	ev.OverTemperatureEvents = stats.OverTemperatureEvents
+	ev.DeepDischargeEvents = stats.DeepDischargeEvents
+	ev.OverCurrentEvents = stats.OverCurrentEvents
+	ev.MaxTemperature = stats.MaxTemperature
+	ev.MinDischargeSoc = stats.MinDischargeSoc
+	ev.PeakCurrent = stats.PeakCurrent
+
+	recommendation, reasons, checks := evaluateWarrantyEligibility(ev, rule)
+	now := time.Now().UTC()
+	report := &model.WarrantyEvaluationReport{
+		Recommendation: recommendation,
+		Reasons:        reasons,
+		Checks:         checks,
+		BatteryModelID: battery.BatteryModelID,
+		RuleSource:     source,
+		EvaluatedAt:    now.In(time.Local).Format("2006-01-02 15:04:05"),
+	}
+
+	b, err := json.Marshal(report)
+	if err != nil {
+		return nil, errcode.WithData(errcode.CodeSystemError, map[string]interface{}{"error": err.Error()})
+	}
+	if err := db.Table(model.TableNameWarrantyApplication).
+		Where("id = ?", app.ID).
+		Updates(map[string]interface{}{
+			"recommendation":    recommendation,
+			"evaluation_report": string(b),
+			"evaluated_at":      now,
+		}).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return report, nil
+}
+
+// attachWarrantyEvaluation 为新建申请自动评估，失败仅记录日志不影响申请创建
+func attachWarrantyEvaluation(ctx context.Context, app *model.WarrantyApplication, resp *model.WarrantyApplicationResp) {
+	report, err := evaluateWarrantyApplication(ctx, app)
+	if err != nil {
+		logrus.WithError(err).WithField("warranty_id", app.ID).Warn("warranty: eligibility evaluation failed")
+		return
+	}
+	resp.Recommendation = &report.Recommendation
+	resp.Evaluation = report
+}
+
+// loadWarrantyEvaluation 读取已保存的评估报告
+func loadWarrantyEvaluation(ctx context.Context, id string) (*model.WarrantyEvaluationReport, error) {
+	var row struct {
+		EvaluationReport *string `gorm:"column:evaluation_report"`
+	}
+	if err := global.DB.WithContext(ctx).Table(model.TableNameWarrantyApplication).
+		Select("evaluation_report").
+		Where("id = ?", id).
+		Limit(1).
+		Scan(&row).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	if row.EvaluationReport == nil || *row.EvaluationReport == "" {
+		return nil, nil
+	}
+	var report model.WarrantyEvaluationReport
+	if err := json.Unmarshal([]byte(*row.EvaluationReport), &report); err != nil {
+		return nil, nil
+	}
+	return &report, nil
+}
+
+// loadWarrantyRecommendations 批量读取评估建议（列表展示用）
+func loadWarrantyRecommendations(ctx context.Context, ids []string) (map[string]string, error) {
+	var rows []struct {
+		ID             string  `gorm:"column:id"`
+		Recommendation *string `gorm:"column:recommendation"`
+	}
+	if err := global.DB.WithContext(ctx).Table(model.TableNameWarrantyApplication).
+		Select("id, recommendation").
+		Where("id IN ?", ids).
+		Scan(&rows).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	result := make(map[string]string, len(rows))
+	for _, r := range rows {
+		if r.Recommendation != nil {
+			result[r.ID] = *r.Recommendation
+		}
+	}
+	return result, nil
+}
+
+// EvaluateWarrantyApplication 重新执行质保资格评估（规则或电池数据变化后）
+func (*Warranty) EvaluateWarrantyApplication(id string, claims *utils.UserClaims) (*model.WarrantyEvaluationReport, error) {
+	ctx := context.Background()
+
+	var app model.WarrantyApplication
+	if err := global.DB.WithContext(ctx).
+		Where("id = ? AND tenant_id = ?", id, claims.TenantID).
+		First(&app).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errcode.New(404)
+		}
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+
+	return evaluateWarrantyApplication(ctx, &app)
+}
+
+// ListEligibilityRules 列出租户下各电池型号的质保评估规则（未配置的型号返回默认规则）
+func (*Warranty) ListEligibilityRules(claims *utils.UserClaims) ([]model.WarrantyEligibilityRuleResp, error) {
+	ctx := context.Background()
+	db := global.DB.WithContext(ctx)
+
+	var models []model.BatteryModel
+	if err := db.Where("tenant_id = ?", claims.TenantID).Order("name ASC").Find(&models).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	var rules []model.WarrantyEligibilityRule
+	if err := db.Where("tenant_id = ?", claims.TenantID).Find(&rules).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	ruleMap := make(map[string]model.WarrantyEligibilityRule, len(rules))
+	for _, r := range rules {
+		ruleMap[r.BatteryModelID] = r
+	}
+
+	list := make([]model.WarrantyEligibilityRuleResp, 0, len(models))
+	for _, m := range models {
+		rule, ok := ruleMap[m.ID]
+		if !ok {
+			rule = defaultWarrantyEligibilityRule()
+		}
+		list = append(list, buildEligibilityRuleResp(m, rule, !ok))
+	}
+	return list, nil
+}
+
+// UpsertEligibilityRule 配置电池型号的质保评估规则（未传字段沿用当前值）
+func (*Warranty) UpsertEligibilityRule(batteryModelID string, req model.WarrantyEligibilityRuleUpsertReq, claims *utils.UserClaims) (*model.WarrantyEligibilityRuleResp, error) {
+	ctx := context.Background()
+	db := global.DB.WithContext(ctx)
+
+	var bm model.BatteryModel
+	if err := db.Where("id = ? AND tenant_id = ?", batteryModelID, claims.TenantID).First(&bm).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errcode.WithData(errcode.CodeNotFound, map[string]interface{}{"message": "电池型号不存在"})
+		}
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+
+	rule, source, err := loadWarrantyRule(ctx, claims.TenantID, &batteryModelID)
+	if err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	now := time.Now().UTC()
+	if source == "DEFAULT" {
+		rule.ID = uuid.New()
+		rule.TenantID = claims.TenantID
+		rule.BatteryModelID = batteryModelID
+		rule.CreatedAt = now
+	}
+	rule.UpdatedAt = now
+
+	if req.GraceDays != nil {
+		rule.GraceDays = *req.GraceDays
+	}
+	if req.RequireActivation != nil {
+		rule.RequireActivation = *req.RequireActivation
+	}
+	if req.MaxCycleCount != nil {
+		rule.MaxCycleCount = req.MaxCycleCount
+	}
+	if req.SohGuarantee != nil {
+		rule.SohGuarantee = req.SohGuarantee
+	}
+	if req.OverTemperatureC != nil {
+		rule.OverTemperatureC = *req.OverTemperatureC
+	}
+	if req.MaxOverTemperatureEvents != nil {
+		rule.MaxOverTemperatureEvents = *req.MaxOverTemperatureEvents
+	}
+	if req.DeepDischargeSoc != nil {
+		rule.DeepDischargeSoc = *req.DeepDischargeSoc
+	}
+	if req.MaxDeepDischargeEvents != nil {
+		rule.MaxDeepDischargeEvents = *req.MaxDeepDischargeEvents
+	}
+	if req.OverCurrentA != nil {
+		rule.OverCurrentA = req.OverCurrentA
+	}
+	if req.MaxOverCurrentEvents != nil {
+		rule.MaxOverCurrentEvents = *req.MaxOverCurrentEvents
+	}
+	if req.Remark != nil {
+		rule.Remark = req.Remark
+	}
+
+	if err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "battery_model_id"}},
+		UpdateAll: true,
+	}).Create(&rule).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+
+	resp := buildEligibilityRuleResp(bm, rule, false)
+	return &resp, nil
+}
+
+// DeleteEligibilityRule 删除电池型号的评估规则，恢复使用默认规则
+func (*Warranty) DeleteEligibilityRule(batteryModelID string, claims *utils.UserClaims) error {
+	if err := global.DB.WithContext(context.Background()).
+		Where("tenant_id = ? AND battery_model_id = ?", claims.TenantID, batteryModelID).
+		Delete(&model.WarrantyEligibilityRule{}).Error; err != nil {
+		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return nil
+}
+
+func buildEligibilityRuleResp(bm model.BatteryModel, rule model.WarrantyEligibilityRule, isDefault bool) model.WarrantyEligibilityRuleResp {
+	resp := model.WarrantyEligibilityRuleResp{
+		BatteryModelID:           bm.ID,
+		BatteryModelName:         bm.Name,
+		IsDefault:                isDefault,
+		GraceDays:                rule.GraceDays,
+		RequireActivation:        rule.RequireActivation,
+		MaxCycleCount:            rule.MaxCycleCount,
+		SohGuarantee:             rule.SohGuarantee,
+		OverTemperatureC:         rule.OverTemperatureC,
+		MaxOverTemperatureEvents: rule.MaxOverTemperatureEvents,
+		DeepDischargeSoc:         rule.DeepDischargeSoc,
+		MaxDeepDischargeEvents:   rule.MaxDeepDischargeEvents,
+		OverCurrentA:             rule.OverCurrentA,
+		MaxOverCurrentEvents:     rule.MaxOverCurrentEvents,
+		Remark:                   rule.Remark,
+	}
+	if !isDefault {
+		resp.UpdatedAt = formatTimePtr(&rule.UpdatedAt)
+	}
+	return resp
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"project/internal/model"
+)
+
+func baseWarrantyEvidence() warrantyEvidence {
+	claim := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+	expire := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	activated := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cycles, soh := 300.0, 72.0
+	return warrantyEvidence{
+		ClaimAt:            claim,
+		WarrantyExpireDate: &expire,
+		ActivationDate:     &activated,
+		CycleCount:         &cycles,
+		Soh:                &soh,
+		SessionCount:       500,
+	}
+}
+
+func findWarrantyCheck(checks []model.WarrantyEvaluationCheck, code string) model.WarrantyEvaluationCheck {
+	for _, c := range checks {
+		if c.Code == code {
+			return c
+		}
+	}
+	return model.WarrantyEvaluationCheck{}
+}
+
+func TestEvaluateWarrantyEligibility_Approve(t *testing.T) {
+	rule := defaultWarrantyEligibilityRule()
+	maxCycles, guarantee := 1500.0, 80.0
+	rule.MaxCycleCount = &maxCycles
+	rule.SohGuarantee = &guarantee
+
+	rec, reasons, checks := evaluateWarrantyEligibility(baseWarrantyEvidence(), rule)
+	if rec != model.WarrantyRecommendApprove {
+		t.Fatalf("expected APPROVE, got %s (%v)", rec, reasons)
+	}
+	if c := findWarrantyCheck(checks, warrantyCheckSoh); c.Result != model.WarrantyCheckPass {
+		t.Fatalf("expected SOH below guarantee to support claim, got %s", c.Result)
+	}
+}
+
+func TestEvaluateWarrantyEligibility_ExpiredWithGrace(t *testing.T) {
+	ev := baseWarrantyEvidence()
+	ev.ClaimAt = ev.WarrantyExpireDate.AddDate(0, 0, 10)
+	rule := defaultWarrantyEligibilityRule()
+
+	if rec, _, _ := evaluateWarrantyEligibility(ev, rule); rec != model.WarrantyRecommendReject {
+		t.Fatalf("expected REJECT after expiry, got %s", rec)
+	}
+	rule.GraceDays = 30
+	if rec, _, _ := evaluateWarrantyEligibility(ev, rule); rec != model.WarrantyRecommendApprove {
+		t.Fatalf("expected APPROVE within grace days, got %s", rec)
+	}
+}
+
+func TestEvaluateWarrantyEligibility_AbuseRejects(t *testing.T) {
+	ev := baseWarrantyEvidence()
+	ev.DeepDischargeEvents = 12
+	rec, reasons, checks := evaluateWarrantyEligibility(ev, defaultWarrantyEligibilityRule())
+	if rec != model.WarrantyRecommendReject || len(reasons) != 1 {
+		t.Fatalf("expected single-reason REJECT, got %s %v", rec, reasons)
+	}
+	if c := findWarrantyCheck(checks, warrantyCheckDeepDischarge); c.Result != model.WarrantyCheckFail {
+		t.Fatalf("expected deep discharge check to fail, got %s", c.Result)
+	}
+}
+
+func TestEvaluateWarrantyEligibility_NoTelemetryNeedsReview(t *testing.T) {
+	ev := baseWarrantyEvidence()
+	ev.SessionCount = 0
+	if rec, _, _ := evaluateWarrantyEligibility(ev, defaultWarrantyEligibilityRule()); rec != model.WarrantyRecommendManualReview {
+		t.Fatalf("expected MANUAL_REVIEW without session data, got %s", rec)
+	}
+}
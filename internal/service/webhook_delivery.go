@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	dal "project/internal/dal"
+	"project/internal/model"
+	"project/pkg/global"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	webhookMaxAttempts   = 12               // 最大投递次数，按退避间隔约覆盖 12 小时
+	webhookBaseBackoff   = 30 * time.Second // 首次重试间隔
+	webhookMaxBackoff    = 2 * time.Hour    // 重试间隔上限
+	webhookLease         = 2 * time.Minute  // 投递中的记录在租约内不会被其他实例重复领取
+	webhookBatchSize     = 50               // 每轮领取的记录数
+	webhookWorkers       = 5                // 每轮并发投递数
+	webhookRetentionDays = 30               // 已结束投递记录保留天数
+	webhookErrorMax      = 1000             // 记录的错误信息最大长度
+)
+
+// webhookHTTPClient Webhook投递客户端
+var webhookHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// webhookBackoff 第 attempts 次投递失败后的重试间隔：30s 起指数增长，上限 2 小时
+func webhookBackoff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	d := webhookBaseBackoff
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= webhookMaxBackoff {
+			return webhookMaxBackoff
+		}
+	}
+	return d
+}
+
+// webhookSignature 带时间戳的签名：hex(HMAC-SHA256(secret, "timestamp.body"))，接收方校验时间戳防重放
+func webhookSignature(secret string, timestamp int64, body string) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(strconv.FormatInt(timestamp, 10) + "." + body))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// webhookLegacySignature 兼容旧版的签名：hex(HMAC-SHA256(secret, body))
+func webhookLegacySignature(secret, body string) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(body))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// newWebhookRequest 构造投递请求并签名
+func newWebhookRequest(ctx context.Context, d *model.WebhookDelivery, secret string, now time.Time) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TargetURL, strings.NewReader(d.Payload))
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %v", err)
+	}
+	timestamp := now.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Id", d.ID)
+	req.Header.Set("X-Webhook-Event", d.EventType)
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Webhook-Attempt", strconv.Itoa(int(d.Attempts)+1))
+	if secret != "" {
+		req.Header.Set("X-Webhook-Signature", "t="+strconv.FormatInt(timestamp, 10)+",v1="+webhookSignature(secret, timestamp, d.Payload))
+		req.Header.Set("X-Signature-256", "sha256="+webhookLegacySignature(secret, d.Payload))
+	}
+	return req, nil
+}
+
+// sendWebhookDelivery 发送一次投递，返回响应状态码
+func sendWebhookDelivery(ctx context.Context, d *model.WebhookDelivery, secret string) (int, error) {
+	req, err := newWebhookRequest(ctx, d, secret, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	resp, err := webhookHTTPClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("发送请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4*1024))
+	if resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("HTTP请求失败，状态码: %d, 响应: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return resp.StatusCode, nil
+}
+
+// webhookDeliverySecret 投递时读取签名密钥：订阅投递取订阅密钥，通知组投递取通知组配置，密钥轮换后重试使用新密钥
+func webhookDeliverySecret(d *model.WebhookDelivery) string {
+	if d.SubscriptionID != nil {
+		var secret string
+		global.DB.Model(&model.WebhookSubscription{}).Where("id = ?", *d.SubscriptionID).Limit(1).Pluck("secret", &secret)
+		return secret
+	}
+	if d.NotificationGroupID != nil {
+		var config *string
+		global.DB.Model(&model.NotificationGroup{}).Where("id = ?", *d.NotificationGroupID).Limit(1).Pluck("notification_config", &config)
+		if config != nil {
+			var cfg struct{ Secret string }
+			_ = json.Unmarshal([]byte(*config), &cfg)
+			return cfg.Secret
+		}
+	}
+	return ""
+}
+
+// enqueueWebhookDelivery 写入投递队列；新记录先持有一个租约，由调用方立即投递一次，失败后按退避重试
+func enqueueWebhookDelivery(d *model.WebhookDelivery) error {
+	now := time.Now().UTC()
+	d.Status = model.WebhookDeliveryPending
+	d.Attempts = 0
+	d.NextAttemptAt = now.Add(webhookLease)
+	d.CreatedAt = now
+	d.UpdatedAt = now
+	return global.DB.Create(d).Error
+}
+
+// attemptWebhookDelivery 投递一次并记录结果：成功置为 SUCCESS，失败按退避安排下次投递，次数耗尽置为 DEAD
+func attemptWebhookDelivery(d *model.WebhookDelivery) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	statusCode, sendErr := sendWebhookDelivery(ctx, d, webhookDeliverySecret(d))
+	cancel()
+
+	now := time.Now().UTC()
+	d.Attempts++
+	d.UpdatedAt = now
+	if statusCode > 0 {
+		code := int32(statusCode)
+		d.LastStatusCode = &code
+	}
+	if sendErr == nil {
+		d.Status = model.WebhookDeliverySuccess
+		d.DeliveredAt = &now
+		d.LastError = nil
+	} else {
+		msg := sendErr.Error()
+		if r := []rune(msg); len(r) > webhookErrorMax {
+			msg = string(r[:webhookErrorMax])
+		}
+		d.LastError = &msg
+		if d.Attempts >= webhookMaxAttempts {
+			d.Status = model.WebhookDeliveryDead
+		} else {
+			d.NextAttemptAt = now.Add(webhookBackoff(int(d.Attempts)))
+		}
+	}
+	if err := global.DB.Model(&model.WebhookDelivery{}).Where("id = ?", d.ID).Updates(map[string]interface{}{
+		"status":           d.Status,
+		"attempts":         d.Attempts,
+		"next_attempt_at":  d.NextAttemptAt,
+		"last_status_code": d.LastStatusCode,
+		"last_error":       d.LastError,
+		"delivered_at":     d.DeliveredAt,
+		"updated_at":       d.UpdatedAt,
+	}).Error; err != nil {
+		logrus.Error("更新Webhook投递记录失败:", err)
+	}
+	syncWebhookNotificationHistory(d)
+	if sendErr != nil {
+		logrus.Warn(fmt.Sprintf("Webhook投递失败，第%d次尝试(%s):", d.Attempts, d.TargetURL), sendErr)
+	}
+	return sendErr
+}
+
+// syncWebhookNotificationHistory 通知组 Webhook 的投递结果同步到通知历史
+func syncWebhookNotificationHistory(d *model.WebhookDelivery) {
+	if d.NotificationHistoryID == nil {
+		return
+	}
+	var err error
+	switch d.Status {
+	case model.WebhookDeliverySuccess:
+		status := "SUCCESS"
+		remark := fmt.Sprintf("第%d次投递成功", d.Attempts)
+		_, err = dal.UpdateNotificationHistory(*d.NotificationHistoryID, &status, &remark)
+	case model.WebhookDeliveryDead:
+		status := "FAILURE"
+		remark := fmt.Sprintf("重试%d次后投递失败: %s", d.Attempts, *d.LastError)
+		_, err = dal.UpdateNotificationHistory(*d.NotificationHistoryID, &status, &remark)
+	default:
+		remark := fmt.Sprintf("第%d次投递失败，%s后重试: %s", d.Attempts, d.NextAttemptAt.Local().Format("2006-01-02 15:04:05"), *d.LastError)
+		_, err = dal.UpdateNotificationHistory(*d.NotificationHistoryID, nil, &remark)
+	}
+	if err != nil {
+		logrus.Error("更新webhook通知历史记录失败:", err)
+	}
+}
+
+// claimDueWebhookDeliveries 领取到期的投递记录并延后下次投递时间作为租约，多实例部署时互不重复
+func claimDueWebhookDeliveries(now time.Time) ([]model.WebhookDelivery, error) {
+	list := make([]model.WebhookDelivery, 0)
+	err := global.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", model.WebhookDeliveryPending, now).
+			Order("next_attempt_at").
+			Limit(webhookBatchSize).
+			Find(&list).Error; err != nil {
+			return err
+		}
+		if len(list) == 0 {
+			return nil
+		}
+		ids := make([]string, 0, len(list))
+		for _, d := range list {
+			ids = append(ids, d.ID)
+		}
+		return tx.Model(&model.WebhookDelivery{}).Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(webhookLease)).Error
+	})
+	return list, err
+}
+
+// ProcessWebhookDeliveriesByCron 定时投递到期的 Webhook
+func (*WebhookSubscription) ProcessWebhookDeliveriesByCron() {
+	list, err := claimDueWebhookDeliveries(time.Now().UTC())
+	if err != nil {
+		logrus.Error("领取Webhook投递记录失败:", err)
+		return
+	}
+	if len(list) == 0 {
+		return
+	}
+	sem := make(chan struct{}, webhookWorkers)
+	var wg sync.WaitGroup
+	for i := range list {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(d *model.WebhookDelivery) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			_ = attemptWebhookDelivery(d)
+		}(&list[i])
+	}
+	wg.Wait()
+}
+
+// CleanWebhookDeliveriesByCron 清理过期的已结束投递记录
+func (*WebhookSubscription) CleanWebhookDeliveriesByCron() {
+	res := global.DB.Where("status <> ? AND updated_at < ?", model.WebhookDeliveryPending, time.Now().UTC().AddDate(0, 0, -webhookRetentionDays)).
+		Delete(&model.WebhookDelivery{})
+	if res.Error != nil {
+		logrus.Error("清理Webhook投递记录失败:", res.Error)
+		return
+	}
+	logrus.Debug("清理Webhook投递记录:", res.RowsAffected)
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"project/internal/model"
+)
+
+func TestWebhookBackoff(t *testing.T) {
+	cases := map[int]time.Duration{
+		0:  30 * time.Second,
+		1:  30 * time.Second,
+		2:  time.Minute,
+		5:  8 * time.Minute,
+		8:  64 * time.Minute,
+		9:  2 * time.Hour,
+		11: 2 * time.Hour,
+	}
+	for attempts, want := range cases {
+		if got := webhookBackoff(attempts); got != want {
+			t.Errorf("webhookBackoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+	// 重试总跨度覆盖数小时
+	var total time.Duration
+	for i := 1; i < webhookMaxAttempts; i++ {
+		total += webhookBackoff(i)
+	}
+	if total < 6*time.Hour {
+		t.Fatalf("total retry window = %v", total)
+	}
+}
+
+func TestSendWebhookDeliverySignature(t *testing.T) {
+	srv, req, body := robotStub(t, 204, "")
+	d := &model.WebhookDelivery{ID: "d1", EventType: model.WebhookEventAlarm, TargetURL: srv.URL, Payload: `{"a":"<b>"}`, Attempts: 2}
+	status, err := sendWebhookDelivery(context.Background(), d, "secret")
+	if err != nil || status != 204 {
+		t.Fatalf("send: %d %v", status, err)
+	}
+	if string(*body) != d.Payload {
+		t.Fatalf("body = %s", *body)
+	}
+	h := req.Header
+	ts, _ := strconv.ParseInt(h.Get("X-Webhook-Timestamp"), 10, 64)
+	if time.Since(time.Unix(ts, 0)) > time.Minute {
+		t.Fatalf("timestamp = %d", ts)
+	}
+	want := "t=" + strconv.FormatInt(ts, 10) + ",v1=" + webhookSignature("secret", ts, d.Payload)
+	if h.Get("X-Webhook-Signature") != want || h.Get("X-Webhook-Id") != "d1" || h.Get("X-Webhook-Event") != "alarm" || h.Get("X-Webhook-Attempt") != "3" {
+		t.Fatalf("headers = %v", h)
+	}
+	if h.Get("X-Signature-256") != "sha256="+webhookLegacySignature("secret", d.Payload) {
+		t.Fatalf("legacy signature = %s", h.Get("X-Signature-256"))
+	}
+	// 签名绑定时间戳，换时间戳后不再匹配
+	if webhookSignature("secret", ts+1, d.Payload) == webhookSignature("secret", ts, d.Payload) {
+		t.Fatal("signature must depend on timestamp")
+	}
+
+	srv2, req2, _ := robotStub(t, 500, "boom")
+	d.TargetURL = srv2.URL
+	status, err = sendWebhookDelivery(context.Background(), d, "")
+	if err == nil || status != 500 || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("want failure, got %d %v", status, err)
+	}
+	if req2.Header.Get("X-Webhook-Signature") != "" {
+		t.Fatal("no signature without secret")
+	}
+}
+
+func TestWebhookSubscriberMatches(t *testing.T) {
+	devices := `["d1","d2"]`
+	configs := `["c1"]`
+	s := newWebhookSubscriber(model.WebhookSubscription{EventTypes: `["alarm","telemetry"]`, DeviceIDs: &devices})
+	if !s.matches(model.WebhookEventAlarm, []string{"d3", "d2"}, nil) {
+		t.Fatal("device filter should match any listed device")
+	}
+	if s.matches(model.WebhookEventAlarm, []string{"d3"}, nil) {
+		t.Fatal("device filter should reject other devices")
+	}
+	if s.matches(model.WebhookEventDeviceOnline, []string{"d1"}, nil) {
+		t.Fatal("event not subscribed")
+	}
+	if !s.matches(model.WebhookEventAlarm, nil, nil) {
+		t.Fatal("events without devices are not filtered")
+	}
+
+	s = newWebhookSubscriber(model.WebhookSubscription{EventTypes: `["telemetry"]`, DeviceIDs: &devices, DeviceConfigIDs: &configs})
+	if !s.matches(model.WebhookEventTelemetry, []string{"d1"}, map[string]string{"d1": "c1"}) {
+		t.Fatal("both filters satisfied")
+	}
+	if s.matches(model.WebhookEventTelemetry, []string{"d1"}, map[string]string{"d1": "c2"}) {
+		t.Fatal("device config filter should apply")
+	}
+}
+
+func TestWebhookEventPayload(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	payload, err := webhookEventPayload("id1", "t1", model.WebhookEventTelemetry, map[string]interface{}{"values": map[string]interface{}{"soc": 80}}, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal([]byte(payload), &got); err != nil {
+		t.Fatalf("payload = %s", payload)
+	}
+	if got["id"] != "id1" || got["event"] != "telemetry" || got["tenant_id"] != "t1" || got["occurred_at"] != "2026-01-02T03:04:05Z" {
+		t.Fatalf("payload = %s", payload)
+	}
+	if _, err := normalizeWebhookEventTypes([]string{"alarm", "nope"}); err == nil {
+		t.Fatal("unknown event type should be rejected")
+	}
+	if list, _ := normalizeWebhookEventTypes([]string{"alarm", " alarm", "telemetry"}); len(list) != 2 {
+		t.Fatalf("dedupe = %v", list)
+	}
+}
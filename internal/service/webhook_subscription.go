@@ -0,0 +1,519 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	dal "project/internal/dal"
+	"project/internal/model"
+	"project/pkg/common"
+	"project/pkg/errcode"
+	"project/pkg/global"
+	"project/pkg/utils"
+
+	"github.com/go-basic/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// webhookSubscriptionCacheTTL 租户订阅缓存有效期，遥测等高频事件不必每条查库
+const webhookSubscriptionCacheTTL = 30 * time.Second
+
+type WebhookSubscription struct{}
+
+// webhookSubscriber 解析后的订阅及过滤条件
+type webhookSubscriber struct {
+	sub           model.WebhookSubscription
+	events        map[string]bool
+	devices       map[string]bool
+	deviceConfigs map[string]bool
+}
+
+type webhookSubscriberCacheEntry struct {
+	subscribers []*webhookSubscriber
+	expiresAt   time.Time
+}
+
+var webhookSubscriberCache = struct {
+	sync.RWMutex
+	tenants map[string]webhookSubscriberCacheEntry
+}{tenants: make(map[string]webhookSubscriberCacheEntry)}
+
+// webhookStringSet JSON 字符串数组转集合，空值返回 nil 表示不过滤
+func webhookStringSet(raw *string) map[string]bool {
+	if raw == nil || strings.TrimSpace(*raw) == "" {
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal([]byte(*raw), &list); err != nil || len(list) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(list))
+	for _, v := range list {
+		set[v] = true
+	}
+	return set
+}
+
+func newWebhookSubscriber(sub model.WebhookSubscription) *webhookSubscriber {
+	events := webhookStringSet(&sub.EventTypes)
+	if events == nil {
+		events = map[string]bool{}
+	}
+	return &webhookSubscriber{
+		sub:           sub,
+		events:        events,
+		devices:       webhookStringSet(sub.DeviceIDs),
+		deviceConfigs: webhookStringSet(sub.DeviceConfigIDs),
+	}
+}
+
+// matches 判断事件是否命中订阅；设备过滤与设备配置过滤同时配置时需同时满足，事件不涉及设备时不受设备过滤影响
+func (s *webhookSubscriber) matches(eventType string, deviceIDs []string, deviceConfigs map[string]string) bool {
+	if !s.events[eventType] {
+		return false
+	}
+	if len(deviceIDs) == 0 || (s.devices == nil && s.deviceConfigs == nil) {
+		return true
+	}
+	for _, id := range deviceIDs {
+		if s.devices != nil && !s.devices[id] {
+			continue
+		}
+		if s.deviceConfigs != nil && !s.deviceConfigs[deviceConfigs[id]] {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// tenantWebhookSubscribers 租户已启用的订阅（带缓存）
+func tenantWebhookSubscribers(tenantID string) []*webhookSubscriber {
+	now := time.Now()
+	webhookSubscriberCache.RLock()
+	entry, ok := webhookSubscriberCache.tenants[tenantID]
+	webhookSubscriberCache.RUnlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.subscribers
+	}
+
+	var subs []model.WebhookSubscription
+	if err := global.DB.Where("tenant_id = ? AND enabled", tenantID).Find(&subs).Error; err != nil {
+		logrus.Error("查询Webhook订阅失败:", err)
+		return entry.subscribers
+	}
+	subscribers := make([]*webhookSubscriber, 0, len(subs))
+	for _, sub := range subs {
+		subscribers = append(subscribers, newWebhookSubscriber(sub))
+	}
+	webhookSubscriberCache.Lock()
+	webhookSubscriberCache.tenants[tenantID] = webhookSubscriberCacheEntry{subscribers: subscribers, expiresAt: now.Add(webhookSubscriptionCacheTTL)}
+	webhookSubscriberCache.Unlock()
+	return subscribers
+}
+
+// invalidateWebhookSubscribers 订阅变更后清除租户缓存
+func invalidateWebhookSubscribers(tenantID string) {
+	webhookSubscriberCache.Lock()
+	delete(webhookSubscriberCache.tenants, tenantID)
+	webhookSubscriberCache.Unlock()
+}
+
+// webhookEventPayload 事件投递的统一信封
+func webhookEventPayload(id, tenantID, eventType string, data interface{}, occurredAt time.Time) (string, error) {
+	buffer := &bytes.Buffer{}
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	err := encoder.Encode(map[string]interface{}{
+		"id":          id,
+		"event":       eventType,
+		"tenant_id":   tenantID,
+		"occurred_at": occurredAt.UTC().Format(time.RFC3339Nano),
+		"data":        data,
+	})
+	return strings.TrimSpace(buffer.String()), err
+}
+
+// publishWebhookEvent 向命中的订阅投递事件，每个订阅一条投递记录，首次投递失败后由定时任务重试
+func publishWebhookEvent(tenantID, eventType string, deviceIDs []string, data interface{}) {
+	subscribers := tenantWebhookSubscribers(tenantID)
+	if len(subscribers) == 0 {
+		return
+	}
+	// 仅在有订阅按设备配置过滤时查询设备配置
+	var deviceConfigs map[string]string
+	for _, s := range subscribers {
+		if s.deviceConfigs != nil && s.events[eventType] && len(deviceIDs) > 0 {
+			var rows []struct {
+				ID             string
+				DeviceConfigID *string
+			}
+			global.DB.Model(&model.Device{}).Select("id, device_config_id").Where("id IN ?", deviceIDs).Scan(&rows)
+			deviceConfigs = make(map[string]string, len(rows))
+			for _, r := range rows {
+				if r.DeviceConfigID != nil {
+					deviceConfigs[r.ID] = *r.DeviceConfigID
+				}
+			}
+			break
+		}
+	}
+
+	now := time.Now()
+	for _, s := range subscribers {
+		if !s.matches(eventType, deviceIDs, deviceConfigs) {
+			continue
+		}
+		id := uuid.New()
+		payload, err := webhookEventPayload(id, tenantID, eventType, data, now)
+		if err != nil {
+			logrus.Error("构建Webhook事件失败:", err)
+			return
+		}
+		subscriptionID := s.sub.ID
+		d := &model.WebhookDelivery{
+			ID:             id,
+			TenantID:       tenantID,
+			SubscriptionID: &subscriptionID,
+			EventType:      eventType,
+			TargetURL:      s.sub.TargetURL,
+			Payload:        payload,
+		}
+		if err := enqueueWebhookDelivery(d); err != nil {
+			logrus.Error("写入Webhook投递队列失败:", err)
+			continue
+		}
+		go attemptWebhookDelivery(d)
+	}
+}
+
+// webhookDeviceData 设备类事件的公共字段
+func webhookDeviceData(device *model.Device) map[string]interface{} {
+	name := device.DeviceNumber
+	if device.Name != nil {
+		name = *device.Name
+	}
+	return map[string]interface{}{
+		"device_id":        device.ID,
+		"device_number":    device.DeviceNumber,
+		"device_name":      name,
+		"device_config_id": device.DeviceConfigID,
+	}
+}
+
+// PublishDeviceStatus 设备上下线事件
+func (*WebhookSubscription) PublishDeviceStatus(device *model.Device, online bool) {
+	eventType := model.WebhookEventDeviceOffline
+	if online {
+		eventType = model.WebhookEventDeviceOnline
+	}
+	data := webhookDeviceData(device)
+	data["is_online"] = online
+	publishWebhookEvent(device.TenantID, eventType, []string{device.ID}, data)
+}
+
+// PublishTelemetry 遥测上报事件
+func (*WebhookSubscription) PublishTelemetry(device *model.Device, values map[string]interface{}) {
+	if len(values) == 0 {
+		return
+	}
+	data := webhookDeviceData(device)
+	data["values"] = values
+	publishWebhookEvent(device.TenantID, model.WebhookEventTelemetry, []string{device.ID}, data)
+}
+
+// PublishOtaProgress OTA 升级进度事件
+func (*WebhookSubscription) PublishOtaProgress(detail *model.OtaUpgradeTaskDetail) {
+	var device model.Device
+	if err := global.DB.Select("id, tenant_id, name, device_number, device_config_id").Where("id = ?", detail.DeviceID).First(&device).Error; err != nil {
+		logrus.Error("OTA进度Webhook查询设备失败:", err)
+		return
+	}
+	data := webhookDeviceData(&device)
+	data["task_id"] = detail.OtaUpgradeTaskID
+	data["task_detail_id"] = detail.ID
+	data["status"] = detail.Status
+	data["step"] = detail.Step
+	data["status_description"] = detail.StatusDescription
+	publishWebhookEvent(device.TenantID, model.WebhookEventOtaProgress, []string{device.ID}, data)
+}
+
+// normalizeWebhookEventTypes 校验并去重订阅的事件类型
+func normalizeWebhookEventTypes(list []string) ([]string, error) {
+	allowed := make(map[string]bool, len(model.WebhookEventTypes))
+	for _, t := range model.WebhookEventTypes {
+		allowed[t] = true
+	}
+	out := make([]string, 0, len(list))
+	for _, t := range list {
+		t = strings.TrimSpace(t)
+		if !allowed[t] {
+			return nil, errcode.WithData(errcode.CodeParamError, map[string]interface{}{"message": "unsupported event type: " + t})
+		}
+		out = append(out, t)
+	}
+	return uniqueStrings(out), nil
+}
+
+// webhookJSONList 过滤列表序列化，空列表存 NULL
+func webhookJSONList(list []string) *string {
+	if len(list) == 0 {
+		return nil
+	}
+	b, _ := json.Marshal(uniqueStrings(append([]string{}, list...)))
+	s := string(b)
+	return &s
+}
+
+// maskWebhookSecret 列表中不返回完整密钥
+func maskWebhookSecret(sub *model.WebhookSubscription) {
+	if r := []rune(sub.Secret); len(r) > 4 {
+		sub.Secret = "****" + string(r[len(r)-4:])
+	} else {
+		sub.Secret = "****"
+	}
+}
+
+// CreateWebhookSubscription 创建Webhook订阅，未指定密钥时自动生成，仅在创建时返回完整密钥
+func (*WebhookSubscription) CreateWebhookSubscription(ctx context.Context, req *model.CreateWebhookSubscriptionReq, claims *utils.UserClaims) (*model.WebhookSubscription, error) {
+	eventTypes, err := normalizeWebhookEventTypes(req.EventTypes)
+	if err != nil {
+		return nil, err
+	}
+	secret := ""
+	if req.Secret != nil {
+		secret = *req.Secret
+	} else if secret, err = common.GenerateRandomString(32); err != nil {
+		return nil, err
+	}
+	eventTypesJSON, _ := json.Marshal(eventTypes)
+	now := time.Now().UTC()
+	sub := &model.WebhookSubscription{
+		ID:              uuid.New(),
+		TenantID:        claims.TenantID,
+		Name:            req.Name,
+		TargetURL:       req.TargetURL,
+		Secret:          secret,
+		EventTypes:      string(eventTypesJSON),
+		DeviceIDs:       webhookJSONList(req.DeviceIDs),
+		DeviceConfigIDs: webhookJSONList(req.DeviceConfigIDs),
+		Enabled:         req.Enabled == nil || *req.Enabled,
+		Remark:          req.Remark,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if err := global.DB.WithContext(ctx).Create(sub).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	invalidateWebhookSubscribers(claims.TenantID)
+	return sub, nil
+}
+
+func getWebhookSubscription(ctx context.Context, id, tenantID string) (*model.WebhookSubscription, error) {
+	var sub model.WebhookSubscription
+	err := global.DB.WithContext(ctx).Where("id = ? AND tenant_id = ?", id, tenantID).First(&sub).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, errcode.New(errcode.CodeNotFound)
+	}
+	if err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return &sub, nil
+}
+
+// UpdateWebhookSubscription 更新Webhook订阅
+func (*WebhookSubscription) UpdateWebhookSubscription(ctx context.Context, id string, req *model.UpdateWebhookSubscriptionReq, claims *utils.UserClaims) (*model.WebhookSubscription, error) {
+	sub, err := getWebhookSubscription(ctx, id, claims.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name != nil {
+		sub.Name = *req.Name
+	}
+	if req.TargetURL != nil {
+		sub.TargetURL = *req.TargetURL
+	}
+	if req.Secret != nil {
+		sub.Secret = *req.Secret
+	}
+	if req.EventTypes != nil {
+		eventTypes, err := normalizeWebhookEventTypes(*req.EventTypes)
+		if err != nil {
+			return nil, err
+		}
+		b, _ := json.Marshal(eventTypes)
+		sub.EventTypes = string(b)
+	}
+	if req.DeviceIDs != nil {
+		sub.DeviceIDs = webhookJSONList(*req.DeviceIDs)
+	}
+	if req.DeviceConfigIDs != nil {
+		sub.DeviceConfigIDs = webhookJSONList(*req.DeviceConfigIDs)
+	}
+	if req.Enabled != nil {
+		sub.Enabled = *req.Enabled
+	}
+	if req.Remark != nil {
+		sub.Remark = req.Remark
+	}
+	sub.UpdatedAt = time.Now().UTC()
+	if err := global.DB.WithContext(ctx).Save(sub).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	invalidateWebhookSubscribers(claims.TenantID)
+	maskWebhookSecret(sub)
+	return sub, nil
+}
+
+// DeleteWebhookSubscription 删除Webhook订阅（投递记录一并删除）
+func (*WebhookSubscription) DeleteWebhookSubscription(ctx context.Context, id string, claims *utils.UserClaims) error {
+	res := global.DB.WithContext(ctx).Where("id = ? AND tenant_id = ?", id, claims.TenantID).Delete(&model.WebhookSubscription{})
+	if res.Error != nil {
+		return errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": res.Error.Error()})
+	}
+	if res.RowsAffected == 0 {
+		return errcode.New(errcode.CodeNotFound)
+	}
+	invalidateWebhookSubscribers(claims.TenantID)
+	return nil
+}
+
+// GetWebhookSubscriptionListByPage Webhook订阅列表
+func (*WebhookSubscription) GetWebhookSubscriptionListByPage(ctx context.Context, req *model.GetWebhookSubscriptionListByPageReq, claims *utils.UserClaims) (map[string]interface{}, error) {
+	db := global.DB.WithContext(ctx).Model(&model.WebhookSubscription{}).Where("tenant_id = ?", claims.TenantID)
+	if req.Enabled != nil {
+		db = db.Where("enabled = ?", *req.Enabled)
+	}
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	list := make([]model.WebhookSubscription, 0)
+	if err := db.Order("created_at DESC").
+		Offset((req.Page - 1) * req.PageSize).
+		Limit(req.PageSize).
+		Find(&list).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	for i := range list {
+		maskWebhookSecret(&list[i])
+	}
+	return map[string]interface{}{"total": total, "list": list}, nil
+}
+
+// GetWebhookEventTypes 可订阅的事件类型
+func (*WebhookSubscription) GetWebhookEventTypes() []string {
+	return model.WebhookEventTypes
+}
+
+// TestWebhookSubscription 向订阅地址同步发送一条测试事件，不进入重试
+func (*WebhookSubscription) TestWebhookSubscription(ctx context.Context, id string, claims *utils.UserClaims) (map[string]interface{}, error) {
+	sub, err := getWebhookSubscription(ctx, id, claims.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	deliveryID := uuid.New()
+	payload, err := webhookEventPayload(deliveryID, sub.TenantID, model.WebhookEventPing, map[string]interface{}{"subscription_id": sub.ID}, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	d := &model.WebhookDelivery{ID: deliveryID, EventType: model.WebhookEventPing, TargetURL: sub.TargetURL, Payload: payload}
+	sendCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	statusCode, sendErr := sendWebhookDelivery(sendCtx, d, sub.Secret)
+	result := map[string]interface{}{"success": sendErr == nil, "status_code": statusCode}
+	if sendErr != nil {
+		result["error"] = sendErr.Error()
+	}
+	return result, nil
+}
+
+// GetWebhookDeliveryListByPage 投递记录列表
+func (*WebhookSubscription) GetWebhookDeliveryListByPage(ctx context.Context, req *model.GetWebhookDeliveryListByPageReq, claims *utils.UserClaims) (map[string]interface{}, error) {
+	db := global.DB.WithContext(ctx).Model(&model.WebhookDelivery{}).Where("tenant_id = ?", claims.TenantID)
+	if req.SubscriptionID != nil && *req.SubscriptionID != "" {
+		db = db.Where("subscription_id = ?", *req.SubscriptionID)
+	}
+	if req.Status != nil && *req.Status != "" {
+		db = db.Where("status = ?", *req.Status)
+	}
+	if req.EventType != nil && *req.EventType != "" {
+		db = db.Where("event_type = ?", *req.EventType)
+	}
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	list := make([]model.WebhookDelivery, 0)
+	if err := db.Order("created_at DESC").
+		Offset((req.Page - 1) * req.PageSize).
+		Limit(req.PageSize).
+		Find(&list).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return map[string]interface{}{"total": total, "list": list}, nil
+}
+
+// redeliverWebhook 重置投递次数并立即投递一次，失败后按退避重新进入重试
+func redeliverWebhook(ctx context.Context, d *model.WebhookDelivery) (*model.WebhookDelivery, error) {
+	now := time.Now().UTC()
+	d.Status = model.WebhookDeliveryPending
+	d.Attempts = 0
+	d.NextAttemptAt = now.Add(webhookLease)
+	d.DeliveredAt = nil
+	d.UpdatedAt = now
+	if err := global.DB.WithContext(ctx).Model(&model.WebhookDelivery{}).Where("id = ?", d.ID).Updates(map[string]interface{}{
+		"status":          d.Status,
+		"attempts":        d.Attempts,
+		"next_attempt_at": d.NextAttemptAt,
+		"delivered_at":    nil,
+		"updated_at":      d.UpdatedAt,
+	}).Error; err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	if d.NotificationHistoryID != nil {
+		pending := "PENDING"
+		remark := "手动重新投递"
+		if _, err := dal.UpdateNotificationHistory(*d.NotificationHistoryID, &pending, &remark); err != nil {
+			logrus.Error("更新webhook通知历史记录失败:", err)
+		}
+	}
+	_ = attemptWebhookDelivery(d)
+	return d, nil
+}
+
+// RedeliverWebhookDelivery 手动重新投递
+func (*WebhookSubscription) RedeliverWebhookDelivery(ctx context.Context, id string, claims *utils.UserClaims) (*model.WebhookDelivery, error) {
+	var d model.WebhookDelivery
+	err := global.DB.WithContext(ctx).Where("id = ? AND tenant_id = ?", id, claims.TenantID).First(&d).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, errcode.New(errcode.CodeNotFound)
+	}
+	if err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return redeliverWebhook(ctx, &d)
+}
+
+// RedeliverNotificationHistory 按通知历史重新投递 Webhook 通知
+func (*WebhookSubscription) RedeliverNotificationHistory(ctx context.Context, historyID string, claims *utils.UserClaims) (*model.WebhookDelivery, error) {
+	var d model.WebhookDelivery
+	err := global.DB.WithContext(ctx).
+		Where("notification_history_id = ? AND tenant_id = ?", historyID, claims.TenantID).
+		Order("created_at DESC").
+		First(&d).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, errcode.WithData(errcode.CodeParamError, map[string]interface{}{
+			"message": "only webhook notifications with a delivery record can be redelivered",
+		})
+	}
+	if err != nil {
+		return nil, errcode.WithData(errcode.CodeDBError, map[string]interface{}{"sql_error": err.Error()})
+	}
+	return redeliverWebhook(ctx, &d)
+}
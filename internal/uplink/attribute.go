@@ -407,6 +407,9 @@ func (f *AttributeUplink) notifyDeviceOnline(device *model.Device) {
 	}
 	global.TPSSEManager.BroadcastEventToTenant(device.TenantID, sseEvent)
 
+	// Webhook 事件订阅
+	go service.GroupApp.WebhookSubscription.PublishDeviceStatus(device, true)
+
 	// 触发自动化
 	err := service.GroupApp.Execute(device, service.AutomateFromExt{
 		TriggerParamType: model.TRIGGER_PARAM_TYPE_STATUS,
@@ -174,10 +174,13 @@ func (f *StatusUplink) processMessage(msg *DeviceMessage) {
 	// 8. 触发自动化
 	go f.triggerAutomation(device, status)
 
-	// 9. 预期数据发送(上线时)
+	// 9. Webhook 事件订阅
+	go service.GroupApp.WebhookSubscription.PublishDeviceStatus(device, status == 1)
+
+	// 10. 预期数据发送(上线时)
 	if status == 1 {
 		go f.sendExpectedData(device)
-		// 10. 离线指令：设备上线后自动执行
+		// 11. 离线指令：设备上线后自动执行
 		go service.GroupApp.OfflineCommand.ExecutePendingForDevice(context.Background(), device.ID)
 	}
 }
@@ -339,7 +339,10 @@ func (f *TelemetryUplink) processDirectDeviceMessage(device *model.Device, paylo
 	// 5. WebSocket 实时推送（异步）
 	go f.checkAndPublishToWS(device.ID, device.TenantID, triggerValues)
 
-	// 6. 场景联动（异步）
+	// 6. Webhook 事件订阅（异步）
+	go service.GroupApp.WebhookSubscription.PublishTelemetry(device, triggerValues)
+
+	// 7. 场景联动（异步）
 	go func() {
 		err := service.GroupApp.Execute(device, service.AutomateFromExt{
 			TriggerParamType: model.TRIGGER_PARAM_TYPE_TEL,
@@ -501,6 +504,9 @@ func (f *TelemetryUplink) notifyDeviceOnline(device *model.Device) {
 	}
 	global.TPSSEManager.BroadcastEventToTenant(device.TenantID, sseEvent)
 
+	// Webhook 事件订阅
+	go service.GroupApp.WebhookSubscription.PublishDeviceStatus(device, true)
+
 	// 触发自动化
 	err := service.GroupApp.Execute(device, service.AutomateFromExt{
 		TriggerParamType: model.TRIGGER_PARAM_TYPE_STATUS,
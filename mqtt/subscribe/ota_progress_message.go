@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	initialize "project/initialize"
 	"project/internal/query"
+	"project/internal/service"
 	"strconv"
 
 	"github.com/sirupsen/logrus"
@@ -108,4 +109,5 @@ func OtaUpgrade(payload []byte, _ string) {
 		logrus.Error(err)
 		return
 	}
+	go service.GroupApp.WebhookSubscription.PublishOtaProgress(otaTaskDetail)
 }
@@ -136,6 +136,100 @@ func GetSceneExecuteTime(taskType, condition string) (time.Time, error) {
 
 }
 
+// GetSceneExecuteTimeIn 按时区 loc 计算定时任务下一次执行时间
+// 条件中的时间视为 loc 下的本地时间（忽略时间串中的偏移量），按日历日推进以正确处理夏令时；
+// loc 为空时与 GetSceneExecuteTime 一致
+func GetSceneExecuteTimeIn(taskType, condition string, loc *time.Location, now time.Time) (time.Time, error) {
+	if loc == nil {
+		return GetSceneExecuteTime(taskType, condition)
+	}
+	now = now.In(loc)
+	switch taskType {
+	case "HOUR":
+		if len(condition) < 2 {
+			return time.Time{}, errors.New("时间格式错误")
+		}
+		min, err := strconv.Atoi(condition[:2])
+		if err != nil || min > 59 || min < 0 {
+			return time.Time{}, errors.New("时间格式错误")
+		}
+		result := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), min, 0, 0, loc)
+		if !result.After(now) {
+			result = result.Add(time.Hour)
+		}
+		return result, nil
+	case "DAY":
+		h, m, sec, err := ParseClock(condition)
+		if err != nil {
+			return time.Time{}, err
+		}
+		for i := 0; i <= 1; i++ {
+			result := time.Date(now.Year(), now.Month(), now.Day()+i, h, m, sec, 0, loc)
+			if result.After(now) {
+				return result, nil
+			}
+		}
+		return time.Date(now.Year(), now.Month(), now.Day()+2, h, m, sec, 0, loc), nil
+	case "WEEK":
+		parts := strings.Split(condition, "|")
+		if len(parts) != 2 {
+			return time.Time{}, errors.New("时间格式错误")
+		}
+		h, m, sec, err := ParseClock(parts[1])
+		if err != nil {
+			return time.Time{}, err
+		}
+		for i := 0; i <= 7; i++ {
+			result := time.Date(now.Year(), now.Month(), now.Day()+i, h, m, sec, 0, loc)
+			if strings.ContainsRune(parts[0], rune('0'+GetWeekDay(result))) && result.After(now) {
+				return result, nil
+			}
+		}
+		return time.Time{}, errors.New("时间格式错误")
+	case "MONTH":
+		idx := strings.Index(condition, "T")
+		if idx <= 0 {
+			return time.Time{}, errors.New("时间解析错误：")
+		}
+		day, err := strconv.Atoi(condition[:idx])
+		if err != nil || day < 1 || day > 31 {
+			return time.Time{}, errors.New("时间解析错误：")
+		}
+		h, m, sec, err := ParseClock(condition[idx+1:])
+		if err != nil {
+			return time.Time{}, err
+		}
+		for i := 0; i <= 12; i++ {
+			result := time.Date(now.Year(), now.Month()+time.Month(i), day, h, m, sec, 0, loc)
+			// 本月没有该日期（如 31 日）时跳过
+			if result.Day() == day && result.After(now) {
+				return result, nil
+			}
+		}
+		return time.Time{}, errors.New("时间解析错误：")
+	case "CRON":
+		specParser := cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.DowOptional | cron.Descriptor)
+		schedule, err := specParser.Parse(condition)
+		if err != nil {
+			return time.Time{}, errors.New("cron格式错误")
+		}
+		return schedule.Next(now), nil
+	}
+	return time.Time{}, errors.New("未支持的时间格式")
+}
+
+// ParseClock 解析 HH:mm:ss 格式的时刻，忽略其后的时区偏移（如 08:00:00+08:00）
+func ParseClock(s string) (int, int, int, error) {
+	if len(s) < 8 {
+		return 0, 0, 0, errors.New("时间格式错误")
+	}
+	t, err := time.Parse("15:04:05", s[:8])
+	if err != nil {
+		return 0, 0, 0, errors.New("时间格式错误")
+	}
+	return t.Hour(), t.Minute(), t.Second(), nil
+}
+
 func GetNextTime(now time.Time, weekdays []time.Weekday, targetTime time.Time) time.Time {
 	//// 获取当前时间的年、月、日和星期几
 	//year, month, day := now.Date()
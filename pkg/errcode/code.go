@@ -31,10 +31,27 @@ const (
 	CodeTooManyAttempts = 200006 // 登录尝试次数过多
 	CodePhoneDuplicated = 200007 // 手机号已被使用
 
+	// 双因素认证 (200020-200029)
+	CodeMfaCodeInvalid    = 200020 // 动态验证码或恢复码错误
+	CodeMfaTokenExpired   = 200021 // 二次验证已过期
+	CodeMfaNotEnabled     = 200022 // 未启用双因素认证
+	CodeMfaAlreadyEnabled = 200023 // 已启用双因素认证
+	CodeMfaEnforced       = 200024 // 租户强制启用，不能关闭
+
+	// 登录会话 (200030-200039)
+	CodeSessionInvalid = 200030 // 刷新令牌无效或会话已失效
+
 	// 权限模块 (201xxx)
 	CodeNoPermission = 201001 // 无权限
 	CodeOpDenied     = 201002 // 操作被拒绝
 	CodeRateLimit    = 201003 // 请求频率限制
+
+	CodeDeviceParamDenied = 201004 // 无权操作设备参数
+
+	// 操作审批 (201010-201019)
+	CodeApprovalNotPending   = 201010 // 审批申请不是待审批状态
+	CodeApprovalSelfDecision = 201011 // 不能审批自己提交的申请
+	CodeApprovalNoApprover   = 201012 // 没有可用的审批人
 )
 
 const (
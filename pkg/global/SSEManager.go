@@ -35,6 +35,7 @@ type SSEEvent struct {
 	Type     string `json:"type"`
 	Message  any    `json:"message"`
 	TenantID string `json:"tenant_id"`
+	UserID   string `json:"user_id,omitempty"` // 非空时只推送给该用户
 }
 
 func NewSSEManager() *SSEManager {
@@ -107,6 +108,9 @@ func (m *SSEManager) ListenForEvents() {
 		tenantClients, ok := m.clients[event.TenantID]
 		if ok {
 			for _, client := range tenantClients {
+				if event.UserID != "" && client.UserID != event.UserID {
+					continue
+				}
 				fmt.Fprintf(client.Writer, "event: %s\ndata: %s\n\n", event.Type, event.Message)
 				client.Writer.(http.Flusher).Flush()
 			}
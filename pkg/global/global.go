@@ -9,8 +9,8 @@ import (
 )
 
 var (
-	VERSION         = "0.0.28"
-	VERSION_NUMBER  = 28
+	VERSION         = "0.0.55"
+	VERSION_NUMBER  = 55
 	SYSTEM_VERSION  = "v1.1.11"
 	DB              *gorm.DB
 	REDIS           *redis.Client
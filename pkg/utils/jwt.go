@@ -17,6 +17,7 @@ type UserClaims struct {
 	CreateTime time.Time `json:"create_time"`
 	Authority  string    `json:"authority"`
 	TenantID   string    `json:"tenant_id"`
+	SessionID  string    `json:"sid,omitempty"` // 登录会话ID，旧版令牌为空
 	jwt.StandardClaims
 }
 
@@ -26,9 +27,11 @@ func NewJWT(key interface{}) *JWT {
 	}
 }
 
-// 生成token
+// 生成token，未指定过期时间时默认30天
 func (j *JWT) GenerateToken(claims UserClaims) (string, error) {
-	claims.ExpiresAt = time.Now().Add(time.Hour * 24 * 30).Unix()
+	if claims.ExpiresAt == 0 {
+		claims.ExpiresAt = time.Now().Add(time.Hour * 24 * 30).Unix()
+	}
 	tokenClaims := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
 	// 生成token
@@ -41,12 +44,12 @@ func (j *JWT) ParseToken(token string) (*UserClaims, error) {
 		return j.Key, nil
 	})
 	if err != nil {
-		logrus.Error(err.Error())
+		// 访问令牌有效期较短，过期属于正常情况
+		logrus.Debug(err.Error())
 		return nil, err
 	}
 	if claims, ok := tokenClaims.Claims.(*UserClaims); ok && tokenClaims.Valid {
 		return claims, nil
 	}
-	logrus.Error(err.Error())
-	return nil, err
+	return nil, jwt.NewValidationError("invalid token", jwt.ValidationErrorClaimsInvalid)
 }
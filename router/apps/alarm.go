@@ -60,5 +60,22 @@ func alarminfo(Router *gin.RouterGroup) {
 
 		// 删
 		url.DELETE("history/:id", api.Controllers.AlarmApi.DeleteAlarmHistory)
+
+		// 处理流程：确认/指派/开始处理/解决/关闭/重新打开
+		url.POST("history/:id/transition", api.Controllers.AlarmApi.TransitionAlarmHistory)
+
+		// 评论
+		url.POST("history/:id/comments", api.Controllers.AlarmApi.CommentAlarmHistory)
+
+		// 评论与处理记录
+		url.GET("history/:id/activities", api.Controllers.AlarmApi.HandleAlarmHistoryActivities)
+
+		// SLA 统计
+		url.GET("sla/report", api.Controllers.AlarmApi.HandleAlarmSlaReport)
+
+		// 告警事件（合并后的相关告警）
+		url.GET("incidents", api.Controllers.AlarmApi.HandleAlarmIncidentListByPage)
+
+		url.GET("incidents/:id", api.Controllers.AlarmApi.HandleAlarmIncident)
 	}
 }
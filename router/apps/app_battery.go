@@ -13,6 +13,8 @@ func (*AppBattery) InitAppBattery(Router *gin.RouterGroup) {
 	r := Router.Group("app/battery")
 	{
 		r.GET("detail/:device_id", api.Controllers.AppBatteryApi.GetBatteryDetail)
+		r.GET("health/:device_id", api.Controllers.AppBatteryApi.GetBatteryHealth)
+		r.GET("cycles/:device_id", api.Controllers.AppBatteryApi.ListBatteryCycles)
 	}
 }
 
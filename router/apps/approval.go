@@ -0,0 +1,24 @@
+package apps
+
+import (
+	"project/internal/api"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Approval 高风险批量操作审批
+type Approval struct{}
+
+func (*Approval) InitApproval(Router *gin.RouterGroup) {
+	g := Router.Group("approval")
+	{
+		g.GET("policies", api.Controllers.ApprovalApi.ListApprovalPolicies)
+		g.PUT("policies/:operation_type", api.Controllers.ApprovalApi.SaveApprovalPolicy)
+
+		g.GET("requests", api.Controllers.ApprovalApi.HandleApprovalRequestListByPage)
+		g.GET("requests/:id", api.Controllers.ApprovalApi.HandleApprovalRequest)
+		g.POST("requests/:id/approve", api.Controllers.ApprovalApi.ApproveRequest)
+		g.POST("requests/:id/reject", api.Controllers.ApprovalApi.RejectRequest)
+		g.POST("requests/:id/cancel", api.Controllers.ApprovalApi.CancelRequest)
+	}
+}
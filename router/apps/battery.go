@@ -40,6 +40,19 @@ func (*Battery) InitBattery(Router *gin.RouterGroup) {
 		batteryApi.GET("/offline-commands/:id", api.Controllers.OfflineCommandApi.GetOfflineCommandDetail)
 		batteryApi.DELETE("/offline-commands/:id", api.Controllers.OfflineCommandApi.CancelOfflineCommand)
 
+		// 电池健康分析（SOH 历史/寿命预测）
+		batteryApi.GET("/health/forecast", api.Controllers.BatteryHealthApi.ListEolForecast)
+		batteryApi.GET("/health/:device_id", api.Controllers.BatteryHealthApi.GetBatteryHealth)
+		batteryApi.POST("/health/:device_id/recalculate", api.Controllers.BatteryHealthApi.RecalculateBatteryHealth)
+
+		// 充放电会话（循环日志）
+		batteryApi.GET("/cycles", api.Controllers.BatteryCycleApi.ListBatteryCycles)
+		batteryApi.GET("/cycles/stats", api.Controllers.BatteryCycleApi.GetBatteryCycleStats)
+
+		// 生命周期（状态机/变更记录）
+		batteryApi.GET("/lifecycle/:device_id", api.Controllers.BatteryLifecycleApi.GetBatteryLifecycle)
+		batteryApi.POST("/lifecycle/:device_id/transition", api.Controllers.BatteryLifecycleApi.TransitionBatteryLifecycle)
+
 		// 参数远程查看/修改（BMS）
 		batteryApi.GET("/params/:id", api.Controllers.BatteryApi.GetBatteryParams)
 		batteryApi.POST("/params/pub", api.Controllers.BatteryApi.PutBatteryParams)
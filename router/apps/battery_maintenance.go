@@ -14,5 +14,16 @@ func (*BatteryMaintenance) InitBatteryMaintenance(Router *gin.RouterGroup) {
 		url.POST("", api.Controllers.BatteryMaintenanceApi.CreateBatteryMaintenance)
 		url.GET("", api.Controllers.BatteryMaintenanceApi.ListBatteryMaintenance)
 		url.GET(":id", api.Controllers.BatteryMaintenanceApi.GetBatteryMaintenanceDetail)
+
+		// 维护计划
+		url.POST("plans", api.Controllers.BatteryMaintenancePlanApi.CreateMaintenancePlan)
+		url.GET("plans", api.Controllers.BatteryMaintenancePlanApi.ListMaintenancePlans)
+		url.PUT("plans/:id", api.Controllers.BatteryMaintenancePlanApi.UpdateMaintenancePlan)
+		url.DELETE("plans/:id", api.Controllers.BatteryMaintenancePlanApi.DeleteMaintenancePlan)
+
+		// 维护工单
+		url.GET("orders", api.Controllers.BatteryMaintenancePlanApi.ListMaintenanceOrders)
+		url.GET("orders/due", api.Controllers.BatteryMaintenancePlanApi.ListDueMaintenanceOrders)
+		url.POST("orders/:id/cancel", api.Controllers.BatteryMaintenancePlanApi.CancelMaintenanceOrder)
 	}
 }
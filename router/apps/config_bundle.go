@@ -0,0 +1,19 @@
+package apps
+
+import (
+	"project/internal/api"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConfigBundle 配置即代码
+type ConfigBundle struct{}
+
+func (*ConfigBundle) InitConfigBundle(Router *gin.RouterGroup) {
+	g := Router.Group("config_bundle")
+	{
+		g.GET("export", api.Controllers.ConfigBundleApi.ExportConfigBundle)
+		g.POST("plan", api.Controllers.ConfigBundleApi.PlanConfigBundle)
+		g.POST("apply", api.Controllers.ConfigBundleApi.ApplyConfigBundle)
+	}
+}
@@ -0,0 +1,19 @@
+package apps
+
+import (
+	"project/internal/api"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeviceAudit 设备操作审计
+type DeviceAudit struct{}
+
+func (*DeviceAudit) InitDeviceAudit(Router *gin.RouterGroup) {
+	g := Router.Group("device_audit")
+	{
+		g.GET("list", api.Controllers.DeviceAuditApi.HandleDeviceAuditLogListByPage)
+		g.GET("export", api.Controllers.DeviceAuditApi.ExportDeviceAuditLogs)
+		g.GET("verify", api.Controllers.DeviceAuditApi.VerifyDeviceAuditChain)
+	}
+}
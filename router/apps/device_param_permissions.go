@@ -0,0 +1,18 @@
+package apps
+
+import (
+	"project/internal/api"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeviceParamPermission 设备参数细粒度权限（物模型标识符级别）
+type DeviceParamPermission struct{}
+
+func (*DeviceParamPermission) InitDeviceParamPermission(Router *gin.RouterGroup) {
+	g := Router.Group("device_param_rules")
+	{
+		g.GET("", api.Controllers.DeviceParamPermissionApi.ListDeviceParamRules)
+		g.PUT(":subject_type/:subject_id", api.Controllers.DeviceParamPermissionApi.ReplaceDeviceParamRules)
+	}
+}
@@ -15,6 +15,9 @@ func (*DeviceTransfer) InitDeviceTransfer(Router *gin.RouterGroup) {
 		// 批量转移设备
 		transferApi.POST("", api.Controllers.DeviceTransferApi.TransferDevices)
 
+		// 批量转移设备到组织
+		transferApi.POST("org", api.Controllers.DeviceTransferApi.TransferDevicesToOrg)
+
 		// 转移记录查询
 		transferApi.GET("history", api.Controllers.DeviceTransferApi.GetTransferHistory)
 	}
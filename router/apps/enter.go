@@ -25,6 +25,9 @@ type apps struct {
 	NotificationGroup          // 通知组
 	NotificationHistoryGroup   // 通知历史组
 	NotificationServicesConfig // 通知服务配置
+	NotificationInbox          // 站内信
+	NotificationTemplate       // 通知模板
+	WebhookSubscription        // Webhook事件订阅
 	Alarm
 	SceneAutomations
 	Scene
@@ -32,22 +35,32 @@ type apps struct {
 	ServicePlugin // 插件管理
 	ExpectedData  // 预期数据
 	OpenAPIKey    // openAPI
+	Oidc          // OIDC单点登录
+	UserMfa       // 双因素认证
+	UserSession   // 登录会话
 	MessagePush
-	SystemMonitor      // 系统监控
-	DeviceAuth         // 设备动态认证
-	Dealer             // BMS: 经销商管理
-	BmsDashboard       // BMS: Dashboard
-	Battery            // BMS: 电池管理
-	BatteryModel       // BMS: 电池型号管理
-	DeviceTransfer     // BMS: 设备转移
-	DeviceBinding      // BMS: APP设备绑定
-	AppBattery         // BMS: APP电池设备（详情/透传）
-	Warranty           // BMS: 维保管理
-	EndUser            // BMS: 终端用户
-	ActivationLog      // BMS: 激活日志
-	BatteryMaintenance // BMS: 电池维保记录
-	Org                // BMS: 组织管理
-	OrgTypePermission  // WEB: 机构类型权限配置（菜单权限/设备参数权限）
+	SystemMonitor         // 系统监控
+	DeviceAuth            // 设备动态认证
+	Dealer                // BMS: 经销商管理
+	BmsDashboard          // BMS: Dashboard
+	Battery               // BMS: 电池管理
+	BatteryModel          // BMS: 电池型号管理
+	DeviceTransfer        // BMS: 设备转移
+	DeviceBinding         // BMS: APP设备绑定
+	AppBattery            // BMS: APP电池设备（详情/透传）
+	Warranty              // BMS: 维保管理
+	EndUser               // BMS: 终端用户
+	ActivationLog         // BMS: 激活日志
+	BatteryMaintenance    // BMS: 电池维保记录
+	Org                   // BMS: 组织管理
+	OrgTypePermission     // WEB: 机构类型权限配置（菜单权限/设备参数权限）
+	DeviceParamPermission // WEB: 设备参数细粒度权限
+	DeviceAudit           // WEB: 设备操作审计
+	Approval              // WEB: 高风险批量操作审批
+	TenantData            // WEB: 租户数据迁移与注销
+	TenantSetting         // WEB: 租户设置（时区、双因素认证策略）
+	HolidayCalendar       // WEB: 节假日日历
+	ConfigBundle          // WEB: 配置即代码
 }
 
 var Model = new(apps)
@@ -0,0 +1,22 @@
+package apps
+
+import (
+	"project/internal/api"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HolidayCalendar 节假日日历
+type HolidayCalendar struct{}
+
+func (*HolidayCalendar) InitHolidayCalendar(Router *gin.RouterGroup) {
+	g := Router.Group("holiday_calendars")
+	{
+		g.GET("", api.Controllers.HolidayCalendarApi.ListHolidayCalendars)
+		g.POST("", api.Controllers.HolidayCalendarApi.CreateHolidayCalendar)
+		g.GET(":id", api.Controllers.HolidayCalendarApi.GetHolidayCalendar)
+		g.PUT(":id", api.Controllers.HolidayCalendarApi.UpdateHolidayCalendar)
+		g.DELETE(":id", api.Controllers.HolidayCalendarApi.DeleteHolidayCalendar)
+		g.PUT(":id/dates", api.Controllers.HolidayCalendarApi.SetHolidayCalendarDates)
+	}
+}
@@ -16,5 +16,8 @@ func (*NotificationHistoryGroup) InitNotificationHistory(Router *gin.RouterGroup
 		// 查
 		url.GET("/list", api.Controllers.NotificationHistoryApi.HandleNotificationHistoryListByPage)
 
+		// Webhook 通知重新投递
+		url.POST("/:id/redeliver", api.Controllers.NotificationHistoryApi.RedeliverNotificationHistory)
+
 	}
 }
@@ -0,0 +1,21 @@
+package apps
+
+import (
+	"project/internal/api"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationInbox struct {
+}
+
+// Init 成员通知站内信（Web 与移动端共用）
+func (*NotificationInbox) Init(Router *gin.RouterGroup) {
+	url := Router.Group("notification_inbox")
+	{
+		url.GET("/list", api.Controllers.NotificationInboxApi.HandleNotificationInboxListByPage)
+		url.GET("/unread_count", api.Controllers.NotificationInboxApi.HandleNotificationInboxUnreadCount)
+		url.PUT("/read", api.Controllers.NotificationInboxApi.MarkNotificationInboxRead)
+		url.PUT("/read_all", api.Controllers.NotificationInboxApi.MarkAllNotificationInboxRead)
+	}
+}
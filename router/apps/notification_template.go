@@ -0,0 +1,23 @@
+package apps
+
+import (
+	"project/internal/api"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationTemplate struct {
+}
+
+// Init 通知模板（按事件类型、渠道与语言）
+func (*NotificationTemplate) Init(Router *gin.RouterGroup) {
+	url := Router.Group("notification_template")
+	{
+		url.POST("", api.Controllers.NotificationTemplateApi.CreateNotificationTemplate)
+		url.GET("/list", api.Controllers.NotificationTemplateApi.HandleNotificationTemplateListByPage)
+		url.GET("/variables", api.Controllers.NotificationTemplateApi.HandleNotificationTemplateVariables)
+		url.POST("/preview", api.Controllers.NotificationTemplateApi.PreviewNotificationTemplate)
+		url.PUT("/:id", api.Controllers.NotificationTemplateApi.UpdateNotificationTemplate)
+		url.DELETE("/:id", api.Controllers.NotificationTemplateApi.DeleteNotificationTemplate)
+	}
+}
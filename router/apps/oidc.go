@@ -0,0 +1,34 @@
+package apps
+
+import (
+	"project/internal/api"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Oidc struct {
+}
+
+// Init OIDC 身份提供方管理（需登录）
+func (*Oidc) Init(Router *gin.RouterGroup) {
+	url := Router.Group("sso/oidc/provider")
+	{
+		url.POST("", api.Controllers.OidcApi.CreateOidcProvider)
+		url.GET("/list", api.Controllers.OidcApi.HandleOidcProviderListByPage)
+		url.PUT("/:id", api.Controllers.OidcApi.UpdateOidcProvider)
+		url.DELETE("/:id", api.Controllers.OidcApi.DeleteOidcProvider)
+		url.POST("/:id/test", api.Controllers.OidcApi.TestOidcProvider)
+	}
+}
+
+// InitPublic OIDC 单点登录流程（无需登录）
+func (*Oidc) InitPublic(Router *gin.RouterGroup) {
+	url := Router.Group("sso/oidc")
+	{
+		url.GET("/providers", api.Controllers.OidcApi.HandleOidcLoginProviders)
+		url.POST("/token", api.Controllers.OidcApi.ExchangeOidcTicket)
+		url.GET("/:id/authorize", api.Controllers.OidcApi.OidcAuthorize)
+		url.GET("/:id/callback", api.Controllers.OidcApi.OidcCallback)
+		url.POST("/:id/backchannel_logout", api.Controllers.OidcApi.OidcBackchannelLogout)
+	}
+}
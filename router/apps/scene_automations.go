@@ -29,6 +29,12 @@ func (*SceneAutomations) Init(Router *gin.RouterGroup) {
 		// 查详情
 		url.GET("detail/:id", api.Controllers.SceneAutomationsApi.HandleSceneAutomations)
 
+		// 试运行
+		url.POST("explain/:id", api.Controllers.SceneAutomationsApi.ExplainSceneAutomations)
+
+		// 历史回放
+		url.POST("replay/:id", api.Controllers.SceneAutomationsApi.ReplaySceneAutomations)
+
 		// 查日志
 		url.GET("log", api.Controllers.SceneAutomationsApi.HandleSceneAutomationsLog)
 
@@ -0,0 +1,28 @@
+package apps
+
+import (
+	"project/internal/api"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TenantData 租户数据迁移与注销
+type TenantData struct{}
+
+func (*TenantData) InitTenantData(Router *gin.RouterGroup) {
+	g := Router.Group("tenant_data")
+	{
+		g.POST("export", api.Controllers.TenantDataApi.CreateTenantExport)
+		g.POST("import", api.Controllers.TenantDataApi.CreateTenantImport)
+		g.GET("jobs", api.Controllers.TenantDataApi.HandleTenantDataJobListByPage)
+		g.GET("jobs/:id", api.Controllers.TenantDataApi.HandleTenantDataJob)
+		g.GET("jobs/:id/download", api.Controllers.TenantDataApi.DownloadTenantExport)
+	}
+
+	o := Router.Group("tenant_offboarding")
+	{
+		o.POST("", api.Controllers.TenantDataApi.ScheduleTenantOffboarding)
+		o.GET("", api.Controllers.TenantDataApi.HandleTenantOffboardingListByPage)
+		o.POST(":id/cancel", api.Controllers.TenantDataApi.CancelTenantOffboarding)
+	}
+}
@@ -0,0 +1,18 @@
+package apps
+
+import (
+	"project/internal/api"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TenantSetting 租户设置（时区、双因素认证策略）
+type TenantSetting struct{}
+
+func (*TenantSetting) InitTenantSetting(Router *gin.RouterGroup) {
+	g := Router.Group("tenant_settings")
+	{
+		g.GET("", api.Controllers.TenantSettingApi.GetTenantSetting)
+		g.PUT("", api.Controllers.TenantSettingApi.UpdateTenantSetting)
+	}
+}
@@ -0,0 +1,38 @@
+package apps
+
+import (
+	"project/internal/api"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UserMfa struct {
+}
+
+// Init 双因素认证管理（需登录）
+func (*UserMfa) Init(Router *gin.RouterGroup) {
+	url := Router.Group("user")
+	{
+		url.GET("mfa", api.Controllers.UserMfaApi.HandleMfaStatus)
+		url.POST("mfa/setup", api.Controllers.UserMfaApi.SetupMfa)
+		url.POST("mfa/enable", api.Controllers.UserMfaApi.EnableMfa)
+		url.POST("mfa/disable", api.Controllers.UserMfaApi.DisableMfa)
+		url.POST("mfa/recovery_codes", api.Controllers.UserMfaApi.RegenerateMfaRecoveryCodes)
+		url.GET("mfa/trusted_devices", api.Controllers.UserMfaApi.HandleTrustedDevices)
+		url.DELETE("mfa/trusted_devices", api.Controllers.UserMfaApi.RevokeAllTrustedDevices)
+		url.DELETE("mfa/trusted_devices/:id", api.Controllers.UserMfaApi.RevokeTrustedDevice)
+
+		// 管理员重置用户的双因素认证
+		url.POST(":id/mfa/reset", api.Controllers.UserMfaApi.ResetUserMfa)
+	}
+}
+
+// InitPublic 登录第二步（无需登录）
+func (*UserMfa) InitPublic(Router *gin.RouterGroup) {
+	url := Router.Group("login/mfa")
+	{
+		url.POST("verify", api.Controllers.UserMfaApi.VerifyLoginMfa)
+		url.POST("setup", api.Controllers.UserMfaApi.LoginMfaSetup)
+		url.POST("enable", api.Controllers.UserMfaApi.LoginMfaEnable)
+	}
+}
@@ -0,0 +1,29 @@
+package apps
+
+import (
+	"project/internal/api"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UserSession struct {
+}
+
+// Init 登录会话管理（需登录）
+func (*UserSession) Init(Router *gin.RouterGroup) {
+	url := Router.Group("user")
+	{
+		url.GET("sessions", api.Controllers.UserSessionApi.HandleSessions)
+		url.DELETE("sessions", api.Controllers.UserSessionApi.RevokeOtherSessions)
+		url.DELETE("sessions/:id", api.Controllers.UserSessionApi.RevokeSession)
+
+		// 管理员查看会话与强制下线
+		url.GET(":id/sessions", api.Controllers.UserSessionApi.HandleUserSessions)
+		url.POST(":id/force_logout", api.Controllers.UserSessionApi.ForceLogout)
+	}
+}
+
+// InitPublic 刷新令牌（无需登录）
+func (*UserSession) InitPublic(Router *gin.RouterGroup) {
+	Router.POST("login/refresh", api.Controllers.UserSessionApi.RefreshSession)
+}
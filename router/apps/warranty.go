@@ -23,6 +23,14 @@ func (*Warranty) InitWarranty(Router *gin.RouterGroup) {
 
 		// 更新维保状态
 		warrantyApi.PUT(":id", api.Controllers.WarrantyApi.UpdateWarrantyStatus)
+
+		// 重新评估质保资格
+		warrantyApi.POST(":id/evaluate", api.Controllers.WarrantyApi.EvaluateWarrantyApplication)
+
+		// 质保评估规则（按电池型号）
+		warrantyApi.GET("eligibility-rules", api.Controllers.WarrantyApi.ListEligibilityRules)
+		warrantyApi.PUT("eligibility-rules/:battery_model_id", api.Controllers.WarrantyApi.UpsertEligibilityRule)
+		warrantyApi.DELETE("eligibility-rules/:battery_model_id", api.Controllers.WarrantyApi.DeleteEligibilityRule)
 	}
 }
 
@@ -0,0 +1,25 @@
+package apps
+
+import (
+	"project/internal/api"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookSubscription struct {
+}
+
+// Init Webhook事件订阅与投递记录
+func (*WebhookSubscription) Init(Router *gin.RouterGroup) {
+	url := Router.Group("webhook_subscription")
+	{
+		url.POST("", api.Controllers.WebhookSubscriptionApi.CreateWebhookSubscription)
+		url.GET("/list", api.Controllers.WebhookSubscriptionApi.HandleWebhookSubscriptionListByPage)
+		url.GET("/event_types", api.Controllers.WebhookSubscriptionApi.HandleWebhookEventTypes)
+		url.GET("/deliveries", api.Controllers.WebhookSubscriptionApi.HandleWebhookDeliveryListByPage)
+		url.POST("/deliveries/:id/redeliver", api.Controllers.WebhookSubscriptionApi.RedeliverWebhookDelivery)
+		url.PUT("/:id", api.Controllers.WebhookSubscriptionApi.UpdateWebhookSubscription)
+		url.DELETE("/:id", api.Controllers.WebhookSubscriptionApi.DeleteWebhookSubscription)
+		url.POST("/:id/test", api.Controllers.WebhookSubscriptionApi.TestWebhookSubscription)
+	}
+}
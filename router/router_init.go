@@ -92,9 +92,15 @@ func RouterInit() *gin.Engine {
 			v1.POST("plugin/service/access/list", controllers.HandlePluginServiceAccessList)
 			v1.POST("plugin/service/access", controllers.HandlePluginServiceAccess)
 			v1.POST("login", controllers.Login)
+			// 登录二次验证（双因素认证）
+			apps.Model.UserMfa.InitPublic(v1)
+			// 刷新令牌换取访问令牌
+			apps.Model.UserSession.InitPublic(v1)
 			v1.GET("verification/code", controllers.HandleVerificationCode)
 			v1.POST("reset/password", controllers.ResetPassword)
 			v1.GET("logo", controllers.HandleLogoList)
+			// 控制台 OIDC 单点登录
+			apps.Model.Oidc.InitPublic(v1)
 
 			// APP/小程序认证（无需登录）
 			appAuth := v1.Group("app/auth")
@@ -228,6 +234,12 @@ func RouterInit() *gin.Engine {
 
 			apps.Model.NotificationServicesConfig.Init(v1) // 通知服务配置
 
+			apps.Model.NotificationInbox.Init(v1) // 站内信
+
+			apps.Model.NotificationTemplate.Init(v1) // 通知模板
+
+			apps.Model.WebhookSubscription.Init(v1) // Webhook事件订阅
+
 			apps.Model.Alarm.Init(v1) // 告警模块
 
 			apps.Model.Scene.Init(v1) // 场景
@@ -242,6 +254,12 @@ func RouterInit() *gin.Engine {
 
 			apps.Model.OpenAPIKey.InitOpenAPIKey(v1)
 
+			apps.Model.Oidc.Init(v1) // OIDC单点登录配置
+
+			apps.Model.UserMfa.Init(v1) // 双因素认证
+
+			apps.Model.UserSession.Init(v1) // 登录会话
+
 			apps.Model.MessagePush.Init(v1)
 
 			// 初始化系统监控路由
@@ -249,6 +267,17 @@ func RouterInit() *gin.Engine {
 
 			// 机构类型权限配置（菜单/设备参数）
 			apps.Model.OrgTypePermission.InitOrgTypePermission(v1)
+			apps.Model.DeviceParamPermission.InitDeviceParamPermission(v1) // 设备参数细粒度权限
+			apps.Model.DeviceAudit.InitDeviceAudit(v1)                     // 设备操作审计
+			apps.Model.Approval.InitApproval(v1)                           // 高风险批量操作审批
+			apps.Model.TenantData.InitTenantData(v1)                       // 租户数据迁移与注销
+
+			// 租户时区与节假日日历（场景联动时间条件）
+			apps.Model.TenantSetting.InitTenantSetting(v1)
+			apps.Model.HolidayCalendar.InitHolidayCalendar(v1)
+
+			// 配置即代码（配置包导出、预览与应用）
+			apps.Model.ConfigBundle.InitConfigBundle(v1)
 
 			// BMS 模块路由（附加组织数据权限中间件）
 			bmsRouter := v1.Group("")
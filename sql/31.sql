@@ -0,0 +1,54 @@
+-- Version: 31
+-- Description: 电池健康分析（SOH 历史 / 等效循环 / 寿命预测）
+
+-- ============================================================================
+-- 1. SOH 日快照
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.battery_soh_history (
+	id varchar(36) NOT NULL,
+	tenant_id varchar(36) NOT NULL,
+	device_id varchar(36) NOT NULL,
+	stat_date date NOT NULL, -- 统计日期
+	soh numeric(6,2) NULL, -- 健康度(%)
+	soh_source varchar(20) NOT NULL DEFAULT 'CAPACITY', -- CAPACITY(容量估算)/REPORTED(BMS自报)
+	capacity_ah numeric(10,3) NULL, -- 估算实际容量(Ah)
+	equivalent_cycles numeric(10,3) NOT NULL DEFAULT 0, -- 当日等效满充次数
+	total_cycles numeric(12,3) NOT NULL DEFAULT 0, -- 累计等效满充次数
+	cell_delta_avg_mv numeric(10,2) NULL, -- 平均压差(mV)
+	cell_delta_max_mv numeric(10,2) NULL, -- 最大压差(mV)
+	internal_resistance_mohm numeric(10,3) NULL, -- 内阻估算(mΩ，ΔV/ΔI)
+	temperature_max numeric(6,2) NULL, -- 当日最高温度(℃)
+	sample_count int4 NOT NULL DEFAULT 0, -- 参与计算的采样点数
+	created_at timestamptz NOT NULL DEFAULT NOW(),
+	CONSTRAINT battery_soh_history_pkey PRIMARY KEY (id),
+	CONSTRAINT battery_soh_history_device_date_uk UNIQUE (device_id, stat_date),
+	CONSTRAINT battery_soh_history_devices_fk FOREIGN KEY (device_id) REFERENCES public.devices(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_battery_soh_history_tenant_date ON public.battery_soh_history (tenant_id, stat_date);
+
+COMMENT ON TABLE public.battery_soh_history IS '电池健康度日快照（由遥测计算）';
+COMMENT ON COLUMN public.battery_soh_history.soh_source IS 'SOH来源：CAPACITY(容量估算)/REPORTED(BMS自报)';
+COMMENT ON COLUMN public.battery_soh_history.equivalent_cycles IS '当日等效满充次数（Σ|ΔSOC|/200）';
+COMMENT ON COLUMN public.battery_soh_history.total_cycles IS '累计等效满充次数';
+COMMENT ON COLUMN public.battery_soh_history.internal_resistance_mohm IS '内阻估算（电流阶跃 ΔV/ΔI 中位数，mΩ）';
+
+-- ============================================================================
+-- 2. 电池健康汇总（device_batteries 扩展）
+-- ============================================================================
+ALTER TABLE public.device_batteries
+	ADD COLUMN IF NOT EXISTS cycle_count numeric(12,3) NULL,
+	ADD COLUMN IF NOT EXISTS soh_fade_per_month numeric(8,4) NULL,
+	ADD COLUMN IF NOT EXISTS eol_forecast_date date NULL,
+	ADD COLUMN IF NOT EXISTS eol_forecast_date_lower date NULL,
+	ADD COLUMN IF NOT EXISTS eol_forecast_date_upper date NULL,
+	ADD COLUMN IF NOT EXISTS health_updated_at timestamptz(6) NULL;
+
+COMMENT ON COLUMN public.device_batteries.cycle_count IS '累计等效满充次数';
+COMMENT ON COLUMN public.device_batteries.soh_fade_per_month IS 'SOH 衰减速率（%/30天）';
+COMMENT ON COLUMN public.device_batteries.eol_forecast_date IS '预测 SOH 跌破寿命阈值日期';
+COMMENT ON COLUMN public.device_batteries.eol_forecast_date_lower IS '预测日期置信区间下限（95%）';
+COMMENT ON COLUMN public.device_batteries.eol_forecast_date_upper IS '预测日期置信区间上限（95%）';
+COMMENT ON COLUMN public.device_batteries.health_updated_at IS '健康分析更新时间';
+
+CREATE INDEX IF NOT EXISTS idx_device_batteries_eol_forecast_date ON public.device_batteries (eol_forecast_date);
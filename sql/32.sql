@@ -0,0 +1,32 @@
+-- Version: 32
+-- Description: 电池充放电会话（循环日志）
+
+CREATE TABLE IF NOT EXISTS public.battery_cycle_sessions (
+	id varchar(36) NOT NULL,
+	tenant_id varchar(36) NOT NULL,
+	device_id varchar(36) NOT NULL,
+	session_type varchar(20) NOT NULL, -- CHARGE/DISCHARGE/IDLE
+	start_at timestamptz(6) NOT NULL, -- 开始时间
+	end_at timestamptz(6) NOT NULL, -- 结束时间
+	duration_sec int4 NOT NULL DEFAULT 0, -- 时长(秒)
+	start_soc numeric(6,2) NULL, -- 开始SOC(%)
+	end_soc numeric(6,2) NULL, -- 结束SOC(%)
+	energy_in_wh numeric(12,3) NOT NULL DEFAULT 0, -- 充入能量(Wh)
+	energy_out_wh numeric(12,3) NOT NULL DEFAULT 0, -- 放出能量(Wh)
+	charge_ah numeric(12,3) NOT NULL DEFAULT 0, -- 电量(Ah，绝对值)
+	peak_current numeric(10,3) NULL, -- 峰值电流(A，绝对值)
+	max_temperature numeric(6,2) NULL, -- 最高温度(℃)
+	sample_count int4 NOT NULL DEFAULT 0,
+	created_at timestamptz NOT NULL DEFAULT NOW(),
+	CONSTRAINT battery_cycle_sessions_pkey PRIMARY KEY (id),
+	CONSTRAINT battery_cycle_sessions_device_start_uk UNIQUE (device_id, start_at),
+	CONSTRAINT battery_cycle_sessions_devices_fk FOREIGN KEY (device_id) REFERENCES public.devices(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_battery_cycle_sessions_tenant_start ON public.battery_cycle_sessions (tenant_id, start_at);
+CREATE INDEX IF NOT EXISTS idx_battery_cycle_sessions_device_type_start ON public.battery_cycle_sessions (device_id, session_type, start_at DESC);
+
+COMMENT ON TABLE public.battery_cycle_sessions IS '电池充放电会话（由电流/SOC遥测切分）';
+COMMENT ON COLUMN public.battery_cycle_sessions.session_type IS '会话类型：CHARGE充电/DISCHARGE放电/IDLE静置';
+COMMENT ON COLUMN public.battery_cycle_sessions.energy_in_wh IS '充入能量(Wh)，∫V·I dt';
+COMMENT ON COLUMN public.battery_cycle_sessions.energy_out_wh IS '放出能量(Wh)，∫V·|I| dt';
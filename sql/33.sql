@@ -0,0 +1,44 @@
+-- Version: 33
+-- Description: 质保资格自动评估（按电池型号配置规则 + 维保申请证据报告）
+
+-- ============================================================================
+-- 1. 质保资格规则（按电池型号配置，未配置的型号使用系统默认规则）
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.warranty_eligibility_rules (
+	id varchar(36) NOT NULL,
+	tenant_id varchar(36) NOT NULL,
+	battery_model_id varchar(36) NOT NULL,
+	grace_days int4 NOT NULL DEFAULT 0, -- 过保宽限天数
+	require_activation bool NOT NULL DEFAULT true, -- 是否要求已激活
+	max_cycle_count numeric(12,3) NULL, -- 质保循环次数上限（NULL不校验）
+	soh_guarantee numeric(6,2) NULL, -- 质保承诺SOH（低于该值视为容量缺陷）
+	over_temperature_c numeric(6,2) NOT NULL DEFAULT 60, -- 过温阈值(℃)
+	max_over_temperature_events int4 NOT NULL DEFAULT 3, -- 允许过温次数
+	deep_discharge_soc numeric(6,2) NOT NULL DEFAULT 5, -- 深度放电SOC阈值(%)
+	max_deep_discharge_events int4 NOT NULL DEFAULT 5, -- 允许深度放电次数
+	over_current_a numeric(10,3) NULL, -- 过流阈值(A，NULL不校验)
+	max_over_current_events int4 NOT NULL DEFAULT 3, -- 允许过流次数
+	remark varchar(255) NULL,
+	created_at timestamptz NOT NULL DEFAULT NOW(),
+	updated_at timestamptz NOT NULL DEFAULT NOW(),
+	CONSTRAINT warranty_eligibility_rules_pkey PRIMARY KEY (id),
+	CONSTRAINT warranty_eligibility_rules_tenant_model_uk UNIQUE (tenant_id, battery_model_id)
+);
+
+COMMENT ON TABLE public.warranty_eligibility_rules IS '质保资格评估规则（按电池型号）';
+COMMENT ON COLUMN public.warranty_eligibility_rules.soh_guarantee IS '质保承诺SOH(%)，质保期内低于该值支持受理';
+COMMENT ON COLUMN public.warranty_eligibility_rules.over_temperature_c IS '会话最高温度达到该值计一次过温';
+COMMENT ON COLUMN public.warranty_eligibility_rules.deep_discharge_soc IS '放电会话结束SOC不高于该值计一次深度放电';
+COMMENT ON COLUMN public.warranty_eligibility_rules.over_current_a IS '会话峰值电流达到该值计一次过流';
+
+-- ============================================================================
+-- 2. 维保申请评估结果
+-- ============================================================================
+ALTER TABLE public.warranty_applications
+	ADD COLUMN IF NOT EXISTS recommendation varchar(20) NULL,
+	ADD COLUMN IF NOT EXISTS evaluation_report jsonb NULL,
+	ADD COLUMN IF NOT EXISTS evaluated_at timestamptz(6) NULL;
+
+COMMENT ON COLUMN public.warranty_applications.recommendation IS '自动评估建议：APPROVE/REJECT/MANUAL_REVIEW';
+COMMENT ON COLUMN public.warranty_applications.evaluation_report IS '自动评估证据报告(JSON)';
+COMMENT ON COLUMN public.warranty_applications.evaluated_at IS '自动评估时间';
@@ -0,0 +1,62 @@
+-- Version: 34
+-- Description: 电池预测性维护（按电池型号配置维护计划，自动生成维护工单）
+
+-- ============================================================================
+-- 1. 维护计划
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.battery_maintenance_plans (
+	id varchar(36) NOT NULL,
+	tenant_id varchar(36) NOT NULL,
+	battery_model_id varchar(36) NOT NULL,
+	name varchar(100) NOT NULL,
+	trigger_type varchar(20) NOT NULL, -- CALENDAR/CYCLE/SOH/ALARM
+	interval_days int4 NULL, -- CALENDAR：维护间隔(天)
+	cycle_interval numeric(12,3) NULL, -- CYCLE：每多少次等效循环维护一次
+	soh_threshold numeric(6,2) NULL, -- SOH：SOH 不高于该值时维护
+	alarm_count int4 NULL, -- ALARM：窗口内告警次数
+	alarm_window_days int4 NULL, -- ALARM：统计窗口(天)
+	maintenance_type varchar(100) NULL, -- 匹配维保记录 fault_type，为空时任意维保记录均可关闭工单
+	lead_days int4 NOT NULL DEFAULT 7, -- CALENDAR提前生成天数；其余触发方式为完成期限(天)
+	notification_group_id varchar(36) NULL, -- 工单生成时通知的通知组
+	enabled bool NOT NULL DEFAULT true,
+	remark varchar(255) NULL,
+	created_at timestamptz NOT NULL DEFAULT NOW(),
+	updated_at timestamptz NOT NULL DEFAULT NOW(),
+	CONSTRAINT battery_maintenance_plans_pkey PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_battery_maintenance_plans_tenant_model ON public.battery_maintenance_plans (tenant_id, battery_model_id);
+
+COMMENT ON TABLE public.battery_maintenance_plans IS '电池维护计划（按电池型号）';
+COMMENT ON COLUMN public.battery_maintenance_plans.trigger_type IS '触发方式：CALENDAR日历间隔/CYCLE循环次数/SOH健康度阈值/ALARM告警历史';
+COMMENT ON COLUMN public.battery_maintenance_plans.maintenance_type IS '维护类型，与维保记录 fault_type 匹配后自动关闭工单';
+
+-- ============================================================================
+-- 2. 维护工单
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.battery_maintenance_orders (
+	id varchar(36) NOT NULL,
+	tenant_id varchar(36) NOT NULL,
+	plan_id varchar(36) NOT NULL,
+	device_id varchar(36) NOT NULL,
+	assignee_org_id varchar(36) NULL, -- 指派组织（设备当前持有方）
+	status varchar(20) NOT NULL DEFAULT 'OPEN', -- OPEN/CLOSED/CANCELLED
+	due_date date NOT NULL, -- 到期日
+	trigger_reason varchar(255) NOT NULL, -- 触发原因
+	trigger_value numeric(12,3) NULL, -- 触发值（循环次数/SOH/告警次数）
+	maintenance_record_id varchar(36) NULL, -- 关闭工单的维保记录
+	closed_at timestamptz(6) NULL,
+	created_at timestamptz NOT NULL DEFAULT NOW(),
+	updated_at timestamptz NOT NULL DEFAULT NOW(),
+	CONSTRAINT battery_maintenance_orders_pkey PRIMARY KEY (id),
+	CONSTRAINT battery_maintenance_orders_plan_fk FOREIGN KEY (plan_id) REFERENCES public.battery_maintenance_plans(id) ON DELETE CASCADE,
+	CONSTRAINT battery_maintenance_orders_devices_fk FOREIGN KEY (device_id) REFERENCES public.devices(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+-- 同一计划同一设备同时只有一张未完成工单
+CREATE UNIQUE INDEX IF NOT EXISTS uk_battery_maintenance_orders_open ON public.battery_maintenance_orders (plan_id, device_id) WHERE status = 'OPEN';
+CREATE INDEX IF NOT EXISTS idx_battery_maintenance_orders_tenant_due ON public.battery_maintenance_orders (tenant_id, status, due_date);
+CREATE INDEX IF NOT EXISTS idx_battery_maintenance_orders_device ON public.battery_maintenance_orders (device_id, status);
+
+COMMENT ON TABLE public.battery_maintenance_orders IS '电池维护工单（由维护计划生成）';
+COMMENT ON COLUMN public.battery_maintenance_orders.status IS '状态：OPEN待处理/CLOSED已完成/CANCELLED已取消';
@@ -0,0 +1,60 @@
+-- Version: 35
+-- Description: 电池生命周期状态机（状态字段 + 变更审计）
+
+-- ============================================================================
+-- 1. device_batteries 生命周期状态
+-- ============================================================================
+ALTER TABLE public.device_batteries
+	ADD COLUMN IF NOT EXISTS lifecycle_status varchar(20) NULL,
+	ADD COLUMN IF NOT EXISTS lifecycle_updated_at timestamptz(6) NULL;
+
+-- 按原激活/流转状态回填
+UPDATE public.device_batteries
+SET lifecycle_status = CASE
+		WHEN activation_status = 'ACTIVE' THEN 'IN_SERVICE'
+		WHEN transfer_status = 'DEALER' THEN 'AT_DEALER'
+		WHEN transfer_status = 'USER' THEN 'ACTIVATED'
+		ELSE 'IN_STOCK'
+	END,
+	lifecycle_updated_at = COALESCE(updated_at, NOW())
+WHERE lifecycle_status IS NULL;
+
+ALTER TABLE public.device_batteries ALTER COLUMN lifecycle_status SET DEFAULT 'IN_STOCK';
+ALTER TABLE public.device_batteries ALTER COLUMN lifecycle_status SET NOT NULL;
+
+DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'device_batteries_lifecycle_status_check') THEN
+		ALTER TABLE public.device_batteries ADD CONSTRAINT device_batteries_lifecycle_status_check CHECK (lifecycle_status IN (
+			'MANUFACTURED', 'IN_STOCK', 'SHIPPED', 'AT_DEALER', 'ACTIVATED', 'IN_SERVICE', 'RETURNED', 'REFURBISHED', 'SCRAPPED'
+		));
+	END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_device_batteries_lifecycle_status ON public.device_batteries (lifecycle_status);
+
+COMMENT ON COLUMN public.device_batteries.lifecycle_status IS '生命周期状态：MANUFACTURED已生产/IN_STOCK在库/SHIPPED已发货/AT_DEALER经销商在库/ACTIVATED已售出激活/IN_SERVICE使用中/RETURNED已退回/REFURBISHED已翻新/SCRAPPED已报废';
+COMMENT ON COLUMN public.device_batteries.activation_status IS '激活状态: INACTIVE, ACTIVE（由生命周期状态派生）';
+COMMENT ON COLUMN public.device_batteries.transfer_status IS '流转状态: FACTORY, DEALER, USER（由生命周期状态派生）';
+
+-- ============================================================================
+-- 2. 生命周期变更记录
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.battery_lifecycle_events (
+	id varchar(36) NOT NULL,
+	tenant_id varchar(36) NOT NULL,
+	device_id varchar(36) NOT NULL,
+	from_status varchar(20) NULL, -- 首次建档时为空
+	to_status varchar(20) NOT NULL,
+	event varchar(50) NOT NULL, -- 触发动作：IMPORT/ASSIGN_DEALER/TRANSFER/BIND/UNBIND/FIRST_USE/MANUAL
+	operator_id varchar(36) NULL, -- 操作人（系统自动为空）
+	reason varchar(500) NULL,
+	created_at timestamptz(6) NOT NULL DEFAULT NOW(),
+	CONSTRAINT battery_lifecycle_events_pkey PRIMARY KEY (id),
+	CONSTRAINT battery_lifecycle_events_devices_fk FOREIGN KEY (device_id) REFERENCES public.devices(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_battery_lifecycle_events_device ON public.battery_lifecycle_events (device_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_battery_lifecycle_events_tenant ON public.battery_lifecycle_events (tenant_id, created_at DESC);
+
+COMMENT ON TABLE public.battery_lifecycle_events IS '电池生命周期状态变更记录';
@@ -0,0 +1,12 @@
+-- Version: 36
+-- Description: 场景联动设备条件支持持续时间、N/M 采样与回差（触发/恢复阈值分离）
+
+ALTER TABLE public.device_trigger_condition ADD COLUMN IF NOT EXISTS hold_duration int4 NULL;
+ALTER TABLE public.device_trigger_condition ADD COLUMN IF NOT EXISTS sample_window int4 NULL;
+ALTER TABLE public.device_trigger_condition ADD COLUMN IF NOT EXISTS sample_hits int4 NULL;
+ALTER TABLE public.device_trigger_condition ADD COLUMN IF NOT EXISTS clear_value varchar(255) NULL;
+
+COMMENT ON COLUMN public.device_trigger_condition.hold_duration IS '持续时间(秒)：条件连续成立达到该时长才触发';
+COMMENT ON COLUMN public.device_trigger_condition.sample_window IS 'N/M 采样窗口 M：最近 M 次上报';
+COMMENT ON COLUMN public.device_trigger_condition.sample_hits IS 'N/M 采样命中数 N：窗口内至少 N 次成立才触发';
+COMMENT ON COLUMN public.device_trigger_condition.clear_value IS '恢复阈值（回差）：触发后数值越过该阈值才恢复，仅支持 > >= < <=';
@@ -0,0 +1,51 @@
+-- Version: 37
+-- Description: 租户/场景联动时区与节假日日历（时间范围条件、定时触发、日统计按时区计算）
+
+-- ============================================================================
+-- 1. 租户设置
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.tenant_settings (
+	tenant_id varchar(36) NOT NULL,
+	timezone varchar(64) NULL, -- IANA 时区，如 Asia/Shanghai；为空沿用原有行为
+	created_at timestamptz(6) NOT NULL DEFAULT now(),
+	updated_at timestamptz(6) NOT NULL DEFAULT now(),
+	CONSTRAINT tenant_settings_pkey PRIMARY KEY (tenant_id)
+);
+
+COMMENT ON TABLE public.tenant_settings IS '租户设置';
+COMMENT ON COLUMN public.tenant_settings.timezone IS 'IANA 时区';
+
+-- ============================================================================
+-- 2. 节假日日历
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.holiday_calendars (
+	id varchar(36) NOT NULL,
+	tenant_id varchar(36) NOT NULL,
+	name varchar(100) NOT NULL,
+	remark varchar(255) NULL,
+	created_at timestamptz(6) NOT NULL DEFAULT now(),
+	updated_at timestamptz(6) NOT NULL DEFAULT now(),
+	CONSTRAINT holiday_calendars_pkey PRIMARY KEY (id),
+	CONSTRAINT holiday_calendars_tenant_name_uk UNIQUE (tenant_id, name)
+);
+
+COMMENT ON TABLE public.holiday_calendars IS '节假日日历';
+
+CREATE TABLE IF NOT EXISTS public.holiday_calendar_dates (
+	calendar_id varchar(36) NOT NULL,
+	holiday_date date NOT NULL,
+	name varchar(100) NULL,
+	CONSTRAINT holiday_calendar_dates_pkey PRIMARY KEY (calendar_id, holiday_date),
+	CONSTRAINT holiday_calendar_dates_calendar_fk FOREIGN KEY (calendar_id) REFERENCES public.holiday_calendars(id) ON DELETE CASCADE
+);
+
+COMMENT ON TABLE public.holiday_calendar_dates IS '节假日日期（按日历所属时区的本地日期）';
+
+-- ============================================================================
+-- 3. 场景联动时区/节假日
+-- ============================================================================
+ALTER TABLE public.scene_automations ADD COLUMN IF NOT EXISTS timezone varchar(64) NULL;
+ALTER TABLE public.scene_automations ADD COLUMN IF NOT EXISTS holiday_calendar_id varchar(36) NULL;
+
+COMMENT ON COLUMN public.scene_automations.timezone IS 'IANA 时区，为空时使用租户时区';
+COMMENT ON COLUMN public.scene_automations.holiday_calendar_id IS '排除的节假日日历：节假日当天时间范围条件不成立、定时触发不执行';
@@ -0,0 +1,8 @@
+-- Version: 38
+-- Description: 场景联动设备条件支持滑动窗口统计（变化率/差值/均值/最小/最大/标准差）
+
+ALTER TABLE public.device_trigger_condition ADD COLUMN IF NOT EXISTS window_func varchar(20) NULL;
+ALTER TABLE public.device_trigger_condition ADD COLUMN IF NOT EXISTS window_seconds int4 NULL;
+
+COMMENT ON COLUMN public.device_trigger_condition.window_func IS '窗口统计函数 RATE：变化率(每分钟) DELTA：差值 AVG MIN MAX STDDEV，为空时使用原始值';
+COMMENT ON COLUMN public.device_trigger_condition.window_seconds IS '统计窗口长度(秒)';
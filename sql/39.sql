@@ -0,0 +1,12 @@
+-- Version: 39
+-- Description: 场景联动支持设备分组/设备模板聚合条件（离线占比、平均值、计数等），由定时任务周期评估
+
+ALTER TABLE public.device_trigger_condition ADD COLUMN IF NOT EXISTS aggregate_func varchar(20) NULL;
+ALTER TABLE public.device_trigger_condition ADD COLUMN IF NOT EXISTS member_operator varchar(20) NULL;
+ALTER TABLE public.device_trigger_condition ADD COLUMN IF NOT EXISTS member_value varchar(255) NULL;
+ALTER TABLE public.device_trigger_condition ADD COLUMN IF NOT EXISTS aggregate_by_subgroup bool NULL;
+
+COMMENT ON COLUMN public.device_trigger_condition.aggregate_func IS '聚合函数（条件类型12/13）COUNT：满足成员条件的设备数 PERCENT：满足成员条件的设备占比(%) AVG MIN MAX SUM';
+COMMENT ON COLUMN public.device_trigger_condition.member_operator IS '成员条件运算符（COUNT/PERCENT）';
+COMMENT ON COLUMN public.device_trigger_condition.member_value IS '成员条件取值（COUNT/PERCENT），状态条件为 ON-LINE/OFF-LINE';
+COMMENT ON COLUMN public.device_trigger_condition.aggregate_by_subgroup IS '按直属子分组分别聚合（条件类型12），任一子分组满足即触发';
@@ -0,0 +1,82 @@
+-- Version: 40
+-- Description: 告警处理流程（确认/指派/处理中/解决/关闭）、评论与操作记录、未确认升级与 SLA 时限
+
+-- ============================================================================
+-- 1. alarm_config 时限与升级策略
+-- ============================================================================
+ALTER TABLE public.alarm_config
+	ADD COLUMN IF NOT EXISTS ack_sla_minutes int4 NULL,
+	ADD COLUMN IF NOT EXISTS resolve_sla_minutes int4 NULL,
+	ADD COLUMN IF NOT EXISTS escalation_minutes int4 NULL,
+	ADD COLUMN IF NOT EXISTS escalation_notification_group_id varchar(36) NULL;
+
+COMMENT ON COLUMN public.alarm_config.ack_sla_minutes IS '确认时限（分钟），为空或0不考核';
+COMMENT ON COLUMN public.alarm_config.resolve_sla_minutes IS '解决时限（分钟），为空或0不考核';
+COMMENT ON COLUMN public.alarm_config.escalation_minutes IS '告警超过N分钟未确认时通知升级通知组，为空或0不升级';
+COMMENT ON COLUMN public.alarm_config.escalation_notification_group_id IS '升级通知组id';
+
+-- ============================================================================
+-- 2. alarm_history 处理状态
+-- ============================================================================
+ALTER TABLE public.alarm_history
+	ADD COLUMN IF NOT EXISTS severity varchar(3) NULL,
+	ADD COLUMN IF NOT EXISTS lifecycle_state varchar(20) NULL,
+	ADD COLUMN IF NOT EXISTS assignee_id varchar(36) NULL,
+	ADD COLUMN IF NOT EXISTS acknowledged_at timestamptz(6) NULL,
+	ADD COLUMN IF NOT EXISTS acknowledged_by varchar(36) NULL,
+	ADD COLUMN IF NOT EXISTS closed_at timestamptz(6) NULL,
+	ADD COLUMN IF NOT EXISTS escalated_at timestamptz(6) NULL;
+
+-- 回填：已处理或恢复记录视为已解决
+UPDATE public.alarm_history
+SET severity = CASE WHEN alarm_status <> 'N' THEN alarm_status ELSE NULL END,
+	lifecycle_state = CASE WHEN alarm_status = 'N' THEN 'RESOLVED' ELSE 'OPEN' END,
+	processed_at = CASE WHEN alarm_status = 'N' THEN COALESCE(processed_at, create_at) ELSE processed_at END
+WHERE lifecycle_state IS NULL;
+
+ALTER TABLE public.alarm_history ALTER COLUMN lifecycle_state SET DEFAULT 'OPEN';
+ALTER TABLE public.alarm_history ALTER COLUMN lifecycle_state SET NOT NULL;
+
+DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'alarm_history_lifecycle_state_check') THEN
+		ALTER TABLE public.alarm_history ADD CONSTRAINT alarm_history_lifecycle_state_check CHECK (lifecycle_state IN (
+			'OPEN', 'ACKNOWLEDGED', 'ASSIGNED', 'IN_PROGRESS', 'RESOLVED', 'CLOSED'
+		));
+	END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_alarm_history_lifecycle ON public.alarm_history (tenant_id, lifecycle_state, create_at DESC);
+CREATE INDEX IF NOT EXISTS idx_alarm_history_scene_group ON public.alarm_history (scene_automation_id, group_id, alarm_config_id);
+
+COMMENT ON COLUMN public.alarm_history.severity IS '触发时的告警级别 H/M/L，恢复记录为空';
+COMMENT ON COLUMN public.alarm_history.lifecycle_state IS '处理状态：OPEN待处理/ACKNOWLEDGED已确认/ASSIGNED已指派/IN_PROGRESS处理中/RESOLVED已解决/CLOSED已关闭';
+COMMENT ON COLUMN public.alarm_history.assignee_id IS '处理人（用户ID）';
+COMMENT ON COLUMN public.alarm_history.acknowledged_at IS '确认时间（首次人工操作时间）';
+COMMENT ON COLUMN public.alarm_history.acknowledged_by IS '确认人（用户ID）';
+COMMENT ON COLUMN public.alarm_history.closed_at IS '关闭时间';
+COMMENT ON COLUMN public.alarm_history.escalated_at IS '升级通知时间';
+COMMENT ON COLUMN public.alarm_history.processed_at IS '解决时间';
+COMMENT ON COLUMN public.alarm_history.processed_by IS '解决人（用户ID，自动恢复为空）';
+
+-- ============================================================================
+-- 3. 告警评论与操作记录
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.alarm_history_activities (
+	id varchar(36) NOT NULL,
+	alarm_history_id varchar(36) NOT NULL,
+	tenant_id varchar(36) NOT NULL,
+	action varchar(20) NOT NULL, -- COMMENT/ACKNOWLEDGE/ASSIGN/START/RESOLVE/CLOSE/REOPEN/ESCALATE/AUTO_RESOLVE
+	from_state varchar(20) NULL,
+	to_state varchar(20) NULL,
+	operator_id varchar(36) NULL, -- 系统操作为空
+	assignee_id varchar(36) NULL,
+	content text NULL,
+	created_at timestamptz(6) NOT NULL DEFAULT NOW(),
+	CONSTRAINT alarm_history_activities_pkey PRIMARY KEY (id),
+	CONSTRAINT alarm_history_activities_alarm_fk FOREIGN KEY (alarm_history_id) REFERENCES public.alarm_history(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_alarm_history_activities_alarm ON public.alarm_history_activities (alarm_history_id, created_at);
+
+COMMENT ON TABLE public.alarm_history_activities IS '告警评论与处理记录';
@@ -0,0 +1,63 @@
+-- Version: 41
+-- Description: 告警合并为告警事件、抖动检测与通知组通知风暴限制（超出部分合并为摘要）
+
+-- ============================================================================
+-- 1. alarm_config 合并与抖动规则
+-- ============================================================================
+ALTER TABLE public.alarm_config
+	ADD COLUMN IF NOT EXISTS group_by varchar(20) NULL,
+	ADD COLUMN IF NOT EXISTS group_window_seconds int4 NULL,
+	ADD COLUMN IF NOT EXISTS flap_threshold int4 NULL,
+	ADD COLUMN IF NOT EXISTS flap_window_seconds int4 NULL;
+
+COMMENT ON COLUMN public.alarm_config.group_by IS '告警合并方式 SCENE-同一场景条件组 GATEWAY-同一网关 CONFIG-同一告警配置，为空不合并';
+COMMENT ON COLUMN public.alarm_config.group_window_seconds IS '告警合并时间窗口（秒）：距事件最近一次告警不超过该时长的告警并入同一事件';
+COMMENT ON COLUMN public.alarm_config.flap_threshold IS '抖动判定触发次数：时间窗口内同一场景条件组触发达到该次数时不再发送通知';
+COMMENT ON COLUMN public.alarm_config.flap_window_seconds IS '抖动判定时间窗口（秒）';
+
+-- ============================================================================
+-- 2. 告警事件
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.alarm_incidents (
+	id varchar(36) NOT NULL,
+	tenant_id varchar(36) NOT NULL,
+	alarm_config_id varchar(36) NOT NULL,
+	group_by varchar(20) NOT NULL,
+	group_key varchar(255) NOT NULL, -- 合并键：场景条件组/网关设备ID/告警配置ID
+	"name" varchar(255) NOT NULL,
+	severity varchar(3) NOT NULL,
+	status varchar(20) NOT NULL DEFAULT 'OPEN', -- OPEN/RESOLVED
+	alarm_count int4 NOT NULL DEFAULT 0,
+	device_list jsonb NOT NULL DEFAULT '[]'::jsonb,
+	flapping bool NOT NULL DEFAULT false,
+	first_at timestamptz(6) NOT NULL,
+	last_at timestamptz(6) NOT NULL,
+	resolved_at timestamptz(6) NULL,
+	CONSTRAINT alarm_incidents_pkey PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_alarm_incidents_key ON public.alarm_incidents (tenant_id, alarm_config_id, group_key, last_at DESC);
+CREATE INDEX IF NOT EXISTS idx_alarm_incidents_tenant ON public.alarm_incidents (tenant_id, status, last_at DESC);
+
+COMMENT ON TABLE public.alarm_incidents IS '告警事件（按规则合并的相关告警）';
+COMMENT ON COLUMN public.alarm_incidents.device_list IS '事件涉及的设备id';
+COMMENT ON COLUMN public.alarm_incidents.flapping IS '是否检测到告警抖动';
+
+ALTER TABLE public.alarm_history
+	ADD COLUMN IF NOT EXISTS incident_id varchar(36) NULL,
+	ADD COLUMN IF NOT EXISTS suppress_reason varchar(20) NULL;
+
+CREATE INDEX IF NOT EXISTS idx_alarm_history_incident ON public.alarm_history (incident_id);
+
+COMMENT ON COLUMN public.alarm_history.incident_id IS '所属告警事件';
+COMMENT ON COLUMN public.alarm_history.suppress_reason IS '未发送通知的原因 DEDUP-已并入告警事件 FLAPPING-抖动';
+
+-- ============================================================================
+-- 3. 通知组通知风暴限制
+-- ============================================================================
+ALTER TABLE public.notification_groups
+	ADD COLUMN IF NOT EXISTS storm_limit int4 NULL,
+	ADD COLUMN IF NOT EXISTS storm_window_seconds int4 NULL;
+
+COMMENT ON COLUMN public.notification_groups.storm_limit IS '通知风暴限制：时间窗口内最多发送条数，超出部分合并为摘要，为空或0不限制';
+COMMENT ON COLUMN public.notification_groups.storm_window_seconds IS '通知风暴限制时间窗口（秒），默认60';
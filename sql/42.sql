@@ -0,0 +1,25 @@
+-- Version: 42
+-- Description: 成员通知：站内信收件箱（按用户记录已读/未读）
+
+CREATE TABLE IF NOT EXISTS public.notification_inbox (
+	id varchar(36) NOT NULL,
+	tenant_id varchar(36) NOT NULL,
+	user_id varchar(36) NOT NULL,
+	notification_group_id varchar(36) NULL, -- 来源通知组，通知组删除后置空
+	title varchar(500) NOT NULL,
+	content text NULL,
+	payload jsonb NULL, -- 原始通知JSON（告警id、设备等）
+	read_at timestamptz(6) NULL, -- 为空表示未读
+	created_at timestamptz(6) NOT NULL DEFAULT NOW(),
+	CONSTRAINT notification_inbox_pkey PRIMARY KEY (id),
+	CONSTRAINT notification_inbox_user_fk FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+	CONSTRAINT notification_inbox_group_fk FOREIGN KEY (notification_group_id) REFERENCES public.notification_groups(id) ON DELETE SET NULL ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_inbox_user ON public.notification_inbox (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_notification_inbox_unread ON public.notification_inbox (user_id) WHERE read_at IS NULL;
+
+COMMENT ON TABLE public.notification_inbox IS '成员通知站内信收件箱';
+COMMENT ON COLUMN public.notification_inbox.read_at IS '已读时间，为空表示未读';
+
+COMMENT ON COLUMN public.message_push_log.message_type IS '消息类型 1告警消息 2成员通知';
@@ -0,0 +1,43 @@
+-- Version: 43
+-- Description: 通知模板：按事件类型与渠道由租户编辑，支持变量与多语言
+
+CREATE TABLE IF NOT EXISTS public.notification_templates (
+	id varchar(36) NOT NULL,
+	tenant_id varchar(36) NOT NULL,
+	event_type varchar(50) NOT NULL, -- ALARM/ALARM_ESCALATION/DIGEST/MAINTENANCE
+	channel varchar(20) NOT NULL, -- EMAIL/SME/WEBHOOK/MEMBER/APP_PUSH
+	"language" varchar(10) NOT NULL DEFAULT '', -- 语言代码（如 zh_CN、en_US、zh），空字符串表示通用
+	subject text NULL, -- 标题模板
+	body text NOT NULL, -- 正文模板（邮件为HTML，Webhook/短信为JSON）
+	sms_template_code varchar(100) NULL, -- 短信服务商模板编码
+	enabled bool NOT NULL DEFAULT true,
+	remark varchar(255) NULL,
+	created_at timestamptz(6) NOT NULL DEFAULT NOW(),
+	updated_at timestamptz(6) NOT NULL DEFAULT NOW(),
+	CONSTRAINT notification_templates_pkey PRIMARY KEY (id),
+	CONSTRAINT notification_templates_uniq UNIQUE (tenant_id, event_type, channel, "language")
+);
+
+COMMENT ON TABLE public.notification_templates IS '通知模板';
+COMMENT ON COLUMN public.notification_templates."language" IS '语言代码，空字符串表示通用模板';
+COMMENT ON COLUMN public.notification_templates.body IS '正文模板，Go模板语法，变量如 {{.device_name}}';
+
+-- 告警级别多语言（模板变量 alarm_level_label）
+INSERT INTO public.sys_dict (id, dict_code, dict_value, created_at, remark) VALUES
+('9a1c0e43-1b0a-4c8e-9f43-2b6f0d1a0001', 'ALARM_LEVEL', 'H', NOW(), '告警级别-高'),
+('9a1c0e43-1b0a-4c8e-9f43-2b6f0d1a0002', 'ALARM_LEVEL', 'M', NOW(), '告警级别-中'),
+('9a1c0e43-1b0a-4c8e-9f43-2b6f0d1a0003', 'ALARM_LEVEL', 'L', NOW(), '告警级别-低')
+ON CONFLICT (dict_code, dict_value) DO NOTHING;
+
+INSERT INTO public.sys_dict_language (id, dict_id, language_code, "translation")
+SELECT v.id, d.id, v.language_code, v.translation
+FROM (VALUES
+	('9a1c0e43-1b0a-4c8e-9f43-2b6f0d1a1001', 'H', 'zh_CN', '高'),
+	('9a1c0e43-1b0a-4c8e-9f43-2b6f0d1a1002', 'H', 'en_US', 'High'),
+	('9a1c0e43-1b0a-4c8e-9f43-2b6f0d1a1003', 'M', 'zh_CN', '中'),
+	('9a1c0e43-1b0a-4c8e-9f43-2b6f0d1a1004', 'M', 'en_US', 'Medium'),
+	('9a1c0e43-1b0a-4c8e-9f43-2b6f0d1a1005', 'L', 'zh_CN', '低'),
+	('9a1c0e43-1b0a-4c8e-9f43-2b6f0d1a1006', 'L', 'en_US', 'Low')
+) AS v(id, dict_value, language_code, translation)
+JOIN public.sys_dict d ON d.dict_code = 'ALARM_LEVEL' AND d.dict_value = v.dict_value
+ON CONFLICT (dict_id, language_code) DO NOTHING;
@@ -0,0 +1,5 @@
+-- Version: 44
+-- Description: 通知组新增群机器人通知类型：钉钉、企业微信、飞书、Slack
+
+COMMENT ON COLUMN public.notification_groups.notification_type IS '通知类型MEMBER-成员通知 EMAIL-邮箱通知 SME-短信通知 VOICE-语音通知 WEBHOOK-webhook DINGTALK-钉钉机器人 WECOM-企业微信机器人 FEISHU-飞书机器人 SLACK-Slack';
+COMMENT ON COLUMN public.notification_histories.notification_type IS '通知类型MEMBER-成员通知 EMAIL-邮箱通知 SME-短信通知 VOICE-语音通知 WEBHOOK-webhook DINGTALK-钉钉机器人 WECOM-企业微信机器人 FEISHU-飞书机器人 SLACK-Slack';
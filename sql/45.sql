@@ -0,0 +1,51 @@
+-- Version: 45
+-- Description: 租户级 Webhook 事件订阅与持久化投递队列（指数退避重试、死信、手动重投）
+
+CREATE TABLE IF NOT EXISTS public.webhook_subscriptions (
+	id varchar(36) NOT NULL,
+	tenant_id varchar(36) NOT NULL,
+	"name" varchar(100) NOT NULL,
+	target_url varchar(500) NOT NULL,
+	secret varchar(200) NOT NULL, -- 签名密钥
+	event_types jsonb NOT NULL DEFAULT '[]'::jsonb, -- 订阅的事件类型，如 ["alarm","device.online"]
+	device_ids jsonb NULL, -- 设备过滤，为空不过滤
+	device_config_ids jsonb NULL, -- 设备配置过滤，为空不过滤
+	enabled bool NOT NULL DEFAULT true,
+	remark varchar(255) NULL,
+	created_at timestamptz(6) NOT NULL DEFAULT NOW(),
+	updated_at timestamptz(6) NOT NULL DEFAULT NOW(),
+	CONSTRAINT webhook_subscriptions_pkey PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_tenant ON public.webhook_subscriptions (tenant_id) WHERE enabled;
+
+COMMENT ON TABLE public.webhook_subscriptions IS 'Webhook事件订阅';
+
+CREATE TABLE IF NOT EXISTS public.webhook_deliveries (
+	id varchar(36) NOT NULL,
+	tenant_id varchar(36) NOT NULL,
+	subscription_id varchar(36) NULL, -- 来源订阅
+	notification_group_id varchar(36) NULL, -- 来源通知组（通知组 Webhook）
+	notification_history_id varchar(36) NULL, -- 对应的通知历史记录
+	event_type varchar(50) NOT NULL,
+	target_url varchar(500) NOT NULL,
+	payload text NOT NULL,
+	status varchar(20) NOT NULL DEFAULT 'PENDING', -- PENDING/SUCCESS/DEAD
+	attempts int4 NOT NULL DEFAULT 0,
+	next_attempt_at timestamptz(6) NOT NULL DEFAULT NOW(),
+	last_status_code int4 NULL,
+	last_error text NULL,
+	delivered_at timestamptz(6) NULL,
+	created_at timestamptz(6) NOT NULL DEFAULT NOW(),
+	updated_at timestamptz(6) NOT NULL DEFAULT NOW(),
+	CONSTRAINT webhook_deliveries_pkey PRIMARY KEY (id),
+	CONSTRAINT webhook_deliveries_status_check CHECK (status IN ('PENDING', 'SUCCESS', 'DEAD')),
+	CONSTRAINT webhook_deliveries_subscription_fk FOREIGN KEY (subscription_id) REFERENCES public.webhook_subscriptions(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON public.webhook_deliveries (next_attempt_at) WHERE status = 'PENDING';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription ON public.webhook_deliveries (subscription_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_history ON public.webhook_deliveries (notification_history_id);
+
+COMMENT ON TABLE public.webhook_deliveries IS 'Webhook投递队列';
+COMMENT ON COLUMN public.webhook_deliveries.status IS 'PENDING-待投递/重试中 SUCCESS-成功 DEAD-重试耗尽（死信）';
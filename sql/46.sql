@@ -0,0 +1,42 @@
+-- Version: 46
+-- Description: Open API 密钥权限范围、组织/设备分组限制、IP白名单、有效期、轮换与调用计量
+
+ALTER TABLE public.open_api_keys
+	ADD COLUMN IF NOT EXISTS scopes jsonb NOT NULL DEFAULT '["*"]'::jsonb,
+	ADD COLUMN IF NOT EXISTS org_id varchar(36) NULL,
+	ADD COLUMN IF NOT EXISTS device_group_id varchar(36) NULL,
+	ADD COLUMN IF NOT EXISTS ip_allowlist jsonb NULL,
+	ADD COLUMN IF NOT EXISTS expires_at timestamptz(6) NULL,
+	ADD COLUMN IF NOT EXISTS rate_limit int4 NULL,
+	ADD COLUMN IF NOT EXISTS previous_api_key varchar(200) NULL,
+	ADD COLUMN IF NOT EXISTS previous_key_expires_at timestamptz(6) NULL,
+	ADD COLUMN IF NOT EXISTS rotated_at timestamptz(6) NULL,
+	ADD COLUMN IF NOT EXISTS last_used_at timestamptz(6) NULL,
+	ADD COLUMN IF NOT EXISTS last_used_ip varchar(64) NULL,
+	ADD COLUMN IF NOT EXISTS request_count int8 NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN public.open_api_keys.scopes IS '权限范围：* 全部、telemetry:read 读取遥测、command:send 下发指令、device:read 读取设备/电池、device:manage 管理设备/电池';
+COMMENT ON COLUMN public.open_api_keys.org_id IS '限制访问的组织（含下级组织）';
+COMMENT ON COLUMN public.open_api_keys.device_group_id IS '限制访问的设备分组（含子分组）';
+COMMENT ON COLUMN public.open_api_keys.ip_allowlist IS '允许调用的IP或CIDR列表，为空不限制';
+COMMENT ON COLUMN public.open_api_keys.expires_at IS '过期时间，为空永久有效';
+COMMENT ON COLUMN public.open_api_keys.rate_limit IS '每分钟请求上限，为空不限制';
+COMMENT ON COLUMN public.open_api_keys.previous_api_key IS '轮换前的密钥，宽限期内仍可使用';
+COMMENT ON COLUMN public.open_api_keys.previous_key_expires_at IS '轮换前密钥的失效时间';
+
+CREATE INDEX IF NOT EXISTS idx_open_api_keys_previous_api_key ON public.open_api_keys (previous_api_key) WHERE previous_api_key IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS public.open_api_key_usage (
+	key_id varchar(36) NOT NULL,
+	usage_date date NOT NULL,
+	tenant_id varchar(36) NOT NULL,
+	request_count int8 NOT NULL DEFAULT 0, -- 通过鉴权的请求数
+	rejected_count int8 NOT NULL DEFAULT 0, -- 被拒绝的请求数（范围/IP/限流）
+	updated_at timestamptz(6) NOT NULL DEFAULT NOW(),
+	CONSTRAINT open_api_key_usage_pkey PRIMARY KEY (key_id, usage_date),
+	CONSTRAINT open_api_key_usage_key_fk FOREIGN KEY (key_id) REFERENCES public.open_api_keys(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_open_api_key_usage_tenant ON public.open_api_key_usage (tenant_id, usage_date);
+
+COMMENT ON TABLE public.open_api_key_usage IS 'Open API 密钥按日调用统计';
@@ -0,0 +1,52 @@
+-- Version: 47
+-- Description: 控制台 OIDC 单点登录：租户身份提供方配置与外部身份绑定
+
+CREATE TABLE IF NOT EXISTS public.oidc_providers (
+	id varchar(36) NOT NULL,
+	tenant_id varchar(36) NOT NULL,
+	name varchar(100) NOT NULL, -- 登录页显示名称
+	issuer varchar(500) NOT NULL, -- 颁发者，用于发现配置与校验 ID Token
+	client_id varchar(255) NOT NULL,
+	client_secret varchar(500) NULL, -- 公共客户端可为空，仅使用 PKCE
+	scopes varchar(500) NOT NULL DEFAULT 'openid profile email',
+	redirect_uri varchar(500) NULL, -- 回调地址，为空时按请求地址生成
+	frontend_url varchar(500) NOT NULL, -- 登录完成后跳转的控制台地址
+	post_logout_redirect_uri varchar(500) NULL, -- 退出登录后身份提供方跳转地址
+	email_claim varchar(100) NOT NULL DEFAULT 'email',
+	name_claim varchar(100) NOT NULL DEFAULT 'name',
+	role_claim varchar(100) NULL, -- 角色映射取值的声明，如 groups
+	role_mappings jsonb NULL, -- [{"value":"iot-admins","authority":"TENANT_ADMIN","role_ids":["..."]}]
+	org_claim varchar(100) NULL, -- 组织映射取值的声明
+	org_mappings jsonb NULL, -- [{"value":"dealer-east","org_id":"..."}]
+	default_authority varchar(50) NOT NULL DEFAULT 'TENANT_USER',
+	default_org_id varchar(36) NULL,
+	auto_provision bool NOT NULL DEFAULT true, -- 首次登录自动创建用户
+	enabled bool NOT NULL DEFAULT true,
+	remark varchar(255) NULL,
+	created_at timestamptz(6) NOT NULL,
+	updated_at timestamptz(6) NOT NULL,
+	CONSTRAINT oidc_providers_pkey PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_oidc_providers_tenant ON public.oidc_providers (tenant_id);
+
+COMMENT ON TABLE public.oidc_providers IS '租户 OIDC 身份提供方';
+
+CREATE TABLE IF NOT EXISTS public.user_identities (
+	id varchar(36) NOT NULL,
+	user_id varchar(36) NOT NULL,
+	provider_id varchar(36) NOT NULL,
+	tenant_id varchar(36) NOT NULL,
+	subject varchar(255) NOT NULL, -- ID Token 的 sub
+	email varchar(255) NULL,
+	last_login_at timestamptz(6) NULL,
+	created_at timestamptz(6) NOT NULL,
+	CONSTRAINT user_identities_pkey PRIMARY KEY (id),
+	CONSTRAINT user_identities_provider_subject_key UNIQUE (provider_id, subject),
+	CONSTRAINT user_identities_user_fk FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+	CONSTRAINT user_identities_provider_fk FOREIGN KEY (provider_id) REFERENCES public.oidc_providers(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identities_user ON public.user_identities (user_id);
+
+COMMENT ON TABLE public.user_identities IS '用户绑定的外部身份（OIDC）';
@@ -0,0 +1,42 @@
+-- Version: 48
+-- Description: 控制台账号双因素认证（TOTP）、恢复码、受信任设备与租户强制策略
+
+CREATE TABLE IF NOT EXISTS public.user_mfa (
+	user_id varchar(36) NOT NULL,
+	tenant_id varchar(36) NULL,
+	enabled bool NOT NULL DEFAULT false,
+	totp_secret varchar(64) NULL, -- 已启用的 TOTP 密钥（Base32）
+	pending_secret varchar(64) NULL, -- 绑定中尚未确认的密钥
+	recovery_codes jsonb NOT NULL DEFAULT '[]'::jsonb, -- 未使用恢复码的 SHA-256 摘要
+	last_used_step int8 NOT NULL DEFAULT 0, -- 最近一次通过校验的时间步，防止验证码重放
+	enabled_at timestamptz(6) NULL,
+	created_at timestamptz(6) NOT NULL,
+	updated_at timestamptz(6) NOT NULL,
+	CONSTRAINT user_mfa_pkey PRIMARY KEY (user_id),
+	CONSTRAINT user_mfa_user_fk FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+COMMENT ON TABLE public.user_mfa IS '用户双因素认证（TOTP）';
+
+CREATE TABLE IF NOT EXISTS public.user_trusted_devices (
+	id varchar(36) NOT NULL,
+	user_id varchar(36) NOT NULL,
+	tenant_id varchar(36) NULL,
+	token_hash varchar(64) NOT NULL, -- 受信任设备令牌的 SHA-256 摘要
+	name varchar(255) NULL, -- 设备名称，默认取 User-Agent
+	ip varchar(64) NULL, -- 信任时的登录IP
+	expires_at timestamptz(6) NOT NULL,
+	last_used_at timestamptz(6) NULL,
+	created_at timestamptz(6) NOT NULL,
+	CONSTRAINT user_trusted_devices_pkey PRIMARY KEY (id),
+	CONSTRAINT user_trusted_devices_token_key UNIQUE (token_hash),
+	CONSTRAINT user_trusted_devices_user_fk FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_trusted_devices_user ON public.user_trusted_devices (user_id);
+
+COMMENT ON TABLE public.user_trusted_devices IS '跳过双因素认证的受信任浏览器';
+
+ALTER TABLE public.tenant_settings ADD COLUMN IF NOT EXISTS mfa_enforced bool NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN public.tenant_settings.mfa_enforced IS '是否强制租户控制台账号启用双因素认证';
@@ -0,0 +1,24 @@
+-- Version: 49
+-- Description: 登录会话：短期访问令牌与轮换刷新令牌，支持查看与撤销会话
+
+CREATE TABLE IF NOT EXISTS public.user_sessions (
+	id varchar(36) NOT NULL, -- 会话ID，即访问令牌中的 sid
+	user_id varchar(36) NOT NULL,
+	tenant_id varchar(36) NULL,
+	refresh_token_hash varchar(64) NOT NULL, -- 当前刷新令牌的 SHA-256 摘要
+	previous_token_hash varchar(64) NULL, -- 上一个刷新令牌的摘要，用于发现令牌重复使用
+	rotated_at timestamptz(6) NULL, -- 最近一次轮换时间
+	user_agent varchar(255) NULL,
+	ip varchar(64) NULL,
+	created_at timestamptz(6) NOT NULL,
+	last_seen_at timestamptz(6) NOT NULL, -- 登录或最近一次刷新时间
+	expires_at timestamptz(6) NULL, -- 为空表示不过期
+	revoked_at timestamptz(6) NULL,
+	revoked_reason varchar(50) NULL, -- logout/revoked/force_logout/single_login/refresh_token_reuse/oidc_backchannel
+	CONSTRAINT user_sessions_pkey PRIMARY KEY (id),
+	CONSTRAINT user_sessions_user_fk FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_sessions_user_active ON public.user_sessions (user_id) WHERE revoked_at IS NULL;
+
+COMMENT ON TABLE public.user_sessions IS '用户登录会话（刷新令牌）';
@@ -0,0 +1,24 @@
+-- Version: 50
+-- Description: 设备参数细粒度权限（按物模型标识符的读/写/执行规则，可分配给角色或机构类型）
+
+CREATE TABLE IF NOT EXISTS public.device_param_rules (
+	id varchar(36) NOT NULL,
+	tenant_id varchar(36) NOT NULL,
+	subject_type varchar(16) NOT NULL, -- ROLE / ORG_TYPE
+	subject_id varchar(64) NOT NULL, -- 角色ID或机构类型（PACK_FACTORY/DEALER/STORE）
+	device_template_id varchar(36) NULL, -- 物模型（设备模板）ID，空表示所有模板
+	param_type varchar(8) NOT NULL, -- TEL / ATTR / CMD
+	identifier varchar(255) NOT NULL, -- 物模型标识符，* 表示该类型下全部标识符
+	action varchar(16) NOT NULL, -- read / write / execute
+	effect varchar(8) NOT NULL, -- allow / deny
+	remark varchar(255) NULL,
+	created_at timestamptz(6) NOT NULL,
+	updated_at timestamptz(6) NOT NULL,
+	CONSTRAINT device_param_rules_pkey PRIMARY KEY (id)
+);
+
+COMMENT ON TABLE public.device_param_rules IS '设备参数权限规则：拒绝优先；同一类型与操作配置了允许规则时，仅允许列出的标识符';
+
+CREATE UNIQUE INDEX IF NOT EXISTS uk_device_param_rules ON public.device_param_rules
+	(tenant_id, subject_type, subject_id, COALESCE(device_template_id, ''), param_type, identifier, action);
+CREATE INDEX IF NOT EXISTS idx_device_param_rules_subject ON public.device_param_rules(tenant_id, subject_type, subject_id);
@@ -0,0 +1,46 @@
+-- Version: 51
+-- Description: 设备操作审计：统一记录指令、参数下发、OTA、转移、绑定与强制解绑，按租户哈希链防篡改，仅允许追加
+
+CREATE TABLE IF NOT EXISTS public.device_audit_logs (
+	id varchar(36) NOT NULL,
+	tenant_id varchar(36) NOT NULL,
+	seq int8 NOT NULL, -- 租户内连续序号，从 1 开始
+	device_id varchar(36) NOT NULL, -- 不建外键：设备删除后审计记录仍需保留
+	"action" varchar(32) NOT NULL, -- COMMAND/ATTRIBUTE_SET/TELEMETRY_SET/OTA_PUSH/TRANSFER/BIND/UNBIND/FORCE_UNBIND
+	"source" varchar(16) NOT NULL, -- MANUAL 手动 / AUTO 自动（场景联动等）
+	actor_id varchar(36) NULL, -- 操作人，系统触发时为空
+	actor_org_id varchar(36) NULL, -- 操作人归属组织
+	target varchar(255) NULL, -- 操作对象：指令标识符、OTA 任务、目标组织等
+	before_value text NULL, -- 变更前的值（JSON）
+	after_value text NULL, -- 变更后的值（JSON）
+	"result" varchar(16) NOT NULL, -- SUCCESS / FAILED
+	error_message varchar(500) NULL,
+	ref_id varchar(64) NULL, -- 关联记录：下发 message_id、OTA 任务明细ID、绑定ID等
+	prev_hash varchar(64) NOT NULL, -- 上一条记录的哈希，首条为空串
+	hash varchar(64) NOT NULL, -- SHA-256(本条内容 + prev_hash)
+	created_at timestamptz(6) NOT NULL,
+	CONSTRAINT device_audit_logs_pkey PRIMARY KEY (id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uk_device_audit_logs_tenant_seq ON public.device_audit_logs (tenant_id, seq);
+CREATE INDEX IF NOT EXISTS idx_device_audit_logs_device ON public.device_audit_logs (device_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_device_audit_logs_tenant_time ON public.device_audit_logs (tenant_id, created_at DESC);
+
+COMMENT ON TABLE public.device_audit_logs IS '设备操作审计日志（仅追加，哈希链校验）';
+
+-- 仅允许追加：禁止修改、删除与清空
+CREATE OR REPLACE FUNCTION public.device_audit_logs_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'device_audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_device_audit_logs_append_only ON public.device_audit_logs;
+CREATE TRIGGER trg_device_audit_logs_append_only
+	BEFORE UPDATE OR DELETE ON public.device_audit_logs
+	FOR EACH ROW EXECUTE FUNCTION public.device_audit_logs_append_only();
+
+DROP TRIGGER IF EXISTS trg_device_audit_logs_no_truncate ON public.device_audit_logs;
+CREATE TRIGGER trg_device_audit_logs_no_truncate
+	BEFORE TRUNCATE ON public.device_audit_logs
+	FOR EACH STATEMENT EXECUTE FUNCTION public.device_audit_logs_append_only();
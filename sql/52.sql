@@ -0,0 +1,53 @@
+-- Version: 52
+-- Description: 高风险批量操作审批（四眼原则）：审批策略与审批申请
+
+CREATE TABLE IF NOT EXISTS public.approval_policies (
+	id varchar(36) NOT NULL,
+	tenant_id varchar(36) NOT NULL,
+	operation_type varchar(32) NOT NULL, -- BATCH_COMMAND / BATCH_OTA / BATCH_ASSIGN_DEALER / ORG_TRANSFER
+	enabled bool NOT NULL DEFAULT true,
+	device_threshold int4 NULL, -- 设备数超过该值需要审批，空表示不按数量判断
+	command_identifiers jsonb NULL, -- 需要审批的指令标识符（仅 BATCH_COMMAND），["*"] 表示全部指令
+	approver_user_ids jsonb NULL, -- 审批人用户ID
+	approver_role_ids jsonb NULL, -- 审批人角色ID（角色下的用户均可审批），未配置审批人时由租户管理员审批
+	notification_group_id varchar(36) NULL, -- 提交审批时额外通知的通知组
+	expire_hours int4 NOT NULL DEFAULT 24, -- 审批有效期（小时）
+	remark varchar(255) NULL,
+	created_at timestamptz(6) NOT NULL,
+	updated_at timestamptz(6) NOT NULL,
+	CONSTRAINT approval_policies_pkey PRIMARY KEY (id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uk_approval_policies ON public.approval_policies(tenant_id, operation_type);
+
+COMMENT ON TABLE public.approval_policies IS '审批策略：每个租户每种高风险操作一条';
+
+CREATE TABLE IF NOT EXISTS public.approval_requests (
+	id varchar(36) NOT NULL,
+	tenant_id varchar(36) NOT NULL,
+	operation_type varchar(32) NOT NULL,
+	scope_org_id varchar(36) NULL, -- 申请人提交时的组织（执行时按该组织做数据隔离）
+	payload jsonb NOT NULL, -- 原始请求参数
+	device_count int4 NOT NULL,
+	summary varchar(500) NOT NULL,
+	trigger_reason varchar(255) NOT NULL, -- 触发审批的原因
+	requester_id varchar(36) NOT NULL,
+	approver_ids jsonb NOT NULL, -- 提交时解析的审批人快照
+	status varchar(16) NOT NULL, -- PENDING / APPROVED / EXECUTED / FAILED / REJECTED / EXPIRED / CANCELLED
+	decided_by varchar(36) NULL,
+	decision_reason varchar(500) NULL,
+	decided_at timestamptz(6) NULL,
+	expires_at timestamptz(6) NOT NULL,
+	executed_at timestamptz(6) NULL,
+	result jsonb NULL, -- 执行结果
+	error_message text NULL,
+	created_at timestamptz(6) NOT NULL,
+	updated_at timestamptz(6) NOT NULL,
+	CONSTRAINT approval_requests_pkey PRIMARY KEY (id)
+);
+
+COMMENT ON TABLE public.approval_requests IS '审批申请：审批通过后以申请人身份执行原操作，审批人不能是申请人';
+
+CREATE INDEX IF NOT EXISTS idx_approval_requests_tenant ON public.approval_requests(tenant_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_approval_requests_pending ON public.approval_requests(expires_at) WHERE status = 'PENDING';
+CREATE INDEX IF NOT EXISTS idx_approval_requests_approvers ON public.approval_requests USING gin (approver_ids);
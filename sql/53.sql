@@ -0,0 +1,61 @@
+-- Version: 53
+-- Description: 租户数据导出/导入（跨区域迁移）与租户分阶段注销
+
+CREATE TABLE IF NOT EXISTS public.tenant_data_jobs (
+	id varchar(36) NOT NULL,
+	job_type varchar(16) NOT NULL, -- EXPORT / IMPORT
+	tenant_id varchar(36) NOT NULL, -- 导出的租户，或导入的目标租户
+	source_tenant_id varchar(36) NULL, -- 导入：归档中的源租户
+	status varchar(16) NOT NULL, -- PENDING / RUNNING / SUCCEEDED / FAILED
+	telemetry_start timestamptz(6) NULL, -- 导出遥测历史的时间范围，为空表示不导出遥测历史
+	telemetry_end timestamptz(6) NULL,
+	file_path varchar(500) NULL, -- 归档文件
+	file_size int8 NULL,
+	checksum varchar(64) NULL, -- 归档文件 SHA-256
+	summary jsonb NULL, -- 各表行数
+	error_message text NULL,
+	requested_by varchar(36) NOT NULL,
+	created_at timestamptz(6) NOT NULL,
+	started_at timestamptz(6) NULL,
+	finished_at timestamptz(6) NULL,
+	CONSTRAINT tenant_data_jobs_pkey PRIMARY KEY (id)
+);
+
+COMMENT ON TABLE public.tenant_data_jobs IS '租户数据导出/导入任务（异步执行）';
+
+CREATE INDEX IF NOT EXISTS idx_tenant_data_jobs_tenant ON public.tenant_data_jobs(tenant_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS public.tenant_offboardings (
+	id varchar(36) NOT NULL,
+	tenant_id varchar(36) NOT NULL,
+	status varchar(16) NOT NULL, -- SCHEDULED / PURGING / PURGED / FAILED / CANCELLED
+	reason varchar(500) NULL,
+	requested_by varchar(36) NOT NULL,
+	purge_after timestamptz(6) NOT NULL, -- 保留期截止，之后清除租户数据
+	frozen_user_ids jsonb NULL, -- 注销时冻结的用户，撤销注销时恢复
+	disabled_api_key_ids jsonb NULL, -- 注销时停用的 API Key，撤销注销时恢复
+	summary jsonb NULL, -- 各表清除行数
+	error_message text NULL,
+	cancelled_by varchar(36) NULL,
+	created_at timestamptz(6) NOT NULL,
+	updated_at timestamptz(6) NOT NULL,
+	purged_at timestamptz(6) NULL,
+	CONSTRAINT tenant_offboardings_pkey PRIMARY KEY (id)
+);
+
+COMMENT ON TABLE public.tenant_offboardings IS '租户注销：保留期内可撤销，到期后清除租户全部数据';
+
+CREATE UNIQUE INDEX IF NOT EXISTS uk_tenant_offboardings_active ON public.tenant_offboardings(tenant_id)
+	WHERE status IN ('SCHEDULED', 'PURGING', 'FAILED');
+
+-- 设备审计日志只允许追加；租户注销清除数据时，在设置了 app.tenant_purge 的事务中允许删除该租户的日志
+CREATE OR REPLACE FUNCTION public.device_audit_logs_append_only() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP = 'DELETE' THEN
+		IF OLD.tenant_id = current_setting('app.tenant_purge', true) THEN
+			RETURN OLD;
+		END IF;
+	END IF;
+	RAISE EXCEPTION 'device_audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,15 @@
+-- Version: 54
+-- Description: 配置即代码：配置包对象标识与本实例对象ID的映射
+
+CREATE TABLE IF NOT EXISTS public.config_bundle_bindings (
+	tenant_id varchar(36) NOT NULL,
+	object_type varchar(32) NOT NULL, -- DEVICE_TEMPLATE / DEVICE_CONFIG / ALARM_CONFIG / SCENE_AUTOMATION
+	bundle_key varchar(255) NOT NULL, -- 配置包中对象的 key，跨环境保持不变
+	object_id varchar(36) NOT NULL, -- 本实例中的对象ID
+	updated_at timestamptz(6) NOT NULL,
+	CONSTRAINT config_bundle_bindings_pkey PRIMARY KEY (tenant_id, object_type, bundle_key)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uk_config_bundle_bindings_object ON public.config_bundle_bindings(object_type, object_id);
+
+COMMENT ON TABLE public.config_bundle_bindings IS '配置包对象映射：不同环境中同一配置对象的ID不同，按 key 对应';
@@ -0,0 +1,17 @@
+-- Version: 55
+-- Description: OIDC 单点登录：登录同步仅作用于自动创建的账号，按邮箱关联的已有账号需显式开启
+
+ALTER TABLE public.oidc_providers
+	ADD COLUMN IF NOT EXISTS sync_linked_users bool NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN public.oidc_providers.sync_linked_users IS '登录时按声明同步按邮箱关联的已有账号';
+
+ALTER TABLE public.user_identities
+	ADD COLUMN IF NOT EXISTS provisioned bool NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN public.user_identities.provisioned IS '账号由该身份提供方首次登录时自动创建';
+
+-- 回填：备注为自动创建标记的账号视为自动创建
+UPDATE public.user_identities i SET provisioned = true
+FROM public.users u, public.oidc_providers p
+WHERE u.id = i.user_id AND p.id = i.provider_id AND u.remark = 'OIDC: ' || p.name;